@@ -0,0 +1,447 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConversionDataAnnotation is the annotation which holds the v1beta1 spec and
+// status fields of a cluster served as v1alpha1 which can't be represented in
+// v1alpha1, they are restored from it when the cluster is converted back.
+const ConversionDataAnnotation = "flinkoperator.k8s.io/conversion-data"
+
+var _ conversion.Convertible = &FlinkCluster{}
+
+// ConvertTo converts this FlinkCluster to the hub version (v1beta1).
+func (src *FlinkCluster) ConvertTo(dstRaw conversion.Hub) error {
+	var dst = dstRaw.(*v1beta1.FlinkCluster)
+	src = src.DeepCopy()
+
+	// The v1beta1 spec and status fields are restored first, then the fields
+	// which exist in v1alpha1 override them, so the updates made in v1alpha1
+	// are kept.
+	var restored v1beta1.FlinkCluster
+	if data, ok := src.Annotations[ConversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &restored); err != nil {
+			return err
+		}
+		delete(src.Annotations, ConversionDataAnnotation)
+		if len(src.Annotations) == 0 {
+			src.Annotations = nil
+		}
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = restored.Spec
+	dst.Status = restored.Status
+	convertSpecTo(&src.Spec, &dst.Spec)
+	convertStatusTo(&src.Status, &dst.Status)
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1beta1) to this version.
+func (dst *FlinkCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	var src = srcRaw.(*v1beta1.FlinkCluster).DeepCopy()
+
+	var unconvertibleSpec, err = getUnconvertibleSpec(&src.Spec)
+	if err != nil {
+		return err
+	}
+	var unconvertibleStatus map[string]interface{}
+	unconvertibleStatus, err = getUnconvertibleStatus(&src.Status)
+	if err != nil {
+		return err
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+	delete(dst.Annotations, ConversionDataAnnotation)
+	var conversionData = map[string]interface{}{}
+	if len(unconvertibleSpec) > 0 {
+		conversionData["spec"] = unconvertibleSpec
+	}
+	if len(unconvertibleStatus) > 0 {
+		conversionData["status"] = unconvertibleStatus
+	}
+	if len(conversionData) > 0 {
+		var data []byte
+		data, err = json.Marshal(conversionData)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[ConversionDataAnnotation] = string(data)
+	}
+	convertSpecFrom(&src.Spec, &dst.Spec)
+	convertStatusFrom(&src.Status, &dst.Status)
+	return nil
+}
+
+// Gets the fields of a v1beta1 spec which can't be represented in v1alpha1,
+// i.e., the ones which are lost when the spec is converted to v1alpha1 and
+// back, as a partial JSON object of the spec.
+func getUnconvertibleSpec(
+	spec *v1beta1.FlinkClusterSpec) (map[string]interface{}, error) {
+	var alphaSpec FlinkClusterSpec
+	var convertedSpec v1beta1.FlinkClusterSpec
+	convertSpecFrom(spec.DeepCopy(), &alphaSpec)
+	convertSpecTo(&alphaSpec, &convertedSpec)
+	return diffJSONObjects(spec, &convertedSpec)
+}
+
+// Gets the fields of a v1beta1 status which can't be represented in v1alpha1,
+// as a partial JSON object of the status.
+func getUnconvertibleStatus(
+	status *v1beta1.FlinkClusterStatus) (map[string]interface{}, error) {
+	var alphaStatus FlinkClusterStatus
+	var convertedStatus v1beta1.FlinkClusterStatus
+	convertStatusFrom(status.DeepCopy(), &alphaStatus)
+	convertStatusTo(&alphaStatus, &convertedStatus)
+	return diffJSONObjects(status, &convertedStatus)
+}
+
+// Gets the fields of an object which differ from the converted one, as a
+// partial JSON object.
+func diffJSONObjects(
+	value interface{}, converted interface{}) (map[string]interface{}, error) {
+	var object, convertedObject map[string]interface{}
+	if err := toJSONObject(value, &object); err != nil {
+		return nil, err
+	}
+	if err := toJSONObject(converted, &convertedObject); err != nil {
+		return nil, err
+	}
+	var diff, _ = diffJSONValues(object, convertedObject)
+	if diff == nil {
+		return nil, nil
+	}
+	return diff.(map[string]interface{}), nil
+}
+
+func toJSONObject(value interface{}, object *map[string]interface{}) error {
+	var data, err = json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, object)
+}
+
+// Gets the part of a JSON value which differs from the converted one. Objects
+// are compared field by field, other values as a whole.
+func diffJSONValues(value, converted interface{}) (interface{}, bool) {
+	var object, isObject = value.(map[string]interface{})
+	var convertedObject, isConvertedObject = converted.(map[string]interface{})
+	if isObject && isConvertedObject {
+		var diff = map[string]interface{}{}
+		for key, fieldValue := range object {
+			var fieldDiff, differs = diffJSONValues(
+				fieldValue, convertedObject[key])
+			if differs {
+				diff[key] = fieldDiff
+			}
+		}
+		if len(diff) == 0 {
+			return nil, false
+		}
+		return diff, true
+	}
+	if reflect.DeepEqual(value, converted) {
+		return nil, false
+	}
+	return value, true
+}
+
+func convertSpecTo(src *FlinkClusterSpec, dst *v1beta1.FlinkClusterSpec) {
+	dst.Image = v1beta1.ImageSpec(src.Image)
+	convertJobManagerTo(&src.JobManager, &dst.JobManager)
+	convertTaskManagerTo(&src.TaskManager, &dst.TaskManager)
+	if src.Job != nil {
+		if dst.Job == nil {
+			dst.Job = &v1beta1.JobSpec{}
+		}
+		convertJobTo(src.Job, dst.Job)
+	} else {
+		dst.Job = nil
+	}
+	dst.EnvVars = src.EnvVars
+	dst.FlinkProperties = src.FlinkProperties
+	if src.HadoopConfig != nil {
+		if dst.HadoopConfig == nil {
+			dst.HadoopConfig = &v1beta1.HadoopConfig{}
+		}
+		dst.HadoopConfig.ConfigMapName = src.HadoopConfig.ConfigMapName
+		dst.HadoopConfig.MountPath = src.HadoopConfig.MountPath
+	} else {
+		dst.HadoopConfig = nil
+	}
+	if src.GCPConfig != nil {
+		dst.GCPConfig = &v1beta1.GCPConfig{}
+		if src.GCPConfig.ServiceAccount != nil {
+			var serviceAccount = v1beta1.GCPServiceAccount(
+				*src.GCPConfig.ServiceAccount)
+			dst.GCPConfig.ServiceAccount = &serviceAccount
+		}
+	} else {
+		dst.GCPConfig = nil
+	}
+}
+
+func convertJobManagerTo(src *JobManagerSpec, dst *v1beta1.JobManagerSpec) {
+	dst.Replicas = src.Replicas
+	dst.AccessScope = src.AccessScope
+	if src.Ingress != nil {
+		if dst.Ingress == nil {
+			dst.Ingress = &v1beta1.JobManagerIngressSpec{}
+		}
+		dst.Ingress.HostFormat = src.Ingress.HostFormat
+		dst.Ingress.Annotations = src.Ingress.Annotations
+		dst.Ingress.UseTLS = src.Ingress.UseTLS
+		dst.Ingress.TLSSecretName = src.Ingress.TLSSecretName
+	} else {
+		dst.Ingress = nil
+	}
+	dst.Ports = v1beta1.JobManagerPorts(src.Ports)
+	dst.Resources = src.Resources
+	dst.MemoryOffHeapRatio = src.MemoryOffHeapRatio
+	dst.MemoryOffHeapMin = src.MemoryOffHeapMin
+	dst.Volumes = src.Volumes
+	dst.VolumeMounts = src.VolumeMounts
+	dst.NodeSelector = src.NodeSelector
+}
+
+func convertTaskManagerTo(src *TaskManagerSpec, dst *v1beta1.TaskManagerSpec) {
+	var replicas = src.Replicas
+	dst.Replicas = &replicas
+	dst.Ports = v1beta1.TaskManagerPorts(src.Ports)
+	dst.Resources = src.Resources
+	dst.MemoryOffHeapRatio = src.MemoryOffHeapRatio
+	dst.MemoryOffHeapMin = src.MemoryOffHeapMin
+	dst.Volumes = src.Volumes
+	dst.VolumeMounts = src.VolumeMounts
+	dst.NodeSelector = src.NodeSelector
+	dst.Sidecars = src.Sidecars
+}
+
+func convertJobTo(src *JobSpec, dst *v1beta1.JobSpec) {
+	dst.JarFile = src.JarFile
+	dst.ClassName = src.ClassName
+	dst.Args = src.Args
+	dst.FromSavepoint = src.FromSavepoint
+	dst.AllowNonRestoredState = src.AllowNonRestoredState
+	dst.SavepointsDir = src.SavepointsDir
+	dst.AutoSavepointSeconds = src.AutoSavepointSeconds
+	dst.SavepointGeneration = src.SavepointGeneration
+	dst.Parallelism = src.Parallelism
+	dst.NoLoggingToStdout = src.NoLoggingToStdout
+	dst.Volumes = src.Volumes
+	dst.VolumeMounts = src.VolumeMounts
+	dst.InitContainers = src.InitContainers
+	dst.RestartPolicy = src.RestartPolicy
+	if src.CleanupPolicy != nil {
+		dst.CleanupPolicy = &v1beta1.CleanupPolicy{
+			AfterJobSucceeds: v1beta1.CleanupAction(
+				src.CleanupPolicy.AfterJobSucceeds),
+			AfterJobFails: v1beta1.CleanupAction(
+				src.CleanupPolicy.AfterJobFails),
+			AfterJobCancelled: v1beta1.CleanupAction(
+				src.CleanupPolicy.AfterJobCancelled),
+		}
+	} else {
+		dst.CleanupPolicy = nil
+	}
+	dst.CancelRequested = src.CancelRequested
+}
+
+func convertStatusTo(src *FlinkClusterStatus, dst *v1beta1.FlinkClusterStatus) {
+	dst.State = src.State
+	var srcComponents = &src.Components
+	var dstComponents = &dst.Components
+	dstComponents.ConfigMap = v1beta1.FlinkClusterComponentState(
+		srcComponents.ConfigMap)
+	dstComponents.JobManagerDeployment.Name =
+		srcComponents.JobManagerDeployment.Name
+	dstComponents.JobManagerDeployment.State =
+		srcComponents.JobManagerDeployment.State
+	dstComponents.JobManagerService.Name = srcComponents.JobManagerService.Name
+	dstComponents.JobManagerService.State = srcComponents.JobManagerService.State
+	if srcComponents.JobManagerIngress != nil {
+		var ingress = v1beta1.JobManagerIngressStatus(
+			*srcComponents.JobManagerIngress)
+		dstComponents.JobManagerIngress = &ingress
+	} else {
+		dstComponents.JobManagerIngress = nil
+	}
+	dstComponents.TaskManagerDeployment.Name =
+		srcComponents.TaskManagerDeployment.Name
+	dstComponents.TaskManagerDeployment.State =
+		srcComponents.TaskManagerDeployment.State
+	if srcComponents.Job != nil {
+		if dstComponents.Job == nil {
+			dstComponents.Job = &v1beta1.JobStatus{}
+		}
+		var srcJob = srcComponents.Job
+		var dstJob = dstComponents.Job
+		dstJob.Name = srcJob.Name
+		dstJob.ID = srcJob.ID
+		dstJob.State = srcJob.State
+		dstJob.FromSavepoint = srcJob.FromSavepoint
+		dstJob.SavepointGeneration = srcJob.SavepointGeneration
+		dstJob.SavepointLocation = srcJob.SavepointLocation
+		dstJob.LastSavepointTriggerID = srcJob.LastSavepointTriggerID
+		dstJob.LastSavepointTime = srcJob.LastSavepointTime
+		dstJob.RestartCount = srcJob.RestartCount
+	} else {
+		dstComponents.Job = nil
+	}
+	dst.LastUpdateTime = src.LastUpdateTime
+}
+
+func convertSpecFrom(src *v1beta1.FlinkClusterSpec, dst *FlinkClusterSpec) {
+	dst.Image = ImageSpec(src.Image)
+	convertJobManagerFrom(&src.JobManager, &dst.JobManager)
+	convertTaskManagerFrom(&src.TaskManager, &dst.TaskManager)
+	dst.Job = nil
+	if src.Job != nil {
+		dst.Job = &JobSpec{}
+		convertJobFrom(src.Job, dst.Job)
+	}
+	dst.EnvVars = src.EnvVars
+	dst.FlinkProperties = src.FlinkProperties
+	dst.HadoopConfig = nil
+	if src.HadoopConfig != nil {
+		dst.HadoopConfig = &HadoopConfig{
+			ConfigMapName: src.HadoopConfig.ConfigMapName,
+			MountPath:     src.HadoopConfig.MountPath,
+		}
+	}
+	dst.GCPConfig = nil
+	if src.GCPConfig != nil {
+		dst.GCPConfig = &GCPConfig{}
+		if src.GCPConfig.ServiceAccount != nil {
+			var serviceAccount = GCPServiceAccount(*src.GCPConfig.ServiceAccount)
+			dst.GCPConfig.ServiceAccount = &serviceAccount
+		}
+	}
+}
+
+func convertJobManagerFrom(src *v1beta1.JobManagerSpec, dst *JobManagerSpec) {
+	dst.Replicas = src.Replicas
+	dst.AccessScope = src.AccessScope
+	dst.Ingress = nil
+	if src.Ingress != nil {
+		dst.Ingress = &JobManagerIngressSpec{
+			HostFormat:    src.Ingress.HostFormat,
+			Annotations:   src.Ingress.Annotations,
+			UseTLS:        src.Ingress.UseTLS,
+			TLSSecretName: src.Ingress.TLSSecretName,
+		}
+	}
+	dst.Ports = JobManagerPorts(src.Ports)
+	dst.Resources = src.Resources
+	dst.MemoryOffHeapRatio = src.MemoryOffHeapRatio
+	dst.MemoryOffHeapMin = src.MemoryOffHeapMin
+	dst.Volumes = src.Volumes
+	dst.VolumeMounts = src.VolumeMounts
+	dst.NodeSelector = src.NodeSelector
+}
+
+func convertTaskManagerFrom(src *v1beta1.TaskManagerSpec, dst *TaskManagerSpec) {
+	dst.Replicas = 1
+	if src.Replicas != nil {
+		dst.Replicas = *src.Replicas
+	}
+	dst.Ports = TaskManagerPorts(src.Ports)
+	dst.Resources = src.Resources
+	dst.MemoryOffHeapRatio = src.MemoryOffHeapRatio
+	dst.MemoryOffHeapMin = src.MemoryOffHeapMin
+	dst.Volumes = src.Volumes
+	dst.VolumeMounts = src.VolumeMounts
+	dst.NodeSelector = src.NodeSelector
+	dst.Sidecars = src.Sidecars
+}
+
+func convertJobFrom(src *v1beta1.JobSpec, dst *JobSpec) {
+	dst.JarFile = src.JarFile
+	dst.ClassName = src.ClassName
+	dst.Args = src.Args
+	dst.FromSavepoint = src.FromSavepoint
+	dst.AllowNonRestoredState = src.AllowNonRestoredState
+	dst.SavepointsDir = src.SavepointsDir
+	dst.AutoSavepointSeconds = src.AutoSavepointSeconds
+	dst.SavepointGeneration = src.SavepointGeneration
+	dst.Parallelism = src.Parallelism
+	dst.NoLoggingToStdout = src.NoLoggingToStdout
+	dst.Volumes = src.Volumes
+	dst.VolumeMounts = src.VolumeMounts
+	dst.InitContainers = src.InitContainers
+	dst.RestartPolicy = src.RestartPolicy
+	dst.CleanupPolicy = nil
+	if src.CleanupPolicy != nil {
+		dst.CleanupPolicy = &CleanupPolicy{
+			AfterJobSucceeds:  CleanupAction(src.CleanupPolicy.AfterJobSucceeds),
+			AfterJobFails:     CleanupAction(src.CleanupPolicy.AfterJobFails),
+			AfterJobCancelled: CleanupAction(src.CleanupPolicy.AfterJobCancelled),
+		}
+	}
+	dst.CancelRequested = src.CancelRequested
+}
+
+func convertStatusFrom(src *v1beta1.FlinkClusterStatus, dst *FlinkClusterStatus) {
+	dst.State = src.State
+	var srcComponents = &src.Components
+	var dstComponents = &dst.Components
+	dstComponents.ConfigMap = FlinkClusterComponentState(srcComponents.ConfigMap)
+	dstComponents.JobManagerDeployment = FlinkClusterComponentState{
+		Name:  srcComponents.JobManagerDeployment.Name,
+		State: srcComponents.JobManagerDeployment.State,
+	}
+	dstComponents.JobManagerService = FlinkClusterComponentState{
+		Name:  srcComponents.JobManagerService.Name,
+		State: srcComponents.JobManagerService.State,
+	}
+	dstComponents.JobManagerIngress = nil
+	if srcComponents.JobManagerIngress != nil {
+		var ingress = JobManagerIngressStatus(*srcComponents.JobManagerIngress)
+		dstComponents.JobManagerIngress = &ingress
+	}
+	dstComponents.TaskManagerDeployment = FlinkClusterComponentState{
+		Name:  srcComponents.TaskManagerDeployment.Name,
+		State: srcComponents.TaskManagerDeployment.State,
+	}
+	dstComponents.Job = nil
+	if srcComponents.Job != nil {
+		var srcJob = srcComponents.Job
+		dstComponents.Job = &JobStatus{
+			Name:                   srcJob.Name,
+			ID:                     srcJob.ID,
+			State:                  srcJob.State,
+			FromSavepoint:          srcJob.FromSavepoint,
+			SavepointGeneration:    srcJob.SavepointGeneration,
+			SavepointLocation:      srcJob.SavepointLocation,
+			LastSavepointTriggerID: srcJob.LastSavepointTriggerID,
+			LastSavepointTime:      srcJob.LastSavepointTime,
+			RestartCount:           srcJob.RestartCount,
+		}
+	}
+	dst.LastUpdateTime = src.LastUpdateTime
+}
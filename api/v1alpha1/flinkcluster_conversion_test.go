@@ -0,0 +1,194 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConvertFromHubAndBack(t *testing.T) {
+	var jmReplicas = int32(1)
+	var tmReplicas int32 = 3
+	var parallelism = int32(2)
+	var restartPolicy = v1beta1.JobRestartPolicyFromSavepointOnFailure
+	var submissionMode = v1beta1.JobSubmissionModeRestAPI
+	var ingressPath = "/flink"
+	var maxRetries = int32(3)
+	var hub = v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mycluster",
+			Namespace:   "default",
+			Annotations: map[string]string{"foo": "bar"},
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.8.1"},
+			JobManager: v1beta1.JobManagerSpec{
+				Replicas:    &jmReplicas,
+				AccessScope: v1beta1.AccessScopeVPC,
+				Ingress: &v1beta1.JobManagerIngressSpec{
+					Path: &ingressPath,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{Replicas: &tmReplicas},
+			Job: &v1beta1.JobSpec{
+				JarFile:        "https://example.com/job.jar",
+				Parallelism:    &parallelism,
+				RestartPolicy:  &restartPolicy,
+				SubmissionMode: &submissionMode,
+				MaxRetries:     &maxRetries,
+				CleanupPolicy: &v1beta1.CleanupPolicy{
+					AfterJobSucceeds: v1beta1.CleanupActionDeleteCluster,
+				},
+			},
+			HighAvailability: &v1beta1.HighAvailabilitySpec{
+				Mode:       v1beta1.HighAvailabilityModeKubernetes,
+				StorageDir: "gs://my-bucket/flink-ha/",
+			},
+		},
+		Status: v1beta1.FlinkClusterStatus{
+			State: v1beta1.ClusterStateRunning,
+			Components: v1beta1.FlinkClusterComponentsStatus{
+				JobManagerService: v1beta1.JobManagerServiceStatus{
+					Name:     "mycluster-jobmanager",
+					State:    v1beta1.ComponentStateReady,
+					NodePort: 30081,
+				},
+				Job: &v1beta1.JobStatus{
+					ID:            "a1b2",
+					State:         v1beta1.JobStateRunning,
+					FlinkJobState: "RUNNING",
+				},
+			},
+			ObservedGeneration: 2,
+		},
+	}
+
+	var cluster FlinkCluster
+	var err = cluster.ConvertFrom(hub.DeepCopy())
+	assert.NilError(t, err)
+	assert.Equal(t, cluster.Spec.JobManager.AccessScope, AccessScopeVPC)
+	assert.Equal(t, cluster.Spec.Job.JarFile, "https://example.com/job.jar")
+	assert.Equal(t, *cluster.Spec.Job.Parallelism, int32(2))
+	assert.Equal(
+		t,
+		cluster.Spec.Job.CleanupPolicy.AfterJobSucceeds,
+		CleanupAction(CleanupActionDeleteCluster))
+	assert.Equal(
+		t, cluster.Status.Components.JobManagerService.Name, "mycluster-jobmanager")
+
+	// Only the spec and status fields which don't exist in v1alpha1 are kept
+	// in the annotation.
+	var conversionData map[string]map[string]interface{}
+	err = json.Unmarshal(
+		[]byte(cluster.Annotations[ConversionDataAnnotation]), &conversionData)
+	assert.NilError(t, err)
+	assert.Equal(t, len(conversionData), 2)
+	var spec = conversionData["spec"]
+	assert.DeepEqual(
+		t,
+		spec["jobManager"],
+		map[string]interface{}{"ingress": map[string]interface{}{"path": "/flink"}})
+	assert.DeepEqual(
+		t,
+		spec["job"],
+		map[string]interface{}{"submissionMode": "RestAPI", "maxRetries": 3.0})
+	assert.Assert(t, spec["highAvailability"] != nil)
+	assert.Assert(t, spec["image"] == nil)
+	assert.Assert(t, spec["taskManager"] == nil)
+	var status = conversionData["status"]
+	assert.DeepEqual(
+		t,
+		status["components"],
+		map[string]interface{}{
+			"jobManagerService": map[string]interface{}{"nodePort": 30081.0},
+			"job":               map[string]interface{}{"flinkJobState": "RUNNING"},
+		})
+	assert.Equal(t, status["observedGeneration"], 2.0)
+	assert.Assert(t, status["state"] == nil)
+
+	// The spec and the status are restored.
+	var converted v1beta1.FlinkCluster
+	err = cluster.ConvertTo(&converted)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, converted.ObjectMeta, hub.ObjectMeta)
+	assert.DeepEqual(
+		t,
+		converted.Spec,
+		hub.Spec,
+		cmpopts.IgnoreUnexported(resource.Quantity{}))
+	assert.DeepEqual(t, converted.Status, hub.Status)
+}
+
+func TestConvertFromHubWithoutConversionData(t *testing.T) {
+	var tmReplicas int32 = 1
+	var hub = v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster"},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image:       v1beta1.ImageSpec{Name: "flink:1.8.1"},
+			TaskManager: v1beta1.TaskManagerSpec{Replicas: &tmReplicas},
+			Job:         &v1beta1.JobSpec{JarFile: "/opt/job.jar"},
+		},
+	}
+
+	var cluster FlinkCluster
+	var err = cluster.ConvertFrom(&hub)
+	assert.NilError(t, err)
+	assert.Assert(t, cluster.Annotations == nil)
+}
+
+func TestConvertToHubKeepsUpdates(t *testing.T) {
+	var tmReplicas int32 = 1
+	var ingressPath = "/flink"
+	var hub = v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster"},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.8.1"},
+			JobManager: v1beta1.JobManagerSpec{
+				AccessScope: v1beta1.AccessScopeCluster,
+				Ingress: &v1beta1.JobManagerIngressSpec{
+					Path: &ingressPath,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{Replicas: &tmReplicas},
+			Job:         &v1beta1.JobSpec{JarFile: "/opt/job.jar"},
+		},
+	}
+
+	var cluster FlinkCluster
+	var err = cluster.ConvertFrom(&hub)
+	assert.NilError(t, err)
+
+	// Update the cluster in v1alpha1, the fields of v1alpha1 take precedence
+	// over the ones restored from the annotation.
+	cluster.Spec.TaskManager.Replicas = 5
+	cluster.Spec.Job = nil
+
+	var converted v1beta1.FlinkCluster
+	err = cluster.ConvertTo(&converted)
+	assert.NilError(t, err)
+	assert.Equal(t, *converted.Spec.TaskManager.Replicas, int32(5))
+	assert.Assert(t, converted.Spec.Job == nil)
+	assert.Equal(t, *converted.Spec.JobManager.Ingress.Path, "/flink")
+	assert.Assert(t, converted.Annotations == nil)
+}
@@ -452,6 +452,7 @@ type FlinkClusterStatus struct {
 // +kubebuilder:object:root=true
 
 // FlinkCluster is the Schema for the flinkclusters API
+// +kubebuilder:resource:shortName=fc,categories=flink
 // +kubebuilder:subresource:status
 type FlinkCluster struct {
 	metav1.TypeMeta   `json:",inline"`
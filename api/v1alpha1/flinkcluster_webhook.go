@@ -18,10 +18,16 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/googlecloudplatform/flink-operator/api/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // +kubebuilder:docs-gen:collapse=Go imports
@@ -30,6 +36,9 @@ var log = logf.Log.WithName("webhook")
 
 // SetupWebhookWithManager adds webhook for FlinkCluster.
 func (cluster *FlinkCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(
+		"/mutate-flinkoperator-k8s-io-v1alpha1-flinkcluster",
+		&webhook.Admission{Handler: &defaultingHandler{}})
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(cluster).
 		Complete()
@@ -41,46 +50,86 @@ This marker is responsible for generating a mutating webhook manifest.
 The meaning of each marker can be found [here](/reference/markers/webhook.md).
 */
 
-// +kubebuilder:webhook:path=/mutate-flinkoperator-k8s-io-v1alpha1-flinkcluster,mutating=true,failurePolicy=fail,groups=flinkoperator.k8s.io,resources=flinkclusters,verbs=create;update,versions=v1alpha1,name=mflinkcluster.flinkoperator.k8s.io
+// +kubebuilder:webhook:path=/mutate-flinkoperator-k8s-io-v1alpha1-flinkcluster,mutating=true,failurePolicy=fail,groups=flinkoperator.k8s.io,resources=flinkclusters,verbs=create;update,versions=v1alpha1,name=mflinkcluster-v1alpha1.flinkoperator.k8s.io
 
 /*
-We use the `webhook.Defaulter` interface to set defaults to our CRD.
-A webhook will automatically be served that calls this defaulting.
-The `Default` method is expected to mutate the receiver, setting the defaults.
+The v1alpha1 clusters are defaulted and validated by the v1beta1 webhooks, so
+both versions share the same rules. The cluster is converted to v1beta1 and
+back, the v1beta1 defaults which don't exist in v1alpha1 are kept in the
+conversion data annotation.
 */
 
-var _ webhook.Defaulter = &FlinkCluster{}
+// Default sets the v1beta1 defaults of the cluster. Unlike
+// webhook.Defaulter, it returns the conversion errors, so the defaulting
+// webhook rejects the request instead of admitting the cluster undefaulted.
+func (cluster *FlinkCluster) Default() error {
+	log.Info("default", "name", cluster.Name, "version", GroupVersion.Version)
+	var hub v1beta1.FlinkCluster
+	if err := cluster.ConvertTo(&hub); err != nil {
+		return err
+	}
+	hub.Default()
+	return cluster.ConvertFrom(&hub)
+}
+
+// The handler of the defaulting webhook of the v1alpha1 clusters.
+type defaultingHandler struct {
+	decoder *admission.Decoder
+}
+
+// InjectDecoder injects the decoder of the admission requests.
+func (h *defaultingHandler) InjectDecoder(decoder *admission.Decoder) error {
+	h.decoder = decoder
+	return nil
+}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the
-// type.
-func (cluster *FlinkCluster) Default() {
-	log.Info("default", "name", cluster.Name, "original", *cluster)
-	_SetDefault(cluster)
-	log.Info("default", "name", cluster.Name, "augmented", *cluster)
+// Handle defaults the cluster of the admission request.
+func (h *defaultingHandler) Handle(
+	ctx context.Context, req admission.Request) admission.Response {
+	var cluster FlinkCluster
+	if err := h.decoder.Decode(req, &cluster); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := cluster.Default(); err != nil {
+		log.Error(err, "Failed to default", "name", cluster.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var marshalled, err = json.Marshal(&cluster)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshalled)
 }
 
 /*
 This marker is responsible for generating a validating webhook manifest.
 */
 
-// +kubebuilder:webhook:path=/validate-flinkoperator-k8s-io-v1alpha1-flinkcluster,mutating=false,failurePolicy=fail,groups=flinkoperator.k8s.io,resources=flinkclusters,verbs=create;update,versions=v1alpha1,name=vflinkcluster.flinkoperator.k8s.io
+// +kubebuilder:webhook:path=/validate-flinkoperator-k8s-io-v1alpha1-flinkcluster,mutating=false,failurePolicy=fail,groups=flinkoperator.k8s.io,resources=flinkclusters,verbs=create;update,versions=v1alpha1,name=vflinkcluster-v1alpha1.flinkoperator.k8s.io
 
 var _ webhook.Validator = &FlinkCluster{}
-var validator = Validator{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered
 // for the type.
 func (cluster *FlinkCluster) ValidateCreate() error {
-	log.Info("Validate create", "name", cluster.Name)
-	return validator.ValidateCreate(cluster)
+	var hub v1beta1.FlinkCluster
+	if err := cluster.ConvertTo(&hub); err != nil {
+		return err
+	}
+	return hub.ValidateCreate()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered
 // for the type.
 func (cluster *FlinkCluster) ValidateUpdate(old runtime.Object) error {
-	log.Info("Validate update", "name", cluster.Name)
-	var oldCluster = old.(*FlinkCluster)
-	return validator.ValidateUpdate(oldCluster, cluster)
+	var hub, oldHub v1beta1.FlinkCluster
+	if err := cluster.ConvertTo(&hub); err != nil {
+		return err
+	}
+	if err := old.(*FlinkCluster).ConvertTo(&oldHub); err != nil {
+		return err
+	}
+	return hub.ValidateUpdate(&oldHub)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered
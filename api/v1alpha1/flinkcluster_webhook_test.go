@@ -0,0 +1,50 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDefault(t *testing.T) {
+	var cluster = FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster"},
+		Spec: FlinkClusterSpec{
+			Image:       ImageSpec{Name: "flink:1.8.1"},
+			TaskManager: TaskManagerSpec{Replicas: 1},
+		},
+	}
+	var err = cluster.Default()
+	assert.NilError(t, err)
+	assert.Equal(t, *cluster.Spec.JobManager.Replicas, int32(1))
+}
+
+func TestDefaultInvalidConversionData(t *testing.T) {
+	var cluster = FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mycluster",
+			Annotations: map[string]string{
+				ConversionDataAnnotation: "{invalid",
+			},
+		},
+	}
+	var err = cluster.Default()
+	assert.ErrorContains(t, err, "invalid character")
+}
@@ -608,18 +608,3 @@ func (in *TaskManagerSpec) DeepCopy() *TaskManagerSpec {
 	in.DeepCopyInto(out)
 	return out
 }
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Validator) DeepCopyInto(out *Validator) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Validator.
-func (in *Validator) DeepCopy() *Validator {
-	if in == nil {
-		return nil
-	}
-	out := new(Validator)
-	in.DeepCopyInto(out)
-	return out
-}
@@ -17,17 +17,61 @@ limitations under the License.
 package v1beta1
 
 import (
+	"github.com/googlecloudplatform/flink-operator/operatorconfig"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Sets default values for unspecified FlinkCluster properties.
 func _SetDefault(cluster *FlinkCluster) {
+	_SetOperatorConfigDefault(cluster)
 	_SetImageDefault(&cluster.Spec.Image)
 	_SetJobManagerDefault(&cluster.Spec.JobManager)
 	_SetTaskManagerDefault(&cluster.Spec.TaskManager)
 	_SetJobDefault(cluster.Spec.Job)
 	_SetHadoopConfigDefault(cluster.Spec.HadoopConfig)
+	_SetGCPConfigDefault(cluster.Spec.GCPConfig)
+	_SetHighAvailabilityDefault(cluster.Spec.HighAvailability)
+	_SetAutoscalerDefault(cluster.Spec.Autoscaler)
+	_SetMetricsDefault(cluster.Spec.Metrics)
+	_SetStateBackendDefault(cluster.Spec.StateBackend)
+	_SetHistoryServerDefault(cluster.Spec.HistoryServer)
+	_SetSecurityDefault(cluster.Spec.Security)
+	_SetIdleTimeoutDefault(&cluster.Spec)
+	_SetBlueGreenDefault(&cluster.Spec)
+}
+
+// Sets the defaults of the operator config, they take precedence over the
+// built-in defaults.
+func _SetOperatorConfigDefault(cluster *FlinkCluster) {
+	var config = operatorconfig.Get()
+	if len(cluster.Spec.Image.Name) == 0 {
+		cluster.Spec.Image.Name = config.Images[cluster.Spec.FlinkVersion]
+	}
+	var jobSpec = cluster.Spec.Job
+	if jobSpec == nil {
+		return
+	}
+	if jobSpec.SavepointsDir == nil && len(config.SavepointsDir) > 0 {
+		jobSpec.SavepointsDir = new(string)
+		*jobSpec.SavepointsDir = config.SavepointsDir
+	}
+	if jobSpec.CleanupPolicy == nil {
+		jobSpec.CleanupPolicy = &CleanupPolicy{}
+	}
+	var cleanupPolicy = jobSpec.CleanupPolicy
+	if len(cleanupPolicy.AfterJobSucceeds) == 0 {
+		cleanupPolicy.AfterJobSucceeds =
+			CleanupAction(config.CleanupPolicy.AfterJobSucceeds)
+	}
+	if len(cleanupPolicy.AfterJobFails) == 0 {
+		cleanupPolicy.AfterJobFails =
+			CleanupAction(config.CleanupPolicy.AfterJobFails)
+	}
+	if len(cleanupPolicy.AfterJobCancelled) == 0 {
+		cleanupPolicy.AfterJobCancelled =
+			CleanupAction(config.CleanupPolicy.AfterJobCancelled)
+	}
 }
 
 func _SetImageDefault(imageSpec *ImageSpec) {
@@ -49,6 +93,10 @@ func _SetJobManagerDefault(jmSpec *JobManagerSpec) {
 			jmSpec.Ingress.UseTLS = new(bool)
 			*jmSpec.Ingress.UseTLS = false
 		}
+		if jmSpec.Ingress.Path == nil {
+			jmSpec.Ingress.Path = new(string)
+			*jmSpec.Ingress.Path = "/"
+		}
 	}
 	if jmSpec.Ports.RPC == nil {
 		jmSpec.Ports.RPC = new(int32)
@@ -66,6 +114,15 @@ func _SetJobManagerDefault(jmSpec *JobManagerSpec) {
 		jmSpec.Ports.UI = new(int32)
 		*jmSpec.Ports.UI = 8081
 	}
+	if jmSpec.UIAuthProxy != nil {
+		if len(jmSpec.UIAuthProxy.Container.Name) == 0 {
+			jmSpec.UIAuthProxy.Container.Name = "ui-auth-proxy"
+		}
+		if jmSpec.UIAuthProxy.Port == nil {
+			jmSpec.UIAuthProxy.Port = new(int32)
+			*jmSpec.UIAuthProxy.Port = 4180
+		}
+	}
 	if jmSpec.MemoryOffHeapMin.Format == "" {
 		jmSpec.MemoryOffHeapMin = *resource.NewScaledQuantity(600, 6) // 600MB
 	}
@@ -76,6 +133,10 @@ func _SetJobManagerDefault(jmSpec *JobManagerSpec) {
 }
 
 func _SetTaskManagerDefault(tmSpec *TaskManagerSpec) {
+	if tmSpec.Replicas == nil {
+		tmSpec.Replicas = new(int32)
+		*tmSpec.Replicas = 1
+	}
 	if tmSpec.Ports.Data == nil {
 		tmSpec.Ports.Data = new(int32)
 		*tmSpec.Ports.Data = 6121
@@ -95,12 +156,32 @@ func _SetTaskManagerDefault(tmSpec *TaskManagerSpec) {
 		tmSpec.MemoryOffHeapRatio = new(int32)
 		*tmSpec.MemoryOffHeapRatio = 25
 	}
+	_SetGPUDefault(tmSpec.GPU)
+}
+
+func _SetGPUDefault(gpu *GPUSpec) {
+	if gpu == nil {
+		return
+	}
+	if gpu.ResourceName == nil {
+		gpu.ResourceName = new(string)
+		*gpu.ResourceName = "nvidia.com/gpu"
+	}
+	if gpu.DiscoveryScriptPath == nil {
+		gpu.DiscoveryScriptPath = new(string)
+		*gpu.DiscoveryScriptPath =
+			"opt/external-resource-gpu/nvidia-gpu-discovery.sh"
+	}
 }
 
 func _SetJobDefault(jobSpec *JobSpec) {
 	if jobSpec == nil {
 		return
 	}
+	if jobSpec.SubmissionMode == nil {
+		jobSpec.SubmissionMode = new(JobSubmissionMode)
+		*jobSpec.SubmissionMode = JobSubmissionModeSubmitter
+	}
 	if jobSpec.AllowNonRestoredState == nil {
 		jobSpec.AllowNonRestoredState = new(bool)
 		*jobSpec.AllowNonRestoredState = false
@@ -117,11 +198,53 @@ func _SetJobDefault(jobSpec *JobSpec) {
 		jobSpec.RestartPolicy = new(JobRestartPolicy)
 		*jobSpec.RestartPolicy = JobRestartPolicyNever
 	}
+	if jobSpec.TakeSavepointOnCancel == nil {
+		jobSpec.TakeSavepointOnCancel = new(bool)
+		*jobSpec.TakeSavepointOnCancel = true
+	}
 	if jobSpec.CleanupPolicy == nil {
-		jobSpec.CleanupPolicy = &CleanupPolicy{
-			AfterJobSucceeds:  CleanupActionDeleteCluster,
-			AfterJobFails:     CleanupActionKeepCluster,
-			AfterJobCancelled: CleanupActionDeleteCluster,
+		jobSpec.CleanupPolicy = &CleanupPolicy{}
+	}
+	if len(jobSpec.CleanupPolicy.AfterJobSucceeds) == 0 {
+		jobSpec.CleanupPolicy.AfterJobSucceeds = CleanupActionDeleteCluster
+	}
+	if len(jobSpec.CleanupPolicy.AfterJobFails) == 0 {
+		jobSpec.CleanupPolicy.AfterJobFails = CleanupActionKeepCluster
+	}
+	if len(jobSpec.CleanupPolicy.AfterJobCancelled) == 0 {
+		jobSpec.CleanupPolicy.AfterJobCancelled = CleanupActionDeleteCluster
+	}
+	if jobSpec.MaxRetries != nil {
+		if jobSpec.RetryBackoffSeconds == nil {
+			jobSpec.RetryBackoffSeconds = new(int32)
+			*jobSpec.RetryBackoffSeconds = 10
+		}
+		if jobSpec.MaxRetryBackoffSeconds == nil {
+			jobSpec.MaxRetryBackoffSeconds = new(int32)
+			*jobSpec.MaxRetryBackoffSeconds = 300
+		}
+	}
+	if jobSpec.SavepointMaxRetries != nil &&
+		jobSpec.SavepointRetryBackoffSeconds == nil {
+		jobSpec.SavepointRetryBackoffSeconds = new(int32)
+		*jobSpec.SavepointRetryBackoffSeconds = 10
+	}
+	if jobSpec.Schedule != nil {
+		if jobSpec.ConcurrencyPolicy == nil {
+			jobSpec.ConcurrencyPolicy = new(JobConcurrencyPolicy)
+			*jobSpec.ConcurrencyPolicy = JobConcurrencyPolicyForbid
+		}
+		if jobSpec.SuccessfulRunsHistoryLimit == nil {
+			jobSpec.SuccessfulRunsHistoryLimit = new(int32)
+			*jobSpec.SuccessfulRunsHistoryLimit = 3
+		}
+		if jobSpec.FailedRunsHistoryLimit == nil {
+			jobSpec.FailedRunsHistoryLimit = new(int32)
+			*jobSpec.FailedRunsHistoryLimit = 1
+		}
+		if jobSpec.DeleteTaskManagersBetweenRuns == nil {
+			jobSpec.DeleteTaskManagersBetweenRuns = new(bool)
+			*jobSpec.DeleteTaskManagersBetweenRuns = false
 		}
 	}
 }
@@ -134,3 +257,139 @@ func _SetHadoopConfigDefault(hadoopConfig *HadoopConfig) {
 		hadoopConfig.MountPath = "/etc/hadoop/conf"
 	}
 }
+
+func _SetGCPConfigDefault(gcpConfig *GCPConfig) {
+	if gcpConfig == nil || gcpConfig.ServiceAccount == nil {
+		return
+	}
+	if len(gcpConfig.ServiceAccount.KeyFile) == 0 {
+		gcpConfig.ServiceAccount.KeyFile = "key.json"
+	}
+	if len(gcpConfig.ServiceAccount.MountPath) == 0 {
+		gcpConfig.ServiceAccount.MountPath = "/etc/gcp/keys"
+	}
+}
+
+func _SetHighAvailabilityDefault(highAvailability *HighAvailabilitySpec) {
+	if highAvailability == nil {
+		return
+	}
+	if len(highAvailability.Mode) == 0 {
+		highAvailability.Mode = HighAvailabilityModeKubernetes
+	}
+}
+
+func _SetAutoscalerDefault(autoscaler *AutoscalerSpec) {
+	if autoscaler == nil {
+		return
+	}
+	if autoscaler.MinParallelism == nil {
+		autoscaler.MinParallelism = new(int32)
+		*autoscaler.MinParallelism = 1
+	}
+	if autoscaler.TargetBusyPercent == nil {
+		autoscaler.TargetBusyPercent = new(int32)
+		*autoscaler.TargetBusyPercent = 70
+	}
+	if autoscaler.StabilizationSeconds == nil {
+		autoscaler.StabilizationSeconds = new(int32)
+		*autoscaler.StabilizationSeconds = 300
+	}
+}
+
+func _SetMetricsDefault(metrics *MetricsSpec) {
+	if metrics == nil || metrics.Prometheus == nil {
+		return
+	}
+	if metrics.Prometheus.Port == nil {
+		metrics.Prometheus.Port = new(int32)
+		*metrics.Prometheus.Port = 9249
+	}
+}
+
+func _SetStateBackendDefault(stateBackend *StateBackendSpec) {
+	if stateBackend == nil || stateBackend.RocksDBLocalDir == nil {
+		return
+	}
+	if len(stateBackend.RocksDBLocalDir.MountPath) == 0 {
+		stateBackend.RocksDBLocalDir.MountPath = "/flink-rocksdb"
+	}
+}
+
+func _SetHistoryServerDefault(historyServer *HistoryServerSpec) {
+	if historyServer == nil {
+		return
+	}
+	if historyServer.Port == nil {
+		historyServer.Port = new(int32)
+		*historyServer.Port = 8082
+	}
+}
+
+func _SetSecurityDefault(security *SecuritySpec) {
+	if security == nil {
+		return
+	}
+	if kerberos := security.Kerberos; kerberos != nil {
+		if len(kerberos.KeytabKey) == 0 {
+			kerberos.KeytabKey = "krb5.keytab"
+		}
+		if len(kerberos.Krb5ConfigKey) == 0 {
+			kerberos.Krb5ConfigKey = "krb5.conf"
+		}
+	}
+	if security.SSL == nil {
+		return
+	}
+	if internal := security.SSL.Internal; internal != nil {
+		if len(internal.KeystoreKey) == 0 {
+			internal.KeystoreKey = "keystore.p12"
+		}
+		if len(internal.TruststoreKey) == 0 {
+			internal.TruststoreKey = "truststore.p12"
+		}
+	}
+	if rest := security.SSL.REST; rest != nil {
+		if len(rest.KeystoreKey) == 0 {
+			rest.KeystoreKey = "keystore.p12"
+		}
+		if len(rest.TruststoreKey) == 0 {
+			rest.TruststoreKey = "truststore.p12"
+		}
+		if rest.IssuerRef != nil {
+			if len(rest.IssuerRef.Kind) == 0 {
+				rest.IssuerRef.Kind = "Issuer"
+			}
+			if len(rest.IssuerRef.Group) == 0 {
+				rest.IssuerRef.Group = "cert-manager.io"
+			}
+		}
+	}
+}
+
+func _SetIdleTimeoutDefault(clusterSpec *FlinkClusterSpec) {
+	if clusterSpec.IdleTimeoutSeconds == nil {
+		return
+	}
+	if len(clusterSpec.IdleTimeoutAction) == 0 {
+		clusterSpec.IdleTimeoutAction = CleanupActionDeleteTaskManager
+	}
+}
+
+func _SetBlueGreenDefault(clusterSpec *FlinkClusterSpec) {
+	if clusterSpec.UpdateStrategy == nil ||
+		*clusterSpec.UpdateStrategy != UpdateStrategyBlueGreen {
+		return
+	}
+	if clusterSpec.BlueGreen == nil {
+		clusterSpec.BlueGreen = &BlueGreenSpec{}
+	}
+	if clusterSpec.BlueGreen.StableSeconds == nil {
+		clusterSpec.BlueGreen.StableSeconds = new(int32)
+		*clusterSpec.BlueGreen.StableSeconds = 60
+	}
+	if clusterSpec.BlueGreen.DeadlineSeconds == nil {
+		clusterSpec.BlueGreen.DeadlineSeconds = new(int32)
+		*clusterSpec.BlueGreen.DeadlineSeconds = 600
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/googlecloudplatform/flink-operator/operatorconfig"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"gotest.tools/assert"
@@ -29,6 +30,7 @@ import (
 
 // Tests default values are set as expected.
 func TestSetDefault(t *testing.T) {
+	var tmReplicas int32 = 1
 	var cluster = FlinkCluster{
 		Spec: FlinkClusterSpec{
 			Job: &JobSpec{},
@@ -36,6 +38,14 @@ func TestSetDefault(t *testing.T) {
 				Ingress: &JobManagerIngressSpec{},
 			},
 			HadoopConfig: &HadoopConfig{},
+			GCPConfig: &GCPConfig{
+				ServiceAccount: &GCPServiceAccount{SecretName: "gcp-service-account"},
+			},
+			HighAvailability: &HighAvailabilitySpec{},
+			StateBackend: &StateBackendSpec{
+				Type:            StateBackendTypeRocksDB,
+				RocksDBLocalDir: &RocksDBLocalDirSpec{},
+			},
 		},
 	}
 	_SetDefault(&cluster)
@@ -48,11 +58,14 @@ func TestSetDefault(t *testing.T) {
 	var defaultTmDataPort = int32(6121)
 	var defaultTmRPCPort = int32(6122)
 	var defaultTmQueryPort = int32(6125)
+	var defaultJobSubmissionMode = JobSubmissionModeSubmitter
 	var defaultJobAllowNonRestoredState = false
 	var defaultJobParallelism = int32(1)
 	var defaultJobNoLoggingToStdout = false
 	var defaultJobRestartPolicy = JobRestartPolicyNever
+	var defaultJobTakeSavepointOnCancel = true
 	var defatulJobManagerIngressTLSUse = false
+	var defaultJobManagerIngressPath = "/"
 	var defaultMemoryOffHeapRatio = int32(25)
 	var defaultMemoryOffHeapMin = resource.MustParse("600M")
 	var expectedCluster = FlinkCluster{
@@ -69,6 +82,7 @@ func TestSetDefault(t *testing.T) {
 				AccessScope: "Cluster",
 				Ingress: &JobManagerIngressSpec{
 					UseTLS: &defatulJobManagerIngressTLSUse,
+					Path:   &defaultJobManagerIngressPath,
 				},
 				Ports: JobManagerPorts{
 					RPC:   &defaultJmRPCPort,
@@ -83,7 +97,7 @@ func TestSetDefault(t *testing.T) {
 				VolumeMounts:       nil,
 			},
 			TaskManager: TaskManagerSpec{
-				Replicas: 0,
+				Replicas: &tmReplicas,
 				Ports: TaskManagerPorts{
 					Data:  &defaultTmDataPort,
 					RPC:   &defaultTmRPCPort,
@@ -95,10 +109,12 @@ func TestSetDefault(t *testing.T) {
 				Volumes:            nil,
 			},
 			Job: &JobSpec{
+				SubmissionMode:        &defaultJobSubmissionMode,
 				AllowNonRestoredState: &defaultJobAllowNonRestoredState,
 				Parallelism:           &defaultJobParallelism,
 				NoLoggingToStdout:     &defaultJobNoLoggingToStdout,
 				RestartPolicy:         &defaultJobRestartPolicy,
+				TakeSavepointOnCancel: &defaultJobTakeSavepointOnCancel,
 				CleanupPolicy: &CleanupPolicy{
 					AfterJobSucceeds:  "DeleteCluster",
 					AfterJobFails:     "KeepCluster",
@@ -109,6 +125,22 @@ func TestSetDefault(t *testing.T) {
 			HadoopConfig: &HadoopConfig{
 				MountPath: "/etc/hadoop/conf",
 			},
+			GCPConfig: &GCPConfig{
+				ServiceAccount: &GCPServiceAccount{
+					SecretName: "gcp-service-account",
+					KeyFile:    "key.json",
+					MountPath:  "/etc/gcp/keys",
+				},
+			},
+			HighAvailability: &HighAvailabilitySpec{
+				Mode: "kubernetes",
+			},
+			StateBackend: &StateBackendSpec{
+				Type: "rocksdb",
+				RocksDBLocalDir: &RocksDBLocalDirSpec{
+					MountPath: "/flink-rocksdb",
+				},
+			},
 			EnvVars: nil,
 		},
 		Status: FlinkClusterStatus{},
@@ -124,6 +156,7 @@ func TestSetDefault(t *testing.T) {
 // Tests non-default values are not overwritten unexpectedly.
 func TestSetNonDefault(t *testing.T) {
 	var jmReplicas = int32(2)
+	var tmReplicas int32 = 3
 	var jmRPCPort = int32(8123)
 	var jmBlobPort = int32(8124)
 	var jmQueryPort = int32(8125)
@@ -131,11 +164,14 @@ func TestSetNonDefault(t *testing.T) {
 	var tmDataPort = int32(8121)
 	var tmRPCPort = int32(8122)
 	var tmQueryPort = int32(8125)
+	var jobSubmissionMode = JobSubmissionModeRestAPI
 	var jobAllowNonRestoredState = true
 	var jobParallelism = int32(2)
 	var jobNoLoggingToStdout = true
 	var jobRestartPolicy = JobRestartPolicyFromSavepointOnFailure
+	var jobTakeSavepointOnCancel = false
 	var jobManagerIngressTLSUse = true
+	var jobManagerIngressPath = "/flink"
 	var memoryOffHeapRatio = int32(50)
 	var memoryOffHeapMin = resource.MustParse("600M")
 	var cluster = FlinkCluster{
@@ -152,6 +188,7 @@ func TestSetNonDefault(t *testing.T) {
 				AccessScope: "Cluster",
 				Ingress: &JobManagerIngressSpec{
 					UseTLS: &jobManagerIngressTLSUse,
+					Path:   &jobManagerIngressPath,
 				},
 				Ports: JobManagerPorts{
 					RPC:   &jmRPCPort,
@@ -166,7 +203,7 @@ func TestSetNonDefault(t *testing.T) {
 				VolumeMounts:       nil,
 			},
 			TaskManager: TaskManagerSpec{
-				Replicas: 0,
+				Replicas: &tmReplicas,
 				Ports: TaskManagerPorts{
 					Data:  &tmDataPort,
 					RPC:   &tmRPCPort,
@@ -178,10 +215,12 @@ func TestSetNonDefault(t *testing.T) {
 				Volumes:            nil,
 			},
 			Job: &JobSpec{
+				SubmissionMode:        &jobSubmissionMode,
 				AllowNonRestoredState: &jobAllowNonRestoredState,
 				Parallelism:           &jobParallelism,
 				NoLoggingToStdout:     &jobNoLoggingToStdout,
 				RestartPolicy:         &jobRestartPolicy,
+				TakeSavepointOnCancel: &jobTakeSavepointOnCancel,
 				CleanupPolicy: &CleanupPolicy{
 					AfterJobSucceeds:  "DeleteTaskManagers",
 					AfterJobFails:     "DeleteCluster",
@@ -213,6 +252,7 @@ func TestSetNonDefault(t *testing.T) {
 				AccessScope: "Cluster",
 				Ingress: &JobManagerIngressSpec{
 					UseTLS: &jobManagerIngressTLSUse,
+					Path:   &jobManagerIngressPath,
 				},
 				Ports: JobManagerPorts{
 					RPC:   &jmRPCPort,
@@ -227,7 +267,7 @@ func TestSetNonDefault(t *testing.T) {
 				VolumeMounts:       nil,
 			},
 			TaskManager: TaskManagerSpec{
-				Replicas: 0,
+				Replicas: &tmReplicas,
 				Ports: TaskManagerPorts{
 					Data:  &tmDataPort,
 					RPC:   &tmRPCPort,
@@ -239,10 +279,12 @@ func TestSetNonDefault(t *testing.T) {
 				Volumes:            nil,
 			},
 			Job: &JobSpec{
+				SubmissionMode:        &jobSubmissionMode,
 				AllowNonRestoredState: &jobAllowNonRestoredState,
 				Parallelism:           &jobParallelism,
 				NoLoggingToStdout:     &jobNoLoggingToStdout,
 				RestartPolicy:         &jobRestartPolicy,
+				TakeSavepointOnCancel: &jobTakeSavepointOnCancel,
 				CleanupPolicy: &CleanupPolicy{
 					AfterJobSucceeds:  "DeleteTaskManagers",
 					AfterJobFails:     "DeleteCluster",
@@ -264,3 +306,193 @@ func TestSetNonDefault(t *testing.T) {
 		expectedCluster,
 		cmpopts.IgnoreUnexported(resource.Quantity{}))
 }
+
+func TestSetScheduleDefault(t *testing.T) {
+	var schedule = "0 2 * * *"
+	var jobSpec = JobSpec{Schedule: &schedule}
+
+	_SetJobDefault(&jobSpec)
+
+	var defaultConcurrencyPolicy = JobConcurrencyPolicyForbid
+	var defaultSuccessfulRunsHistoryLimit = int32(3)
+	var defaultFailedRunsHistoryLimit = int32(1)
+	var defaultDeleteTaskManagersBetweenRuns = false
+	assert.DeepEqual(t, jobSpec.ConcurrencyPolicy, &defaultConcurrencyPolicy)
+	assert.DeepEqual(
+		t, jobSpec.SuccessfulRunsHistoryLimit, &defaultSuccessfulRunsHistoryLimit)
+	assert.DeepEqual(
+		t, jobSpec.FailedRunsHistoryLimit, &defaultFailedRunsHistoryLimit)
+	assert.DeepEqual(
+		t,
+		jobSpec.DeleteTaskManagersBetweenRuns,
+		&defaultDeleteTaskManagersBetweenRuns)
+}
+
+func TestSetRetriesDefault(t *testing.T) {
+	var maxRetries int32 = 3
+	var jobSpec = JobSpec{MaxRetries: &maxRetries}
+
+	_SetJobDefault(&jobSpec)
+
+	var defaultRetryBackoffSeconds = int32(10)
+	var defaultMaxRetryBackoffSeconds = int32(300)
+	assert.DeepEqual(t, jobSpec.RetryBackoffSeconds, &defaultRetryBackoffSeconds)
+	assert.DeepEqual(
+		t, jobSpec.MaxRetryBackoffSeconds, &defaultMaxRetryBackoffSeconds)
+}
+
+func TestSetSavepointRetriesDefault(t *testing.T) {
+	var maxRetries int32 = 3
+	var jobSpec = JobSpec{SavepointMaxRetries: &maxRetries}
+
+	_SetJobDefault(&jobSpec)
+
+	var defaultRetryBackoffSeconds = int32(10)
+	assert.DeepEqual(
+		t, jobSpec.SavepointRetryBackoffSeconds, &defaultRetryBackoffSeconds)
+}
+
+func TestSetCleanupPolicyDefault(t *testing.T) {
+	var jobSpec = JobSpec{
+		CleanupPolicy: &CleanupPolicy{
+			AfterJobFails: CleanupActionDeleteTaskManager,
+		},
+	}
+
+	_SetJobDefault(&jobSpec)
+
+	assert.DeepEqual(
+		t,
+		jobSpec.CleanupPolicy,
+		&CleanupPolicy{
+			AfterJobSucceeds:  CleanupActionDeleteCluster,
+			AfterJobFails:     CleanupActionDeleteTaskManager,
+			AfterJobCancelled: CleanupActionDeleteCluster,
+		})
+}
+
+func TestSetOperatorConfigDefault(t *testing.T) {
+	operatorconfig.Set(&operatorconfig.Config{
+		Images:        map[string]string{"1.12": "flink:1.12.7"},
+		SavepointsDir: "gs://my-bucket/savepoints/",
+		CleanupPolicy: operatorconfig.CleanupPolicy{
+			AfterJobSucceeds: string(CleanupActionKeepCluster),
+		},
+	})
+	defer operatorconfig.Set(&operatorconfig.Config{})
+	var cluster = FlinkCluster{
+		Spec: FlinkClusterSpec{
+			FlinkVersion: "1.12",
+			Job: &JobSpec{
+				CleanupPolicy: &CleanupPolicy{
+					AfterJobFails: CleanupActionDeleteTaskManager,
+				},
+			},
+		},
+	}
+
+	_SetDefault(&cluster)
+
+	assert.Equal(t, cluster.Spec.Image.Name, "flink:1.12.7")
+	assert.Equal(t, *cluster.Spec.Job.SavepointsDir, "gs://my-bucket/savepoints/")
+	assert.DeepEqual(
+		t,
+		cluster.Spec.Job.CleanupPolicy,
+		&CleanupPolicy{
+			AfterJobSucceeds:  CleanupActionKeepCluster,
+			AfterJobFails:     CleanupActionDeleteTaskManager,
+			AfterJobCancelled: CleanupActionDeleteCluster,
+		})
+
+	// The specified properties take precedence over the operator config.
+	var savepointsDir = "gs://other-bucket/savepoints/"
+	cluster = FlinkCluster{
+		Spec: FlinkClusterSpec{
+			FlinkVersion: "1.12",
+			Image:        ImageSpec{Name: "my-flink:1.12"},
+			Job:          &JobSpec{SavepointsDir: &savepointsDir},
+		},
+	}
+
+	_SetDefault(&cluster)
+
+	assert.Equal(t, cluster.Spec.Image.Name, "my-flink:1.12")
+	assert.Equal(t, *cluster.Spec.Job.SavepointsDir, savepointsDir)
+}
+
+func TestSetGPUDefault(t *testing.T) {
+	var tmSpec = TaskManagerSpec{GPU: &GPUSpec{Amount: 1}}
+
+	_SetTaskManagerDefault(&tmSpec)
+
+	var defaultResourceName = "nvidia.com/gpu"
+	var defaultDiscoveryScriptPath = "opt/external-resource-gpu/nvidia-gpu-discovery.sh"
+	assert.DeepEqual(
+		t,
+		tmSpec.GPU,
+		&GPUSpec{
+			Amount:              1,
+			ResourceName:        &defaultResourceName,
+			DiscoveryScriptPath: &defaultDiscoveryScriptPath,
+		})
+}
+
+func TestSetHistoryServerDefault(t *testing.T) {
+	var historyServer = HistoryServerSpec{ArchiveDir: "gs://my-bucket/completed-jobs/"}
+
+	_SetHistoryServerDefault(&historyServer)
+
+	var defaultPort int32 = 8082
+	assert.DeepEqual(
+		t,
+		historyServer,
+		HistoryServerSpec{
+			ArchiveDir: "gs://my-bucket/completed-jobs/",
+			Port:       &defaultPort,
+		})
+}
+
+func TestSetIdleTimeoutDefault(t *testing.T) {
+	var idleTimeoutSeconds int32 = 600
+	var clusterSpec = FlinkClusterSpec{IdleTimeoutSeconds: &idleTimeoutSeconds}
+
+	_SetIdleTimeoutDefault(&clusterSpec)
+
+	assert.Equal(
+		t, clusterSpec.IdleTimeoutAction, CleanupAction(CleanupActionDeleteTaskManager))
+}
+
+func TestSetBlueGreenDefault(t *testing.T) {
+	var updateStrategy = UpdateStrategyBlueGreen
+	var clusterSpec = FlinkClusterSpec{UpdateStrategy: &updateStrategy}
+
+	_SetBlueGreenDefault(&clusterSpec)
+
+	var defaultStableSeconds int32 = 60
+	var defaultDeadlineSeconds int32 = 600
+	assert.DeepEqual(
+		t,
+		*clusterSpec.BlueGreen,
+		BlueGreenSpec{
+			StableSeconds:   &defaultStableSeconds,
+			DeadlineSeconds: &defaultDeadlineSeconds,
+		})
+
+	var inPlaceSpec = FlinkClusterSpec{}
+	_SetBlueGreenDefault(&inPlaceSpec)
+	assert.Assert(t, inPlaceSpec.BlueGreen == nil)
+}
+
+func TestSetTaskManagerReplicasDefault(t *testing.T) {
+	var defaultReplicas int32 = 1
+	var zeroReplicas int32 = 0
+
+	var tmSpec = TaskManagerSpec{}
+	_SetTaskManagerDefault(&tmSpec)
+	assert.DeepEqual(t, tmSpec.Replicas, &defaultReplicas)
+
+	// Scaled down to zero, not overwritten by the default.
+	tmSpec = TaskManagerSpec{Replicas: &zeroReplicas}
+	_SetTaskManagerDefault(&tmSpec)
+	assert.DeepEqual(t, tmSpec.Replicas, &zeroReplicas)
+}
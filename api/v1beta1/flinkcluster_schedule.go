@@ -0,0 +1,225 @@
+/*
+Copyright 2020 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parsed cron schedule, each field is a bit set of the allowed values.
+type cronSchedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// Whether the day of month or the day of week is "*", the day matches
+	// either of the fields when both are restricted, like in cron.
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var cronMinute = cronField{name: "minute", min: 0, max: 59}
+var cronHour = cronField{name: "hour", min: 0, max: 23}
+var cronDayOfMonth = cronField{name: "day of month", min: 1, max: 31}
+var cronMonth = cronField{
+	name: "month",
+	min:  1,
+	max:  12,
+	names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	},
+}
+
+// Both 0 and 7 are Sunday.
+var cronDayOfWeek = cronField{
+	name: "day of week",
+	min:  0,
+	max:  7,
+	names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// GetNextScheduleTime parses a schedule in the cron syntax, i.e., the
+// standard 5 fields "minute hour day-of-month month day-of-week" or one of the
+// macros like "@daily", and returns the first scheduled time after `after`.
+func GetNextScheduleTime(schedule string, after time.Time) (time.Time, error) {
+	var parsed, err = parseCronSchedule(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var next, ok = parsed.next(after)
+	if !ok {
+		return time.Time{}, fmt.Errorf(
+			"no scheduled time of %q in the next 5 years", schedule)
+	}
+	return next, nil
+}
+
+func parseCronSchedule(schedule string) (*cronSchedule, error) {
+	var spec = strings.TrimSpace(schedule)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	var fields = strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(
+			"expected 5 fields or a macro, found %v fields", len(fields))
+	}
+	var parsed = &cronSchedule{
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}
+	var err error
+	if parsed.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if parsed.hour, err = cronHour.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if parsed.dayOfMonth, err = cronDayOfMonth.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if parsed.month, err = cronMonth.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if parsed.dayOfWeek, err = cronDayOfWeek.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if parsed.dayOfWeek&(1<<7) != 0 {
+		parsed.dayOfWeek |= 1
+	}
+	return parsed, nil
+}
+
+// Parses a comma separated list of "*", values and ranges, each optionally
+// with a step, e.g., "*/15" or "1-5,10".
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		var rangeAndStep = strings.SplitN(item, "/", 2)
+		var start, end int
+		if rangeAndStep[0] == "*" {
+			start, end = f.min, f.max
+		} else {
+			var bounds = strings.SplitN(rangeAndStep[0], "-", 2)
+			var err error
+			if start, err = f.parseValue(bounds[0]); err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = f.parseValue(bounds[1]); err != nil {
+					return 0, err
+				}
+			}
+		}
+		var step = 1
+		if len(rangeAndStep) == 2 {
+			var err error
+			step, err = strconv.Atoi(rangeAndStep[1])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf(
+					"invalid step %q in %v field", rangeAndStep[1], f.name)
+			}
+			// "a/n" means from a to the max.
+			if rangeAndStep[0] != "*" && !strings.Contains(rangeAndStep[0], "-") {
+				end = f.max
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q in %v field", item, f.name)
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) parseValue(value string) (int, error) {
+	if v, ok := f.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	var v, err = strconv.Atoi(value)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf(
+			"invalid value %q in %v field, it must be in [%v, %v]",
+			value, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	var domMatch = s.dayOfMonth&(1<<uint(t.Day())) != 0
+	var dowMatch = s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Finds the first matching minute after `after`, moving forward by the
+// largest unit which doesn't match. Gives up after 5 years, e.g., for
+// "0 0 30 2 *".
+func (s *cronSchedule) next(after time.Time) (time.Time, bool) {
+	var t = after.Truncate(time.Minute).Add(time.Minute)
+	var limit = t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(
+				t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
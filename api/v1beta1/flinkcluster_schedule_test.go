@@ -0,0 +1,59 @@
+/*
+Copyright 2020 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestGetNextScheduleTime(t *testing.T) {
+	// Friday.
+	var after = time.Date(2020, 2, 28, 23, 59, 30, 0, time.UTC)
+	var expected = map[string]time.Time{
+		"*/15 * * * *":    time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		"@hourly":         time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 2 * * mon-fri": time.Date(2020, 3, 2, 2, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":      time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		"30 12 1,15 * 0":  time.Date(2020, 3, 1, 12, 30, 0, 0, time.UTC),
+		"5/20 3 * * 7":    time.Date(2020, 3, 1, 3, 5, 0, 0, time.UTC),
+		"0 0 1 jan *":     time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for schedule, expectedTime := range expected {
+		var next, err = GetNextScheduleTime(schedule, after)
+		assert.NilError(t, err)
+		assert.Equal(t, next, expectedTime, schedule)
+	}
+}
+
+func TestGetNextScheduleTimeInvalid(t *testing.T) {
+	var after = time.Date(2020, 2, 28, 23, 59, 30, 0, time.UTC)
+	var expectedErrs = map[string]string{
+		"@every 1h":    "expected 5 fields or a macro, found 2 fields",
+		"0 0 * * 8":    "invalid value \"8\" in day of week field, it must be in [0, 7]",
+		"10-5 * * * *": "invalid range \"10-5\" in minute field",
+		"*/0 * * * *":  "invalid step \"0\" in minute field",
+		"0 0 30 2 *":   "no scheduled time of \"0 0 30 2 *\" in the next 5 years",
+	}
+	for schedule, expectedErr := range expectedErrs {
+		var _, err = GetNextScheduleTime(schedule, after)
+		assert.Assert(t, err != nil, "err is not expected to be nil")
+		assert.Equal(t, err.Error(), expectedErr)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The reader of the FlinkClusterTemplates, it is set when the webhooks are set
+// up, so the templates are not merged without the webhooks, e.g., in tests.
+var templateReader client.Reader
+
+// Gets the spec of the template referenced by the cluster, the unknown
+// properties of the template are rejected.
+func getClusterTemplateSpec(cluster *FlinkCluster) (*FlinkClusterSpec, error) {
+	var template = &FlinkClusterTemplate{}
+	var err = templateReader.Get(
+		context.Background(),
+		types.NamespacedName{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Spec.TemplateRef.Name,
+		},
+		template)
+	if err != nil {
+		return nil, err
+	}
+	var spec = &FlinkClusterSpec{}
+	if len(template.Spec.Raw) > 0 {
+		var decoder = json.NewDecoder(bytes.NewReader(template.Spec.Raw))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(spec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The templates are not chained.
+	spec.TemplateRef = nil
+	return spec, nil
+}
+
+// Merges the template referenced by a new cluster into its spec. The
+// template is only merged when the cluster is created, the merged properties
+// are stored in the cluster, so the changes of the template don't change the
+// existing clusters.
+func _SetTemplateDefault(cluster *FlinkCluster) error {
+	if cluster.Spec.TemplateRef == nil || templateReader == nil ||
+		!cluster.CreationTimestamp.IsZero() {
+		return nil
+	}
+	var templateSpec, err = getClusterTemplateSpec(cluster)
+	if err != nil {
+		return err
+	}
+	mergeTemplate(
+		reflect.ValueOf(&cluster.Spec).Elem(),
+		reflect.ValueOf(templateSpec).Elem())
+	return nil
+}
+
+// Deep-merges the template value into the value, the non-zero properties of
+// the value take precedence. The maps are merged by key, the lists and the
+// opaque structs, e.g., quantities, are taken from the template only when they
+// are empty in the value.
+func mergeTemplate(value reflect.Value, template reflect.Value) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if template.IsNil() {
+			return
+		}
+		if value.IsNil() {
+			value.Set(template)
+			return
+		}
+		mergeTemplate(value.Elem(), template.Elem())
+	case reflect.Struct:
+		if !hasOnlyExportedFields(value.Type()) {
+			if isZero(value) {
+				value.Set(template)
+			}
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			mergeTemplate(value.Field(i), template.Field(i))
+		}
+	case reflect.Map:
+		if template.IsNil() {
+			return
+		}
+		if value.IsNil() {
+			value.Set(template)
+			return
+		}
+		for _, key := range template.MapKeys() {
+			var element = value.MapIndex(key)
+			if !element.IsValid() {
+				value.SetMapIndex(key, template.MapIndex(key))
+				continue
+			}
+			var merged = reflect.New(element.Type()).Elem()
+			merged.Set(element)
+			mergeTemplate(merged, template.MapIndex(key))
+			value.SetMapIndex(key, merged)
+		}
+	case reflect.Slice:
+		if value.Len() == 0 && template.Len() > 0 {
+			value.Set(template)
+		}
+	default:
+		if isZero(value) {
+			value.Set(template)
+		}
+	}
+}
+
+func hasOnlyExportedFields(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		if len(structType.Field(i).PkgPath) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(value reflect.Value) bool {
+	return reflect.DeepEqual(
+		value.Interface(), reflect.Zero(value.Type()).Interface())
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// A reader of the templates in a map.
+type templateMapReader map[client.ObjectKey]*FlinkClusterTemplate
+
+func (reader templateMapReader) Get(
+	ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	var template, ok = reader[key]
+	if !ok {
+		return apierrors.NewNotFound(
+			schema.GroupResource{Resource: "flinkclustertemplates"}, key.Name)
+	}
+	template.DeepCopyInto(obj.(*FlinkClusterTemplate))
+	return nil
+}
+
+func (reader templateMapReader) List(
+	ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return nil
+}
+
+func TestSetTemplateDefault(t *testing.T) {
+	templateReader = templateMapReader{
+		{Namespace: "default", Name: "mytemplate"}: {
+			Spec: runtime.RawExtension{Raw: []byte(`{
+				"image": {"name": "flink:1.12.7", "pullPolicy": "IfNotPresent"},
+				"taskManager": {
+					"nodeSelector": {"pool": "flink", "zone": "a"},
+					"resources": {"limits": {"cpu": "1", "memory": "2Gi"}},
+					"securityContext": {"runAsUser": 9999, "runAsGroup": 9999}
+				},
+				"flinkProperties": {
+					"metrics.reporters": "prom",
+					"taskmanager.numberOfTaskSlots": "1"
+				}
+			}`)},
+		},
+	}
+	defer func() { templateReader = nil }()
+	var runAsUser int64 = 1000
+	var cluster = FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "default"},
+		Spec: FlinkClusterSpec{
+			TemplateRef: &corev1.LocalObjectReference{Name: "mytemplate"},
+			TaskManager: TaskManagerSpec{
+				NodeSelector: map[string]string{"zone": "b"},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsUser: &runAsUser,
+				},
+			},
+			FlinkProperties: map[string]string{
+				"taskmanager.numberOfTaskSlots": "4",
+			},
+		},
+	}
+
+	var err = _SetTemplateDefault(&cluster)
+	assert.NilError(t, err)
+
+	var runAsGroup int64 = 9999
+	assert.DeepEqual(
+		t,
+		cluster.Spec.Image,
+		ImageSpec{Name: "flink:1.12.7", PullPolicy: corev1.PullIfNotPresent})
+	assert.DeepEqual(
+		t,
+		cluster.Spec.TaskManager.NodeSelector,
+		map[string]string{"pool": "flink", "zone": "b"})
+	assert.DeepEqual(
+		t,
+		cluster.Spec.TaskManager.SecurityContext,
+		&corev1.PodSecurityContext{RunAsUser: &runAsUser, RunAsGroup: &runAsGroup})
+	var limits = cluster.Spec.TaskManager.Resources.Limits
+	assert.Equal(t, limits.Cpu().String(), "1")
+	assert.Equal(t, limits.Memory().String(), "4Gi")
+	assert.DeepEqual(
+		t,
+		cluster.Spec.FlinkProperties,
+		map[string]string{
+			"metrics.reporters":             "prom",
+			"taskmanager.numberOfTaskSlots": "4",
+		})
+
+	// The template is not merged into the existing clusters.
+	cluster = FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "mycluster",
+			Namespace:         "default",
+			CreationTimestamp: metav1.Now(),
+		},
+		Spec: FlinkClusterSpec{
+			TemplateRef: &corev1.LocalObjectReference{Name: "mytemplate"},
+		},
+	}
+	err = _SetTemplateDefault(&cluster)
+	assert.NilError(t, err)
+	assert.Equal(t, cluster.Spec.Image.Name, "")
+}
+
+func TestValidateTemplateRef(t *testing.T) {
+	templateReader = templateMapReader{
+		{Namespace: "default", Name: "invalid"}: {
+			Spec: runtime.RawExtension{Raw: []byte(`{"imag": {}}`)},
+		},
+	}
+	defer func() { templateReader = nil }()
+	var validator = &Validator{}
+	var path = field.NewPath("spec", "templateRef")
+	var cluster = FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "default"},
+		Spec: FlinkClusterSpec{
+			TemplateRef: &corev1.LocalObjectReference{Name: "missing"},
+		},
+	}
+
+	var errs = validator.validateTemplateRef(&cluster, path)
+	assert.ErrorContains(
+		t,
+		errs.ToAggregate(),
+		`spec.templateRef.name: Invalid value: "missing": failed to get the cluster template`)
+
+	cluster.Spec.TemplateRef.Name = "invalid"
+	errs = validator.validateTemplateRef(&cluster, path)
+	assert.ErrorContains(t, errs.ToAggregate(), `unknown field "imag"`)
+}
@@ -20,6 +20,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // ClusterState defines states for a cluster.
@@ -49,6 +50,62 @@ const (
 	JobStateUnknown   = "Unknown"
 )
 
+// JobUpgradeState defines states for the upgrade of a running job.
+const (
+	JobUpgradeStateInProgress = "InProgress"
+	JobUpgradeStateDeploying  = "Deploying"
+	JobUpgradeStateFailed     = "Failed"
+)
+
+// JobRollbackState defines states for the rollback of a job upgrade.
+const (
+	JobRollbackStateInProgress = "InProgress"
+	JobRollbackStateSucceeded  = "Succeeded"
+)
+
+// ClusterConditionType defines the types of the cluster conditions.
+const (
+	// ClusterConditionClusterReady - the JobManager and TaskManagers are
+	// ready.
+	ClusterConditionClusterReady = "ClusterReady"
+
+	// ClusterConditionJobRunning - the job is running.
+	ClusterConditionJobRunning = "JobRunning"
+
+	// ClusterConditionJobFinished - the job has succeeded, failed or been
+	// cancelled, and it will not be restarted.
+	ClusterConditionJobFinished = "JobFinished"
+
+	// ClusterConditionSavepointComplete - the last savepoint has succeeded.
+	ClusterConditionSavepointComplete = "SavepointComplete"
+
+	// ClusterConditionJobUpgradeFailed - the upgrade of the running job to the
+	// updated job spec has failed.
+	ClusterConditionJobUpgradeFailed = "JobUpgradeFailed"
+
+	// ClusterConditionTimedOut - a lifecycle phase of the cluster didn't
+	// complete within its timeout, the reason is the phase.
+	ClusterConditionTimedOut = "TimedOut"
+
+	// ClusterConditionJobLost - the running job is not found in the
+	// JobManager.
+	ClusterConditionJobLost = "JobLost"
+
+	// ClusterConditionInsufficientQuota - the JobManager or TaskManagers are
+	// not created because their resources exceed the ResourceQuotas of the
+	// namespace.
+	ClusterConditionInsufficientQuota = "InsufficientQuota"
+)
+
+// TimeoutPhase defines the lifecycle phases which can time out.
+const (
+	TimeoutPhaseClusterStartup = "ClusterStartup"
+	TimeoutPhaseJobSubmission  = "JobSubmission"
+	TimeoutPhaseSavepoint      = "Savepoint"
+	TimeoutPhaseCancellation   = "Cancellation"
+	TimeoutPhaseUpgrade        = "Upgrade"
+)
+
 // AccessScope defines the access scope of JobManager service.
 const (
 	AccessScopeCluster  = "Cluster"
@@ -57,6 +114,76 @@ const (
 	AccessScopeNodePort = "NodePort"
 )
 
+// HighAvailabilityMode defines the HA services of the JobManager.
+type HighAvailabilityMode = string
+
+const (
+	// HighAvailabilityModeKubernetes - Flink's Kubernetes HA services, the
+	// leader information is stored in ConfigMaps.
+	HighAvailabilityModeKubernetes = "kubernetes"
+
+	// HighAvailabilityModeZooKeeper - Flink's ZooKeeper HA services, the
+	// leader information is stored in an existing ZooKeeper quorum.
+	HighAvailabilityModeZooKeeper = "zookeeper"
+)
+
+// DeploymentType defines the kind of the workload which runs the pods of a
+// component.
+type DeploymentType = string
+
+const (
+	// DeploymentTypeDeployment - the pods are run by a Deployment.
+	DeploymentTypeDeployment = "Deployment"
+
+	// DeploymentTypeStatefulSet - the pods are run by a StatefulSet, each pod
+	// gets a stable name and hostname, and its own PersistentVolumeClaims from
+	// the volume claim templates.
+	DeploymentTypeStatefulSet = "StatefulSet"
+)
+
+// PodManagementPolicy defines the order in which the pods of a StatefulSet
+// are created and deleted.
+type PodManagementPolicy = string
+
+const (
+	// PodManagementPolicyOrderedReady - the pods are created one at a time in
+	// the order of their ordinals, each one after the previous one is ready,
+	// and deleted in the reverse order.
+	PodManagementPolicyOrderedReady = "OrderedReady"
+
+	// PodManagementPolicyParallel - the pods are created and deleted all at
+	// once.
+	PodManagementPolicyParallel = "Parallel"
+)
+
+// StateBackendType defines where the state of the job is kept.
+type StateBackendType = string
+
+const (
+	// StateBackendTypeHashMap - the state is kept as objects on the JVM heap
+	// of the TaskManagers.
+	StateBackendTypeHashMap = "hashmap"
+
+	// StateBackendTypeRocksDB - the state is kept in RocksDB instances on the
+	// local disk of the TaskManagers.
+	StateBackendTypeRocksDB = "rocksdb"
+)
+
+// BatchSchedulerName defines the batch scheduler which gang schedules the
+// JobManager and TaskManager pods.
+type BatchSchedulerName = string
+
+const (
+	// BatchSchedulerVolcano - Volcano, the pods are grouped by a
+	// `scheduling.volcano.sh/v1beta1` PodGroup.
+	BatchSchedulerVolcano = "volcano"
+
+	// BatchSchedulerSchedulerPlugins - the Coscheduling plugin of the
+	// Kubernetes scheduler-plugins, the pods are grouped by a
+	// `scheduling.sigs.k8s.io/v1alpha1` PodGroup.
+	BatchSchedulerSchedulerPlugins = "scheduler-plugins"
+)
+
 // JobRestartPolicy defines the restart policy when a job fails.
 type JobRestartPolicy = string
 
@@ -65,10 +192,87 @@ const (
 	JobRestartPolicyNever = "Never"
 
 	// JobRestartPolicyFromSavepointOnFailure - restart the job from the latest
-	// savepoint if available, otherwise do not restart.
+	// externalized checkpoint or savepoint if available, otherwise do not
+	// restart.
 	JobRestartPolicyFromSavepointOnFailure = "FromSavepointOnFailure"
 )
 
+// JobSubmissionMode defines how the job is submitted to the cluster.
+type JobSubmissionMode = string
+
+const (
+	// JobSubmissionModeSubmitter - submit the job with the Flink CLI in a
+	// separate Kubernetes job.
+	JobSubmissionModeSubmitter = "Submitter"
+
+	// JobSubmissionModeRestAPI - upload the JAR and run the job through the
+	// Flink REST API by the operator.
+	JobSubmissionModeRestAPI = "RestAPI"
+)
+
+// JobConcurrencyPolicy defines how a scheduled run is handled when the
+// previous run is still in progress.
+type JobConcurrencyPolicy = string
+
+const (
+	// JobConcurrencyPolicyForbid - start the scheduled run after the previous
+	// run finishes.
+	JobConcurrencyPolicyForbid = "Forbid"
+
+	// JobConcurrencyPolicyReplace - cancel the running job and start the
+	// scheduled run.
+	JobConcurrencyPolicyReplace = "Replace"
+)
+
+// CheckpointingMode defines the consistency guarantee of the checkpoints.
+type CheckpointingMode = string
+
+const (
+	// CheckpointingModeExactlyOnce - the state reflects each record exactly
+	// once after a recovery.
+	CheckpointingModeExactlyOnce = "ExactlyOnce"
+
+	// CheckpointingModeAtLeastOnce - records may be reflected more than once
+	// in the state after a recovery, with lower latency.
+	CheckpointingModeAtLeastOnce = "AtLeastOnce"
+)
+
+// CheckpointRetention defines whether externalized checkpoints are retained
+// when the job is cancelled.
+type CheckpointRetention = string
+
+const (
+	// CheckpointRetentionRetainOnCancellation - retain the externalized
+	// checkpoints when the job is cancelled.
+	CheckpointRetentionRetainOnCancellation = "RetainOnCancellation"
+
+	// CheckpointRetentionDeleteOnCancellation - delete the externalized
+	// checkpoints when the job is cancelled.
+	CheckpointRetentionDeleteOnCancellation = "DeleteOnCancellation"
+)
+
+// UpdateStrategy defines how the changes of the cluster spec are rolled out.
+type UpdateStrategy = string
+
+const (
+	// UpdateStrategyInPlace - update the components of the cluster in place,
+	// the job is stopped with a savepoint and resubmitted from it.
+	UpdateStrategyInPlace = "InPlace"
+
+	// UpdateStrategyBlueGreen - deploy the updated job in a new cluster from
+	// the latest savepoint, and delete the old cluster after the new one is
+	// verified.
+	UpdateStrategyBlueGreen = "BlueGreen"
+)
+
+// Blue/green deployment state
+const (
+	BlueGreenStateStable     = "Stable"
+	BlueGreenStateDeploying  = "Deploying"
+	BlueGreenStateVerifying  = "Verifying"
+	BlueGreenStateRolledBack = "RolledBack"
+)
+
 // User requested control
 const (
 	// control annotation key
@@ -84,6 +288,15 @@ const (
 	ControlStateFailed      = "Failed"
 )
 
+// ClusterFinalizer is the finalizer which makes the deletion of a cluster wait
+// for the operator to cancel its jobs and delete its components.
+const ClusterFinalizer = "flinkclusters.flinkoperator.k8s.io/finalizer"
+
+// PausedAnnotation is the annotation which pauses the reconciliation of a
+// cluster when it is "true", e.g., to edit its components manually. The
+// deletion of a paused cluster is still finalized.
+const PausedAnnotation = "flinkclusters.flinkoperator.k8s.io/paused"
+
 // Savepoint status
 const (
 	SavepointStateNotTriggered  = "NotTriggered"
@@ -95,6 +308,7 @@ const (
 	SavepointTriggerReasonUserRequested = "user requested"
 	SavepointTriggerReasonJobCancel     = "for job-cancel"
 	SavepointTriggerReasonScheduled     = "scheduled"
+	SavepointTriggerReasonUpdate        = "for update"
 )
 
 // ImageSpec defines Flink image of JobManager and TaskManager containers.
@@ -138,19 +352,72 @@ type JobManagerIngressSpec struct {
 
 	// TLS secret name.
 	TLSSecretName *string `json:"tlsSecretName,omitempty"`
+
+	// Ingress path of the web UI, default: "/".
+	Path *string `json:"path,omitempty"`
+}
+
+// UIAuthProxySpec defines the auth proxy sidecar of the JobManager web UI.
+type UIAuthProxySpec struct {
+	// The proxy container, which authenticates the requests and forwards them
+	// to the UI at `http://127.0.0.1:<ui port>`, its name defaults to
+	// "ui-auth-proxy".
+	Container corev1.Container `json:"container"`
+
+	// (Optional) Port which the proxy listens on, default: 4180.
+	Port *int32 `json:"port,omitempty"`
+}
+
+// JobManagerServiceSpec defines the service of JobManager.
+type JobManagerServiceSpec struct {
+	// Service type, enum("ClusterIP", "NodePort", "LoadBalancer"), it
+	// overrides the type derived from the access scope.
+	Type *corev1.ServiceType `json:"type,omitempty"`
+
+	// Service annotations, e.g., for an internal load balancer or a static IP.
+	// They are merged with the annotations derived from the access scope.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Client IP ranges allowed to access the load balancer, only for the
+	// LoadBalancer service type.
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
 }
 
 // JobManagerSpec defines properties of JobManager.
 type JobManagerSpec struct {
-	// The number of replicas.
+	// The number of replicas, default: 1. It must be 1 unless
+	// `highAvailability` is specified, the extra replicas run as standby
+	// JobManagers.
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// (Optional) The kind of the workload which runs the JobManager pods,
+	// "Deployment" or "StatefulSet", default: "Deployment". A StatefulSet
+	// gives the JobManagers stable names and hostnames through a headless
+	// service.
+	DeploymentType *DeploymentType `json:"deploymentType,omitempty"`
+
+	// (Optional) The order in which the pods of the JobManager StatefulSet are
+	// created and deleted, "OrderedReady" or "Parallel", default:
+	// "OrderedReady". It requires the "StatefulSet" deployment type.
+	PodManagementPolicy *PodManagementPolicy `json:"podManagementPolicy,omitempty"`
+
 	// Access scope, enum("Cluster", "VPC", "External").
 	AccessScope string `json:"accessScope"`
 
 	// (Optional) Ingress.
 	Ingress *JobManagerIngressSpec `json:"ingress,omitempty"`
 
+	// (Optional) Service type and annotations.
+	Service *JobManagerServiceSpec `json:"service,omitempty"`
+
+	// (Optional) Auth proxy sidecar in front of the web UI, e.g.,
+	// oauth2-proxy, which the UI port of the JobManager service and ingress
+	// are routed to, so that the UI which allows job cancellation and JAR
+	// uploads is not exposed unauthenticated. The operator and the job
+	// submitter connect to the REST API through the internal service
+	// `<cluster>-jobmanager-rest` instead.
+	UIAuthProxy *UIAuthProxySpec `json:"uiAuthProxy,omitempty"`
+
 	// Ports.
 	Ports JobManagerPorts `json:"ports,omitempty"`
 
@@ -176,14 +443,99 @@ type JobManagerSpec struct {
 	// Volume mounts in the JobManager container.
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
 
+	// Environment variables of the JobManager container, in addition to the
+	// cluster `envVars`. The names must be unique and must not be set in
+	// `envVars`.
+	// More info: https://kubernetes.io/docs/tasks/inject-data-application/define-environment-variable-container/
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Sources of environment variables of the JobManager container, e.g., to
+	// inject credentials from a Secret or tuning flags from a ConfigMap.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-pod-configmap/#configure-all-key-value-pairs-in-a-configmap-as-container-environment-variables
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// Selector which must match a node's labels for the JobManager pod to be
 	// scheduled on that node.
 	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
+	// Tolerations of the JobManager pod, e.g., to schedule it on the nodes of
+	// a tainted dedicated node pool.
+	// More info: https://kubernetes.io/docs/concepts/configuration/taint-and-toleration/
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// (Optional) Affinity of the JobManager pod, it replaces the default
+	// anti-affinity which spreads the standby JobManagers across nodes.
+	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/#affinity-and-anti-affinity
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// (Optional) PodDisruptionBudget of the JobManager pods, which limits
+	// their voluntary disruptions, e.g., by node drains.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/disruptions/
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
+	// (Optional) Name of the PriorityClass of the JobManager pods, e.g., to
+	// preempt lower priority pods when the Kubernetes cluster is full.
+	// More info: https://kubernetes.io/docs/concepts/configuration/pod-priority-preemption/
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+
+	// (Optional) Pod-level security context of the JobManager pod, e.g., to
+	// run as a non-root user.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/security-context/
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// (Optional) Security context of the JobManager container, e.g., to drop
+	// capabilities or make the root filesystem read-only.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/security-context/
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+
+	// (Optional) Readiness probe of the JobManager container, default: HTTP
+	// GET `/config` on the UI port, i.e., the JobManager is ready when its
+	// REST API is up. The default handler is used when the probe doesn't
+	// specify one, e.g., to only tune the thresholds.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// (Optional) Liveness probe of the JobManager container, which restarts
+	// a wedged JobManager, default: TCP on the RPC port. The default handler
+	// is used when the probe doesn't specify one.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// (Optional) The time which the JobManager has to shut down cleanly on
+	// SIGTERM, e.g., when its node is drained, before it is killed, default:
+	// 30. The shutdown timeout of the Flink cluster services is derived from
+	// the shortest grace period of the JobManager and TaskManagers.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/pod-lifecycle/#pod-termination
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
 	// Sidecar containers running alongside with the JobManager container in the
-	// pod.
+	// pod, e.g., log shippers, metrics exporters or auth proxies. The
+	// `jobmanager` container name is reserved.
 	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// Init containers of the JobManager pod, e.g., to fetch artifacts or wait
+	// for dependencies before the JobManager starts. The JobManager volume
+	// mounts are added to each init container unless it mounts another volume
+	// at the same path.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/init-containers/
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// (Optional) Pod template of the JobManager pod, e.g., labels, annotations,
+	// scheduling constraints and extra containers. The fields managed by the
+	// operator take precedence over the template, and the `jobmanager`
+	// container name and the JobManager ports are reserved.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/pod-overview/#pod-templates
+	PodTemplate *corev1.PodTemplateSpec `json:"podTemplate,omitempty"`
+
+	// (Optional) Labels of the JobManager resources, i.e., the deployment or
+	// StatefulSet, pods, services, ingress, PodDisruptionBudget and
+	// NetworkPolicy, they override the cluster `labels`.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// (Optional) Annotations of the JobManager resources, they override the
+	// cluster `annotations`.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // TaskManagerPorts defines ports of TaskManager.
@@ -200,8 +552,16 @@ type TaskManagerPorts struct {
 
 // TaskManagerSpec defines properties of TaskManager.
 type TaskManagerSpec struct {
-	// The number of replicas.
-	Replicas int32 `json:"replicas"`
+	// The number of replicas, default: 1. Setting it to 0 scales the
+	// TaskManagers down to zero, e.g., through `kubectl scale --replicas=0`.
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// (Optional) The maximum time to wait on scale-down for the TaskManagers
+	// to be removed to become idle, i.e., all their task slots are free, so
+	// that the running job is not restarted. The idle TaskManagers of a
+	// deployment are removed first. The TaskManagers are removed without
+	// waiting if it is not set.
+	DecommissionTimeoutSeconds *int32 `json:"decommissionTimeoutSeconds,omitempty"`
 
 	// Ports.
 	Ports TaskManagerPorts `json:"ports,omitempty"`
@@ -226,18 +586,174 @@ type TaskManagerSpec struct {
 	// More info: https://kubernetes.io/docs/concepts/storage/volumes/
 	Volumes []corev1.Volume `json:"volumes,omitempty"`
 
-	// Volume mounts in the TaskManager containers.
+	// Volume mounts in the TaskManager containers, they can also mount the
+	// volumes of `volumeClaimTemplates` by name.
 	// More info: https://kubernetes.io/docs/concepts/storage/volumes/
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
 
+	// (Optional) The kind of the workload which runs the TaskManager pods,
+	// "Deployment" or "StatefulSet", default: "Deployment". A StatefulSet
+	// gives the TaskManagers stable names and hostnames through a headless
+	// service, and the TaskManagers register with their hostnames.
+	DeploymentType *DeploymentType `json:"deploymentType,omitempty"`
+
+	// (Optional) The order in which the pods of the TaskManager StatefulSet
+	// are created and deleted, "OrderedReady" or "Parallel", default:
+	// "Parallel". It requires the "StatefulSet" deployment type.
+	PodManagementPolicy *PodManagementPolicy `json:"podManagementPolicy,omitempty"`
+
+	// (Optional) PersistentVolumeClaim templates of the TaskManager
+	// StatefulSet, each TaskManager gets its own claims, e.g., for RocksDB
+	// local state and spill directories on dedicated persistent or local SSD
+	// volumes. They require the "StatefulSet" deployment type, and the claims
+	// are kept when the TaskManagers or the cluster are deleted.
+	// More info: https://kubernetes.io/docs/concepts/workloads/controllers/statefulset/#volume-claim-templates
+	VolumeClaimTemplates []corev1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+
+	// Environment variables of the TaskManager containers, in addition to the
+	// cluster `envVars`. The names must be unique and must not be set in
+	// `envVars`.
+	// More info: https://kubernetes.io/docs/tasks/inject-data-application/define-environment-variable-container/
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Sources of environment variables of the TaskManager containers, e.g., to
+	// inject credentials from a Secret or tuning flags from a ConfigMap.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-pod-configmap/#configure-all-key-value-pairs-in-a-configmap-as-container-environment-variables
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// Selector which must match a node's labels for the TaskManager pod to be
 	// scheduled on that node.
 	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
+	// Tolerations of the TaskManager pods, e.g., to schedule them on the nodes
+	// of a tainted dedicated node pool.
+	// More info: https://kubernetes.io/docs/concepts/configuration/taint-and-toleration/
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// (Optional) Affinity of the TaskManager pods.
+	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/#affinity-and-anti-affinity
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// (Optional) Topology spread constraints of the TaskManager pods, e.g., to
+	// spread them across zones so that a zone outage doesn't take down most
+	// of the slots of a job. They require Kubernetes 1.19+.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/pod-topology-spread-constraints/
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// (Optional) PodDisruptionBudget of the TaskManager pods, e.g., so that
+	// node drains do not evict all TaskManagers at once.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/disruptions/
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
+	// (Optional) Name of the PriorityClass of the TaskManager pods.
+	// More info: https://kubernetes.io/docs/concepts/configuration/pod-priority-preemption/
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+
+	// (Optional) GPUs of each TaskManager. The operator requests the GPUs for
+	// the TaskManager container and configures them as an external resource
+	// of Flink, so that the operators of the jobs can discover them.
+	GPU *GPUSpec `json:"gpu,omitempty"`
+
+	// (Optional) Pod-level security context of the TaskManager pods, e.g., to
+	// run as a non-root user.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/security-context/
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// (Optional) Security context of the TaskManager container, e.g., to drop
+	// capabilities or make the root filesystem read-only.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/security-context/
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+
+	// (Optional) Readiness probe of the TaskManager container, default: TCP
+	// on the RPC port. The default handler is used when the probe doesn't
+	// specify one, e.g., to only tune the thresholds.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// (Optional) Liveness probe of the TaskManager container, which restarts
+	// a wedged TaskManager, default: TCP on the RPC port. The default handler
+	// is used when the probe doesn't specify one.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// (Optional) The time which the TaskManager has to shut down cleanly on
+	// SIGTERM, e.g., when its node is drained, before it is killed, default:
+	// 30. The shutdown timeout of the Flink cluster services is derived from
+	// the shortest grace period of the JobManager and TaskManagers.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/pod-lifecycle/#pod-termination
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
 	// Sidecar containers running alongside with the TaskManager container in the
-	// pod.
+	// pod, e.g., log shippers, metrics exporters or auth proxies. The
+	// `taskmanager` container name is reserved.
 	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// Init containers of the TaskManager pods, e.g., to fetch artifacts or
+	// wait for dependencies before the TaskManager starts. The TaskManager
+	// volume mounts are added to each init container unless it mounts another
+	// volume at the same path.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/init-containers/
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// (Optional) Pod template of the TaskManager pods, e.g., labels,
+	// annotations, scheduling constraints and extra containers. The fields
+	// managed by the operator take precedence over the template, and the
+	// `taskmanager` container name and the TaskManager ports are reserved.
+	// More info: https://kubernetes.io/docs/concepts/workloads/pods/pod-overview/#pod-templates
+	PodTemplate *corev1.PodTemplateSpec `json:"podTemplate,omitempty"`
+
+	// (Optional) Labels of the TaskManager resources, i.e., the deployment or
+	// StatefulSet, pods, headless service, PodDisruptionBudget and
+	// NetworkPolicy, they override the cluster `labels`.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// (Optional) Annotations of the TaskManager resources, they override the
+	// cluster `annotations`.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// UnsatisfiableConstraintAction defines how to deal with a pod which doesn't
+// satisfy a topology spread constraint.
+type UnsatisfiableConstraintAction = string
+
+// UnsatisfiableConstraintAction types.
+const (
+	UnsatisfiableConstraintActionDoNotSchedule  = "DoNotSchedule"
+	UnsatisfiableConstraintActionScheduleAnyway = "ScheduleAnyway"
+)
+
+// TopologySpreadConstraint defines how the TaskManager pods are spread across
+// the topology domains, like the `topologySpreadConstraints` of a pod spec.
+type TopologySpreadConstraint struct {
+	// The maximum difference of the number of matching pods between any two
+	// topology domains, it must be >= 1.
+	MaxSkew int32 `json:"maxSkew"`
+
+	// The node label whose values are the topology domains, e.g.,
+	// `topology.kubernetes.io/zone` or `kubernetes.io/hostname`.
+	TopologyKey string `json:"topologyKey"`
+
+	// How to deal with a pod which doesn't satisfy the constraint,
+	// "DoNotSchedule" or "ScheduleAnyway".
+	WhenUnsatisfiable UnsatisfiableConstraintAction `json:"whenUnsatisfiable"`
+
+	// (Optional) The pods which are counted in each topology domain, default:
+	// the TaskManager pods of the cluster.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// PodDisruptionBudgetSpec defines the PodDisruptionBudget of the pods of a
+// component, exactly one of `minAvailable` and `maxUnavailable` must be
+// specified.
+type PodDisruptionBudgetSpec struct {
+	// (Optional) The number or percentage of the pods which must remain
+	// available during an eviction, e.g., 2 or "50%".
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// (Optional) The number or percentage of the pods which can be unavailable
+	// during an eviction, e.g., 1 or "25%".
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
 // CleanupAction defines the action to take after job finishes.
@@ -262,14 +778,90 @@ type CleanupPolicy struct {
 	AfterJobCancelled CleanupAction `json:"afterJobCancelled,omitempty"`
 }
 
+// CheckpointingSpec defines the checkpointing of the job, it is translated
+// into the `execution.checkpointing.*` properties of Flink 1.10+.
+// More info: https://ci.apache.org/projects/flink/flink-docs-stable/deployment/config.html#checkpointing
+type CheckpointingSpec struct {
+	// Interval between two checkpoints in seconds, it must be >= 1.
+	IntervalSeconds int32 `json:"intervalSeconds"`
+
+	// (Optional) Checkpointing mode, "ExactlyOnce" or "AtLeastOnce",
+	// default: "ExactlyOnce".
+	Mode *CheckpointingMode `json:"mode,omitempty"`
+
+	// (Optional) Timeout in seconds after which an in-progress checkpoint is
+	// aborted, default: 600.
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// (Optional) Minimum pause in seconds between the end of a checkpoint and
+	// the start of the next one, default: 0.
+	MinPauseSeconds *int32 `json:"minPauseSeconds,omitempty"`
+
+	// (Optional) Whether externalized checkpoints are retained when the job is
+	// cancelled, "RetainOnCancellation" or "DeleteOnCancellation". If
+	// unspecified, checkpoints are not externalized. Retained checkpoints can
+	// be used by the "FromSavepointOnFailure" restart policy.
+	ExternalizedRetention *CheckpointRetention `json:"externalizedRetention,omitempty"`
+
+	// (Optional) Enable unaligned checkpoints, which requires Flink 1.11+ and
+	// the "ExactlyOnce" mode, default: false.
+	Unaligned *bool `json:"unaligned,omitempty"`
+}
+
 // JobSpec defines properties of a Flink job.
 type JobSpec struct {
-	// JAR file of the job.
-	JarFile string `json:"jarFile"`
+	// JAR file of the job, a local path in the image or a volume, or a remote
+	// http(s)://, gs:// or s3:// URI. A remote JAR file is downloaded to a
+	// shared volume by an init container injected by the operator. Exactly one
+	// of `jarFile`, `pythonFile`, `sql` and `sqlConfigMap` must be specified.
+	JarFile string `json:"jarFile,omitempty"`
+
+	// (Optional) SHA-256 checksum of the remote JAR file in hex, the
+	// downloaded JAR file is verified against it before the job is submitted.
+	JarSha256 *string `json:"jarSha256,omitempty"`
+
+	// (Optional) The image of the init container downloading the remote JAR
+	// file, e.g., a mirror in a private registry. It must provide `gsutil`,
+	// `aws` or `curl` for the gs://, s3:// or http(s):// URI respectively.
+	JarDownloaderImage *string `json:"jarDownloaderImage,omitempty"`
+
+	// Python entry point file of a PyFlink job, a local path in the image or a
+	// volume. The job is submitted with `flink run --python`.
+	PythonFile *string `json:"pythonFile,omitempty"`
+
+	// (Optional) Extra Python files of a PyFlink job, e.g., .py, .zip or .egg
+	// files, which are added to the PYTHONPATH of the job.
+	PythonFiles []string `json:"pythonFiles,omitempty"`
+
+	// (Optional) The requirements.txt file of the third-party Python
+	// dependencies of a PyFlink job, which are installed before the job runs.
+	PythonRequirements *string `json:"pythonRequirements,omitempty"`
+
+	// Flink SQL statements of a SQL job, separated by semicolons. The
+	// statements are executed against the cluster by the SQL client in the
+	// job submitter, the SQL client of Flink 1.13 or later is required.
+	SQL *string `json:"sql,omitempty"`
+
+	// Key of a ConfigMap in the cluster namespace which holds the Flink SQL
+	// statements of a SQL job, an alternative to `sql` for long scripts.
+	SQLConfigMap *corev1.ConfigMapKeySelector `json:"sqlConfigMap,omitempty"`
 
 	// Fully qualified Java class name of the job.
 	ClassName *string `json:"className,omitempty"`
 
+	// How the job is submitted, "Submitter" or "RestAPI", default:
+	// "Submitter".
+	//
+	// "Submitter" means the job is submitted with the Flink CLI in a separate
+	// Kubernetes job.
+	//
+	// "RestAPI" means the operator downloads the JAR file, uploads it to the
+	// JobManager and runs the job through the Flink REST API with a job ID
+	// derived from the cluster and the job spec. The `jarFile` must be an HTTP
+	// or HTTPS URL, and the `volumes`, `volumeMounts`, `initContainers` and
+	// `noLoggingToStdout` properties are ignored in this mode.
+	SubmissionMode *JobSubmissionMode `json:"submissionMode,omitempty"`
+
 	// Args of the job.
 	Args []string `json:"args,omitempty"`
 
@@ -282,9 +874,39 @@ type JobSpec struct {
 	// Savepoints dir where to store savepoints of the job.
 	SavepointsDir *string `json:"savepointsDir,omitempty"`
 
-	// Automatically take a savepoint to the `savepointsDir` every n seconds.
+	// (Optional) Whether the running job is stopped with a savepoint to
+	// `savepointsDir` before it is upgraded, default: true. The job spec
+	// cannot be updated without `savepointsDir` unless it is false, in which
+	// case the job is cancelled and resubmitted without its state.
+	TakeSavepointOnUpgrade *bool `json:"takeSavepointOnUpgrade,omitempty"`
+
+	// Automatically take a savepoint to the `savepointsDir` every n seconds,
+	// `savepointsDir` is required when it is specified. The location and time
+	// of the latest savepoint are recorded in the job status.
 	AutoSavepointSeconds *int32 `json:"autoSavepointSeconds,omitempty"`
 
+	// (Optional) The number of the latest savepoints to retain, the locations
+	// are recorded in `jobStatus.savepointHistory` and the directories of the
+	// older savepoints in GCS or S3 are deleted by the operator. Savepoints
+	// are retained indefinitely when it is not specified.
+	SavepointHistoryLimit *int32 `json:"savepointHistoryLimit,omitempty"`
+
+	// (Optional) The seconds after the start of an upgrade within which the
+	// upgraded job must reach the RUNNING state, otherwise the cluster spec is
+	// rolled back to the one the previous job ran with, and the job is restored from the savepoint taken
+	// before the upgrade. `savepointsDir` is required when it is specified.
+	UpgradeTimeoutSeconds *int32 `json:"upgradeTimeoutSeconds,omitempty"`
+
+	// (Optional) The maximum number of retries of a savepoint which fails or
+	// doesn't complete within `timeouts.savepointSeconds`, default: 0. The
+	// job cancellation or upgrade which waits for the savepoint fails only
+	// after the retries are exhausted.
+	SavepointMaxRetries *int32 `json:"savepointMaxRetries,omitempty"`
+
+	// The backoff in seconds before the first retry of a failed savepoint, it
+	// doubles for each further retry up to 300, default: 10.
+	SavepointRetryBackoffSeconds *int32 `json:"savepointRetryBackoffSeconds,omitempty"`
+
 	// Update this field to `jobStatus.savepointGeneration + 1` for a running job
 	// cluster to trigger a new savepoint to `savepointsDir` on demand.
 	SavepointGeneration int32 `json:"savepointGeneration,omitempty"`
@@ -292,6 +914,12 @@ type JobSpec struct {
 	// Job parallelism, default: 1.
 	Parallelism *int32 `json:"parallelism,omitempty"`
 
+	// (Optional) The number of available task slots registered with the
+	// JobManager, which is required before the job is submitted, e.g., the job
+	// parallelism. The job waits for the TaskManagers on slow-scaling node
+	// pools instead of failing with NoResourceAvailableException.
+	ReadySlotsRequired *int32 `json:"readySlotsRequired,omitempty"`
+
 	// No logging output to STDOUT, default: false.
 	NoLoggingToStdout *bool `json:"noLoggingToStdout,omitempty"`
 
@@ -303,12 +931,89 @@ type JobSpec struct {
 	// More info: https://kubernetes.io/docs/concepts/storage/volumes/
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
 
+	// Environment variables of the Job container and the jar downloader init
+	// container, in addition to the cluster `envVars`. The names must be
+	// unique and must not be set in `envVars`.
+	// More info: https://kubernetes.io/docs/tasks/inject-data-application/define-environment-variable-container/
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Sources of environment variables of the Job container and the jar
+	// downloader init container, e.g., to inject credentials from a Secret.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-pod-configmap/#configure-all-key-value-pairs-in-a-configmap-as-container-environment-variables
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// Init containers of the Job pod. A typical use case could be using an init
 	// container to download a remote job jar to a local path which is
 	// referenced by the `jarFile` property.
 	// More info: https://kubernetes.io/docs/concepts/workloads/pods/init-containers/
 	InitContainers []corev1.Container `json:"initContainers,omitempty"`
 
+	// Selector which must match a node's labels for the Job pod to be
+	// scheduled on that node.
+	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the Job pod.
+	// More info: https://kubernetes.io/docs/concepts/configuration/taint-and-toleration/
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// (Optional) Affinity of the Job pod.
+	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/#affinity-and-anti-affinity
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// (Optional) Name of the PriorityClass of the Job pod.
+	// More info: https://kubernetes.io/docs/concepts/configuration/pod-priority-preemption/
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+
+	// Compute resources of the Job container, which runs the Flink CLI and
+	// may need more memory than the namespace defaults, e.g., to build the job
+	// graph of a large job.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// (Optional) The name of the ServiceAccount which the Job pod runs as,
+	// default: the cluster `serviceAccountName`.
+	ServiceAccountName *string `json:"serviceAccountName,omitempty"`
+
+	// (Optional) Labels of the Job pod, in addition to the labels managed by
+	// the operator, which cannot be overridden.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// (Optional) Annotations of the Job pod, e.g., to inject secrets with the
+	// Vault Agent injector.
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// (Optional) Labels of the Job and its pod, they override the cluster
+	// `labels` and are overridden by `podLabels` on the pod.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// (Optional) Annotations of the Job and its pod, they override the
+	// cluster `annotations` and are overridden by `podAnnotations` on the pod.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// (Optional) Absolute paths of the files which the job submitter waits
+	// for before it submits the job, e.g., the secrets which Vault Agent or
+	// another secret injector sidecar writes into a shared volume. The
+	// submission fails if they don't exist within 5 minutes.
+	WaitForFiles []string `json:"waitForFiles,omitempty"`
+
+	// (Optional) HTTP URLs which the job submitter sends a POST request to
+	// when it exits, to stop the sidecars of its pod, e.g.,
+	// "http://127.0.0.1:15020/quitquitquit", otherwise the pod doesn't
+	// complete while they are running.
+	SidecarShutdownURLs []string `json:"sidecarShutdownURLs,omitempty"`
+
+	// (Optional) Pod-level security context of the Job pod, e.g., to run as a
+	// non-root user.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/security-context/
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// (Optional) Security context of the Job container and the jar downloader
+	// init container, e.g., to drop capabilities or make the root filesystem
+	// read-only.
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/security-context/
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+
 	// Restart policy when the job fails, "Never" or "FromSavepointOnFailure",
 	// default: "Never".
 	//
@@ -316,18 +1021,85 @@ type JobSpec struct {
 	// cleanup and restart is required.
 	//
 	// "FromSavepointOnFailure" means the operator will try to restart the failed
-	// job from the savepoint recorded in the job status if available; otherwise,
-	// the job will stay in failed state. This option is usually used together
-	// with `autoSavepointSeconds` and `savepointsDir`.
+	// job from the latest externalized checkpoint or savepoint recorded in the
+	// job status if available; otherwise, the job will stay in failed state.
+	// This option is usually used together with `autoSavepointSeconds` and
+	// `savepointsDir`, or with retained checkpoints.
 	RestartPolicy *JobRestartPolicy `json:"restartPolicy"`
 
+	// (Optional) The maximum age in seconds of the checkpoint or savepoint
+	// which a failed job is restarted from with the "FromSavepointOnFailure"
+	// restart policy. The job stays in failed state if the latest one is older.
+	MaxStateAgeToRestoreSeconds *int32 `json:"maxStateAgeToRestoreSeconds,omitempty"`
+
+	// (Optional) The maximum number of retries of a failed job submission,
+	// default: 0. The submission fails when the job cannot be submitted to the
+	// JobManager, e.g., it is not ready or its address cannot be resolved.
+	// Failures of the submitted job are handled by the restart policy instead.
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+
+	// The backoff in seconds before the first retry of a failed job
+	// submission, it doubles for each further retry, default: 10.
+	RetryBackoffSeconds *int32 `json:"retryBackoffSeconds,omitempty"`
+
+	// The maximum backoff in seconds between the retries of a failed job
+	// submission, default: 300.
+	MaxRetryBackoffSeconds *int32 `json:"maxRetryBackoffSeconds,omitempty"`
+
 	// The action to take after job finishes.
 	CleanupPolicy *CleanupPolicy `json:"cleanupPolicy,omitempty"`
 
+	// (Optional) Seconds to delay the cleanup action after the job finishes,
+	// during which the web UI and logs of the cluster can still be inspected.
+	// If unspecified, the cleanup action is taken immediately. It doesn't
+	// apply to scheduled jobs.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
 	// Request the job to be cancelled. Only applies to running jobs. If
 	// `savePointsDir` is provided, a savepoint will be taken before stopping the
 	// job.
 	CancelRequested *bool `json:"cancelRequested,omitempty"`
+
+	// (Optional) Checkpointing of the job, the properties are written to
+	// flink-conf.yaml and cannot be set in `flinkProperties`.
+	Checkpointing *CheckpointingSpec `json:"checkpointing,omitempty"`
+
+	// Take a savepoint to `savepointsDir` when the job is cancelled, default:
+	// true. The job is stopped with Flink's stop-with-savepoint API, and the
+	// savepoint location is recorded in the job status for future restores.
+	TakeSavepointOnCancel *bool `json:"takeSavepointOnCancel,omitempty"`
+
+	// (Optional) Stop the job with `drain` when it is cancelled, so that all
+	// the in-flight data is flushed and MAX_WATERMARK is emitted before the
+	// savepoint, e.g., to decommission a pipeline. It requires `savepointsDir`
+	// and `takeSavepointOnCancel`, default: false.
+	StopWithDrain *bool `json:"stopWithDrain,omitempty"`
+
+	// (Optional) Take a final savepoint to `savepointsDir` before the running
+	// job is cancelled when the FlinkCluster is deleted, default: false. The
+	// deletion waits until the savepoint is taken.
+	TakeSavepointOnDelete *bool `json:"takeSavepointOnDelete,omitempty"`
+
+	// (Optional) Schedule of a batch job in the cron syntax in UTC, e.g.,
+	// "0 2 * * *" or "@hourly". The job is submitted again at each scheduled
+	// time, the cleanup policy doesn't apply to the runs of a scheduled job.
+	Schedule *string `json:"schedule,omitempty"`
+
+	// How a scheduled run is handled when the previous run is still in
+	// progress, "Forbid" or "Replace", default: "Forbid".
+	ConcurrencyPolicy *JobConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// The number of successful runs of a scheduled job kept in the run
+	// history of the job status, default: 3.
+	SuccessfulRunsHistoryLimit *int32 `json:"successfulRunsHistoryLimit,omitempty"`
+
+	// The number of failed or cancelled runs of a scheduled job kept in the
+	// run history of the job status, default: 1.
+	FailedRunsHistoryLimit *int32 `json:"failedRunsHistoryLimit,omitempty"`
+
+	// Delete the TaskManagers between the runs of a scheduled job, they are
+	// created again before the next run, default: false.
+	DeleteTaskManagersBetweenRuns *bool `json:"deleteTaskManagersBetweenRuns,omitempty"`
 }
 
 // FlinkClusterSpec defines the desired state of FlinkCluster
@@ -335,6 +1107,33 @@ type FlinkClusterSpec struct {
 	// Flink image spec for the cluster's components.
 	Image ImageSpec `json:"image"`
 
+	// (Optional) Flink version of the image, e.g., "1.12" or "1.12.2". With
+	// Flink 1.10+ the TaskManager, and with Flink 1.11+ the JobManager, are
+	// configured with the unified memory model, the process size is derived
+	// from the memory limit and `memoryOffHeapRatio` and `memoryOffHeapMin`
+	// are ignored. If unspecified, the legacy heap size model is used.
+	// More info: https://ci.apache.org/projects/flink/flink-docs-stable/deployment/memory/mem_setup.html
+	FlinkVersion string `json:"flinkVersion,omitempty"`
+
+	// (Optional) The FlinkClusterTemplate in the same namespace whose spec is
+	// deep-merged into this spec when the cluster is created. The properties
+	// of this spec take precedence, the lists of this spec replace those of
+	// the template.
+	TemplateRef *corev1.LocalObjectReference `json:"templateRef,omitempty"`
+
+	// (Optional) Labels of all the resources created by the operator for the
+	// cluster and of their pods, e.g., to identify them by team or app for
+	// cost allocation, logging or policies. They can be overridden for the
+	// resources of each component, and the labels managed by the operator,
+	// e.g., `cluster` and `component`, cannot be overridden.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// (Optional) Annotations of all the resources created by the operator for
+	// the cluster and of their pods. They can be overridden for the resources
+	// of each component, and the annotations managed by the operator take
+	// precedence.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
 	// Flink JobManager spec.
 	JobManager JobManagerSpec `json:"jobManager"`
 
@@ -350,26 +1149,587 @@ type FlinkClusterSpec struct {
 	// containers.
 	EnvVars []corev1.EnvVar `json:"envVars,omitempty"`
 
-	// Flink properties which are appened to flink-conf.yaml.
+	// Flink properties which are appened to flink-conf.yaml. Properties managed
+	// by the operator (addresses, ports and memory sizes derived from the
+	// resource limits) cannot be overridden. The JobManager and TaskManager
+	// are restarted when the properties are updated.
 	FlinkProperties map[string]string `json:"flinkProperties,omitempty"`
 
+	// (Optional) Flink properties whose values are read from Secrets, so that
+	// credentials, e.g., `s3.secret-key`, are not written to the CR or the
+	// ConfigMap. They are passed to the JobManager and TaskManagers as dynamic
+	// properties from environment variables, which Kubernetes substitutes when
+	// the containers start.
+	FlinkPropertiesFrom []FlinkPropertySource `json:"flinkPropertiesFrom,omitempty"`
+
+	// (Optional) Log config files which are put in the Flink conf directory,
+	// keyed by file name, e.g., "log4j-console.properties" or
+	// "logback-console.xml". They replace the default files of the same name.
+	// The JobManager and TaskManager are restarted when the files are updated.
+	LogConfig map[string]string `json:"logConfig,omitempty"`
+
 	// Config for Hadoop.
 	HadoopConfig *HadoopConfig `json:"hadoopConfig,omitempty"`
 
 	// Config for GCP.
 	GCPConfig *GCPConfig `json:"gcpConfig,omitempty"`
+
+	// (Optional) The name of the ServiceAccount which the JobManager,
+	// TaskManager and job pods run as. When the Kubernetes HA services are
+	// enabled, the operator grants the HA permissions to it instead of
+	// creating a ServiceAccount for the cluster.
+	ServiceAccountName *string `json:"serviceAccountName,omitempty"`
+
+	// (Optional) Whether the ServiceAccount token is mounted into the
+	// JobManager, TaskManager and job pods, default: the setting of the
+	// ServiceAccount. It cannot be false when the Kubernetes HA services are
+	// enabled.
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+
+	// (Optional) High availability config of the JobManager. If specified,
+	// the JobManager is run with Flink's Kubernetes HA services, and recovers
+	// the running jobs after it is restarted.
+	HighAvailability *HighAvailabilitySpec `json:"highAvailability,omitempty"`
+
+	// (Optional) Autoscaler of the job, only applies to job clusters.
+	Autoscaler *AutoscalerSpec `json:"autoscaler,omitempty"`
+
+	// (Optional) Metric reporters of the JobManager and TaskManagers.
+	Metrics *MetricsSpec `json:"metrics,omitempty"`
+
+	// (Optional) File systems of the checkpoint, savepoint and HA storage.
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// (Optional) State backend of the jobs, the properties are written to
+	// flink-conf.yaml and cannot be set in `flinkProperties`.
+	StateBackend *StateBackendSpec `json:"stateBackend,omitempty"`
+
+	// (Optional) NetworkPolicies which restrict the ingress traffic of the
+	// JobManager and TaskManager pods.
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// (Optional) Compatibility with a service mesh which injects a sidecar
+	// proxy into the pods.
+	ServiceMesh *ServiceMeshSpec `json:"serviceMesh,omitempty"`
+
+	// (Optional) Security of the connections of the JobManager and
+	// TaskManagers.
+	Security *SecuritySpec `json:"security,omitempty"`
+
+	// (Optional) Batch scheduler which schedules the JobManager and
+	// TaskManager pods as a gang, so that they are started only if all of them
+	// fit in the Kubernetes cluster.
+	BatchScheduler *BatchSchedulerSpec `json:"batchScheduler,omitempty"`
+
+	// (Optional) Flink History Server, which serves the details of the
+	// completed jobs from their archives, so that they survive the cleanup of
+	// the cluster.
+	HistoryServer *HistoryServerSpec `json:"historyServer,omitempty"`
+
+	// (Optional) Idle timeout of a session cluster in seconds. When no job has
+	// been running in the cluster for the timeout, the `idleTimeoutAction` is
+	// taken to save the cost of the idle cluster.
+	IdleTimeoutSeconds *int32 `json:"idleTimeoutSeconds,omitempty"`
+
+	// (Optional) Action to take after the idle timeout of a session cluster,
+	// `DeleteTaskManager` or `DeleteCluster`, default: `DeleteTaskManager`.
+	// The TaskManagers deleted after the timeout are created again when a job
+	// is submitted to the JobManager.
+	IdleTimeoutAction CleanupAction `json:"idleTimeoutAction,omitempty"`
+
+	// (Optional) How the changes of the spec are rolled out, `InPlace` or
+	// `BlueGreen`, default: `InPlace`. With `BlueGreen`, the operator runs
+	// the cluster as a child FlinkCluster named `<name>-blue` or
+	// `<name>-green`. An update takes a savepoint of the running job, deploys
+	// the updated job in the other child from the savepoint, and deletes the
+	// old child once the new job is verified. The strategy cannot be changed
+	// after the cluster is created.
+	UpdateStrategy *UpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// (Optional) Verification of the new cluster of a `BlueGreen` update.
+	BlueGreen *BlueGreenSpec `json:"blueGreen,omitempty"`
+
+	// (Optional) An arbitrary value, changing it restarts the cluster without
+	// any other spec change, e.g., to pick up rotated secrets or changed
+	// ConfigMaps mounted in the pods. The running job is stopped with a
+	// savepoint, the JobManager and TaskManager pods are restarted, then the
+	// job is resubmitted from the savepoint.
+	RestartNonce string `json:"restartNonce,omitempty"`
+
+	// (Optional) ConfigMaps and Secrets whose changes restart the pods which
+	// reference them, e.g., rotated credentials or config files mounted in
+	// the pods.
+	RestartOnChange *RestartOnChangeSpec `json:"restartOnChange,omitempty"`
+
+	// (Optional) Timeouts of the lifecycle phases of the cluster, a phase
+	// which doesn't complete in time fails instead of hanging forever.
+	Timeouts *TimeoutsSpec `json:"timeouts,omitempty"`
+}
+
+// RestartOnChangeSpec defines the ConfigMaps and Secrets in the namespace of
+// the cluster which the JobManager, TaskManager and History Server pods are
+// restarted on, when their data changes. Only the pods which reference them
+// through volumes or environment variables are restarted, and the running job
+// is not stopped with a savepoint, use `restartNonce` for that.
+type RestartOnChangeSpec struct {
+	// (Optional) Names of the ConfigMaps.
+	ConfigMaps []string `json:"configMaps,omitempty"`
+
+	// (Optional) Names of the Secrets.
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// TimeoutsSpec defines the timeouts of the lifecycle phases of the cluster.
+// The phase which timed out is recorded in the status and reported by the
+// `TimedOut` condition.
+type TimeoutsSpec struct {
+	// (Optional) Seconds for the cluster to be running after it is created.
+	// The job of a job cluster fails and it is not submitted if the cluster
+	// doesn't start in time, then the cluster is cleaned up according to
+	// `job.cleanupPolicy.afterJobFails`.
+	ClusterStartupSeconds *int32 `json:"clusterStartupSeconds,omitempty"`
+
+	// (Optional) Seconds for the job submitter to submit the job after it is
+	// created, the job fails if it is not submitted in time.
+	JobSubmissionSeconds *int32 `json:"jobSubmissionSeconds,omitempty"`
+
+	// (Optional) Seconds for a savepoint to complete after it is triggered,
+	// default: 60.
+	SavepointSeconds *int32 `json:"savepointSeconds,omitempty"`
+
+	// (Optional) Seconds for the `job-cancel` control to complete, the
+	// control fails if the job is not cancelled in time.
+	CancellationSeconds *int32 `json:"cancellationSeconds,omitempty"`
+
+	// (Optional) Seconds for the running job to be stopped for an upgrade,
+	// the upgrade fails and the job keeps running with the previous spec if
+	// it is not stopped in time.
+	UpgradeSeconds *int32 `json:"upgradeSeconds,omitempty"`
+}
+
+// BlueGreenSpec defines how the new cluster of a blue/green update is
+// verified before the traffic is switched to it and the old cluster is
+// deleted. The update is rolled back by deleting the new cluster if its job
+// fails or it is not verified before the deadline.
+type BlueGreenSpec struct {
+	// (Optional) Seconds the job of the new cluster must keep running before
+	// it is verified, default: 60.
+	StableSeconds *int32 `json:"stableSeconds,omitempty"`
+
+	// (Optional) URL which must return a 2xx response to an HTTP GET before
+	// the new cluster is verified, `{cluster}` in the URL is replaced with the
+	// name of the new cluster, e.g.,
+	// `http://{cluster}-jobmanager:8081/jobs/overview`.
+	HealthCheckURL *string `json:"healthCheckURL,omitempty"`
+
+	// (Optional) Seconds after which the update is rolled back if the new
+	// cluster has not been verified, default: 600.
+	DeadlineSeconds *int32 `json:"deadlineSeconds,omitempty"`
+}
+
+// AutoscalerSpec defines the autoscaling policy of a job. The operator polls
+// the metrics of the running job through the Flink REST API, and rescales the
+// job within the bounds by taking a savepoint and resubmitting the job with
+// the new parallelism. TaskManager replicas are adjusted to provide enough
+// task slots for the new parallelism.
+type AutoscalerSpec struct {
+	// Minimum parallelism of the job, default: 1.
+	MinParallelism *int32 `json:"minParallelism,omitempty"`
+
+	// Maximum parallelism of the job.
+	MaxParallelism int32 `json:"maxParallelism"`
+
+	// Target percentage of busy time of the busiest operator, the job is
+	// scaled up when it is busier and scaled down when it is less busy,
+	// default: 70.
+	TargetBusyPercent *int32 `json:"targetBusyPercent,omitempty"`
+
+	// (Optional) Maximum total consumer lag of the sources in records, the job
+	// is scaled up when it is exceeded.
+	MaxConsumerLag *int64 `json:"maxConsumerLag,omitempty"`
+
+	// Minimum interval between two rescales in seconds, default: 300.
+	StabilizationSeconds *int32 `json:"stabilizationSeconds,omitempty"`
+}
+
+// MetricsSpec defines the metric reporters of the JobManager and
+// TaskManagers.
+type MetricsSpec struct {
+	// (Optional) Prometheus metric reporter.
+	Prometheus *PrometheusMetricsSpec `json:"prometheus,omitempty"`
+}
+
+// PrometheusMetricsSpec defines the Prometheus metric reporter. The operator
+// configures the PrometheusReporter in flink-conf.yaml and exposes its port
+// on the JobManager and TaskManager pods. The flink-metrics-prometheus JAR
+// must be available in the Flink image.
+type PrometheusMetricsSpec struct {
+	// Port of the metric reporter, default: 9249.
+	Port *int32 `json:"port,omitempty"`
+
+	// (Optional) PodMonitor of the Prometheus Operator which scrapes the
+	// JobManager and TaskManager pods. The PodMonitor CRD must be installed.
+	PodMonitor *PodMonitorSpec `json:"podMonitor,omitempty"`
+}
+
+// PodMonitorSpec defines the PodMonitor created for the Prometheus Operator.
+type PodMonitorSpec struct {
+	// Labels of the PodMonitor, e.g., to be selected by the Prometheus
+	// resource.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Scrape interval, e.g., `30s`, default: the interval of Prometheus.
+	Interval *string `json:"interval,omitempty"`
+}
+
+// GPUSpec defines the GPUs of each TaskManager. The operator sets the
+// `external-resources` and `external-resource.gpu.*` properties in
+// flink-conf.yaml, and enables the GPU plugin of the Flink image through the
+// ENABLE_BUILT_IN_PLUGINS env var, so `flinkVersion` must be 1.11+ and
+// include the patch version, e.g., "1.12.2".
+// More info: https://ci.apache.org/projects/flink/flink-docs-stable/deployment/advanced/external_resources.html
+type GPUSpec struct {
+	// Number of GPUs of each TaskManager.
+	Amount int32 `json:"amount"`
+
+	// (Optional) Extended resource name of the GPUs in Kubernetes, default:
+	// "nvidia.com/gpu".
+	ResourceName *string `json:"resourceName,omitempty"`
+
+	// (Optional) Path of the GPU discovery script, relative to the Flink home
+	// directory or absolute, default: the NVIDIA discovery script of the Flink
+	// image, "opt/external-resource-gpu/nvidia-gpu-discovery.sh".
+	DiscoveryScriptPath *string `json:"discoveryScriptPath,omitempty"`
+
+	// (Optional) Arguments of the GPU discovery script, e.g.,
+	// "--enable-coordination-mode".
+	DiscoveryScriptArgs *string `json:"discoveryScriptArgs,omitempty"`
+}
+
+// StorageSpec defines the file systems which the JobManager, TaskManager and
+// job containers use to access the checkpoint, savepoint and HA storage.
+type StorageSpec struct {
+	// Built-in file system plugins of the Flink image to enable, e.g.,
+	// "s3-fs-presto" for s3:// URIs or "azure-fs-hadoop" for wasb:// URIs.
+	// The plugin JARs of the Flink version are enabled through the
+	// ENABLE_BUILT_IN_PLUGINS env var of the image, so `flinkVersion` must be
+	// specified with the patch version, e.g., "1.12.2".
+	// More info: https://ci.apache.org/projects/flink/flink-docs-stable/deployment/filesystems/plugins.html
+	Plugins []string `json:"plugins,omitempty"`
+}
+
+// NetworkPolicySpec defines the NetworkPolicies of the JobManager and
+// TaskManager pods. The pods of the cluster can reach each other on the
+// JobManager and TaskManager ports, the operator can reach the JobManager REST
+// API, and all the other ingress traffic is denied except for the UI from the
+// given CIDRs. More traffic can be allowed by additional NetworkPolicies.
+// More info: https://kubernetes.io/docs/concepts/services-networking/network-policies/
+type NetworkPolicySpec struct {
+	// (Optional) CIDRs which are allowed to access the JobManager UI and REST
+	// API, e.g., "10.0.0.0/8".
+	UIIngressCIDRs []string `json:"uiIngressCIDRs,omitempty"`
+
+	// (Optional) Selector of the namespaces in which the operator pods are
+	// allowed to access the JobManager REST API, default: all namespaces.
+	OperatorNamespaceSelector *metav1.LabelSelector `json:"operatorNamespaceSelector,omitempty"`
+}
+
+// ServiceMeshSpec defines the compatibility of the cluster with a service mesh.
+type ServiceMeshSpec struct {
+	// Whether the pods run with the Istio sidecar proxy. The Flink RPC, blob,
+	// data and queryable state ports are excluded from the interception of
+	// the sidecar, as Flink doesn't work through it, while the REST API and
+	// UI stay in the mesh. Flink is started after the sidecar is ready, and
+	// the job submitter stops the sidecar when it exits, so its pod
+	// completes.
+	IstioEnabled bool `json:"istioEnabled,omitempty"`
+}
+
+// SecuritySpec defines the security of the connections of the JobManager and
+// TaskManagers.
+type SecuritySpec struct {
+	// (Optional) TLS of the Flink connections.
+	SSL *SSLSpec `json:"ssl,omitempty"`
+
+	// (Optional) Kerberos authentication of the Flink components to secured
+	// services, e.g., Kafka, HDFS and Hive.
+	Kerberos *KerberosSpec `json:"kerberos,omitempty"`
+}
+
+// KerberosSpec defines the Kerberos credentials of the Flink components. The
+// keytab and krb5.conf are mounted in the JobManager, TaskManager, History
+// Server and job submitter pods, and Flink logs in with the keytab.
+// More info: https://ci.apache.org/projects/flink/flink-docs-stable/deployment/security/security-kerberos.html
+type KerberosSpec struct {
+	// Name of the Secret which contains the keytab.
+	KeytabSecretName string `json:"keytabSecretName"`
+
+	// (Optional) Key of the keytab in the Secret, default: "krb5.keytab".
+	KeytabKey string `json:"keytabKey,omitempty"`
+
+	// Kerberos principal of the keytab, e.g., `flink/admin@EXAMPLE.COM`.
+	Principal string `json:"principal"`
+
+	// (Optional) Name of the ConfigMap which contains krb5.conf, which is
+	// mounted at `/etc/krb5.conf`. If not specified, the krb5.conf of the
+	// image is used.
+	Krb5ConfigMapName string `json:"krb5ConfigMapName,omitempty"`
+
+	// (Optional) Key of krb5.conf in the ConfigMap, default: "krb5.conf".
+	Krb5ConfigKey string `json:"krb5ConfigKey,omitempty"`
+
+	// (Optional) JAAS login contexts which the Kerberos credentials are
+	// provided to, e.g., `Client` for ZooKeeper and `KafkaClient` for Kafka.
+	// HDFS and Hive use the Hadoop login, which doesn't need a context.
+	LoginContexts []string `json:"loginContexts,omitempty"`
+}
+
+// SSLSpec defines the TLS of the Flink connections.
+// More info: https://ci.apache.org/projects/flink/flink-docs-stable/ops/security-ssl.html
+type SSLSpec struct {
+	// (Optional) TLS with mutual authentication of the internal connections,
+	// i.e., the RPC, blob and data connections between the JobManager and
+	// TaskManagers. It requires Flink 1.11+.
+	Internal *InternalSSLSpec `json:"internal,omitempty"`
+
+	// (Optional) TLS of the REST API and UI of the JobManager, which the job
+	// submitter and the operator connect to with HTTPS.
+	REST *RESTSSLSpec `json:"rest,omitempty"`
+}
+
+// InternalSSLSpec defines the keystore and truststore of the internal TLS,
+// which are mounted in the JobManager and TaskManager pods. The passwords are
+// passed to Flink as dynamic properties from the Secret, so they are not
+// written to flink-conf.yaml.
+type InternalSSLSpec struct {
+	// (Optional) Name of the Secret which contains the keystore and
+	// truststore, e.g., a Secret of cert-manager with PKCS12 keystores. If not
+	// specified, the operator generates the Secret `<cluster>-internal-tls`
+	// with a self-signed certificate, which is both the keystore and the
+	// truststore, and a random password.
+	SecretName string `json:"secretName,omitempty"`
+
+	// (Optional) Key of the keystore in the Secret, default: "keystore.p12".
+	KeystoreKey string `json:"keystoreKey,omitempty"`
+
+	// (Optional) Key of the truststore in the Secret, default:
+	// "truststore.p12".
+	TruststoreKey string `json:"truststoreKey,omitempty"`
+
+	// (Optional) Password of the keystore, its key and the truststore,
+	// default: the "password" key of the Secret. It can only be specified with
+	// `secretName`.
+	PasswordSecretKeyRef *corev1.SecretKeySelector `json:"passwordSecretKeyRef,omitempty"`
+}
+
+// RESTSSLSpec defines the certificate of the REST API and UI of the
+// JobManager, either issued by cert-manager or in an existing Secret. The
+// certificate must be valid for the JobManager service, e.g.,
+// `<cluster>-jobmanager.<namespace>.svc.cluster.local`. The keystore and
+// truststore are mounted in the JobManager and job submitter pods, and the
+// operator trusts the CA in the `ca.crt` key of the Secret.
+type RESTSSLSpec struct {
+	// (Optional) Name of an existing Secret which contains the keystore and
+	// truststore, e.g., of a cert-manager Certificate with PKCS12 keystores.
+	// Either `secretName` or `issuerRef` is required.
+	SecretName string `json:"secretName,omitempty"`
+
+	// (Optional) cert-manager issuer of the certificate. The operator requests
+	// the Certificate `<cluster>-rest-tls` for the JobManager service with
+	// PKCS12 keystores in the Secret of the same name, whose password is
+	// generated in the Secret `<cluster>-rest-tls-password`.
+	IssuerRef *CertManagerIssuerRef `json:"issuerRef,omitempty"`
+
+	// (Optional) Key of the keystore in the Secret, default: "keystore.p12".
+	KeystoreKey string `json:"keystoreKey,omitempty"`
+
+	// (Optional) Key of the truststore in the Secret, default:
+	// "truststore.p12".
+	TruststoreKey string `json:"truststoreKey,omitempty"`
+
+	// (Optional) Password of the keystore, its key and the truststore,
+	// default: the "password" key of the Secret. It can only be specified with
+	// `secretName`.
+	PasswordSecretKeyRef *corev1.SecretKeySelector `json:"passwordSecretKeyRef,omitempty"`
+}
+
+// CertManagerIssuerRef references a cert-manager Issuer or ClusterIssuer.
+type CertManagerIssuerRef struct {
+	// Name of the issuer.
+	Name string `json:"name"`
+
+	// (Optional) Kind of the issuer, e.g., "ClusterIssuer", default:
+	// "Issuer".
+	Kind string `json:"kind,omitempty"`
+
+	// (Optional) API group of the issuer, default: "cert-manager.io".
+	Group string `json:"group,omitempty"`
+}
+
+// BatchSchedulerSpec defines the batch scheduler of the JobManager and
+// TaskManager pods. The operator creates a PodGroup whose minimum member is
+// the total number of JobManager and TaskManager replicas, and assigns the
+// pods to it, so that a job does not hang waiting for task slots when only
+// some of the TaskManagers fit. The scheduler and its PodGroup CRD must be
+// installed.
+type BatchSchedulerSpec struct {
+	// Name of the batch scheduler, enum("volcano", "scheduler-plugins").
+	Name BatchSchedulerName `json:"name"`
+
+	// (Optional) Scheduler name of the pods, default: "volcano" for
+	// "volcano", "scheduler-plugins-scheduler" for "scheduler-plugins".
+	SchedulerName *string `json:"schedulerName,omitempty"`
+
+	// (Optional) Queue of the PodGroup, only supported by "volcano",
+	// default: the default queue of Volcano.
+	Queue *string `json:"queue,omitempty"`
+}
+
+// HistoryServerSpec defines the Flink History Server of the cluster. The
+// JobManager uploads the archives of the completed jobs to `archiveDir`, from
+// which the History Server fetches them. The History Server and the ConfigMap
+// are not deleted by the cleanup policy of the job, but with the cluster.
+// More info: https://ci.apache.org/projects/flink/flink-docs-stable/deployment/advanced/historyserver.html
+type HistoryServerSpec struct {
+	// Directory of the job archives, e.g., `gs://my-bucket/completed-jobs/`.
+	ArchiveDir string `json:"archiveDir"`
+
+	// Port of the web UI, default: 8082.
+	Port *int32 `json:"port,omitempty"`
+
+	// (Optional) Interval in seconds between two fetches of the archives,
+	// default: the Flink default, 10 seconds.
+	RefreshIntervalSeconds *int32 `json:"refreshIntervalSeconds,omitempty"`
+
+	// Compute resources of the History Server container.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// (Optional) Service of the History Server, in the same way as the
+	// JobManager service, default type: ClusterIP.
+	Service *JobManagerServiceSpec `json:"service,omitempty"`
+
+	// (Optional) Ingress of the web UI of the History Server, in the same way
+	// as the JobManager ingress.
+	Ingress *JobManagerIngressSpec `json:"ingress,omitempty"`
+
+	// (Optional) Labels of the History Server resources, i.e., the
+	// deployment, pod, service and ingress, they override the cluster
+	// `labels`.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// (Optional) Annotations of the History Server resources, they override
+	// the cluster `annotations`.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// StateBackendSpec defines the state backend of the jobs and the directories
+// of their checkpoints and savepoints.
+// More info: https://ci.apache.org/projects/flink/flink-docs-stable/ops/state/state_backends.html
+type StateBackendSpec struct {
+	// State backend type, enum("hashmap", "rocksdb"). "hashmap" is written as
+	// "filesystem", its name before Flink 1.13, unless `flinkVersion` is 1.13+.
+	Type StateBackendType `json:"type"`
+
+	// (Optional) Directory of the checkpoints, e.g.,
+	// `gs://my-bucket/checkpoints/`.
+	CheckpointDir *string `json:"checkpointDir,omitempty"`
+
+	// (Optional) Default directory of the savepoints which are triggered
+	// without a target directory, e.g., `gs://my-bucket/savepoints/`.
+	SavepointDir *string `json:"savepointDir,omitempty"`
+
+	// (Optional) Take incremental checkpoints, only supported by the "rocksdb"
+	// state backend, default: false.
+	Incremental *bool `json:"incremental,omitempty"`
+
+	// (Optional) Volume of the local directory of RocksDB in the TaskManagers,
+	// only supported by the "rocksdb" state backend. If unspecified, RocksDB
+	// uses the temporary directories of the TaskManagers.
+	RocksDBLocalDir *RocksDBLocalDirSpec `json:"rocksDBLocalDir,omitempty"`
+}
+
+// RocksDBLocalDirSpec defines the volume which is mounted to the TaskManager
+// containers as the local directory of RocksDB. Exactly one of `emptyDir`,
+// `persistentVolumeClaim` and `volumeClaimTemplate` must be specified.
+type RocksDBLocalDirSpec struct {
+	// The mount path of the volume in the TaskManager containers, default:
+	// "/flink-rocksdb".
+	MountPath string `json:"mountPath,omitempty"`
+
+	// (Optional) An emptyDir volume, e.g., with a `sizeLimit`.
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+
+	// (Optional) An existing PersistentVolumeClaim, it is shared by all
+	// TaskManagers, so it must support the ReadWriteMany access mode when there
+	// is more than one TaskManager.
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+
+	// (Optional) Name of one of the `taskManager.volumeClaimTemplates`, so that
+	// each TaskManager keeps its RocksDB files on its own claim.
+	VolumeClaimTemplate string `json:"volumeClaimTemplate,omitempty"`
+}
+
+// FlinkPropertySource defines a Flink property whose value is read from a
+// Secret.
+type FlinkPropertySource struct {
+	// Name of the Flink property, e.g., `s3.secret-key`.
+	Name string `json:"name"`
+
+	// Source of the value of the property.
+	ValueFrom FlinkPropertyValueSource `json:"valueFrom"`
+}
+
+// FlinkPropertyValueSource defines the source of the value of a Flink
+// property.
+type FlinkPropertyValueSource struct {
+	// Key of a Secret in the namespace of the cluster.
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
 // HadoopConfig defines configs for Hadoop.
 type HadoopConfig struct {
 	// The name of the ConfigMap which contains the Hadoop config files.
-	// The ConfigMap must be in the same namespace as the FlinkCluster.
+	// The ConfigMap must be in the same namespace as the FlinkCluster. Either
+	// `configMapName` or `properties` is required.
 	ConfigMapName string `json:"configMapName,omitempty"`
 
+	// Hadoop properties which are rendered into core-site.xml, e.g., the
+	// default file system or the HDFS name node address, an alternative to
+	// `configMapName` without managing a ConfigMap.
+	Properties map[string]string `json:"properties,omitempty"`
+
 	// The path where to mount the Volume of the ConfigMap.
 	MountPath string `json:"mountPath,omitempty"`
 }
 
+// HighAvailabilitySpec defines the high availability config of the
+// JobManager.
+type HighAvailabilitySpec struct {
+	// HA services mode, enum("kubernetes", "zookeeper"), default: "kubernetes".
+	//
+	// "kubernetes" stores the leader information in ConfigMaps managed by
+	// Flink, the operator creates a ServiceAccount with the permissions to
+	// manage them for the JobManager and TaskManager pods. Requires Flink
+	// 1.12+.
+	//
+	// "zookeeper" stores the leader information in the ZooKeeper quorum
+	// specified by `zookeeperQuorum`.
+	Mode HighAvailabilityMode `json:"mode,omitempty"`
+
+	// The directory where the JobManager persists its metadata, e.g.,
+	// `gs://my-bucket/flink-ha/`.
+	StorageDir string `json:"storageDir"`
+
+	// The ZooKeeper quorum address, e.g., `zk-0.zk:2181,zk-1.zk:2181`.
+	// Required in "zookeeper" mode.
+	ZooKeeperQuorum string `json:"zookeeperQuorum,omitempty"`
+
+	// (Optional) The root ZooKeeper node under which the cluster nodes are
+	// placed, e.g., `/flink`.
+	ZooKeeperRootPath string `json:"zookeeperRootPath,omitempty"`
+}
+
 // GCPConfig defines configs for GCP.
 type GCPConfig struct {
 	// GCP service account.
@@ -406,7 +1766,7 @@ type FlinkClusterComponentsStatus struct {
 	ConfigMap FlinkClusterComponentState `json:"configMap"`
 
 	// The state of JobManager deployment.
-	JobManagerDeployment FlinkClusterComponentState `json:"jobManagerDeployment"`
+	JobManagerDeployment JobManagerDeploymentStatus `json:"jobManagerDeployment"`
 
 	// The state of JobManager service.
 	JobManagerService JobManagerServiceStatus `json:"jobManagerService"`
@@ -415,7 +1775,11 @@ type FlinkClusterComponentsStatus struct {
 	JobManagerIngress *JobManagerIngressStatus `json:"jobManagerIngress,omitempty"`
 
 	// The state of TaskManager deployment.
-	TaskManagerDeployment FlinkClusterComponentState `json:"taskManagerDeployment"`
+	TaskManagerDeployment TaskManagerDeploymentStatus `json:"taskManagerDeployment"`
+
+	// The state of the History Server deployment, available only when the
+	// History Server is specified.
+	HistoryServer *FlinkClusterComponentState `json:"historyServer,omitempty"`
 
 	// The status of the job, available only when JobSpec is provided.
 	Job *JobStatus `json:"job,omitempty"`
@@ -450,6 +1814,24 @@ type JobStatus struct {
 	// The state of the Kubernetes job.
 	State string `json:"state"`
 
+	// The state of the Flink job reported by the JobManager, e.g., RUNNING,
+	// RESTARTING, FAILING, FINISHED.
+	FlinkJobState string `json:"flinkJobState,omitempty"`
+
+	// The start time of the Flink job.
+	StartTime string `json:"startTime,omitempty"`
+
+	// The time when the job finished, i.e., succeeded, failed or was
+	// cancelled.
+	CompletionTime string `json:"completionTime,omitempty"`
+
+	// The number of restarts of the Flink job by its restart strategy.
+	FlinkJobRestarts int32 `json:"flinkJobRestarts,omitempty"`
+
+	// The root exceptions of the recent failures of the Flink job prefixed
+	// with their timestamps, the latest one is the last.
+	FailureReasons []string `json:"failureReasons,omitempty"`
+
 	// The actual savepoint from which this job started.
 	// In case of restart, it might be different from the savepoint in the job
 	// spec.
@@ -463,14 +1845,161 @@ type JobStatus struct {
 	// Savepoint location.
 	SavepointLocation string `json:"savepointLocation,omitempty"`
 
+	// The locations of the retained savepoints from the oldest to the latest,
+	// recorded when `savepointHistoryLimit` is specified.
+	SavepointHistory []string `json:"savepointHistory,omitempty"`
+
+	// The number of consecutive failed attempts to delete the expired
+	// savepoints, the deletion is retried with a backoff.
+	SavepointDeletionFailures int32 `json:"savepointDeletionFailures,omitempty"`
+
+	// The time of the last failed attempt to delete the expired savepoints.
+	LastSavepointDeletionFailureTime string `json:"lastSavepointDeletionFailureTime,omitempty"`
+
 	// Last savepoint trigger ID.
 	LastSavepointTriggerID string `json:"lastSavepointTriggerID,omitempty"`
 
 	// Last successful or failed savepoint operation timestamp.
 	LastSavepointTime string `json:"lastSavepointTime,omitempty"`
 
+	// The location of the latest externalized checkpoint of the Flink job.
+	LastCheckpointLocation string `json:"lastCheckpointLocation,omitempty"`
+
+	// The completion timestamp of the latest externalized checkpoint.
+	LastCheckpointTime string `json:"lastCheckpointTime,omitempty"`
+
+	// The ID of the latest completed checkpoint of the Flink job, externalized
+	// or not.
+	LastCompletedCheckpointID int64 `json:"lastCompletedCheckpointID,omitempty"`
+
+	// The completion timestamp of the latest completed checkpoint, how far
+	// behind the state would be if the job recovered now.
+	LastCompletedCheckpointTime string `json:"lastCompletedCheckpointTime,omitempty"`
+
+	// The size of the state of the latest completed checkpoint in bytes.
+	LastCompletedCheckpointSize int64 `json:"lastCompletedCheckpointSize,omitempty"`
+
+	// The final user accumulators of the finished Flink job by their names,
+	// e.g., the counters of the processed records. At most 32 of them are
+	// recorded and the values longer than 256 characters are truncated.
+	Accumulators map[string]string `json:"accumulators,omitempty"`
+
 	// The number of restarts.
 	RestartCount int32 `json:"restartCount,omitempty"`
+
+	// The number of failed attempts to submit the job, it is reset when the
+	// job is restarted.
+	SubmissionAttempts int32 `json:"submissionAttempts,omitempty"`
+
+	// The error of the last failed job submission.
+	LastSubmissionError string `json:"lastSubmissionError,omitempty"`
+
+	// The time of the last failed job submission.
+	LastSubmissionErrorTime string `json:"lastSubmissionErrorTime,omitempty"`
+
+	// The termination message of the last failed container of the job
+	// submitter, which falls back to the last lines of its logs, so that it is
+	// available after the pod is garbage collected.
+	SubmissionFailureMessage string `json:"submissionFailureMessage,omitempty"`
+
+	// The hash of the job spec which the job was submitted with, only set in
+	// the "RestAPI" submission mode.
+	SpecHash string `json:"specHash,omitempty"`
+
+	// The scheduled time of the current run of a scheduled job.
+	LastScheduleTime string `json:"lastScheduleTime,omitempty"`
+
+	// The previous runs of a scheduled job, the latest one is the last.
+	RunHistory []JobRunStatus `json:"runHistory,omitempty"`
+
+	// The upgrade of the running job to the updated job spec, unset when the
+	// job is not being upgraded.
+	Upgrade *JobUpgradeStatus `json:"upgrade,omitempty"`
+
+	// The JSON of the cluster spec which the job last ran with, recorded when
+	// `upgradeTimeoutSeconds` is specified and restored when an upgrade is
+	// rolled back.
+	StableSpec string `json:"stableSpec,omitempty"`
+
+	// The last rollback of a job upgrade, kept until the job is upgraded
+	// again.
+	Rollback *JobRollbackStatus `json:"rollback,omitempty"`
+
+	// The time since when the running job has not been found in the
+	// JobManager, e.g., the JobManager restarted without HA. Unset when the
+	// job is found again or stopped.
+	LostSince string `json:"lostSince,omitempty"`
+}
+
+// JobUpgradeStatus defines the status of the upgrade of a running job.
+type JobUpgradeStatus struct {
+	// The hash of the job spec which the job is upgraded to.
+	SpecHash string `json:"specHash"`
+
+	// The upgrade state, "InProgress", "Deploying" or "Failed". The upgrade
+	// fails after 3 failed savepoints and is not retried until the job spec
+	// changes again. When `upgradeTimeoutSeconds` is specified, the upgrade
+	// is "Deploying" after the previous job is stopped until the upgraded job
+	// is running.
+	State string `json:"state"`
+
+	// The ID of the Flink job which is upgraded.
+	FromJobID string `json:"fromJobID,omitempty"`
+
+	// The start time of the upgrade.
+	StartTime string `json:"startTime,omitempty"`
+
+	// The number of failed savepoints taken for the upgrade.
+	FailedSavepoints int32 `json:"failedSavepoints,omitempty"`
+
+	// The trigger time of the last failed savepoint.
+	LastFailedSavepointTime string `json:"lastFailedSavepointTime,omitempty"`
+
+	// The error of the last failed savepoint.
+	Message string `json:"message,omitempty"`
+}
+
+// JobRollbackStatus defines the status of the rollback of a job upgrade.
+type JobRollbackStatus struct {
+	// The hash of the job spec which was rolled back, the upgrade to it is
+	// not retried until the job spec changes.
+	SpecHash string `json:"specHash"`
+
+	// The ID of the upgraded Flink job which didn't reach the RUNNING state.
+	JobID string `json:"jobID,omitempty"`
+
+	// The savepoint taken before the upgrade which the job is restored from.
+	Savepoint string `json:"savepoint,omitempty"`
+
+	// The rollback state, "InProgress" or "Succeeded".
+	State string `json:"state"`
+
+	// The time of the rollback.
+	Time string `json:"time,omitempty"`
+
+	// The reason of the rollback.
+	Message string `json:"message,omitempty"`
+}
+
+// JobRunStatus defines the status of a finished run of a scheduled job.
+type JobRunStatus struct {
+	// The scheduled time of the run.
+	ScheduleTime string `json:"scheduleTime"`
+
+	// The ID of the Flink job of the run.
+	ID string `json:"id,omitempty"`
+
+	// The final state of the run, "Succeeded", "Failed" or "Cancelled".
+	State string `json:"state"`
+
+	// The final state of the Flink job reported by the JobManager.
+	FlinkJobState string `json:"flinkJobState,omitempty"`
+
+	// The start time of the Flink job.
+	StartTime string `json:"startTime,omitempty"`
+
+	// The root exceptions of the recent failures of the Flink job.
+	FailureReasons []string `json:"failureReasons,omitempty"`
 }
 
 // SavepointStatus defines the status of savepoint progress
@@ -492,6 +2021,18 @@ type SavepointStatus struct {
 
 	// Savepoint message.
 	Message string `json:"message,omitempty"`
+
+	// The percentage of the subtasks which have acknowledged the savepoint
+	// in progress, reported by the Flink REST API.
+	ProgressPercent int32 `json:"progressPercent,omitempty"`
+
+	// The number of times the savepoint has been retried after it failed or
+	// didn't complete in time.
+	Retries int32 `json:"retries,omitempty"`
+
+	// The time when the failed savepoint is retried, the savepoint is not
+	// triggered before it.
+	RetryTime string `json:"retryTime,omitempty"`
 }
 
 // JobManagerIngressStatus defines the status of a JobManager ingress.
@@ -506,6 +2047,22 @@ type JobManagerIngressStatus struct {
 	URLs []string `json:"urls,omitempty"`
 }
 
+// JobManagerDeploymentStatus defines the observed status of the JobManager
+// deployment, or StatefulSet.
+type JobManagerDeploymentStatus struct {
+	// The resource name of the component.
+	Name string `json:"name"`
+
+	// The state of the component.
+	State string `json:"state"`
+
+	// The number of JobManager pods.
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// The number of ready JobManager pods.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+}
+
 // JobManagerServiceStatus defines the observed state of FlinkCluster
 type JobManagerServiceStatus struct {
 	// The name of the Kubernetes jobManager service.
@@ -516,6 +2073,133 @@ type JobManagerServiceStatus struct {
 
 	// (Optional) The node port, present when `accessScope` is `NodePort`.
 	NodePort int32 `json:"nodePort,omitempty"`
+
+	// The URL of the JobManager REST API inside the Kubernetes cluster,
+	// which the operator uses.
+	RestURL string `json:"restURL,omitempty"`
+
+	// The URL of the Flink web UI, the first ingress URL, the load balancer
+	// address when `accessScope` is `VPC` or `External`, or the REST URL
+	// otherwise.
+	UIURL string `json:"uiURL,omitempty"`
+}
+
+// TaskManagerDeploymentStatus defines the observed status of the TaskManager
+// deployment.
+type TaskManagerDeploymentStatus struct {
+	// The resource name of the component.
+	Name string `json:"name"`
+
+	// The state of the component.
+	State string `json:"state"`
+
+	// The number of TaskManager pods.
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// The number of ready TaskManager pods.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// The label selector of the TaskManager pods, required by the scale
+	// subresource.
+	Selector string `json:"selector,omitempty"`
+
+	// The number of TaskManagers registered in the JobManager, reported by
+	// the Flink REST API.
+	RegisteredTaskManagers int32 `json:"registeredTaskManagers,omitempty"`
+
+	// The number of task slots of the registered TaskManagers.
+	SlotsTotal int32 `json:"slotsTotal,omitempty"`
+
+	// The number of available task slots of the registered TaskManagers.
+	SlotsAvailable int32 `json:"slotsAvailable,omitempty"`
+
+	// The time when the TaskManagers started waiting to become idle for the
+	// pending scale-down, see `decommissionTimeoutSeconds`.
+	DecommissionStartTime string `json:"decommissionStartTime,omitempty"`
+}
+
+// AutoscalerStatus defines the status of the autoscaler, the metrics are the
+// ones observed when the autoscaler made the last scaling decision.
+type AutoscalerStatus struct {
+	// Percentage of busy time of the busiest operator.
+	BusyPercent int32 `json:"busyPercent,omitempty"`
+
+	// Percentage of back pressured time of the most back pressured operator.
+	BackPressuredPercent int32 `json:"backPressuredPercent,omitempty"`
+
+	// Total consumer lag of the sources in records.
+	ConsumerLag int64 `json:"consumerLag,omitempty"`
+
+	// The parallelism of the job after the last rescale.
+	Parallelism int32 `json:"parallelism,omitempty"`
+
+	// Last rescale timestamp.
+	LastScaleTime string `json:"lastScaleTime,omitempty"`
+}
+
+// BlueGreenStatus defines the status of a blue/green deployment.
+type BlueGreenStatus struct {
+	// The state of the deployment, "Stable", "Deploying", "Verifying" or
+	// "RolledBack".
+	State string `json:"state"`
+
+	// The name of the child cluster which is running the current spec.
+	ActiveCluster string `json:"activeCluster,omitempty"`
+
+	// The hash of the spec the active cluster is running.
+	ActiveSpecHash string `json:"activeSpecHash,omitempty"`
+
+	// The name of the child cluster being deployed with the updated spec.
+	TargetCluster string `json:"targetCluster,omitempty"`
+
+	// The hash of the updated spec.
+	TargetSpecHash string `json:"targetSpecHash,omitempty"`
+
+	// The location of the savepoint the target cluster is started from.
+	Savepoint string `json:"savepoint,omitempty"`
+
+	// The time when the deployment of the target cluster started.
+	DeployStartTime string `json:"deployStartTime,omitempty"`
+
+	// The time since when the job of the target cluster has been running.
+	VerifyingSince string `json:"verifyingSince,omitempty"`
+
+	// The reason why the last deployment was rolled back.
+	Message string `json:"message,omitempty"`
+}
+
+// ClusterCondition defines an observed condition of the cluster, it follows
+// the Kubernetes conventions, so tools like `kubectl wait` can track it.
+type ClusterCondition struct {
+	// The type of the condition, "ClusterReady", "JobRunning", "JobFinished",
+	// "SavepointComplete", "JobUpgradeFailed" or "TimedOut".
+	Type string `json:"type"`
+
+	// The status of the condition, "True", "False" or "Unknown".
+	Status corev1.ConditionStatus `json:"status"`
+
+	// The reason of the status in CamelCase, e.g., the state of the cluster or
+	// the job.
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message about the status.
+	Message string `json:"message,omitempty"`
+
+	// The last time the status of the condition changed.
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// TimeoutStatus defines the status of the lifecycle phase which timed out.
+type TimeoutStatus struct {
+	// The phase which timed out, "ClusterStartup", "JobSubmission",
+	// "Savepoint", "Cancellation" or "Upgrade".
+	Phase string `json:"phase"`
+
+	// The time when the phase timed out.
+	Time string `json:"time,omitempty"`
+
+	// A human readable message about the timeout.
+	Message string `json:"message,omitempty"`
 }
 
 // FlinkClusterStatus defines the observed state of FlinkCluster
@@ -535,6 +2219,36 @@ type FlinkClusterStatus struct {
 	// The status of savepoint progress
 	Savepoint *SavepointStatus `json:"savepoint,omitempty"`
 
+	// The status of the autoscaler.
+	Autoscaler *AutoscalerStatus `json:"autoscaler,omitempty"`
+
+	// The time since when no job has been running in the session cluster,
+	// only recorded when `idleTimeoutSeconds` is specified.
+	IdleSince string `json:"idleSince,omitempty"`
+
+	// Whether the session cluster has been idle for `idleTimeoutSeconds`, then
+	// the `idleTimeoutAction` is taken.
+	IdleTimedOut bool `json:"idleTimedOut,omitempty"`
+
+	// The status of the blue/green deployment, only recorded when the
+	// `updateStrategy` is `BlueGreen`.
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+
+	// The lifecycle phase which timed out, it is cleared when the phase is
+	// completed later.
+	Timeout *TimeoutStatus `json:"timeout,omitempty"`
+
+	// The ResourceQuotas of the namespace which the JobManager or TaskManagers
+	// to be created don't fit in, with the requested and available resources.
+	// The components are created when it is cleared.
+	InsufficientQuota string `json:"insufficientQuota,omitempty"`
+
+	// The observed conditions of the cluster.
+	Conditions []ClusterCondition `json:"conditions,omitempty"`
+
+	// The generation of the cluster spec which the status is derived from.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// Last update timestamp for this status.
 	LastUpdateTime string `json:"lastUpdateTime,omitempty"`
 }
@@ -542,7 +2256,14 @@ type FlinkClusterStatus struct {
 // +kubebuilder:object:root=true
 
 // FlinkCluster is the Schema for the flinkclusters API
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=fc,categories=flink
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Job State",type="string",JSONPath=".status.components.job.state"
+// +kubebuilder:printcolumn:name="Last Savepoint",type="date",JSONPath=".status.components.job.lastSavepointTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.taskManager.replicas,statuspath=.status.components.taskManagerDeployment.readyReplicas,selectorpath=.status.components.taskManagerDeployment.selector
 type FlinkCluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
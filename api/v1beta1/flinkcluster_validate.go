@@ -18,13 +18,25 @@ package v1beta1
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/googlecloudplatform/flink-operator/operatorconfig"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 const (
@@ -36,114 +48,379 @@ const (
 	ControlChangeWarnMsg           = "change is not allowed for control in progress, annotation: %v"
 )
 
+// Flink properties managed by the operator, which cannot be overridden through
+// `flinkProperties`.
+var reservedFlinkProperties = map[string]struct{}{
+	"jobmanager.rpc.address": {},
+	"jobmanager.rpc.port":    {},
+	"blob.server.port":       {},
+	"query.server.port":      {},
+	"rest.port":              {},
+	"taskmanager.rpc.port":   {},
+}
+
+// Flink properties managed by the operator when high availability is enabled.
+var highAvailabilityFlinkProperties = map[string]struct{}{
+	"high-availability":                     {},
+	"high-availability.storageDir":          {},
+	"high-availability.cluster-id":          {},
+	"high-availability.zookeeper.quorum":    {},
+	"high-availability.zookeeper.path.root": {},
+	"kubernetes.cluster-id":                 {},
+	"kubernetes.namespace":                  {},
+}
+
+// Flink properties managed by the operator when the job checkpointing is
+// specified.
+var checkpointingFlinkProperties = map[string]struct{}{
+	"execution.checkpointing.interval":                          {},
+	"execution.checkpointing.mode":                              {},
+	"execution.checkpointing.timeout":                           {},
+	"execution.checkpointing.min-pause":                         {},
+	"execution.checkpointing.externalized-checkpoint-retention": {},
+	"execution.checkpointing.unaligned":                         {},
+}
+
+// Flink properties managed by the operator when the state backend is
+// specified.
+var stateBackendFlinkProperties = map[string]struct{}{
+	"state.backend":                  {},
+	"state.checkpoints.dir":          {},
+	"state.savepoints.dir":           {},
+	"state.backend.incremental":      {},
+	"state.backend.rocksdb.localdir": {},
+}
+
+// Flink properties managed by the operator when the History Server is
+// specified.
+var historyServerFlinkProperties = map[string]struct{}{
+	"jobmanager.archive.fs.dir":                 {},
+	"historyserver.archive.fs.dir":              {},
+	"historyserver.archive.fs.refresh-interval": {},
+	"historyserver.web.address":                 {},
+	"historyserver.web.port":                    {},
+}
+
+// Flink properties which are managed by the operator when
+// `security.ssl.internal` is specified.
+var internalSSLFlinkProperties = map[string]struct{}{
+	"security.ssl.internal.enabled":             {},
+	"security.ssl.internal.keystore":            {},
+	"security.ssl.internal.keystore-password":   {},
+	"security.ssl.internal.key-password":        {},
+	"security.ssl.internal.truststore":          {},
+	"security.ssl.internal.truststore-password": {},
+}
+
+// Flink properties which are managed by the operator when
+// `security.ssl.rest` is specified.
+var restSSLFlinkProperties = map[string]struct{}{
+	"security.ssl.rest.enabled":             {},
+	"security.ssl.rest.keystore":            {},
+	"security.ssl.rest.keystore-password":   {},
+	"security.ssl.rest.key-password":        {},
+	"security.ssl.rest.truststore":          {},
+	"security.ssl.rest.truststore-password": {},
+}
+
+// Flink properties which are managed by the operator when
+// `security.kerberos` is specified.
+var kerberosFlinkProperties = map[string]struct{}{
+	"security.kerberos.login.use-ticket-cache": {},
+	"security.kerberos.login.keytab":           {},
+	"security.kerberos.login.principal":        {},
+	"security.kerberos.login.contexts":         {},
+}
+
+// Labels of the Job pod managed by the operator, which cannot be overridden
+// through `podLabels`.
+var reservedJobPodLabels = map[string]struct{}{
+	"cluster": {},
+	"app":     {},
+}
+
+// Labels of the resources managed by the operator, which cannot be overridden
+// through the `labels` of the cluster or its components.
+var reservedResourceLabels = map[string]struct{}{
+	"cluster":   {},
+	"app":       {},
+	"component": {},
+}
+
+// Files in the Flink ConfigMap managed by the operator, which cannot be
+// overridden through `logConfig`.
+var reservedConfigFiles = map[string]struct{}{
+	"flink-conf.yaml": {},
+	"submit-job.sh":   {},
+	"core-site.xml":   {},
+}
+
+// Built-in file system plugins of the Flink image, which can be enabled
+// through `storage.plugins`.
+var builtInFileSystemPlugins = map[string]struct{}{
+	"s3-fs-presto":    {},
+	"s3-fs-hadoop":    {},
+	"gs-fs-hadoop":    {},
+	"azure-fs-hadoop": {},
+	"oss-fs-hadoop":   {},
+	"swift-fs-hadoop": {},
+}
+
+// Prefix of the Flink properties of the Prometheus metric reporter, which
+// are managed by the operator when `metrics.prometheus` is specified.
+const prometheusReporterPropertyPrefix = "metrics.reporter.prom."
+
+// Flink property which lists the external resources, and prefix of the Flink
+// properties of the GPU external resource, which are managed by the operator
+// when `taskManager.gpu` is specified.
+const (
+	externalResourcesFlinkProperty    = "external-resources"
+	gpuExternalResourcePropertyPrefix = "external-resource.gpu."
+)
+
+// Duration format of Prometheus, e.g., `30s` or `1m`.
+var prometheusDurationRegex = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d|w|y)$`)
+
+// Volumes created by the operator in the JobManager, TaskManager and job
+// pods, which cannot be declared in `volumes`.
+var reservedVolumeNames = map[string]struct{}{
+	"flink-config-volume":        {},
+	"gcp-service-account-volume": {},
+	"hadoop-config-volume":       {},
+	"job-jar-volume":             {},
+	"rocksdb-local-dir-volume":   {},
+}
+
+// SHA-256 checksum in hex.
+var sha256Pattern = regexp.MustCompile("^[0-9a-fA-F]{64}$")
+
 // Validator validates CUD requests for the CR.
 type Validator struct{}
 
-// ValidateCreate validates create request.
+// Qualified kinds of the CRs, used in the errors returned by the validator.
+var (
+	flinkClusterGroupKind    = GroupVersion.WithKind("FlinkCluster").GroupKind()
+	flinkSessionJobGroupKind = GroupVersion.WithKind("FlinkSessionJob").GroupKind()
+	flinkSavepointGroupKind  = GroupVersion.WithKind("FlinkSavepoint").GroupKind()
+)
+
+// ValidateCreate validates create request. All violations are returned at
+// once, each of them with the path of the invalid field.
 func (v *Validator) ValidateCreate(cluster *FlinkCluster) error {
-	var err error
-	err = v.validateMeta(&cluster.ObjectMeta)
-	if err != nil {
-		return err
-	}
-	err = v.validateHadoopConfig(cluster.Spec.HadoopConfig)
-	if err != nil {
-		return err
-	}
-	err = v.validateGCPConfig(cluster.Spec.GCPConfig)
-	if err != nil {
-		return err
+	var allErrs field.ErrorList
+	allErrs = append(allErrs,
+		v.validateMeta(&cluster.ObjectMeta, field.NewPath("metadata"))...)
+	allErrs = append(allErrs, v.validateClusterSpec(cluster)...)
+	allErrs = append(allErrs, v.validateTemplateRef(
+		cluster, field.NewPath("spec", "templateRef"))...)
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(flinkClusterGroupKind, cluster.Name, allErrs)
 	}
-	err = v.validateImage(&cluster.Spec.Image)
-	if err != nil {
-		return err
+	return nil
+}
+
+// Checks that the template referenced by a new cluster could be merged.
+func (v *Validator) validateTemplateRef(
+	cluster *FlinkCluster, path *field.Path) field.ErrorList {
+	var templateRef = cluster.Spec.TemplateRef
+	if templateRef == nil {
+		return nil
 	}
-	err = v.validateJobManager(&cluster.Spec.JobManager)
-	if err != nil {
-		return err
+	if len(templateRef.Name) == 0 {
+		return field.ErrorList{field.Required(path.Child("name"), "")}
 	}
-	err = v.validateTaskManager(&cluster.Spec.TaskManager)
-	if err != nil {
-		return err
+	if templateReader == nil {
+		return nil
 	}
-	err = v.validateJob(cluster.Spec.Job)
+	var _, err = getClusterTemplateSpec(cluster)
 	if err != nil {
-		return err
+		return field.ErrorList{field.Invalid(
+			path.Child("name"),
+			templateRef.Name,
+			fmt.Sprintf("failed to get the cluster template: %v", err))}
 	}
 	return nil
 }
 
+// validateClusterSpec validates the properties of the cluster spec, it is
+// also used to validate the updates of blue/green clusters, which can change
+// any property.
+func (v *Validator) validateClusterSpec(cluster *FlinkCluster) field.ErrorList {
+	var allErrs field.ErrorList
+	var specPath = field.NewPath("spec")
+	allErrs = append(allErrs, v.validateHadoopConfig(
+		cluster.Spec.HadoopConfig, specPath.Child("hadoopConfig"))...)
+	allErrs = append(allErrs, v.validateGCPConfig(
+		cluster.Spec.GCPConfig, specPath.Child("gcpConfig"))...)
+	allErrs = append(allErrs,
+		v.validateImage(&cluster.Spec.Image, specPath.Child("image"))...)
+	allErrs = append(allErrs, v.validateFlinkVersion(
+		cluster.Spec.FlinkVersion, specPath.Child("flinkVersion"))...)
+	allErrs = append(allErrs, v.validateJobManager(
+		&cluster.Spec.JobManager,
+		cluster.Spec.HighAvailability,
+		specPath.Child("jobManager"))...)
+	allErrs = append(allErrs, v.validateTaskManager(
+		&cluster.Spec.TaskManager, specPath.Child("taskManager"))...)
+	allErrs = append(allErrs,
+		v.validateJob(cluster.Spec.Job, specPath.Child("job"))...)
+	allErrs = append(allErrs, v.validateCheckpointing(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateHighAvailability(
+		cluster.Spec.HighAvailability, specPath.Child("highAvailability"))...)
+	allErrs = append(allErrs, v.validateServiceAccount(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateEnvVars(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateResourcesMetadata(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateFlinkProperties(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateLogConfig(
+		cluster.Spec.LogConfig, specPath.Child("logConfig"))...)
+	allErrs = append(allErrs, v.validateAutoscaler(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateIdleTimeout(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs,
+		v.validateTimeouts(cluster.Spec.Timeouts, specPath.Child("timeouts"))...)
+	allErrs = append(allErrs, v.validateRestartOnChange(
+		cluster.Spec.RestartOnChange, specPath.Child("restartOnChange"))...)
+	allErrs = append(allErrs,
+		v.validateMetrics(cluster.Spec.Metrics, specPath.Child("metrics"))...)
+	allErrs = append(allErrs, v.validateStorage(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateGPU(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateStateBackend(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateNetworkPolicy(
+		cluster.Spec.NetworkPolicy, specPath.Child("networkPolicy"))...)
+	allErrs = append(allErrs, v.validateSecurity(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validateBatchScheduler(
+		cluster.Spec.BatchScheduler, specPath.Child("batchScheduler"))...)
+	allErrs = append(allErrs, v.validateHistoryServer(
+		cluster.Spec.HistoryServer, specPath.Child("historyServer"))...)
+	allErrs = append(allErrs, v.validateUpdateStrategy(&cluster.Spec, specPath)...)
+	allErrs = append(allErrs, v.validatePolicy(cluster)...)
+	return allErrs
+}
+
 // ValidateUpdate validates update request.
 func (v *Validator) ValidateUpdate(old *FlinkCluster, new *FlinkCluster) error {
-	err := v.checkControlAnnotations(old, new)
-	if err != nil {
-		return err
+	var allErrs = v.validateUpdate(old, new)
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(flinkClusterGroupKind, new.Name, allErrs)
 	}
+	return nil
+}
 
-	cancelRequested, err := v.checkCancelRequested(old, new)
-	if err != nil {
-		return err
+func (v *Validator) validateUpdate(
+	old *FlinkCluster, new *FlinkCluster) field.ErrorList {
+	var allErrs = v.checkControlAnnotations(old, new)
+	if len(allErrs) > 0 {
+		return allErrs
 	}
-	if cancelRequested {
-		return nil
+
+	blueGreenUpdated, allErrs := v.checkBlueGreenUpdate(old, new)
+	if len(allErrs) > 0 || blueGreenUpdated {
+		return allErrs
 	}
 
-	savepointGenUpdated, err := v.checkSavepointGeneration(old, new)
-	if err != nil {
-		return err
+	cancelRequested, allErrs := v.checkCancelRequested(old, new)
+	if len(allErrs) > 0 || cancelRequested {
+		return allErrs
 	}
-	if savepointGenUpdated {
-		return nil
+
+	savepointGenUpdated, allErrs := v.checkSavepointGeneration(old, new)
+	if len(allErrs) > 0 || savepointGenUpdated {
+		return allErrs
+	}
+
+	tmReplicasUpdated, allErrs := v.checkTaskManagerReplicas(old, new)
+	if len(allErrs) > 0 || tmReplicasUpdated {
+		return allErrs
+	}
+
+	upgradeRequested, allErrs := v.checkUpgradableProperties(old, new)
+	if len(allErrs) > 0 || upgradeRequested {
+		return allErrs
 	}
 
 	if !reflect.DeepEqual(new.Spec, old.Spec) {
-		return fmt.Errorf("the cluster properties are immutable")
+		return field.ErrorList{field.Forbidden(
+			field.NewPath("spec"), "the cluster properties are immutable")}
 	}
 
 	return nil
 }
 
-func (v *Validator) checkControlAnnotations(old *FlinkCluster, new *FlinkCluster) error {
+func (v *Validator) checkControlAnnotations(
+	old *FlinkCluster, new *FlinkCluster) field.ErrorList {
+	var annotationPath = field.NewPath("metadata", "annotations").Key(
+		ControlAnnotation)
 	oldUserControl, _ := old.Annotations[ControlAnnotation]
 	newUserControl, ok := new.Annotations[ControlAnnotation]
-	if ok {
-		if oldUserControl != newUserControl && old.Status.Control != nil && old.Status.Control.State == ControlStateProgressing {
-			return fmt.Errorf(ControlChangeWarnMsg, ControlAnnotation)
-		}
-		switch newUserControl {
-		case ControlNameJobCancel:
-			var jobStatus = old.Status.Components.Job
-			if old.Spec.Job == nil {
-				return fmt.Errorf(SessionClusterWarnMsg, ControlNameJobCancel, ControlAnnotation)
-			} else if jobStatus == nil || isJobTerminated(old.Spec.Job.RestartPolicy, jobStatus) {
-				return fmt.Errorf(InvalidJobStateForJobCancelMsg, ControlAnnotation)
-			}
-		case ControlNameSavepoint:
-			var jobStatus = old.Status.Components.Job
-			if old.Spec.Job == nil {
-				return fmt.Errorf(SessionClusterWarnMsg, ControlNameSavepoint, ControlAnnotation)
-			} else if old.Spec.Job.SavepointsDir == nil || *old.Spec.Job.SavepointsDir == "" {
-				return fmt.Errorf(InvalidSavepointDirMsg, ControlAnnotation)
-			} else if jobStatus == nil || isJobStopped(old.Status.Components.Job) {
-				return fmt.Errorf(InvalidJobStateForSavepointMsg, ControlAnnotation)
-			}
-		default:
-			return fmt.Errorf(InvalidControlAnnMsg, ControlAnnotation, newUserControl)
+	if !ok {
+		return nil
+	}
+	if oldUserControl != newUserControl && old.Status.Control != nil && old.Status.Control.State == ControlStateProgressing {
+		return field.ErrorList{field.Forbidden(
+			annotationPath, fmt.Sprintf(ControlChangeWarnMsg, ControlAnnotation))}
+	}
+	var jobStatus = old.Status.Components.Job
+	switch newUserControl {
+	case ControlNameJobCancel:
+		if old.Spec.Job == nil {
+			return field.ErrorList{field.Forbidden(
+				annotationPath,
+				fmt.Sprintf(SessionClusterWarnMsg, ControlNameJobCancel, ControlAnnotation))}
+		} else if jobStatus == nil || isJobTerminated(old.Spec.Job, jobStatus) {
+			return field.ErrorList{field.Forbidden(
+				annotationPath,
+				fmt.Sprintf(InvalidJobStateForJobCancelMsg, ControlAnnotation))}
 		}
+	case ControlNameSavepoint:
+		if old.Spec.Job == nil {
+			return field.ErrorList{field.Forbidden(
+				annotationPath,
+				fmt.Sprintf(SessionClusterWarnMsg, ControlNameSavepoint, ControlAnnotation))}
+		} else if old.Spec.Job.SavepointsDir == nil || *old.Spec.Job.SavepointsDir == "" {
+			return field.ErrorList{field.Forbidden(
+				annotationPath, fmt.Sprintf(InvalidSavepointDirMsg, ControlAnnotation))}
+		} else if jobStatus == nil || isJobStopped(jobStatus) {
+			return field.ErrorList{field.Forbidden(
+				annotationPath,
+				fmt.Sprintf(InvalidJobStateForSavepointMsg, ControlAnnotation))}
+		}
+	default:
+		return field.ErrorList{field.NotSupported(
+			annotationPath,
+			newUserControl,
+			[]string{ControlNameSavepoint, ControlNameJobCancel})}
 	}
 	return nil
 }
 
+// checkBlueGreenUpdate checks the updates of the update strategy, which
+// cannot be changed. Any property of a blue/green cluster can be updated,
+// because the update is deployed in a new cluster.
+func (v *Validator) checkBlueGreenUpdate(
+	old *FlinkCluster, new *FlinkCluster) (bool, field.ErrorList) {
+	var oldBlueGreen = isBlueGreen(&old.Spec)
+	var newBlueGreen = isBlueGreen(&new.Spec)
+	if oldBlueGreen != newBlueGreen {
+		return false, field.ErrorList{field.Forbidden(
+			field.NewPath("spec", "updateStrategy"),
+			"updateStrategy cannot be changed after the cluster is created")}
+	}
+	if !newBlueGreen || reflect.DeepEqual(new.Spec, old.Spec) {
+		return false, nil
+	}
+	return true, v.validateClusterSpec(new)
+}
+
 func (v *Validator) checkCancelRequested(
-	old *FlinkCluster, new *FlinkCluster) (bool, error) {
+	old *FlinkCluster, new *FlinkCluster) (bool, field.ErrorList) {
 	if old.Spec.Job == nil || new.Spec.Job == nil {
 		return false, nil
 	}
 	var restartJob = (old.Spec.Job.CancelRequested != nil && *old.Spec.Job.CancelRequested) &&
 		(new.Spec.Job.CancelRequested == nil || !*new.Spec.Job.CancelRequested)
 	if restartJob {
-		return false, fmt.Errorf(
-			"updating cancelRequested from true to false is not allowed")
+		return false, field.ErrorList{field.Forbidden(
+			field.NewPath("spec", "job", "cancelRequested"),
+			"updating cancelRequested from true to false is not allowed")}
 	}
 
 	var stopJob = (old.Spec.Job.CancelRequested == nil || !*old.Spec.Job.CancelRequested) &&
@@ -159,7 +436,7 @@ func (v *Validator) checkCancelRequested(
 }
 
 func (v *Validator) checkSavepointGeneration(
-	old *FlinkCluster, new *FlinkCluster) (bool, error) {
+	old *FlinkCluster, new *FlinkCluster) (bool, field.ErrorList) {
 	if old.Spec.Job == nil || new.Spec.Job == nil {
 		return false, nil
 	}
@@ -170,14 +447,22 @@ func (v *Validator) checkSavepointGeneration(
 		return false, nil
 	}
 
+	var jobPath = field.NewPath("spec", "job")
 	var oldStatusGen int32 = 0
 	if old.Status.Components.Job != nil {
 		oldStatusGen = old.Status.Components.Job.SavepointGeneration
 	}
 	if newSpecGen != oldStatusGen+1 {
-		return false, fmt.Errorf(
-			"you can only update savepointGeneration to %v",
-			oldStatusGen+1)
+		return false, field.ErrorList{field.Invalid(
+			jobPath.Child("savepointGeneration"),
+			newSpecGen,
+			fmt.Sprintf("you can only update savepointGeneration to %v", oldStatusGen+1))}
+	}
+
+	if new.Spec.Job.SavepointsDir == nil || len(*new.Spec.Job.SavepointsDir) == 0 {
+		return false, field.ErrorList{field.Required(
+			jobPath.Child("savepointsDir"),
+			"savepointGeneration cannot be updated without savepointsDir")}
 	}
 
 	// Check if only `savepointGeneration` changed, no other changes.
@@ -186,71 +471,434 @@ func (v *Validator) checkSavepointGeneration(
 	return reflect.DeepEqual(new.Spec, oldCopy.Spec), nil
 }
 
-func (v *Validator) validateMeta(meta *metav1.ObjectMeta) error {
+// Checks if only `taskManager.replicas` changed, TaskManagers are scaled in
+// place without restarting the cluster.
+func (v *Validator) checkTaskManagerReplicas(
+	old *FlinkCluster, new *FlinkCluster) (bool, field.ErrorList) {
+	if reflect.DeepEqual(
+		old.Spec.TaskManager.Replicas, new.Spec.TaskManager.Replicas) {
+		return false, nil
+	}
+	if new.Spec.TaskManager.Replicas == nil ||
+		*new.Spec.TaskManager.Replicas < 0 {
+		return false, field.ErrorList{field.Invalid(
+			field.NewPath("spec", "taskManager", "replicas"),
+			new.Spec.TaskManager.Replicas,
+			"it must be >= 0")}
+	}
+	var allErrs = v.validateTaskManagerReplicasPolicy(
+		new.Spec.TaskManager.Replicas,
+		field.NewPath("spec", "taskManager", "replicas"))
+	if len(allErrs) > 0 {
+		return false, allErrs
+	}
+
+	var oldCopy = old.DeepCopy()
+	oldCopy.Spec.TaskManager.Replicas = new.Spec.TaskManager.Replicas
+	return reflect.DeepEqual(new.Spec, oldCopy.Spec), nil
+}
+
+// Checks if only the properties which can be upgraded on a running cluster
+// changed: image, flinkProperties and the job jarFile, jarSha256, Python
+// files, args and parallelism.
+// The operator upgrades the cluster by taking a savepoint of the job, updating
+// the JobManager and TaskManager, then resubmitting the job from the
+// savepoint.
+func (v *Validator) checkUpgradableProperties(
+	old *FlinkCluster, new *FlinkCluster) (bool, field.ErrorList) {
+	if (old.Spec.Job == nil) != (new.Spec.Job == nil) {
+		return false, nil
+	}
+
+	var allErrs field.ErrorList
+	var specPath = field.NewPath("spec")
+	var jobPath = specPath.Child("job")
+	if !reflect.DeepEqual(old.Spec.Image, new.Spec.Image) {
+		allErrs = append(allErrs,
+			v.validateImage(&new.Spec.Image, specPath.Child("image"))...)
+	}
+	if old.Spec.FlinkVersion != new.Spec.FlinkVersion {
+		allErrs = append(allErrs, v.validateFlinkVersion(
+			new.Spec.FlinkVersion, specPath.Child("flinkVersion"))...)
+	}
+	var checkpointingChanged = new.Spec.Job != nil &&
+		!reflect.DeepEqual(old.Spec.Job.Checkpointing, new.Spec.Job.Checkpointing)
+	if old.Spec.FlinkVersion != new.Spec.FlinkVersion ||
+		!reflect.DeepEqual(old.Spec.FlinkProperties, new.Spec.FlinkProperties) ||
+		!reflect.DeepEqual(
+			old.Spec.FlinkPropertiesFrom, new.Spec.FlinkPropertiesFrom) ||
+		checkpointingChanged {
+		allErrs = append(allErrs, v.validateFlinkProperties(&new.Spec, specPath)...)
+	}
+	if old.Spec.FlinkVersion != new.Spec.FlinkVersion || checkpointingChanged {
+		allErrs = append(allErrs, v.validateCheckpointing(&new.Spec, specPath)...)
+	}
+	if !reflect.DeepEqual(old.Spec.LogConfig, new.Spec.LogConfig) {
+		allErrs = append(allErrs, v.validateLogConfig(
+			new.Spec.LogConfig, specPath.Child("logConfig"))...)
+	}
+	if !reflect.DeepEqual(old.Spec.Timeouts, new.Spec.Timeouts) {
+		allErrs = append(allErrs, v.validateTimeouts(
+			new.Spec.Timeouts, specPath.Child("timeouts"))...)
+	}
+	if !reflect.DeepEqual(old.Spec.RestartOnChange, new.Spec.RestartOnChange) {
+		allErrs = append(allErrs, v.validateRestartOnChange(
+			new.Spec.RestartOnChange, specPath.Child("restartOnChange"))...)
+	}
+	if new.Spec.Job != nil {
+		if old.Spec.Job.JarFile != new.Spec.Job.JarFile ||
+			!reflect.DeepEqual(old.Spec.Job.JarSha256, new.Spec.Job.JarSha256) ||
+			!reflect.DeepEqual(old.Spec.Job.PythonFile, new.Spec.Job.PythonFile) ||
+			!reflect.DeepEqual(old.Spec.Job.PythonFiles, new.Spec.Job.PythonFiles) ||
+			!reflect.DeepEqual(
+				old.Spec.Job.PythonRequirements, new.Spec.Job.PythonRequirements) ||
+			!reflect.DeepEqual(old.Spec.Job.SQL, new.Spec.Job.SQL) ||
+			!reflect.DeepEqual(old.Spec.Job.SQLConfigMap, new.Spec.Job.SQLConfigMap) {
+			allErrs = append(allErrs, v.validateJobFile(new.Spec.Job, jobPath)...)
+		}
+		if old.Spec.Job.JarFile != new.Spec.Job.JarFile &&
+			isRestAPISubmission(new.Spec.Job) {
+			allErrs = append(allErrs,
+				v.validateRestAPIJarFile(new.Spec.Job.JarFile, jobPath)...)
+		}
+		if !reflect.DeepEqual(old.Spec.Job.Schedule, new.Spec.Job.Schedule) ||
+			!reflect.DeepEqual(
+				old.Spec.Job.ConcurrencyPolicy, new.Spec.Job.ConcurrencyPolicy) ||
+			!reflect.DeepEqual(
+				old.Spec.Job.SuccessfulRunsHistoryLimit,
+				new.Spec.Job.SuccessfulRunsHistoryLimit) ||
+			!reflect.DeepEqual(
+				old.Spec.Job.FailedRunsHistoryLimit,
+				new.Spec.Job.FailedRunsHistoryLimit) ||
+			!reflect.DeepEqual(
+				old.Spec.Job.DeleteTaskManagersBetweenRuns,
+				new.Spec.Job.DeleteTaskManagersBetweenRuns) {
+			allErrs = append(allErrs, v.validateJobSchedule(new.Spec.Job, jobPath)...)
+		}
+		if !reflect.DeepEqual(old.Spec.Job.Parallelism, new.Spec.Job.Parallelism) &&
+			(new.Spec.Job.Parallelism == nil || *new.Spec.Job.Parallelism < 1) {
+			allErrs = append(allErrs, field.Invalid(
+				jobPath.Child("parallelism"),
+				new.Spec.Job.Parallelism,
+				"it must be >= 1"))
+		}
+		if isJobUpgraded(old, new) && !canUpgradeJob(new.Spec.Job) {
+			allErrs = append(allErrs, field.Required(
+				jobPath.Child("savepointsDir"),
+				"it is required to upgrade the job with its state, "+
+					"unless takeSavepointOnUpgrade is false"))
+		}
+	}
+
+	if !reflect.DeepEqual(
+		old.Spec.TaskManager.Replicas, new.Spec.TaskManager.Replicas) &&
+		(new.Spec.TaskManager.Replicas == nil ||
+			*new.Spec.TaskManager.Replicas < 0) {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("taskManager", "replicas"),
+			new.Spec.TaskManager.Replicas,
+			"it must be >= 0"))
+	}
+	if !reflect.DeepEqual(
+		old.Spec.TaskManager.Replicas, new.Spec.TaskManager.Replicas) {
+		allErrs = append(allErrs, v.validateTaskManagerReplicasPolicy(
+			new.Spec.TaskManager.Replicas,
+			specPath.Child("taskManager", "replicas"))...)
+	}
+	if len(allErrs) > 0 {
+		return false, allErrs
+	}
+
+	// Check if only upgradable properties changed, no other changes. The
+	// TaskManager replicas are allowed to change together with the job
+	// parallelism, e.g., when the job is rescaled by the autoscaler.
+	var oldCopy = old.DeepCopy()
+	oldCopy.Spec.Image = new.Spec.Image
+	oldCopy.Spec.FlinkVersion = new.Spec.FlinkVersion
+	oldCopy.Spec.FlinkProperties = new.Spec.FlinkProperties
+	oldCopy.Spec.FlinkPropertiesFrom = new.Spec.FlinkPropertiesFrom
+	oldCopy.Spec.LogConfig = new.Spec.LogConfig
+	oldCopy.Spec.TaskManager.Replicas = new.Spec.TaskManager.Replicas
+	oldCopy.Spec.RestartNonce = new.Spec.RestartNonce
+	oldCopy.Spec.RestartOnChange = new.Spec.RestartOnChange
+	oldCopy.Spec.Timeouts = new.Spec.Timeouts
+	if new.Spec.Job != nil {
+		oldCopy.Spec.Job.JarFile = new.Spec.Job.JarFile
+		oldCopy.Spec.Job.JarSha256 = new.Spec.Job.JarSha256
+		oldCopy.Spec.Job.PythonFile = new.Spec.Job.PythonFile
+		oldCopy.Spec.Job.PythonFiles = new.Spec.Job.PythonFiles
+		oldCopy.Spec.Job.PythonRequirements = new.Spec.Job.PythonRequirements
+		oldCopy.Spec.Job.SQL = new.Spec.Job.SQL
+		oldCopy.Spec.Job.SQLConfigMap = new.Spec.Job.SQLConfigMap
+		oldCopy.Spec.Job.Args = new.Spec.Job.Args
+		oldCopy.Spec.Job.Parallelism = new.Spec.Job.Parallelism
+		oldCopy.Spec.Job.Checkpointing = new.Spec.Job.Checkpointing
+		oldCopy.Spec.Job.Schedule = new.Spec.Job.Schedule
+		oldCopy.Spec.Job.ConcurrencyPolicy = new.Spec.Job.ConcurrencyPolicy
+		oldCopy.Spec.Job.SuccessfulRunsHistoryLimit =
+			new.Spec.Job.SuccessfulRunsHistoryLimit
+		oldCopy.Spec.Job.FailedRunsHistoryLimit = new.Spec.Job.FailedRunsHistoryLimit
+		oldCopy.Spec.Job.DeleteTaskManagersBetweenRuns =
+			new.Spec.Job.DeleteTaskManagersBetweenRuns
+		oldCopy.Spec.Job.UpgradeTimeoutSeconds = new.Spec.Job.UpgradeTimeoutSeconds
+		oldCopy.Spec.Job.SavepointMaxRetries = new.Spec.Job.SavepointMaxRetries
+		oldCopy.Spec.Job.SavepointRetryBackoffSeconds =
+			new.Spec.Job.SavepointRetryBackoffSeconds
+		oldCopy.Spec.Job.SavepointsDir = new.Spec.Job.SavepointsDir
+		oldCopy.Spec.Job.TakeSavepointOnUpgrade = new.Spec.Job.TakeSavepointOnUpgrade
+	}
+	return reflect.DeepEqual(new.Spec, oldCopy.Spec), nil
+}
+
+func (v *Validator) validateMeta(
+	meta *metav1.ObjectMeta, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
 	if len(meta.Name) == 0 {
-		return fmt.Errorf("cluster name is unspecified")
+		allErrs = append(allErrs, field.Required(path.Child("name"), ""))
 	}
 	if len(meta.Namespace) == 0 {
-		return fmt.Errorf("cluster namesapce is unspecified")
+		allErrs = append(allErrs, field.Required(path.Child("namespace"), ""))
+	}
+	return allErrs
+}
+
+func (v *Validator) validateFlinkVersion(
+	flinkVersion string, path *field.Path) field.ErrorList {
+	if len(flinkVersion) == 0 {
+		return nil
+	}
+	if _, _, err := parseFlinkVersion(flinkVersion); err != nil {
+		return field.ErrorList{field.Invalid(
+			path, flinkVersion, "it must be in the format <major>.<minor>[.<patch>]")}
 	}
 	return nil
 }
 
-func (v *Validator) validateHadoopConfig(hadoopConfig *HadoopConfig) error {
+func (v *Validator) validateHadoopConfig(
+	hadoopConfig *HadoopConfig, path *field.Path) field.ErrorList {
 	if hadoopConfig == nil {
 		return nil
 	}
-	if len(hadoopConfig.ConfigMapName) == 0 {
-		return fmt.Errorf("Hadoop ConfigMap name is unspecified")
+	var allErrs field.ErrorList
+	if len(hadoopConfig.ConfigMapName) == 0 && len(hadoopConfig.Properties) == 0 {
+		allErrs = append(allErrs, field.Required(
+			path.Child("configMapName"), "configMapName or properties is required"))
+	} else if len(hadoopConfig.ConfigMapName) > 0 &&
+		len(hadoopConfig.Properties) > 0 {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("properties"),
+			"it cannot be specified together with configMapName"))
+	}
+	if _, ok := hadoopConfig.Properties[""]; ok {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("properties"), "", "the property name must not be empty"))
 	}
 	if len(hadoopConfig.MountPath) == 0 {
-		return fmt.Errorf("Hadoop config volume mount path is unspecified")
+		allErrs = append(allErrs, field.Required(path.Child("mountPath"), ""))
 	}
-	return nil
+	return allErrs
 }
 
-func (v *Validator) validateGCPConfig(gcpConfig *GCPConfig) error {
-	if gcpConfig == nil {
+func (v *Validator) validateGCPConfig(
+	gcpConfig *GCPConfig, path *field.Path) field.ErrorList {
+	if gcpConfig == nil || gcpConfig.ServiceAccount == nil {
 		return nil
 	}
+	var allErrs field.ErrorList
 	var saConfig = gcpConfig.ServiceAccount
-	if saConfig != nil {
-		if len(saConfig.SecretName) == 0 {
-			return fmt.Errorf("GCP service account secret name is unspecified")
-		}
-		if len(saConfig.KeyFile) == 0 {
-			return fmt.Errorf("GCP service account key file name is unspecified")
-		}
-		if len(saConfig.MountPath) == 0 {
-			return fmt.Errorf("GCP service account volume mount path is unspecified")
-		}
-		if strings.HasSuffix(saConfig.MountPath, saConfig.KeyFile) {
-			return fmt.Errorf("invalid GCP service account volume mount path")
-		}
+	var saPath = path.Child("serviceAccount")
+	if len(saConfig.SecretName) == 0 {
+		allErrs = append(allErrs, field.Required(saPath.Child("secretName"), ""))
 	}
-	return nil
+	if len(saConfig.KeyFile) == 0 {
+		allErrs = append(allErrs, field.Required(saPath.Child("keyFile"), ""))
+	}
+	if len(saConfig.MountPath) == 0 {
+		allErrs = append(allErrs, field.Required(saPath.Child("mountPath"), ""))
+	} else if len(saConfig.KeyFile) > 0 &&
+		strings.HasSuffix(saConfig.MountPath, saConfig.KeyFile) {
+		allErrs = append(allErrs, field.Invalid(
+			saPath.Child("mountPath"),
+			saConfig.MountPath,
+			"it must be the directory of the key file"))
+	}
+	return allErrs
 }
 
-func (v *Validator) validateImage(imageSpec *ImageSpec) error {
+func (v *Validator) validateImage(
+	imageSpec *ImageSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
 	if len(imageSpec.Name) == 0 {
-		return fmt.Errorf("image name is unspecified")
+		allErrs = append(allErrs, field.Required(path.Child("name"), ""))
+	} else if !isImageAllowed(imageSpec.Name) {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("name"),
+			fmt.Sprintf(
+				"image must start with one of the allowed prefixes %v",
+				operatorconfig.Get().Webhook.AllowedImagePrefixes)))
 	}
 	switch imageSpec.PullPolicy {
 	case corev1.PullAlways:
 	case corev1.PullIfNotPresent:
 	case corev1.PullNever:
 	default:
-		return fmt.Errorf("invalid image pullPolicy: %v", imageSpec.PullPolicy)
+		allErrs = append(allErrs, field.NotSupported(
+			path.Child("pullPolicy"),
+			string(imageSpec.PullPolicy),
+			[]string{
+				string(corev1.PullAlways),
+				string(corev1.PullIfNotPresent),
+				string(corev1.PullNever),
+			}))
+	}
+	return allErrs
+}
+
+// Checks whether the image is allowed by the operator config, any image is
+// allowed without allowed prefixes.
+func isImageAllowed(image string) bool {
+	var prefixes = operatorconfig.Get().Webhook.AllowedImagePrefixes
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(image, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validates the cluster against the policy of the operator config, e.g., the
+// maximum TaskManager replicas and pod resources.
+func (v *Validator) validatePolicy(cluster *FlinkCluster) field.ErrorList {
+	var allErrs field.ErrorList
+	var policy = &operatorconfig.Get().Webhook
+	var specPath = field.NewPath("spec")
+	var jmPath = specPath.Child("jobManager")
+	var tmPath = specPath.Child("taskManager")
+	for _, label := range policy.RequiredLabels {
+		if _, ok := cluster.Labels[label]; !ok {
+			allErrs = append(allErrs, field.Required(
+				field.NewPath("metadata", "labels").Key(label),
+				"the label is required by the operator policy"))
+		}
+	}
+	allErrs = append(allErrs, v.validateTaskManagerReplicasPolicy(
+		cluster.Spec.TaskManager.Replicas, tmPath.Child("replicas"))...)
+	allErrs = append(allErrs, v.validatePodResourcesPolicy(
+		&cluster.Spec.JobManager.Resources,
+		cluster.Spec.JobManager.Sidecars,
+		jmPath)...)
+	allErrs = append(allErrs, v.validatePodResourcesPolicy(
+		&cluster.Spec.TaskManager.Resources,
+		cluster.Spec.TaskManager.Sidecars,
+		tmPath)...)
+	allErrs = append(allErrs, v.validateContainerImagesPolicy(
+		cluster.Spec.JobManager.Sidecars, jmPath.Child("sidecars"))...)
+	allErrs = append(allErrs, v.validateContainerImagesPolicy(
+		cluster.Spec.JobManager.InitContainers, jmPath.Child("initContainers"))...)
+	allErrs = append(allErrs, v.validateContainerImagesPolicy(
+		cluster.Spec.TaskManager.Sidecars, tmPath.Child("sidecars"))...)
+	allErrs = append(allErrs, v.validateContainerImagesPolicy(
+		cluster.Spec.TaskManager.InitContainers, tmPath.Child("initContainers"))...)
+	return allErrs
+}
+
+func (v *Validator) validateTaskManagerReplicasPolicy(
+	replicas *int32, path *field.Path) field.ErrorList {
+	var maxReplicas = operatorconfig.Get().Webhook.MaxTaskManagerReplicas
+	if maxReplicas > 0 && replicas != nil && *replicas > maxReplicas {
+		return field.ErrorList{field.Invalid(
+			path,
+			*replicas,
+			fmt.Sprintf(
+				"it must be <= %v, the maximum allowed by the operator policy",
+				maxReplicas))}
 	}
 	return nil
 }
 
-func (v *Validator) validateJobManager(jmSpec *JobManagerSpec) error {
-	var err error
+// Validates the total resources of the containers of a JobManager or
+// TaskManager pod against the maximum pod resources of the policy.
+func (v *Validator) validatePodResourcesPolicy(
+	resources *corev1.ResourceRequirements,
+	sidecars []corev1.Container,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var policy = &operatorconfig.Get().Webhook
+	var maxResources = map[corev1.ResourceName]*resource.Quantity{
+		corev1.ResourceCPU:    policy.MaxPodCPU,
+		corev1.ResourceMemory: policy.MaxPodMemory,
+	}
+	for _, name := range []corev1.ResourceName{
+		corev1.ResourceCPU, corev1.ResourceMemory} {
+		var maxQuantity = maxResources[name]
+		if maxQuantity == nil {
+			continue
+		}
+		var total = getContainerResource(resources, name)
+		for i := range sidecars {
+			total.Add(getContainerResource(&sidecars[i].Resources, name))
+		}
+		if total.Cmp(*maxQuantity) > 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("resources", "limits").Key(string(name)),
+				total.String(),
+				fmt.Sprintf(
+					"the %v of the pod must be <= %v, the maximum allowed by the operator policy",
+					name, maxQuantity.String())))
+		}
+	}
+	return allErrs
+}
+
+// Gets the limit of the resource of a container, or its request without a
+// limit.
+func getContainerResource(
+	resources *corev1.ResourceRequirements,
+	name corev1.ResourceName) resource.Quantity {
+	if quantity, ok := resources.Limits[name]; ok {
+		return quantity.DeepCopy()
+	}
+	if quantity, ok := resources.Requests[name]; ok {
+		return quantity.DeepCopy()
+	}
+	return resource.Quantity{}
+}
 
-	// Replicas.
-	if jmSpec.Replicas == nil || *jmSpec.Replicas != 1 {
-		return fmt.Errorf("invalid JobManager replicas, it must be 1")
+func (v *Validator) validateContainerImagesPolicy(
+	containers []corev1.Container, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, container := range containers {
+		if !isImageAllowed(container.Image) {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Index(i).Child("image"),
+				fmt.Sprintf(
+					"image must start with one of the allowed prefixes %v",
+					operatorconfig.Get().Webhook.AllowedImagePrefixes)))
+		}
+	}
+	return allErrs
+}
+
+func (v *Validator) validateJobManager(
+	jmSpec *JobManagerSpec,
+	highAvailability *HighAvailabilitySpec,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// Replicas, standby JobManagers require HA services.
+	if highAvailability == nil {
+		if jmSpec.Replicas == nil || *jmSpec.Replicas != 1 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("replicas"),
+				jmSpec.Replicas,
+				"it must be 1 unless highAvailability is specified"))
+		}
+	} else if jmSpec.Replicas == nil || *jmSpec.Replicas < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("replicas"), jmSpec.Replicas, "it must be >= 1"))
 	}
 
 	// AccessScope.
@@ -260,183 +908,2493 @@ func (v *Validator) validateJobManager(jmSpec *JobManagerSpec) error {
 	case AccessScopeExternal:
 	case AccessScopeNodePort:
 	default:
-		return fmt.Errorf("invalid JobManager access scope: %v", jmSpec.AccessScope)
+		allErrs = append(allErrs, field.NotSupported(
+			path.Child("accessScope"),
+			jmSpec.AccessScope,
+			[]string{
+				AccessScopeCluster,
+				AccessScopeVPC,
+				AccessScopeExternal,
+				AccessScopeNodePort,
+			}))
 	}
 
-	// Ports.
-	err = v.validatePort(jmSpec.Ports.RPC, "rpc", "jobmanager")
-	if err != nil {
-		return err
-	}
-	err = v.validatePort(jmSpec.Ports.Blob, "blob", "jobmanager")
-	if err != nil {
-		return err
-	}
-	err = v.validatePort(jmSpec.Ports.Query, "query", "jobmanager")
-	if err != nil {
-		return err
-	}
-	err = v.validatePort(jmSpec.Ports.UI, "ui", "jobmanager")
-	if err != nil {
-		return err
+	// Ingress.
+	if jmSpec.Ingress != nil && jmSpec.Ingress.Path != nil &&
+		!strings.HasPrefix(*jmSpec.Ingress.Path, "/") {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("ingress", "path"),
+			*jmSpec.Ingress.Path,
+			"it must start with /"))
 	}
 
+	// Service.
+	allErrs = append(allErrs,
+		v.validateJobManagerService(
+			jmSpec.AccessScope, jmSpec.Service, path.Child("service"))...)
+
+	// Ports.
+	var portsPath = path.Child("ports")
+	allErrs = append(allErrs, v.validatePort(jmSpec.Ports.RPC, portsPath.Child("rpc"))...)
+	allErrs = append(allErrs, v.validatePort(jmSpec.Ports.Blob, portsPath.Child("blob"))...)
+	allErrs = append(allErrs, v.validatePort(jmSpec.Ports.Query, portsPath.Child("query"))...)
+	allErrs = append(allErrs, v.validatePort(jmSpec.Ports.UI, portsPath.Child("ui"))...)
+
+	// UIAuthProxy
+	allErrs = append(allErrs, v.validateUIAuthProxy(
+		jmSpec, path.Child("uiAuthProxy"))...)
+
 	// MemoryOffHeapRatio
-	err = v.validateMemoryOffHeapRatio(jmSpec.MemoryOffHeapRatio, "jobmanager")
-	if err != nil {
-		return err
-	}
+	allErrs = append(allErrs, v.validateMemoryOffHeapRatio(
+		jmSpec.MemoryOffHeapRatio, path.Child("memoryOffHeapRatio"))...)
 
 	// MemoryOffHeapMin
-	err = v.validateMemoryOffHeapMin(&jmSpec.MemoryOffHeapMin, jmSpec.Resources.Limits.Memory(), "jobmanager")
-	if err != nil {
-		return err
-	}
+	allErrs = append(allErrs, v.validateMemoryOffHeapMin(
+		&jmSpec.MemoryOffHeapMin,
+		jmSpec.Resources.Limits.Memory(),
+		path.Child("memoryOffHeapMin"))...)
 
-	return nil
-}
+	// Tolerations and Affinity
+	allErrs = append(allErrs, v.validateScheduling(
+		jmSpec.Tolerations, jmSpec.Affinity, jmSpec.PodTemplate, path)...)
 
-func (v *Validator) validateTaskManager(tmSpec *TaskManagerSpec) error {
-	// Replicas.
-	if tmSpec.Replicas < 1 {
-		return fmt.Errorf("invalid TaskManager replicas, it must >= 1")
-	}
+	// PodDisruptionBudget
+	allErrs = append(allErrs, v.validatePodDisruptionBudget(
+		jmSpec.PodDisruptionBudget, path.Child("podDisruptionBudget"))...)
 
-	// Ports.
-	var err error
-	err = v.validatePort(tmSpec.Ports.RPC, "rpc", "taskmanager")
-	if err != nil {
-		return err
-	}
-	err = v.validatePort(tmSpec.Ports.Data, "data", "taskmanager")
-	if err != nil {
-		return err
-	}
-	err = v.validatePort(tmSpec.Ports.Query, "query", "taskmanager")
-	if err != nil {
-		return err
-	}
+	// PriorityClassName
+	allErrs = append(allErrs, v.validatePriorityClassName(
+		jmSpec.PriorityClassName, path.Child("priorityClassName"))...)
 
-	// MemoryOffHeapRatio
-	err = v.validateMemoryOffHeapRatio(tmSpec.MemoryOffHeapRatio, "taskmanager")
-	if err != nil {
-		return err
-	}
+	// SecurityContext and ContainerSecurityContext
+	allErrs = append(allErrs, v.validateSecurityContext(
+		jmSpec.SecurityContext,
+		jmSpec.ContainerSecurityContext,
+		jmSpec.PodTemplate,
+		path)...)
 
-	// MemoryOffHeapMin
-	err = v.validateMemoryOffHeapMin(&tmSpec.MemoryOffHeapMin, tmSpec.Resources.Limits.Memory(), "taskmanager")
-	if err != nil {
-		return err
-	}
+	// ReadinessProbe and LivenessProbe
+	allErrs = append(allErrs, v.validateProbe(
+		jmSpec.ReadinessProbe, path.Child("readinessProbe"))...)
+	allErrs = append(allErrs, v.validateProbe(
+		jmSpec.LivenessProbe, path.Child("livenessProbe"))...)
 
-	return nil
+	// TerminationGracePeriodSeconds
+	allErrs = append(allErrs, v.validateTerminationGracePeriod(
+		jmSpec.TerminationGracePeriodSeconds,
+		path.Child("terminationGracePeriodSeconds"))...)
+
+	// InitContainers and Sidecars
+	allErrs = append(allErrs, v.validateContainerNames(
+		jmSpec.InitContainers, jmSpec.Sidecars, "jobmanager", path)...)
+
+	// DeploymentType and PodManagementPolicy
+	var _, deploymentErrs = v.validateDeploymentType(
+		jmSpec.DeploymentType, jmSpec.PodManagementPolicy, path)
+	allErrs = append(allErrs, deploymentErrs...)
+
+	// Volumes
+	allErrs = append(allErrs, v.validateVolumes(
+		jmSpec.Volumes,
+		nil, /* volumeClaimTemplates */
+		jmSpec.VolumeMounts,
+		jmSpec.InitContainers,
+		jmSpec.PodTemplate,
+		path)...)
+
+	// PodTemplate
+	allErrs = append(allErrs, v.validatePodTemplate(
+		jmSpec.PodTemplate,
+		getSpecifiedPorts(
+			jmSpec.Ports.RPC,
+			jmSpec.Ports.Blob,
+			jmSpec.Ports.Query,
+			jmSpec.Ports.UI),
+		"jobmanager",
+		path.Child("podTemplate"))...)
+
+	return allErrs
 }
 
-func (v *Validator) validateJob(jobSpec *JobSpec) error {
-	if jobSpec == nil {
+// Validates the PodDisruptionBudget of a component, exactly one of
+// `minAvailable` and `maxUnavailable` must be specified, either as a
+// non-negative number or as a percentage.
+func (v *Validator) validatePodDisruptionBudget(
+	pdb *PodDisruptionBudgetSpec, path *field.Path) field.ErrorList {
+	if pdb == nil {
 		return nil
 	}
-
-	if len(jobSpec.JarFile) == 0 {
-		return fmt.Errorf("job jarFile is unspecified")
+	var allErrs field.ErrorList
+	var budgetDetail = "exactly one of minAvailable and maxUnavailable must be specified"
+	if pdb.MinAvailable == nil && pdb.MaxUnavailable == nil {
+		return field.ErrorList{field.Required(path, budgetDetail)}
+	} else if pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+		allErrs = append(allErrs, field.Forbidden(path, budgetDetail))
 	}
+	allErrs = append(allErrs, v.validateIntOrPercent(
+		pdb.MinAvailable, path.Child("minAvailable"))...)
+	allErrs = append(allErrs, v.validateIntOrPercent(
+		pdb.MaxUnavailable, path.Child("maxUnavailable"))...)
+	return allErrs
+}
 
-	if jobSpec.Parallelism == nil {
-		return fmt.Errorf("job parallelism is unspecified")
-	}
-	if *jobSpec.Parallelism < 1 {
-		return fmt.Errorf("job parallelism must be >= 1")
+// Validates the topology spread constraints of the TaskManager pods, like the
+// API server validates them in a pod spec.
+func (v *Validator) validateTopologySpreadConstraints(
+	constraints []TopologySpreadConstraint, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var seen = make(map[string]bool)
+	for i, constraint := range constraints {
+		var constraintPath = path.Index(i)
+		if constraint.MaxSkew < 1 {
+			allErrs = append(allErrs, field.Invalid(
+				constraintPath.Child("maxSkew"),
+				constraint.MaxSkew,
+				"it must be >= 1"))
+		}
+		if len(constraint.TopologyKey) == 0 {
+			allErrs = append(allErrs, field.Required(
+				constraintPath.Child("topologyKey"), ""))
+		} else {
+			for _, msg := range validation.IsQualifiedName(constraint.TopologyKey) {
+				allErrs = append(allErrs, field.Invalid(
+					constraintPath.Child("topologyKey"),
+					constraint.TopologyKey,
+					msg))
+			}
+		}
+		switch constraint.WhenUnsatisfiable {
+		case UnsatisfiableConstraintActionDoNotSchedule,
+			UnsatisfiableConstraintActionScheduleAnyway:
+			var key = constraint.TopologyKey + "/" + constraint.WhenUnsatisfiable
+			if seen[key] {
+				allErrs = append(allErrs, field.Duplicate(
+					constraintPath,
+					"{"+constraint.TopologyKey+", "+constraint.WhenUnsatisfiable+"}"))
+			}
+			seen[key] = true
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				constraintPath.Child("whenUnsatisfiable"),
+				constraint.WhenUnsatisfiable,
+				[]string{
+					UnsatisfiableConstraintActionDoNotSchedule,
+					UnsatisfiableConstraintActionScheduleAnyway,
+				}))
+		}
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(
+			constraint.LabelSelector, constraintPath.Child("labelSelector"))...)
 	}
+	return allErrs
+}
 
-	if jobSpec.RestartPolicy == nil {
-		return fmt.Errorf("job restartPolicy is unspecified")
+// Validates the name of a PriorityClass, which must be a DNS subdomain.
+func (v *Validator) validatePriorityClassName(
+	name *string, path *field.Path) field.ErrorList {
+	if name == nil {
+		return nil
 	}
-	switch *jobSpec.RestartPolicy {
-	case JobRestartPolicyNever:
-	case JobRestartPolicyFromSavepointOnFailure:
-	default:
-		return fmt.Errorf("invalid job restartPolicy: %v", *jobSpec.RestartPolicy)
+	if len(*name) == 0 {
+		return field.ErrorList{field.Required(path, "")}
 	}
-
-	if jobSpec.CleanupPolicy == nil {
-		return fmt.Errorf("job cleanupPolicy is unspecified")
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(*name) {
+		allErrs = append(allErrs, field.Invalid(path, *name, msg))
 	}
-	var err = v.validateCleanupAction(
-		"cleanupPolicy.afterJobSucceeds", jobSpec.CleanupPolicy.AfterJobSucceeds)
-	if err != nil {
-		return err
+	return allErrs
+}
+
+// Validates a number which must be >= 0 or a percentage between 0% and 100%.
+func (v *Validator) validateIntOrPercent(
+	value *intstr.IntOrString, path *field.Path) field.ErrorList {
+	if value == nil {
+		return nil
 	}
-	err = v.validateCleanupAction(
-		"cleanupPolicy.afterJobFails", jobSpec.CleanupPolicy.AfterJobFails)
-	if err != nil {
-		return err
+	if value.Type == intstr.Int {
+		if value.IntVal < 0 {
+			return field.ErrorList{
+				field.Invalid(path, value.IntVal, "it must be >= 0")}
+		}
+		return nil
 	}
-
-	if jobSpec.CancelRequested != nil && *jobSpec.CancelRequested {
-		return fmt.Errorf(
-			"property `cancelRequested` cannot be set to true for a new job")
+	var percent, err = strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+	if !strings.HasSuffix(value.StrVal, "%") || err != nil ||
+		percent < 0 || percent > 100 {
+		return field.ErrorList{field.Invalid(
+			path,
+			value.StrVal,
+			"it must be a number or a percentage between 0% and 100%")}
 	}
-
 	return nil
 }
 
-func (v *Validator) validatePort(
-	port *int32, name string, component string) error {
-	if port == nil {
-		return fmt.Errorf("%v %v port is unspecified", component, name)
+// Validates the deployment type of a component and returns whether it is
+// deployed as a StatefulSet. The pod management policy is only allowed for
+// StatefulSets.
+func (v *Validator) validateDeploymentType(
+	deploymentType *DeploymentType,
+	podManagementPolicy *PodManagementPolicy,
+	path *field.Path) (bool, field.ErrorList) {
+	var allErrs field.ErrorList
+	var isStatefulSet = false
+	if deploymentType != nil {
+		switch *deploymentType {
+		case DeploymentTypeDeployment:
+		case DeploymentTypeStatefulSet:
+			isStatefulSet = true
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("deploymentType"),
+				*deploymentType,
+				[]string{DeploymentTypeDeployment, DeploymentTypeStatefulSet}))
+		}
 	}
-	if *port <= 1024 {
-		return fmt.Errorf(
-			"invalid %v %v port: %v, must be > 1024", component, name, *port)
+	if podManagementPolicy != nil {
+		switch *podManagementPolicy {
+		case PodManagementPolicyOrderedReady:
+		case PodManagementPolicyParallel:
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("podManagementPolicy"),
+				*podManagementPolicy,
+				[]string{PodManagementPolicyOrderedReady, PodManagementPolicyParallel}))
+		}
+		if !isStatefulSet {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("podManagementPolicy"),
+				"it is only allowed with the StatefulSet deployment type"))
+		}
 	}
-	return nil
+	return isStatefulSet, allErrs
 }
 
-func (v *Validator) validateCleanupAction(
-	property string, value CleanupAction) error {
-	switch value {
-	case CleanupActionDeleteCluster:
-	case CleanupActionDeleteTaskManager:
-	case CleanupActionKeepCluster:
-	default:
-		return fmt.Errorf(
-			"invalid %v: %v",
-			property, value)
+// Validates the JobManager service spec. The service type must not conflict
+// with the type derived from the access scope unless the scope is the default
+// "Cluster", and source ranges are only allowed for load balancers.
+func (v *Validator) validateJobManagerService(
+	accessScope string,
+	serviceSpec *JobManagerServiceSpec,
+	path *field.Path) field.ErrorList {
+	if serviceSpec == nil {
+		return nil
 	}
-	return nil
-}
 
-func (v *Validator) validateMemoryOffHeapRatio(
-	offHeapRatio *int32, component string) error {
-	if offHeapRatio == nil || *offHeapRatio > 100 || *offHeapRatio < 0 {
-		return fmt.Errorf("invalid %v memoryOffHeapRatio, it must be between 0 and 100", component)
+	var allErrs field.ErrorList
+	var serviceType = getAccessScopeServiceType(accessScope)
+	if serviceSpec.Type != nil {
+		switch *serviceSpec.Type {
+		case corev1.ServiceTypeClusterIP:
+		case corev1.ServiceTypeNodePort:
+		case corev1.ServiceTypeLoadBalancer:
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("type"),
+				string(*serviceSpec.Type),
+				[]string{
+					string(corev1.ServiceTypeClusterIP),
+					string(corev1.ServiceTypeNodePort),
+					string(corev1.ServiceTypeLoadBalancer),
+				}))
+		}
+		if accessScope != AccessScopeCluster &&
+			*serviceSpec.Type != serviceType {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("type"),
+				string(*serviceSpec.Type),
+				fmt.Sprintf("it conflicts with access scope %v", accessScope)))
+		}
+		serviceType = *serviceSpec.Type
 	}
-	return nil
-}
 
-func (v *Validator) validateMemoryOffHeapMin(
-	offHeapMin *resource.Quantity, memoryLimit *resource.Quantity, component string) error {
-	if offHeapMin == nil {
-		return fmt.Errorf("invalid %v memory configuration, MemoryOffHeapMin is not specified", component)
-	} else if memoryLimit.Value() > 0 {
-		if offHeapMin.Value() > memoryLimit.Value() {
-			return fmt.Errorf("invalid %v memory configuration, memory limit must be larger than MemoryOffHeapMin, "+
-				"memory limit: %d bytes, memoryOffHeapMin: %d bytes", component, memoryLimit.Value(), offHeapMin.Value())
+	var sourceRangesPath = path.Child("loadBalancerSourceRanges")
+	if len(serviceSpec.LoadBalancerSourceRanges) > 0 &&
+		serviceType != corev1.ServiceTypeLoadBalancer {
+		allErrs = append(allErrs, field.Forbidden(
+			sourceRangesPath, "it requires the LoadBalancer service type"))
+	}
+	for i, sourceRange := range serviceSpec.LoadBalancerSourceRanges {
+		var _, _, err = net.ParseCIDR(sourceRange)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				sourceRangesPath.Index(i), sourceRange, "it must be a CIDR"))
 		}
 	}
-	return nil
+
+	return allErrs
 }
 
-// shouldRestartJob returns true if the controller should restart the failed
-// job.
-func shouldRestartJob(
-	restartPolicy *JobRestartPolicy,
-	jobStatus *JobStatus) bool {
-	return restartPolicy != nil &&
-		*restartPolicy == JobRestartPolicyFromSavepointOnFailure &&
-		jobStatus != nil &&
-		jobStatus.State == JobStateFailed &&
-		len(jobStatus.SavepointLocation) > 0
+func (v *Validator) validateTaskManager(
+	tmSpec *TaskManagerSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// Replicas.
+	if tmSpec.Replicas == nil || *tmSpec.Replicas < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("replicas"), tmSpec.Replicas, "it must be >= 0"))
+	}
+
+	// DecommissionTimeoutSeconds.
+	if tmSpec.DecommissionTimeoutSeconds != nil &&
+		*tmSpec.DecommissionTimeoutSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("decommissionTimeoutSeconds"),
+			*tmSpec.DecommissionTimeoutSeconds,
+			"it must be >= 0"))
+	}
+
+	// Ports.
+	var portsPath = path.Child("ports")
+	allErrs = append(allErrs, v.validatePort(tmSpec.Ports.RPC, portsPath.Child("rpc"))...)
+	allErrs = append(allErrs, v.validatePort(tmSpec.Ports.Data, portsPath.Child("data"))...)
+	allErrs = append(allErrs, v.validatePort(tmSpec.Ports.Query, portsPath.Child("query"))...)
+
+	// MemoryOffHeapRatio
+	allErrs = append(allErrs, v.validateMemoryOffHeapRatio(
+		tmSpec.MemoryOffHeapRatio, path.Child("memoryOffHeapRatio"))...)
+
+	// MemoryOffHeapMin
+	allErrs = append(allErrs, v.validateMemoryOffHeapMin(
+		&tmSpec.MemoryOffHeapMin,
+		tmSpec.Resources.Limits.Memory(),
+		path.Child("memoryOffHeapMin"))...)
+
+	// Tolerations and Affinity
+	allErrs = append(allErrs, v.validateScheduling(
+		tmSpec.Tolerations, tmSpec.Affinity, tmSpec.PodTemplate, path)...)
+
+	// TopologySpreadConstraints
+	allErrs = append(allErrs, v.validateTopologySpreadConstraints(
+		tmSpec.TopologySpreadConstraints,
+		path.Child("topologySpreadConstraints"))...)
+
+	// PodDisruptionBudget
+	allErrs = append(allErrs, v.validatePodDisruptionBudget(
+		tmSpec.PodDisruptionBudget, path.Child("podDisruptionBudget"))...)
+
+	// PriorityClassName
+	allErrs = append(allErrs, v.validatePriorityClassName(
+		tmSpec.PriorityClassName, path.Child("priorityClassName"))...)
+
+	// SecurityContext and ContainerSecurityContext
+	allErrs = append(allErrs, v.validateSecurityContext(
+		tmSpec.SecurityContext,
+		tmSpec.ContainerSecurityContext,
+		tmSpec.PodTemplate,
+		path)...)
+
+	// ReadinessProbe and LivenessProbe
+	allErrs = append(allErrs, v.validateProbe(
+		tmSpec.ReadinessProbe, path.Child("readinessProbe"))...)
+	allErrs = append(allErrs, v.validateProbe(
+		tmSpec.LivenessProbe, path.Child("livenessProbe"))...)
+
+	// TerminationGracePeriodSeconds
+	allErrs = append(allErrs, v.validateTerminationGracePeriod(
+		tmSpec.TerminationGracePeriodSeconds,
+		path.Child("terminationGracePeriodSeconds"))...)
+
+	// InitContainers and Sidecars
+	allErrs = append(allErrs, v.validateContainerNames(
+		tmSpec.InitContainers, tmSpec.Sidecars, "taskmanager", path)...)
+
+	// DeploymentType, PodManagementPolicy and VolumeClaimTemplates
+	var isStatefulSet, deploymentErrs = v.validateDeploymentType(
+		tmSpec.DeploymentType, tmSpec.PodManagementPolicy, path)
+	allErrs = append(allErrs, deploymentErrs...)
+	if len(tmSpec.VolumeClaimTemplates) > 0 && !isStatefulSet {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("volumeClaimTemplates"),
+			"it is only allowed with the StatefulSet deployment type"))
+	}
+
+	// Volumes
+	allErrs = append(allErrs, v.validateVolumes(
+		tmSpec.Volumes,
+		tmSpec.VolumeClaimTemplates,
+		tmSpec.VolumeMounts,
+		tmSpec.InitContainers,
+		tmSpec.PodTemplate,
+		path)...)
+
+	// PodTemplate
+	allErrs = append(allErrs, v.validatePodTemplate(
+		tmSpec.PodTemplate,
+		getSpecifiedPorts(tmSpec.Ports.Data, tmSpec.Ports.RPC, tmSpec.Ports.Query),
+		"taskmanager",
+		path.Child("podTemplate"))...)
+
+	return allErrs
+}
+
+func (v *Validator) validateJob(
+	jobSpec *JobSpec, path *field.Path) field.ErrorList {
+	if jobSpec == nil {
+		return nil
+	}
+
+	var allErrs = v.validateJobFile(jobSpec, path)
+
+	if jobSpec.SubmissionMode != nil {
+		switch *jobSpec.SubmissionMode {
+		case JobSubmissionModeSubmitter:
+		case JobSubmissionModeRestAPI:
+			allErrs = append(allErrs,
+				v.validateRestAPIJarFile(jobSpec.JarFile, path)...)
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("submissionMode"),
+				*jobSpec.SubmissionMode,
+				[]string{JobSubmissionModeSubmitter, JobSubmissionModeRestAPI}))
+		}
+	}
+
+	if jobSpec.Parallelism == nil {
+		allErrs = append(allErrs, field.Required(path.Child("parallelism"), ""))
+	} else if *jobSpec.Parallelism < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("parallelism"), *jobSpec.Parallelism, "it must be >= 1"))
+	}
+
+	if jobSpec.ReadySlotsRequired != nil && *jobSpec.ReadySlotsRequired < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("readySlotsRequired"),
+			*jobSpec.ReadySlotsRequired,
+			"it must be >= 1"))
+	}
+
+	if jobSpec.FromSavepoint != nil {
+		var savepointURL, err = url.Parse(*jobSpec.FromSavepoint)
+		if err != nil || len(savepointURL.Scheme) == 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("fromSavepoint"),
+				*jobSpec.FromSavepoint,
+				"the URI scheme is unspecified"))
+		}
+	}
+
+	if jobSpec.AutoSavepointSeconds != nil {
+		if *jobSpec.AutoSavepointSeconds < 1 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("autoSavepointSeconds"),
+				*jobSpec.AutoSavepointSeconds,
+				"it must be >= 1"))
+		}
+		if jobSpec.SavepointsDir == nil || len(*jobSpec.SavepointsDir) == 0 {
+			allErrs = append(allErrs, field.Required(
+				path.Child("savepointsDir"),
+				"it is required when autoSavepointSeconds is specified"))
+		}
+	}
+
+	if jobSpec.UpgradeTimeoutSeconds != nil {
+		if *jobSpec.UpgradeTimeoutSeconds < 1 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("upgradeTimeoutSeconds"),
+				*jobSpec.UpgradeTimeoutSeconds,
+				"it must be >= 1"))
+		}
+		if jobSpec.SavepointsDir == nil || len(*jobSpec.SavepointsDir) == 0 {
+			allErrs = append(allErrs, field.Required(
+				path.Child("savepointsDir"),
+				"it is required when upgradeTimeoutSeconds is specified"))
+		}
+	}
+
+	if jobSpec.SavepointHistoryLimit != nil && *jobSpec.SavepointHistoryLimit < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("savepointHistoryLimit"),
+			*jobSpec.SavepointHistoryLimit,
+			"it must be >= 1"))
+	}
+
+	if jobSpec.RestartPolicy == nil {
+		allErrs = append(allErrs, field.Required(path.Child("restartPolicy"), ""))
+	} else {
+		switch *jobSpec.RestartPolicy {
+		case JobRestartPolicyNever:
+		case JobRestartPolicyFromSavepointOnFailure:
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("restartPolicy"),
+				*jobSpec.RestartPolicy,
+				[]string{
+					JobRestartPolicyNever,
+					JobRestartPolicyFromSavepointOnFailure,
+				}))
+		}
+	}
+	if jobSpec.MaxStateAgeToRestoreSeconds != nil &&
+		*jobSpec.MaxStateAgeToRestoreSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("maxStateAgeToRestoreSeconds"),
+			*jobSpec.MaxStateAgeToRestoreSeconds,
+			"it must be >= 1"))
+	}
+
+	allErrs = append(allErrs, v.validateJobRetries(jobSpec, path)...)
+	allErrs = append(allErrs, v.validateSavepointRetries(jobSpec, path)...)
+
+	if jobSpec.CleanupPolicy == nil {
+		allErrs = append(allErrs, field.Required(path.Child("cleanupPolicy"), ""))
+	} else {
+		var cleanupPolicyPath = path.Child("cleanupPolicy")
+		allErrs = append(allErrs, v.validateCleanupAction(
+			jobSpec.CleanupPolicy.AfterJobSucceeds,
+			cleanupPolicyPath.Child("afterJobSucceeds"))...)
+		allErrs = append(allErrs, v.validateCleanupAction(
+			jobSpec.CleanupPolicy.AfterJobFails,
+			cleanupPolicyPath.Child("afterJobFails"))...)
+	}
+
+	if jobSpec.StopWithDrain != nil && *jobSpec.StopWithDrain {
+		if jobSpec.SavepointsDir == nil || len(*jobSpec.SavepointsDir) == 0 {
+			allErrs = append(allErrs, field.Required(
+				path.Child("savepointsDir"), "it is required by stopWithDrain"))
+		}
+		if jobSpec.TakeSavepointOnCancel != nil && !*jobSpec.TakeSavepointOnCancel {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("stopWithDrain"), "it requires takeSavepointOnCancel"))
+		}
+	}
+
+	if jobSpec.TakeSavepointOnDelete != nil && *jobSpec.TakeSavepointOnDelete {
+		if jobSpec.SavepointsDir == nil || len(*jobSpec.SavepointsDir) == 0 {
+			allErrs = append(allErrs, field.Required(
+				path.Child("savepointsDir"), "it is required by takeSavepointOnDelete"))
+		}
+	}
+
+	if jobSpec.TTLSecondsAfterFinished != nil {
+		var ttlPath = path.Child("ttlSecondsAfterFinished")
+		if *jobSpec.TTLSecondsAfterFinished < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				ttlPath, *jobSpec.TTLSecondsAfterFinished, "it must be >= 0"))
+		} else if jobSpec.Schedule != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				ttlPath, "it is not supported for scheduled jobs"))
+		}
+	}
+
+	if jobSpec.CancelRequested != nil && *jobSpec.CancelRequested {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("cancelRequested"),
+			"it cannot be set to true for a new job"))
+	}
+
+	allErrs = append(allErrs, v.validateJobSchedule(jobSpec, path)...)
+
+	allErrs = append(allErrs, v.validateScheduling(
+		jobSpec.Tolerations, jobSpec.Affinity, nil /* podTemplate */, path)...)
+
+	allErrs = append(allErrs, v.validatePriorityClassName(
+		jobSpec.PriorityClassName, path.Child("priorityClassName"))...)
+
+	allErrs = append(allErrs, v.validateServiceAccountName(
+		jobSpec.ServiceAccountName, path.Child("serviceAccountName"))...)
+
+	allErrs = append(allErrs, v.validateJobPodMetadata(jobSpec, path)...)
+
+	allErrs = append(allErrs, v.validateJobSubmitterGates(jobSpec, path)...)
+
+	allErrs = append(allErrs, v.validateSecurityContext(
+		jobSpec.SecurityContext,
+		jobSpec.ContainerSecurityContext,
+		nil, /* podTemplate */
+		path)...)
+
+	allErrs = append(allErrs, v.validateContainerNames(
+		jobSpec.InitContainers, nil /* sidecars */, "main", path)...)
+
+	allErrs = append(allErrs, v.validateVolumes(
+		jobSpec.Volumes,
+		nil, /* volumeClaimTemplates */
+		jobSpec.VolumeMounts,
+		jobSpec.InitContainers,
+		nil, /* podTemplate */
+		path)...)
+
+	return allErrs
+}
+
+// Validates the checkpointing of the job. The `execution.checkpointing.*`
+// properties require Flink 1.10+, and unaligned checkpoints require Flink
+// 1.11+ and the exactly-once mode.
+func (v *Validator) validateCheckpointing(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	if clusterSpec.Job == nil || clusterSpec.Job.Checkpointing == nil {
+		return nil
+	}
+	var checkpointing = clusterSpec.Job.Checkpointing
+	var path = specPath.Child("job", "checkpointing")
+	var allErrs field.ErrorList
+	var flinkVersion = clusterSpec.FlinkVersion
+	var _, _, versionErr = parseFlinkVersion(flinkVersion)
+	var hasFlinkVersion = len(flinkVersion) > 0 && versionErr == nil
+	if hasFlinkVersion && !isFlinkVersionAtLeast(flinkVersion, 1, 10) {
+		allErrs = append(allErrs, field.Forbidden(path, "it requires Flink 1.10+"))
+	}
+	if checkpointing.IntervalSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("intervalSeconds"),
+			checkpointing.IntervalSeconds,
+			"it must be >= 1"))
+	}
+	if checkpointing.Mode != nil {
+		switch *checkpointing.Mode {
+		case CheckpointingModeExactlyOnce:
+		case CheckpointingModeAtLeastOnce:
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("mode"),
+				*checkpointing.Mode,
+				[]string{CheckpointingModeExactlyOnce, CheckpointingModeAtLeastOnce}))
+		}
+	}
+	if checkpointing.TimeoutSeconds != nil && *checkpointing.TimeoutSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("timeoutSeconds"),
+			*checkpointing.TimeoutSeconds,
+			"it must be >= 1"))
+	}
+	if checkpointing.MinPauseSeconds != nil && *checkpointing.MinPauseSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("minPauseSeconds"),
+			*checkpointing.MinPauseSeconds,
+			"it must be >= 0"))
+	}
+	if checkpointing.ExternalizedRetention != nil {
+		switch *checkpointing.ExternalizedRetention {
+		case CheckpointRetentionRetainOnCancellation:
+		case CheckpointRetentionDeleteOnCancellation:
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("externalizedRetention"),
+				*checkpointing.ExternalizedRetention,
+				[]string{
+					CheckpointRetentionRetainOnCancellation,
+					CheckpointRetentionDeleteOnCancellation,
+				}))
+		}
+	}
+	if checkpointing.Unaligned != nil && *checkpointing.Unaligned {
+		var unalignedPath = path.Child("unaligned")
+		if checkpointing.Mode != nil &&
+			*checkpointing.Mode == CheckpointingModeAtLeastOnce {
+			allErrs = append(allErrs, field.Forbidden(
+				unalignedPath, "it requires the ExactlyOnce mode"))
+		}
+		if hasFlinkVersion && !isFlinkVersionAtLeast(flinkVersion, 1, 11) {
+			allErrs = append(allErrs, field.Forbidden(
+				unalignedPath, "it requires Flink 1.11+"))
+		}
+	}
+	return allErrs
+}
+
+// Validates the retries of the failed savepoints, the backoff is only allowed
+// with `savepointMaxRetries`.
+func (v *Validator) validateSavepointRetries(
+	jobSpec *JobSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if jobSpec.SavepointMaxRetries == nil {
+		if jobSpec.SavepointRetryBackoffSeconds != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("savepointRetryBackoffSeconds"),
+				"it is only allowed with savepointMaxRetries"))
+		}
+		return allErrs
+	}
+	if *jobSpec.SavepointMaxRetries < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("savepointMaxRetries"),
+			*jobSpec.SavepointMaxRetries,
+			"it must be >= 0"))
+	}
+	if jobSpec.SavepointRetryBackoffSeconds == nil ||
+		*jobSpec.SavepointRetryBackoffSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("savepointRetryBackoffSeconds"),
+			jobSpec.SavepointRetryBackoffSeconds,
+			"it must be >= 1"))
+	}
+	return allErrs
+}
+
+// Validates the retries of failed job submissions, the backoff is only
+// allowed with `maxRetries`.
+func (v *Validator) validateJobRetries(
+	jobSpec *JobSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if jobSpec.MaxRetries == nil {
+		if jobSpec.RetryBackoffSeconds != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("retryBackoffSeconds"),
+				"it is only allowed with maxRetries"))
+		}
+		if jobSpec.MaxRetryBackoffSeconds != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("maxRetryBackoffSeconds"),
+				"it is only allowed with maxRetries"))
+		}
+		return allErrs
+	}
+	if *jobSpec.MaxRetries < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("maxRetries"), *jobSpec.MaxRetries, "it must be >= 0"))
+	}
+	if jobSpec.RetryBackoffSeconds == nil || *jobSpec.RetryBackoffSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("retryBackoffSeconds"),
+			jobSpec.RetryBackoffSeconds,
+			"it must be >= 1"))
+	} else if jobSpec.MaxRetryBackoffSeconds == nil ||
+		*jobSpec.MaxRetryBackoffSeconds < *jobSpec.RetryBackoffSeconds {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("maxRetryBackoffSeconds"),
+			jobSpec.MaxRetryBackoffSeconds,
+			"it must be >= retryBackoffSeconds"))
+	}
+	return allErrs
+}
+
+// Validates the schedule of a batch job and the options of the scheduled runs,
+// which are only allowed with `schedule`.
+func (v *Validator) validateJobSchedule(
+	jobSpec *JobSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if jobSpec.Schedule == nil {
+		var scheduleOptions = []struct {
+			name      string
+			specified bool
+		}{
+			{"concurrencyPolicy", jobSpec.ConcurrencyPolicy != nil},
+			{"successfulRunsHistoryLimit", jobSpec.SuccessfulRunsHistoryLimit != nil},
+			{"failedRunsHistoryLimit", jobSpec.FailedRunsHistoryLimit != nil},
+			{"deleteTaskManagersBetweenRuns", jobSpec.DeleteTaskManagersBetweenRuns != nil},
+		}
+		for _, option := range scheduleOptions {
+			if option.specified {
+				allErrs = append(allErrs, field.Forbidden(
+					path.Child(option.name), "it is only allowed with schedule"))
+			}
+		}
+		return allErrs
+	}
+	var _, err = GetNextScheduleTime(*jobSpec.Schedule, time.Now())
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("schedule"), *jobSpec.Schedule, err.Error()))
+	}
+	if isRestAPISubmission(jobSpec) {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("schedule"),
+			"it is not supported in RestAPI submission mode"))
+	}
+	if jobSpec.ConcurrencyPolicy != nil {
+		switch *jobSpec.ConcurrencyPolicy {
+		case JobConcurrencyPolicyForbid:
+		case JobConcurrencyPolicyReplace:
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				path.Child("concurrencyPolicy"),
+				*jobSpec.ConcurrencyPolicy,
+				[]string{JobConcurrencyPolicyForbid, JobConcurrencyPolicyReplace}))
+		}
+	}
+	if jobSpec.SuccessfulRunsHistoryLimit != nil &&
+		*jobSpec.SuccessfulRunsHistoryLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("successfulRunsHistoryLimit"),
+			*jobSpec.SuccessfulRunsHistoryLimit,
+			"it must be >= 0"))
+	}
+	if jobSpec.FailedRunsHistoryLimit != nil &&
+		*jobSpec.FailedRunsHistoryLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("failedRunsHistoryLimit"),
+			*jobSpec.FailedRunsHistoryLimit,
+			"it must be >= 0"))
+	}
+	return allErrs
+}
+
+// Validates the program of the job, it is either a JAR file, a PyFlink
+// program or Flink SQL statements, exactly one of `jarFile`, `pythonFile`,
+// `sql` and `sqlConfigMap` must be specified.
+func (v *Validator) validateJobFile(
+	jobSpec *JobSpec, path *field.Path) field.ErrorList {
+	var hasJarFile = len(jobSpec.JarFile) > 0
+	var hasPythonFile = jobSpec.PythonFile != nil && len(*jobSpec.PythonFile) > 0
+	var hasSQL = jobSpec.SQL != nil && len(*jobSpec.SQL) > 0
+	var hasSQLConfigMap = jobSpec.SQLConfigMap != nil
+	var numPrograms = 0
+	for _, specified := range []bool{
+		hasJarFile, hasPythonFile, hasSQL, hasSQLConfigMap} {
+		if specified {
+			numPrograms++
+		}
+	}
+	var programDetail = "exactly one of jarFile, pythonFile, sql and sqlConfigMap must be specified"
+	if numPrograms == 0 {
+		return field.ErrorList{field.Required(path, programDetail)}
+	} else if numPrograms > 1 {
+		return field.ErrorList{field.Forbidden(path, programDetail)}
+	}
+	var allErrs field.ErrorList
+	if !hasPythonFile {
+		if len(jobSpec.PythonFiles) > 0 {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("pythonFiles"), "it is only allowed with pythonFile"))
+		}
+		if jobSpec.PythonRequirements != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("pythonRequirements"), "it is only allowed with pythonFile"))
+		}
+	}
+	if hasJarFile {
+		return append(
+			allErrs, v.validateJarFile(jobSpec.JarFile, jobSpec.JarSha256, path)...)
+	}
+	if jobSpec.JarSha256 != nil {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("jarSha256"), "it is only allowed for a remote jarFile"))
+	}
+	if hasSQLConfigMap {
+		if len(jobSpec.SQLConfigMap.Name) == 0 {
+			allErrs = append(allErrs,
+				field.Required(path.Child("sqlConfigMap", "name"), ""))
+		}
+		if len(jobSpec.SQLConfigMap.Key) == 0 {
+			allErrs = append(allErrs,
+				field.Required(path.Child("sqlConfigMap", "key"), ""))
+		}
+	}
+	if isRestAPISubmission(jobSpec) {
+		if hasPythonFile {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("pythonFile"),
+				"it is not supported in RestAPI submission mode"))
+		} else if hasSQL {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("sql"),
+				"it is not supported in RestAPI submission mode"))
+		} else {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("sqlConfigMap"),
+				"it is not supported in RestAPI submission mode"))
+		}
+	}
+	return allErrs
+}
+
+// Validates the job JAR file, a remote JAR file must have one of the schemes
+// supported by the JAR downloader.
+func (v *Validator) validateJarFile(
+	jarFile string, jarSha256 *string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var jarFilePath = path.Child("jarFile")
+	if len(jarFile) == 0 {
+		return field.ErrorList{field.Required(jarFilePath, "")}
+	}
+	if strings.Contains(jarFile, "://") {
+		var jarURL, err = url.Parse(jarFile)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(jarFilePath, jarFile, err.Error()))
+		} else {
+			switch jarURL.Scheme {
+			case "http", "https", "gs", "s3":
+			default:
+				allErrs = append(allErrs, field.Invalid(
+					jarFilePath,
+					jarFile,
+					"the URI scheme must be http, https, gs or s3"))
+			}
+		}
+	}
+	if jarSha256 != nil {
+		var jarSha256Path = path.Child("jarSha256")
+		if !strings.Contains(jarFile, "://") {
+			allErrs = append(allErrs, field.Forbidden(
+				jarSha256Path, "it is only allowed for a remote jarFile"))
+		}
+		if !sha256Pattern.MatchString(*jarSha256) {
+			allErrs = append(allErrs, field.Invalid(
+				jarSha256Path, *jarSha256, "it must be 64 hex characters"))
+		}
+	}
+	return allErrs
+}
+
+// The operator downloads the JAR file by itself in the "RestAPI" submission
+// mode, so it must be reachable over HTTP(S).
+func (v *Validator) validateRestAPIJarFile(
+	jarFile string, path *field.Path) field.ErrorList {
+	var jarURL, err = url.Parse(jarFile)
+	if err != nil || (jarURL.Scheme != "http" && jarURL.Scheme != "https") {
+		return field.ErrorList{field.Invalid(
+			path.Child("jarFile"),
+			jarFile,
+			"it must be an HTTP or HTTPS URL in RestAPI submission mode")}
+	}
+	return nil
+}
+
+func (v *Validator) validatePort(port *int32, path *field.Path) field.ErrorList {
+	if port == nil {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	if *port <= 1024 {
+		return field.ErrorList{field.Invalid(path, *port, "it must be > 1024")}
+	}
+	return nil
+}
+
+func (v *Validator) validateCleanupAction(
+	value CleanupAction, path *field.Path) field.ErrorList {
+	switch value {
+	case CleanupActionDeleteCluster:
+	case CleanupActionDeleteTaskManager:
+	case CleanupActionKeepCluster:
+	default:
+		return field.ErrorList{field.NotSupported(
+			path,
+			string(value),
+			[]string{
+				string(CleanupActionDeleteCluster),
+				string(CleanupActionDeleteTaskManager),
+				string(CleanupActionKeepCluster),
+			})}
+	}
+	return nil
+}
+
+func (v *Validator) validateMemoryOffHeapRatio(
+	offHeapRatio *int32, path *field.Path) field.ErrorList {
+	if offHeapRatio == nil || *offHeapRatio > 100 || *offHeapRatio < 0 {
+		return field.ErrorList{field.Invalid(
+			path, offHeapRatio, "it must be between 0 and 100")}
+	}
+	return nil
+}
+
+func (v *Validator) validateMemoryOffHeapMin(
+	offHeapMin *resource.Quantity,
+	memoryLimit *resource.Quantity,
+	path *field.Path) field.ErrorList {
+	if offHeapMin == nil {
+		return field.ErrorList{field.Required(path, "")}
+	} else if memoryLimit.Value() > 0 && offHeapMin.Value() > memoryLimit.Value() {
+		return field.ErrorList{field.Invalid(
+			path,
+			offHeapMin.String(),
+			fmt.Sprintf(
+				"it must not be larger than the memory limit %v", memoryLimit.String()))}
+	}
+	return nil
+}
+
+// Validates the names of the init containers and sidecars of a component,
+// they must be unique in the pod and must not be the name of the main
+// container.
+// Validates the auth proxy sidecar of the web UI, its container name and port
+// must not conflict with the other containers and ports of the JobManager.
+func (v *Validator) validateUIAuthProxy(
+	jmSpec *JobManagerSpec, path *field.Path) field.ErrorList {
+	var proxy = jmSpec.UIAuthProxy
+	if proxy == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var namePath = path.Child("container", "name")
+	if len(proxy.Container.Name) == 0 {
+		allErrs = append(allErrs, field.Required(namePath, ""))
+	} else if proxy.Container.Name == "jobmanager" {
+		allErrs = append(allErrs, field.Invalid(
+			namePath, proxy.Container.Name, "the container name is reserved"))
+	} else {
+		for _, sidecar := range jmSpec.Sidecars {
+			if sidecar.Name == proxy.Container.Name {
+				allErrs = append(allErrs, field.Duplicate(namePath, proxy.Container.Name))
+			}
+		}
+	}
+	if len(proxy.Container.Image) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("container", "image"), ""))
+	}
+	var portPath = path.Child("port")
+	allErrs = append(allErrs, v.validatePort(proxy.Port, portPath)...)
+	if proxy.Port != nil {
+		var ports = jmSpec.Ports
+		for _, port := range []*int32{ports.RPC, ports.Blob, ports.Query, ports.UI} {
+			if port != nil && *port == *proxy.Port {
+				allErrs = append(allErrs, field.Invalid(
+					portPath, *proxy.Port, "it conflicts with the JobManager ports"))
+				break
+			}
+		}
+	}
+	return allErrs
+}
+
+func (v *Validator) validateContainerNames(
+	initContainers []corev1.Container,
+	sidecars []corev1.Container,
+	mainContainer string,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var names = map[string]struct{}{}
+	var validateName = func(name string, namePath *field.Path) {
+		if len(name) == 0 {
+			allErrs = append(allErrs, field.Required(namePath, ""))
+		} else if name == mainContainer {
+			allErrs = append(allErrs, field.Invalid(
+				namePath, name, "the container name is reserved"))
+		} else if _, ok := names[name]; ok {
+			allErrs = append(allErrs, field.Duplicate(namePath, name))
+		}
+		names[name] = struct{}{}
+	}
+	for i, initContainer := range initContainers {
+		validateName(
+			initContainer.Name, path.Child("initContainers").Index(i).Child("name"))
+	}
+	for i, sidecar := range sidecars {
+		validateName(sidecar.Name, path.Child("sidecars").Index(i).Child("name"))
+	}
+	return allErrs
+}
+
+// Validates the tolerations of a component, and checks that the affinity is
+// not specified in both the component spec and its pod template.
+func (v *Validator) validateScheduling(
+	tolerations []corev1.Toleration,
+	affinity *corev1.Affinity,
+	podTemplate *corev1.PodTemplateSpec,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, toleration := range tolerations {
+		var tolerationPath = path.Child("tolerations").Index(i)
+		switch toleration.Operator {
+		case "", corev1.TolerationOpEqual:
+			if len(toleration.Key) == 0 {
+				allErrs = append(allErrs, field.Required(
+					tolerationPath.Child("key"),
+					"it is required unless the operator is Exists"))
+			}
+		case corev1.TolerationOpExists:
+			if len(toleration.Value) > 0 {
+				allErrs = append(allErrs, field.Invalid(
+					tolerationPath.Child("value"),
+					toleration.Value,
+					"it must be empty when the operator is Exists"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				tolerationPath.Child("operator"),
+				string(toleration.Operator),
+				[]string{
+					string(corev1.TolerationOpEqual),
+					string(corev1.TolerationOpExists),
+				}))
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule:
+			if toleration.TolerationSeconds != nil {
+				allErrs = append(allErrs, field.Forbidden(
+					tolerationPath.Child("tolerationSeconds"),
+					"it is only allowed with the NoExecute effect"))
+			}
+		case corev1.TaintEffectNoExecute:
+		default:
+			allErrs = append(allErrs, field.NotSupported(
+				tolerationPath.Child("effect"),
+				string(toleration.Effect),
+				[]string{
+					string(corev1.TaintEffectNoSchedule),
+					string(corev1.TaintEffectPreferNoSchedule),
+					string(corev1.TaintEffectNoExecute),
+				}))
+		}
+	}
+	if affinity != nil && podTemplate != nil &&
+		podTemplate.Spec.Affinity != nil {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("podTemplate", "spec", "affinity"),
+			"it cannot be specified together with affinity"))
+	}
+	return allErrs
+}
+
+// Validates the environment variables of the cluster and its components. The
+// names must be unique in each list, and the names in the `env` of a component
+// must not be set in the cluster `envVars`.
+func (v *Validator) validateEnvVars(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, v.validateEnv(
+		clusterSpec.EnvVars, nil /* sharedEnv */, specPath.Child("envVars"))...)
+
+	var jmPath = specPath.Child("jobManager")
+	allErrs = append(allErrs, v.validateEnv(
+		clusterSpec.JobManager.Env, clusterSpec.EnvVars, jmPath.Child("env"))...)
+	allErrs = append(allErrs, v.validateEnvFrom(
+		clusterSpec.JobManager.EnvFrom, jmPath.Child("envFrom"))...)
+
+	var tmPath = specPath.Child("taskManager")
+	allErrs = append(allErrs, v.validateEnv(
+		clusterSpec.TaskManager.Env, clusterSpec.EnvVars, tmPath.Child("env"))...)
+	allErrs = append(allErrs, v.validateEnvFrom(
+		clusterSpec.TaskManager.EnvFrom, tmPath.Child("envFrom"))...)
+
+	if clusterSpec.Job != nil {
+		var jobPath = specPath.Child("job")
+		allErrs = append(allErrs, v.validateEnv(
+			clusterSpec.Job.Env, clusterSpec.EnvVars, jobPath.Child("env"))...)
+		allErrs = append(allErrs, v.validateEnvFrom(
+			clusterSpec.Job.EnvFrom, jobPath.Child("envFrom"))...)
+	}
+	return allErrs
+}
+
+// Validates the names of a list of environment variables, they must be valid
+// and unique, and must not be set in `sharedEnv`.
+func (v *Validator) validateEnv(
+	env []corev1.EnvVar,
+	sharedEnv []corev1.EnvVar,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var sharedNames = map[string]struct{}{}
+	for _, envVar := range sharedEnv {
+		sharedNames[envVar.Name] = struct{}{}
+	}
+	var names = map[string]struct{}{}
+	for i, envVar := range env {
+		var namePath = path.Index(i).Child("name")
+		if len(envVar.Name) == 0 {
+			allErrs = append(allErrs, field.Required(namePath, ""))
+			continue
+		}
+		for _, msg := range validation.IsEnvVarName(envVar.Name) {
+			allErrs = append(allErrs, field.Invalid(namePath, envVar.Name, msg))
+		}
+		if _, ok := names[envVar.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(namePath, envVar.Name))
+		} else if _, ok := sharedNames[envVar.Name]; ok {
+			allErrs = append(allErrs, field.Invalid(
+				namePath, envVar.Name, "it is already set in envVars"))
+		}
+		names[envVar.Name] = struct{}{}
+	}
+	return allErrs
+}
+
+// Validates the sources of environment variables, each one must reference
+// either a ConfigMap or a Secret.
+func (v *Validator) validateEnvFrom(
+	envFrom []corev1.EnvFromSource, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, source := range envFrom {
+		var sourcePath = path.Index(i)
+		if len(source.Prefix) > 0 {
+			for _, msg := range validation.IsEnvVarName(source.Prefix) {
+				allErrs = append(allErrs, field.Invalid(
+					sourcePath.Child("prefix"), source.Prefix, msg))
+			}
+		}
+		switch {
+		case source.ConfigMapRef == nil && source.SecretRef == nil:
+			allErrs = append(allErrs, field.Required(
+				sourcePath, "one of configMapRef or secretRef is required"))
+		case source.ConfigMapRef != nil && source.SecretRef != nil:
+			allErrs = append(allErrs, field.Forbidden(
+				sourcePath.Child("secretRef"),
+				"it cannot be specified together with configMapRef"))
+		case source.ConfigMapRef != nil && len(source.ConfigMapRef.Name) == 0:
+			allErrs = append(allErrs, field.Required(
+				sourcePath.Child("configMapRef", "name"), ""))
+		case source.SecretRef != nil && len(source.SecretRef.Name) == 0:
+			allErrs = append(allErrs, field.Required(
+				sourcePath.Child("secretRef", "name"), ""))
+		}
+	}
+	return allErrs
+}
+
+// Validates the security contexts of a component. The pod security context
+// cannot be specified in both the component spec and its pod template, and a
+// privileged container always allows privilege escalation.
+func (v *Validator) validateSecurityContext(
+	securityContext *corev1.PodSecurityContext,
+	containerSecurityContext *corev1.SecurityContext,
+	podTemplate *corev1.PodTemplateSpec,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if securityContext != nil && podTemplate != nil &&
+		podTemplate.Spec.SecurityContext != nil {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("podTemplate", "spec", "securityContext"),
+			"it cannot be specified together with securityContext"))
+	}
+	if containerSecurityContext != nil &&
+		containerSecurityContext.Privileged != nil &&
+		*containerSecurityContext.Privileged &&
+		containerSecurityContext.AllowPrivilegeEscalation != nil &&
+		!*containerSecurityContext.AllowPrivilegeEscalation {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("containerSecurityContext", "allowPrivilegeEscalation"),
+			false,
+			"it cannot be false when privileged is true"))
+	}
+	return allErrs
+}
+
+func (v *Validator) validateTerminationGracePeriod(
+	seconds *int64, path *field.Path) field.ErrorList {
+	if seconds != nil && *seconds < 0 {
+		return field.ErrorList{
+			field.Invalid(path, *seconds, "it must be >= 0")}
+	}
+	return nil
+}
+
+// Validates the probe of a JobManager or TaskManager container, which has at
+// most one handler, the default one is used without a handler.
+func (v *Validator) validateProbe(
+	probe *corev1.Probe, path *field.Path) field.ErrorList {
+	if probe == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var handlers = 0
+	if probe.Exec != nil {
+		handlers++
+	}
+	if probe.HTTPGet != nil {
+		handlers++
+	}
+	if probe.TCPSocket != nil {
+		handlers++
+	}
+	if handlers > 1 {
+		allErrs = append(allErrs, field.Forbidden(
+			path, "only one of exec, httpGet and tcpSocket may be specified"))
+	}
+	var thresholds = []struct {
+		name  string
+		value int32
+	}{
+		{"initialDelaySeconds", probe.InitialDelaySeconds},
+		{"timeoutSeconds", probe.TimeoutSeconds},
+		{"periodSeconds", probe.PeriodSeconds},
+		{"successThreshold", probe.SuccessThreshold},
+		{"failureThreshold", probe.FailureThreshold},
+	}
+	for _, threshold := range thresholds {
+		if threshold.value < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child(threshold.name), threshold.value, "it must be >= 0"))
+		}
+	}
+	return allErrs
+}
+
+// Validates the volumes of a component and checks that the volume mounts of
+// the component and its init containers reference declared volumes, either in
+// `volumes` or in the pod template.
+func (v *Validator) validateVolumes(
+	volumes []corev1.Volume,
+	volumeClaimTemplates []corev1.PersistentVolumeClaim,
+	volumeMounts []corev1.VolumeMount,
+	initContainers []corev1.Container,
+	podTemplate *corev1.PodTemplateSpec,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var declaredVolumes = map[string]struct{}{}
+	var declareVolume = func(name string, namePath *field.Path) {
+		if len(name) == 0 {
+			allErrs = append(allErrs, field.Required(namePath, ""))
+			return
+		}
+		if _, ok := reservedVolumeNames[name]; ok {
+			allErrs = append(allErrs, field.Invalid(
+				namePath, name, "the volume name is reserved"))
+		} else if _, ok := declaredVolumes[name]; ok {
+			allErrs = append(allErrs, field.Duplicate(namePath, name))
+		}
+		declaredVolumes[name] = struct{}{}
+	}
+	for i, volume := range volumes {
+		declareVolume(volume.Name, path.Child("volumes").Index(i).Child("name"))
+	}
+	// The claims of the templates are mounted as volumes of the same name.
+	for i, claimTemplate := range volumeClaimTemplates {
+		var namePath = path.Child("volumeClaimTemplates").Index(i).Child("metadata", "name")
+		declareVolume(claimTemplate.Name, namePath)
+		if len(claimTemplate.Name) > 0 {
+			for _, msg := range validation.IsDNS1123Label(claimTemplate.Name) {
+				allErrs = append(allErrs, field.Invalid(namePath, claimTemplate.Name, msg))
+			}
+		}
+	}
+	if podTemplate != nil {
+		for _, volume := range podTemplate.Spec.Volumes {
+			declaredVolumes[volume.Name] = struct{}{}
+		}
+	}
+	var validateVolumeMounts = func(
+		volumeMounts []corev1.VolumeMount, volumeMountsPath *field.Path) {
+		for i, volumeMount := range volumeMounts {
+			var volumeMountPath = volumeMountsPath.Index(i)
+			if _, ok := declaredVolumes[volumeMount.Name]; !ok {
+				allErrs = append(allErrs, field.NotFound(
+					volumeMountPath.Child("name"), volumeMount.Name))
+			}
+			if len(volumeMount.MountPath) == 0 {
+				allErrs = append(allErrs, field.Required(
+					volumeMountPath.Child("mountPath"), ""))
+			}
+		}
+	}
+	validateVolumeMounts(volumeMounts, path.Child("volumeMounts"))
+	for i, initContainer := range initContainers {
+		validateVolumeMounts(
+			initContainer.VolumeMounts,
+			path.Child("initContainers").Index(i).Child("volumeMounts"))
+	}
+	return allErrs
+}
+
+// Validates the pod template of a component. The component container name,
+// which is the same as the component name, and the component ports are
+// reserved by the operator.
+func (v *Validator) validatePodTemplate(
+	podTemplate *corev1.PodTemplateSpec,
+	reservedPorts []int32,
+	component string,
+	path *field.Path) field.ErrorList {
+	if podTemplate == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var specPath = path.Child("spec")
+	for i, container := range podTemplate.Spec.InitContainers {
+		if container.Name == component {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("initContainers").Index(i).Child("name"),
+				container.Name,
+				"the container name is reserved"))
+		}
+	}
+	for i, container := range podTemplate.Spec.Containers {
+		var containerPath = specPath.Child("containers").Index(i)
+		if container.Name == component {
+			allErrs = append(allErrs, field.Invalid(
+				containerPath.Child("name"),
+				container.Name,
+				"the container name is reserved"))
+		}
+		for j, port := range container.Ports {
+			for _, reservedPort := range reservedPorts {
+				if port.ContainerPort == reservedPort {
+					allErrs = append(allErrs, field.Invalid(
+						containerPath.Child("ports").Index(j).Child("containerPort"),
+						port.ContainerPort,
+						"the port is reserved"))
+				}
+			}
+		}
+	}
+	return allErrs
+}
+
+func (v *Validator) validateIdleTimeout(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var actionPath = specPath.Child("idleTimeoutAction")
+	var timeoutSeconds = clusterSpec.IdleTimeoutSeconds
+	if timeoutSeconds == nil {
+		if len(clusterSpec.IdleTimeoutAction) > 0 {
+			return field.ErrorList{field.Forbidden(
+				actionPath, "it requires idleTimeoutSeconds")}
+		}
+		return nil
+	}
+	var timeoutPath = specPath.Child("idleTimeoutSeconds")
+	if clusterSpec.Job != nil {
+		return field.ErrorList{field.Forbidden(
+			timeoutPath, "it is only supported for session clusters")}
+	}
+	var allErrs field.ErrorList
+	if *timeoutSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			timeoutPath, *timeoutSeconds, "it must be >= 1"))
+	}
+	switch clusterSpec.IdleTimeoutAction {
+	case CleanupActionDeleteCluster:
+	case CleanupActionDeleteTaskManager:
+	default:
+		allErrs = append(allErrs, field.NotSupported(
+			actionPath,
+			string(clusterSpec.IdleTimeoutAction),
+			[]string{
+				string(CleanupActionDeleteCluster),
+				string(CleanupActionDeleteTaskManager),
+			}))
+	}
+	return allErrs
+}
+
+// Validates the names of the ConfigMaps and Secrets which the pods are
+// restarted on.
+func (v *Validator) validateRestartOnChange(
+	restartOnChange *RestartOnChangeSpec, path *field.Path) field.ErrorList {
+	if restartOnChange == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var validateNames = func(names []string, namesPath *field.Path) {
+		var seen = map[string]struct{}{}
+		for i, name := range names {
+			var namePath = namesPath.Index(i)
+			if _, ok := seen[name]; ok {
+				allErrs = append(allErrs, field.Duplicate(namePath, name))
+				continue
+			}
+			seen[name] = struct{}{}
+			for _, msg := range validation.IsDNS1123Subdomain(name) {
+				allErrs = append(allErrs, field.Invalid(namePath, name, msg))
+			}
+		}
+	}
+	validateNames(restartOnChange.ConfigMaps, path.Child("configMaps"))
+	validateNames(restartOnChange.Secrets, path.Child("secrets"))
+	return allErrs
+}
+
+func (v *Validator) validateTimeouts(
+	timeouts *TimeoutsSpec, timeoutsPath *field.Path) field.ErrorList {
+	if timeouts == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	for _, timeout := range []struct {
+		name    string
+		seconds *int32
+	}{
+		{"clusterStartupSeconds", timeouts.ClusterStartupSeconds},
+		{"jobSubmissionSeconds", timeouts.JobSubmissionSeconds},
+		{"savepointSeconds", timeouts.SavepointSeconds},
+		{"cancellationSeconds", timeouts.CancellationSeconds},
+		{"upgradeSeconds", timeouts.UpgradeSeconds},
+	} {
+		if timeout.seconds != nil && *timeout.seconds < 1 {
+			allErrs = append(allErrs, field.Invalid(
+				timeoutsPath.Child(timeout.name),
+				*timeout.seconds,
+				"it must be >= 1"))
+		}
+	}
+	return allErrs
+}
+
+func (v *Validator) validateUpdateStrategy(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var strategyPath = specPath.Child("updateStrategy")
+	var blueGreenPath = specPath.Child("blueGreen")
+	if clusterSpec.UpdateStrategy == nil ||
+		*clusterSpec.UpdateStrategy == UpdateStrategyInPlace {
+		if clusterSpec.BlueGreen != nil {
+			return field.ErrorList{field.Forbidden(
+				blueGreenPath, "it requires the BlueGreen updateStrategy")}
+		}
+		return nil
+	}
+	if *clusterSpec.UpdateStrategy != UpdateStrategyBlueGreen {
+		return field.ErrorList{field.NotSupported(
+			strategyPath,
+			*clusterSpec.UpdateStrategy,
+			[]string{UpdateStrategyInPlace, UpdateStrategyBlueGreen})}
+	}
+
+	var allErrs field.ErrorList
+	var jobSpec = clusterSpec.Job
+	if jobSpec == nil {
+		return field.ErrorList{field.Forbidden(
+			strategyPath, "BlueGreen is only supported for job clusters")}
+	}
+	var jobPath = specPath.Child("job")
+	if jobSpec.SavepointsDir == nil {
+		allErrs = append(allErrs, field.Required(
+			jobPath.Child("savepointsDir"), "it is required by the BlueGreen updateStrategy"))
+	}
+	if jobSpec.Schedule != nil {
+		allErrs = append(allErrs, field.Forbidden(
+			jobPath.Child("schedule"), "it is not supported with the BlueGreen updateStrategy"))
+	}
+	if jobSpec.CancelRequested != nil && *jobSpec.CancelRequested {
+		allErrs = append(allErrs, field.Forbidden(
+			jobPath.Child("cancelRequested"),
+			"it is not supported with the BlueGreen updateStrategy, delete the cluster instead"))
+	}
+	var blueGreen = clusterSpec.BlueGreen
+	if blueGreen == nil {
+		return allErrs
+	}
+	if blueGreen.StableSeconds != nil && *blueGreen.StableSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			blueGreenPath.Child("stableSeconds"), *blueGreen.StableSeconds, "it must be >= 0"))
+	}
+	if blueGreen.DeadlineSeconds != nil && *blueGreen.DeadlineSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			blueGreenPath.Child("deadlineSeconds"), *blueGreen.DeadlineSeconds, "it must be >= 1"))
+	}
+	if blueGreen.HealthCheckURL != nil {
+		var healthCheckURL = strings.Replace(*blueGreen.HealthCheckURL, "{cluster}", "cluster", -1)
+		var parsedURL, err = url.Parse(healthCheckURL)
+		if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+			allErrs = append(allErrs, field.Invalid(
+				blueGreenPath.Child("healthCheckURL"),
+				*blueGreen.HealthCheckURL,
+				"it must be an http or https URL"))
+		}
+	}
+	return allErrs
+}
+
+func (v *Validator) validateAutoscaler(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var autoscaler = clusterSpec.Autoscaler
+	if autoscaler == nil {
+		return nil
+	}
+	var autoscalerPath = specPath.Child("autoscaler")
+	var jobSpec = clusterSpec.Job
+	if jobSpec == nil {
+		return field.ErrorList{field.Forbidden(
+			autoscalerPath, "it is only supported for job clusters")}
+	}
+	var allErrs field.ErrorList
+	if jobSpec.SavepointsDir == nil || len(*jobSpec.SavepointsDir) == 0 {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("job", "savepointsDir"), "it is required by the autoscaler"))
+	}
+	if autoscaler.MinParallelism == nil || *autoscaler.MinParallelism < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			autoscalerPath.Child("minParallelism"),
+			autoscaler.MinParallelism,
+			"it must be >= 1"))
+	} else if autoscaler.MaxParallelism < *autoscaler.MinParallelism {
+		allErrs = append(allErrs, field.Invalid(
+			autoscalerPath.Child("maxParallelism"),
+			autoscaler.MaxParallelism,
+			"it must be >= minParallelism"))
+	}
+	if autoscaler.TargetBusyPercent == nil ||
+		*autoscaler.TargetBusyPercent < 1 || *autoscaler.TargetBusyPercent > 100 {
+		allErrs = append(allErrs, field.Invalid(
+			autoscalerPath.Child("targetBusyPercent"),
+			autoscaler.TargetBusyPercent,
+			"it must be in range [1, 100]"))
+	}
+	if autoscaler.MaxConsumerLag != nil && *autoscaler.MaxConsumerLag < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			autoscalerPath.Child("maxConsumerLag"),
+			*autoscaler.MaxConsumerLag,
+			"it must be >= 1"))
+	}
+	if autoscaler.StabilizationSeconds == nil ||
+		*autoscaler.StabilizationSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			autoscalerPath.Child("stabilizationSeconds"),
+			autoscaler.StabilizationSeconds,
+			"it must be >= 0"))
+	}
+	return allErrs
+}
+
+func (v *Validator) validateMetrics(
+	metrics *MetricsSpec, path *field.Path) field.ErrorList {
+	if metrics == nil || metrics.Prometheus == nil {
+		return nil
+	}
+	var prometheusPath = path.Child("prometheus")
+	var allErrs = v.validatePort(
+		metrics.Prometheus.Port, prometheusPath.Child("port"))
+	var podMonitor = metrics.Prometheus.PodMonitor
+	if podMonitor != nil && podMonitor.Interval != nil &&
+		!prometheusDurationRegex.MatchString(*podMonitor.Interval) {
+		allErrs = append(allErrs, field.Invalid(
+			prometheusPath.Child("podMonitor", "interval"),
+			*podMonitor.Interval,
+			"it must be a Prometheus duration, e.g., 30s"))
+	}
+	return allErrs
+}
+
+// Validates the file system plugins, they must be built-in plugins of the Flink
+// image, and the full Flink version is required to find the plugin JARs.
+func (v *Validator) validateStorage(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var storage = clusterSpec.Storage
+	if storage == nil || len(storage.Plugins) == 0 {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var pluginsPath = specPath.Child("storage", "plugins")
+	var plugins = map[string]struct{}{}
+	for i, plugin := range storage.Plugins {
+		if _, ok := builtInFileSystemPlugins[plugin]; !ok {
+			var supported []string
+			for name := range builtInFileSystemPlugins {
+				supported = append(supported, name)
+			}
+			sort.Strings(supported)
+			allErrs = append(allErrs, field.NotSupported(
+				pluginsPath.Index(i), plugin, supported))
+		} else if _, ok := plugins[plugin]; ok {
+			allErrs = append(allErrs, field.Duplicate(pluginsPath.Index(i), plugin))
+		}
+		plugins[plugin] = struct{}{}
+	}
+	if strings.Count(clusterSpec.FlinkVersion, ".") != 2 {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("flinkVersion"),
+			"the version with the patch version, e.g., 1.12.2, is required by storage plugins"))
+	}
+	for i, envVar := range clusterSpec.EnvVars {
+		if envVar.Name == "ENABLE_BUILT_IN_PLUGINS" {
+			allErrs = append(allErrs, field.Forbidden(
+				specPath.Child("envVars").Index(i),
+				"ENABLE_BUILT_IN_PLUGINS is managed by the operator when storage plugins are specified"))
+		}
+	}
+	return allErrs
+}
+
+// Validates the TaskManager GPUs, the GPU plugin of the Flink image is enabled
+// by the operator, which requires Flink 1.11+ with the patch version, and the
+// GPU resources of the TaskManager container are managed by the operator.
+func (v *Validator) validateGPU(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var gpu = clusterSpec.TaskManager.GPU
+	if gpu == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var path = specPath.Child("taskManager", "gpu")
+	if gpu.Amount < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("amount"), gpu.Amount, "it must be >= 1"))
+	}
+	if gpu.ResourceName != nil {
+		var resourceNamePath = path.Child("resourceName")
+		for _, msg := range validation.IsQualifiedName(*gpu.ResourceName) {
+			allErrs = append(allErrs, field.Invalid(
+				resourceNamePath, *gpu.ResourceName, msg))
+		}
+		var resourceName = corev1.ResourceName(*gpu.ResourceName)
+		var resources = clusterSpec.TaskManager.Resources
+		if _, ok := resources.Limits[resourceName]; ok {
+			allErrs = append(allErrs, field.Forbidden(
+				specPath.Child("taskManager", "resources", "limits").Key(*gpu.ResourceName),
+				"it is managed by the operator when taskManager gpu is specified"))
+		}
+		if _, ok := resources.Requests[resourceName]; ok {
+			allErrs = append(allErrs, field.Forbidden(
+				specPath.Child("taskManager", "resources", "requests").Key(*gpu.ResourceName),
+				"it is managed by the operator when taskManager gpu is specified"))
+		}
+	}
+	if gpu.DiscoveryScriptPath != nil && len(*gpu.DiscoveryScriptPath) == 0 {
+		allErrs = append(allErrs, field.Required(
+			path.Child("discoveryScriptPath"), ""))
+	}
+	if strings.Count(clusterSpec.FlinkVersion, ".") != 2 ||
+		!isFlinkVersionAtLeast(clusterSpec.FlinkVersion, 1, 11) {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("flinkVersion"),
+			"the version 1.11+ with the patch version, e.g., 1.12.2, is required by taskManager gpu"))
+	}
+	// Reported by the storage validation if storage plugins are specified.
+	if clusterSpec.Storage == nil || len(clusterSpec.Storage.Plugins) == 0 {
+		for i, envVar := range clusterSpec.EnvVars {
+			if envVar.Name == "ENABLE_BUILT_IN_PLUGINS" {
+				allErrs = append(allErrs, field.Forbidden(
+					specPath.Child("envVars").Index(i),
+					"ENABLE_BUILT_IN_PLUGINS is managed by the operator when taskManager gpu is specified"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// Validates the state backend, the directories must be URIs with a scheme, and
+// incremental checkpoints and the local directory are only supported by
+// RocksDB.
+func (v *Validator) validateStateBackend(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var stateBackend = clusterSpec.StateBackend
+	if stateBackend == nil {
+		return nil
+	}
+	var path = specPath.Child("stateBackend")
+	var allErrs field.ErrorList
+	var isRocksDB = false
+	switch stateBackend.Type {
+	case StateBackendTypeHashMap:
+	case StateBackendTypeRocksDB:
+		isRocksDB = true
+	default:
+		allErrs = append(allErrs, field.NotSupported(
+			path.Child("type"),
+			stateBackend.Type,
+			[]string{StateBackendTypeHashMap, StateBackendTypeRocksDB}))
+	}
+	var dirs = []struct {
+		name string
+		dir  *string
+	}{
+		{"checkpointDir", stateBackend.CheckpointDir},
+		{"savepointDir", stateBackend.SavepointDir},
+	}
+	for _, dir := range dirs {
+		if dir.dir == nil {
+			continue
+		}
+		var dirURL, err = url.Parse(*dir.dir)
+		if err != nil || len(dirURL.Scheme) == 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child(dir.name), *dir.dir, "the URI scheme is unspecified"))
+		}
+	}
+	if stateBackend.Incremental != nil && *stateBackend.Incremental && !isRocksDB {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("incremental"),
+			"incremental checkpoints are only supported by the rocksdb state backend"))
+	}
+	var localDir = stateBackend.RocksDBLocalDir
+	if localDir != nil {
+		var localDirPath = path.Child("rocksDBLocalDir")
+		if !isRocksDB {
+			allErrs = append(allErrs, field.Forbidden(
+				localDirPath, "it is only supported by the rocksdb state backend"))
+		}
+		if !strings.HasPrefix(localDir.MountPath, "/") {
+			allErrs = append(allErrs, field.Invalid(
+				localDirPath.Child("mountPath"),
+				localDir.MountPath,
+				"it must be an absolute path"))
+		}
+		var numVolumes = 0
+		for _, specified := range []bool{
+			localDir.EmptyDir != nil,
+			localDir.PersistentVolumeClaim != nil,
+			len(localDir.VolumeClaimTemplate) > 0} {
+			if specified {
+				numVolumes++
+			}
+		}
+		var volumeDetail = "exactly one of emptyDir, persistentVolumeClaim and volumeClaimTemplate must be specified"
+		if numVolumes == 0 {
+			allErrs = append(allErrs, field.Required(localDirPath, volumeDetail))
+		} else if numVolumes > 1 {
+			allErrs = append(allErrs, field.Forbidden(localDirPath, volumeDetail))
+		} else if localDir.PersistentVolumeClaim != nil &&
+			len(localDir.PersistentVolumeClaim.ClaimName) == 0 {
+			allErrs = append(allErrs, field.Required(
+				localDirPath.Child("persistentVolumeClaim", "claimName"), ""))
+		} else if len(localDir.VolumeClaimTemplate) > 0 {
+			var found = false
+			for _, claimTemplate := range clusterSpec.TaskManager.VolumeClaimTemplates {
+				if claimTemplate.Name == localDir.VolumeClaimTemplate {
+					found = true
+				}
+			}
+			if !found {
+				allErrs = append(allErrs, field.NotFound(
+					localDirPath.Child("volumeClaimTemplate"),
+					localDir.VolumeClaimTemplate))
+			}
+		}
+	}
+	return allErrs
+}
+
+// Validates the NetworkPolicy spec, the UI ingress sources must be CIDRs.
+func (v *Validator) validateNetworkPolicy(
+	networkPolicy *NetworkPolicySpec, path *field.Path) field.ErrorList {
+	if networkPolicy == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var cidrsPath = path.Child("uiIngressCIDRs")
+	for i, cidr := range networkPolicy.UIIngressCIDRs {
+		var _, _, err = net.ParseCIDR(cidr)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				cidrsPath.Index(i), cidr, "it must be a CIDR"))
+		}
+	}
+	if networkPolicy.OperatorNamespaceSelector != nil {
+		var _, err = metav1.LabelSelectorAsSelector(
+			networkPolicy.OperatorNamespaceSelector)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("operatorNamespaceSelector"),
+				networkPolicy.OperatorNamespaceSelector,
+				err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// Validates the security of the Flink connections. The passwords of the
+// keystores are passed to the JobManager as dynamic properties, which requires
+// Flink 1.11+, and the password of a keystore which is not in an existing
+// Secret cannot be referenced.
+func (v *Validator) validateSecurity(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	if clusterSpec.Security == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if kerberos := clusterSpec.Security.Kerberos; kerberos != nil {
+		allErrs = append(allErrs, validateKerberos(
+			kerberos, specPath.Child("security", "kerberos"))...)
+	}
+	if clusterSpec.Security.SSL == nil {
+		return allErrs
+	}
+	var ssl = clusterSpec.Security.SSL
+	var path = specPath.Child("security", "ssl")
+	var flinkVersion = clusterSpec.FlinkVersion
+	var _, _, versionErr = parseFlinkVersion(flinkVersion)
+	var hasFlinkVersion = len(flinkVersion) > 0 && versionErr == nil
+	if (ssl.Internal != nil || ssl.REST != nil) &&
+		hasFlinkVersion && !isFlinkVersionAtLeast(flinkVersion, 1, 11) {
+		allErrs = append(allErrs, field.Forbidden(path, "it requires Flink 1.11+"))
+	}
+	if internal := ssl.Internal; internal != nil {
+		allErrs = append(allErrs, validateKeystore(
+			internal.SecretName,
+			internal.KeystoreKey,
+			internal.TruststoreKey,
+			internal.PasswordSecretKeyRef,
+			path.Child("internal"))...)
+	}
+	if rest := ssl.REST; rest != nil {
+		var restPath = path.Child("rest")
+		if len(rest.SecretName) == 0 && rest.IssuerRef == nil {
+			allErrs = append(allErrs, field.Required(
+				restPath, "either secretName or issuerRef is required"))
+		} else if len(rest.SecretName) > 0 && rest.IssuerRef != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				restPath.Child("issuerRef"), "it cannot be specified with secretName"))
+		}
+		if rest.IssuerRef != nil {
+			var issuerPath = restPath.Child("issuerRef")
+			if len(rest.IssuerRef.Name) == 0 {
+				allErrs = append(allErrs, field.Required(issuerPath.Child("name"), ""))
+			}
+			if len(rest.IssuerRef.Kind) == 0 {
+				allErrs = append(allErrs, field.Required(issuerPath.Child("kind"), ""))
+			}
+			if len(rest.IssuerRef.Group) == 0 {
+				allErrs = append(allErrs, field.Required(issuerPath.Child("group"), ""))
+			}
+		}
+		allErrs = append(allErrs, validateKeystore(
+			rest.SecretName,
+			rest.KeystoreKey,
+			rest.TruststoreKey,
+			rest.PasswordSecretKeyRef,
+			restPath)...)
+	}
+	return allErrs
+}
+
+// Validates the keytab, principal and krb5.conf of the Kerberos login.
+func validateKerberos(kerberos *KerberosSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(kerberos.KeytabSecretName) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("keytabSecretName"), ""))
+	}
+	if len(kerberos.KeytabKey) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("keytabKey"), ""))
+	}
+	if len(kerberos.Principal) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("principal"), ""))
+	} else if !strings.Contains(kerberos.Principal, "@") {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("principal"),
+			kerberos.Principal,
+			"it must include the realm, e.g., flink/admin@EXAMPLE.COM"))
+	}
+	if len(kerberos.Krb5ConfigMapName) > 0 && len(kerberos.Krb5ConfigKey) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("krb5ConfigKey"), ""))
+	}
+	var contexts = map[string]struct{}{}
+	for i, context := range kerberos.LoginContexts {
+		var contextPath = path.Child("loginContexts").Index(i)
+		if len(context) == 0 || strings.ContainsAny(context, ", ") {
+			allErrs = append(allErrs, field.Invalid(
+				contextPath, context, "it must be a JAAS login context name"))
+		} else if _, ok := contexts[context]; ok {
+			allErrs = append(allErrs, field.Duplicate(contextPath, context))
+		}
+		contexts[context] = struct{}{}
+	}
+	return allErrs
+}
+
+// Validates the keys of a keystore and truststore in a Secret, and the
+// reference to their password, which requires an existing Secret.
+func validateKeystore(
+	secretName string,
+	keystoreKey string,
+	truststoreKey string,
+	passwordRef *corev1.SecretKeySelector,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(keystoreKey) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("keystoreKey"), ""))
+	}
+	if len(truststoreKey) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("truststoreKey"), ""))
+	}
+	if passwordRef != nil {
+		var passwordPath = path.Child("passwordSecretKeyRef")
+		if len(secretName) == 0 {
+			allErrs = append(allErrs, field.Forbidden(
+				passwordPath,
+				"it requires secretName, the operator generates the password otherwise"))
+		}
+		if len(passwordRef.Name) == 0 {
+			allErrs = append(allErrs, field.Required(passwordPath.Child("name"), ""))
+		}
+		if len(passwordRef.Key) == 0 {
+			allErrs = append(allErrs, field.Required(passwordPath.Child("key"), ""))
+		}
+	}
+	return allErrs
+}
+
+func isInternalSSLEnabled(clusterSpec *FlinkClusterSpec) bool {
+	var security = clusterSpec.Security
+	return security != nil && security.SSL != nil && security.SSL.Internal != nil
+}
+
+func isRESTSSLEnabled(clusterSpec *FlinkClusterSpec) bool {
+	var security = clusterSpec.Security
+	return security != nil && security.SSL != nil && security.SSL.REST != nil
+}
+
+func isKerberosEnabled(clusterSpec *FlinkClusterSpec) bool {
+	var security = clusterSpec.Security
+	return security != nil && security.Kerberos != nil
+}
+
+func (v *Validator) validateBatchScheduler(
+	batchScheduler *BatchSchedulerSpec, path *field.Path) field.ErrorList {
+	if batchScheduler == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	switch batchScheduler.Name {
+	case BatchSchedulerVolcano:
+	case BatchSchedulerSchedulerPlugins:
+		if batchScheduler.Queue != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("queue"), "it is only supported by volcano"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(
+			path.Child("name"),
+			batchScheduler.Name,
+			[]string{BatchSchedulerVolcano, BatchSchedulerSchedulerPlugins}))
+	}
+	if batchScheduler.SchedulerName != nil &&
+		len(*batchScheduler.SchedulerName) == 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("schedulerName"),
+			*batchScheduler.SchedulerName,
+			"it must not be empty"))
+	}
+	if batchScheduler.Queue != nil && len(*batchScheduler.Queue) == 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("queue"), *batchScheduler.Queue, "it must not be empty"))
+	}
+	return allErrs
+}
+
+// Validates the History Server, the archive directory must be a URI with a
+// scheme, and the service is validated in the same way as the JobManager
+// service with the default "Cluster" access scope.
+func (v *Validator) validateHistoryServer(
+	historyServer *HistoryServerSpec, path *field.Path) field.ErrorList {
+	if historyServer == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	var archiveDirPath = path.Child("archiveDir")
+	if len(historyServer.ArchiveDir) == 0 {
+		allErrs = append(allErrs, field.Required(archiveDirPath, ""))
+	} else {
+		var dirURL, err = url.Parse(historyServer.ArchiveDir)
+		if err != nil || len(dirURL.Scheme) == 0 {
+			allErrs = append(allErrs, field.Invalid(
+				archiveDirPath,
+				historyServer.ArchiveDir,
+				"the URI scheme is unspecified"))
+		}
+	}
+	allErrs = append(allErrs,
+		v.validatePort(historyServer.Port, path.Child("port"))...)
+	if historyServer.RefreshIntervalSeconds != nil &&
+		*historyServer.RefreshIntervalSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("refreshIntervalSeconds"),
+			*historyServer.RefreshIntervalSeconds,
+			"it must be >= 1"))
+	}
+	allErrs = append(allErrs, v.validateJobManagerService(
+		AccessScopeCluster, historyServer.Service, path.Child("service"))...)
+	if historyServer.Ingress != nil && historyServer.Ingress.Path != nil &&
+		!strings.HasPrefix(*historyServer.Ingress.Path, "/") {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("ingress", "path"),
+			*historyServer.Ingress.Path,
+			"it must start with /"))
+	}
+	return allErrs
+}
+
+func (v *Validator) validateHighAvailability(
+	highAvailability *HighAvailabilitySpec, path *field.Path) field.ErrorList {
+	if highAvailability == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	switch highAvailability.Mode {
+	case HighAvailabilityModeKubernetes:
+	case HighAvailabilityModeZooKeeper:
+		if len(highAvailability.ZooKeeperQuorum) == 0 {
+			allErrs = append(allErrs, field.Required(
+				path.Child("zookeeperQuorum"), "it is required in zookeeper mode"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(
+			path.Child("mode"),
+			highAvailability.Mode,
+			[]string{HighAvailabilityModeKubernetes, HighAvailabilityModeZooKeeper}))
+	}
+	if len(highAvailability.StorageDir) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("storageDir"), ""))
+	} else {
+		var storageURL, err = url.Parse(highAvailability.StorageDir)
+		if err != nil || len(storageURL.Scheme) == 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("storageDir"),
+				highAvailability.StorageDir,
+				"the URI scheme is unspecified"))
+		}
+	}
+	return allErrs
+}
+
+// Validates the ServiceAccount of the cluster pods, the Kubernetes HA services
+// require the ServiceAccount token to manage the leader ConfigMaps.
+func (v *Validator) validateServiceAccount(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var allErrs = v.validateServiceAccountName(
+		clusterSpec.ServiceAccountName, specPath.Child("serviceAccountName"))
+	var highAvailability = clusterSpec.HighAvailability
+	if clusterSpec.AutomountServiceAccountToken != nil &&
+		!*clusterSpec.AutomountServiceAccountToken &&
+		highAvailability != nil &&
+		highAvailability.Mode != HighAvailabilityModeZooKeeper {
+		allErrs = append(allErrs, field.Forbidden(
+			specPath.Child("automountServiceAccountToken"),
+			"it cannot be false with the Kubernetes HA services"))
+	}
+	return allErrs
+}
+
+func (v *Validator) validateServiceAccountName(
+	name *string, path *field.Path) field.ErrorList {
+	if name == nil {
+		return nil
+	}
+	if len(*name) == 0 {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(*name) {
+		allErrs = append(allErrs, field.Invalid(path, *name, msg))
+	}
+	return allErrs
+}
+
+// Validates the labels and annotations of the Job pod, the labels managed by
+// the operator cannot be overridden.
+func (v *Validator) validateJobPodMetadata(
+	jobSpec *JobSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var labelsPath = path.Child("podLabels")
+	for _, key := range getSortedKeys(jobSpec.PodLabels) {
+		var value = jobSpec.PodLabels[key]
+		var keyPath = labelsPath.Key(key)
+		if _, ok := reservedJobPodLabels[key]; ok {
+			allErrs = append(allErrs, field.Forbidden(
+				keyPath, "it is managed by the operator"))
+			continue
+		}
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(keyPath, key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			allErrs = append(allErrs, field.Invalid(keyPath, value, msg))
+		}
+	}
+	var annotationsPath = path.Child("podAnnotations")
+	for _, key := range getSortedKeys(jobSpec.PodAnnotations) {
+		for _, msg := range validation.IsQualifiedName(strings.ToLower(key)) {
+			allErrs = append(allErrs, field.Invalid(annotationsPath.Key(key), key, msg))
+		}
+	}
+	return allErrs
+}
+
+// Validates the labels and annotations of the resources of the cluster and of
+// each of its components.
+func (v *Validator) validateResourcesMetadata(
+	clusterSpec *FlinkClusterSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, v.validateResourceMetadata(
+		clusterSpec.Labels, clusterSpec.Annotations, path)...)
+	allErrs = append(allErrs, v.validateResourceMetadata(
+		clusterSpec.JobManager.Labels,
+		clusterSpec.JobManager.Annotations,
+		path.Child("jobManager"))...)
+	allErrs = append(allErrs, v.validateResourceMetadata(
+		clusterSpec.TaskManager.Labels,
+		clusterSpec.TaskManager.Annotations,
+		path.Child("taskManager"))...)
+	if clusterSpec.Job != nil {
+		allErrs = append(allErrs, v.validateResourceMetadata(
+			clusterSpec.Job.Labels,
+			clusterSpec.Job.Annotations,
+			path.Child("job"))...)
+	}
+	if clusterSpec.HistoryServer != nil {
+		allErrs = append(allErrs, v.validateResourceMetadata(
+			clusterSpec.HistoryServer.Labels,
+			clusterSpec.HistoryServer.Annotations,
+			path.Child("historyServer"))...)
+	}
+	return allErrs
+}
+
+// Validates the `labels` and `annotations` under the path, the labels managed
+// by the operator cannot be overridden.
+func (v *Validator) validateResourceMetadata(
+	labels map[string]string,
+	annotations map[string]string,
+	path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var labelsPath = path.Child("labels")
+	for _, key := range getSortedKeys(labels) {
+		var value = labels[key]
+		var keyPath = labelsPath.Key(key)
+		if _, ok := reservedResourceLabels[key]; ok {
+			allErrs = append(allErrs, field.Forbidden(
+				keyPath, "it is managed by the operator"))
+			continue
+		}
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(keyPath, key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			allErrs = append(allErrs, field.Invalid(keyPath, value, msg))
+		}
+	}
+	var annotationsPath = path.Child("annotations")
+	for _, key := range getSortedKeys(annotations) {
+		for _, msg := range validation.IsQualifiedName(strings.ToLower(key)) {
+			allErrs = append(allErrs, field.Invalid(annotationsPath.Key(key), key, msg))
+		}
+	}
+	return allErrs
+}
+
+// Validates the files which the job submitter waits for, which are absolute
+// paths, and the URLs of its sidecars to shut down, which are HTTP URLs.
+func (v *Validator) validateJobSubmitterGates(
+	jobSpec *JobSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var filesPath = path.Child("waitForFiles")
+	for i, file := range jobSpec.WaitForFiles {
+		if !strings.HasPrefix(file, "/") || strings.ContainsAny(file, "\n") {
+			allErrs = append(allErrs, field.Invalid(
+				filesPath.Index(i), file, "it must be an absolute path"))
+		}
+	}
+	var urlsPath = path.Child("sidecarShutdownURLs")
+	for i, shutdownURL := range jobSpec.SidecarShutdownURLs {
+		var parsedURL, err = url.Parse(shutdownURL)
+		if err != nil || parsedURL.Scheme != "http" ||
+			len(parsedURL.Host) == 0 || strings.ContainsAny(shutdownURL, " \n") {
+			allErrs = append(allErrs, field.Invalid(
+				urlsPath.Index(i), shutdownURL, "it must be an HTTP URL"))
+		}
+	}
+	return allErrs
+}
+
+// Validates Flink properties, properties managed by the operator cannot be
+// overridden. Heap sizes, or process sizes with the unified memory model of
+// Flink 1.10+, are only managed by the operator when memory limits of the
+// component are specified.
+func (v *Validator) validateFlinkProperties(
+	clusterSpec *FlinkClusterSpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var propertiesPath = specPath.Child("flinkProperties")
+	var keys []string
+	for key := range clusterSpec.FlinkProperties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if detail := getManagedFlinkPropertyDetail(clusterSpec, key); len(detail) > 0 {
+			allErrs = append(allErrs, field.Forbidden(propertiesPath.Key(key), detail))
+		}
+	}
+	var names = map[string]struct{}{}
+	for i, source := range clusterSpec.FlinkPropertiesFrom {
+		var sourcePath = specPath.Child("flinkPropertiesFrom").Index(i)
+		var namePath = sourcePath.Child("name")
+		if len(source.Name) == 0 {
+			allErrs = append(allErrs, field.Required(namePath, ""))
+		} else if _, ok := names[source.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(namePath, source.Name))
+		} else if _, ok := clusterSpec.FlinkProperties[source.Name]; ok {
+			allErrs = append(allErrs, field.Invalid(
+				namePath, source.Name, "it is already set in flinkProperties"))
+		} else if detail := getManagedFlinkPropertyDetail(clusterSpec, source.Name); len(detail) > 0 {
+			allErrs = append(allErrs, field.Forbidden(namePath, detail))
+		}
+		names[source.Name] = struct{}{}
+		var refPath = sourcePath.Child("valueFrom", "secretKeyRef")
+		if ref := source.ValueFrom.SecretKeyRef; ref == nil {
+			allErrs = append(allErrs, field.Required(refPath, ""))
+		} else {
+			if len(ref.Name) == 0 {
+				allErrs = append(allErrs, field.Required(refPath.Child("name"), ""))
+			}
+			if len(ref.Key) == 0 {
+				allErrs = append(allErrs, field.Required(refPath.Child("key"), ""))
+			}
+		}
+	}
+	var jmMemoryKey = "jobmanager.heap.size"
+	if UsesJobManagerProcessMemory(clusterSpec.FlinkVersion) {
+		jmMemoryKey = "jobmanager.memory.process.size"
+	}
+	if _, ok := clusterSpec.FlinkProperties[jmMemoryKey]; ok &&
+		clusterSpec.JobManager.Resources.Limits.Memory().Value() > 0 {
+		allErrs = append(allErrs, field.Forbidden(
+			propertiesPath.Key(jmMemoryKey),
+			"it is derived from the jobmanager memory limit"))
+	}
+	var tmMemoryKey = "taskmanager.heap.size"
+	if UsesTaskManagerProcessMemory(clusterSpec.FlinkVersion) {
+		tmMemoryKey = "taskmanager.memory.process.size"
+	}
+	if _, ok := clusterSpec.FlinkProperties[tmMemoryKey]; ok &&
+		clusterSpec.TaskManager.Resources.Limits.Memory().Value() > 0 {
+		allErrs = append(allErrs, field.Forbidden(
+			propertiesPath.Key(tmMemoryKey),
+			"it is derived from the taskmanager memory limit"))
+	}
+	return allErrs
+}
+
+// Gets why a Flink property cannot be set by the user, empty if it can be
+// set. The properties are managed by the operator, some of them only when the
+// corresponding spec is specified.
+func getManagedFlinkPropertyDetail(
+	clusterSpec *FlinkClusterSpec, key string) string {
+	var isIn = func(properties map[string]struct{}) bool {
+		var _, ok = properties[key]
+		return ok
+	}
+	switch {
+	case isIn(reservedFlinkProperties):
+		return "it is managed by the operator"
+	case isIn(highAvailabilityFlinkProperties) &&
+		clusterSpec.HighAvailability != nil:
+		return "it is managed by the operator when highAvailability is specified"
+	case isIn(checkpointingFlinkProperties) &&
+		clusterSpec.Job != nil && clusterSpec.Job.Checkpointing != nil:
+		return "it is managed by the operator when job checkpointing is specified"
+	case isIn(stateBackendFlinkProperties) && clusterSpec.StateBackend != nil:
+		return "it is managed by the operator when stateBackend is specified"
+	case isIn(historyServerFlinkProperties) && clusterSpec.HistoryServer != nil:
+		return "it is managed by the operator when historyServer is specified"
+	case isIn(internalSSLFlinkProperties) && isInternalSSLEnabled(clusterSpec):
+		return "it is managed by the operator when security ssl internal is specified"
+	case isIn(restSSLFlinkProperties) && isRESTSSLEnabled(clusterSpec):
+		return "it is managed by the operator when security ssl rest is specified"
+	case isIn(kerberosFlinkProperties) && isKerberosEnabled(clusterSpec):
+		return "it is managed by the operator when security kerberos is specified"
+	case strings.HasPrefix(key, prometheusReporterPropertyPrefix) &&
+		clusterSpec.Metrics != nil && clusterSpec.Metrics.Prometheus != nil:
+		return "it is managed by the operator when metrics prometheus is specified"
+	case (key == externalResourcesFlinkProperty ||
+		strings.HasPrefix(key, gpuExternalResourcePropertyPrefix)) &&
+		clusterSpec.TaskManager.GPU != nil:
+		return "it is managed by the operator when taskManager gpu is specified"
+	}
+	return ""
+}
+
+// Validates the names of the log config files, they are keys of the Flink
+// ConfigMap and must not be the files managed by the operator.
+func (v *Validator) validateLogConfig(
+	logConfig map[string]string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var names []string
+	for name := range logConfig {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var namePath = path.Key(name)
+		if _, ok := reservedConfigFiles[name]; ok {
+			allErrs = append(allErrs, field.Forbidden(
+				namePath, "it is managed by the operator"))
+			continue
+		}
+		for _, msg := range validation.IsConfigMapKey(name) {
+			allErrs = append(allErrs, field.Invalid(namePath, name, msg))
+		}
+	}
+	return allErrs
+}
+
+// shouldRestartJob returns true if the controller should restart the failed
+// job.
+func shouldRestartJob(
+	jobSpec *JobSpec,
+	jobStatus *JobStatus) bool {
+	return jobSpec != nil &&
+		jobSpec.RestartPolicy != nil &&
+		*jobSpec.RestartPolicy == JobRestartPolicyFromSavepointOnFailure &&
+		jobStatus != nil &&
+		jobStatus.State == JobStateFailed &&
+		len(getRestoreLocation(jobSpec, jobStatus, time.Now())) > 0
+}
+
+// getRestoreLocation returns the latest one of the externalized checkpoint and
+// the savepoint recorded in the job status, or empty if there is none or it is
+// older than `maxStateAgeToRestoreSeconds`.
+func getRestoreLocation(
+	jobSpec *JobSpec,
+	jobStatus *JobStatus,
+	now time.Time) string {
+	var location string
+	var stateTime time.Time
+	if len(jobStatus.SavepointLocation) > 0 {
+		location = jobStatus.SavepointLocation
+		stateTime, _ = time.Parse(time.RFC3339, jobStatus.LastSavepointTime)
+	}
+	if len(jobStatus.LastCheckpointLocation) > 0 {
+		var checkpointTime, _ = time.Parse(
+			time.RFC3339, jobStatus.LastCheckpointTime)
+		if len(location) == 0 || checkpointTime.After(stateTime) {
+			location = jobStatus.LastCheckpointLocation
+			stateTime = checkpointTime
+		}
+	}
+	if len(location) > 0 && jobSpec.MaxStateAgeToRestoreSeconds != nil {
+		var maxAge = time.Duration(*jobSpec.MaxStateAgeToRestoreSeconds) * time.Second
+		if now.Sub(stateTime) > maxAge {
+			return ""
+		}
+	}
+	return location
+}
+
+// getSpecifiedPorts returns the ports which are specified, unspecified ports
+// are reported by the port validation.
+func getSortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getSpecifiedPorts(ports ...*int32) []int32 {
+	var specifiedPorts []int32
+	for _, port := range ports {
+		if port != nil {
+			specifiedPorts = append(specifiedPorts, *port)
+		}
+	}
+	return specifiedPorts
+}
+
+// getAccessScopeServiceType returns the JobManager service type derived from
+// the access scope.
+func getAccessScopeServiceType(accessScope string) corev1.ServiceType {
+	switch accessScope {
+	case AccessScopeVPC, AccessScopeExternal:
+		return corev1.ServiceTypeLoadBalancer
+	case AccessScopeNodePort:
+		return corev1.ServiceTypeNodePort
+	default:
+		return corev1.ServiceTypeClusterIP
+	}
+}
+
+func isBlueGreen(clusterSpec *FlinkClusterSpec) bool {
+	return clusterSpec.UpdateStrategy != nil &&
+		*clusterSpec.UpdateStrategy == UpdateStrategyBlueGreen
+}
+
+func isRestAPISubmission(jobSpec *JobSpec) bool {
+	return jobSpec != nil && jobSpec.SubmissionMode != nil &&
+		*jobSpec.SubmissionMode == JobSubmissionModeRestAPI
+}
+
+// Checks whether the update changes the job spec, i.e., the running job is
+// stopped and resubmitted to apply it.
+func isJobUpgraded(old *FlinkCluster, new *FlinkCluster) bool {
+	var oldJob, newJob = old.Spec.Job, new.Spec.Job
+	return !reflect.DeepEqual(old.Spec.Image, new.Spec.Image) ||
+		old.Spec.FlinkVersion != new.Spec.FlinkVersion ||
+		!reflect.DeepEqual(old.Spec.FlinkProperties, new.Spec.FlinkProperties) ||
+		!reflect.DeepEqual(
+			old.Spec.FlinkPropertiesFrom, new.Spec.FlinkPropertiesFrom) ||
+		!reflect.DeepEqual(old.Spec.RestartNonce, new.Spec.RestartNonce) ||
+		oldJob.JarFile != newJob.JarFile ||
+		!reflect.DeepEqual(oldJob.JarSha256, newJob.JarSha256) ||
+		!reflect.DeepEqual(oldJob.PythonFile, newJob.PythonFile) ||
+		!reflect.DeepEqual(oldJob.PythonFiles, newJob.PythonFiles) ||
+		!reflect.DeepEqual(oldJob.PythonRequirements, newJob.PythonRequirements) ||
+		!reflect.DeepEqual(oldJob.SQL, newJob.SQL) ||
+		!reflect.DeepEqual(oldJob.SQLConfigMap, newJob.SQLConfigMap) ||
+		!reflect.DeepEqual(oldJob.Args, newJob.Args) ||
+		!reflect.DeepEqual(oldJob.Parallelism, newJob.Parallelism) ||
+		!reflect.DeepEqual(oldJob.Checkpointing, newJob.Checkpointing)
+}
+
+// Checks whether the job can be upgraded without losing its state, either
+// with a savepoint or by explicitly opting out of it.
+func canUpgradeJob(jobSpec *JobSpec) bool {
+	return (jobSpec.SavepointsDir != nil && len(*jobSpec.SavepointsDir) > 0) ||
+		(jobSpec.TakeSavepointOnUpgrade != nil && !*jobSpec.TakeSavepointOnUpgrade)
 }
 
 func isJobStopped(status *JobStatus) bool {
@@ -446,6 +3404,6 @@ func isJobStopped(status *JobStatus) bool {
 			status.State == JobStateCancelled)
 }
 
-func isJobTerminated(restartPolicy *JobRestartPolicy, jobStatus *JobStatus) bool {
-	return isJobStopped(jobStatus) && !shouldRestartJob(restartPolicy, jobStatus)
+func isJobTerminated(jobSpec *JobSpec, jobStatus *JobStatus) bool {
+	return isJobStopped(jobStatus) && !shouldRestartJob(jobSpec, jobStatus)
 }
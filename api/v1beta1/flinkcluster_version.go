@@ -0,0 +1,88 @@
+/*
+Copyright 2020 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parses a Flink version in the format "<major>.<minor>" or
+// "<major>.<minor>.<patch>", the patch version is ignored.
+func parseFlinkVersion(version string) (major int, minor int, err error) {
+	var parts = strings.Split(version, ".")
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, 0, fmt.Errorf(
+			"invalid Flink version %q, it must be in the format <major>.<minor>[.<patch>]",
+			version)
+	}
+	var numbers = make([]int, len(parts))
+	for i, part := range parts {
+		var number, err = strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return 0, 0, fmt.Errorf(
+				"invalid Flink version %q, it must be in the format <major>.<minor>[.<patch>]",
+				version)
+		}
+		numbers[i] = number
+	}
+	return numbers[0], numbers[1], nil
+}
+
+// Returns true if the Flink version is at least `major.minor`, false if the
+// version is unspecified or invalid.
+func isFlinkVersionAtLeast(version string, major int, minor int) bool {
+	if len(version) == 0 {
+		return false
+	}
+	var actualMajor, actualMinor, err = parseFlinkVersion(version)
+	if err != nil {
+		return false
+	}
+	return actualMajor > major || (actualMajor == major && actualMinor >= minor)
+}
+
+// UsesTaskManagerProcessMemory returns true if the TaskManager of the Flink
+// version is configured with the unified memory model (FLIP-49), i.e., Flink
+// 1.10+, in which `taskmanager.memory.process.size` is the total memory of
+// the process.
+func UsesTaskManagerProcessMemory(flinkVersion string) bool {
+	return isFlinkVersionAtLeast(flinkVersion, 1, 10)
+}
+
+// UsesJobManagerProcessMemory returns true if the JobManager of the Flink
+// version is configured with the unified memory model (FLIP-116), i.e., Flink
+// 1.11+, in which `jobmanager.memory.process.size` is the total memory of the
+// process.
+func UsesJobManagerProcessMemory(flinkVersion string) bool {
+	return isFlinkVersionAtLeast(flinkVersion, 1, 11)
+}
+
+// UsesHashMapStateBackendName returns true if the heap state backend of the
+// Flink version is named "hashmap", i.e., Flink 1.13+. Older versions name it
+// "filesystem", which is still accepted by newer versions.
+func UsesHashMapStateBackendName(flinkVersion string) bool {
+	return isFlinkVersionAtLeast(flinkVersion, 1, 13)
+}
+
+// SupportsFixedJobID returns true if the ID of a job submitted with the Flink
+// CLI of the Flink version can be fixed with `$internal.pipeline.job-id`,
+// i.e., Flink 1.12+.
+func SupportsFixedJobID(flinkVersion string) bool {
+	return isFlinkVersionAtLeast(flinkVersion, 1, 12)
+}
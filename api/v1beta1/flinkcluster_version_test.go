@@ -0,0 +1,59 @@
+/*
+Copyright 2020 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseFlinkVersion(t *testing.T) {
+	var major, minor, err = parseFlinkVersion("1.12.2")
+	assert.NilError(t, err)
+	assert.Equal(t, major, 1)
+	assert.Equal(t, minor, 12)
+
+	major, minor, err = parseFlinkVersion("1.9")
+	assert.NilError(t, err)
+	assert.Equal(t, major, 1)
+	assert.Equal(t, minor, 9)
+
+	for _, version := range []string{"", "1", "1.x", "1.12.2.1", "1.-1"} {
+		_, _, err = parseFlinkVersion(version)
+		assert.Assert(t, err != nil, version)
+	}
+}
+
+func TestUsesProcessMemory(t *testing.T) {
+	assert.Assert(t, !UsesTaskManagerProcessMemory(""))
+	assert.Assert(t, !UsesTaskManagerProcessMemory("invalid"))
+	assert.Assert(t, !UsesTaskManagerProcessMemory("1.9.3"))
+	assert.Assert(t, UsesTaskManagerProcessMemory("1.10"))
+	assert.Assert(t, UsesTaskManagerProcessMemory("2.0"))
+
+	assert.Assert(t, !UsesJobManagerProcessMemory("1.10.1"))
+	assert.Assert(t, UsesJobManagerProcessMemory("1.11"))
+	assert.Assert(t, UsesJobManagerProcessMemory("1.12.2"))
+}
+
+func TestSupportsFixedJobID(t *testing.T) {
+	assert.Assert(t, !SupportsFixedJobID(""))
+	assert.Assert(t, !SupportsFixedJobID("1.11.3"))
+	assert.Assert(t, SupportsFixedJobID("1.12"))
+	assert.Assert(t, SupportsFixedJobID("1.13.1"))
+}
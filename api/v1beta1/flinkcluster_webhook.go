@@ -30,6 +30,7 @@ var log = logf.Log.WithName("webhook")
 
 // SetupWebhookWithManager adds webhook for FlinkCluster.
 func (cluster *FlinkCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	templateReader = mgr.GetAPIReader()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(cluster).
 		Complete()
@@ -55,6 +56,11 @@ var _ webhook.Defaulter = &FlinkCluster{}
 // type.
 func (cluster *FlinkCluster) Default() {
 	log.Info("default", "name", cluster.Name, "original", *cluster)
+	var err = _SetTemplateDefault(cluster)
+	if err != nil {
+		// The cluster is rejected by the validation.
+		log.Error(err, "Failed to merge the cluster template", "name", cluster.Name)
+	}
 	_SetDefault(cluster)
 	log.Info("default", "name", cluster.Name, "augmented", *cluster)
 }
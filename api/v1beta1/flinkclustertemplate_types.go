@@ -0,0 +1,53 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:object:root=true
+
+// FlinkClusterTemplate is the Schema for the flinkclustertemplates API. It
+// holds the common properties of the FlinkClusters which reference it with
+// `spec.templateRef`, e.g., node selectors, security contexts and metric
+// reporters.
+// +kubebuilder:resource:shortName=fct,categories=flink
+type FlinkClusterTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Any subset of the FlinkCluster spec, e.g.,
+	// `{"taskManager": {"nodeSelector": {"pool": "flink"}}}`. It is
+	// deep-merged into the spec of the referencing clusters, the properties
+	// of the clusters take precedence.
+	Spec runtime.RawExtension `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FlinkClusterTemplateList contains a list of FlinkClusterTemplate
+type FlinkClusterTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FlinkClusterTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FlinkClusterTemplate{}, &FlinkClusterTemplateList{})
+}
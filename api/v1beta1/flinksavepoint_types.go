@@ -0,0 +1,96 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FlinkSavepointSpec defines the desired state of FlinkSavepoint. The spec
+// cannot be updated after the savepoint is created.
+type FlinkSavepointSpec struct {
+	// The name of the FlinkCluster in the same namespace whose running job the
+	// savepoint is taken for.
+	ClusterName string `json:"clusterName"`
+
+	// (Optional) The directory where to store the savepoint, default: the
+	// `savepointsDir` of the job.
+	SavepointsDir *string `json:"savepointsDir,omitempty"`
+}
+
+// FlinkSavepointStatus defines the observed state of FlinkSavepoint.
+type FlinkSavepointStatus struct {
+	// The state of the savepoint, "NotTriggered", "InProgress",
+	// "TriggerFailed", "Failed" or "Succeeded".
+	State string `json:"state"`
+
+	// The ID of the Flink job.
+	JobID string `json:"jobID,omitempty"`
+
+	// Savepoint trigger ID.
+	TriggerID string `json:"triggerID,omitempty"`
+
+	// Savepoint triggered time.
+	TriggerTime string `json:"triggerTime,omitempty"`
+
+	// Savepoint completion time.
+	CompletionTime string `json:"completionTime,omitempty"`
+
+	// The location of the savepoint, recorded when it succeeded.
+	Location string `json:"location,omitempty"`
+
+	// The reason why the savepoint failed.
+	Message string `json:"message,omitempty"`
+
+	// The time since when the savepoint status cannot be polled from the
+	// JobManager, the savepoint fails if it cannot be polled for 5 minutes.
+	PollErrorSince string `json:"pollErrorSince,omitempty"`
+
+	// Last update timestamp for this status.
+	LastUpdateTime string `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FlinkSavepoint is the Schema for the flinksavepoints API
+// +kubebuilder:resource:shortName=fsp,categories=flink
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".status.completionTime"
+// +kubebuilder:printcolumn:name="Location",type="string",priority=1,JSONPath=".status.location"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+type FlinkSavepoint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FlinkSavepointSpec   `json:"spec"`
+	Status FlinkSavepointStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FlinkSavepointList contains a list of FlinkSavepoint
+type FlinkSavepointList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FlinkSavepoint `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FlinkSavepoint{}, &FlinkSavepointList{})
+}
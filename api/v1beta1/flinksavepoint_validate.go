@@ -0,0 +1,64 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"net/url"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateSavepointCreate validates create request of FlinkSavepoint.
+func (v *Validator) ValidateSavepointCreate(savepoint *FlinkSavepoint) error {
+	var allErrs = v.validateMeta(&savepoint.ObjectMeta, field.NewPath("metadata"))
+
+	var spec = &savepoint.Spec
+	var specPath = field.NewPath("spec")
+	if len(spec.ClusterName) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("clusterName"), ""))
+	}
+
+	if spec.SavepointsDir != nil {
+		var dirURL, err = url.Parse(*spec.SavepointsDir)
+		if err != nil || len(dirURL.Scheme) == 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("savepointsDir"),
+				*spec.SavepointsDir,
+				"the URI scheme is unspecified"))
+		}
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(flinkSavepointGroupKind, savepoint.Name, allErrs)
+	}
+	return nil
+}
+
+// ValidateSavepointUpdate validates update request of FlinkSavepoint.
+func (v *Validator) ValidateSavepointUpdate(
+	old *FlinkSavepoint, new *FlinkSavepoint) error {
+	if !reflect.DeepEqual(new.Spec, old.Spec) {
+		return apierrors.NewInvalid(
+			flinkSavepointGroupKind,
+			new.Name,
+			field.ErrorList{field.Forbidden(
+				field.NewPath("spec"), "the savepoint properties are immutable")})
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateSavepointCreate(t *testing.T) {
+	var validator = &Validator{}
+	var savepointsDir = "gs://my-bucket/savepoints/"
+	var savepoint = FlinkSavepoint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysavepoint",
+			Namespace: "default",
+		},
+		Spec: FlinkSavepointSpec{
+			ClusterName:   "mycluster",
+			SavepointsDir: &savepointsDir,
+		},
+	}
+	var err = validator.ValidateSavepointCreate(&savepoint)
+	assert.NilError(t, err, "create validation failed unexpectedly")
+
+	var savepoint1 = savepoint.DeepCopy()
+	savepoint1.Spec.ClusterName = ""
+	err = validator.ValidateSavepointCreate(savepoint1)
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+	assert.ErrorContains(t, err, "spec.clusterName: Required value")
+
+	var savepoint2 = savepoint.DeepCopy()
+	var invalidDir = "/savepoints"
+	savepoint2.Spec.SavepointsDir = &invalidDir
+	err = validator.ValidateSavepointCreate(savepoint2)
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+	assert.ErrorContains(
+		t,
+		err,
+		`spec.savepointsDir: Invalid value: "/savepoints": the URI scheme is unspecified`)
+}
+
+func TestValidateSavepointUpdate(t *testing.T) {
+	var validator = &Validator{}
+	var oldSavepoint = FlinkSavepoint{
+		Spec: FlinkSavepointSpec{
+			ClusterName: "mycluster",
+		},
+	}
+	var newSavepoint = oldSavepoint.DeepCopy()
+	newSavepoint.Status.State = SavepointStateSucceeded
+	var err = validator.ValidateSavepointUpdate(&oldSavepoint, newSavepoint)
+	assert.NilError(t, err, "status update validation failed unexpectedly")
+
+	newSavepoint.Spec.ClusterName = "othercluster"
+	err = validator.ValidateSavepointUpdate(&oldSavepoint, newSavepoint)
+	assert.ErrorContains(
+		t, err, "spec: Forbidden: the savepoint properties are immutable")
+}
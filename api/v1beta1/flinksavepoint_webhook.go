@@ -0,0 +1,56 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager adds webhook for FlinkSavepoint.
+func (savepoint *FlinkSavepoint) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(savepoint).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-flinkoperator-k8s-io-v1beta1-flinksavepoint,mutating=false,failurePolicy=fail,groups=flinkoperator.k8s.io,resources=flinksavepoints,verbs=create;update,versions=v1beta1,name=vflinksavepoint.flinkoperator.k8s.io
+
+var _ webhook.Validator = &FlinkSavepoint{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered
+// for the type.
+func (savepoint *FlinkSavepoint) ValidateCreate() error {
+	log.Info("Validate create", "name", savepoint.Name)
+	return validator.ValidateSavepointCreate(savepoint)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered
+// for the type.
+func (savepoint *FlinkSavepoint) ValidateUpdate(old runtime.Object) error {
+	log.Info("Validate update", "name", savepoint.Name)
+	var oldSavepoint = old.(*FlinkSavepoint)
+	return validator.ValidateSavepointUpdate(oldSavepoint, savepoint)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered
+// for the type.
+func (savepoint *FlinkSavepoint) ValidateDelete() error {
+	log.Info("validate delete", "name", savepoint.Name)
+	return nil
+}
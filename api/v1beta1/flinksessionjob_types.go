@@ -0,0 +1,102 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FlinkSessionJobSpec defines the desired state of FlinkSessionJob. The spec
+// cannot be updated after the job is created.
+type FlinkSessionJobSpec struct {
+	// The name of the session cluster in the same namespace to run the job in,
+	// i.e., a FlinkCluster without the job spec.
+	ClusterName string `json:"clusterName"`
+
+	// JAR file of the job. It must be an HTTP or HTTPS URL, the operator
+	// downloads the JAR file and uploads it to the JobManager.
+	JarFile string `json:"jarFile"`
+
+	// Fully qualified Java class name of the job.
+	ClassName *string `json:"className,omitempty"`
+
+	// Args of the job.
+	Args []string `json:"args,omitempty"`
+
+	// FromSavepoint where to restore the job from (e.g., gs://my-savepoint/1234).
+	FromSavepoint *string `json:"fromSavepoint,omitempty"`
+
+	// Allow non-restored state, default: false.
+	AllowNonRestoredState *bool `json:"allowNonRestoredState,omitempty"`
+
+	// Job parallelism, default: 1.
+	Parallelism *int32 `json:"parallelism,omitempty"`
+}
+
+// FlinkSessionJobStatus defines the observed state of FlinkSessionJob.
+type FlinkSessionJobStatus struct {
+	// The ID of the Flink job.
+	ID string `json:"id,omitempty"`
+
+	// The state of the job, "Pending", "Running", "Succeeded", "Failed" or
+	// "Cancelled".
+	State string `json:"state"`
+
+	// The state of the Flink job reported by the JobManager, e.g., RUNNING,
+	// RESTARTING, FAILING, FINISHED.
+	FlinkJobState string `json:"flinkJobState,omitempty"`
+
+	// The start time of the Flink job.
+	StartTime string `json:"startTime,omitempty"`
+
+	// The root exceptions of the recent failures of the Flink job prefixed
+	// with their timestamps, the latest one is the last.
+	FailureReasons []string `json:"failureReasons,omitempty"`
+
+	// Last update timestamp for this status.
+	LastUpdateTime string `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FlinkSessionJob is the Schema for the flinksessionjobs API
+// +kubebuilder:resource:shortName=fsj,categories=flink
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Flink Job State",type="string",JSONPath=".status.flinkJobState"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+type FlinkSessionJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FlinkSessionJobSpec   `json:"spec"`
+	Status FlinkSessionJobStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FlinkSessionJobList contains a list of FlinkSessionJob
+type FlinkSessionJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FlinkSessionJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FlinkSessionJob{}, &FlinkSessionJobList{})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"net/url"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateSessionJobCreate validates create request of FlinkSessionJob.
+func (v *Validator) ValidateSessionJobCreate(job *FlinkSessionJob) error {
+	var allErrs = v.validateMeta(&job.ObjectMeta, field.NewPath("metadata"))
+
+	var jobSpec = &job.Spec
+	var specPath = field.NewPath("spec")
+	if len(jobSpec.ClusterName) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("clusterName"), ""))
+	}
+
+	if len(jobSpec.JarFile) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("jarFile"), ""))
+	} else {
+		var jarURL, err = url.Parse(jobSpec.JarFile)
+		if err != nil || (jarURL.Scheme != "http" && jarURL.Scheme != "https") {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("jarFile"),
+				jobSpec.JarFile,
+				"it must be an HTTP or HTTPS URL"))
+		}
+	}
+
+	if jobSpec.Parallelism == nil {
+		allErrs = append(allErrs, field.Required(specPath.Child("parallelism"), ""))
+	} else if *jobSpec.Parallelism < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("parallelism"), *jobSpec.Parallelism, "it must be >= 1"))
+	}
+
+	if jobSpec.FromSavepoint != nil {
+		var savepointURL, err = url.Parse(*jobSpec.FromSavepoint)
+		if err != nil || len(savepointURL.Scheme) == 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("fromSavepoint"),
+				*jobSpec.FromSavepoint,
+				"the URI scheme is unspecified"))
+		}
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(flinkSessionJobGroupKind, job.Name, allErrs)
+	}
+	return nil
+}
+
+// ValidateSessionJobUpdate validates update request of FlinkSessionJob.
+func (v *Validator) ValidateSessionJobUpdate(
+	old *FlinkSessionJob, new *FlinkSessionJob) error {
+	if !reflect.DeepEqual(new.Spec, old.Spec) {
+		return apierrors.NewInvalid(
+			flinkSessionJobGroupKind,
+			new.Name,
+			field.ErrorList{field.Forbidden(
+				field.NewPath("spec"), "the session job properties are immutable")})
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateSessionJobCreate(t *testing.T) {
+	var validator = &Validator{}
+	var parallelism int32 = 2
+	var job = FlinkSessionJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myjob",
+			Namespace: "default",
+		},
+		Spec: FlinkSessionJobSpec{
+			ClusterName: "mycluster",
+			JarFile:     "https://my-repo/myjob.jar",
+			Parallelism: &parallelism,
+		},
+	}
+	var err = validator.ValidateSessionJobCreate(&job)
+	assert.NilError(t, err, "create validation failed unexpectedly")
+
+	var job1 = job.DeepCopy()
+	job1.Spec.ClusterName = ""
+	err = validator.ValidateSessionJobCreate(job1)
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+	assert.ErrorContains(t, err, "spec.clusterName: Required value")
+
+	var job2 = job.DeepCopy()
+	job2.Spec.JarFile = "gs://my-bucket/myjob.jar"
+	err = validator.ValidateSessionJobCreate(job2)
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+	assert.ErrorContains(
+		t,
+		err,
+		`spec.jarFile: Invalid value: "gs://my-bucket/myjob.jar": it must be an HTTP or HTTPS URL`)
+
+	var job3 = job.DeepCopy()
+	*job3.Spec.Parallelism = 0
+	err = validator.ValidateSessionJobCreate(job3)
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+	assert.ErrorContains(t, err, "spec.parallelism: Invalid value: 0: it must be >= 1")
+
+	var job4 = job.DeepCopy()
+	var fromSavepoint = "/savepoints/1234"
+	job4.Spec.FromSavepoint = &fromSavepoint
+	err = validator.ValidateSessionJobCreate(job4)
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+	assert.ErrorContains(
+		t,
+		err,
+		`spec.fromSavepoint: Invalid value: "/savepoints/1234": the URI scheme is unspecified`)
+}
+
+func TestValidateSessionJobUpdate(t *testing.T) {
+	var validator = &Validator{}
+	var parallelism int32 = 2
+	var oldJob = FlinkSessionJob{
+		Spec: FlinkSessionJobSpec{
+			ClusterName: "mycluster",
+			JarFile:     "https://my-repo/myjob.jar",
+			Parallelism: &parallelism,
+		},
+	}
+	var newJob = oldJob.DeepCopy()
+	newJob.Status.State = JobStateRunning
+	var err = validator.ValidateSessionJobUpdate(&oldJob, newJob)
+	assert.NilError(t, err, "update validation failed unexpectedly")
+
+	newJob.Spec.JarFile = "https://my-repo/myjob-v2.jar"
+	err = validator.ValidateSessionJobUpdate(&oldJob, newJob)
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+	assert.ErrorContains(
+		t, err, "spec: Forbidden: the session job properties are immutable")
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager adds webhook for FlinkSessionJob.
+func (job *FlinkSessionJob) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(job).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-flinkoperator-k8s-io-v1beta1-flinksessionjob,mutating=true,failurePolicy=fail,groups=flinkoperator.k8s.io,resources=flinksessionjobs,verbs=create;update,versions=v1beta1,name=mflinksessionjob.flinkoperator.k8s.io
+
+var _ webhook.Defaulter = &FlinkSessionJob{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the
+// type.
+func (job *FlinkSessionJob) Default() {
+	log.Info("default", "name", job.Name, "original", *job)
+	_SetSessionJobDefault(job)
+	log.Info("default", "name", job.Name, "augmented", *job)
+}
+
+// +kubebuilder:webhook:path=/validate-flinkoperator-k8s-io-v1beta1-flinksessionjob,mutating=false,failurePolicy=fail,groups=flinkoperator.k8s.io,resources=flinksessionjobs,verbs=create;update,versions=v1beta1,name=vflinksessionjob.flinkoperator.k8s.io
+
+var _ webhook.Validator = &FlinkSessionJob{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered
+// for the type.
+func (job *FlinkSessionJob) ValidateCreate() error {
+	log.Info("Validate create", "name", job.Name)
+	return validator.ValidateSessionJobCreate(job)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered
+// for the type.
+func (job *FlinkSessionJob) ValidateUpdate(old runtime.Object) error {
+	log.Info("Validate update", "name", job.Name)
+	var oldJob = old.(*FlinkSessionJob)
+	return validator.ValidateSessionJobUpdate(oldJob, job)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered
+// for the type.
+func (job *FlinkSessionJob) ValidateDelete() error {
+	log.Info("validate delete", "name", job.Name)
+	return nil
+}
@@ -22,9 +22,186 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerSpec) DeepCopyInto(out *AutoscalerSpec) {
+	*out = *in
+	if in.MinParallelism != nil {
+		in, out := &in.MinParallelism, &out.MinParallelism
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetBusyPercent != nil {
+		in, out := &in.TargetBusyPercent, &out.TargetBusyPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxConsumerLag != nil {
+		in, out := &in.MaxConsumerLag, &out.MaxConsumerLag
+		*out = new(int64)
+		**out = **in
+	}
+	if in.StabilizationSeconds != nil {
+		in, out := &in.StabilizationSeconds, &out.StabilizationSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerSpec.
+func (in *AutoscalerSpec) DeepCopy() *AutoscalerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerStatus) DeepCopyInto(out *AutoscalerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerStatus.
+func (in *AutoscalerStatus) DeepCopy() *AutoscalerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BatchSchedulerSpec) DeepCopyInto(out *BatchSchedulerSpec) {
+	*out = *in
+	if in.SchedulerName != nil {
+		in, out := &in.SchedulerName, &out.SchedulerName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Queue != nil {
+		in, out := &in.Queue, &out.Queue
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BatchSchedulerSpec.
+func (in *BatchSchedulerSpec) DeepCopy() *BatchSchedulerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BatchSchedulerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenSpec) DeepCopyInto(out *BlueGreenSpec) {
+	*out = *in
+	if in.StableSeconds != nil {
+		in, out := &in.StableSeconds, &out.StableSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HealthCheckURL != nil {
+		in, out := &in.HealthCheckURL, &out.HealthCheckURL
+		*out = new(string)
+		**out = **in
+	}
+	if in.DeadlineSeconds != nil {
+		in, out := &in.DeadlineSeconds, &out.DeadlineSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenSpec.
+func (in *BlueGreenSpec) DeepCopy() *BlueGreenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenStatus) DeepCopyInto(out *BlueGreenStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenStatus.
+func (in *BlueGreenStatus) DeepCopy() *BlueGreenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerRef) DeepCopyInto(out *CertManagerIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerRef.
+func (in *CertManagerIssuerRef) DeepCopy() *CertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckpointingSpec) DeepCopyInto(out *CheckpointingSpec) {
+	*out = *in
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(string)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinPauseSeconds != nil {
+		in, out := &in.MinPauseSeconds, &out.MinPauseSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ExternalizedRetention != nil {
+		in, out := &in.ExternalizedRetention, &out.ExternalizedRetention
+		*out = new(string)
+		**out = **in
+	}
+	if in.Unaligned != nil {
+		in, out := &in.Unaligned, &out.Unaligned
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckpointingSpec.
+func (in *CheckpointingSpec) DeepCopy() *CheckpointingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckpointingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CleanupPolicy) DeepCopyInto(out *CleanupPolicy) {
 	*out = *in
@@ -40,6 +217,21 @@ func (in *CleanupPolicy) DeepCopy() *CleanupPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCondition.
+func (in *ClusterCondition) DeepCopy() *ClusterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FlinkCluster) DeepCopyInto(out *FlinkCluster) {
 	*out = *in
@@ -94,10 +286,15 @@ func (in *FlinkClusterComponentsStatus) DeepCopyInto(out *FlinkClusterComponents
 		(*in).DeepCopyInto(*out)
 	}
 	out.TaskManagerDeployment = in.TaskManagerDeployment
+	if in.HistoryServer != nil {
+		in, out := &in.HistoryServer, &out.HistoryServer
+		*out = new(FlinkClusterComponentState)
+		**out = **in
+	}
 	if in.Job != nil {
 		in, out := &in.Job, &out.Job
 		*out = new(JobStatus)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -169,6 +366,25 @@ func (in *FlinkClusterList) DeepCopyObject() runtime.Object {
 func (in *FlinkClusterSpec) DeepCopyInto(out *FlinkClusterSpec) {
 	*out = *in
 	in.Image.DeepCopyInto(&out.Image)
+	if in.TemplateRef != nil {
+		in, out := &in.TemplateRef, &out.TemplateRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	in.JobManager.DeepCopyInto(&out.JobManager)
 	in.TaskManager.DeepCopyInto(&out.TaskManager)
 	if in.Job != nil {
@@ -190,16 +406,115 @@ func (in *FlinkClusterSpec) DeepCopyInto(out *FlinkClusterSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.FlinkPropertiesFrom != nil {
+		in, out := &in.FlinkPropertiesFrom, &out.FlinkPropertiesFrom
+		*out = make([]FlinkPropertySource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LogConfig != nil {
+		in, out := &in.LogConfig, &out.LogConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.HadoopConfig != nil {
 		in, out := &in.HadoopConfig, &out.HadoopConfig
 		*out = new(HadoopConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.GCPConfig != nil {
 		in, out := &in.GCPConfig, &out.GCPConfig
 		*out = new(GCPConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ServiceAccountName != nil {
+		in, out := &in.ServiceAccountName, &out.ServiceAccountName
+		*out = new(string)
+		**out = **in
+	}
+	if in.AutomountServiceAccountToken != nil {
+		in, out := &in.AutomountServiceAccountToken, &out.AutomountServiceAccountToken
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HighAvailability != nil {
+		in, out := &in.HighAvailability, &out.HighAvailability
+		*out = new(HighAvailabilitySpec)
+		**out = **in
+	}
+	if in.Autoscaler != nil {
+		in, out := &in.Autoscaler, &out.Autoscaler
+		*out = new(AutoscalerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(MetricsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StateBackend != nil {
+		in, out := &in.StateBackend, &out.StateBackend
+		*out = new(StateBackendSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceMesh != nil {
+		in, out := &in.ServiceMesh, &out.ServiceMesh
+		*out = new(ServiceMeshSpec)
+		**out = **in
+	}
+	if in.Security != nil {
+		in, out := &in.Security, &out.Security
+		*out = new(SecuritySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BatchScheduler != nil {
+		in, out := &in.BatchScheduler, &out.BatchScheduler
+		*out = new(BatchSchedulerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HistoryServer != nil {
+		in, out := &in.HistoryServer, &out.HistoryServer
+		*out = new(HistoryServerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdleTimeoutSeconds != nil {
+		in, out := &in.IdleTimeoutSeconds, &out.IdleTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(string)
+		**out = **in
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestartOnChange != nil {
+		in, out := &in.RestartOnChange, &out.RestartOnChange
+		*out = new(RestartOnChangeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(TimeoutsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkClusterSpec.
@@ -226,6 +541,26 @@ func (in *FlinkClusterStatus) DeepCopyInto(out *FlinkClusterStatus) {
 		*out = new(SavepointStatus)
 		**out = **in
 	}
+	if in.Autoscaler != nil {
+		in, out := &in.Autoscaler, &out.Autoscaler
+		*out = new(AutoscalerStatus)
+		**out = **in
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenStatus)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(TimeoutStatus)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ClusterCondition, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkClusterStatus.
@@ -239,142 +574,1630 @@ func (in *FlinkClusterStatus) DeepCopy() *FlinkClusterStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GCPConfig) DeepCopyInto(out *GCPConfig) {
+func (in *FlinkClusterTemplate) DeepCopyInto(out *FlinkClusterTemplate) {
 	*out = *in
-	if in.ServiceAccount != nil {
-		in, out := &in.ServiceAccount, &out.ServiceAccount
-		*out = new(GCPServiceAccount)
-		**out = **in
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPConfig.
-func (in *GCPConfig) DeepCopy() *GCPConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkClusterTemplate.
+func (in *FlinkClusterTemplate) DeepCopy() *FlinkClusterTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(GCPConfig)
+	out := new(FlinkClusterTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlinkClusterTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GCPServiceAccount) DeepCopyInto(out *GCPServiceAccount) {
+func (in *FlinkClusterTemplateList) DeepCopyInto(out *FlinkClusterTemplateList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FlinkClusterTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPServiceAccount.
-func (in *GCPServiceAccount) DeepCopy() *GCPServiceAccount {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkClusterTemplateList.
+func (in *FlinkClusterTemplateList) DeepCopy() *FlinkClusterTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(GCPServiceAccount)
+	out := new(FlinkClusterTemplateList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlinkClusterTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HadoopConfig) DeepCopyInto(out *HadoopConfig) {
+func (in *FlinkPropertySource) DeepCopyInto(out *FlinkPropertySource) {
 	*out = *in
+	in.ValueFrom.DeepCopyInto(&out.ValueFrom)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HadoopConfig.
-func (in *HadoopConfig) DeepCopy() *HadoopConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkPropertySource.
+func (in *FlinkPropertySource) DeepCopy() *FlinkPropertySource {
 	if in == nil {
 		return nil
 	}
-	out := new(HadoopConfig)
+	out := new(FlinkPropertySource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ImageSpec) DeepCopyInto(out *ImageSpec) {
+func (in *FlinkPropertyValueSource) DeepCopyInto(out *FlinkPropertyValueSource) {
 	*out = *in
-	if in.PullSecrets != nil {
-		in, out := &in.PullSecrets, &out.PullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
-		copy(*out, *in)
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSpec.
-func (in *ImageSpec) DeepCopy() *ImageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkPropertyValueSource.
+func (in *FlinkPropertyValueSource) DeepCopy() *FlinkPropertyValueSource {
 	if in == nil {
 		return nil
 	}
-	out := new(ImageSpec)
+	out := new(FlinkPropertyValueSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobManagerIngressSpec) DeepCopyInto(out *JobManagerIngressSpec) {
+func (in *FlinkSavepoint) DeepCopyInto(out *FlinkSavepoint) {
 	*out = *in
-	if in.HostFormat != nil {
-		in, out := &in.HostFormat, &out.HostFormat
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSavepoint.
+func (in *FlinkSavepoint) DeepCopy() *FlinkSavepoint {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSavepoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlinkSavepoint) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlinkSavepointList) DeepCopyInto(out *FlinkSavepointList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FlinkSavepoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSavepointList.
+func (in *FlinkSavepointList) DeepCopy() *FlinkSavepointList {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSavepointList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlinkSavepointList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlinkSavepointSpec) DeepCopyInto(out *FlinkSavepointSpec) {
+	*out = *in
+	if in.SavepointsDir != nil {
+		in, out := &in.SavepointsDir, &out.SavepointsDir
 		*out = new(string)
 		**out = **in
 	}
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSavepointSpec.
+func (in *FlinkSavepointSpec) DeepCopy() *FlinkSavepointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSavepointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlinkSavepointStatus) DeepCopyInto(out *FlinkSavepointStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSavepointStatus.
+func (in *FlinkSavepointStatus) DeepCopy() *FlinkSavepointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSavepointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlinkSessionJob) DeepCopyInto(out *FlinkSessionJob) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSessionJob.
+func (in *FlinkSessionJob) DeepCopy() *FlinkSessionJob {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSessionJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlinkSessionJob) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlinkSessionJobList) DeepCopyInto(out *FlinkSessionJobList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FlinkSessionJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSessionJobList.
+func (in *FlinkSessionJobList) DeepCopy() *FlinkSessionJobList {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSessionJobList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlinkSessionJobList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlinkSessionJobSpec) DeepCopyInto(out *FlinkSessionJobSpec) {
+	*out = *in
+	if in.ClassName != nil {
+		in, out := &in.ClassName, &out.ClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FromSavepoint != nil {
+		in, out := &in.FromSavepoint, &out.FromSavepoint
+		*out = new(string)
+		**out = **in
+	}
+	if in.AllowNonRestoredState != nil {
+		in, out := &in.AllowNonRestoredState, &out.AllowNonRestoredState
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSessionJobSpec.
+func (in *FlinkSessionJobSpec) DeepCopy() *FlinkSessionJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSessionJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlinkSessionJobStatus) DeepCopyInto(out *FlinkSessionJobStatus) {
+	*out = *in
+	if in.FailureReasons != nil {
+		in, out := &in.FailureReasons, &out.FailureReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlinkSessionJobStatus.
+func (in *FlinkSessionJobStatus) DeepCopy() *FlinkSessionJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FlinkSessionJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPConfig) DeepCopyInto(out *GCPConfig) {
+	*out = *in
+	if in.ServiceAccount != nil {
+		in, out := &in.ServiceAccount, &out.ServiceAccount
+		*out = new(GCPServiceAccount)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPConfig.
+func (in *GCPConfig) DeepCopy() *GCPConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPServiceAccount) DeepCopyInto(out *GCPServiceAccount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPServiceAccount.
+func (in *GCPServiceAccount) DeepCopy() *GCPServiceAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPServiceAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUSpec) DeepCopyInto(out *GPUSpec) {
+	*out = *in
+	if in.ResourceName != nil {
+		in, out := &in.ResourceName, &out.ResourceName
+		*out = new(string)
+		**out = **in
+	}
+	if in.DiscoveryScriptPath != nil {
+		in, out := &in.DiscoveryScriptPath, &out.DiscoveryScriptPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.DiscoveryScriptArgs != nil {
+		in, out := &in.DiscoveryScriptArgs, &out.DiscoveryScriptArgs
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUSpec.
+func (in *GPUSpec) DeepCopy() *GPUSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HadoopConfig) DeepCopyInto(out *HadoopConfig) {
+	*out = *in
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
-	if in.UseTLS != nil {
-		in, out := &in.UseTLS, &out.UseTLS
-		*out = new(bool)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HadoopConfig.
+func (in *HadoopConfig) DeepCopy() *HadoopConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HadoopConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HighAvailabilitySpec) DeepCopyInto(out *HighAvailabilitySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HighAvailabilitySpec.
+func (in *HighAvailabilitySpec) DeepCopy() *HighAvailabilitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HighAvailabilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HistoryServerSpec) DeepCopyInto(out *HistoryServerSpec) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RefreshIntervalSeconds != nil {
+		in, out := &in.RefreshIntervalSeconds, &out.RefreshIntervalSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(JobManagerServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(JobManagerIngressSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HistoryServerSpec.
+func (in *HistoryServerSpec) DeepCopy() *HistoryServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HistoryServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageSpec) DeepCopyInto(out *ImageSpec) {
+	*out = *in
+	if in.PullSecrets != nil {
+		in, out := &in.PullSecrets, &out.PullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSpec.
+func (in *ImageSpec) DeepCopy() *ImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InternalSSLSpec) DeepCopyInto(out *InternalSSLSpec) {
+	*out = *in
+	if in.PasswordSecretKeyRef != nil {
+		in, out := &in.PasswordSecretKeyRef, &out.PasswordSecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InternalSSLSpec.
+func (in *InternalSSLSpec) DeepCopy() *InternalSSLSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InternalSSLSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobManagerDeploymentStatus) DeepCopyInto(out *JobManagerDeploymentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerDeploymentStatus.
+func (in *JobManagerDeploymentStatus) DeepCopy() *JobManagerDeploymentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobManagerDeploymentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobManagerIngressSpec) DeepCopyInto(out *JobManagerIngressSpec) {
+	*out = *in
+	if in.HostFormat != nil {
+		in, out := &in.HostFormat, &out.HostFormat
+		*out = new(string)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.UseTLS != nil {
+		in, out := &in.UseTLS, &out.UseTLS
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TLSSecretName != nil {
+		in, out := &in.TLSSecretName, &out.TLSSecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerIngressSpec.
+func (in *JobManagerIngressSpec) DeepCopy() *JobManagerIngressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JobManagerIngressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobManagerIngressStatus) DeepCopyInto(out *JobManagerIngressStatus) {
+	*out = *in
+	if in.URLs != nil {
+		in, out := &in.URLs, &out.URLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerIngressStatus.
+func (in *JobManagerIngressStatus) DeepCopy() *JobManagerIngressStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobManagerIngressStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobManagerPorts) DeepCopyInto(out *JobManagerPorts) {
+	*out = *in
+	if in.RPC != nil {
+		in, out := &in.RPC, &out.RPC
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Blob != nil {
+		in, out := &in.Blob, &out.Blob
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Query != nil {
+		in, out := &in.Query, &out.Query
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UI != nil {
+		in, out := &in.UI, &out.UI
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerPorts.
+func (in *JobManagerPorts) DeepCopy() *JobManagerPorts {
+	if in == nil {
+		return nil
+	}
+	out := new(JobManagerPorts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobManagerServiceStatus) DeepCopyInto(out *JobManagerServiceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerServiceStatus.
+func (in *JobManagerServiceStatus) DeepCopy() *JobManagerServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobManagerServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobManagerServiceSpec) DeepCopyInto(out *JobManagerServiceSpec) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(v1.ServiceType)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerServiceSpec.
+func (in *JobManagerServiceSpec) DeepCopy() *JobManagerServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JobManagerServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobManagerSpec) DeepCopyInto(out *JobManagerSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DeploymentType != nil {
+		in, out := &in.DeploymentType, &out.DeploymentType
+		*out = new(string)
+		**out = **in
+	}
+	if in.PodManagementPolicy != nil {
+		in, out := &in.PodManagementPolicy, &out.PodManagementPolicy
+		*out = new(string)
+		**out = **in
+	}
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(JobManagerIngressSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(JobManagerServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UIAuthProxy != nil {
+		in, out := &in.UIAuthProxy, &out.UIAuthProxy
+		*out = new(UIAuthProxySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Ports.DeepCopyInto(&out.Ports)
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.MemoryOffHeapRatio != nil {
+		in, out := &in.MemoryOffHeapRatio, &out.MemoryOffHeapRatio
+		*out = new(int32)
+		**out = **in
+	}
+	out.MemoryOffHeapMin = in.MemoryOffHeapMin.DeepCopy()
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]v1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PriorityClassName != nil {
+		in, out := &in.PriorityClassName, &out.PriorityClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(v1.PodTemplateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerSpec.
+func (in *JobManagerSpec) DeepCopy() *JobManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JobManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobRollbackStatus) DeepCopyInto(out *JobRollbackStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobRollbackStatus.
+func (in *JobRollbackStatus) DeepCopy() *JobRollbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobRollbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobRunStatus) DeepCopyInto(out *JobRunStatus) {
+	*out = *in
+	if in.FailureReasons != nil {
+		in, out := &in.FailureReasons, &out.FailureReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobRunStatus.
+func (in *JobRunStatus) DeepCopy() *JobRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobSpec) DeepCopyInto(out *JobSpec) {
+	*out = *in
+	if in.JarSha256 != nil {
+		in, out := &in.JarSha256, &out.JarSha256
+		*out = new(string)
+		**out = **in
+	}
+	if in.JarDownloaderImage != nil {
+		in, out := &in.JarDownloaderImage, &out.JarDownloaderImage
+		*out = new(string)
+		**out = **in
+	}
+	if in.PythonFile != nil {
+		in, out := &in.PythonFile, &out.PythonFile
+		*out = new(string)
+		**out = **in
+	}
+	if in.PythonFiles != nil {
+		in, out := &in.PythonFiles, &out.PythonFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PythonRequirements != nil {
+		in, out := &in.PythonRequirements, &out.PythonRequirements
+		*out = new(string)
+		**out = **in
+	}
+	if in.SQL != nil {
+		in, out := &in.SQL, &out.SQL
+		*out = new(string)
+		**out = **in
+	}
+	if in.SQLConfigMap != nil {
+		in, out := &in.SQLConfigMap, &out.SQLConfigMap
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClassName != nil {
+		in, out := &in.ClassName, &out.ClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SubmissionMode != nil {
+		in, out := &in.SubmissionMode, &out.SubmissionMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FromSavepoint != nil {
+		in, out := &in.FromSavepoint, &out.FromSavepoint
+		*out = new(string)
+		**out = **in
+	}
+	if in.AllowNonRestoredState != nil {
+		in, out := &in.AllowNonRestoredState, &out.AllowNonRestoredState
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SavepointsDir != nil {
+		in, out := &in.SavepointsDir, &out.SavepointsDir
+		*out = new(string)
+		**out = **in
+	}
+	if in.TakeSavepointOnUpgrade != nil {
+		in, out := &in.TakeSavepointOnUpgrade, &out.TakeSavepointOnUpgrade
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AutoSavepointSeconds != nil {
+		in, out := &in.AutoSavepointSeconds, &out.AutoSavepointSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SavepointHistoryLimit != nil {
+		in, out := &in.SavepointHistoryLimit, &out.SavepointHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UpgradeTimeoutSeconds != nil {
+		in, out := &in.UpgradeTimeoutSeconds, &out.UpgradeTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SavepointMaxRetries != nil {
+		in, out := &in.SavepointMaxRetries, &out.SavepointMaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SavepointRetryBackoffSeconds != nil {
+		in, out := &in.SavepointRetryBackoffSeconds, &out.SavepointRetryBackoffSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReadySlotsRequired != nil {
+		in, out := &in.ReadySlotsRequired, &out.ReadySlotsRequired
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NoLoggingToStdout != nil {
+		in, out := &in.NoLoggingToStdout, &out.NoLoggingToStdout
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]v1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PriorityClassName != nil {
+		in, out := &in.PriorityClassName, &out.PriorityClassName
+		*out = new(string)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.ServiceAccountName != nil {
+		in, out := &in.ServiceAccountName, &out.ServiceAccountName
+		*out = new(string)
+		**out = **in
+	}
+	if in.PodLabels != nil {
+		in, out := &in.PodLabels, &out.PodLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.WaitForFiles != nil {
+		in, out := &in.WaitForFiles, &out.WaitForFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SidecarShutdownURLs != nil {
+		in, out := &in.SidecarShutdownURLs, &out.SidecarShutdownURLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestartPolicy != nil {
+		in, out := &in.RestartPolicy, &out.RestartPolicy
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxStateAgeToRestoreSeconds != nil {
+		in, out := &in.MaxStateAgeToRestoreSeconds, &out.MaxStateAgeToRestoreSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetryBackoffSeconds != nil {
+		in, out := &in.RetryBackoffSeconds, &out.RetryBackoffSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRetryBackoffSeconds != nil {
+		in, out := &in.MaxRetryBackoffSeconds, &out.MaxRetryBackoffSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CleanupPolicy != nil {
+		in, out := &in.CleanupPolicy, &out.CleanupPolicy
+		*out = new(CleanupPolicy)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CancelRequested != nil {
+		in, out := &in.CancelRequested, &out.CancelRequested
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Checkpointing != nil {
+		in, out := &in.Checkpointing, &out.Checkpointing
+		*out = new(CheckpointingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TakeSavepointOnCancel != nil {
+		in, out := &in.TakeSavepointOnCancel, &out.TakeSavepointOnCancel
+		*out = new(bool)
+		**out = **in
+	}
+	if in.StopWithDrain != nil {
+		in, out := &in.StopWithDrain, &out.StopWithDrain
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TakeSavepointOnDelete != nil {
+		in, out := &in.TakeSavepointOnDelete, &out.TakeSavepointOnDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConcurrencyPolicy != nil {
+		in, out := &in.ConcurrencyPolicy, &out.ConcurrencyPolicy
+		*out = new(string)
+		**out = **in
+	}
+	if in.SuccessfulRunsHistoryLimit != nil {
+		in, out := &in.SuccessfulRunsHistoryLimit, &out.SuccessfulRunsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedRunsHistoryLimit != nil {
+		in, out := &in.FailedRunsHistoryLimit, &out.FailedRunsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DeleteTaskManagersBetweenRuns != nil {
+		in, out := &in.DeleteTaskManagersBetweenRuns, &out.DeleteTaskManagersBetweenRuns
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSpec.
+func (in *JobSpec) DeepCopy() *JobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobStatus) DeepCopyInto(out *JobStatus) {
+	*out = *in
+	if in.FailureReasons != nil {
+		in, out := &in.FailureReasons, &out.FailureReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SavepointHistory != nil {
+		in, out := &in.SavepointHistory, &out.SavepointHistory
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Accumulators != nil {
+		in, out := &in.Accumulators, &out.Accumulators
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RunHistory != nil {
+		in, out := &in.RunHistory, &out.RunHistory
+		*out = make([]JobRunStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(JobUpgradeStatus)
+		**out = **in
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(JobRollbackStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobStatus.
+func (in *JobStatus) DeepCopy() *JobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobUpgradeStatus) DeepCopyInto(out *JobUpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobUpgradeStatus.
+func (in *JobUpgradeStatus) DeepCopy() *JobUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KerberosSpec) DeepCopyInto(out *KerberosSpec) {
+	*out = *in
+	if in.LoginContexts != nil {
+		in, out := &in.LoginContexts, &out.LoginContexts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KerberosSpec.
+func (in *KerberosSpec) DeepCopy() *KerberosSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KerberosSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsSpec) DeepCopyInto(out *MetricsSpec) {
+	*out = *in
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = new(PrometheusMetricsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsSpec.
+func (in *MetricsSpec) DeepCopy() *MetricsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
+	*out = *in
+	if in.UIIngressCIDRs != nil {
+		in, out := &in.UIIngressCIDRs, &out.UIIngressCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OperatorNamespaceSelector != nil {
+		in, out := &in.OperatorNamespaceSelector, &out.OperatorNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
+func (in *NetworkPolicySpec) DeepCopy() *NetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetSpec) DeepCopyInto(out *PodDisruptionBudgetSpec) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetSpec.
+func (in *PodDisruptionBudgetSpec) DeepCopy() *PodDisruptionBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodMonitorSpec) DeepCopyInto(out *PodMonitorSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodMonitorSpec.
+func (in *PodMonitorSpec) DeepCopy() *PodMonitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodMonitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusMetricsSpec) DeepCopyInto(out *PrometheusMetricsSpec) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PodMonitor != nil {
+		in, out := &in.PodMonitor, &out.PodMonitor
+		*out = new(PodMonitorSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusMetricsSpec.
+func (in *PrometheusMetricsSpec) DeepCopy() *PrometheusMetricsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusMetricsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RESTSSLSpec) DeepCopyInto(out *RESTSSLSpec) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(CertManagerIssuerRef)
+		**out = **in
+	}
+	if in.PasswordSecretKeyRef != nil {
+		in, out := &in.PasswordSecretKeyRef, &out.PasswordSecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RESTSSLSpec.
+func (in *RESTSSLSpec) DeepCopy() *RESTSSLSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RESTSSLSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartOnChangeSpec) DeepCopyInto(out *RestartOnChangeSpec) {
+	*out = *in
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartOnChangeSpec.
+func (in *RestartOnChangeSpec) DeepCopy() *RestartOnChangeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartOnChangeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RocksDBLocalDirSpec) DeepCopyInto(out *RocksDBLocalDirSpec) {
+	*out = *in
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(v1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(v1.PersistentVolumeClaimVolumeSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RocksDBLocalDirSpec.
+func (in *RocksDBLocalDirSpec) DeepCopy() *RocksDBLocalDirSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RocksDBLocalDirSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSLSpec) DeepCopyInto(out *SSLSpec) {
+	*out = *in
+	if in.Internal != nil {
+		in, out := &in.Internal, &out.Internal
+		*out = new(InternalSSLSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.REST != nil {
+		in, out := &in.REST, &out.REST
+		*out = new(RESTSSLSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSLSpec.
+func (in *SSLSpec) DeepCopy() *SSLSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SSLSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SavepointStatus) DeepCopyInto(out *SavepointStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SavepointStatus.
+func (in *SavepointStatus) DeepCopy() *SavepointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SavepointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskManagerDeploymentStatus) DeepCopyInto(out *TaskManagerDeploymentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskManagerDeploymentStatus.
+func (in *TaskManagerDeploymentStatus) DeepCopy() *TaskManagerDeploymentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskManagerDeploymentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecuritySpec) DeepCopyInto(out *SecuritySpec) {
+	*out = *in
+	if in.SSL != nil {
+		in, out := &in.SSL, &out.SSL
+		*out = new(SSLSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kerberos != nil {
+		in, out := &in.Kerberos, &out.Kerberos
+		*out = new(KerberosSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecuritySpec.
+func (in *SecuritySpec) DeepCopy() *SecuritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecuritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshSpec) DeepCopyInto(out *ServiceMeshSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshSpec.
+func (in *ServiceMeshSpec) DeepCopy() *ServiceMeshSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StateBackendSpec) DeepCopyInto(out *StateBackendSpec) {
+	*out = *in
+	if in.CheckpointDir != nil {
+		in, out := &in.CheckpointDir, &out.CheckpointDir
+		*out = new(string)
+		**out = **in
+	}
+	if in.SavepointDir != nil {
+		in, out := &in.SavepointDir, &out.SavepointDir
+		*out = new(string)
 		**out = **in
 	}
-	if in.TLSSecretName != nil {
-		in, out := &in.TLSSecretName, &out.TLSSecretName
-		*out = new(string)
+	if in.Incremental != nil {
+		in, out := &in.Incremental, &out.Incremental
+		*out = new(bool)
 		**out = **in
 	}
+	if in.RocksDBLocalDir != nil {
+		in, out := &in.RocksDBLocalDir, &out.RocksDBLocalDir
+		*out = new(RocksDBLocalDirSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerIngressSpec.
-func (in *JobManagerIngressSpec) DeepCopy() *JobManagerIngressSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StateBackendSpec.
+func (in *StateBackendSpec) DeepCopy() *StateBackendSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(JobManagerIngressSpec)
+	out := new(StateBackendSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobManagerIngressStatus) DeepCopyInto(out *JobManagerIngressStatus) {
+func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
 	*out = *in
-	if in.URLs != nil {
-		in, out := &in.URLs, &out.URLs
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerIngressStatus.
-func (in *JobManagerIngressStatus) DeepCopy() *JobManagerIngressStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageSpec.
+func (in *StorageSpec) DeepCopy() *StorageSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(JobManagerIngressStatus)
+	out := new(StorageSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobManagerPorts) DeepCopyInto(out *JobManagerPorts) {
+func (in *TaskManagerPorts) DeepCopyInto(out *TaskManagerPorts) {
 	*out = *in
-	if in.RPC != nil {
-		in, out := &in.RPC, &out.RPC
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
 		*out = new(int32)
 		**out = **in
 	}
-	if in.Blob != nil {
-		in, out := &in.Blob, &out.Blob
+	if in.RPC != nil {
+		in, out := &in.RPC, &out.RPC
 		*out = new(int32)
 		**out = **in
 	}
@@ -383,50 +2206,30 @@ func (in *JobManagerPorts) DeepCopyInto(out *JobManagerPorts) {
 		*out = new(int32)
 		**out = **in
 	}
-	if in.UI != nil {
-		in, out := &in.UI, &out.UI
-		*out = new(int32)
-		**out = **in
-	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerPorts.
-func (in *JobManagerPorts) DeepCopy() *JobManagerPorts {
-	if in == nil {
-		return nil
-	}
-	out := new(JobManagerPorts)
-	in.DeepCopyInto(out)
-	return out
 }
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobManagerServiceStatus) DeepCopyInto(out *JobManagerServiceStatus) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerServiceStatus.
-func (in *JobManagerServiceStatus) DeepCopy() *JobManagerServiceStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskManagerPorts.
+func (in *TaskManagerPorts) DeepCopy() *TaskManagerPorts {
 	if in == nil {
 		return nil
 	}
-	out := new(JobManagerServiceStatus)
+	out := new(TaskManagerPorts)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobManagerSpec) DeepCopyInto(out *JobManagerSpec) {
+func (in *TaskManagerSpec) DeepCopyInto(out *TaskManagerSpec) {
 	*out = *in
 	if in.Replicas != nil {
 		in, out := &in.Replicas, &out.Replicas
 		*out = new(int32)
 		**out = **in
 	}
-	if in.Ingress != nil {
-		in, out := &in.Ingress, &out.Ingress
-		*out = new(JobManagerIngressSpec)
-		(*in).DeepCopyInto(*out)
+	if in.DecommissionTimeoutSeconds != nil {
+		in, out := &in.DecommissionTimeoutSeconds, &out.DecommissionTimeoutSeconds
+		*out = new(int32)
+		**out = **in
 	}
 	in.Ports.DeepCopyInto(&out.Ports)
 	in.Resources.DeepCopyInto(&out.Resources)
@@ -450,6 +2253,37 @@ func (in *JobManagerSpec) DeepCopyInto(out *JobManagerSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DeploymentType != nil {
+		in, out := &in.DeploymentType, &out.DeploymentType
+		*out = new(string)
+		**out = **in
+	}
+	if in.PodManagementPolicy != nil {
+		in, out := &in.PodManagementPolicy, &out.PodManagementPolicy
+		*out = new(string)
+		**out = **in
+	}
+	if in.VolumeClaimTemplates != nil {
+		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
+		*out = make([]v1.PersistentVolumeClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]v1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -457,78 +2291,68 @@ func (in *JobManagerSpec) DeepCopyInto(out *JobManagerSpec) {
 			(*out)[key] = val
 		}
 	}
-	if in.Sidecars != nil {
-		in, out := &in.Sidecars, &out.Sidecars
-		*out = make([]v1.Container, len(*in))
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobManagerSpec.
-func (in *JobManagerSpec) DeepCopy() *JobManagerSpec {
-	if in == nil {
-		return nil
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
 	}
-	out := new(JobManagerSpec)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobSpec) DeepCopyInto(out *JobSpec) {
-	*out = *in
-	if in.ClassName != nil {
-		in, out := &in.ClassName, &out.ClassName
-		*out = new(string)
-		**out = **in
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.Args != nil {
-		in, out := &in.Args, &out.Args
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetSpec)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.FromSavepoint != nil {
-		in, out := &in.FromSavepoint, &out.FromSavepoint
+	if in.PriorityClassName != nil {
+		in, out := &in.PriorityClassName, &out.PriorityClassName
 		*out = new(string)
 		**out = **in
 	}
-	if in.AllowNonRestoredState != nil {
-		in, out := &in.AllowNonRestoredState, &out.AllowNonRestoredState
-		*out = new(bool)
-		**out = **in
+	if in.GPU != nil {
+		in, out := &in.GPU, &out.GPU
+		*out = new(GPUSpec)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.SavepointsDir != nil {
-		in, out := &in.SavepointsDir, &out.SavepointsDir
-		*out = new(string)
-		**out = **in
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.AutoSavepointSeconds != nil {
-		in, out := &in.AutoSavepointSeconds, &out.AutoSavepointSeconds
-		*out = new(int32)
-		**out = **in
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.Parallelism != nil {
-		in, out := &in.Parallelism, &out.Parallelism
-		*out = new(int32)
-		**out = **in
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.NoLoggingToStdout != nil {
-		in, out := &in.NoLoggingToStdout, &out.NoLoggingToStdout
-		*out = new(bool)
-		**out = **in
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.Volumes != nil {
-		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
 	}
-	if in.VolumeMounts != nil {
-		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -540,140 +2364,129 @@ func (in *JobSpec) DeepCopyInto(out *JobSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.RestartPolicy != nil {
-		in, out := &in.RestartPolicy, &out.RestartPolicy
-		*out = new(string)
-		**out = **in
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(v1.PodTemplateSpec)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.CleanupPolicy != nil {
-		in, out := &in.CleanupPolicy, &out.CleanupPolicy
-		*out = new(CleanupPolicy)
-		**out = **in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
-	if in.CancelRequested != nil {
-		in, out := &in.CancelRequested, &out.CancelRequested
-		*out = new(bool)
-		**out = **in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSpec.
-func (in *JobSpec) DeepCopy() *JobSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskManagerSpec.
+func (in *TaskManagerSpec) DeepCopy() *TaskManagerSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(JobSpec)
+	out := new(TaskManagerSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobStatus) DeepCopyInto(out *JobStatus) {
+func (in *TimeoutStatus) DeepCopyInto(out *TimeoutStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobStatus.
-func (in *JobStatus) DeepCopy() *JobStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeoutStatus.
+func (in *TimeoutStatus) DeepCopy() *TimeoutStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(JobStatus)
+	out := new(TimeoutStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SavepointStatus) DeepCopyInto(out *SavepointStatus) {
+func (in *TimeoutsSpec) DeepCopyInto(out *TimeoutsSpec) {
 	*out = *in
+	if in.ClusterStartupSeconds != nil {
+		in, out := &in.ClusterStartupSeconds, &out.ClusterStartupSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.JobSubmissionSeconds != nil {
+		in, out := &in.JobSubmissionSeconds, &out.JobSubmissionSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SavepointSeconds != nil {
+		in, out := &in.SavepointSeconds, &out.SavepointSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CancellationSeconds != nil {
+		in, out := &in.CancellationSeconds, &out.CancellationSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UpgradeSeconds != nil {
+		in, out := &in.UpgradeSeconds, &out.UpgradeSeconds
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SavepointStatus.
-func (in *SavepointStatus) DeepCopy() *SavepointStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeoutsSpec.
+func (in *TimeoutsSpec) DeepCopy() *TimeoutsSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(SavepointStatus)
+	out := new(TimeoutsSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TaskManagerPorts) DeepCopyInto(out *TaskManagerPorts) {
+func (in *TopologySpreadConstraint) DeepCopyInto(out *TopologySpreadConstraint) {
 	*out = *in
-	if in.Data != nil {
-		in, out := &in.Data, &out.Data
-		*out = new(int32)
-		**out = **in
-	}
-	if in.RPC != nil {
-		in, out := &in.RPC, &out.RPC
-		*out = new(int32)
-		**out = **in
-	}
-	if in.Query != nil {
-		in, out := &in.Query, &out.Query
-		*out = new(int32)
-		**out = **in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskManagerPorts.
-func (in *TaskManagerPorts) DeepCopy() *TaskManagerPorts {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpreadConstraint.
+func (in *TopologySpreadConstraint) DeepCopy() *TopologySpreadConstraint {
 	if in == nil {
 		return nil
 	}
-	out := new(TaskManagerPorts)
+	out := new(TopologySpreadConstraint)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TaskManagerSpec) DeepCopyInto(out *TaskManagerSpec) {
+func (in *UIAuthProxySpec) DeepCopyInto(out *UIAuthProxySpec) {
 	*out = *in
-	in.Ports.DeepCopyInto(&out.Ports)
-	in.Resources.DeepCopyInto(&out.Resources)
-	if in.MemoryOffHeapRatio != nil {
-		in, out := &in.MemoryOffHeapRatio, &out.MemoryOffHeapRatio
+	in.Container.DeepCopyInto(&out.Container)
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
 		*out = new(int32)
 		**out = **in
 	}
-	out.MemoryOffHeapMin = in.MemoryOffHeapMin.DeepCopy()
-	if in.Volumes != nil {
-		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.VolumeMounts != nil {
-		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Sidecars != nil {
-		in, out := &in.Sidecars, &out.Sidecars
-		*out = make([]v1.Container, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskManagerSpec.
-func (in *TaskManagerSpec) DeepCopy() *TaskManagerSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UIAuthProxySpec.
+func (in *UIAuthProxySpec) DeepCopy() *UIAuthProxySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(TaskManagerSpec)
+	out := new(UIAuthProxySpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -17,8 +17,13 @@ limitations under the License.
 package flinkclient
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -27,6 +32,11 @@ import (
 const (
 	savepointStateInProgress = "IN_PROGRESS"
 	savepointStateCompleted  = "COMPLETED"
+
+	checkpointStatusInProgress = "IN_PROGRESS"
+
+	// The external path of a checkpoint which is not externalized.
+	checkpointNotExternalized = "<checkpoint-not-externalized>"
 )
 
 // FlinkClient - Flink API client.
@@ -46,6 +56,84 @@ type JobStatusList struct {
 	Jobs []JobStatus
 }
 
+// JobDetails defines the details of a Flink job.
+type JobDetails struct {
+	ID    string `json:"jid"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	// Start time in milliseconds since the epoch.
+	StartTime int64 `json:"start-time"`
+	// The number of restarts by the restart strategy of the job, it is not
+	// part of the job details API response but read from the job metrics.
+	Restarts int32 `json:"-"`
+}
+
+// ClusterOverview defines the overview of a Flink cluster.
+type ClusterOverview struct {
+	TaskManagers   int32 `json:"taskmanagers"`
+	SlotsTotal     int32 `json:"slots-total"`
+	SlotsAvailable int32 `json:"slots-available"`
+}
+
+// TaskManager defines a TaskManager registered in the JobManager.
+type TaskManager struct {
+	ID string `json:"id"`
+	// The RPC address of the TaskManager, e.g.,
+	// akka.tcp://flink@10.8.0.5:6122/user/rpc/taskmanager_0.
+	Path        string `json:"path"`
+	SlotsNumber int32  `json:"slotsNumber"`
+	FreeSlots   int32  `json:"freeSlots"`
+}
+
+// JobExceptions defines the exceptions of a Flink job.
+type JobExceptions struct {
+	// Stack trace of the root exception of the last failure.
+	RootException string `json:"root-exception"`
+	// Timestamp of the last failure in milliseconds since the epoch.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Accumulator defines a user accumulator of a Flink job, aggregated over its
+// tasks.
+type Accumulator struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Checkpoint defines a checkpoint or savepoint of a Flink job.
+type Checkpoint struct {
+	ID int64 `json:"id"`
+	// IN_PROGRESS, COMPLETED or FAILED.
+	Status      string `json:"status"`
+	IsSavepoint bool   `json:"is_savepoint"`
+	// Location of the externalized checkpoint.
+	ExternalPath string `json:"external_path"`
+	// Timestamp of the last acknowledgement in milliseconds since the epoch.
+	LatestAckTimestamp int64 `json:"latest_ack_timestamp"`
+	// Size of the checkpointed state in bytes.
+	StateSize               int64 `json:"state_size"`
+	NumSubtasks             int32 `json:"num_subtasks"`
+	NumAcknowledgedSubtasks int32 `json:"num_acknowledged_subtasks"`
+	Discarded               bool  `json:"discarded"`
+}
+
+// IsExternalized returns whether the checkpoint is externalized, i.e., the
+// job can be restored from it.
+func (c *Checkpoint) IsExternalized() bool {
+	return !c.Discarded &&
+		c.ExternalPath != "" &&
+		c.ExternalPath != checkpointNotExternalized
+}
+
+// CheckpointStats defines the checkpoint statistics of a Flink job.
+type CheckpointStats struct {
+	// The latest completed checkpoint, nil if there is none.
+	LatestCompleted *Checkpoint
+	// The latest savepoint in progress, nil if there is none.
+	SavepointInProgress *Checkpoint
+}
+
 // SavepointTriggerID defines trigger ID of an async savepoint operation.
 type SavepointTriggerID struct {
 	RequestID string `json:"request-id"`
@@ -90,6 +178,208 @@ func (c *FlinkClient) GetJobStatusList(
 	return c.HTTPClient.Get(apiBaseURL+"/jobs", jobStatusList)
 }
 
+// GetClusterOverview gets the overview of the cluster, including the number of
+// task slots registered by the TaskManagers.
+func (c *FlinkClient) GetClusterOverview(
+	apiBaseURL string) (ClusterOverview, error) {
+	var overview ClusterOverview
+	var err = c.HTTPClient.Get(apiBaseURL+"/overview", &overview)
+	return overview, err
+}
+
+// GetTaskManagers gets the TaskManagers registered in the JobManager,
+// including their free task slots.
+func (c *FlinkClient) GetTaskManagers(
+	apiBaseURL string) ([]TaskManager, error) {
+	var list struct {
+		TaskManagers []TaskManager `json:"taskmanagers"`
+	}
+	var err = c.HTTPClient.Get(apiBaseURL+"/taskmanagers", &list)
+	return list.TaskManagers, err
+}
+
+// GetJobsOverview gets the overview of all jobs, including their start times.
+func (c *FlinkClient) GetJobsOverview(apiBaseURL string) ([]JobDetails, error) {
+	var overview struct {
+		Jobs []JobDetails `json:"jobs"`
+	}
+	var err = c.HTTPClient.Get(apiBaseURL+"/jobs/overview", &overview)
+	return overview.Jobs, err
+}
+
+// GetJobDetails gets the details of a job, including the number of restarts.
+func (c *FlinkClient) GetJobDetails(
+	apiBaseURL string, jobID string) (JobDetails, error) {
+	var details = JobDetails{}
+	var err = c.HTTPClient.Get(
+		fmt.Sprintf("%s/jobs/%s", apiBaseURL, jobID), &details)
+	if err != nil {
+		return details, err
+	}
+
+	// `fullRestarts` was renamed to `numRestarts` in Flink 1.10.
+	var metrics []struct {
+		ID    string `json:"id"`
+		Value string `json:"value"`
+	}
+	err = c.HTTPClient.Get(
+		fmt.Sprintf(
+			"%s/jobs/%s/metrics?get=numRestarts,fullRestarts",
+			apiBaseURL,
+			jobID),
+		&metrics)
+	if err != nil {
+		return details, err
+	}
+	for _, metric := range metrics {
+		if restarts, err := strconv.ParseInt(metric.Value, 10, 32); err == nil {
+			details.Restarts = int32(restarts)
+			if metric.ID == "numRestarts" {
+				break
+			}
+		}
+	}
+	return details, nil
+}
+
+// GetJobExceptions gets the exceptions of a job.
+func (c *FlinkClient) GetJobExceptions(
+	apiBaseURL string, jobID string) (JobExceptions, error) {
+	var exceptions = JobExceptions{}
+	var err = c.HTTPClient.Get(
+		fmt.Sprintf("%s/jobs/%s/exceptions", apiBaseURL, jobID), &exceptions)
+	return exceptions, err
+}
+
+// GetJobAccumulators gets the user accumulators of a job, e.g., the counters
+// of the records processed by a batch job.
+func (c *FlinkClient) GetJobAccumulators(
+	apiBaseURL string, jobID string) ([]Accumulator, error) {
+	var accumulators struct {
+		UserTaskAccumulators []Accumulator `json:"user-task-accumulators"`
+	}
+	var err = c.HTTPClient.Get(
+		fmt.Sprintf("%s/jobs/%s/accumulators", apiBaseURL, jobID), &accumulators)
+	return accumulators.UserTaskAccumulators, err
+}
+
+// GetCheckpointStats gets the latest completed checkpoint of a job and the
+// progress of the savepoint in progress.
+func (c *FlinkClient) GetCheckpointStats(
+	apiBaseURL string, jobID string) (*CheckpointStats, error) {
+	var checkpoints struct {
+		Latest struct {
+			Completed *Checkpoint `json:"completed"`
+		} `json:"latest"`
+		// The recent checkpoints and savepoints, the latest one is the first.
+		History []Checkpoint `json:"history"`
+	}
+	var err = c.HTTPClient.Get(
+		fmt.Sprintf("%s/jobs/%s/checkpoints", apiBaseURL, jobID), &checkpoints)
+	if err != nil {
+		return nil, err
+	}
+	var stats = &CheckpointStats{LatestCompleted: checkpoints.Latest.Completed}
+	for i := range checkpoints.History {
+		var checkpoint = &checkpoints.History[i]
+		if checkpoint.IsSavepoint &&
+			checkpoint.Status == checkpointStatusInProgress {
+			stats.SavepointInProgress = checkpoint
+			break
+		}
+	}
+	return stats, nil
+}
+
+// JarRunRequest defines the request to run an uploaded JAR.
+type JarRunRequest struct {
+	EntryClass            string   `json:"entryClass,omitempty"`
+	ProgramArgsList       []string `json:"programArgsList,omitempty"`
+	Parallelism           int32    `json:"parallelism,omitempty"`
+	JobID                 string   `json:"jobId,omitempty"`
+	SavepointPath         string   `json:"savepointPath,omitempty"`
+	AllowNonRestoredState bool     `json:"allowNonRestoredState,omitempty"`
+}
+
+// UploadJar downloads the JAR file from the URI and uploads it to the
+// JobManager, returns the ID of the uploaded JAR. The downloaded JAR is
+// verified against the SHA-256 checksum in hex if it is not empty.
+func (c *FlinkClient) UploadJar(
+	apiBaseURL string, jarURI string, jarSha256 string) (string, error) {
+	var content, err = c.HTTPClient.Download(jarURI)
+	if err != nil {
+		return "", fmt.Errorf("failed to download JAR %v: %v", jarURI, err)
+	}
+	if len(jarSha256) > 0 {
+		var checksum = fmt.Sprintf("%x", sha256.Sum256(content))
+		if !strings.EqualFold(checksum, jarSha256) {
+			return "", fmt.Errorf(
+				"checksum mismatch of JAR %v: expected %v, got %v",
+				jarURI, jarSha256, checksum)
+		}
+	}
+
+	var jarName = jarURI
+	if jarURL, err := url.Parse(jarURI); err == nil {
+		jarName = jarURL.Path
+	}
+	jarName = jarName[strings.LastIndex(jarName, "/")+1:]
+
+	// The filename in the response is the path of the uploaded JAR on the
+	// JobManager, its base name is the JAR ID.
+	var resp struct {
+		Filename string `json:"filename"`
+		Status   string `json:"status"`
+	}
+	err = c.HTTPClient.PostFile(
+		apiBaseURL+"/jars/upload", "jarfile", jarName, content, &resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload JAR %v: %v", jarURI, err)
+	}
+	return resp.Filename[strings.LastIndex(resp.Filename, "/")+1:], nil
+}
+
+// RunJar runs an uploaded JAR, returns the ID of the submitted job.
+func (c *FlinkClient) RunJar(
+	apiBaseURL string, jarID string, request JarRunRequest) (string, error) {
+	var body, err = json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		JobID string `json:"jobid"`
+	}
+	err = c.HTTPClient.Post(
+		fmt.Sprintf("%s/jars/%s/run", apiBaseURL, jarID), body, &resp)
+	return resp.JobID, err
+}
+
+// DeleteJar deletes an uploaded JAR.
+func (c *FlinkClient) DeleteJar(apiBaseURL string, jarID string) error {
+	var resp = struct{}{}
+	return c.HTTPClient.Delete(
+		fmt.Sprintf("%s/jars/%s", apiBaseURL, jarID), &resp)
+}
+
+// SubmitJob uploads the JAR file and runs the job with it, the uploaded JAR
+// is deleted afterwards. The error of running the job is returned as is, so
+// callers can tell a job rejected by Flink from a failed upload.
+func (c *FlinkClient) SubmitJob(
+	apiBaseURL string,
+	jarURI string,
+	jarSha256 string,
+	request JarRunRequest) error {
+	var jarID, err = c.UploadJar(apiBaseURL, jarURI, jarSha256)
+	if err != nil {
+		return err
+	}
+	_, err = c.RunJar(apiBaseURL, jarID, request)
+	if deleteErr := c.DeleteJar(apiBaseURL, jarID); deleteErr != nil {
+		c.Log.Info("Failed to delete uploaded JAR", "jarID", jarID, "error", deleteErr)
+	}
+	return err
+}
+
 // StopJob stops a job.
 func (c *FlinkClient) StopJob(
 	apiBaseURL string, jobID string) error {
@@ -111,6 +401,25 @@ func (c *FlinkClient) TriggerSavepoint(
 	return triggerID, err
 }
 
+// StopJobWithSavepoint triggers an async stop-with-savepoint operation, the
+// job is stopped after the savepoint completes. With drain, MAX_WATERMARK is
+// emitted before the savepoint to flush the in-flight data. The status of the
+// operation can be queried with GetSavepointStatus.
+func (c *FlinkClient) StopJobWithSavepoint(
+	apiBaseURL string,
+	jobID string,
+	dir string,
+	drain bool) (SavepointTriggerID, error) {
+	var url = fmt.Sprintf("%s/jobs/%s/stop", apiBaseURL, jobID)
+	var jsonStr = fmt.Sprintf(`{
+		"targetDirectory" : "%s",
+		"drain" : %t
+	}`, dir, drain)
+	var triggerID = SavepointTriggerID{}
+	var err = c.HTTPClient.Post(url, []byte(jsonStr), &triggerID)
+	return triggerID, err
+}
+
 // GetSavepointStatus returns savepoint status.
 //
 // Flink API response examples:
@@ -217,3 +526,95 @@ func (c *FlinkClient) TakeSavepointAsync(
 
 	return triggerID.RequestID, err
 }
+
+// JobMetrics defines the aggregated metrics of a job used by the autoscaler.
+type JobMetrics struct {
+	// Max busyTimeMsPerSecond of all the subtasks, in range [0, 1000].
+	MaxBusyTimeMsPerSecond float64
+	// Max backPressuredTimeMsPerSecond of all the subtasks, in range [0, 1000].
+	MaxBackPressuredTimeMsPerSecond float64
+	// Sum of the consumer lag of all the source subtasks.
+	ConsumerLag float64
+	// Whether the busy time metric is reported by the job.
+	HasBusyTime bool
+}
+
+type jobVertex struct {
+	ID string `json:"id"`
+}
+
+type jobVertices struct {
+	Vertices []jobVertex `json:"vertices"`
+}
+
+type aggregatedMetric struct {
+	ID  string  `json:"id"`
+	Max float64 `json:"max"`
+	Sum float64 `json:"sum"`
+}
+
+// GetJobMetrics gets the busy time, back pressured time and consumer lag
+// metrics aggregated over the subtasks of all the vertices of a job.
+func (c *FlinkClient) GetJobMetrics(
+	apiBaseURL string, jobID string) (JobMetrics, error) {
+	var metrics = JobMetrics{}
+	var vertices = jobVertices{}
+	var err = c.HTTPClient.Get(
+		fmt.Sprintf("%s/jobs/%s", apiBaseURL, jobID), &vertices)
+	if err != nil {
+		return metrics, err
+	}
+
+	for _, vertex := range vertices.Vertices {
+		var metricsURL = fmt.Sprintf(
+			"%s/jobs/%s/vertices/%s/subtasks/metrics",
+			apiBaseURL, jobID, vertex.ID)
+		var available []aggregatedMetric
+		err = c.HTTPClient.Get(metricsURL, &available)
+		if err != nil {
+			return metrics, err
+		}
+		var names []string
+		for _, metric := range available {
+			if isJobMetricUsed(metric.ID) {
+				names = append(names, metric.ID)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		var values []aggregatedMetric
+		err = c.HTTPClient.Get(
+			fmt.Sprintf(
+				"%s?get=%s&agg=max,sum",
+				metricsURL,
+				url.QueryEscape(strings.Join(names, ","))),
+			&values)
+		if err != nil {
+			return metrics, err
+		}
+		for _, value := range values {
+			switch {
+			case value.ID == "busyTimeMsPerSecond":
+				metrics.HasBusyTime = true
+				metrics.MaxBusyTimeMsPerSecond =
+					math.Max(metrics.MaxBusyTimeMsPerSecond, value.Max)
+			case value.ID == "backPressuredTimeMsPerSecond":
+				metrics.MaxBackPressuredTimeMsPerSecond =
+					math.Max(metrics.MaxBackPressuredTimeMsPerSecond, value.Max)
+			default:
+				metrics.ConsumerLag += value.Sum
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+func isJobMetricUsed(id string) bool {
+	return id == "busyTimeMsPerSecond" ||
+		id == "backPressuredTimeMsPerSecond" ||
+		strings.HasSuffix(id, "records-lag-max") ||
+		strings.HasSuffix(id, "pendingRecords")
+}
@@ -18,28 +18,55 @@ package flinkclient
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/googlecloudplatform/flink-operator/controllers/tracing"
 )
 
 // HTTPClient - HTTP client.
 type HTTPClient struct {
 	Log logr.Logger
+	// TLS config of the requests to the Flink REST API, e.g., the CA which
+	// signed its certificate. Downloads don't use it.
+	TLSConfig *tls.Config
+	// Context of the requests to the Flink REST API, their spans are children
+	// of the span in the context, e.g., of a phase of the reconciliation.
+	Context context.Context
 }
 
 type HTTPError struct {
 	StatusCode int
 	Status     string
+	// Error messages in the response body, Flink REST API responds with
+	// `{"errors": [...]}` on failures.
+	Errors []string
 }
 
 func (e *HTTPError) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("%s: %s", e.Status, strings.Join(e.Errors, "; "))
+	}
 	return e.Status
 }
 
+// IsClientError checks whether the error is a 4xx response, i.e., the request
+// is rejected and retrying it doesn't help, unlike a 5xx response or a
+// connection error.
+func IsClientError(err error) bool {
+	var httpErr, ok = err.(*HTTPError)
+	return ok && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500
+}
+
 // Get - HTTP GET.
 func (c *HTTPClient) Get(url string, outStructPtr interface{}) error {
 	return c.doHTTP("GET", url, nil, outStructPtr)
@@ -57,26 +84,109 @@ func (c *HTTPClient) Patch(
 	return c.doHTTP("PATCH", url, body, outStructPtr)
 }
 
+// Delete - HTTP DELETE.
+func (c *HTTPClient) Delete(url string, outStructPtr interface{}) error {
+	return c.doHTTP("DELETE", url, nil, outStructPtr)
+}
+
+// PostFile - HTTP POST of a file as a multipart form.
+func (c *HTTPClient) PostFile(
+	url string,
+	fieldName string,
+	fileName string,
+	content []byte,
+	outStructPtr interface{}) error {
+	var body = &bytes.Buffer{}
+	var writer = multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(fieldName, fileName)
+	if err == nil {
+		_, err = part.Write(content)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		return err
+	}
+	req, err := c.createRequest("POST", url, body.Bytes())
+	c.Log.Info("HTTPClient", "url", url, "method", "POST", "error", err)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return c.do(c.newHTTPClient(5*time.Minute), req, outStructPtr)
+}
+
+// Download - HTTP GET of a file.
+func (c *HTTPClient) Download(url string) ([]byte, error) {
+	httpClient := &http.Client{Timeout: 5 * time.Minute}
+	c.Log.Info("HTTPClient", "url", url, "method", "GET")
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
 func (c *HTTPClient) doHTTP(
 	method string, url string, body []byte, outStructPtr interface{}) error {
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := c.newHTTPClient(30 * time.Second)
 	req, err := c.createRequest(method, url, body)
 	c.Log.Info("HTTPClient", "url", url, "method", method, "error", err)
 	if err != nil {
 		return err
 	}
+	return c.do(httpClient, req, outStructPtr)
+}
+
+func (c *HTTPClient) newHTTPClient(timeout time.Duration) *http.Client {
+	if c.TLSConfig == nil {
+		return &http.Client{Timeout: timeout}
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   c.TLSConfig,
+			DisableKeepAlives: true,
+		},
+	}
+}
+
+func (c *HTTPClient) do(
+	httpClient *http.Client,
+	req *http.Request,
+	outStructPtr interface{}) (err error) {
+	var ctx = c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := tracing.StartSpan(
+		ctx,
+		req.Method,
+		tracing.SpanKindClient,
+		"http.request.method", req.Method,
+		"url.full", req.URL.String(),
+		"server.address", req.URL.Hostname())
+	defer func() { span.End(err) }()
+	tracing.Inject(ctx, req.Header)
+
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes("http.response.status_code", resp.StatusCode)
 	c.Log.Info(
 		"HTTPClient", "status", resp.Status, "body", outStructPtr, "error", err)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		err = &HTTPError{
-			StatusCode: resp.StatusCode,
-			Status:     resp.Status,
-		}
-		return err
+		return c.readError(resp)
 	}
 	return c.readResponse(resp, outStructPtr)
 }
@@ -89,6 +199,21 @@ func (c *HTTPClient) createRequest(
 	return req, err
 }
 
+func (c *HTTPClient) readError(resp *http.Response) error {
+	defer resp.Body.Close()
+	var httpErr = &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err == nil {
+		httpErr.Errors = body.Errors
+	}
+	return httpErr
+}
+
 func (c *HTTPClient) readResponse(
 	resp *http.Response, out interface{}) error {
 	defer resp.Body.Close()
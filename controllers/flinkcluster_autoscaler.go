@@ -0,0 +1,170 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The job is not rescaled when the busy time is within this ratio of the
+// target, to avoid flapping.
+const autoscalerTolerance = 0.1
+
+// Computes the parallelism of the job based on its metrics. The busy time of
+// the busiest operator is kept around the target, and the parallelism is
+// increased by one when the consumer lag exceeds the limit.
+func getAutoscaledParallelism(
+	autoscaler *v1beta1.AutoscalerSpec,
+	parallelism int32,
+	metrics *flinkclient.JobMetrics) int32 {
+	if metrics == nil || !metrics.HasBusyTime {
+		return parallelism
+	}
+
+	var newParallelism = parallelism
+	var busyPercent = metrics.MaxBusyTimeMsPerSecond / 10
+	var targetPercent = float64(*autoscaler.TargetBusyPercent)
+	if math.Abs(busyPercent-targetPercent) > targetPercent*autoscalerTolerance {
+		newParallelism = int32(
+			math.Ceil(float64(parallelism) * busyPercent / targetPercent))
+	}
+	if autoscaler.MaxConsumerLag != nil &&
+		metrics.ConsumerLag > float64(*autoscaler.MaxConsumerLag) &&
+		newParallelism <= parallelism {
+		newParallelism = parallelism + 1
+	}
+
+	if newParallelism < *autoscaler.MinParallelism {
+		newParallelism = *autoscaler.MinParallelism
+	}
+	if newParallelism > autoscaler.MaxParallelism {
+		newParallelism = autoscaler.MaxParallelism
+	}
+	return newParallelism
+}
+
+// Gets the number of TaskManagers which provide enough task slots for the
+// parallelism.
+func getTaskManagerReplicas(
+	parallelism int32, flinkProperties map[string]string) int32 {
+	var slots = 1
+	if value, ok := flinkProperties["taskmanager.numberOfTaskSlots"]; ok {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			slots = n
+		}
+	}
+	return int32(math.Ceil(float64(parallelism) / float64(slots)))
+}
+
+// Rescales the job based on its metrics. The new parallelism and TaskManager
+// replicas are set in the cluster spec, then the job is upgraded with a
+// savepoint and the TaskManager deployment is scaled in the following
+// reconciliations.
+func (reconciler *ClusterReconciler) reconcileAutoscaler() error {
+	var log = reconciler.log
+	var observed = reconciler.observed
+	var cluster = observed.cluster
+	var autoscaler = cluster.Spec.Autoscaler
+	var jobSpec = cluster.Spec.Job
+	var autoscalerStatus = cluster.Status.Autoscaler
+
+	if autoscaler == nil || jobSpec == nil || jobSpec.Parallelism == nil ||
+		observed.jobMetrics == nil {
+		return nil
+	}
+
+	if reconciler.isJobUpgradeRequested() {
+		log.Info("Skip autoscaling, the job is being upgraded")
+		return nil
+	}
+	var savepointStatus = cluster.Status.Savepoint
+	if savepointStatus != nil &&
+		savepointStatus.State == v1beta1.SavepointStateInProgress {
+		log.Info("Skip autoscaling, savepoint is in progress")
+		return nil
+	}
+
+	// Starts the stabilization window when the job is scaled for the first
+	// time, or the parallelism is changed by the user.
+	if autoscalerStatus == nil ||
+		autoscalerStatus.Parallelism != *jobSpec.Parallelism {
+		return reconciler.updateAutoscalerStatus(*jobSpec.Parallelism)
+	}
+
+	var tc = &TimeConverter{}
+	var lastScaleTime = tc.FromString(autoscalerStatus.LastScaleTime)
+	var nextScaleTime = lastScaleTime.Add(
+		time.Duration(*autoscaler.StabilizationSeconds) * time.Second)
+	if time.Now().Before(nextScaleTime) {
+		log.Info("Skip autoscaling, in stabilization window",
+			"lastScaleTime", autoscalerStatus.LastScaleTime)
+		return nil
+	}
+
+	var parallelism = getAutoscaledParallelism(
+		autoscaler, *jobSpec.Parallelism, observed.jobMetrics)
+	if parallelism == *jobSpec.Parallelism {
+		return nil
+	}
+
+	log.Info("Rescaling job",
+		"metrics", *observed.jobMetrics,
+		"from", *jobSpec.Parallelism,
+		"to", parallelism)
+	var clusterClone = cluster.DeepCopy()
+	*clusterClone.Spec.Job.Parallelism = parallelism
+	var replicas = getTaskManagerReplicas(parallelism, cluster.Spec.FlinkProperties)
+	clusterClone.Spec.TaskManager.Replicas = &replicas
+	var err = reconciler.k8sClient.Update(reconciler.context, clusterClone)
+	if err != nil {
+		log.Error(err, "Failed to update the cluster spec for rescaling")
+		return err
+	}
+	reconciler.recorder.Event(
+		cluster,
+		corev1.EventTypeNormal,
+		"Autoscaling",
+		fmt.Sprintf(
+			"Rescaling job from parallelism %v to %v",
+			*jobSpec.Parallelism, parallelism))
+
+	reconciler.observed.cluster = clusterClone
+	return reconciler.updateAutoscalerStatus(parallelism)
+}
+
+func (reconciler *ClusterReconciler) updateAutoscalerStatus(
+	parallelism int32) error {
+	var metrics = reconciler.observed.jobMetrics
+	var clusterClone = reconciler.observed.cluster.DeepCopy()
+	var tc = &TimeConverter{}
+	clusterClone.Status.Autoscaler = &v1beta1.AutoscalerStatus{
+		BusyPercent:          int32(metrics.MaxBusyTimeMsPerSecond / 10),
+		BackPressuredPercent: int32(metrics.MaxBackPressuredTimeMsPerSecond / 10),
+		ConsumerLag:          int64(metrics.ConsumerLag),
+		Parallelism:          parallelism,
+		LastScaleTime:        tc.ToString(time.Now()),
+	}
+	return reconciler.k8sClient.Status().Update(
+		reconciler.context, clusterClone)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"gotest.tools/assert"
+)
+
+func TestGetAutoscaledParallelism(t *testing.T) {
+	var minParallelism int32 = 2
+	var targetBusyPercent int32 = 50
+	var maxConsumerLag int64 = 1000
+	var autoscaler = v1beta1.AutoscalerSpec{
+		MinParallelism:    &minParallelism,
+		MaxParallelism:    10,
+		TargetBusyPercent: &targetBusyPercent,
+		MaxConsumerLag:    &maxConsumerLag,
+	}
+
+	// No busy time metric.
+	var metrics1 = flinkclient.JobMetrics{ConsumerLag: 5000}
+	assert.Equal(t, getAutoscaledParallelism(&autoscaler, 4, &metrics1), int32(4))
+
+	// Within the tolerance.
+	var metrics2 = flinkclient.JobMetrics{
+		HasBusyTime: true, MaxBusyTimeMsPerSecond: 520}
+	assert.Equal(t, getAutoscaledParallelism(&autoscaler, 4, &metrics2), int32(4))
+
+	// Scale up.
+	var metrics3 = flinkclient.JobMetrics{
+		HasBusyTime: true, MaxBusyTimeMsPerSecond: 900}
+	assert.Equal(t, getAutoscaledParallelism(&autoscaler, 4, &metrics3), int32(8))
+
+	// Scale up, bounded by the max parallelism.
+	assert.Equal(t, getAutoscaledParallelism(&autoscaler, 8, &metrics3), int32(10))
+
+	// Scale down.
+	var metrics4 = flinkclient.JobMetrics{
+		HasBusyTime: true, MaxBusyTimeMsPerSecond: 200}
+	assert.Equal(t, getAutoscaledParallelism(&autoscaler, 5, &metrics4), int32(2))
+
+	// Scale down, bounded by the min parallelism.
+	assert.Equal(t, getAutoscaledParallelism(&autoscaler, 3, &metrics4), int32(2))
+
+	// Scale up by the consumer lag.
+	var metrics5 = flinkclient.JobMetrics{
+		HasBusyTime: true, MaxBusyTimeMsPerSecond: 500, ConsumerLag: 5000}
+	assert.Equal(t, getAutoscaledParallelism(&autoscaler, 4, &metrics5), int32(5))
+}
+
+func TestGetTaskManagerReplicas(t *testing.T) {
+	assert.Equal(t, getTaskManagerReplicas(3, nil), int32(3))
+	assert.Equal(
+		t,
+		getTaskManagerReplicas(
+			5, map[string]string{"taskmanager.numberOfTaskSlots": "2"}),
+		int32(3))
+	assert.Equal(
+		t,
+		getTaskManagerReplicas(
+			4, map[string]string{"taskmanager.numberOfTaskSlots": "2"}),
+		int32(2))
+}
@@ -0,0 +1,453 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The annotation of a blue/green child cluster recording the hash of the
+// parent spec it was created from.
+const blueGreenSpecHashAnnotation = "flinkoperator.k8s.io/blue-green-spec-hash"
+
+// Checks whether the changes of the cluster are deployed by blue/green
+// deployments of child clusters.
+func isBlueGreenCluster(cluster *v1beta1.FlinkCluster) bool {
+	return cluster.Spec.UpdateStrategy != nil &&
+		*cluster.Spec.UpdateStrategy == v1beta1.UpdateStrategyBlueGreen
+}
+
+// Gets the name of the blue or green child cluster.
+func getBlueGreenClusterName(clusterName string, color string) string {
+	return clusterName + "-" + color
+}
+
+// Gets the name of the child cluster the next deployment goes to, which is
+// the one not active.
+func getBlueGreenTargetName(clusterName string, activeCluster string) string {
+	if activeCluster == getBlueGreenClusterName(clusterName, "blue") {
+		return getBlueGreenClusterName(clusterName, "green")
+	}
+	return getBlueGreenClusterName(clusterName, "blue")
+}
+
+// Gets the hash of the spec deployed by the child clusters, the properties of
+// the update strategy itself are not deployed.
+func getBlueGreenSpecHash(cluster *v1beta1.FlinkCluster) string {
+	var spec = cluster.Spec.DeepCopy()
+	spec.UpdateStrategy = nil
+	spec.BlueGreen = nil
+	var specJSON, _ = json.Marshal(spec)
+	var hash = sha256.Sum256(specJSON)
+	return fmt.Sprintf("%x", hash)
+}
+
+// Gets the desired child cluster, which runs the spec of the parent with the
+// job started from the given savepoint.
+func getDesiredBlueGreenCluster(
+	cluster *v1beta1.FlinkCluster,
+	name string,
+	specHash string,
+	fromSavepoint string) *v1beta1.FlinkCluster {
+	var spec = cluster.Spec.DeepCopy()
+	spec.UpdateStrategy = nil
+	spec.BlueGreen = nil
+	if len(fromSavepoint) > 0 {
+		spec.Job.FromSavepoint = &fromSavepoint
+	}
+	var labels = map[string]string{}
+	for key, value := range cluster.Labels {
+		labels[key] = value
+	}
+	return &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       cluster.Namespace,
+			Labels:          labels,
+			Annotations:     map[string]string{blueGreenSpecHashAnnotation: specHash},
+			OwnerReferences: []metav1.OwnerReference{toOwnerReference(cluster)},
+		},
+		Spec: *spec,
+	}
+}
+
+// Reconciles a blue/green cluster, which has no components itself. Its spec
+// is run by the active child cluster, an update of the spec takes a savepoint
+// of the active job, deploys the updated job from the savepoint in the other
+// child cluster, and deletes the active one after the new job has been
+// running for `stableSeconds` and passed the health check. The state,
+// components and conditions of the active child are mirrored in the status of
+// the cluster.
+func (reconciler *ClusterReconciler) reconcileBlueGreen() (ctrl.Result, error) {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+
+	var status = &v1beta1.BlueGreenStatus{}
+	if cluster.Status.BlueGreen != nil {
+		status = cluster.Status.BlueGreen.DeepCopy()
+	}
+	var err = reconciler.reconcileBlueGreenDeployment(status)
+
+	var newStatus = cluster.Status.DeepCopy()
+	newStatus.BlueGreen = status
+	newStatus.ObservedGeneration = cluster.Generation
+	var active, getErr = reconciler.getBlueGreenCluster(status.ActiveCluster)
+	if getErr != nil {
+		return ctrl.Result{}, getErr
+	}
+	if active != nil && len(active.Status.State) > 0 {
+		newStatus.State = active.Status.State
+		newStatus.Components = active.Status.Components
+		newStatus.Conditions = active.Status.Conditions
+	} else {
+		newStatus.State = v1beta1.ClusterStateCreating
+	}
+	if !reflect.DeepEqual(*newStatus, cluster.Status) {
+		log.Info("Updating blue/green status", "status", *status)
+		var clusterClone = cluster.DeepCopy()
+		clusterClone.Status = *newStatus
+		setTimestamp(&clusterClone.Status.LastUpdateTime)
+		var updateErr = reconciler.k8sClient.Status().Update(
+			reconciler.context, clusterClone)
+		if updateErr != nil {
+			log.Error(updateErr, "Failed to update blue/green status")
+			if err == nil {
+				err = updateErr
+			}
+		}
+	}
+	// Requeued to keep mirroring the status of the active cluster.
+	return requeueResult, err
+}
+
+func (reconciler *ClusterReconciler) reconcileBlueGreenDeployment(
+	status *v1beta1.BlueGreenStatus) error {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+	var specHash = getBlueGreenSpecHash(cluster)
+
+	if len(status.ActiveCluster) == 0 {
+		status.ActiveCluster = getBlueGreenClusterName(cluster.Name, "blue")
+		status.ActiveSpecHash = specHash
+		status.State = v1beta1.BlueGreenStateStable
+	}
+	var active, err = reconciler.getBlueGreenCluster(status.ActiveCluster)
+	if err != nil {
+		return err
+	}
+	if active == nil {
+		// The active cluster is created initially, or again if it was deleted
+		// out of band, with the latest spec.
+		log.Info("Creating active blue/green cluster", "name", status.ActiveCluster)
+		status.ActiveSpecHash = specHash
+		var desired = getDesiredBlueGreenCluster(
+			cluster, status.ActiveCluster, specHash, "")
+		return reconciler.createObject(desired, "FlinkCluster")
+	}
+
+	switch status.State {
+	case v1beta1.BlueGreenStateDeploying, v1beta1.BlueGreenStateVerifying:
+		if specHash == status.ActiveSpecHash {
+			log.Info("The spec was reverted, cancelling the blue/green deployment")
+			err = reconciler.deleteBlueGreenTarget(status.TargetCluster)
+			resetBlueGreenTarget(status)
+			status.State = v1beta1.BlueGreenStateStable
+			return err
+		}
+		if specHash != status.TargetSpecHash {
+			log.Info("The spec was updated, restarting the blue/green deployment")
+			startBlueGreenDeployment(status, cluster.Name, specHash)
+			return nil
+		}
+		return reconciler.deployBlueGreenTarget(active, status)
+	default:
+		if specHash == status.ActiveSpecHash {
+			status.State = v1beta1.BlueGreenStateStable
+			status.TargetSpecHash = ""
+			status.Message = ""
+			return nil
+		}
+		if status.State == v1beta1.BlueGreenStateRolledBack &&
+			specHash == status.TargetSpecHash {
+			log.Info("The spec was rolled back, waiting for another update")
+			return nil
+		}
+		log.Info("Starting blue/green deployment", "specHash", specHash)
+		startBlueGreenDeployment(status, cluster.Name, specHash)
+		return nil
+	}
+}
+
+// Deploys the updated spec in the target cluster, then verifies it and
+// switches the active cluster to it, or rolls it back.
+func (reconciler *ClusterReconciler) deployBlueGreenTarget(
+	active *v1beta1.FlinkCluster,
+	status *v1beta1.BlueGreenStatus) error {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+	var blueGreenSpec = cluster.Spec.BlueGreen
+
+	var target, err = reconciler.getBlueGreenCluster(status.TargetCluster)
+	if err != nil {
+		return err
+	}
+	// The target of a previous deployment is deleted before the new one.
+	if target != nil &&
+		(target.Annotations[blueGreenSpecHashAnnotation] != status.TargetSpecHash ||
+			!target.DeletionTimestamp.IsZero()) {
+		log.Info("Waiting for the stale blue/green cluster to be deleted", "name", target.Name)
+		if target.DeletionTimestamp.IsZero() {
+			err = reconciler.deleteObject(target, "FlinkCluster")
+		}
+		return err
+	}
+
+	var tc = &TimeConverter{}
+	var now = time.Now()
+	var deadline = tc.FromString(status.DeployStartTime).Add(
+		time.Duration(*blueGreenSpec.DeadlineSeconds) * time.Second)
+	if now.After(deadline) {
+		return reconciler.rollbackBlueGreen(
+			target,
+			status,
+			fmt.Sprintf(
+				"%v was not verified within %v seconds",
+				status.TargetCluster, *blueGreenSpec.DeadlineSeconds))
+	}
+
+	if len(status.Savepoint) == 0 {
+		var location, message, err = reconciler.takeBlueGreenSavepoint(active, status)
+		if len(message) > 0 {
+			return reconciler.rollbackBlueGreen(target, status, message)
+		}
+		if err != nil || len(location) == 0 {
+			return err
+		}
+		status.Savepoint = location
+	}
+	if target == nil {
+		log.Info(
+			"Creating target blue/green cluster",
+			"name", status.TargetCluster, "savepoint", status.Savepoint)
+		var desired = getDesiredBlueGreenCluster(
+			cluster, status.TargetCluster, status.TargetSpecHash, status.Savepoint)
+		return reconciler.createObject(desired, "FlinkCluster")
+	}
+
+	var jobStatus = target.Status.Components.Job
+	if isJobStopped(jobStatus) {
+		return reconciler.rollbackBlueGreen(
+			target,
+			status,
+			fmt.Sprintf("the job of %v is %v", target.Name, jobStatus.State))
+	}
+	if jobStatus == nil || jobStatus.State != v1beta1.JobStateRunning {
+		status.State = v1beta1.BlueGreenStateDeploying
+		status.VerifyingSince = ""
+		return nil
+	}
+	if status.State == v1beta1.BlueGreenStateDeploying {
+		log.Info("The job of the target cluster is running, verifying it")
+		status.State = v1beta1.BlueGreenStateVerifying
+		setTimestamp(&status.VerifyingSince)
+		return nil
+	}
+
+	var stableTime = tc.FromString(status.VerifyingSince).Add(
+		time.Duration(*blueGreenSpec.StableSeconds) * time.Second)
+	if now.Before(stableTime) {
+		log.Info("Waiting for the job of the target cluster to be stable")
+		return nil
+	}
+	if blueGreenSpec.HealthCheckURL != nil {
+		var healthCheckURL = strings.Replace(
+			*blueGreenSpec.HealthCheckURL, "{cluster}", target.Name, -1)
+		var _, err = reconciler.flinkClient.HTTPClient.Download(healthCheckURL)
+		if err != nil {
+			log.Info("Health check of the target cluster failed", "error", err)
+			return nil
+		}
+	}
+
+	log.Info(
+		"Switching blue/green cluster",
+		"from", status.ActiveCluster, "to", status.TargetCluster)
+	if active.DeletionTimestamp.IsZero() {
+		err = reconciler.deleteObject(active, "FlinkCluster")
+		if err != nil {
+			return err
+		}
+	}
+	reconciler.recorder.Event(
+		cluster,
+		corev1.EventTypeNormal,
+		"BlueGreenSwitched",
+		fmt.Sprintf("Switched from %v to %v", status.ActiveCluster, status.TargetCluster))
+	status.ActiveCluster = status.TargetCluster
+	status.ActiveSpecHash = status.TargetSpecHash
+	resetBlueGreenTarget(status)
+	status.State = v1beta1.BlueGreenStateStable
+	status.Message = ""
+	return nil
+}
+
+// Takes the savepoint of the active job the target cluster is started from,
+// by a FlinkSavepoint named after the target cluster and the spec hash. The
+// latest savepoint of the active job is used if the job is not running or the
+// savepoint failed. A non-empty message is returned when there is no
+// savepoint to start from.
+func (reconciler *ClusterReconciler) takeBlueGreenSavepoint(
+	active *v1beta1.FlinkCluster,
+	status *v1beta1.BlueGreenStatus) (string, string, error) {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+	var activeJob = active.Status.Components.Job
+	var latestSavepoint = ""
+	if activeJob != nil {
+		latestSavepoint = activeJob.SavepointLocation
+	}
+	var useLatestSavepoint = func(reason string) (string, string, error) {
+		if len(latestSavepoint) == 0 {
+			return "", fmt.Sprintf("no savepoint of %v to start from: %v", active.Name, reason), nil
+		}
+		log.Info("Using the latest savepoint", "reason", reason, "location", latestSavepoint)
+		return latestSavepoint, "", nil
+	}
+	if isJobStopped(activeJob) {
+		return useLatestSavepoint("the job is not running")
+	}
+
+	var name = status.TargetCluster + "-" + status.TargetSpecHash[:10]
+	var savepoint = &v1beta1.FlinkSavepoint{}
+	var err = reconciler.k8sClient.Get(
+		reconciler.context,
+		types.NamespacedName{Namespace: cluster.Namespace, Name: name},
+		savepoint)
+	if client.IgnoreNotFound(err) != nil {
+		return "", "", err
+	}
+	var tc = &TimeConverter{}
+	if err == nil &&
+		savepoint.CreationTimestamp.Time.Before(tc.FromString(status.DeployStartTime)) {
+		log.Info("Deleting the savepoint of a previous deployment", "name", name)
+		err = reconciler.k8sClient.Delete(reconciler.context, savepoint)
+		return "", "", client.IgnoreNotFound(err)
+	}
+	if err != nil {
+		log.Info("Taking savepoint of the active cluster", "name", name)
+		savepoint = &v1beta1.FlinkSavepoint{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       cluster.Namespace,
+				OwnerReferences: []metav1.OwnerReference{toOwnerReference(cluster)},
+			},
+			Spec: v1beta1.FlinkSavepointSpec{ClusterName: active.Name},
+		}
+		return "", "", reconciler.createObject(savepoint, "FlinkSavepoint")
+	}
+
+	switch savepoint.Status.State {
+	case v1beta1.SavepointStateSucceeded:
+		return savepoint.Status.Location, "", nil
+	case v1beta1.SavepointStateFailed, v1beta1.SavepointStateTriggerFailed:
+		return useLatestSavepoint(savepoint.Status.Message)
+	}
+	log.Info("Waiting for the savepoint of the active cluster", "name", name)
+	return "", "", nil
+}
+
+// Rolls back a blue/green deployment by deleting the target cluster, the same
+// spec is not deployed again until it is updated.
+func (reconciler *ClusterReconciler) rollbackBlueGreen(
+	target *v1beta1.FlinkCluster,
+	status *v1beta1.BlueGreenStatus,
+	message string) error {
+	reconciler.log.Info("Rolling back blue/green deployment", "reason", message)
+	var err error
+	if target != nil && target.DeletionTimestamp.IsZero() {
+		err = reconciler.deleteObject(target, "FlinkCluster")
+	}
+	reconciler.recorder.Event(
+		reconciler.observed.cluster,
+		corev1.EventTypeWarning,
+		"BlueGreenRolledBack",
+		fmt.Sprintf("Rolled back to %v: %v", status.ActiveCluster, message))
+	var targetSpecHash = status.TargetSpecHash
+	resetBlueGreenTarget(status)
+	status.TargetSpecHash = targetSpecHash
+	status.State = v1beta1.BlueGreenStateRolledBack
+	status.Message = message
+	return err
+}
+
+func (reconciler *ClusterReconciler) deleteBlueGreenTarget(name string) error {
+	var target, err = reconciler.getBlueGreenCluster(name)
+	if err != nil || target == nil || !target.DeletionTimestamp.IsZero() {
+		return err
+	}
+	return reconciler.deleteObject(target, "FlinkCluster")
+}
+
+// Gets a child cluster, nil if it doesn't exist.
+func (reconciler *ClusterReconciler) getBlueGreenCluster(
+	name string) (*v1beta1.FlinkCluster, error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+	var child = &v1beta1.FlinkCluster{}
+	var err = reconciler.k8sClient.Get(
+		reconciler.context,
+		types.NamespacedName{
+			Namespace: reconciler.observed.cluster.Namespace,
+			Name:      name,
+		},
+		child)
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return child, nil
+}
+
+func startBlueGreenDeployment(
+	status *v1beta1.BlueGreenStatus, clusterName string, specHash string) {
+	resetBlueGreenTarget(status)
+	status.TargetCluster = getBlueGreenTargetName(clusterName, status.ActiveCluster)
+	status.TargetSpecHash = specHash
+	status.State = v1beta1.BlueGreenStateDeploying
+	status.Message = ""
+	setTimestamp(&status.DeployStartTime)
+}
+
+func resetBlueGreenTarget(status *v1beta1.BlueGreenStatus) {
+	status.TargetCluster = ""
+	status.TargetSpecHash = ""
+	status.Savepoint = ""
+	status.DeployStartTime = ""
+	status.VerifyingSince = ""
+}
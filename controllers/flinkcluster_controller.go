@@ -23,12 +23,22 @@ import (
 	"github.com/go-logr/logr"
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
 	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"github.com/googlecloudplatform/flink-operator/controllers/storageclient"
+	"github.com/googlecloudplatform/flink-operator/controllers/tracing"
+	"github.com/googlecloudplatform/flink-operator/operatorconfig"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // FlinkClusterReconciler reconciles a FlinkCluster object
@@ -36,68 +46,171 @@ type FlinkClusterReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 	Mgr    ctrl.Manager
+
+	// The maximum number of FlinkClusters reconciled concurrently, default: 1.
+	MaxConcurrentReconciles int
+
+	// The shard of the FlinkClusters reconciled by this operator instance,
+	// default: all the clusters.
+	Shard ClusterShard
+
+	// The log levels of the reconciliations, everything is logged if nil.
+	LogLevels *LogLevels
+
+	// Whether the components of the clusters are created and updated with
+	// server-side apply instead of create and update requests.
+	ServerSideApply bool
+
+	// The namespaces of the reconciled FlinkClusters, all the namespaces if
+	// empty.
+	WatchNamespaces []string
+
+	// Whether the ConfigMaps and Secrets in `restartOnChange` of the clusters
+	// are watched, otherwise their changes are only found by the periodic
+	// reconciliations.
+	WatchReferencedData bool
 }
 
 // +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinkclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinkclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinkclusters/finalizers,verbs=update
+// +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinkclustertemplates,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get
-// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets/status,verbs=get
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=core,resources=pods/status,verbs=get
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services/status,verbs=get
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events/status,verbs=get
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=create
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get
 // +kubebuilder:rbac:groups=extensions,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=extensions,resources=ingresses/status,verbs=get
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=podmonitors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=scheduling.volcano.sh,resources=podgroups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=scheduling.sigs.k8s.io,resources=podgroups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile the observed state towards the desired state for a FlinkCluster custom resource.
 func (reconciler *FlinkClusterReconciler) Reconcile(
 	request ctrl.Request) (ctrl.Result, error) {
-	var log = reconciler.Log.WithValues(
+	var log = reconciler.LogLevels.getLogger(
+		reconciler.Log, "FlinkCluster", request.NamespacedName).WithValues(
 		"cluster", request.NamespacedName)
+
+	// The clusters in other shards are reconciled by other operator instances.
+	inShard, err := reconciler.Shard.containsClusterNamed(
+		context.Background(), reconciler.Client, request.NamespacedName)
+	if err != nil || !inShard {
+		return ctrl.Result{}, err
+	}
+
+	// The reconciliation is traced with a span, whose children are the spans
+	// of its phases and of their Flink REST API requests.
+	var ctx, span = tracing.StartSpan(
+		context.Background(),
+		"Reconcile FlinkCluster",
+		tracing.SpanKindInternal,
+		"k8s.namespace.name", request.Namespace,
+		"flinkcluster.name", request.Name)
+	if span != nil {
+		log = log.WithValues("traceID", span.TraceID())
+	}
+
 	var handler = FlinkClusterHandler{
 		k8sClient: reconciler.Client,
 		flinkClient: flinkclient.FlinkClient{
 			Log:        log,
-			HTTPClient: flinkclient.HTTPClient{Log: log},
+			HTTPClient: flinkclient.HTTPClient{Log: log, Context: ctx},
 		},
-		request:  request,
-		context:  context.Background(),
-		log:      log,
-		recorder: reconciler.Mgr.GetEventRecorderFor("FlinkOperator"),
-		observed: ObservedClusterState{},
+		storageClients: storageclient.NewStorageClients(log),
+		request:        request,
+		context:        ctx,
+		log:            log,
+		recorder:       reconciler.Mgr.GetEventRecorderFor("FlinkOperator"),
+		observed:       ObservedClusterState{},
+
+		serverSideApply: reconciler.ServerSideApply,
+		scheme:          reconciler.Mgr.GetScheme(),
 	}
-	return handler.reconcile(request)
+	var startTime = time.Now()
+	result, err := handler.reconcile(request)
+	recordReconcileMetrics(request.NamespacedName, startTime, err)
+	span.End(err)
+	return result, err
 }
 
 // SetupWithManager registers this reconciler with the controller manager and
-// starts watching FlinkCluster, Deployment and Service resources.
+// starts watching FlinkCluster resources and the resources they own.
 func (reconciler *FlinkClusterReconciler) SetupWithManager(
 	mgr ctrl.Manager) error {
 	reconciler.Mgr = mgr
-	return ctrl.NewControllerManagedBy(mgr).
+	var clusterController, err = ctrl.NewControllerManagedBy(mgr).
 		For(&v1beta1.FlinkCluster{}).
+		Owns(&v1beta1.FlinkCluster{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&extensionsv1beta1.Ingress{}).
 		Owns(&batchv1.Job{}).
-		Complete(reconciler)
+		Owns(&policyv1beta1.PodDisruptionBudget{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		WithEventFilter(clusterEventFilter).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: reconciler.MaxConcurrentReconciles,
+		}).
+		Build(reconciler)
+	if err != nil || !reconciler.WatchReferencedData {
+		return err
+	}
+
+	// The ConfigMaps and Secrets in `restartOnChange` of the clusters, only
+	// their events which restart some clusters are handled.
+	var referencedDataFilter = reconciler.getReferencedDataFilter()
+	for _, object := range []runtime.Object{&corev1.ConfigMap{}, &corev1.Secret{}} {
+		err = clusterController.Watch(
+			&source.Kind{Type: object},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(
+					reconciler.getRestartOnChangeRequests),
+			},
+			clusterEventFilter,
+			referencedDataFilter)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // FlinkClusterHandler holds the context and state for a
 // reconcile request.
 type FlinkClusterHandler struct {
-	k8sClient   client.Client
-	flinkClient flinkclient.FlinkClient
-	request     ctrl.Request
-	context     context.Context
-	log         logr.Logger
-	recorder    record.EventRecorder
-	observed    ObservedClusterState
-	desired     DesiredClusterState
+	k8sClient      client.Client
+	flinkClient    flinkclient.FlinkClient
+	storageClients storageclient.StorageClients
+	request        ctrl.Request
+	context        context.Context
+	log            logr.Logger
+	recorder       record.EventRecorder
+	observed       ObservedClusterState
+	desired        DesiredClusterState
+
+	serverSideApply bool
+	scheme          *runtime.Scheme
 }
 
 func (handler *FlinkClusterHandler) reconcile(
@@ -114,29 +227,74 @@ func (handler *FlinkClusterHandler) reconcile(
 	log.Info("============================================================")
 	log.Info("---------- 1. Observe the current state ----------")
 
+	var observeContext, observeSpan = tracing.StartSpan(
+		context, "Observe", tracing.SpanKindInternal)
+	flinkClient.HTTPClient.Context = observeContext
 	var observer = ClusterStateObserver{
 		k8sClient:   k8sClient,
 		flinkClient: flinkClient,
 		request:     request,
-		context:     context,
+		context:     observeContext,
 		log:         log,
 	}
 	err = observer.observe(observed)
+	observeSpan.End(err)
 	if err != nil {
 		log.Error(err, "Failed to observe the current state")
 		return ctrl.Result{}, err
 	}
+	// The Flink client of the observer is configured with the TLS of the
+	// REST API.
+	flinkClient = observer.flinkClient
+	recordClusterStateMetrics(request.NamespacedName, observed.cluster)
+
+	// The finalizer is added before taking any action, and the deletion of the
+	// cluster is finalized instead of reconciling its status and components.
+	// Nothing else is done while the reconciliation is paused.
+	if observed.cluster != nil {
+		var reconciler = ClusterReconciler{
+			k8sClient:   handler.k8sClient,
+			flinkClient: flinkClient,
+			context:     handler.context,
+			log:         handler.log,
+			observed:    handler.observed,
+			recorder:    handler.recorder,
+
+			serverSideApply: handler.serverSideApply,
+			scheme:          handler.scheme,
+		}
+		if !observed.cluster.DeletionTimestamp.IsZero() {
+			log.Info("---------- Finalize the deletion ----------")
+			return traceActions(
+				&reconciler, "Finalize deletion", reconciler.reconcileDeletion)
+		}
+		if isReconciliationPaused(observed.cluster) {
+			log.Info("Reconciliation is paused, no action")
+			return ctrl.Result{}, nil
+		}
+		if !hasClusterFinalizer(observed.cluster) {
+			return ctrl.Result{}, reconciler.addClusterFinalizer()
+		}
+		if isBlueGreenCluster(observed.cluster) {
+			log.Info("---------- Reconcile the blue/green deployment ----------")
+			return traceActions(
+				&reconciler, "Reconcile blue/green deployment", reconciler.reconcileBlueGreen)
+		}
+	}
 
 	log.Info("---------- 2. Update cluster status ----------")
 
+	var updateContext, updateSpan = tracing.StartSpan(
+		context, "Update status", tracing.SpanKindInternal)
 	var updater = ClusterStatusUpdater{
 		k8sClient: handler.k8sClient,
-		context:   handler.context,
+		context:   updateContext,
 		log:       handler.log,
 		recorder:  handler.recorder,
 		observed:  handler.observed,
 	}
 	statusChanged, err = updater.updateStatusIfChanged()
+	updateSpan.End(err)
 	if err != nil {
 		log.Error(err, "Failed to update cluster status")
 		return ctrl.Result{}, err
@@ -153,7 +311,10 @@ func (handler *FlinkClusterHandler) reconcile(
 
 	log.Info("---------- 3. Compute the desired state ----------")
 
+	var _, computeSpan = tracing.StartSpan(
+		context, "Compute desired state", tracing.SpanKindInternal)
 	*desired = getDesiredClusterState(observed.cluster, time.Now())
+	setDesiredReferencesHashes(desired, observed.referencedDataHashes)
 	if desired.ConfigMap != nil {
 		log.Info("Desired state", "ConfigMap", *desired.ConfigMap)
 	} else {
@@ -164,6 +325,16 @@ func (handler *FlinkClusterHandler) reconcile(
 	} else {
 		log.Info("Desired state", "JobManager deployment", "nil")
 	}
+	if desired.JmStatefulSet != nil {
+		log.Info("Desired state", "JobManager StatefulSet", *desired.JmStatefulSet)
+	} else {
+		log.Info("Desired state", "JobManager StatefulSet", "nil")
+	}
+	if desired.JmHeadlessService != nil {
+		log.Info("Desired state", "JobManager headless service", *desired.JmHeadlessService)
+	} else {
+		log.Info("Desired state", "JobManager headless service", "nil")
+	}
 	if desired.JmService != nil {
 		log.Info("Desired state", "JobManager service", *desired.JmService)
 	} else {
@@ -179,30 +350,104 @@ func (handler *FlinkClusterHandler) reconcile(
 	} else {
 		log.Info("Desired state", "TaskManager deployment", "nil")
 	}
+	if desired.TmStatefulSet != nil {
+		log.Info("Desired state", "TaskManager StatefulSet", *desired.TmStatefulSet)
+	} else {
+		log.Info("Desired state", "TaskManager StatefulSet", "nil")
+	}
+	if desired.TmHeadlessService != nil {
+		log.Info("Desired state", "TaskManager headless service", *desired.TmHeadlessService)
+	} else {
+		log.Info("Desired state", "TaskManager headless service", "nil")
+	}
+	if desired.JmPodDisruptionBudget != nil {
+		log.Info("Desired state", "JobManager PodDisruptionBudget", *desired.JmPodDisruptionBudget)
+	} else {
+		log.Info("Desired state", "JobManager PodDisruptionBudget", "nil")
+	}
+	if desired.TmPodDisruptionBudget != nil {
+		log.Info("Desired state", "TaskManager PodDisruptionBudget", *desired.TmPodDisruptionBudget)
+	} else {
+		log.Info("Desired state", "TaskManager PodDisruptionBudget", "nil")
+	}
+	if desired.JmNetworkPolicy != nil {
+		log.Info("Desired state", "JobManager NetworkPolicy", *desired.JmNetworkPolicy)
+	} else {
+		log.Info("Desired state", "JobManager NetworkPolicy", "nil")
+	}
+	if desired.TmNetworkPolicy != nil {
+		log.Info("Desired state", "TaskManager NetworkPolicy", *desired.TmNetworkPolicy)
+	} else {
+		log.Info("Desired state", "TaskManager NetworkPolicy", "nil")
+	}
+	if desired.PodGroup != nil {
+		log.Info("Desired state", "PodGroup", *desired.PodGroup)
+	} else {
+		log.Info("Desired state", "PodGroup", "nil")
+	}
+	if desired.HistoryServerDeployment != nil {
+		log.Info("Desired state", "History Server deployment", *desired.HistoryServerDeployment)
+	} else {
+		log.Info("Desired state", "History Server deployment", "nil")
+	}
+	if desired.HistoryServerService != nil {
+		log.Info("Desired state", "History Server service", *desired.HistoryServerService)
+	} else {
+		log.Info("Desired state", "History Server service", "nil")
+	}
+	if desired.HistoryServerIngress != nil {
+		log.Info("Desired state", "History Server ingress", *desired.HistoryServerIngress)
+	} else {
+		log.Info("Desired state", "History Server ingress", "nil")
+	}
 	if desired.Job != nil {
 		log.Info("Desired state", "Job", *desired.Job)
 	} else {
 		log.Info("Desired state", "Job", "nil")
 	}
+	computeSpan.End(nil)
 
 	log.Info("---------- 4. Take actions ----------")
 
 	var reconciler = ClusterReconciler{
-		k8sClient:   handler.k8sClient,
-		flinkClient: flinkClient,
-		context:     handler.context,
-		log:         handler.log,
-		observed:    handler.observed,
-		desired:     handler.desired,
-		recorder:    handler.recorder,
+		k8sClient:      handler.k8sClient,
+		flinkClient:    flinkClient,
+		storageClients: handler.storageClients,
+		context:        handler.context,
+		log:            handler.log,
+		observed:       handler.observed,
+		desired:        handler.desired,
+		recorder:       handler.recorder,
+
+		serverSideApply: handler.serverSideApply,
+		scheme:          handler.scheme,
 	}
-	result, err := reconciler.reconcile()
+	result, err := traceActions(&reconciler, "Apply", reconciler.reconcile)
 	if err != nil {
 		log.Error(err, "Failed to reconcile")
 	}
+	var requeueInterval = operatorconfig.Get().RequeueIntervalSeconds
+	if result == requeueResult && requeueInterval > 0 {
+		result.RequeueAfter = time.Duration(requeueInterval) * time.Second
+	}
 	if result.RequeueAfter > 0 {
 		log.Info("Requeue reconcile request", "after", result.RequeueAfter)
 	}
 
 	return result, err
 }
+
+// Takes the actions of the reconciler in a span, the Flink REST API requests
+// of the actions are traced in its context.
+func traceActions(
+	reconciler *ClusterReconciler,
+	name string,
+	actions func() (ctrl.Result, error)) (ctrl.Result, error) {
+	var context, span = tracing.StartSpan(
+		reconciler.context, name, tracing.SpanKindInternal)
+	reconciler.context = context
+	reconciler.flinkClient.HTTPClient.Context = context
+	var result, err = actions()
+	span.End(err)
+	return result, err
+}
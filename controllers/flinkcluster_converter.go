@@ -17,8 +17,13 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/xml"
 	"fmt"
 	"math"
+	"net/url"
+	"path"
 	"regexp"
 	"sort"
 	"strconv"
@@ -28,11 +33,17 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -45,6 +56,96 @@ const (
 	flinkConfigMapVolume            = "flink-config-volume"
 	gcpServiceAccountVolume         = "gcp-service-account-volume"
 	hadoopConfigVolume              = "hadoop-config-volume"
+	hadoopCoreSiteName              = "core-site.xml"
+	jobJarVolume                    = "job-jar-volume"
+	jobJarPath                      = "/opt/flink/job"
+	jarDownloaderContainer          = "jar-downloader"
+	rocksDBLocalDirVolume           = "rocksdb-local-dir-volume"
+
+	// Name of the port of the UI auth proxy in the JobManager pod.
+	uiAuthProxyPortName = "ui-proxy"
+
+	// Prefix of the environment variables of the Flink properties from
+	// Secrets, followed by their index in `flinkPropertiesFrom`.
+	flinkPropertyEnvPrefix = "FLINK_PROPERTY_FROM_"
+
+	// The default images of the JAR downloader by the URI scheme, pinned so
+	// that the init container doesn't change between pod restarts.
+	gcsJarDownloaderImage  = "google/cloud-sdk:290.0.1-alpine"
+	s3JarDownloaderImage   = "amazon/aws-cli:2.0.10"
+	httpJarDownloaderImage = "curlimages/curl:7.70.0"
+
+	// Pod annotation holding the hash of flink-conf.yaml, which triggers a
+	// rolling restart of the JobManager and TaskManager when Flink properties
+	// change.
+	flinkConfHashAnnotation = "flinkoperator.k8s.io/flink-conf-hash"
+
+	// Job annotation holding the hash of the upgradable properties of the
+	// cluster spec, which triggers a job upgrade when they change.
+	jobSpecHashAnnotation = "flinkoperator.k8s.io/job-spec-hash"
+
+	// Pod annotation holding the restart nonce of the cluster spec, which
+	// triggers a rolling restart of the JobManager and TaskManager when it
+	// changes.
+	restartNonceAnnotation = "flinkoperator.k8s.io/restart-nonce"
+
+	// Pod annotation holding the hash of the data of the ConfigMaps and
+	// Secrets in `restartOnChange` which the pod references, which triggers a
+	// rolling restart of the pod's workload when they change.
+	referencesHashAnnotation = "flinkoperator.k8s.io/references-hash"
+
+	// Pod annotations of the Istio sidecar which exclude ports from its
+	// interception and configure the proxy.
+	istioExcludeInboundPortsAnnotation  = "traffic.sidecar.istio.io/excludeInboundPorts"
+	istioExcludeOutboundPortsAnnotation = "traffic.sidecar.istio.io/excludeOutboundPorts"
+	istioProxyConfigAnnotation          = "proxy.istio.io/config"
+
+	// Istio proxy config which starts the application containers after the
+	// sidecar is ready (Istio 1.7+).
+	istioHoldApplicationConfig = `{"holdApplicationUntilProxyStarts": true}`
+
+	// Endpoint of the Istio agent which stops the sidecar.
+	istioQuitURL = "http://127.0.0.1:15020/quitquitquit"
+
+	// Flink HA services factory backed by Kubernetes ConfigMaps.
+	kubernetesHAServicesFactory = "org.apache.flink.kubernetes.highavailability.KubernetesHaServicesFactory"
+
+	// Flink factory of the GPU driver of the external resource framework.
+	gpuDriverFactoryClass = "org.apache.flink.externalresource.gpu.GPUDriverFactory"
+
+	// Flink metric reporter which exposes the metrics to Prometheus.
+	prometheusReporterClass = "org.apache.flink.metrics.prometheus.PrometheusReporter"
+
+	// The part of the termination grace period which is left for the JVM to
+	// exit after the Flink cluster services shut down.
+	shutdownTimeoutMarginSeconds int64 = 5
+)
+
+// PodMonitor of the Prometheus Operator.
+var podMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PodMonitor",
+}
+
+// PodGroups of the batch schedulers.
+var volcanoPodGroupGVK = schema.GroupVersionKind{
+	Group:   "scheduling.volcano.sh",
+	Version: "v1beta1",
+	Kind:    "PodGroup",
+}
+var schedulerPluginsPodGroupGVK = schema.GroupVersionKind{
+	Group:   "scheduling.sigs.k8s.io",
+	Version: "v1alpha1",
+	Kind:    "PodGroup",
+}
+
+const (
+	// Pod annotation which assigns a pod to a Volcano PodGroup.
+	volcanoPodGroupAnnotation = "scheduling.k8s.io/group-name"
+
+	// Pod label which assigns a pod to a scheduler-plugins PodGroup.
+	schedulerPluginsPodGroupLabel = "pod-group.scheduling.sigs.k8s.io"
 )
 
 var flinkSysProps = map[string]struct{}{
@@ -53,6 +154,7 @@ var flinkSysProps = map[string]struct{}{
 	"blob.server.port":       {},
 	"query.server.port":      {},
 	"rest.port":              {},
+	"taskmanager.rpc.port":   {},
 }
 
 // DesiredClusterState holds desired state of a cluster.
@@ -63,6 +165,50 @@ type DesiredClusterState struct {
 	TmDeployment *appsv1.Deployment
 	ConfigMap    *corev1.ConfigMap
 	Job          *batchv1.Job
+
+	// Internal service of the JobManager REST API with the UI auth proxy.
+	JmRESTService *corev1.Service
+
+	// JobManager and TaskManager StatefulSets, which replace the deployments
+	// with the "StatefulSet" deployment type, and their headless services.
+	JmStatefulSet     *appsv1.StatefulSet
+	JmHeadlessService *corev1.Service
+	TmStatefulSet     *appsv1.StatefulSet
+	TmHeadlessService *corev1.Service
+
+	// Topology spread constraints of the TaskManager pods, which are set in
+	// the pod spec of the TaskManager Deployment or StatefulSet when it is
+	// created or updated.
+	TmTopologySpreadConstraints []interface{}
+
+	// PodDisruptionBudgets of the JobManager and TaskManager pods.
+	JmPodDisruptionBudget *policyv1beta1.PodDisruptionBudget
+	TmPodDisruptionBudget *policyv1beta1.PodDisruptionBudget
+
+	// NetworkPolicies of the JobManager and TaskManager pods.
+	JmNetworkPolicy *networkingv1.NetworkPolicy
+	TmNetworkPolicy *networkingv1.NetworkPolicy
+
+	// ServiceAccount, Role and RoleBinding which allow the JobManager and
+	// TaskManagers to manage the HA ConfigMaps.
+	HAServiceAccount *corev1.ServiceAccount
+	HARole           *rbacv1.Role
+	HARoleBinding    *rbacv1.RoleBinding
+
+	// cert-manager Certificate of the REST API.
+	RESTCertificate *unstructured.Unstructured
+
+	// PodMonitor of the Prometheus Operator.
+	PodMonitor *unstructured.Unstructured
+
+	// PodGroup of the batch scheduler.
+	PodGroup *unstructured.Unstructured
+
+	// Deployment, service and ingress of the History Server, which are not
+	// deleted by the cleanup policy of the job.
+	HistoryServerDeployment *appsv1.Deployment
+	HistoryServerService    *corev1.Service
+	HistoryServerIngress    *extensionsv1beta1.Ingress
 }
 
 // Gets the desired state of a cluster.
@@ -73,25 +219,150 @@ func getDesiredClusterState(
 	if cluster == nil {
 		return DesiredClusterState{}
 	}
-	return DesiredClusterState{
+	var desired = DesiredClusterState{
 		ConfigMap:    getDesiredConfigMap(cluster),
 		JmDeployment: getDesiredJobManagerDeployment(cluster),
 		JmService:    getDesiredJobManagerService(cluster),
 		JmIngress:    getDesiredJobManagerIngress(cluster),
 		TmDeployment: getDesiredTaskManagerDeployment(cluster),
 		Job:          getDesiredJob(cluster),
+
+		JmRESTService: getDesiredJobManagerRESTService(cluster),
+
+		JmStatefulSet:     getDesiredJobManagerStatefulSet(cluster),
+		JmHeadlessService: getDesiredJobManagerHeadlessService(cluster),
+		TmStatefulSet:     getDesiredTaskManagerStatefulSet(cluster),
+		TmHeadlessService: getDesiredTaskManagerHeadlessService(cluster),
+
+		TmTopologySpreadConstraints: getDesiredTaskManagerTopologySpreadConstraints(cluster),
+
+		JmPodDisruptionBudget: getDesiredJobManagerPodDisruptionBudget(cluster),
+		TmPodDisruptionBudget: getDesiredTaskManagerPodDisruptionBudget(cluster),
+
+		JmNetworkPolicy: getDesiredJobManagerNetworkPolicy(cluster),
+		TmNetworkPolicy: getDesiredTaskManagerNetworkPolicy(cluster),
+
+		HAServiceAccount: getDesiredHAServiceAccount(cluster),
+		HARole:           getDesiredHARole(cluster),
+		HARoleBinding:    getDesiredHARoleBinding(cluster),
+
+		RESTCertificate: getDesiredRESTCertificate(cluster),
+
+		PodMonitor: getDesiredPodMonitor(cluster),
+
+		PodGroup: getDesiredPodGroup(cluster),
+
+		HistoryServerDeployment: getDesiredHistoryServerDeployment(cluster),
+		HistoryServerService:    getDesiredHistoryServerService(cluster),
+		HistoryServerIngress:    getDesiredHistoryServerIngress(cluster),
 	}
+	setDesiredResourcesMetadata(&desired, cluster)
+	return desired
 }
 
-// Gets the desired JobManager deployment spec from the FlinkCluster spec.
+// Gets the desired JobManager deployment spec from the FlinkCluster spec, nil
+// if the JobManagers are deployed as a StatefulSet.
 func getDesiredJobManagerDeployment(
 	flinkCluster *v1beta1.FlinkCluster) *appsv1.Deployment {
 
-	if shouldCleanup(flinkCluster, "JobManagerDeployment") {
+	if shouldCleanup(flinkCluster, "JobManagerDeployment") ||
+		isJobManagerStatefulSet(&flinkCluster.Spec.JobManager) {
 		return nil
 	}
 
-	var clusterNamespace = flinkCluster.ObjectMeta.Namespace
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var labels = getJobManagerLabels(clusterName)
+	var jobManagerDeployment = &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       flinkCluster.ObjectMeta.Namespace,
+			Name:            getJobManagerDeploymentName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{toOwnerReference(flinkCluster)},
+			Labels:          labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: flinkCluster.Spec.JobManager.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: getDesiredJobManagerPodTemplate(flinkCluster),
+		},
+	}
+	return jobManagerDeployment
+}
+
+// Gets the desired JobManager StatefulSet spec from the FlinkCluster spec, nil
+// unless the JobManagers are deployed as a StatefulSet. The JobManager pods get
+// stable hostnames through the JobManager headless service.
+func getDesiredJobManagerStatefulSet(
+	flinkCluster *v1beta1.FlinkCluster) *appsv1.StatefulSet {
+
+	if shouldCleanup(flinkCluster, "JobManagerDeployment") ||
+		!isJobManagerStatefulSet(&flinkCluster.Spec.JobManager) {
+		return nil
+	}
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var jobManagerSpec = flinkCluster.Spec.JobManager
+	var jobManagerStatefulSetName = getJobManagerDeploymentName(clusterName)
+	var labels = getJobManagerLabels(clusterName)
+	var jobManagerStatefulSet = &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      jobManagerStatefulSetName,
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    jobManagerSpec.Replicas,
+			Selector:    &metav1.LabelSelector{MatchLabels: labels},
+			Template:    getDesiredJobManagerPodTemplate(flinkCluster),
+			ServiceName: getHeadlessServiceName(jobManagerStatefulSetName),
+			PodManagementPolicy: getPodManagementPolicy(
+				jobManagerSpec.PodManagementPolicy,
+				appsv1.OrderedReadyPodManagement),
+		},
+	}
+	return jobManagerStatefulSet
+}
+
+// Gets the desired JobManager headless service, which gives the pods of the
+// JobManager StatefulSet stable DNS names, nil unless the JobManagers are
+// deployed as a StatefulSet.
+func getDesiredJobManagerHeadlessService(
+	flinkCluster *v1beta1.FlinkCluster) *corev1.Service {
+
+	if shouldCleanup(flinkCluster, "JobManagerDeployment") ||
+		!isJobManagerStatefulSet(&flinkCluster.Spec.JobManager) {
+		return nil
+	}
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var ports = flinkCluster.Spec.JobManager.Ports
+	return getDesiredHeadlessService(
+		flinkCluster,
+		getHeadlessServiceName(getJobManagerDeploymentName(clusterName)),
+		getJobManagerLabels(clusterName),
+		[]corev1.ServicePort{
+			{Name: "rpc", Port: *ports.RPC},
+			{Name: "blob", Port: *ports.Blob},
+			{Name: "query", Port: *ports.Query},
+			{Name: "ui", Port: *ports.UI},
+		})
+}
+
+// Gets the pod labels of the JobManager, which are also the selector of the
+// JobManager Deployment or StatefulSet and services.
+func getJobManagerLabels(clusterName string) map[string]string {
+	return map[string]string{
+		"cluster":   clusterName,
+		"app":       "flink",
+		"component": "jobmanager",
+	}
+}
+
+// Gets the desired JobManager pod template of the Deployment or StatefulSet
+// from the FlinkCluster spec.
+func getDesiredJobManagerPodTemplate(
+	flinkCluster *v1beta1.FlinkCluster) corev1.PodTemplateSpec {
 	var clusterName = flinkCluster.ObjectMeta.Name
 	var clusterSpec = flinkCluster.Spec
 	var imageSpec = clusterSpec.Image
@@ -100,12 +371,11 @@ func getDesiredJobManagerDeployment(
 	var blobPort = corev1.ContainerPort{Name: "blob", ContainerPort: *jobManagerSpec.Ports.Blob}
 	var queryPort = corev1.ContainerPort{Name: "query", ContainerPort: *jobManagerSpec.Ports.Query}
 	var uiPort = corev1.ContainerPort{Name: "ui", ContainerPort: *jobManagerSpec.Ports.UI}
-	var jobManagerDeploymentName = getJobManagerDeploymentName(clusterName)
-	var labels = map[string]string{
-		"cluster":   clusterName,
-		"app":       "flink",
-		"component": "jobmanager",
+	var ports = []corev1.ContainerPort{rpcPort, blobPort, queryPort, uiPort}
+	if metricsPort := getMetricsPort(flinkCluster); metricsPort != nil {
+		ports = append(ports, *metricsPort)
 	}
+	var labels = getJobManagerLabels(clusterName)
 	// Make Volume, VolumeMount to use configMap data for flink-conf.yaml, if flinkProperties is provided.
 	var volumes []corev1.Volume
 	var volumeMounts []corev1.VolumeMount
@@ -136,18 +406,24 @@ func getDesiredJobManagerDeployment(
 			},
 		},
 	}
-	var readinessProbe = corev1.Probe{
+	var readinessScheme corev1.URIScheme
+	if isRESTTLSEnabled(flinkCluster) {
+		readinessScheme = corev1.URISchemeHTTPS
+	}
+	var readinessProbe = getProbe(jobManagerSpec.ReadinessProbe, corev1.Probe{
 		Handler: corev1.Handler{
-			TCPSocket: &corev1.TCPSocketAction{
-				Port: intstr.FromInt(int(*jobManagerSpec.Ports.RPC)),
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   "/config",
+				Port:   intstr.FromInt(int(*jobManagerSpec.Ports.UI)),
+				Scheme: readinessScheme,
 			},
 		},
 		TimeoutSeconds:      10,
 		InitialDelaySeconds: 5,
 		PeriodSeconds:       5,
 		FailureThreshold:    60,
-	}
-	var livenessProbe = corev1.Probe{
+	})
+	var livenessProbe = getProbe(jobManagerSpec.LivenessProbe, corev1.Probe{
 		Handler: corev1.Handler{
 			TCPSocket: &corev1.TCPSocketAction{
 				Port: intstr.FromInt(int(*jobManagerSpec.Ports.RPC)),
@@ -157,10 +433,11 @@ func getDesiredJobManagerDeployment(
 		InitialDelaySeconds: 5,
 		PeriodSeconds:       60,
 		FailureThreshold:    5,
-	}
+	})
 
 	// Hadoop config.
-	var hcVolume, hcMount, hcEnv = convertHadoopConfig(clusterSpec.HadoopConfig)
+	var hcVolume, hcMount, hcEnv = convertHadoopConfig(
+		clusterName, clusterSpec.HadoopConfig)
 	if hcVolume != nil {
 		volumes = append(volumes, *hcVolume)
 	}
@@ -183,49 +460,127 @@ func getDesiredJobManagerDeployment(
 		envVars = append(envVars, *saEnv)
 	}
 
+	// Built-in file system and GPU plugins.
+	if pluginsEnv := getPluginsEnv(&clusterSpec); pluginsEnv != nil {
+		envVars = append(envVars, *pluginsEnv)
+	}
+
+	// With HA services, the JobManager publishes its own address through the
+	// leader election, so it binds to the pod IP instead of the service name.
+	var args = []string{"jobmanager"}
+	if clusterSpec.HighAvailability != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name: "POD_IP",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "status.podIP",
+				},
+			},
+		})
+		args = append(args, "$(POD_IP)")
+	}
+
+	// Internal TLS, whose password is passed as dynamic properties.
+	var tlsVolume, tlsMount, tlsEnv, tlsArgs = convertInternalTLS(flinkCluster)
+	if tlsVolume != nil {
+		volumes = append(volumes, *tlsVolume)
+		volumeMounts = append(volumeMounts, *tlsMount)
+		envVars = append(envVars, *tlsEnv)
+		args = append(args, tlsArgs...)
+	}
+
+	// REST API TLS, whose password is passed as dynamic properties.
+	var restVolume, restMount, restEnv, restArgs = convertRESTTLS(flinkCluster)
+	if restVolume != nil {
+		volumes = append(volumes, *restVolume)
+		volumeMounts = append(volumeMounts, *restMount)
+		envVars = append(envVars, *restEnv)
+		args = append(args, restArgs...)
+	}
+
+	// Flink properties from Secrets.
+	var propEnvVars, propArgs = convertFlinkPropertiesFrom(
+		clusterSpec.FlinkPropertiesFrom)
+	envVars = append(envVars, propEnvVars...)
+	args = append(args, propArgs...)
+
+	// Kerberos keytab and krb5.conf.
+	var kerberosVolumes, kerberosMounts = convertKerberos(flinkCluster)
+	volumes = append(volumes, kerberosVolumes...)
+	volumeMounts = append(volumeMounts, kerberosMounts...)
+
 	envVars = append(envVars, flinkCluster.Spec.EnvVars...)
+	envVars = append(envVars, jobManagerSpec.Env...)
 	var containers = []corev1.Container{corev1.Container{
 		Name:            "jobmanager",
 		Image:           imageSpec.Name,
 		ImagePullPolicy: imageSpec.PullPolicy,
-		Args:            []string{"jobmanager"},
-		Ports: []corev1.ContainerPort{
-			rpcPort, blobPort, queryPort, uiPort},
-		LivenessProbe:  &livenessProbe,
-		ReadinessProbe: &readinessProbe,
-		Resources:      jobManagerSpec.Resources,
-		Env:            envVars,
-		VolumeMounts:   volumeMounts,
+		Args:            args,
+		Ports:           ports,
+		LivenessProbe:   livenessProbe,
+		ReadinessProbe:  readinessProbe,
+		Resources:       jobManagerSpec.Resources,
+		Env:             envVars,
+		EnvFrom:         jobManagerSpec.EnvFrom,
+		VolumeMounts:    volumeMounts,
+		SecurityContext: jobManagerSpec.ContainerSecurityContext,
 	}}
 
 	containers = append(containers, jobManagerSpec.Sidecars...)
-
-	var podSpec = corev1.PodSpec{
-		Containers:       containers,
-		Volumes:          volumes,
-		NodeSelector:     jobManagerSpec.NodeSelector,
-		ImagePullSecrets: imageSpec.PullSecrets,
+	if proxyContainer := convertUIAuthProxy(jobManagerSpec.UIAuthProxy); proxyContainer != nil {
+		containers = append(containers, *proxyContainer)
 	}
 
-	var jobManagerDeployment = &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:       clusterNamespace,
-			Name:            jobManagerDeploymentName,
-			OwnerReferences: []metav1.OwnerReference{toOwnerReference(flinkCluster)},
-			Labels:          labels,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: jobManagerSpec.Replicas,
-			Selector: &metav1.LabelSelector{MatchLabels: labels},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+	var podSpec = corev1.PodSpec{
+		InitContainers: convertInitContainers(
+			jobManagerSpec.InitContainers, jobManagerSpec.VolumeMounts),
+		Containers:                   containers,
+		Volumes:                      volumes,
+		NodeSelector:                 jobManagerSpec.NodeSelector,
+		Tolerations:                  jobManagerSpec.Tolerations,
+		Affinity:                     jobManagerSpec.Affinity,
+		SecurityContext:              jobManagerSpec.SecurityContext,
+		ImagePullSecrets:             imageSpec.PullSecrets,
+		ServiceAccountName:           getServiceAccountName(flinkCluster),
+		AutomountServiceAccountToken: flinkCluster.Spec.AutomountServiceAccountToken,
+	}
+	if jobManagerSpec.PriorityClassName != nil {
+		podSpec.PriorityClassName = *jobManagerSpec.PriorityClassName
+	}
+	podSpec.TerminationGracePeriodSeconds = jobManagerSpec.TerminationGracePeriodSeconds
+	// Spread the standby JobManagers across nodes, so that a node failure does
+	// not take down all of them.
+	if podSpec.Affinity == nil &&
+		jobManagerSpec.Replicas != nil && *jobManagerSpec.Replicas > 1 {
+		podSpec.Affinity = &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{
+						Weight: 100,
+						PodAffinityTerm: corev1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+							TopologyKey:   "kubernetes.io/hostname",
+						},
+					},
 				},
-				Spec: podSpec,
 			},
-		},
+		}
 	}
-	return jobManagerDeployment
+	var inboundPorts = []int32{
+		*jobManagerSpec.Ports.RPC,
+		*jobManagerSpec.Ports.Blob,
+		*jobManagerSpec.Ports.Query,
+	}
+	var podTemplate = mergePodTemplate(
+		jobManagerSpec.PodTemplate,
+		labels,
+		mergeStringMaps(
+			getFlinkConfAnnotations(flinkCluster),
+			getIstioAnnotations(flinkCluster, inboundPorts)),
+		podSpec)
+	setBatchScheduler(flinkCluster, &podTemplate)
+	return podTemplate
 }
 
 // Gets the desired JobManager service spec from a cluster spec.
@@ -255,6 +610,11 @@ func getDesiredJobManagerService(
 		Name:       "ui",
 		Port:       *jobManagerSpec.Ports.UI,
 		TargetPort: intstr.FromString("ui")}
+	// The UI port is routed to the auth proxy, which forwards the
+	// authenticated requests to the UI.
+	if jobManagerSpec.UIAuthProxy != nil {
+		uiPort.TargetPort = intstr.FromString(uiAuthProxyPortName)
+	}
 	var jobManagerServiceName = getJobManagerServiceName(clusterName)
 	var labels = map[string]string{
 		"cluster":   clusterName,
@@ -292,9 +652,84 @@ func getDesiredJobManagerService(
 		panic(fmt.Sprintf(
 			"Unknown service access cope: %v", jobManagerSpec.AccessScope))
 	}
+	setServiceSpec(jobManagerService, jobManagerSpec.Service)
 	return jobManagerService
 }
 
+// Gets the desired internal service of the JobManager REST API, which the
+// operator and the job submitter connect to without the UI auth proxy, nil if
+// the proxy is not enabled.
+func getDesiredJobManagerRESTService(
+	flinkCluster *v1beta1.FlinkCluster) *corev1.Service {
+	if !isUIAuthProxyEnabled(flinkCluster) ||
+		shouldCleanup(flinkCluster, "JobManagerService") {
+		return nil
+	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var labels = getJobManagerLabels(clusterName)
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      getJobManagerRESTServiceName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []corev1.ServicePort{{
+				Name:       "ui",
+				Port:       *flinkCluster.Spec.JobManager.Ports.UI,
+				TargetPort: intstr.FromString("ui"),
+			}},
+		},
+	}
+}
+
+// Checks whether the UI port of the JobManager service is routed to the auth
+// proxy sidecar.
+func isUIAuthProxyEnabled(flinkCluster *v1beta1.FlinkCluster) bool {
+	return flinkCluster.Spec.JobManager.UIAuthProxy != nil
+}
+
+// Gets the auth proxy container of the JobManager pod with its port, nil if
+// it is not specified.
+func convertUIAuthProxy(proxySpec *v1beta1.UIAuthProxySpec) *corev1.Container {
+	if proxySpec == nil {
+		return nil
+	}
+	var container = proxySpec.Container.DeepCopy()
+	container.Ports = append(container.Ports, corev1.ContainerPort{
+		Name:          uiAuthProxyPortName,
+		ContainerPort: *proxySpec.Port,
+	})
+	return container
+}
+
+// Applies the service spec to a service, it overrides the type and adds
+// annotations on top of the ones derived from the access scope.
+func setServiceSpec(
+	service *corev1.Service, serviceSpec *v1beta1.JobManagerServiceSpec) {
+	if serviceSpec == nil {
+		return
+	}
+	if serviceSpec.Type != nil {
+		service.Spec.Type = *serviceSpec.Type
+	}
+	if len(serviceSpec.Annotations) > 0 {
+		var annotations = map[string]string{}
+		for name, value := range service.Annotations {
+			annotations[name] = value
+		}
+		for name, value := range serviceSpec.Annotations {
+			annotations[name] = value
+		}
+		service.Annotations = annotations
+	}
+	service.Spec.LoadBalancerSourceRanges = serviceSpec.LoadBalancerSourceRanges
+}
+
 // Gets the desired JobManager ingress spec from a cluster spec.
 func getDesiredJobManagerIngress(
 	flinkCluster *v1beta1.FlinkCluster) *extensionsv1beta1.Ingress {
@@ -307,30 +742,43 @@ func getDesiredJobManagerIngress(
 		return nil
 	}
 
+	var clusterName = flinkCluster.ObjectMeta.Name
+	return getDesiredIngress(
+		flinkCluster,
+		jobManagerIngressSpec,
+		getJobManagerIngressName(clusterName),
+		getJobManagerServiceName(clusterName),
+		getJobManagerLabels(clusterName))
+}
+
+// Gets the desired ingress to the "ui" port of a service from an ingress spec.
+func getDesiredIngress(
+	flinkCluster *v1beta1.FlinkCluster,
+	ingressSpec *v1beta1.JobManagerIngressSpec,
+	ingressName string,
+	serviceName string,
+	labels map[string]string) *extensionsv1beta1.Ingress {
 	var clusterNamespace = flinkCluster.ObjectMeta.Namespace
 	var clusterName = flinkCluster.ObjectMeta.Name
-	var jobManagerServiceName = getJobManagerServiceName(clusterName)
-	var jobManagerServiceUIPort = intstr.FromString("ui")
-	var ingressName = getJobManagerIngressName(clusterName)
-	var ingressAnnotations = jobManagerIngressSpec.Annotations
+	var serviceUIPort = intstr.FromString("ui")
+	var ingressAnnotations = ingressSpec.Annotations
 	var ingressHost string
+	var ingressPath = "/"
 	var ingressTLS []extensionsv1beta1.IngressTLS
-	var labels = map[string]string{
-		"cluster":   clusterName,
-		"app":       "flink",
-		"component": "jobmanager",
+	if ingressSpec.HostFormat != nil {
+		ingressHost = getJobManagerIngressHost(*ingressSpec.HostFormat, clusterName)
 	}
-	if jobManagerIngressSpec.HostFormat != nil {
-		ingressHost = getJobManagerIngressHost(*jobManagerIngressSpec.HostFormat, clusterName)
+	if ingressSpec.Path != nil {
+		ingressPath = *ingressSpec.Path
 	}
-	if jobManagerIngressSpec.UseTLS != nil && *jobManagerIngressSpec.UseTLS == true {
+	if ingressSpec.UseTLS != nil && *ingressSpec.UseTLS == true {
 		var secretName string
 		var hosts []string
 		if ingressHost != "" {
 			hosts = []string{ingressHost}
 		}
-		if jobManagerIngressSpec.TLSSecretName != nil {
-			secretName = *jobManagerIngressSpec.TLSSecretName
+		if ingressSpec.TLSSecretName != nil {
+			secretName = *ingressSpec.TLSSecretName
 		}
 		if hosts != nil || secretName != "" {
 			ingressTLS = []extensionsv1beta1.IngressTLS{{
@@ -339,7 +787,7 @@ func getDesiredJobManagerIngress(
 			}}
 		}
 	}
-	var jobManagerIngress = &extensionsv1beta1.Ingress{
+	var ingress = &extensionsv1beta1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: clusterNamespace,
 			Name:      ingressName,
@@ -355,10 +803,10 @@ func getDesiredJobManagerIngress(
 				IngressRuleValue: extensionsv1beta1.IngressRuleValue{
 					HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
 						Paths: []extensionsv1beta1.HTTPIngressPath{{
-							Path: "/",
+							Path: ingressPath,
 							Backend: extensionsv1beta1.IngressBackend{
-								ServiceName: jobManagerServiceName,
-								ServicePort: jobManagerServiceUIPort,
+								ServiceName: serviceName,
+								ServicePort: serviceUIPort,
 							},
 						}},
 					},
@@ -367,101 +815,1029 @@ func getDesiredJobManagerIngress(
 		},
 	}
 
-	return jobManagerIngress
+	return ingress
 }
 
-// Gets the desired TaskManager deployment spec from a cluster spec.
+// Gets the desired TaskManager deployment spec from a cluster spec, nil if the
+// TaskManagers are deployed as a StatefulSet.
 func getDesiredTaskManagerDeployment(
 	flinkCluster *v1beta1.FlinkCluster) *appsv1.Deployment {
 
-	if shouldCleanup(flinkCluster, "TaskManagerDeployment") {
+	if shouldCleanup(flinkCluster, "TaskManagerDeployment") ||
+		isTaskManagerStatefulSet(&flinkCluster.Spec.TaskManager) {
 		return nil
 	}
 
-	var clusterNamespace = flinkCluster.ObjectMeta.Namespace
 	var clusterName = flinkCluster.ObjectMeta.Name
-	var clusterSpec = flinkCluster.Spec
-	var imageSpec = flinkCluster.Spec.Image
 	var taskManagerSpec = flinkCluster.Spec.TaskManager
-	var dataPort = corev1.ContainerPort{Name: "data", ContainerPort: *taskManagerSpec.Ports.Data}
-	var rpcPort = corev1.ContainerPort{Name: "rpc", ContainerPort: *taskManagerSpec.Ports.RPC}
-	var queryPort = corev1.ContainerPort{Name: "query", ContainerPort: *taskManagerSpec.Ports.Query}
-	var taskManagerDeploymentName = getTaskManagerDeploymentName(clusterName)
-	var labels = map[string]string{
-		"cluster":   clusterName,
-		"app":       "flink",
-		"component": "taskmanager",
+	var labels = getTaskManagerLabels(clusterName)
+	var taskManagerDeployment = &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      getTaskManagerDeploymentName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: taskManagerSpec.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: getDesiredTaskManagerPodTemplate(flinkCluster),
+		},
 	}
-	// Make Volume, VolumeMount to use configMap data for flink-conf.yaml
-	var volumes []corev1.Volume
-	var volumeMounts []corev1.VolumeMount
+	return taskManagerDeployment
+}
 
-	// Flink config.
-	var confVol, confMount = convertFlinkConfig(clusterName)
-	volumes = append(taskManagerSpec.Volumes, *confVol)
-	volumeMounts = append(taskManagerSpec.VolumeMounts, *confMount)
+// Gets the desired TaskManager StatefulSet spec from a cluster spec, nil if
+// the TaskManagers are deployed as a Deployment. By default, the pods are
+// started and deleted in parallel like the pods of a Deployment. Each pod gets
+// its own claims from the volume claim templates, and a stable hostname
+// through the TaskManager headless service.
+func getDesiredTaskManagerStatefulSet(
+	flinkCluster *v1beta1.FlinkCluster) *appsv1.StatefulSet {
 
-	var envVars = []corev1.EnvVar{
-		{
-			Name: "TASK_MANAGER_CPU_LIMIT",
-			ValueFrom: &corev1.EnvVarSource{
-				ResourceFieldRef: &corev1.ResourceFieldSelector{
-					ContainerName: "taskmanager",
-					Resource:      "limits.cpu",
-					Divisor:       resource.MustParse("1m"),
-				},
-			},
-		},
-		{
-			Name: "TASK_MANAGER_MEMORY_LIMIT",
-			ValueFrom: &corev1.EnvVarSource{
-				ResourceFieldRef: &corev1.ResourceFieldSelector{
-					ContainerName: "taskmanager",
-					Resource:      "limits.memory",
-					Divisor:       resource.MustParse("1Mi"),
-				},
-			},
-		},
+	if shouldCleanup(flinkCluster, "TaskManagerDeployment") ||
+		!isTaskManagerStatefulSet(&flinkCluster.Spec.TaskManager) {
+		return nil
 	}
-	var readinessProbe = corev1.Probe{
-		Handler: corev1.Handler{
-			TCPSocket: &corev1.TCPSocketAction{
-				Port: intstr.FromInt(int(*taskManagerSpec.Ports.RPC)),
-			},
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var taskManagerSpec = flinkCluster.Spec.TaskManager
+	var taskManagerStatefulSetName = getTaskManagerDeploymentName(clusterName)
+	var labels = getTaskManagerLabels(clusterName)
+	var taskManagerStatefulSet = &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      taskManagerStatefulSetName,
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
 		},
-		TimeoutSeconds:      10,
-		InitialDelaySeconds: 5,
-		PeriodSeconds:       5,
-		FailureThreshold:    60,
-	}
-	var livenessProbe = corev1.Probe{
-		Handler: corev1.Handler{
-			TCPSocket: &corev1.TCPSocketAction{
-				Port: intstr.FromInt(int(*taskManagerSpec.Ports.RPC)),
-			},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             taskManagerSpec.Replicas,
+			Selector:             &metav1.LabelSelector{MatchLabels: labels},
+			Template:             getDesiredTaskManagerPodTemplate(flinkCluster),
+			VolumeClaimTemplates: taskManagerSpec.VolumeClaimTemplates,
+			ServiceName:          getHeadlessServiceName(taskManagerStatefulSetName),
+			PodManagementPolicy: getPodManagementPolicy(
+				taskManagerSpec.PodManagementPolicy,
+				appsv1.ParallelPodManagement),
 		},
-		TimeoutSeconds:      10,
-		InitialDelaySeconds: 5,
-		PeriodSeconds:       60,
-		FailureThreshold:    5,
 	}
+	return taskManagerStatefulSet
+}
 
-	// Hadoop config.
-	var hcVolume, hcMount, hcEnv = convertHadoopConfig(clusterSpec.HadoopConfig)
-	if hcVolume != nil {
-		volumes = append(volumes, *hcVolume)
+// Gets the desired TaskManager headless service, which gives the pods of the
+// TaskManager StatefulSet stable DNS names, nil unless the TaskManagers are
+// deployed as a StatefulSet.
+func getDesiredTaskManagerHeadlessService(
+	flinkCluster *v1beta1.FlinkCluster) *corev1.Service {
+
+	if shouldCleanup(flinkCluster, "TaskManagerDeployment") ||
+		!isTaskManagerStatefulSet(&flinkCluster.Spec.TaskManager) {
+		return nil
 	}
-	if hcMount != nil {
-		volumeMounts = append(volumeMounts, *hcMount)
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var ports = flinkCluster.Spec.TaskManager.Ports
+	return getDesiredHeadlessService(
+		flinkCluster,
+		getHeadlessServiceName(getTaskManagerDeploymentName(clusterName)),
+		getTaskManagerLabels(clusterName),
+		[]corev1.ServicePort{
+			{Name: "data", Port: *ports.Data},
+			{Name: "rpc", Port: *ports.RPC},
+			{Name: "query", Port: *ports.Query},
+		})
+}
+
+// Gets a headless service which selects the pods of a StatefulSet. The
+// addresses of the pods are published before they are ready, so that the
+// components can resolve each other while they are starting.
+func getDesiredHeadlessService(
+	flinkCluster *v1beta1.FlinkCluster,
+	name string,
+	labels map[string]string,
+	ports []corev1.ServicePort) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                corev1.ClusterIPNone,
+			Selector:                 labels,
+			Ports:                    ports,
+			PublishNotReadyAddresses: true,
+		},
 	}
-	if hcEnv != nil {
-		envVars = append(envVars, *hcEnv)
+}
+
+// Gets the desired PodDisruptionBudget of the JobManager pods, nil if it is
+// not specified.
+func getDesiredJobManagerPodDisruptionBudget(
+	flinkCluster *v1beta1.FlinkCluster) *policyv1beta1.PodDisruptionBudget {
+	var pdbSpec = flinkCluster.Spec.JobManager.PodDisruptionBudget
+	if pdbSpec == nil || shouldCleanup(flinkCluster, "JobManagerDeployment") {
+		return nil
 	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	return getDesiredPodDisruptionBudget(
+		flinkCluster,
+		getJobManagerDeploymentName(clusterName),
+		getJobManagerLabels(clusterName),
+		pdbSpec)
+}
 
-	// GCP service account config.
-	var saVolume, saMount, saEnv = convertGCPConfig(clusterSpec.GCPConfig)
-	if saVolume != nil {
-		volumes = append(volumes, *saVolume)
+// Gets the desired PodDisruptionBudget of the TaskManager pods, nil if it is
+// not specified.
+func getDesiredTaskManagerPodDisruptionBudget(
+	flinkCluster *v1beta1.FlinkCluster) *policyv1beta1.PodDisruptionBudget {
+	var pdbSpec = flinkCluster.Spec.TaskManager.PodDisruptionBudget
+	if pdbSpec == nil || shouldCleanup(flinkCluster, "TaskManagerDeployment") {
+		return nil
+	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	return getDesiredPodDisruptionBudget(
+		flinkCluster,
+		getTaskManagerDeploymentName(clusterName),
+		getTaskManagerLabels(clusterName),
+		pdbSpec)
+}
+
+// Gets a PodDisruptionBudget which selects the pods with the labels.
+func getDesiredPodDisruptionBudget(
+	flinkCluster *v1beta1.FlinkCluster,
+	name string,
+	labels map[string]string,
+	pdbSpec *v1beta1.PodDisruptionBudgetSpec) *policyv1beta1.PodDisruptionBudget {
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable:   pdbSpec.MinAvailable,
+			MaxUnavailable: pdbSpec.MaxUnavailable,
+			Selector:       &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+}
+
+// Gets the desired NetworkPolicy of the JobManager pods, nil if it is not
+// specified. The pods of the cluster, i.e., the JobManagers, TaskManagers and
+// the job submitter, can access all the JobManager ports, while the operator
+// and the UI ingress CIDRs can only access the UI and REST API.
+func getDesiredJobManagerNetworkPolicy(
+	flinkCluster *v1beta1.FlinkCluster) *networkingv1.NetworkPolicy {
+	var networkPolicySpec = flinkCluster.Spec.NetworkPolicy
+	if networkPolicySpec == nil ||
+		shouldCleanup(flinkCluster, "JobManagerDeployment") {
+		return nil
+	}
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var ports = flinkCluster.Spec.JobManager.Ports
+	var uiPorts = getNetworkPolicyPorts(*ports.UI)
+	// The UI is accessed through the auth proxy from the allowed CIDRs.
+	var exposedUIPorts = uiPorts
+	if proxySpec := flinkCluster.Spec.JobManager.UIAuthProxy; proxySpec != nil {
+		exposedUIPorts = getNetworkPolicyPorts(*proxySpec.Port)
+	}
+	var operatorNamespaceSelector = networkPolicySpec.OperatorNamespaceSelector
+	if operatorNamespaceSelector == nil {
+		operatorNamespaceSelector = &metav1.LabelSelector{}
+	}
+	var rules = []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: getClusterNetworkPolicyPeers(clusterName),
+			Ports: getNetworkPolicyPorts(
+				*ports.RPC, *ports.Blob, *ports.Query, *ports.UI),
+		},
+		{
+			From: []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: operatorNamespaceSelector,
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"control-plane": "controller-manager",
+					},
+				},
+			}},
+			Ports: uiPorts,
+		},
+	}
+	if len(networkPolicySpec.UIIngressCIDRs) > 0 {
+		var peers []networkingv1.NetworkPolicyPeer
+		for _, cidr := range networkPolicySpec.UIIngressCIDRs {
+			peers = append(peers, networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+			})
+		}
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  peers,
+			Ports: exposedUIPorts,
+		})
+	}
+	return getDesiredNetworkPolicy(
+		flinkCluster,
+		getJobManagerDeploymentName(clusterName),
+		getJobManagerLabels(clusterName),
+		rules)
+}
+
+// Gets the desired NetworkPolicy of the TaskManager pods, nil if it is not
+// specified. Only the pods of the cluster can access the TaskManager ports.
+func getDesiredTaskManagerNetworkPolicy(
+	flinkCluster *v1beta1.FlinkCluster) *networkingv1.NetworkPolicy {
+	if flinkCluster.Spec.NetworkPolicy == nil ||
+		shouldCleanup(flinkCluster, "TaskManagerDeployment") {
+		return nil
+	}
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var ports = flinkCluster.Spec.TaskManager.Ports
+	var rules = []networkingv1.NetworkPolicyIngressRule{
+		{
+			From:  getClusterNetworkPolicyPeers(clusterName),
+			Ports: getNetworkPolicyPorts(*ports.Data, *ports.RPC, *ports.Query),
+		},
+	}
+	return getDesiredNetworkPolicy(
+		flinkCluster,
+		getTaskManagerDeploymentName(clusterName),
+		getTaskManagerLabels(clusterName),
+		rules)
+}
+
+// Gets a NetworkPolicy which only allows the ingress traffic of the rules to
+// the pods with the labels.
+func getDesiredNetworkPolicy(
+	flinkCluster *v1beta1.FlinkCluster,
+	name string,
+	labels map[string]string,
+	rules []networkingv1.NetworkPolicyIngressRule) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: labels},
+			Ingress:     rules,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+// Gets the NetworkPolicy peers which select all the pods of the cluster.
+func getClusterNetworkPolicyPeers(
+	clusterName string) []networkingv1.NetworkPolicyPeer {
+	return []networkingv1.NetworkPolicyPeer{{
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"cluster": clusterName, "app": "flink"},
+		},
+	}}
+}
+
+// Gets the TCP NetworkPolicy ports of the port numbers.
+func getNetworkPolicyPorts(ports ...int32) []networkingv1.NetworkPolicyPort {
+	var policyPorts []networkingv1.NetworkPolicyPort
+	for _, port := range ports {
+		var protocol = corev1.ProtocolTCP
+		var policyPort = intstr.FromInt(int(port))
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &policyPort,
+		})
+	}
+	return policyPorts
+}
+
+// Gets the pod management policy of a StatefulSet, or the given default if it
+// is unspecified.
+func getPodManagementPolicy(
+	policy *v1beta1.PodManagementPolicy,
+	defaultPolicy appsv1.PodManagementPolicyType) appsv1.PodManagementPolicyType {
+	if policy == nil {
+		return defaultPolicy
+	}
+	return appsv1.PodManagementPolicyType(*policy)
+}
+
+func getTaskManagerLabels(clusterName string) map[string]string {
+	return map[string]string{
+		"cluster":   clusterName,
+		"app":       "flink",
+		"component": "taskmanager",
+	}
+}
+
+// Gets the desired TaskManager pod template of the Deployment or StatefulSet
+// from a cluster spec.
+func getDesiredTaskManagerPodTemplate(
+	flinkCluster *v1beta1.FlinkCluster) corev1.PodTemplateSpec {
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var clusterSpec = flinkCluster.Spec
+	var imageSpec = flinkCluster.Spec.Image
+	var taskManagerSpec = flinkCluster.Spec.TaskManager
+	var dataPort = corev1.ContainerPort{Name: "data", ContainerPort: *taskManagerSpec.Ports.Data}
+	var rpcPort = corev1.ContainerPort{Name: "rpc", ContainerPort: *taskManagerSpec.Ports.RPC}
+	var queryPort = corev1.ContainerPort{Name: "query", ContainerPort: *taskManagerSpec.Ports.Query}
+	var ports = []corev1.ContainerPort{dataPort, rpcPort, queryPort}
+	if metricsPort := getMetricsPort(flinkCluster); metricsPort != nil {
+		ports = append(ports, *metricsPort)
+	}
+	// Make Volume, VolumeMount to use configMap data for flink-conf.yaml
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+
+	// Flink config.
+	var confVol, confMount = convertFlinkConfig(clusterName)
+	volumes = append(taskManagerSpec.Volumes, *confVol)
+	volumeMounts = append(taskManagerSpec.VolumeMounts, *confMount)
+
+	var envVars = []corev1.EnvVar{
+		{
+			Name: "TASK_MANAGER_CPU_LIMIT",
+			ValueFrom: &corev1.EnvVarSource{
+				ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: "taskmanager",
+					Resource:      "limits.cpu",
+					Divisor:       resource.MustParse("1m"),
+				},
+			},
+		},
+		{
+			Name: "TASK_MANAGER_MEMORY_LIMIT",
+			ValueFrom: &corev1.EnvVarSource{
+				ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: "taskmanager",
+					Resource:      "limits.memory",
+					Divisor:       resource.MustParse("1Mi"),
+				},
+			},
+		},
+	}
+	var readinessProbe = getProbe(taskManagerSpec.ReadinessProbe, corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(int(*taskManagerSpec.Ports.RPC)),
+			},
+		},
+		TimeoutSeconds:      10,
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       5,
+		FailureThreshold:    60,
+	})
+	var livenessProbe = getProbe(taskManagerSpec.LivenessProbe, corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(int(*taskManagerSpec.Ports.RPC)),
+			},
+		},
+		TimeoutSeconds:      10,
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       60,
+		FailureThreshold:    5,
+	})
+
+	// Hadoop config.
+	var hcVolume, hcMount, hcEnv = convertHadoopConfig(
+		clusterName, clusterSpec.HadoopConfig)
+	if hcVolume != nil {
+		volumes = append(volumes, *hcVolume)
+	}
+	if hcMount != nil {
+		volumeMounts = append(volumeMounts, *hcMount)
+	}
+	if hcEnv != nil {
+		envVars = append(envVars, *hcEnv)
+	}
+
+	// GCP service account config.
+	var saVolume, saMount, saEnv = convertGCPConfig(clusterSpec.GCPConfig)
+	if saVolume != nil {
+		volumes = append(volumes, *saVolume)
+	}
+	if saMount != nil {
+		volumeMounts = append(volumeMounts, *saMount)
+	}
+	if saEnv != nil {
+		envVars = append(envVars, *saEnv)
+	}
+
+	// RocksDB local directory.
+	var rocksDBVolume, rocksDBMount = convertRocksDBLocalDir(clusterSpec.StateBackend)
+	if rocksDBVolume != nil {
+		volumes = append(volumes, *rocksDBVolume)
+	}
+	if rocksDBMount != nil {
+		volumeMounts = append(volumeMounts, *rocksDBMount)
+	}
+
+	// Built-in file system and GPU plugins.
+	if pluginsEnv := getPluginsEnv(&clusterSpec); pluginsEnv != nil {
+		envVars = append(envVars, *pluginsEnv)
+	}
+	// In a StatefulSet, the TaskManager registers with its stable hostname
+	// instead of the pod IP, so that the hostname shows up in the metrics and
+	// the web UI.
+	var args = []string{"taskmanager"}
+	if isTaskManagerStatefulSet(&taskManagerSpec) {
+		envVars = append(envVars, corev1.EnvVar{
+			Name: "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.name",
+				},
+			},
+		})
+		args = append(args, fmt.Sprintf(
+			"-Dtaskmanager.host=$(POD_NAME).%s",
+			getHeadlessServiceName(getTaskManagerDeploymentName(clusterName))))
+	}
+
+	// Internal TLS, whose password is passed as dynamic properties.
+	var tlsVolume, tlsMount, tlsEnv, tlsArgs = convertInternalTLS(flinkCluster)
+	if tlsVolume != nil {
+		volumes = append(volumes, *tlsVolume)
+		volumeMounts = append(volumeMounts, *tlsMount)
+		envVars = append(envVars, *tlsEnv)
+		args = append(args, tlsArgs...)
+	}
+
+	// Flink properties from Secrets.
+	var propEnvVars, propArgs = convertFlinkPropertiesFrom(
+		clusterSpec.FlinkPropertiesFrom)
+	envVars = append(envVars, propEnvVars...)
+	args = append(args, propArgs...)
+
+	// Kerberos keytab and krb5.conf.
+	var kerberosVolumes, kerberosMounts = convertKerberos(flinkCluster)
+	volumes = append(volumes, kerberosVolumes...)
+	volumeMounts = append(volumeMounts, kerberosMounts...)
+
+	envVars = append(envVars, flinkCluster.Spec.EnvVars...)
+	envVars = append(envVars, taskManagerSpec.Env...)
+
+	var containers = []corev1.Container{corev1.Container{
+		Name:            "taskmanager",
+		Image:           imageSpec.Name,
+		ImagePullPolicy: imageSpec.PullPolicy,
+		Args:            args,
+		Ports:           ports,
+		LivenessProbe:   livenessProbe,
+		ReadinessProbe:  readinessProbe,
+		Resources:       getTaskManagerResources(&taskManagerSpec),
+		Env:             envVars,
+		EnvFrom:         taskManagerSpec.EnvFrom,
+		VolumeMounts:    volumeMounts,
+		SecurityContext: taskManagerSpec.ContainerSecurityContext,
+	}}
+	containers = append(containers, taskManagerSpec.Sidecars...)
+	var podSpec = corev1.PodSpec{
+		InitContainers: convertInitContainers(
+			taskManagerSpec.InitContainers, taskManagerSpec.VolumeMounts),
+		Containers:                   containers,
+		Volumes:                      volumes,
+		NodeSelector:                 taskManagerSpec.NodeSelector,
+		Tolerations:                  taskManagerSpec.Tolerations,
+		Affinity:                     taskManagerSpec.Affinity,
+		SecurityContext:              taskManagerSpec.SecurityContext,
+		ImagePullSecrets:             imageSpec.PullSecrets,
+		ServiceAccountName:           getServiceAccountName(flinkCluster),
+		AutomountServiceAccountToken: flinkCluster.Spec.AutomountServiceAccountToken,
+	}
+	if taskManagerSpec.PriorityClassName != nil {
+		podSpec.PriorityClassName = *taskManagerSpec.PriorityClassName
+	}
+	podSpec.TerminationGracePeriodSeconds = taskManagerSpec.TerminationGracePeriodSeconds
+	var inboundPorts = []int32{
+		*taskManagerSpec.Ports.RPC,
+		*taskManagerSpec.Ports.Data,
+		*taskManagerSpec.Ports.Query,
+	}
+	var podTemplate = mergePodTemplate(
+		taskManagerSpec.PodTemplate,
+		getTaskManagerLabels(clusterName),
+		mergeStringMaps(
+			getFlinkConfAnnotations(flinkCluster),
+			getIstioAnnotations(flinkCluster, inboundPorts)),
+		podSpec)
+	setBatchScheduler(flinkCluster, &podTemplate)
+	return podTemplate
+}
+
+// Gets the desired configMap.
+func getDesiredConfigMap(
+	flinkCluster *v1beta1.FlinkCluster) *corev1.ConfigMap {
+
+	// The History Server keeps reading its config after the cleanup.
+	if shouldCleanup(flinkCluster, "ConfigMap") &&
+		flinkCluster.Spec.HistoryServer == nil {
+		return nil
+	}
+
+	var clusterNamespace = flinkCluster.ObjectMeta.Namespace
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var configMapName = getConfigMapName(clusterName)
+	var labels = map[string]string{
+		"cluster": clusterName,
+		"app":     "flink",
+	}
+	var configMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterNamespace,
+			Name:      configMapName,
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Data: map[string]string{
+			"flink-conf.yaml": getFlinkConf(flinkCluster),
+			"submit-job.sh":   submitJobScript,
+		},
+	}
+	// The log config files in the spec replace the default ones.
+	var logConf = mergeStringMaps(getLogConf(), flinkCluster.Spec.LogConfig)
+	for name, content := range logConf {
+		configMap.Data[name] = content
+	}
+	if coreSite := getDesiredHadoopCoreSite(flinkCluster); len(coreSite) > 0 {
+		configMap.Data[hadoopCoreSiteName] = coreSite
+	}
+
+	return configMap
+}
+
+// Gets the content of core-site.xml rendered from the Hadoop properties, empty
+// if the Hadoop config files are provided in a ConfigMap.
+func getDesiredHadoopCoreSite(flinkCluster *v1beta1.FlinkCluster) string {
+	var hadoopConfig = flinkCluster.Spec.HadoopConfig
+	if hadoopConfig == nil || len(hadoopConfig.ConfigMapName) > 0 {
+		return ""
+	}
+	return getHadoopCoreSite(hadoopConfig.Properties)
+}
+
+// Gets the content of flink-conf.yaml from the cluster spec.
+func getFlinkConf(flinkCluster *v1beta1.FlinkCluster) string {
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var flinkProperties = flinkCluster.Spec.FlinkProperties
+	var jmPorts = flinkCluster.Spec.JobManager.Ports
+	var tmPorts = flinkCluster.Spec.TaskManager.Ports
+	// Properties which should be provided from real deployed environment.
+	var flinkProps = map[string]string{
+		"jobmanager.rpc.address": getJobManagerServiceName(clusterName),
+		"jobmanager.rpc.port":    strconv.FormatInt(int64(*jmPorts.RPC), 10),
+		"blob.server.port":       strconv.FormatInt(int64(*jmPorts.Blob), 10),
+		"query.server.port":      strconv.FormatInt(int64(*jmPorts.Query), 10),
+		"rest.port":              strconv.FormatInt(int64(*jmPorts.UI), 10),
+		"taskmanager.rpc.port":   strconv.FormatInt(int64(*tmPorts.RPC), 10),
+	}
+	for k, v := range calFlinkHeapSize(flinkCluster) {
+		flinkProps[k] = v
+	}
+	for k, v := range calFlinkProcessSize(flinkCluster) {
+		flinkProps[k] = v
+	}
+	for k, v := range getShutdownProperties(&flinkCluster.Spec) {
+		flinkProps[k] = v
+	}
+	// Add custom Flink properties.
+	for k, v := range flinkProperties {
+		// Do not allow to override properties from real deployment.
+		if _, ok := flinkSysProps[k]; ok {
+			continue
+		}
+		flinkProps[k] = v
+	}
+	// HA services.
+	var highAvailability = flinkCluster.Spec.HighAvailability
+	if highAvailability != nil {
+		flinkProps["high-availability.storageDir"] = highAvailability.StorageDir
+		switch highAvailability.Mode {
+		case v1beta1.HighAvailabilityModeZooKeeper:
+			flinkProps["high-availability"] = "zookeeper"
+			flinkProps["high-availability.zookeeper.quorum"] =
+				highAvailability.ZooKeeperQuorum
+			if len(highAvailability.ZooKeeperRootPath) > 0 {
+				flinkProps["high-availability.zookeeper.path.root"] =
+					highAvailability.ZooKeeperRootPath
+			}
+			// Clusters with the same name in different namespaces may share
+			// the quorum.
+			flinkProps["high-availability.cluster-id"] = fmt.Sprintf(
+				"/%s/%s", flinkCluster.ObjectMeta.Namespace, clusterName)
+		default:
+			flinkProps["high-availability"] = kubernetesHAServicesFactory
+			flinkProps["kubernetes.cluster-id"] = clusterName
+			flinkProps["kubernetes.namespace"] = flinkCluster.ObjectMeta.Namespace
+		}
+	}
+	// Prometheus metric reporter.
+	var metrics = flinkCluster.Spec.Metrics
+	if metrics != nil && metrics.Prometheus != nil {
+		flinkProps["metrics.reporter.prom.class"] = prometheusReporterClass
+		flinkProps["metrics.reporter.prom.port"] =
+			strconv.FormatInt(int64(*metrics.Prometheus.Port), 10)
+	}
+	// Checkpointing of the job.
+	for k, v := range getCheckpointingProperties(flinkCluster.Spec.Job) {
+		flinkProps[k] = v
+	}
+	// State backend.
+	for k, v := range getStateBackendProperties(&flinkCluster.Spec) {
+		flinkProps[k] = v
+	}
+	// Internal TLS.
+	for k, v := range getInternalTLSProperties(flinkCluster) {
+		flinkProps[k] = v
+	}
+	// REST API TLS.
+	for k, v := range getRESTTLSProperties(flinkCluster) {
+		flinkProps[k] = v
+	}
+	// Kerberos login.
+	for k, v := range getKerberosProperties(flinkCluster) {
+		flinkProps[k] = v
+	}
+	// TaskManager GPUs.
+	for k, v := range getGPUProperties(flinkCluster.Spec.TaskManager.GPU) {
+		flinkProps[k] = v
+	}
+	// History Server.
+	for k, v := range getHistoryServerProperties(flinkCluster.Spec.HistoryServer) {
+		flinkProps[k] = v
+	}
+	return getFlinkProperties(flinkProps)
+}
+
+// Gets the Flink properties of the clean shutdown on SIGTERM, which must
+// complete within the shortest termination grace period of the JobManager and
+// TaskManagers before they are killed, empty if no grace period is specified.
+// They can be overridden with `flinkProperties`.
+func getShutdownProperties(
+	clusterSpec *v1beta1.FlinkClusterSpec) map[string]string {
+	var props = map[string]string{}
+	var gracePeriod *int64
+	for _, seconds := range []*int64{
+		clusterSpec.JobManager.TerminationGracePeriodSeconds,
+		clusterSpec.TaskManager.TerminationGracePeriodSeconds} {
+		if seconds != nil && (gracePeriod == nil || *seconds < *gracePeriod) {
+			gracePeriod = seconds
+		}
+	}
+	if gracePeriod == nil {
+		return props
+	}
+	var timeoutSeconds = *gracePeriod - shutdownTimeoutMarginSeconds
+	if timeoutSeconds < 1 {
+		timeoutSeconds = 1
+	}
+	props["cluster.services.shutdown-timeout"] =
+		strconv.FormatInt(timeoutSeconds*1000, 10)
+	return props
+}
+
+// Gets the Flink properties of the checkpointing of the job, empty if
+// checkpointing is not specified.
+func getCheckpointingProperties(jobSpec *v1beta1.JobSpec) map[string]string {
+	var props = map[string]string{}
+	if jobSpec == nil || jobSpec.Checkpointing == nil {
+		return props
+	}
+	var checkpointing = jobSpec.Checkpointing
+	props["execution.checkpointing.interval"] =
+		fmt.Sprintf("%ds", checkpointing.IntervalSeconds)
+	var mode = "EXACTLY_ONCE"
+	if checkpointing.Mode != nil &&
+		*checkpointing.Mode == v1beta1.CheckpointingModeAtLeastOnce {
+		mode = "AT_LEAST_ONCE"
+	}
+	props["execution.checkpointing.mode"] = mode
+	if checkpointing.TimeoutSeconds != nil {
+		props["execution.checkpointing.timeout"] =
+			fmt.Sprintf("%ds", *checkpointing.TimeoutSeconds)
+	}
+	if checkpointing.MinPauseSeconds != nil {
+		props["execution.checkpointing.min-pause"] =
+			fmt.Sprintf("%ds", *checkpointing.MinPauseSeconds)
+	}
+	if checkpointing.ExternalizedRetention != nil {
+		var retention = "DELETE_ON_CANCELLATION"
+		if *checkpointing.ExternalizedRetention ==
+			v1beta1.CheckpointRetentionRetainOnCancellation {
+			retention = "RETAIN_ON_CANCELLATION"
+		}
+		props["execution.checkpointing.externalized-checkpoint-retention"] = retention
+	}
+	if checkpointing.Unaligned != nil {
+		props["execution.checkpointing.unaligned"] =
+			strconv.FormatBool(*checkpointing.Unaligned)
+	}
+	return props
+}
+
+// Gets the Flink properties of the state backend, empty if the state backend is
+// not specified. The "hashmap" state backend of Flink 1.13+ is the
+// "filesystem" state backend of older versions, which is used unless the Flink
+// version is known to be 1.13+.
+func getStateBackendProperties(
+	clusterSpec *v1beta1.FlinkClusterSpec) map[string]string {
+	var props = map[string]string{}
+	var stateBackend = clusterSpec.StateBackend
+	if stateBackend == nil {
+		return props
+	}
+	var backendType = stateBackend.Type
+	if backendType == v1beta1.StateBackendTypeHashMap &&
+		!v1beta1.UsesHashMapStateBackendName(clusterSpec.FlinkVersion) {
+		backendType = "filesystem"
+	}
+	props["state.backend"] = backendType
+	if stateBackend.CheckpointDir != nil {
+		props["state.checkpoints.dir"] = *stateBackend.CheckpointDir
+	}
+	if stateBackend.SavepointDir != nil {
+		props["state.savepoints.dir"] = *stateBackend.SavepointDir
+	}
+	if stateBackend.Incremental != nil {
+		props["state.backend.incremental"] =
+			strconv.FormatBool(*stateBackend.Incremental)
+	}
+	if stateBackend.RocksDBLocalDir != nil {
+		props["state.backend.rocksdb.localdir"] =
+			stateBackend.RocksDBLocalDir.MountPath
+	}
+	return props
+}
+
+// Gets the Flink properties of the GPU external resource of the TaskManagers,
+// empty if the GPUs are not specified. The GPUs are discovered by the GPU
+// driver of the Flink plugin with the discovery script.
+func getGPUProperties(gpu *v1beta1.GPUSpec) map[string]string {
+	var props = map[string]string{}
+	if gpu == nil {
+		return props
+	}
+	props["external-resources"] = "gpu"
+	props["external-resource.gpu.amount"] =
+		strconv.FormatInt(int64(gpu.Amount), 10)
+	props["external-resource.gpu.driver-factory.class"] = gpuDriverFactoryClass
+	props["external-resource.gpu.param.discovery-script.path"] =
+		*gpu.DiscoveryScriptPath
+	if gpu.DiscoveryScriptArgs != nil {
+		props["external-resource.gpu.param.discovery-script.args"] =
+			*gpu.DiscoveryScriptArgs
+	}
+	return props
+}
+
+// Gets the resources of the TaskManager container, with the GPUs added to the
+// limits if they are specified. The requests of extended resources default to
+// the limits.
+func getTaskManagerResources(
+	tmSpec *v1beta1.TaskManagerSpec) corev1.ResourceRequirements {
+	var resources = *tmSpec.Resources.DeepCopy()
+	if tmSpec.GPU == nil {
+		return resources
+	}
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+	resources.Limits[corev1.ResourceName(*tmSpec.GPU.ResourceName)] =
+		*resource.NewQuantity(int64(tmSpec.GPU.Amount), resource.DecimalSI)
+	return resources
+}
+
+// Converts the RocksDB local directory of the state backend to the volume and
+// volume mount of the TaskManager container. The volume is nil when the claim
+// of a volume claim template of the StatefulSet is mounted.
+func convertRocksDBLocalDir(stateBackend *v1beta1.StateBackendSpec) (
+	*corev1.Volume, *corev1.VolumeMount) {
+	if stateBackend == nil || stateBackend.RocksDBLocalDir == nil {
+		return nil, nil
+	}
+	var localDir = stateBackend.RocksDBLocalDir
+	if len(localDir.VolumeClaimTemplate) > 0 {
+		return nil, &corev1.VolumeMount{
+			Name:      localDir.VolumeClaimTemplate,
+			MountPath: localDir.MountPath,
+		}
+	}
+	var volume = &corev1.Volume{
+		Name: rocksDBLocalDirVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir:              localDir.EmptyDir,
+			PersistentVolumeClaim: localDir.PersistentVolumeClaim,
+		},
+	}
+	var mount = &corev1.VolumeMount{
+		Name:      rocksDBLocalDirVolume,
+		MountPath: localDir.MountPath,
+	}
+	return volume, mount
+}
+
+// Gets the container port of the Prometheus metric reporter, nil if it is not
+// enabled.
+func getMetricsPort(flinkCluster *v1beta1.FlinkCluster) *corev1.ContainerPort {
+	var metrics = flinkCluster.Spec.Metrics
+	if metrics == nil || metrics.Prometheus == nil {
+		return nil
+	}
+	return &corev1.ContainerPort{
+		Name: "metrics", ContainerPort: *metrics.Prometheus.Port}
+}
+
+// Gets the Flink properties of the History Server, the JobManager uploads the
+// archives of the completed jobs to the directory which the History Server
+// fetches them from.
+func getHistoryServerProperties(
+	historyServer *v1beta1.HistoryServerSpec) map[string]string {
+	var props = map[string]string{}
+	if historyServer == nil {
+		return props
+	}
+	props["jobmanager.archive.fs.dir"] = historyServer.ArchiveDir
+	props["historyserver.archive.fs.dir"] = historyServer.ArchiveDir
+	props["historyserver.web.address"] = "0.0.0.0"
+	props["historyserver.web.port"] =
+		strconv.FormatInt(int64(*historyServer.Port), 10)
+	if historyServer.RefreshIntervalSeconds != nil {
+		props["historyserver.archive.fs.refresh-interval"] = strconv.FormatInt(
+			int64(*historyServer.RefreshIntervalSeconds)*1000, 10)
+	}
+	return props
+}
+
+// Gets the desired PodMonitor which scrapes the metrics of the JobManager and
+// TaskManager pods. It is an unstructured object, so that the operator does
+// not depend on the Prometheus Operator.
+func getDesiredPodMonitor(
+	flinkCluster *v1beta1.FlinkCluster) *unstructured.Unstructured {
+	var metrics = flinkCluster.Spec.Metrics
+	if metrics == nil || metrics.Prometheus == nil ||
+		metrics.Prometheus.PodMonitor == nil ||
+		shouldCleanup(flinkCluster, "PodMonitor") {
+		return nil
+	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var podMonitorSpec = metrics.Prometheus.PodMonitor
+	var labels = map[string]string{"cluster": clusterName, "app": "flink"}
+	var endpoint = map[string]interface{}{"port": "metrics"}
+	if podMonitorSpec.Interval != nil {
+		endpoint["interval"] = *podMonitorSpec.Interval
+	}
+	var podMonitor = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{
+						"cluster": clusterName,
+						"app":     "flink",
+					},
+				},
+				"podMetricsEndpoints": []interface{}{endpoint},
+			},
+		},
+	}
+	podMonitor.SetGroupVersionKind(podMonitorGVK)
+	podMonitor.SetNamespace(flinkCluster.ObjectMeta.Namespace)
+	podMonitor.SetName(getPodMonitorName(clusterName))
+	podMonitor.SetOwnerReferences(
+		[]metav1.OwnerReference{toOwnerReference(flinkCluster)})
+	podMonitor.SetLabels(mergeStringMaps(podMonitorSpec.Labels, labels))
+	return podMonitor
+}
+
+// Gets the GroupVersionKind of the PodGroup of a batch scheduler.
+func getPodGroupGVK(
+	batchScheduler *v1beta1.BatchSchedulerSpec) schema.GroupVersionKind {
+	if batchScheduler.Name == v1beta1.BatchSchedulerSchedulerPlugins {
+		return schedulerPluginsPodGroupGVK
+	}
+	return volcanoPodGroupGVK
+}
+
+// Gets the desired PodGroup of the batch scheduler, whose minimum member is
+// the number of the JobManager and TaskManager pods, so that they are
+// scheduled all or nothing. It is an unstructured object, so that the operator
+// does not depend on the batch scheduler.
+func getDesiredPodGroup(
+	flinkCluster *v1beta1.FlinkCluster) *unstructured.Unstructured {
+	var batchScheduler = flinkCluster.Spec.BatchScheduler
+	if batchScheduler == nil || shouldCleanup(flinkCluster, "PodGroup") {
+		return nil
+	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var minMember int64 = 1
+	if flinkCluster.Spec.JobManager.Replicas != nil {
+		minMember = int64(*flinkCluster.Spec.JobManager.Replicas)
+	}
+	if !shouldCleanup(flinkCluster, "TaskManagerDeployment") &&
+		flinkCluster.Spec.TaskManager.Replicas != nil {
+		minMember += int64(*flinkCluster.Spec.TaskManager.Replicas)
+	}
+	var spec = map[string]interface{}{"minMember": minMember}
+	if batchScheduler.Queue != nil {
+		spec["queue"] = *batchScheduler.Queue
+	}
+	var podGroup = &unstructured.Unstructured{
+		Object: map[string]interface{}{"spec": spec},
+	}
+	podGroup.SetGroupVersionKind(getPodGroupGVK(batchScheduler))
+	podGroup.SetNamespace(flinkCluster.ObjectMeta.Namespace)
+	podGroup.SetName(getPodGroupName(clusterName))
+	podGroup.SetOwnerReferences(
+		[]metav1.OwnerReference{toOwnerReference(flinkCluster)})
+	podGroup.SetLabels(map[string]string{"cluster": clusterName, "app": "flink"})
+	return podGroup
+}
+
+// Assigns the pods of the template to the batch scheduler and its PodGroup,
+// if the batch scheduler is specified.
+func setBatchScheduler(
+	flinkCluster *v1beta1.FlinkCluster, podTemplate *corev1.PodTemplateSpec) {
+	var batchScheduler = flinkCluster.Spec.BatchScheduler
+	if batchScheduler == nil {
+		return
+	}
+	var podGroupName = getPodGroupName(flinkCluster.ObjectMeta.Name)
+	switch batchScheduler.Name {
+	case v1beta1.BatchSchedulerSchedulerPlugins:
+		podTemplate.Spec.SchedulerName = "scheduler-plugins-scheduler"
+		podTemplate.ObjectMeta.Labels = mergeStringMaps(
+			podTemplate.ObjectMeta.Labels,
+			map[string]string{schedulerPluginsPodGroupLabel: podGroupName})
+	default:
+		podTemplate.Spec.SchedulerName = "volcano"
+		podTemplate.ObjectMeta.Annotations = mergeStringMaps(
+			podTemplate.ObjectMeta.Annotations,
+			map[string]string{volcanoPodGroupAnnotation: podGroupName})
+	}
+	if batchScheduler.SchedulerName != nil {
+		podTemplate.Spec.SchedulerName = *batchScheduler.SchedulerName
+	}
+}
+
+// Gets the pod labels of the History Server, which are also the selector of
+// its deployment and service.
+func getHistoryServerLabels(clusterName string) map[string]string {
+	return map[string]string{
+		"cluster":   clusterName,
+		"app":       "flink",
+		"component": "historyserver",
+	}
+}
+
+// Gets the desired History Server deployment, nil if the History Server is not
+// specified. It is not deleted by the cleanup policy, so that the completed
+// jobs can still be inspected after the cluster is stopped.
+func getDesiredHistoryServerDeployment(
+	flinkCluster *v1beta1.FlinkCluster) *appsv1.Deployment {
+	var historyServer = flinkCluster.Spec.HistoryServer
+	if historyServer == nil {
+		return nil
+	}
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var clusterSpec = flinkCluster.Spec
+	var imageSpec = clusterSpec.Image
+	var labels = getHistoryServerLabels(clusterName)
+	var confVol, confMount = convertFlinkConfig(clusterName)
+	var volumes = []corev1.Volume{*confVol}
+	var volumeMounts = []corev1.VolumeMount{*confMount}
+	var envVars []corev1.EnvVar
+
+	// Hadoop config.
+	var hcVolume, hcMount, hcEnv = convertHadoopConfig(
+		clusterName, clusterSpec.HadoopConfig)
+	if hcVolume != nil {
+		volumes = append(volumes, *hcVolume)
+	}
+	if hcMount != nil {
+		volumeMounts = append(volumeMounts, *hcMount)
+	}
+	if hcEnv != nil {
+		envVars = append(envVars, *hcEnv)
+	}
+
+	// GCP service account config.
+	var saVolume, saMount, saEnv = convertGCPConfig(clusterSpec.GCPConfig)
+	if saVolume != nil {
+		volumes = append(volumes, *saVolume)
 	}
 	if saMount != nil {
 		volumeMounts = append(volumeMounts, *saMount)
@@ -469,112 +1845,314 @@ func getDesiredTaskManagerDeployment(
 	if saEnv != nil {
 		envVars = append(envVars, *saEnv)
 	}
-	envVars = append(envVars, flinkCluster.Spec.EnvVars...)
 
-	var containers = []corev1.Container{corev1.Container{
-		Name:            "taskmanager",
-		Image:           imageSpec.Name,
-		ImagePullPolicy: imageSpec.PullPolicy,
-		Args:            []string{"taskmanager"},
-		Ports: []corev1.ContainerPort{
-			dataPort, rpcPort, queryPort},
-		LivenessProbe:  &livenessProbe,
-		ReadinessProbe: &readinessProbe,
-		Resources:      taskManagerSpec.Resources,
-		Env:            envVars,
-		VolumeMounts:   volumeMounts,
-	}}
-	containers = append(containers, taskManagerSpec.Sidecars...)
-	var podSpec = corev1.PodSpec{
-		Containers:       containers,
-		Volumes:          volumes,
-		NodeSelector:     taskManagerSpec.NodeSelector,
-		ImagePullSecrets: imageSpec.PullSecrets,
+	// Built-in file system plugins to access the archive directory.
+	if pluginsEnv := getPluginsEnv(&clusterSpec); pluginsEnv != nil {
+		envVars = append(envVars, *pluginsEnv)
+	}
+
+	// Kerberos keytab and krb5.conf, e.g., for an archive directory on a
+	// secured HDFS.
+	var kerberosVolumes, kerberosMounts = convertKerberos(flinkCluster)
+	volumes = append(volumes, kerberosVolumes...)
+	volumeMounts = append(volumeMounts, kerberosMounts...)
+	envVars = append(envVars, clusterSpec.EnvVars...)
+
+	var probePort = intstr.FromInt(int(*historyServer.Port))
+	var readinessProbe = corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: probePort},
+		},
+		TimeoutSeconds:      10,
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       5,
+		FailureThreshold:    60,
+	}
+	var livenessProbe = corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: probePort},
+		},
+		TimeoutSeconds:      10,
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       60,
+		FailureThreshold:    5,
+	}
+	var replicas int32 = 1
+	var historyServerDeployment = &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       flinkCluster.ObjectMeta.Namespace,
+			Name:            getHistoryServerName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{toOwnerReference(flinkCluster)},
+			Labels:          labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: getFlinkConfAnnotations(flinkCluster),
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            "historyserver",
+						Image:           imageSpec.Name,
+						ImagePullPolicy: imageSpec.PullPolicy,
+						Args:            []string{"history-server"},
+						Ports: []corev1.ContainerPort{
+							{Name: "ui", ContainerPort: *historyServer.Port}},
+						LivenessProbe:  &livenessProbe,
+						ReadinessProbe: &readinessProbe,
+						Resources:      historyServer.Resources,
+						Env:            envVars,
+						VolumeMounts:   volumeMounts,
+					}},
+					Volumes:                      volumes,
+					ImagePullSecrets:             imageSpec.PullSecrets,
+					ServiceAccountName:           getServiceAccountName(flinkCluster),
+					AutomountServiceAccountToken: clusterSpec.AutomountServiceAccountToken,
+				},
+			},
+		},
+	}
+	return historyServerDeployment
+}
+
+// Gets the desired History Server service, nil if the History Server is not
+// specified. The type is ClusterIP unless overridden by the service spec.
+func getDesiredHistoryServerService(
+	flinkCluster *v1beta1.FlinkCluster) *corev1.Service {
+	var historyServer = flinkCluster.Spec.HistoryServer
+	if historyServer == nil {
+		return nil
+	}
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var labels = getHistoryServerLabels(clusterName)
+	var historyServerService = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: flinkCluster.ObjectMeta.Namespace,
+			Name:      getHistoryServerName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(flinkCluster)},
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []corev1.ServicePort{{
+				Name:       "ui",
+				Port:       *historyServer.Port,
+				TargetPort: intstr.FromString("ui"),
+			}},
+		},
+	}
+	setServiceSpec(historyServerService, historyServer.Service)
+	return historyServerService
+}
+
+// Gets the desired History Server ingress, nil if the History Server or its
+// ingress is not specified.
+func getDesiredHistoryServerIngress(
+	flinkCluster *v1beta1.FlinkCluster) *extensionsv1beta1.Ingress {
+	var historyServer = flinkCluster.Spec.HistoryServer
+	if historyServer == nil || historyServer.Ingress == nil {
+		return nil
+	}
+
+	var clusterName = flinkCluster.ObjectMeta.Name
+	return getDesiredIngress(
+		flinkCluster,
+		historyServer.Ingress,
+		getHistoryServerName(clusterName),
+		getHistoryServerName(clusterName),
+		getHistoryServerLabels(clusterName))
+}
+
+// Checks whether the Kubernetes HA services are enabled, which require the
+// permissions to manage ConfigMaps.
+func isKubernetesHAEnabled(flinkCluster *v1beta1.FlinkCluster) bool {
+	var highAvailability = flinkCluster.Spec.HighAvailability
+	return highAvailability != nil &&
+		highAvailability.Mode != v1beta1.HighAvailabilityModeZooKeeper
+}
+
+// Gets the service account of the JobManager and TaskManager pods, the one
+// specified in the cluster spec, or the one created by the operator when the
+// Kubernetes HA services are enabled.
+func getServiceAccountName(flinkCluster *v1beta1.FlinkCluster) string {
+	if flinkCluster.Spec.ServiceAccountName != nil {
+		return *flinkCluster.Spec.ServiceAccountName
+	}
+	if !isKubernetesHAEnabled(flinkCluster) {
+		return ""
+	}
+	return getHAName(flinkCluster.ObjectMeta.Name)
+}
+
+// Gets the desired ServiceAccount of the JobManager and TaskManager pods when
+// the Kubernetes HA services are enabled and no ServiceAccount is specified
+// in the cluster spec.
+func getDesiredHAServiceAccount(
+	flinkCluster *v1beta1.FlinkCluster) *corev1.ServiceAccount {
+	if !isKubernetesHAEnabled(flinkCluster) ||
+		flinkCluster.Spec.ServiceAccountName != nil ||
+		shouldCleanup(flinkCluster, "HighAvailability") {
+		return nil
+	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       flinkCluster.ObjectMeta.Namespace,
+			Name:            getHAName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{toOwnerReference(flinkCluster)},
+			Labels:          map[string]string{"cluster": clusterName, "app": "flink"},
+		},
+	}
+}
+
+// Gets the desired Role which allows to manage the HA ConfigMaps.
+func getDesiredHARole(flinkCluster *v1beta1.FlinkCluster) *rbacv1.Role {
+	if !isKubernetesHAEnabled(flinkCluster) ||
+		shouldCleanup(flinkCluster, "HighAvailability") {
+		return nil
+	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       flinkCluster.ObjectMeta.Namespace,
+			Name:            getHAName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{toOwnerReference(flinkCluster)},
+			Labels:          map[string]string{"cluster": clusterName, "app": "flink"},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"configmaps"},
+				Verbs: []string{
+					"get", "list", "watch", "create", "update", "patch", "delete"},
+			},
+		},
+	}
+}
+
+// Gets the desired RoleBinding which grants the HA Role to the ServiceAccount
+// of the JobManager and TaskManager pods.
+func getDesiredHARoleBinding(
+	flinkCluster *v1beta1.FlinkCluster) *rbacv1.RoleBinding {
+	if !isKubernetesHAEnabled(flinkCluster) ||
+		shouldCleanup(flinkCluster, "HighAvailability") {
+		return nil
 	}
-	var taskManagerDeployment = &appsv1.Deployment{
+	var clusterNamespace = flinkCluster.ObjectMeta.Namespace
+	var clusterName = flinkCluster.ObjectMeta.Name
+	return &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: clusterNamespace,
-			Name:      taskManagerDeploymentName,
-			OwnerReferences: []metav1.OwnerReference{
-				toOwnerReference(flinkCluster)},
-			Labels: labels,
+			Namespace:       clusterNamespace,
+			Name:            getHAName(clusterName),
+			OwnerReferences: []metav1.OwnerReference{toOwnerReference(flinkCluster)},
+			Labels:          map[string]string{"cluster": clusterName, "app": "flink"},
 		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &taskManagerSpec.Replicas,
-			Selector: &metav1.LabelSelector{MatchLabels: labels},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
-				},
-				Spec: podSpec,
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     getHAName(clusterName),
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      getServiceAccountName(flinkCluster),
+				Namespace: clusterNamespace,
 			},
 		},
 	}
-	return taskManagerDeployment
 }
 
-// Gets the desired configMap.
-func getDesiredConfigMap(
-	flinkCluster *v1beta1.FlinkCluster) *corev1.ConfigMap {
+func isIstioEnabled(flinkCluster *v1beta1.FlinkCluster) bool {
+	var serviceMesh = flinkCluster.Spec.ServiceMesh
+	return serviceMesh != nil && serviceMesh.IstioEnabled
+}
 
-	if shouldCleanup(flinkCluster, "ConfigMap") {
+// Gets the pod annotations of the Istio sidecar, nil if Istio is not enabled.
+// Flink starts after the sidecar is ready, and the given Flink ports of the
+// pod, the metrics port and the Flink ports of the other pods are excluded
+// from the interception of the sidecar. The job submitter, which has no Flink
+// ports, only talks to the REST API through the sidecar.
+func getIstioAnnotations(
+	flinkCluster *v1beta1.FlinkCluster, inboundPorts []int32) map[string]string {
+	if !isIstioEnabled(flinkCluster) {
 		return nil
 	}
-
-	var clusterNamespace = flinkCluster.ObjectMeta.Namespace
-	var clusterName = flinkCluster.ObjectMeta.Name
-	var flinkProperties = flinkCluster.Spec.FlinkProperties
-	var jmPorts = flinkCluster.Spec.JobManager.Ports
-	var tmPorts = flinkCluster.Spec.TaskManager.Ports
-	var configMapName = getConfigMapName(clusterName)
-	var labels = map[string]string{
-		"cluster": clusterName,
-		"app":     "flink",
+	var annotations = map[string]string{
+		istioProxyConfigAnnotation: istioHoldApplicationConfig,
 	}
-	var flinkHeapSize = calFlinkHeapSize(flinkCluster)
-	// Properties which should be provided from real deployed environment.
-	var flinkProps = map[string]string{
-		"jobmanager.rpc.address": getJobManagerServiceName(clusterName),
-		"jobmanager.rpc.port":    strconv.FormatInt(int64(*jmPorts.RPC), 10),
-		"blob.server.port":       strconv.FormatInt(int64(*jmPorts.Blob), 10),
-		"query.server.port":      strconv.FormatInt(int64(*jmPorts.Query), 10),
-		"rest.port":              strconv.FormatInt(int64(*jmPorts.UI), 10),
-		"taskmanager.rpc.port":   strconv.FormatInt(int64(*tmPorts.RPC), 10),
+	if len(inboundPorts) == 0 {
+		return annotations
 	}
-	if flinkHeapSize["jobmanager.heap.size"] != "" {
-		flinkProps["jobmanager.heap.size"] = flinkHeapSize["jobmanager.heap.size"]
+	if metricsPort := getMetricsPort(flinkCluster); metricsPort != nil {
+		inboundPorts = append(inboundPorts, metricsPort.ContainerPort)
 	}
-	if flinkHeapSize["taskmanager.heap.size"] != "" {
-		flinkProps["taskmanager.heap.size"] = flinkHeapSize["taskmanager.heap.size"]
+	var jmPorts = flinkCluster.Spec.JobManager.Ports
+	var tmPorts = flinkCluster.Spec.TaskManager.Ports
+	var outboundPorts = []int32{
+		*jmPorts.RPC, *jmPorts.Blob, *jmPorts.Query,
+		*tmPorts.RPC, *tmPorts.Data, *tmPorts.Query,
 	}
-	// Add custom Flink properties.
-	for k, v := range flinkProperties {
-		// Do not allow to override properties from real deployment.
-		if _, ok := flinkSysProps[k]; ok {
-			continue
-		}
-		flinkProps[k] = v
+	annotations[istioExcludeInboundPortsAnnotation] = joinPorts(inboundPorts)
+	annotations[istioExcludeOutboundPortsAnnotation] = joinPorts(outboundPorts)
+	return annotations
+}
+
+// Gets the URLs which the job submitter sends a POST request to when it exits
+// to stop the sidecars of its pod, including the Istio sidecar.
+func getSidecarShutdownURLs(flinkCluster *v1beta1.FlinkCluster) []string {
+	var shutdownURLs []string
+	if isIstioEnabled(flinkCluster) {
+		shutdownURLs = append(shutdownURLs, istioQuitURL)
 	}
-	// TODO: Provide logging options: log4j-console.properties and log4j.properties
-	var log4jPropName = "log4j-console.properties"
-	var logbackXMLName = "logback-console.xml"
-	var configMap = &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: clusterNamespace,
-			Name:      configMapName,
-			OwnerReferences: []metav1.OwnerReference{
-				toOwnerReference(flinkCluster)},
-			Labels: labels,
-		},
-		Data: map[string]string{
-			"flink-conf.yaml": getFlinkProperties(flinkProps),
-			log4jPropName:     getLogConf()[log4jPropName],
-			logbackXMLName:    getLogConf()[logbackXMLName],
-			"submit-job.sh":   submitJobScript,
-		},
+	return append(shutdownURLs, flinkCluster.Spec.Job.SidecarShutdownURLs...)
+}
+
+// Joins the distinct ports with commas, e.g., "6123,6124".
+func joinPorts(ports []int32) string {
+	var values []string
+	var joined = map[int32]bool{}
+	for _, port := range ports {
+		if !joined[port] {
+			joined[port] = true
+			values = append(values, strconv.FormatInt(int64(port), 10))
+		}
 	}
+	return strings.Join(values, ",")
+}
 
-	return configMap
+// Gets the pod annotations which record the hash of flink-conf.yaml, the log
+// config files and the Hadoop properties in the spec, and the restart nonce,
+// so that pods are restarted when Flink properties, the log config, the Hadoop
+// properties or the restart nonce change.
+func getFlinkConfAnnotations(
+	flinkCluster *v1beta1.FlinkCluster) map[string]string {
+	var conf = getFlinkConf(flinkCluster)
+	var logConfig = flinkCluster.Spec.LogConfig
+	var names []string
+	for name := range logConfig {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conf += "\n" + name + "\n" + logConfig[name]
+	}
+	if coreSite := getDesiredHadoopCoreSite(flinkCluster); len(coreSite) > 0 {
+		conf += "\n" + hadoopCoreSiteName + "\n" + coreSite
+	}
+	var hash = sha256.Sum256([]byte(conf))
+	var annotations = map[string]string{
+		flinkConfHashAnnotation: fmt.Sprintf("%x", hash),
+	}
+	if len(flinkCluster.Spec.RestartNonce) > 0 {
+		annotations[restartNonceAnnotation] = flinkCluster.Spec.RestartNonce
+	}
+	return annotations
 }
 
 // Gets the desired job spec from a cluster spec.
@@ -586,11 +2164,24 @@ func getDesiredJob(
 		return nil
 	}
 
-	var controlStatus = flinkCluster.Status.Control
+	// The job is submitted by the operator through the Flink REST API, there
+	// is no job submitter.
+	if isRestAPISubmission(jobSpec) {
+		return nil
+	}
+
 	// We need to watch whether job is cancelled already if jobSpec.CancelRequested is deprecated
-	if (flinkCluster.Status.Components.Job != nil && flinkCluster.Status.Components.Job.State == v1beta1.JobStateCancelled) ||
-		(jobSpec.CancelRequested != nil && *jobSpec.CancelRequested) ||
-		(controlStatus != nil && controlStatus.Name == v1beta1.ControlNameJobCancel && controlStatus.State == v1beta1.ControlStateProgressing) {
+	if isJobCancelRequested(flinkCluster) {
+		return nil
+	}
+
+	// The runs of a scheduled job are started by the reconciler at the
+	// scheduled times, and the job submitter of a run is deleted after it
+	// finishes.
+	var jobStatus = flinkCluster.Status.Components.Job
+	if isScheduledJob(jobSpec) &&
+		(jobStatus == nil || len(jobStatus.LastScheduleTime) == 0 ||
+			isJobTerminated(jobSpec, jobStatus)) {
 		return nil
 	}
 
@@ -600,9 +2191,8 @@ func getDesiredJob(
 	var clusterNamespace = flinkCluster.ObjectMeta.Namespace
 	var clusterName = flinkCluster.ObjectMeta.Name
 	var jobName = getJobName(clusterName)
-	var jobManagerServiceName = clusterName + "-jobmanager"
 	var jobManagerAddress = fmt.Sprintf(
-		"%s:%d", jobManagerServiceName, *jobManagerSpec.Ports.UI)
+		"%s:%d", getFlinkAPIServiceName(flinkCluster), *jobManagerSpec.Ports.UI)
 	var labels = map[string]string{
 		"cluster": clusterName,
 		"app":     "flink",
@@ -613,8 +2203,8 @@ func getDesiredJob(
 		jobArgs = append(jobArgs, "--class", *jobSpec.ClassName)
 	}
 
-	var jobStatus = flinkCluster.Status.Components.Job
-	var fromSavepoint = convertFromSavepoint(jobSpec, jobStatus)
+	var fromSavepoint = convertFromSavepoint(
+		jobSpec, jobStatus, flinkCluster.Status.Savepoint)
 	if fromSavepoint != nil {
 		jobArgs = append(jobArgs, "--fromSavepoint", *fromSavepoint)
 	}
@@ -634,27 +2224,41 @@ func getDesiredJob(
 	jobArgs = append(jobArgs, "--detached")
 
 	var envVars = []corev1.EnvVar{}
-
-	// If the JAR file is remote, put the URI in the env variable
-	// FLINK_JOB_JAR_URI and rewrite the JAR path to a local path. The entrypoint
-	// script of the container will download it before submitting it to Flink.
-	var jarPath = jobSpec.JarFile
-	if strings.Contains(jobSpec.JarFile, "://") {
-		var parts = strings.Split(jobSpec.JarFile, "/")
-		jarPath = "/opt/flink/job/" + parts[len(parts)-1]
-		envVars = append(envVars, corev1.EnvVar{
-			Name:  "FLINK_JOB_JAR_URI",
-			Value: jobSpec.JarFile,
-		})
-	}
-	jobArgs = append(jobArgs, jarPath)
-	jobArgs = append(jobArgs, jobSpec.Args...)
-
 	var volumes []corev1.Volume
 	var volumeMounts []corev1.VolumeMount
 	volumes = append(volumes, jobSpec.Volumes...)
 	volumeMounts = append(volumeMounts, jobSpec.VolumeMounts...)
 
+	// If the JAR file is remote, rewrite the JAR path to a local path in a
+	// shared volume, the JAR downloader init container downloads it there
+	// before the job is submitted.
+	var jarPath = jobSpec.JarFile
+	var jarVolume, jarMount = convertRemoteJobJar(jobSpec)
+	if jarVolume != nil {
+		jarPath = getRemoteJobJarPath(jobSpec.JarFile)
+		volumes = append(volumes, *jarVolume)
+		volumeMounts = append(volumeMounts, *jarMount)
+	}
+	// The statements of a SQL job are passed to the submit job script through
+	// an env var, which runs them with the SQL client instead of `flink run`.
+	var sqlEnv = convertSQLJobEnv(jobSpec)
+	// The job ID is fixed (Flink 1.12+), so the job submitter which runs
+	// again, e.g., after the operator restarted, finds the job it submitted
+	// instead of submitting a second copy. The statements of a SQL job may
+	// run as multiple jobs, which can't share the ID.
+	var submitterJobID = ""
+	if sqlEnv == nil && v1beta1.SupportsFixedJobID(clusterSpec.FlinkVersion) {
+		submitterJobID = getSubmitterJobID(flinkCluster, fromSavepoint)
+		jobArgs = append(
+			jobArgs, "-D$internal.pipeline.job-id="+submitterJobID)
+	}
+	if jobSpec.PythonFile != nil {
+		jobArgs = append(jobArgs, convertPythonJobArgs(jobSpec)...)
+	} else if sqlEnv == nil {
+		jobArgs = append(jobArgs, jarPath)
+	}
+	jobArgs = append(jobArgs, jobSpec.Args...)
+
 	// Submit job script config.
 	var sbsVolume *corev1.Volume
 	var sbsMount *corev1.VolumeMount
@@ -663,7 +2267,8 @@ func getDesiredJob(
 	volumeMounts = append(volumeMounts, *sbsMount)
 
 	// Hadoop config.
-	var hcVolume, hcMount, hcEnv = convertHadoopConfig(clusterSpec.HadoopConfig)
+	var hcVolume, hcMount, hcEnv = convertHadoopConfig(
+		clusterName, clusterSpec.HadoopConfig)
 	if hcVolume != nil {
 		volumes = append(volumes, *hcVolume)
 	}
@@ -686,23 +2291,119 @@ func getDesiredJob(
 		envVars = append(envVars, *saEnv)
 	}
 
+	// Built-in file system and GPU plugins.
+	if pluginsEnv := getPluginsEnv(&clusterSpec); pluginsEnv != nil {
+		envVars = append(envVars, *pluginsEnv)
+	}
+
+	if sqlEnv != nil {
+		envVars = append(envVars, *sqlEnv)
+	}
+
+	// The job submitter doesn't mount the Flink config, the submit job script
+	// adds the truststore of the REST API TLS and its password to a copy of
+	// the config of the image.
+	var restVolume, restMount, restEnv, _ = convertRESTTLS(flinkCluster)
+	if restVolume != nil {
+		volumes = append(volumes, *restVolume)
+		volumeMounts = append(volumeMounts, *restMount)
+		envVars = append(envVars, *restEnv, corev1.EnvVar{
+			Name:  restTLSTruststoreEnv,
+			Value: getRESTTLSTruststorePath(flinkCluster),
+		})
+	}
+
+	// The submit job script also adds the Kerberos login to the config.
+	var kerberosVolumes, kerberosMounts = convertKerberos(flinkCluster)
+	volumes = append(volumes, kerberosVolumes...)
+	volumeMounts = append(volumeMounts, kerberosMounts...)
+	envVars = append(envVars, getKerberosSubmitterEnv(flinkCluster)...)
+
+	// The Flink jobs of the previous runs of a scheduled job are not
+	// considered by the submit job script.
+	if isScheduledJob(jobSpec) {
+		envVars = append(
+			envVars, corev1.EnvVar{Name: "FLINK_JOB_SCHEDULED", Value: "true"})
+	}
+	if len(submitterJobID) > 0 {
+		envVars = append(
+			envVars, corev1.EnvVar{Name: "FLINK_JOB_ID", Value: submitterJobID})
+	}
+	// The submit job script waits for the files, e.g., secrets injected by a
+	// sidecar, before it submits the job.
+	if len(jobSpec.WaitForFiles) > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "FLINK_JOB_WAIT_FOR_FILES",
+			Value: strings.Join(jobSpec.WaitForFiles, "\n"),
+		})
+	}
+	// The submit job script stops the sidecars when it exits, otherwise the
+	// pod of the job never completes.
+	if shutdownURLs := getSidecarShutdownURLs(flinkCluster); len(shutdownURLs) > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "FLINK_SIDECAR_SHUTDOWN_URLS",
+			Value: strings.Join(shutdownURLs, " "),
+		})
+	}
+
 	envVars = append(envVars, flinkCluster.Spec.EnvVars...)
+	envVars = append(envVars, jobSpec.Env...)
+
+	var initContainers []corev1.Container
+	if jarVolume != nil {
+		var downloaderEnvVars []corev1.EnvVar
+		var downloaderMounts = []corev1.VolumeMount{*jarMount}
+		if saEnv != nil {
+			downloaderEnvVars = append(downloaderEnvVars, *saEnv)
+		}
+		if saMount != nil {
+			downloaderMounts = append(downloaderMounts, *saMount)
+		}
+		downloaderEnvVars = append(downloaderEnvVars, flinkCluster.Spec.EnvVars...)
+		downloaderEnvVars = append(downloaderEnvVars, jobSpec.Env...)
+		initContainers = append(
+			initContainers,
+			getJarDownloaderContainer(
+				jobSpec, jarPath, downloaderEnvVars, downloaderMounts))
+	}
+	initContainers = append(
+		initContainers,
+		convertInitContainers(jobSpec.InitContainers, jobSpec.VolumeMounts)...)
 
 	var podSpec = corev1.PodSpec{
-		InitContainers: convertJobInitContainers(jobSpec),
+		InitContainers: initContainers,
 		Containers: []corev1.Container{
 			corev1.Container{
 				Name:            "main",
 				Image:           imageSpec.Name,
 				ImagePullPolicy: imageSpec.PullPolicy,
 				Args:            jobArgs,
+				Resources:       jobSpec.Resources,
 				Env:             envVars,
+				EnvFrom:         jobSpec.EnvFrom,
 				VolumeMounts:    volumeMounts,
+				SecurityContext: jobSpec.ContainerSecurityContext,
+				// The last lines of the logs are kept in the status when the
+				// submission fails without a termination message.
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 			},
 		},
-		RestartPolicy:    corev1.RestartPolicyNever,
-		Volumes:          volumes,
-		ImagePullSecrets: imageSpec.PullSecrets,
+		RestartPolicy:                corev1.RestartPolicyNever,
+		Volumes:                      volumes,
+		NodeSelector:                 jobSpec.NodeSelector,
+		Tolerations:                  jobSpec.Tolerations,
+		Affinity:                     jobSpec.Affinity,
+		SecurityContext:              jobSpec.SecurityContext,
+		ImagePullSecrets:             imageSpec.PullSecrets,
+		AutomountServiceAccountToken: clusterSpec.AutomountServiceAccountToken,
+	}
+	if jobSpec.ServiceAccountName != nil {
+		podSpec.ServiceAccountName = *jobSpec.ServiceAccountName
+	} else if clusterSpec.ServiceAccountName != nil {
+		podSpec.ServiceAccountName = *clusterSpec.ServiceAccountName
+	}
+	if jobSpec.PriorityClassName != nil {
+		podSpec.PriorityClassName = *jobSpec.PriorityClassName
 	}
 
 	// Disable the retry mechanism of k8s Job, all retires should be initiated
@@ -720,11 +2421,19 @@ func getDesiredJob(
 			OwnerReferences: []metav1.OwnerReference{
 				toOwnerReference(flinkCluster)},
 			Labels: labels,
+			Annotations: map[string]string{
+				jobSpecHashAnnotation: getJobSpecHash(flinkCluster),
+			},
 		},
 		Spec: batchv1.JobSpec{
 			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{Labels: labels},
-				Spec:       podSpec,
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: mergeStringMaps(jobSpec.PodLabels, labels),
+					Annotations: mergeStringMaps(
+						getIstioAnnotations(flinkCluster, nil),
+						jobSpec.PodAnnotations),
+				},
+				Spec: podSpec,
 			},
 			BackoffLimit: &backoffLimit,
 		},
@@ -733,36 +2442,246 @@ func getDesiredJob(
 }
 
 func convertFromSavepoint(
-	jobSpec *v1beta1.JobSpec, jobStatus *v1beta1.JobStatus) *string {
-	if shouldRestartJob(jobSpec.RestartPolicy, jobStatus) {
+	jobSpec *v1beta1.JobSpec,
+	jobStatus *v1beta1.JobStatus,
+	savepointStatus *v1beta1.SavepointStatus) *string {
+	// Resubmit the rolled back job from the savepoint taken before the
+	// upgrade.
+	if jobStatus != nil && jobStatus.Rollback != nil &&
+		jobStatus.Rollback.State == v1beta1.JobRollbackStateInProgress &&
+		len(jobStatus.Rollback.Savepoint) > 0 {
+		return &jobStatus.Rollback.Savepoint
+	}
+	if shouldRestartJob(jobSpec, jobStatus) {
+		var restoreLocation = getRestoreLocation(jobSpec, jobStatus, time.Now())
+		return &restoreLocation
+	}
+	// Resubmit the upgraded job from the savepoint taken before the update.
+	if savepointStatus != nil &&
+		savepointStatus.TriggerReason == v1beta1.SavepointTriggerReasonUpdate &&
+		savepointStatus.State == v1beta1.SavepointStateSucceeded &&
+		jobStatus != nil && len(jobStatus.SavepointLocation) > 0 {
 		return &jobStatus.SavepointLocation
 	}
 	return jobSpec.FromSavepoint
 }
 
-func convertJobInitContainers(jobSpec *v1beta1.JobSpec) []corev1.Container {
-	var initContainers = []corev1.Container{}
-	// Add jobSpec level volume mounts to each init container if there is no
-	// conflict.
-	for _, initContainer := range jobSpec.InitContainers {
-		for _, jobMount := range jobSpec.VolumeMounts {
-			var conflit = false
+// Gets the request to run the job through the Flink REST API. The job ID is
+// derived from the cluster, the job spec, the savepoint to restore from and
+// the previous job, so a retried submission of the same job gets the same ID.
+func getDesiredJarRunRequest(
+	flinkCluster *v1beta1.FlinkCluster) flinkclient.JarRunRequest {
+	var jobSpec = flinkCluster.Spec.Job
+	var jobStatus = flinkCluster.Status.Components.Job
+	var request = flinkclient.JarRunRequest{
+		ProgramArgsList: jobSpec.Args,
+	}
+	if jobSpec.ClassName != nil {
+		request.EntryClass = *jobSpec.ClassName
+	}
+	if jobSpec.Parallelism != nil {
+		request.Parallelism = *jobSpec.Parallelism
+	}
+	var fromSavepoint = convertFromSavepoint(
+		jobSpec, jobStatus, flinkCluster.Status.Savepoint)
+	if fromSavepoint != nil {
+		request.SavepointPath = *fromSavepoint
+	}
+	if jobSpec.AllowNonRestoredState != nil {
+		request.AllowNonRestoredState = *jobSpec.AllowNonRestoredState
+	}
+
+	var previousJobID = ""
+	if jobStatus != nil {
+		previousJobID = jobStatus.ID
+	}
+	request.JobID = getDeterministicJobID(
+		string(flinkCluster.UID),
+		getJobSpecHash(flinkCluster),
+		request.SavepointPath,
+		previousJobID)
+	return request
+}
+
+// Gets the ID of the job submitted by the job submitter. It is derived from
+// the cluster UID and generation, the previous job, the savepoint to restore
+// from and the scheduled run, so it changes when the job is restarted or
+// upgraded, but not when the same submitter runs again.
+func getSubmitterJobID(
+	flinkCluster *v1beta1.FlinkCluster, fromSavepoint *string) string {
+	var jobStatus = flinkCluster.Status.Components.Job
+	var previousJobID = ""
+	var lastScheduleTime = ""
+	if jobStatus != nil {
+		previousJobID = jobStatus.ID
+		lastScheduleTime = jobStatus.LastScheduleTime
+	}
+	var savepointPath = ""
+	if fromSavepoint != nil {
+		savepointPath = *fromSavepoint
+	}
+	return getDeterministicJobID(
+		string(flinkCluster.UID),
+		fmt.Sprint(flinkCluster.Generation),
+		savepointPath,
+		previousJobID,
+		lastScheduleTime)
+}
+
+// Gets a Flink job ID, which is 16 bytes in hex, from the hash of the fields.
+func getDeterministicJobID(fields ...string) string {
+	var hash = sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return fmt.Sprintf("%x", hash[:16])
+}
+
+// Gets the hash of the cluster properties which require the job to be
+// upgraded when they change: image, flink-conf.yaml, the restart nonce and the
+// job jarFile, jarSha256, Python files, args and parallelism.
+func getJobSpecHash(flinkCluster *v1beta1.FlinkCluster) string {
+	var jobSpec = flinkCluster.Spec.Job
+	var parallelism = ""
+	if jobSpec.Parallelism != nil {
+		parallelism = fmt.Sprint(*jobSpec.Parallelism)
+	}
+	var fields = []string{
+		flinkCluster.Spec.Image.Name,
+		jobSpec.JarFile,
+		fmt.Sprintf("%q", jobSpec.Args),
+		parallelism,
+		getFlinkConf(flinkCluster),
+	}
+	// Appended only when specified to keep the hash of the existing jobs.
+	if jobSpec.JarSha256 != nil {
+		fields = append(fields, *jobSpec.JarSha256)
+	}
+	if jobSpec.PythonFile != nil {
+		fields = append(
+			fields,
+			*jobSpec.PythonFile,
+			fmt.Sprintf("%q", jobSpec.PythonFiles))
+		if jobSpec.PythonRequirements != nil {
+			fields = append(fields, *jobSpec.PythonRequirements)
+		}
+	}
+	if jobSpec.SQL != nil {
+		fields = append(fields, *jobSpec.SQL)
+	}
+	if jobSpec.SQLConfigMap != nil {
+		fields = append(
+			fields, jobSpec.SQLConfigMap.Name, jobSpec.SQLConfigMap.Key)
+	}
+	if len(flinkCluster.Spec.RestartNonce) > 0 {
+		fields = append(fields, "restartNonce="+flinkCluster.Spec.RestartNonce)
+	}
+	var hash = sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return fmt.Sprintf("%x", hash)
+}
+
+// Converts the init containers of a component. The component level volume
+// mounts are added to each init container if there is no conflict, so that
+// the artifacts downloaded by the init containers land in the volumes shared
+// with the main container.
+func convertInitContainers(
+	specInitContainers []corev1.Container,
+	volumeMounts []corev1.VolumeMount) []corev1.Container {
+	var initContainers []corev1.Container
+	for _, initContainer := range specInitContainers {
+		var containerMounts = append(
+			[]corev1.VolumeMount{}, initContainer.VolumeMounts...)
+		for _, specMount := range volumeMounts {
+			var conflict = false
 			for _, mount := range initContainer.VolumeMounts {
-				if jobMount.MountPath == mount.MountPath {
-					conflit = true
+				if specMount.MountPath == mount.MountPath {
+					conflict = true
 					break
 				}
 			}
-			if !conflit {
-				initContainer.VolumeMounts =
-					append(initContainer.VolumeMounts, jobMount)
+			if !conflict {
+				containerMounts = append(containerMounts, specMount)
 			}
 		}
+		initContainer.VolumeMounts = containerMounts
 		initContainers = append(initContainers, initContainer)
 	}
 	return initContainers
 }
 
+// Merges the user provided pod template with the labels, annotations and pod
+// spec generated by the operator. Operator-managed fields take precedence over
+// the template, containers, volumes, tolerations and image pull secrets of the
+// template are appended to the generated ones.
+func mergePodTemplate(
+	podTemplate *corev1.PodTemplateSpec,
+	labels map[string]string,
+	annotations map[string]string,
+	podSpec corev1.PodSpec) corev1.PodTemplateSpec {
+	if podTemplate == nil {
+		return corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: podSpec,
+		}
+	}
+
+	var merged = podTemplate.DeepCopy()
+	merged.ObjectMeta.Labels = mergeStringMaps(merged.Labels, labels)
+	merged.ObjectMeta.Annotations =
+		mergeStringMaps(merged.Annotations, annotations)
+	merged.Spec.InitContainers =
+		append(podSpec.InitContainers, merged.Spec.InitContainers...)
+	merged.Spec.Containers =
+		append(podSpec.Containers, merged.Spec.Containers...)
+	merged.Spec.Volumes = append(podSpec.Volumes, merged.Spec.Volumes...)
+	merged.Spec.ImagePullSecrets =
+		append(podSpec.ImagePullSecrets, merged.Spec.ImagePullSecrets...)
+	merged.Spec.NodeSelector =
+		mergeStringMaps(merged.Spec.NodeSelector, podSpec.NodeSelector)
+	merged.Spec.Tolerations =
+		append(podSpec.Tolerations, merged.Spec.Tolerations...)
+	if len(podSpec.ServiceAccountName) > 0 {
+		merged.Spec.ServiceAccountName = podSpec.ServiceAccountName
+	}
+	if podSpec.AutomountServiceAccountToken != nil {
+		merged.Spec.AutomountServiceAccountToken =
+			podSpec.AutomountServiceAccountToken
+	}
+	// The affinity of the template takes precedence over the default one.
+	if merged.Spec.Affinity == nil {
+		merged.Spec.Affinity = podSpec.Affinity
+	}
+	if podSpec.SecurityContext != nil {
+		merged.Spec.SecurityContext = podSpec.SecurityContext
+	}
+	if len(podSpec.PriorityClassName) > 0 {
+		merged.Spec.PriorityClassName = podSpec.PriorityClassName
+	}
+	// The grace period of the template takes precedence over the one of the
+	// component spec.
+	if merged.Spec.TerminationGracePeriodSeconds == nil {
+		merged.Spec.TerminationGracePeriodSeconds =
+			podSpec.TerminationGracePeriodSeconds
+	}
+	return *merged
+}
+
+// Merges two string maps, values in `overrides` take precedence.
+func mergeStringMaps(
+	base map[string]string, overrides map[string]string) map[string]string {
+	if len(base) == 0 {
+		return overrides
+	}
+	var merged = make(map[string]string)
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
 // Converts the FlinkCluster as owner reference for its child resources.
 func toOwnerReference(
 	flinkCluster *v1beta1.FlinkCluster) metav1.OwnerReference {
@@ -792,6 +2711,27 @@ func getFlinkProperties(properties map[string]string) string {
 	return builder.String()
 }
 
+// Gets the environment variables of the Flink properties from Secrets, and
+// the dynamic properties which reference them, so that Kubernetes substitutes
+// the values when the container starts and they are not written to the
+// ConfigMap.
+func convertFlinkPropertiesFrom(
+	sources []v1beta1.FlinkPropertySource) ([]corev1.EnvVar, []string) {
+	var envVars []corev1.EnvVar
+	var args []string
+	for i, source := range sources {
+		var envName = fmt.Sprintf("%s%d", flinkPropertyEnvPrefix, i)
+		envVars = append(envVars, corev1.EnvVar{
+			Name: envName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: source.ValueFrom.SecretKeyRef,
+			},
+		})
+		args = append(args, "-D", source.Name+"=$("+envName+")")
+	}
+	return envVars, args
+}
+
 var jobManagerIngressHostRegex = regexp.MustCompile("{{\\s*[$]clusterName\\s*}}")
 
 func getJobManagerIngressHost(ingressHostFormat string, clusterName string) string {
@@ -803,33 +2743,57 @@ func getJobManagerIngressHost(ingressHostFormat string, clusterName string) stri
 // policy. Always return false for session cluster.
 func shouldCleanup(
 	cluster *v1beta1.FlinkCluster, component string) bool {
+	switch getCleanupAction(cluster) {
+	case v1beta1.CleanupActionDeleteCluster:
+		return true
+	case v1beta1.CleanupActionDeleteTaskManager:
+		return component == "TaskManagerDeployment"
+	}
+	return false
+}
+
+// Gets the cleanup action to take for the job state, empty if the job is not
+// finished.
+func getCleanupAction(cluster *v1beta1.FlinkCluster) v1beta1.CleanupAction {
 	var jobStatus = cluster.Status.Components.Job
 
-	// Session cluster.
+	// The cluster of a scheduled job is kept between the runs, only the
+	// TaskManagers are deleted if requested.
+	if isScheduledJob(cluster.Spec.Job) {
+		var deleteTaskManagers = cluster.Spec.Job.DeleteTaskManagersBetweenRuns
+		if deleteTaskManagers != nil && *deleteTaskManagers &&
+			(jobStatus == nil ||
+				isJobTerminated(cluster.Spec.Job, jobStatus)) {
+			return v1beta1.CleanupActionDeleteTaskManager
+		}
+		return ""
+	}
+
+	// Session cluster, which is cleaned up after its idle timeout.
 	if jobStatus == nil {
-		return false
+		if cluster.Status.IdleTimedOut {
+			return cluster.Spec.IdleTimeoutAction
+		}
+		return ""
+	}
+
+	// The cleanup is delayed for the TTL of the finished job, until the
+	// cluster is stopped by the updater.
+	if cluster.Spec.Job.TTLSecondsAfterFinished != nil &&
+		(cluster.Status.State == v1beta1.ClusterStateRunning ||
+			cluster.Status.State == v1beta1.ClusterStateReconciling) {
+		return ""
 	}
 
-	var action v1beta1.CleanupAction
 	switch jobStatus.State {
 	case v1beta1.JobStateSucceeded:
-		action = cluster.Spec.Job.CleanupPolicy.AfterJobSucceeds
+		return cluster.Spec.Job.CleanupPolicy.AfterJobSucceeds
 	case v1beta1.JobStateFailed:
-		action = cluster.Spec.Job.CleanupPolicy.AfterJobFails
+		return cluster.Spec.Job.CleanupPolicy.AfterJobFails
 	case v1beta1.JobStateCancelled:
-		action = cluster.Spec.Job.CleanupPolicy.AfterJobCancelled
-	default:
-		return false
-	}
-
-	switch action {
-	case v1beta1.CleanupActionDeleteCluster:
-		return true
-	case v1beta1.CleanupActionDeleteTaskManager:
-		return component == "TaskManagerDeployment"
+		return cluster.Spec.Job.CleanupPolicy.AfterJobCancelled
 	}
-
-	return false
+	return ""
 }
 
 func calFlinkHeapSize(cluster *v1beta1.FlinkCluster) map[string]string {
@@ -837,9 +2801,10 @@ func calFlinkHeapSize(cluster *v1beta1.FlinkCluster) map[string]string {
 		return nil
 	}
 	var flinkHeapSize = make(map[string]string)
+	var flinkVersion = cluster.Spec.FlinkVersion
 	var jmMemoryLimitByte = cluster.Spec.JobManager.Resources.Limits.Memory().Value()
 	var tmMemLimitByte = cluster.Spec.TaskManager.Resources.Limits.Memory().Value()
-	if jmMemoryLimitByte > 0 {
+	if jmMemoryLimitByte > 0 && !v1beta1.UsesJobManagerProcessMemory(flinkVersion) {
 		jmMemoryOffHeapMinByte := cluster.Spec.JobManager.MemoryOffHeapMin.Value()
 		jmMemoryOffHeapRatio := int64(*cluster.Spec.JobManager.MemoryOffHeapRatio)
 		heapSizeMB := calHeapSize(
@@ -850,7 +2815,7 @@ func calFlinkHeapSize(cluster *v1beta1.FlinkCluster) map[string]string {
 			flinkHeapSize["jobmanager.heap.size"] = strconv.FormatInt(heapSizeMB, 10) + "m"
 		}
 	}
-	if tmMemLimitByte > 0 {
+	if tmMemLimitByte > 0 && !v1beta1.UsesTaskManagerProcessMemory(flinkVersion) {
 		tmMemoryOffHeapMinByte := cluster.Spec.TaskManager.MemoryOffHeapMin.Value()
 		tmMemoryOffHeapRatio := int64(*cluster.Spec.TaskManager.MemoryOffHeapRatio)
 		heapSizeMB := calHeapSize(
@@ -864,6 +2829,37 @@ func calFlinkHeapSize(cluster *v1beta1.FlinkCluster) map[string]string {
 	return flinkHeapSize
 }
 
+// Gets the process sizes of the components which are configured with the
+// unified memory model of Flink 1.10+, the whole memory limit of the container
+// is given to the Flink process, which divides it into heap, off-heap, managed
+// memory and JVM overhead.
+func calFlinkProcessSize(cluster *v1beta1.FlinkCluster) map[string]string {
+	var flinkProcessSize = make(map[string]string)
+	var flinkVersion = cluster.Spec.FlinkVersion
+	var jmMemoryLimitByte = cluster.Spec.JobManager.Resources.Limits.Memory().Value()
+	var tmMemLimitByte = cluster.Spec.TaskManager.Resources.Limits.Memory().Value()
+	if v1beta1.UsesJobManagerProcessMemory(flinkVersion) {
+		if processSizeMB := calProcessSize(jmMemoryLimitByte); processSizeMB > 0 {
+			flinkProcessSize["jobmanager.memory.process.size"] =
+				strconv.FormatInt(processSizeMB, 10) + "m"
+		}
+	}
+	if v1beta1.UsesTaskManagerProcessMemory(flinkVersion) {
+		if processSizeMB := calProcessSize(tmMemLimitByte); processSizeMB > 0 {
+			flinkProcessSize["taskmanager.memory.process.size"] =
+				strconv.FormatInt(processSizeMB, 10) + "m"
+		}
+	}
+	return flinkProcessSize
+}
+
+// Calculate process size in MB, the "m" unit of Flink memory sizes is
+// mebibytes, the size is rounded down so that the process fits in the memory
+// limit.
+func calProcessSize(memSize int64) int64 {
+	return memSize / (1024 * 1024)
+}
+
 // Converts memory value to the format of divisor and returns ceiling of the value.
 func convertResourceMemoryToInt64(memory resource.Quantity, divisor resource.Quantity) int64 {
 	return int64(math.Ceil(float64(memory.Value()) / float64(divisor.Value())))
@@ -885,6 +2881,20 @@ func calHeapSize(memSize int64, offHeapMin int64, offHeapRatio int64) int64 {
 	return heapSizeMB
 }
 
+// Gets the probe of a JobManager or TaskManager container, the default
+// handler is used when the specified probe doesn't have one, e.g., to only
+// tune the thresholds.
+func getProbe(probe *corev1.Probe, defaultProbe corev1.Probe) *corev1.Probe {
+	if probe == nil {
+		return &defaultProbe
+	}
+	var result = probe.DeepCopy()
+	if result.Exec == nil && result.HTTPGet == nil && result.TCPSocket == nil {
+		result.Handler = defaultProbe.Handler
+	}
+	return result
+}
+
 func convertFlinkConfig(clusterName string) (*corev1.Volume, *corev1.VolumeMount) {
 	var confVol *corev1.Volume
 	var confMount *corev1.VolumeMount
@@ -926,20 +2936,152 @@ func convertSubmitJobScript(clusterName string) (*corev1.Volume, *corev1.VolumeM
 	return confVol, confMount
 }
 
-func convertHadoopConfig(hadoopConfig *v1beta1.HadoopConfig) (
+// Converts the Python files of a PyFlink job to the `flink run` options.
+func convertPythonJobArgs(jobSpec *v1beta1.JobSpec) []string {
+	var args = []string{"--python", *jobSpec.PythonFile}
+	if len(jobSpec.PythonFiles) > 0 {
+		args = append(args, "--pyFiles", strings.Join(jobSpec.PythonFiles, ","))
+	}
+	if jobSpec.PythonRequirements != nil {
+		args = append(args, "--pyRequirements", *jobSpec.PythonRequirements)
+	}
+	return args
+}
+
+// Converts the statements of a SQL job to the env var read by the submit job
+// script, returns nil if the job is not a SQL job.
+func convertSQLJobEnv(jobSpec *v1beta1.JobSpec) *corev1.EnvVar {
+	if jobSpec.SQL != nil {
+		return &corev1.EnvVar{Name: "FLINK_JOB_SQL", Value: *jobSpec.SQL}
+	}
+	if jobSpec.SQLConfigMap != nil {
+		return &corev1.EnvVar{
+			Name: "FLINK_JOB_SQL",
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: jobSpec.SQLConfigMap.DeepCopy(),
+			},
+		}
+	}
+	return nil
+}
+
+// Converts the remote JAR file of the job to the shared volume where the JAR
+// downloader init container downloads it, returns nil if the JAR file is
+// local.
+func convertRemoteJobJar(jobSpec *v1beta1.JobSpec) (
+	*corev1.Volume, *corev1.VolumeMount) {
+	if !strings.Contains(jobSpec.JarFile, "://") {
+		return nil, nil
+	}
+	var jarVolume = &corev1.Volume{
+		Name: jobJarVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+	var jarMount = &corev1.VolumeMount{
+		Name:      jobJarVolume,
+		MountPath: jobJarPath,
+	}
+	return jarVolume, jarMount
+}
+
+// Gets the local path of a remote JAR file in the shared volume.
+func getRemoteJobJarPath(jarURI string) string {
+	var fileName = "job.jar"
+	if u, err := url.Parse(jarURI); err == nil {
+		if name := path.Base(u.Path); name != "." && name != "/" {
+			fileName = name
+		}
+	}
+	return jobJarPath + "/" + fileName
+}
+
+// Gets the init container which downloads the remote JAR file of the job to
+// the shared volume and verifies its checksum if `jarSha256` is specified. The
+// image is chosen by the URI scheme: gs://, s3:// or http(s)://, unless
+// `jarDownloaderImage` is specified.
+func getJarDownloaderContainer(
+	jobSpec *v1beta1.JobSpec,
+	jarPath string,
+	envVars []corev1.EnvVar,
+	volumeMounts []corev1.VolumeMount) corev1.Container {
+	var image string
+	var download string
+	switch {
+	case strings.HasPrefix(jobSpec.JarFile, "gs://"):
+		image = gcsJarDownloaderImage
+		download = `gsutil cp "${JAR_URI}" "${JAR_PATH}"`
+	case strings.HasPrefix(jobSpec.JarFile, "s3://"):
+		image = s3JarDownloaderImage
+		download = `aws s3 cp "${JAR_URI}" "${JAR_PATH}"`
+	default:
+		image = httpJarDownloaderImage
+		download = `curl -fsSL -o "${JAR_PATH}" "${JAR_URI}"`
+	}
+	if jobSpec.JarDownloaderImage != nil {
+		image = *jobSpec.JarDownloaderImage
+	}
+	var script = strings.Join([]string{
+		"set -e",
+		`echo "Downloading job JAR ${JAR_URI} to ${JAR_PATH}"`,
+		download,
+		`if [ -n "${JAR_SHA256}" ]; then`,
+		`  echo "${JAR_SHA256}  ${JAR_PATH}" | sha256sum -c -`,
+		"fi",
+	}, "\n")
+	var jarSha256 = ""
+	if jobSpec.JarSha256 != nil {
+		jarSha256 = strings.ToLower(*jobSpec.JarSha256)
+	}
+	var containerEnvVars = []corev1.EnvVar{
+		{Name: "JAR_URI", Value: jobSpec.JarFile},
+		{Name: "JAR_PATH", Value: jarPath},
+		{Name: "JAR_SHA256", Value: jarSha256},
+	}
+	containerEnvVars = append(containerEnvVars, envVars...)
+	return corev1.Container{
+		Name:                     jarDownloaderContainer,
+		Image:                    image,
+		Command:                  []string{"sh", "-c", script},
+		Env:                      containerEnvVars,
+		EnvFrom:                  jobSpec.EnvFrom,
+		VolumeMounts:             volumeMounts,
+		SecurityContext:          jobSpec.ContainerSecurityContext,
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}
+}
+
+// Converts the Hadoop config to the volume, volume mount and HADOOP_CONF_DIR
+// env var of the Flink containers. The volume is the ConfigMap specified in
+// the config, or the core-site.xml rendered from the Hadoop properties into
+// the Flink ConfigMap.
+func convertHadoopConfig(
+	clusterName string, hadoopConfig *v1beta1.HadoopConfig) (
 	*corev1.Volume, *corev1.VolumeMount, *corev1.EnvVar) {
 	if hadoopConfig == nil {
 		return nil, nil, nil
 	}
 
+	var configMapSource = &corev1.ConfigMapVolumeSource{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: hadoopConfig.ConfigMapName,
+		},
+	}
+	if len(hadoopConfig.ConfigMapName) == 0 {
+		configMapSource = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: getConfigMapName(clusterName),
+			},
+			Items: []corev1.KeyToPath{
+				{Key: hadoopCoreSiteName, Path: hadoopCoreSiteName},
+			},
+		}
+	}
 	var volume = &corev1.Volume{
 		Name: hadoopConfigVolume,
 		VolumeSource: corev1.VolumeSource{
-			ConfigMap: &corev1.ConfigMapVolumeSource{
-				LocalObjectReference: corev1.LocalObjectReference{
-					Name: hadoopConfig.ConfigMapName,
-				},
-			},
+			ConfigMap: configMapSource,
 		},
 	}
 	var mount = &corev1.VolumeMount{
@@ -954,8 +3096,53 @@ func convertHadoopConfig(hadoopConfig *v1beta1.HadoopConfig) (
 	return volume, mount, env
 }
 
+// Renders Hadoop properties into the content of core-site.xml.
+func getHadoopCoreSite(properties map[string]string) string {
+	var names []string
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buffer bytes.Buffer
+	buffer.WriteString("<?xml version=\"1.0\"?>\n<configuration>\n")
+	for _, name := range names {
+		buffer.WriteString("  <property>\n    <name>")
+		xml.EscapeText(&buffer, []byte(name))
+		buffer.WriteString("</name>\n    <value>")
+		xml.EscapeText(&buffer, []byte(properties[name]))
+		buffer.WriteString("</value>\n  </property>\n")
+	}
+	buffer.WriteString("</configuration>\n")
+	return buffer.String()
+}
+
+// Gets the env var which enables the built-in file system plugins and the GPU
+// plugin of the Flink image, the entrypoint of the image links the plugin JARs
+// of the Flink version from the opt directory into the plugins directory.
+func getPluginsEnv(clusterSpec *v1beta1.FlinkClusterSpec) *corev1.EnvVar {
+	var jars []string
+	if clusterSpec.Storage != nil {
+		for _, plugin := range clusterSpec.Storage.Plugins {
+			jars = append(jars, fmt.Sprintf(
+				"flink-%s-%s.jar", plugin, clusterSpec.FlinkVersion))
+		}
+	}
+	if clusterSpec.TaskManager.GPU != nil {
+		jars = append(jars, fmt.Sprintf(
+			"external-resource-gpu/flink-external-resource-gpu-%s.jar",
+			clusterSpec.FlinkVersion))
+	}
+	if len(jars) == 0 {
+		return nil
+	}
+	return &corev1.EnvVar{
+		Name:  "ENABLE_BUILT_IN_PLUGINS",
+		Value: strings.Join(jars, ";"),
+	}
+}
+
 func convertGCPConfig(gcpConfig *v1beta1.GCPConfig) (*corev1.Volume, *corev1.VolumeMount, *corev1.EnvVar) {
-	if gcpConfig == nil {
+	if gcpConfig == nil || gcpConfig.ServiceAccount == nil {
 		return nil, nil, nil
 	}
 
@@ -28,12 +28,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestGetDesiredClusterState(t *testing.T) {
+	var tmReplicas int32 = 42
 	var controller = true
 	var blockOwnerDeletion = false
 	var parallelism int32 = 2
@@ -54,8 +56,9 @@ func TestGetDesiredClusterState(t *testing.T) {
 	var jobBackoffLimit int32 = 0
 	var jmReadinessProbe = corev1.Probe{
 		Handler: corev1.Handler{
-			TCPSocket: &corev1.TCPSocketAction{
-				Port: intstr.FromInt(int(jmRPCPort)),
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/config",
+				Port: intstr.FromInt(int(jmUIPort)),
 			},
 		},
 		TimeoutSeconds:      10,
@@ -147,6 +150,12 @@ func TestGetDesiredClusterState(t *testing.T) {
 					},
 					UseTLS: &useTLS,
 				},
+				Service: &v1beta1.JobManagerServiceSpec{
+					Annotations: map[string]string{
+						"networking.gke.io/internal-load-balancer-allow-global-access": "true",
+					},
+					LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+				},
 				Ports: v1beta1.JobManagerPorts{
 					RPC:   &jmRPCPort,
 					Blob:  &jmBlobPort,
@@ -167,7 +176,7 @@ func TestGetDesiredClusterState(t *testing.T) {
 				MemoryOffHeapMin:   memoryOffHeapMin,
 			},
 			TaskManager: v1beta1.TaskManagerSpec{
-				Replicas: 42,
+				Replicas: &tmReplicas,
 				Ports: v1beta1.TaskManagerPorts{
 					Data:  &tmDataPort,
 					RPC:   &tmRPCPort,
@@ -216,6 +225,7 @@ func TestGetDesiredClusterState(t *testing.T) {
 
 	// Run.
 	var desiredState = getDesiredClusterState(cluster, time.Now())
+	var flinkConfHash = "736ff8f8e4c212463c3255ed6c0f1e10ccb150d142a0b1b370f8819042224f08"
 
 	// Verify.
 
@@ -254,6 +264,9 @@ func TestGetDesiredClusterState(t *testing.T) {
 						"cluster":   "flinkjobcluster-sample",
 						"component": "jobmanager",
 					},
+					Annotations: map[string]string{
+						"flinkoperator.k8s.io/flink-conf-hash": flinkConfHash,
+					},
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
@@ -381,7 +394,8 @@ func TestGetDesiredClusterState(t *testing.T) {
 				"component": "jobmanager",
 			},
 			Annotations: map[string]string{
-				"cloud.google.com/load-balancer-type": "Internal",
+				"cloud.google.com/load-balancer-type":                          "Internal",
+				"networking.gke.io/internal-load-balancer-allow-global-access": "true",
 			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -406,6 +420,7 @@ func TestGetDesiredClusterState(t *testing.T) {
 				{Name: "query", Port: 6125, TargetPort: intstr.FromString("query")},
 				{Name: "ui", Port: 8081, TargetPort: intstr.FromString("ui")},
 			},
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
 		},
 	}
 	assert.Assert(t, desiredState.JmService != nil)
@@ -501,6 +516,9 @@ func TestGetDesiredClusterState(t *testing.T) {
 						"cluster":   "flinkjobcluster-sample",
 						"component": "taskmanager",
 					},
+					Annotations: map[string]string{
+						"flinkoperator.k8s.io/flink-conf-hash": flinkConfHash,
+					},
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
@@ -628,6 +646,9 @@ func TestGetDesiredClusterState(t *testing.T) {
 			Namespace: "default",
 			Labels: map[string]string{
 				"app": "flink", "cluster": "flinkjobcluster-sample"},
+			Annotations: map[string]string{
+				"flinkoperator.k8s.io/job-spec-hash": "2359dacfd43e3ef31f64c5ab1fcb02590f4adc00374ceda45ffcdbfcfb5d429e",
+			},
 			OwnerReferences: []metav1.OwnerReference{
 				{APIVersion: "flinkoperator.k8s.io/v1beta1",
 					Kind:               "FlinkCluster",
@@ -700,6 +721,7 @@ func TestGetDesiredClusterState(t *testing.T) {
 									ReadOnly:  true,
 								},
 							},
+							TerminationMessagePolicy: v1.TerminationMessageFallbackToLogsOnError,
 						},
 					},
 					RestartPolicy: v1beta1.JobRestartPolicyNever,
@@ -792,72 +814,2217 @@ taskmanager.rpc.port: 6122
 		expectedConfigMap)
 }
 
-func TestCalFlinkHeapSize(t *testing.T) {
-	var memoryOffHeapRatio int32 = 25
-	var memoryOffHeapMin = resource.MustParse("600M")
+func TestMergePodTemplate(t *testing.T) {
+	var runAsUser int64 = 9999
+	var templateRunAsUser int64 = 1000
+	var labels = map[string]string{
+		"app":       "flink",
+		"cluster":   "mycluster",
+		"component": "taskmanager",
+	}
+	var annotations = map[string]string{
+		"flinkoperator.k8s.io/flink-conf-hash": "abc",
+	}
+	var podSpec = corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "downloader", Image: "curl"}},
+		Containers:     []corev1.Container{{Name: "taskmanager", Image: "flink"}},
+		Volumes:        []corev1.Volume{{Name: "flink-config-volume"}},
+		NodeSelector:   map[string]string{"pool": "flink"},
+		Tolerations: []corev1.Toleration{
+			{Key: "dedicated", Operator: corev1.TolerationOpExists},
+		},
+		SecurityContext: &corev1.PodSecurityContext{RunAsUser: &runAsUser},
+	}
 
-	// Case 1: Heap sizes are computed from memoryOffHeapMin or memoryOffHeapRatio
-	cluster := &v1beta1.FlinkCluster{
+	// Without a pod template.
+	var merged = mergePodTemplate(nil, labels, annotations, podSpec)
+	assert.DeepEqual(
+		t,
+		merged,
+		corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: podSpec,
+		})
+
+	// With a pod template.
+	var podTemplate = &corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "mycluster",
+			Labels: map[string]string{
+				"component": "xxx",
+				"team":      "data",
+			},
+			Annotations: map[string]string{
+				"prometheus.io/scrape": "true",
+			},
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "wait", Image: "busybox"}},
+			Containers:     []corev1.Container{{Name: "logger", Image: "fluentd"}},
+			Volumes:        []corev1.Volume{{Name: "log-volume"}},
+			NodeSelector: map[string]string{
+				"pool": "xxx",
+				"zone": "us-central1-a",
+			},
+			Tolerations: []corev1.Toleration{
+				{Key: "gpu", Operator: corev1.TolerationOpExists},
+			},
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsUser: &templateRunAsUser,
+			},
+			PriorityClassName: "high-priority",
+		},
+	}
+	merged = mergePodTemplate(podTemplate, labels, annotations, podSpec)
+	assert.DeepEqual(
+		t,
+		merged,
+		corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					"app":       "flink",
+					"cluster":   "mycluster",
+					"component": "taskmanager",
+					"team":      "data",
+				},
+				Annotations: map[string]string{
+					"flinkoperator.k8s.io/flink-conf-hash": "abc",
+					"prometheus.io/scrape":                 "true",
+				},
+			},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "downloader", Image: "curl"},
+					{Name: "wait", Image: "busybox"},
+				},
+				Containers: []corev1.Container{
+					{Name: "taskmanager", Image: "flink"},
+					{Name: "logger", Image: "fluentd"},
+				},
+				Volumes: []corev1.Volume{
+					{Name: "flink-config-volume"},
+					{Name: "log-volume"},
+				},
+				NodeSelector: map[string]string{
+					"pool": "flink",
+					"zone": "us-central1-a",
+				},
+				Tolerations: []corev1.Toleration{
+					{Key: "dedicated", Operator: corev1.TolerationOpExists},
+					{Key: "gpu", Operator: corev1.TolerationOpExists},
+				},
+				SecurityContext:   &corev1.PodSecurityContext{RunAsUser: &runAsUser},
+				PriorityClassName: "high-priority",
+			},
+		})
+
+	// The termination grace period of the component is kept unless the pod
+	// template sets it.
+	var gracePeriod int64 = 300
+	var templateGracePeriod int64 = 60
+	podSpec.TerminationGracePeriodSeconds = &gracePeriod
+	merged = mergePodTemplate(podTemplate, labels, annotations, podSpec)
+	assert.Equal(t, *merged.Spec.TerminationGracePeriodSeconds, gracePeriod)
+	podTemplate.Spec.TerminationGracePeriodSeconds = &templateGracePeriod
+	merged = mergePodTemplate(podTemplate, labels, annotations, podSpec)
+	assert.Equal(t, *merged.Spec.TerminationGracePeriodSeconds, templateGracePeriod)
+}
+
+func TestConvertInitContainers(t *testing.T) {
+	var volumeMounts = []corev1.VolumeMount{
+		{Name: "cache-volume", MountPath: "/cache"},
+		{Name: "conf-volume", MountPath: "/conf"},
+	}
+	var initContainers = []corev1.Container{
+		{
+			Name:  "downloader",
+			Image: "curl",
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "other-conf-volume", MountPath: "/conf"},
+			},
+		},
+		{Name: "wait", Image: "busybox"},
+	}
+
+	assert.Assert(t, convertInitContainers(nil, volumeMounts) == nil)
+	assert.DeepEqual(
+		t,
+		convertInitContainers(initContainers, volumeMounts),
+		[]corev1.Container{
+			{
+				Name:  "downloader",
+				Image: "curl",
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "other-conf-volume", MountPath: "/conf"},
+					{Name: "cache-volume", MountPath: "/cache"},
+				},
+			},
+			{
+				Name:         "wait",
+				Image:        "busybox",
+				VolumeMounts: volumeMounts,
+			},
+		})
+	// The spec is not modified.
+	assert.Equal(t, len(initContainers[0].VolumeMounts), 1)
+}
+
+func TestGetJarDownloaderContainer(t *testing.T) {
+	var jarSha256 = "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"
+	var readOnlyRootFilesystem = true
+	var jobSpec = &v1beta1.JobSpec{
+		JarFile:   "gs://my-bucket/my-job.jar",
+		JarSha256: &jarSha256,
+		ContainerSecurityContext: &corev1.SecurityContext{
+			ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
+		},
+	}
+	var jarVolume, jarMount = convertRemoteJobJar(jobSpec)
+	assert.Assert(t, jarVolume != nil)
+	assert.Equal(t, jarMount.MountPath, "/opt/flink/job")
+
+	var jarPath = getRemoteJobJarPath(jobSpec.JarFile)
+	assert.Equal(t, jarPath, "/opt/flink/job/my-job.jar")
+	// The query and the fragment are not part of the file name.
+	assert.Equal(
+		t,
+		getRemoteJobJarPath("https://my-repo/jobs/my-job.jar?version=2#sha"),
+		"/opt/flink/job/my-job.jar")
+	assert.Equal(
+		t, getRemoteJobJarPath("https://my-repo/"), "/opt/flink/job/job.jar")
+
+	var container = getJarDownloaderContainer(
+		jobSpec,
+		jarPath,
+		[]corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+		[]corev1.VolumeMount{*jarMount})
+	assert.Equal(t, container.Name, "jar-downloader")
+	assert.Equal(t, container.Image, "google/cloud-sdk:290.0.1-alpine")
+	assert.DeepEqual(
+		t,
+		container.Env,
+		[]corev1.EnvVar{
+			{Name: "JAR_URI", Value: "gs://my-bucket/my-job.jar"},
+			{Name: "JAR_PATH", Value: "/opt/flink/job/my-job.jar"},
+			{
+				Name:  "JAR_SHA256",
+				Value: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			},
+			{Name: "FOO", Value: "bar"},
+		})
+	assert.DeepEqual(t, container.VolumeMounts, []corev1.VolumeMount{*jarMount})
+	assert.DeepEqual(
+		t, container.SecurityContext, jobSpec.ContainerSecurityContext)
+
+	jobSpec.JarFile = "s3://my-bucket/my-job.jar"
+	container = getJarDownloaderContainer(jobSpec, jarPath, nil, nil)
+	assert.Equal(t, container.Image, "amazon/aws-cli:2.0.10")
+
+	jobSpec.JarFile = "https://my-repo/my-job.jar"
+	container = getJarDownloaderContainer(jobSpec, jarPath, nil, nil)
+	assert.Equal(t, container.Image, "curlimages/curl:7.70.0")
+
+	var downloaderImage = "my-registry/curl:7.70.0"
+	jobSpec.JarDownloaderImage = &downloaderImage
+	container = getJarDownloaderContainer(jobSpec, jarPath, nil, nil)
+	assert.Equal(t, container.Image, "my-registry/curl:7.70.0")
+
+	// Local JAR files are not downloaded.
+	jarVolume, jarMount = convertRemoteJobJar(
+		&v1beta1.JobSpec{JarFile: "/cache/my-job.jar"})
+	assert.Assert(t, jarVolume == nil)
+	assert.Assert(t, jarMount == nil)
+}
+
+func TestConvertHadoopConfig(t *testing.T) {
+	var hadoopConfig = &v1beta1.HadoopConfig{
+		Properties: map[string]string{
+			"fs.defaultFS":        "hdfs://namenode:8020",
+			"dfs.client.use.name": "a&b",
+		},
+		MountPath: "/etc/hadoop/conf",
+	}
+	var volume, mount, env = convertHadoopConfig("mycluster", hadoopConfig)
+	assert.DeepEqual(
+		t,
+		*volume,
+		corev1.Volume{
+			Name: "hadoop-config-volume",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "mycluster-configmap",
+					},
+					Items: []corev1.KeyToPath{
+						{Key: "core-site.xml", Path: "core-site.xml"},
+					},
+				},
+			},
+		})
+	assert.Equal(t, mount.MountPath, "/etc/hadoop/conf")
+	assert.DeepEqual(
+		t, *env, corev1.EnvVar{Name: "HADOOP_CONF_DIR", Value: "/etc/hadoop/conf"})
+
+	var expectedCoreSite = `<?xml version="1.0"?>
+<configuration>
+  <property>
+    <name>dfs.client.use.name</name>
+    <value>a&amp;b</value>
+  </property>
+  <property>
+    <name>fs.defaultFS</name>
+    <value>hdfs://namenode:8020</value>
+  </property>
+</configuration>
+`
+	assert.Equal(t, getHadoopCoreSite(hadoopConfig.Properties), expectedCoreSite)
+	var cluster = &v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{HadoopConfig: hadoopConfig},
+	}
+	assert.Equal(t, getDesiredHadoopCoreSite(cluster), expectedCoreSite)
+
+	// The Hadoop config files are provided in a ConfigMap.
+	hadoopConfig.ConfigMapName = "hadoop-configmap"
+	hadoopConfig.Properties = nil
+	volume, _, _ = convertHadoopConfig("mycluster", hadoopConfig)
+	assert.Equal(t, volume.ConfigMap.Name, "hadoop-configmap")
+	assert.Assert(t, volume.ConfigMap.Items == nil)
+	assert.Equal(t, getDesiredHadoopCoreSite(cluster), "")
+}
+
+func TestConvertGCPConfig(t *testing.T) {
+	var gcpConfig = &v1beta1.GCPConfig{
+		ServiceAccount: &v1beta1.GCPServiceAccount{
+			SecretName: "gcp-service-account",
+			KeyFile:    "key.json",
+			MountPath:  "/etc/gcp/keys",
+		},
+	}
+	var volume, mount, env = convertGCPConfig(gcpConfig)
+	assert.Equal(t, volume.Secret.SecretName, "gcp-service-account")
+	assert.Equal(t, mount.MountPath, "/etc/gcp/keys/")
+	assert.DeepEqual(
+		t,
+		*env,
+		corev1.EnvVar{
+			Name:  "GOOGLE_APPLICATION_CREDENTIALS",
+			Value: "/etc/gcp/keys/key.json",
+		})
+
+	// No service account.
+	volume, mount, env = convertGCPConfig(&v1beta1.GCPConfig{})
+	assert.Assert(t, volume == nil)
+	assert.Assert(t, mount == nil)
+	assert.Assert(t, env == nil)
+}
+
+func TestGetPluginsEnv(t *testing.T) {
+	var clusterSpec = v1beta1.FlinkClusterSpec{
+		FlinkVersion: "1.12.2",
+		Storage: &v1beta1.StorageSpec{
+			Plugins: []string{"s3-fs-presto", "azure-fs-hadoop"},
+		},
+	}
+	assert.DeepEqual(
+		t,
+		*getPluginsEnv(&clusterSpec),
+		corev1.EnvVar{
+			Name:  "ENABLE_BUILT_IN_PLUGINS",
+			Value: "flink-s3-fs-presto-1.12.2.jar;flink-azure-fs-hadoop-1.12.2.jar",
+		})
+
+	// The GPU plugin is enabled with the TaskManager GPUs.
+	clusterSpec.TaskManager.GPU = &v1beta1.GPUSpec{Amount: 1}
+	assert.DeepEqual(
+		t,
+		*getPluginsEnv(&clusterSpec),
+		corev1.EnvVar{
+			Name:  "ENABLE_BUILT_IN_PLUGINS",
+			Value: "flink-s3-fs-presto-1.12.2.jar;flink-azure-fs-hadoop-1.12.2.jar;external-resource-gpu/flink-external-resource-gpu-1.12.2.jar",
+		})
+	clusterSpec.TaskManager.GPU = nil
+
+	// No plugins.
+	clusterSpec.Storage = &v1beta1.StorageSpec{}
+	assert.Assert(t, getPluginsEnv(&clusterSpec) == nil)
+	clusterSpec.Storage = nil
+	assert.Assert(t, getPluginsEnv(&clusterSpec) == nil)
+}
+
+func TestConvertPythonJobArgs(t *testing.T) {
+	var pythonFile = "/opt/flink/job/main.py"
+	var pythonRequirements = "/opt/flink/job/requirements.txt"
+
+	assert.DeepEqual(
+		t,
+		convertPythonJobArgs(&v1beta1.JobSpec{PythonFile: &pythonFile}),
+		[]string{"--python", "/opt/flink/job/main.py"})
+	assert.DeepEqual(
+		t,
+		convertPythonJobArgs(&v1beta1.JobSpec{
+			PythonFile:         &pythonFile,
+			PythonFiles:        []string{"/opt/flink/job/a.py", "/opt/flink/job/b.zip"},
+			PythonRequirements: &pythonRequirements,
+		}),
+		[]string{
+			"--python", "/opt/flink/job/main.py",
+			"--pyFiles", "/opt/flink/job/a.py,/opt/flink/job/b.zip",
+			"--pyRequirements", "/opt/flink/job/requirements.txt",
+		})
+}
+
+func TestConvertSQLJobEnv(t *testing.T) {
+	var sql = "INSERT INTO sink SELECT * FROM source;"
+	var sqlConfigMap = corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "my-sql"},
+		Key:                  "job.sql",
+	}
+
+	assert.Assert(
+		t, convertSQLJobEnv(&v1beta1.JobSpec{JarFile: "/cache/my-job.jar"}) == nil)
+	assert.DeepEqual(
+		t,
+		*convertSQLJobEnv(&v1beta1.JobSpec{SQL: &sql}),
+		corev1.EnvVar{Name: "FLINK_JOB_SQL", Value: sql})
+	assert.DeepEqual(
+		t,
+		*convertSQLJobEnv(&v1beta1.JobSpec{SQLConfigMap: &sqlConfigMap}),
+		corev1.EnvVar{
+			Name: "FLINK_JOB_SQL",
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &sqlConfigMap,
+			},
+		})
+}
+
+func TestGetDesiredHighAvailability(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flinksessioncluster-sample",
 			Namespace: "default",
 		},
 		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
 			JobManager: v1beta1.JobManagerSpec{
-				Resources: corev1.ResourceRequirements{
-					Limits: map[corev1.ResourceName]resource.Quantity{
-						corev1.ResourceMemory: resource.MustParse("1Gi"),
-					},
+				Replicas:    &jmReplicas,
+				AccessScope: v1beta1.AccessScopeCluster,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
 				},
-				MemoryOffHeapRatio: &memoryOffHeapRatio,
-				MemoryOffHeapMin:   memoryOffHeapMin,
 			},
 			TaskManager: v1beta1.TaskManagerSpec{
-				Resources: corev1.ResourceRequirements{
-					Limits: map[corev1.ResourceName]resource.Quantity{
-						corev1.ResourceMemory: resource.MustParse("4Gi"),
-					},
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
 				},
-				MemoryOffHeapRatio: &memoryOffHeapRatio,
-				MemoryOffHeapMin:   memoryOffHeapMin,
+			},
+			HighAvailability: &v1beta1.HighAvailabilitySpec{
+				Mode:       v1beta1.HighAvailabilityModeKubernetes,
+				StorageDir: "gs://my-bucket/flink-ha/",
 			},
 		},
 	}
 
-	flinkHeapSize := calFlinkHeapSize(cluster)
-	expectedFlinkHeapSize := map[string]string{
-		"jobmanager.heap.size":  "474m",  // get values calculated with limit - memoryOffHeapMin
-		"taskmanager.heap.size": "3222m", // get values calculated with limit - limit * memoryOffHeapRatio / 100
-	}
-	assert.Assert(t, len(flinkHeapSize) == 2)
+	var desiredState = getDesiredClusterState(cluster, time.Now())
+
+	var expectedFlinkConf = `blob.server.port: 6124
+high-availability: org.apache.flink.kubernetes.highavailability.KubernetesHaServicesFactory
+high-availability.storageDir: gs://my-bucket/flink-ha/
+jobmanager.rpc.address: flinksessioncluster-sample-jobmanager
+jobmanager.rpc.port: 6123
+kubernetes.cluster-id: flinksessioncluster-sample
+kubernetes.namespace: default
+query.server.port: 6125
+rest.port: 8081
+taskmanager.rpc.port: 6122
+`
+	assert.Equal(
+		t, desiredState.ConfigMap.Data["flink-conf.yaml"], expectedFlinkConf)
+
+	var jmPodSpec = desiredState.JmDeployment.Spec.Template.Spec
+	assert.Equal(t, jmPodSpec.ServiceAccountName, "flinksessioncluster-sample-ha")
+	assert.DeepEqual(
+		t, jmPodSpec.Containers[0].Args, []string{"jobmanager", "$(POD_IP)"})
+	var tmPodSpec = desiredState.TmDeployment.Spec.Template.Spec
+	assert.Equal(t, tmPodSpec.ServiceAccountName, "flinksessioncluster-sample-ha")
+
+	assert.Assert(t, desiredState.HAServiceAccount != nil)
+	assert.Equal(t, desiredState.HAServiceAccount.Name, "flinksessioncluster-sample-ha")
+	assert.Assert(t, desiredState.HARole != nil)
 	assert.DeepEqual(
+		t, desiredState.HARole.Rules[0].Resources, []string{"configmaps"})
+	assert.Assert(t, desiredState.HARoleBinding != nil)
+	assert.Equal(t, desiredState.HARoleBinding.RoleRef.Name, "flinksessioncluster-sample-ha")
+	assert.Equal(
 		t,
-		flinkHeapSize,
-		expectedFlinkHeapSize)
+		desiredState.HARoleBinding.Subjects[0].Name,
+		"flinksessioncluster-sample-ha")
 
-	// Case 2: No values when memory limits are missing or insufficient
-	cluster = &v1beta1.FlinkCluster{
+	// The ServiceAccount specified in the cluster spec is granted the HA
+	// permissions instead of creating one.
+	var serviceAccountName = "flink"
+	cluster.Spec.ServiceAccountName = &serviceAccountName
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Equal(
+		t, desiredState.JmDeployment.Spec.Template.Spec.ServiceAccountName, "flink")
+	assert.Equal(
+		t, desiredState.TmDeployment.Spec.Template.Spec.ServiceAccountName, "flink")
+	assert.Assert(t, desiredState.HAServiceAccount == nil)
+	assert.Assert(t, desiredState.HARoleBinding != nil)
+	assert.Equal(t, desiredState.HARoleBinding.Subjects[0].Name, "flink")
+	cluster.Spec.ServiceAccountName = nil
+
+	// ZooKeeper mode.
+	cluster.Spec.HighAvailability = &v1beta1.HighAvailabilitySpec{
+		Mode:              v1beta1.HighAvailabilityModeZooKeeper,
+		StorageDir:        "gs://my-bucket/flink-ha/",
+		ZooKeeperQuorum:   "zk-0.zk:2181,zk-1.zk:2181",
+		ZooKeeperRootPath: "/flink",
+	}
+	desiredState = getDesiredClusterState(cluster, time.Now())
+
+	expectedFlinkConf = `blob.server.port: 6124
+high-availability: zookeeper
+high-availability.cluster-id: /default/flinksessioncluster-sample
+high-availability.storageDir: gs://my-bucket/flink-ha/
+high-availability.zookeeper.path.root: /flink
+high-availability.zookeeper.quorum: zk-0.zk:2181,zk-1.zk:2181
+jobmanager.rpc.address: flinksessioncluster-sample-jobmanager
+jobmanager.rpc.port: 6123
+query.server.port: 6125
+rest.port: 8081
+taskmanager.rpc.port: 6122
+`
+	assert.Equal(
+		t, desiredState.ConfigMap.Data["flink-conf.yaml"], expectedFlinkConf)
+	assert.Equal(
+		t, desiredState.JmDeployment.Spec.Template.Spec.ServiceAccountName, "")
+	assert.Assert(t, desiredState.HAServiceAccount == nil)
+	assert.Assert(t, desiredState.HARole == nil)
+	assert.Assert(t, desiredState.HARoleBinding == nil)
+
+	// Standby JobManagers are spread across nodes.
+	var jmReplicas2 int32 = 2
+	cluster.Spec.JobManager.Replicas = &jmReplicas2
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Equal(t, *desiredState.JmDeployment.Spec.Replicas, int32(2))
+	var affinity = desiredState.JmDeployment.Spec.Template.Spec.Affinity
+	assert.Assert(t, affinity != nil && affinity.PodAntiAffinity != nil)
+	assert.Equal(
+		t,
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey,
+		"kubernetes.io/hostname")
+}
+
+func TestGetDesiredEnv(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var clusterEnv = corev1.EnvVar{Name: "FOO", Value: "bar"}
+	var secretEnvFrom = corev1.EnvFromSource{
+		SecretRef: &corev1.SecretEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "aws-credentials"},
+		},
+	}
+	var configMapEnvFrom = corev1.EnvFromSource{
+		Prefix: "FLINK_",
+		ConfigMapRef: &corev1.ConfigMapEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "flink-tuning"},
+		},
+	}
+	var cluster = &v1beta1.FlinkCluster{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "mycluster",
+			Name:      "flinkjobcluster-sample",
 			Namespace: "default",
 		},
 		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
 			JobManager: v1beta1.JobManagerSpec{
-				Resources: corev1.ResourceRequirements{
-					Limits: map[corev1.ResourceName]resource.Quantity{
-						corev1.ResourceMemory: resource.MustParse("500Mi"),
-					},
+				AccessScope: v1beta1.AccessScopeCluster,
+				Replicas:    &jmReplicas,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
 				},
-				MemoryOffHeapRatio: &memoryOffHeapRatio,
-				MemoryOffHeapMin:   memoryOffHeapMin,
+				Env:     []corev1.EnvVar{{Name: "JM_ONLY", Value: "1"}},
+				EnvFrom: []corev1.EnvFromSource{configMapEnvFrom},
 			},
 			TaskManager: v1beta1.TaskManagerSpec{
-				MemoryOffHeapRatio: &memoryOffHeapRatio,
-				MemoryOffHeapMin:   memoryOffHeapMin,
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+				Env:     []corev1.EnvVar{{Name: "TM_ONLY", Value: "1"}},
+				EnvFrom: []corev1.EnvFromSource{configMapEnvFrom},
+			},
+			Job: &v1beta1.JobSpec{
+				JarFile: "s3://my-bucket/my-job.jar",
+				Env:     []corev1.EnvVar{{Name: "JOB_ONLY", Value: "1"}},
+				EnvFrom: []corev1.EnvFromSource{secretEnvFrom},
 			},
+			EnvVars: []corev1.EnvVar{clusterEnv},
 		},
 	}
 
-	flinkHeapSize = calFlinkHeapSize(cluster)
-	assert.Assert(t, len(flinkHeapSize) == 0)
+	var desiredState = getDesiredClusterState(cluster, time.Now())
+
+	// The component env vars are added after the cluster env vars.
+	var jmContainer = desiredState.JmDeployment.Spec.Template.Spec.Containers[0]
+	var jmEnv = jmContainer.Env[len(jmContainer.Env)-2:]
+	assert.DeepEqual(
+		t, jmEnv, []corev1.EnvVar{clusterEnv, {Name: "JM_ONLY", Value: "1"}})
+	assert.DeepEqual(
+		t, jmContainer.EnvFrom, []corev1.EnvFromSource{configMapEnvFrom})
+
+	var tmContainer = desiredState.TmDeployment.Spec.Template.Spec.Containers[0]
+	var tmEnv = tmContainer.Env[len(tmContainer.Env)-2:]
+	assert.DeepEqual(
+		t, tmEnv, []corev1.EnvVar{clusterEnv, {Name: "TM_ONLY", Value: "1"}})
+	assert.DeepEqual(
+		t, tmContainer.EnvFrom, []corev1.EnvFromSource{configMapEnvFrom})
+
+	var jobPodSpec = desiredState.Job.Spec.Template.Spec
+	for _, container := range []corev1.Container{
+		jobPodSpec.Containers[0], jobPodSpec.InitContainers[0]} {
+		var env = container.Env[len(container.Env)-2:]
+		assert.DeepEqual(
+			t, env, []corev1.EnvVar{clusterEnv, {Name: "JOB_ONLY", Value: "1"}})
+		assert.DeepEqual(
+			t, container.EnvFrom, []corev1.EnvFromSource{secretEnvFrom})
+	}
+}
+
+func TestGetDesiredPriorityClassName(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var jmPriorityClassName = "flink-jobmanager"
+	var tmPriorityClassName = "flink-taskmanager"
+	var jobPriorityClassName = "flink-job"
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flinkjobcluster-sample",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				AccessScope: v1beta1.AccessScopeCluster,
+				Replicas:    &jmReplicas,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+				PriorityClassName: &jmPriorityClassName,
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+				PriorityClassName: &tmPriorityClassName,
+			},
+			Job: &v1beta1.JobSpec{
+				JarFile:           "s3://my-bucket/my-job.jar",
+				PriorityClassName: &jobPriorityClassName,
+			},
+		},
+	}
+
+	var desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Equal(
+		t,
+		desiredState.JmDeployment.Spec.Template.Spec.PriorityClassName,
+		"flink-jobmanager")
+	assert.Equal(
+		t,
+		desiredState.TmDeployment.Spec.Template.Spec.PriorityClassName,
+		"flink-taskmanager")
+	assert.Equal(
+		t, desiredState.Job.Spec.Template.Spec.PriorityClassName, "flink-job")
+
+	// The field takes precedence over the pod template.
+	cluster.Spec.TaskManager.PodTemplate = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{PriorityClassName: "low-priority"},
+	}
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Equal(
+		t,
+		desiredState.TmDeployment.Spec.Template.Spec.PriorityClassName,
+		"flink-taskmanager")
+
+	cluster.Spec.TaskManager.PriorityClassName = nil
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Equal(
+		t,
+		desiredState.TmDeployment.Spec.Template.Spec.PriorityClassName,
+		"low-priority")
+}
+
+func TestGetDesiredJobSubmitterPod(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var clusterServiceAccountName = "flink"
+	var jobServiceAccountName = "flink-submitter"
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flinkjobcluster-sample",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				AccessScope: v1beta1.AccessScopeCluster,
+				Replicas:    &jmReplicas,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+			},
+			Job: &v1beta1.JobSpec{
+				JarFile: "/opt/flink/job/my-job.jar",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+				ServiceAccountName: &jobServiceAccountName,
+				PodLabels:          map[string]string{"team": "data"},
+				PodAnnotations: map[string]string{
+					"cluster-autoscaler.kubernetes.io/safe-to-evict": "false",
+				},
+			},
+			ServiceAccountName: &clusterServiceAccountName,
+		},
+	}
+
+	var job = getDesiredJob(cluster)
+	var podTemplate = job.Spec.Template
+	assert.DeepEqual(
+		t,
+		podTemplate.ObjectMeta.Labels,
+		map[string]string{
+			"app":     "flink",
+			"cluster": "flinkjobcluster-sample",
+			"team":    "data",
+		})
+	assert.DeepEqual(
+		t,
+		podTemplate.ObjectMeta.Annotations,
+		map[string]string{
+			"cluster-autoscaler.kubernetes.io/safe-to-evict": "false",
+		})
+	assert.Equal(t, podTemplate.Spec.ServiceAccountName, "flink-submitter")
+	assert.DeepEqual(
+		t,
+		podTemplate.Spec.Containers[0].Resources,
+		cluster.Spec.Job.Resources,
+		cmpopts.IgnoreUnexported(resource.Quantity{}))
+	// The labels of the job resource are not changed.
+	assert.DeepEqual(
+		t,
+		job.ObjectMeta.Labels,
+		map[string]string{
+			"app":     "flink",
+			"cluster": "flinkjobcluster-sample",
+		})
+
+	// The Job pod runs as the cluster ServiceAccount by default.
+	cluster.Spec.Job.ServiceAccountName = nil
+	job = getDesiredJob(cluster)
+	assert.Equal(t, job.Spec.Template.Spec.ServiceAccountName, "flink")
+}
+
+func TestGetDesiredPrometheusMetrics(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var metricsPort int32 = 9249
+	var interval = "30s"
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flinksessioncluster-sample",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				Replicas:    &jmReplicas,
+				AccessScope: v1beta1.AccessScopeCluster,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+			},
+			Metrics: &v1beta1.MetricsSpec{
+				Prometheus: &v1beta1.PrometheusMetricsSpec{
+					Port: &metricsPort,
+					PodMonitor: &v1beta1.PodMonitorSpec{
+						Labels:   map[string]string{"release": "prometheus"},
+						Interval: &interval,
+					},
+				},
+			},
+		},
+	}
+
+	var desiredState = getDesiredClusterState(cluster, time.Now())
+
+	var expectedFlinkConf = `blob.server.port: 6124
+jobmanager.rpc.address: flinksessioncluster-sample-jobmanager
+jobmanager.rpc.port: 6123
+metrics.reporter.prom.class: org.apache.flink.metrics.prometheus.PrometheusReporter
+metrics.reporter.prom.port: 9249
+query.server.port: 6125
+rest.port: 8081
+taskmanager.rpc.port: 6122
+`
+	assert.Equal(
+		t, desiredState.ConfigMap.Data["flink-conf.yaml"], expectedFlinkConf)
+
+	var expectedPort = corev1.ContainerPort{Name: "metrics", ContainerPort: 9249}
+	var jmPorts = desiredState.JmDeployment.Spec.Template.Spec.Containers[0].Ports
+	assert.DeepEqual(t, jmPorts[len(jmPorts)-1], expectedPort)
+	var tmPorts = desiredState.TmDeployment.Spec.Template.Spec.Containers[0].Ports
+	assert.DeepEqual(t, tmPorts[len(tmPorts)-1], expectedPort)
+
+	var podMonitor = desiredState.PodMonitor
+	assert.Assert(t, podMonitor != nil)
+	assert.Equal(t, podMonitor.GetKind(), "PodMonitor")
+	assert.Equal(t, podMonitor.GetName(), "flinksessioncluster-sample-flink-metrics")
+	assert.DeepEqual(
+		t,
+		podMonitor.GetLabels(),
+		map[string]string{
+			"cluster": "flinksessioncluster-sample",
+			"app":     "flink",
+			"release": "prometheus",
+		})
+	assert.DeepEqual(
+		t,
+		podMonitor.Object["spec"],
+		map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					"cluster": "flinksessioncluster-sample",
+					"app":     "flink",
+				},
+			},
+			"podMetricsEndpoints": []interface{}{
+				map[string]interface{}{"port": "metrics", "interval": "30s"},
+			},
+		})
+
+	// No PodMonitor.
+	cluster.Spec.Metrics.Prometheus.PodMonitor = nil
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Assert(t, desiredState.PodMonitor == nil)
+}
+
+func TestGetDesiredBatchScheduler(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 3
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var queue = "flink"
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flinksessioncluster-sample",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				AccessScope: v1beta1.AccessScopeCluster,
+				Replicas:    &jmReplicas,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+			},
+			BatchScheduler: &v1beta1.BatchSchedulerSpec{
+				Name:  v1beta1.BatchSchedulerVolcano,
+				Queue: &queue,
+			},
+		},
+	}
+
+	var desiredState = getDesiredClusterState(cluster, time.Now())
+
+	var podGroup = desiredState.PodGroup
+	assert.Assert(t, podGroup != nil)
+	assert.Equal(t, podGroup.GetAPIVersion(), "scheduling.volcano.sh/v1beta1")
+	assert.Equal(t, podGroup.GetKind(), "PodGroup")
+	assert.Equal(t, podGroup.GetName(), "flinksessioncluster-sample-flink-podgroup")
+	assert.DeepEqual(
+		t,
+		podGroup.Object["spec"],
+		map[string]interface{}{"minMember": int64(4), "queue": "flink"})
+	for _, podTemplate := range []corev1.PodTemplateSpec{
+		desiredState.JmDeployment.Spec.Template,
+		desiredState.TmDeployment.Spec.Template} {
+		assert.Equal(t, podTemplate.Spec.SchedulerName, "volcano")
+		assert.Equal(
+			t,
+			podTemplate.Annotations["scheduling.k8s.io/group-name"],
+			"flinksessioncluster-sample-flink-podgroup")
+	}
+
+	var schedulerName = "coscheduler"
+	cluster.Spec.BatchScheduler = &v1beta1.BatchSchedulerSpec{
+		Name:          v1beta1.BatchSchedulerSchedulerPlugins,
+		SchedulerName: &schedulerName,
+	}
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	podGroup = desiredState.PodGroup
+	assert.Equal(t, podGroup.GetAPIVersion(), "scheduling.sigs.k8s.io/v1alpha1")
+	assert.DeepEqual(
+		t, podGroup.Object["spec"], map[string]interface{}{"minMember": int64(4)})
+	var tmPodTemplate = desiredState.TmDeployment.Spec.Template
+	assert.Equal(t, tmPodTemplate.Spec.SchedulerName, "coscheduler")
+	assert.Equal(
+		t,
+		tmPodTemplate.Labels["pod-group.scheduling.sigs.k8s.io"],
+		"flinksessioncluster-sample-flink-podgroup")
+	assert.Equal(t, tmPodTemplate.Labels["component"], "taskmanager")
+	assert.DeepEqual(
+		t,
+		desiredState.TmDeployment.Spec.Selector.MatchLabels,
+		map[string]string{
+			"cluster":   "flinksessioncluster-sample",
+			"app":       "flink",
+			"component": "taskmanager",
+		})
+
+	// No batch scheduler.
+	cluster.Spec.BatchScheduler = nil
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Assert(t, desiredState.PodGroup == nil)
+	assert.Equal(t, desiredState.TmDeployment.Spec.Template.Spec.SchedulerName, "")
+}
+
+func TestGetDesiredJobFixedJobID(t *testing.T) {
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "flinkjobcluster-sample",
+			Namespace:  "default",
+			UID:        "a1b2c3d4",
+			Generation: 1,
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image:        v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			FlinkVersion: "1.12",
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+			},
+			Job: &v1beta1.JobSpec{
+				JarFile: "/opt/flink/job/my-job.jar",
+			},
+		},
+	}
+
+	var jobID = getSubmitterJobID(cluster, nil)
+	assert.Equal(t, len(jobID), 32)
+	var container = getDesiredJob(cluster).Spec.Template.Spec.Containers[0]
+	assert.DeepEqual(
+		t,
+		container.Args[len(container.Args)-2:],
+		[]string{
+			"-D$internal.pipeline.job-id=" + jobID,
+			"/opt/flink/job/my-job.jar",
+		})
+	assert.DeepEqual(
+		t,
+		container.Env,
+		[]corev1.EnvVar{{Name: "FLINK_JOB_ID", Value: jobID}})
+
+	// The job submitter which runs again gets the same ID, the restarted job
+	// gets a new one.
+	assert.Equal(t, getSubmitterJobID(cluster, nil), jobID)
+	cluster.Status.Components.Job = &v1beta1.JobStatus{
+		ID:    jobID,
+		State: v1beta1.JobStateFailed,
+	}
+	assert.Assert(t, getSubmitterJobID(cluster, nil) != jobID)
+
+	// Not fixed before Flink 1.12.
+	cluster.Spec.FlinkVersion = "1.11"
+	container = getDesiredJob(cluster).Spec.Template.Spec.Containers[0]
+	assert.Equal(t, len(container.Env), 0)
+}
+
+func TestGetDesiredJarRunRequest(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var className = "org.apache.flink.examples.java.wordcount.WordCount"
+	var parallelism int32 = 2
+	var allowNonRestoredState = true
+	var submissionMode = v1beta1.JobSubmissionModeRestAPI
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flinkjobcluster-sample",
+			Namespace: "default",
+			UID:       "a1b2c3d4",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				Replicas:    &jmReplicas,
+				AccessScope: v1beta1.AccessScopeCluster,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+			},
+			Job: &v1beta1.JobSpec{
+				JarFile:               "https://my-repo/wordcount.jar",
+				ClassName:             &className,
+				Args:                  []string{"--input", "./README.txt"},
+				Parallelism:           &parallelism,
+				AllowNonRestoredState: &allowNonRestoredState,
+				SubmissionMode:        &submissionMode,
+			},
+		},
+	}
+
+	// No job submitter in the RestAPI submission mode.
+	var desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Assert(t, desiredState.Job == nil)
+
+	var request1 = getDesiredJarRunRequest(cluster)
+	assert.Equal(t, request1.EntryClass, className)
+	assert.DeepEqual(t, request1.ProgramArgsList, []string{"--input", "./README.txt"})
+	assert.Equal(t, request1.Parallelism, int32(2))
+	assert.Equal(t, request1.AllowNonRestoredState, true)
+	assert.Equal(t, request1.SavepointPath, "")
+	assert.Equal(t, len(request1.JobID), 32)
+
+	// The job ID is deterministic.
+	assert.Equal(t, getDesiredJarRunRequest(cluster).JobID, request1.JobID)
+
+	// The restarted job gets a new ID and is restored from the savepoint.
+	var restartPolicy = v1beta1.JobRestartPolicyFromSavepointOnFailure
+	cluster.Spec.Job.RestartPolicy = &restartPolicy
+	cluster.Status.Components.Job = &v1beta1.JobStatus{
+		ID:                request1.JobID,
+		State:             v1beta1.JobStateFailed,
+		SavepointLocation: "gs://my-bucket/savepoint-123",
+	}
+	var request2 = getDesiredJarRunRequest(cluster)
+	assert.Equal(t, request2.SavepointPath, "gs://my-bucket/savepoint-123")
+	assert.Assert(t, request2.JobID != request1.JobID)
+}
+
+func TestCalFlinkHeapSize(t *testing.T) {
+	var memoryOffHeapRatio int32 = 25
+	var memoryOffHeapMin = resource.MustParse("600M")
+
+	// Case 1: Heap sizes are computed from memoryOffHeapMin or memoryOffHeapRatio
+	cluster := &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+				MemoryOffHeapRatio: &memoryOffHeapRatio,
+				MemoryOffHeapMin:   memoryOffHeapMin,
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+				MemoryOffHeapRatio: &memoryOffHeapRatio,
+				MemoryOffHeapMin:   memoryOffHeapMin,
+			},
+		},
+	}
+
+	flinkHeapSize := calFlinkHeapSize(cluster)
+	expectedFlinkHeapSize := map[string]string{
+		"jobmanager.heap.size":  "474m",  // get values calculated with limit - memoryOffHeapMin
+		"taskmanager.heap.size": "3222m", // get values calculated with limit - limit * memoryOffHeapRatio / 100
+	}
+	assert.Assert(t, len(flinkHeapSize) == 2)
+	assert.DeepEqual(
+		t,
+		flinkHeapSize,
+		expectedFlinkHeapSize)
+
+	// Case 2: No values when memory limits are missing or insufficient
+	cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceMemory: resource.MustParse("500Mi"),
+					},
+				},
+				MemoryOffHeapRatio: &memoryOffHeapRatio,
+				MemoryOffHeapMin:   memoryOffHeapMin,
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				MemoryOffHeapRatio: &memoryOffHeapRatio,
+				MemoryOffHeapMin:   memoryOffHeapMin,
+			},
+		},
+	}
+
+	flinkHeapSize = calFlinkHeapSize(cluster)
+	assert.Assert(t, len(flinkHeapSize) == 0)
+}
+
+func TestGetDesiredLogConfig(t *testing.T) {
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var tmRPCPort int32 = 6122
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Ports: v1beta1.TaskManagerPorts{RPC: &tmRPCPort},
+			},
+		},
+	}
+	var defaultHash = getFlinkConfAnnotations(cluster)[flinkConfHashAnnotation]
+
+	cluster.Spec.LogConfig = map[string]string{
+		"log4j-console.properties": "rootLogger.level = DEBUG",
+		"log4j-cli.properties":     "rootLogger.level = WARN",
+	}
+	var configMap = getDesiredConfigMap(cluster)
+	assert.Equal(
+		t,
+		configMap.Data["log4j-console.properties"],
+		"rootLogger.level = DEBUG")
+	assert.Equal(
+		t, configMap.Data["log4j-cli.properties"], "rootLogger.level = WARN")
+	// The default files which are not replaced are kept.
+	assert.Equal(
+		t,
+		configMap.Data["logback-console.xml"],
+		getLogConf()["logback-console.xml"])
+	assert.Equal(t, configMap.Data["flink-conf.yaml"], getFlinkConf(cluster))
+
+	// The pods are restarted when the log config changes.
+	var hash = getFlinkConfAnnotations(cluster)[flinkConfHashAnnotation]
+	assert.Assert(t, hash != defaultHash)
+	cluster.Spec.LogConfig["log4j-console.properties"] = "rootLogger.level = INFO"
+	assert.Assert(
+		t, getFlinkConfAnnotations(cluster)[flinkConfHashAnnotation] != hash)
+}
+
+func TestGetCheckpointingProperties(t *testing.T) {
+	var mode = v1beta1.CheckpointingModeAtLeastOnce
+	var timeoutSeconds int32 = 300
+	var minPauseSeconds int32 = 10
+	var retention = v1beta1.CheckpointRetentionRetainOnCancellation
+	var unaligned = false
+	var jobSpec = &v1beta1.JobSpec{
+		Checkpointing: &v1beta1.CheckpointingSpec{
+			IntervalSeconds:       60,
+			Mode:                  &mode,
+			TimeoutSeconds:        &timeoutSeconds,
+			MinPauseSeconds:       &minPauseSeconds,
+			ExternalizedRetention: &retention,
+			Unaligned:             &unaligned,
+		},
+	}
+	assert.DeepEqual(
+		t,
+		getCheckpointingProperties(jobSpec),
+		map[string]string{
+			"execution.checkpointing.interval":                          "60s",
+			"execution.checkpointing.mode":                              "AT_LEAST_ONCE",
+			"execution.checkpointing.timeout":                           "300s",
+			"execution.checkpointing.min-pause":                         "10s",
+			"execution.checkpointing.externalized-checkpoint-retention": "RETAIN_ON_CANCELLATION",
+			"execution.checkpointing.unaligned":                         "false",
+		})
+
+	// Only the interval is specified, the mode defaults to exactly-once.
+	jobSpec.Checkpointing = &v1beta1.CheckpointingSpec{IntervalSeconds: 30}
+	assert.DeepEqual(
+		t,
+		getCheckpointingProperties(jobSpec),
+		map[string]string{
+			"execution.checkpointing.interval": "30s",
+			"execution.checkpointing.mode":     "EXACTLY_ONCE",
+		})
+
+	// No checkpointing.
+	jobSpec.Checkpointing = nil
+	assert.DeepEqual(t, getCheckpointingProperties(jobSpec), map[string]string{})
+	assert.DeepEqual(t, getCheckpointingProperties(nil), map[string]string{})
+}
+
+func TestGetDesiredGPU(t *testing.T) {
+	var resourceName = "nvidia.com/gpu"
+	var discoveryScriptPath = "opt/external-resource-gpu/nvidia-gpu-discovery.sh"
+	var discoveryScriptArgs = "--enable-coordination-mode"
+	var tmSpec = &v1beta1.TaskManagerSpec{
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+		},
+		GPU: &v1beta1.GPUSpec{
+			Amount:              2,
+			ResourceName:        &resourceName,
+			DiscoveryScriptPath: &discoveryScriptPath,
+			DiscoveryScriptArgs: &discoveryScriptArgs,
+		},
+	}
+	assert.DeepEqual(
+		t,
+		getGPUProperties(tmSpec.GPU),
+		map[string]string{
+			"external-resources":                                "gpu",
+			"external-resource.gpu.amount":                      "2",
+			"external-resource.gpu.driver-factory.class":        "org.apache.flink.externalresource.gpu.GPUDriverFactory",
+			"external-resource.gpu.param.discovery-script.path": "opt/external-resource-gpu/nvidia-gpu-discovery.sh",
+			"external-resource.gpu.param.discovery-script.args": "--enable-coordination-mode",
+		})
+
+	var resources = getTaskManagerResources(tmSpec)
+	assert.Equal(t, resources.Limits.Memory().String(), "4Gi")
+	var gpuLimit = resources.Limits[corev1.ResourceName("nvidia.com/gpu")]
+	assert.Equal(t, gpuLimit.Value(), int64(2))
+	// The spec is not modified.
+	assert.Equal(t, len(tmSpec.Resources.Limits), 1)
+
+	// No GPUs.
+	tmSpec.GPU = nil
+	assert.DeepEqual(t, getGPUProperties(tmSpec.GPU), map[string]string{})
+	assert.DeepEqual(
+		t,
+		getTaskManagerResources(tmSpec),
+		tmSpec.Resources,
+		cmpopts.IgnoreUnexported(resource.Quantity{}))
+}
+
+func TestGetStateBackendProperties(t *testing.T) {
+	var checkpointDir = "gs://my-bucket/checkpoints/"
+	var savepointDir = "gs://my-bucket/savepoints/"
+	var incremental = true
+	var clusterSpec = v1beta1.FlinkClusterSpec{
+		FlinkVersion: "1.13.1",
+		StateBackend: &v1beta1.StateBackendSpec{
+			Type:          v1beta1.StateBackendTypeRocksDB,
+			CheckpointDir: &checkpointDir,
+			SavepointDir:  &savepointDir,
+			Incremental:   &incremental,
+			RocksDBLocalDir: &v1beta1.RocksDBLocalDirSpec{
+				MountPath: "/flink-rocksdb",
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "rocksdb",
+				},
+			},
+		},
+	}
+	assert.DeepEqual(
+		t,
+		getStateBackendProperties(&clusterSpec),
+		map[string]string{
+			"state.backend":                  "rocksdb",
+			"state.checkpoints.dir":          "gs://my-bucket/checkpoints/",
+			"state.savepoints.dir":           "gs://my-bucket/savepoints/",
+			"state.backend.incremental":      "true",
+			"state.backend.rocksdb.localdir": "/flink-rocksdb",
+		})
+	var volume, mount = convertRocksDBLocalDir(clusterSpec.StateBackend)
+	assert.DeepEqual(
+		t,
+		*volume,
+		corev1.Volume{
+			Name: "rocksdb-local-dir-volume",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "rocksdb",
+				},
+			},
+		})
+	assert.DeepEqual(
+		t,
+		*mount,
+		corev1.VolumeMount{
+			Name:      "rocksdb-local-dir-volume",
+			MountPath: "/flink-rocksdb",
+		})
+
+	// The heap state backend is named "filesystem" before Flink 1.13.
+	clusterSpec.StateBackend = &v1beta1.StateBackendSpec{
+		Type: v1beta1.StateBackendTypeHashMap,
+	}
+	assert.DeepEqual(
+		t,
+		getStateBackendProperties(&clusterSpec),
+		map[string]string{"state.backend": "hashmap"})
+	clusterSpec.FlinkVersion = "1.12"
+	assert.DeepEqual(
+		t,
+		getStateBackendProperties(&clusterSpec),
+		map[string]string{"state.backend": "filesystem"})
+	volume, mount = convertRocksDBLocalDir(clusterSpec.StateBackend)
+	assert.Assert(t, volume == nil)
+	assert.Assert(t, mount == nil)
+
+	// No state backend.
+	clusterSpec.StateBackend = nil
+	assert.DeepEqual(t, getStateBackendProperties(&clusterSpec), map[string]string{})
+}
+
+func TestGetDesiredTaskManagerStatefulSet(t *testing.T) {
+	var tmReplicas int32 = 3
+	var jmRPCPort int32 = 6123
+	var jmBlobPort int32 = 6124
+	var jmQueryPort int32 = 6125
+	var jmUIPort int32 = 8081
+	var tmDataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var tmQueryPort int32 = 6125
+	var deploymentType = v1beta1.DeploymentTypeStatefulSet
+	var storageClassName = "local-ssd"
+	var volumeClaimTemplates = []corev1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "rocksdb"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{
+					corev1.ReadWriteOnce,
+				},
+				StorageClassName: &storageClassName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("100Gi"),
+					},
+				},
+			},
+		},
+	}
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.13.1"},
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &jmBlobPort,
+					Query: &jmQueryPort,
+					UI:    &jmUIPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas:       &tmReplicas,
+				DeploymentType: &deploymentType,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &tmDataPort,
+					RPC:   &tmRPCPort,
+					Query: &tmQueryPort,
+				},
+				VolumeClaimTemplates: volumeClaimTemplates,
+			},
+			StateBackend: &v1beta1.StateBackendSpec{
+				Type: v1beta1.StateBackendTypeRocksDB,
+				RocksDBLocalDir: &v1beta1.RocksDBLocalDirSpec{
+					MountPath:           "/flink-rocksdb",
+					VolumeClaimTemplate: "rocksdb",
+				},
+			},
+		},
+	}
+
+	assert.Assert(t, getDesiredTaskManagerDeployment(cluster) == nil)
+
+	var statefulSet = getDesiredTaskManagerStatefulSet(cluster)
+	assert.Assert(t, statefulSet != nil)
+	assert.Equal(t, statefulSet.ObjectMeta.Name, "mycluster-taskmanager")
+	assert.Equal(t, statefulSet.Spec.ServiceName, "mycluster-taskmanager-headless")
+	assert.Equal(t, *statefulSet.Spec.Replicas, int32(3))
+	assert.Equal(
+		t, statefulSet.Spec.PodManagementPolicy, appsv1.ParallelPodManagement)
+	assert.DeepEqual(
+		t,
+		statefulSet.Spec.Selector.MatchLabels,
+		map[string]string{
+			"app":       "flink",
+			"cluster":   "mycluster",
+			"component": "taskmanager",
+		})
+	assert.DeepEqual(
+		t,
+		statefulSet.Spec.VolumeClaimTemplates,
+		volumeClaimTemplates,
+		cmpopts.IgnoreUnexported(resource.Quantity{}))
+
+	// The RocksDB local directory is mounted from the claim template, no pod
+	// volume is added for it.
+	var container = statefulSet.Spec.Template.Spec.Containers[0]
+	assert.DeepEqual(
+		t,
+		container.VolumeMounts[len(container.VolumeMounts)-1],
+		corev1.VolumeMount{Name: "rocksdb", MountPath: "/flink-rocksdb"})
+	for _, volume := range statefulSet.Spec.Template.Spec.Volumes {
+		assert.Assert(t, volume.Name != "rocksdb-local-dir-volume")
+	}
+
+	// The TaskManagers register with their stable hostnames.
+	assert.DeepEqual(
+		t,
+		container.Args,
+		[]string{
+			"taskmanager",
+			"-Dtaskmanager.host=$(POD_NAME).mycluster-taskmanager-headless",
+		})
+
+	var headlessService = getDesiredTaskManagerHeadlessService(cluster)
+	assert.Assert(t, headlessService != nil)
+	assert.Equal(t, headlessService.ObjectMeta.Name, "mycluster-taskmanager-headless")
+	assert.Equal(t, headlessService.Spec.ClusterIP, corev1.ClusterIPNone)
+	assert.Assert(t, headlessService.Spec.PublishNotReadyAddresses)
+	assert.DeepEqual(
+		t,
+		headlessService.Spec.Ports,
+		[]corev1.ServicePort{
+			{Name: "data", Port: 6121},
+			{Name: "rpc", Port: 6122},
+			{Name: "query", Port: 6125},
+		})
+
+	var orderedReady = v1beta1.PodManagementPolicyOrderedReady
+	cluster.Spec.TaskManager.PodManagementPolicy = &orderedReady
+	statefulSet = getDesiredTaskManagerStatefulSet(cluster)
+	assert.Equal(
+		t, statefulSet.Spec.PodManagementPolicy, appsv1.OrderedReadyPodManagement)
+
+	// TaskManagers are deployed as a Deployment by default.
+	cluster.Spec.TaskManager.DeploymentType = nil
+	cluster.Spec.TaskManager.PodManagementPolicy = nil
+	assert.Assert(t, getDesiredTaskManagerStatefulSet(cluster) == nil)
+	assert.Assert(t, getDesiredTaskManagerHeadlessService(cluster) == nil)
+	assert.DeepEqual(
+		t,
+		getDesiredTaskManagerDeployment(cluster).Spec.Template.Spec.Containers[0].Args,
+		[]string{"taskmanager"})
+}
+
+func TestGetDesiredJobManagerStatefulSet(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var jmBlobPort int32 = 6124
+	var jmQueryPort int32 = 6125
+	var jmUIPort int32 = 8081
+	var tmDataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var tmQueryPort int32 = 6125
+	var deploymentType = v1beta1.DeploymentTypeStatefulSet
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.13.1"},
+			JobManager: v1beta1.JobManagerSpec{
+				Replicas:       &jmReplicas,
+				DeploymentType: &deploymentType,
+				AccessScope:    v1beta1.AccessScopeCluster,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &jmBlobPort,
+					Query: &jmQueryPort,
+					UI:    &jmUIPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &tmDataPort,
+					RPC:   &tmRPCPort,
+					Query: &tmQueryPort,
+				},
+			},
+		},
+	}
+
+	var desired = getDesiredClusterState(cluster, time.Now())
+	assert.Assert(t, desired.JmDeployment == nil)
+	assert.Assert(t, desired.JmStatefulSet != nil)
+	assert.Assert(t, desired.TmDeployment != nil)
+	assert.Assert(t, desired.TmStatefulSet == nil)
+	assert.Assert(t, desired.TmHeadlessService == nil)
+
+	var statefulSet = desired.JmStatefulSet
+	assert.Equal(t, statefulSet.ObjectMeta.Name, "mycluster-jobmanager")
+	assert.Equal(t, statefulSet.Spec.ServiceName, "mycluster-jobmanager-headless")
+	assert.Equal(
+		t, statefulSet.Spec.PodManagementPolicy, appsv1.OrderedReadyPodManagement)
+	assert.DeepEqual(
+		t,
+		statefulSet.Spec.Template,
+		getDesiredJobManagerPodTemplate(cluster),
+		cmpopts.IgnoreUnexported(resource.Quantity{}))
+
+	var headlessService = desired.JmHeadlessService
+	assert.Assert(t, headlessService != nil)
+	assert.Equal(t, headlessService.ObjectMeta.Name, "mycluster-jobmanager-headless")
+	assert.Equal(t, headlessService.Spec.ClusterIP, corev1.ClusterIPNone)
+	assert.DeepEqual(
+		t,
+		headlessService.Spec.Selector,
+		map[string]string{
+			"app":       "flink",
+			"cluster":   "mycluster",
+			"component": "jobmanager",
+		})
+
+	// The JobManager service is created regardless of the deployment type.
+	assert.Assert(t, desired.JmService != nil)
+}
+
+func TestGetDesiredPodDisruptionBudget(t *testing.T) {
+	var minAvailable = intstr.FromInt(1)
+	var maxUnavailable = intstr.FromString("25%")
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				PodDisruptionBudget: &v1beta1.PodDisruptionBudgetSpec{
+					MinAvailable: &minAvailable,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				PodDisruptionBudget: &v1beta1.PodDisruptionBudgetSpec{
+					MaxUnavailable: &maxUnavailable,
+				},
+			},
+		},
+	}
+
+	var jmPDB = getDesiredJobManagerPodDisruptionBudget(cluster)
+	assert.Equal(t, jmPDB.ObjectMeta.Name, "mycluster-jobmanager")
+	assert.DeepEqual(t, *jmPDB.Spec.MinAvailable, minAvailable)
+	assert.Assert(t, jmPDB.Spec.MaxUnavailable == nil)
+	assert.DeepEqual(
+		t,
+		jmPDB.Spec.Selector.MatchLabels,
+		map[string]string{
+			"app":       "flink",
+			"cluster":   "mycluster",
+			"component": "jobmanager",
+		})
+
+	var tmPDB = getDesiredTaskManagerPodDisruptionBudget(cluster)
+	assert.Equal(t, tmPDB.ObjectMeta.Name, "mycluster-taskmanager")
+	assert.Assert(t, tmPDB.Spec.MinAvailable == nil)
+	assert.DeepEqual(t, *tmPDB.Spec.MaxUnavailable, maxUnavailable)
+	assert.DeepEqual(
+		t,
+		tmPDB.Spec.Selector.MatchLabels,
+		map[string]string{
+			"app":       "flink",
+			"cluster":   "mycluster",
+			"component": "taskmanager",
+		})
+
+	cluster.Spec.TaskManager.PodDisruptionBudget = nil
+	assert.Assert(t, getDesiredTaskManagerPodDisruptionBudget(cluster) == nil)
+}
+
+func TestGetDesiredNetworkPolicy(t *testing.T) {
+	var jmRPCPort int32 = 6123
+	var jmBlobPort int32 = 6124
+	var jmQueryPort int32 = 6125
+	var jmUIPort int32 = 8081
+	var tmDataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var tmQueryPort int32 = 6125
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &jmBlobPort,
+					Query: &jmQueryPort,
+					UI:    &jmUIPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &tmDataPort,
+					RPC:   &tmRPCPort,
+					Query: &tmQueryPort,
+				},
+			},
+			NetworkPolicy: &v1beta1.NetworkPolicySpec{
+				UIIngressCIDRs: []string{"10.0.0.0/8"},
+			},
+		},
+	}
+	var clusterPeers = []networkingv1.NetworkPolicyPeer{{
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"cluster": "mycluster", "app": "flink"},
+		},
+	}}
+
+	var jmNetworkPolicy = getDesiredJobManagerNetworkPolicy(cluster)
+	assert.Equal(t, jmNetworkPolicy.ObjectMeta.Name, "mycluster-jobmanager")
+	assert.DeepEqual(
+		t,
+		jmNetworkPolicy.Spec.PodSelector.MatchLabels,
+		map[string]string{
+			"app":       "flink",
+			"cluster":   "mycluster",
+			"component": "jobmanager",
+		})
+	assert.DeepEqual(
+		t,
+		jmNetworkPolicy.Spec.PolicyTypes,
+		[]networkingv1.PolicyType{networkingv1.PolicyTypeIngress})
+	assert.Equal(t, len(jmNetworkPolicy.Spec.Ingress), 3)
+	assert.DeepEqual(t, jmNetworkPolicy.Spec.Ingress[0].From, clusterPeers)
+	assert.DeepEqual(
+		t,
+		jmNetworkPolicy.Spec.Ingress[0].Ports,
+		getNetworkPolicyPorts(6123, 6124, 6125, 8081))
+	assert.DeepEqual(
+		t,
+		*jmNetworkPolicy.Spec.Ingress[1].From[0].NamespaceSelector,
+		metav1.LabelSelector{})
+	assert.DeepEqual(
+		t,
+		jmNetworkPolicy.Spec.Ingress[1].Ports,
+		getNetworkPolicyPorts(8081))
+	assert.DeepEqual(
+		t,
+		jmNetworkPolicy.Spec.Ingress[2].From,
+		[]networkingv1.NetworkPolicyPeer{{
+			IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"},
+		}})
+	assert.DeepEqual(
+		t,
+		jmNetworkPolicy.Spec.Ingress[2].Ports,
+		getNetworkPolicyPorts(8081))
+
+	var tmNetworkPolicy = getDesiredTaskManagerNetworkPolicy(cluster)
+	assert.Equal(t, tmNetworkPolicy.ObjectMeta.Name, "mycluster-taskmanager")
+	assert.Equal(t, len(tmNetworkPolicy.Spec.Ingress), 1)
+	assert.DeepEqual(t, tmNetworkPolicy.Spec.Ingress[0].From, clusterPeers)
+	assert.DeepEqual(
+		t,
+		tmNetworkPolicy.Spec.Ingress[0].Ports,
+		getNetworkPolicyPorts(6121, 6122, 6125))
+
+	cluster.Spec.NetworkPolicy.UIIngressCIDRs = nil
+	jmNetworkPolicy = getDesiredJobManagerNetworkPolicy(cluster)
+	assert.Equal(t, len(jmNetworkPolicy.Spec.Ingress), 2)
+
+	cluster.Spec.NetworkPolicy = nil
+	assert.Assert(t, getDesiredJobManagerNetworkPolicy(cluster) == nil)
+	assert.Assert(t, getDesiredTaskManagerNetworkPolicy(cluster) == nil)
+}
+
+func TestGetDesiredHistoryServer(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 1
+	var jmRPCPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var tmRPCPort int32 = 6122
+	var historyServerPort int32 = 8082
+	var refreshIntervalSeconds int32 = 30
+	var serviceType = corev1.ServiceTypeLoadBalancer
+	var hostFormat = "{{$clusterName}}-history.example.com"
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flinkjobcluster-sample",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				AccessScope: v1beta1.AccessScopeCluster,
+				Replicas:    &jmReplicas,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas: &tmReplicas,
+				Ports: v1beta1.TaskManagerPorts{
+					Data:  &dataPort,
+					RPC:   &tmRPCPort,
+					Query: &queryPort,
+				},
+			},
+			Job: &v1beta1.JobSpec{
+				JarFile: "gs://my-bucket/my-job.jar",
+				CleanupPolicy: &v1beta1.CleanupPolicy{
+					AfterJobSucceeds: v1beta1.CleanupActionDeleteCluster,
+				},
+			},
+			HistoryServer: &v1beta1.HistoryServerSpec{
+				ArchiveDir:             "gs://my-bucket/completed-jobs/",
+				Port:                   &historyServerPort,
+				RefreshIntervalSeconds: &refreshIntervalSeconds,
+				Service: &v1beta1.JobManagerServiceSpec{
+					Type: &serviceType,
+				},
+				Ingress: &v1beta1.JobManagerIngressSpec{
+					HostFormat: &hostFormat,
+				},
+			},
+		},
+	}
+
+	assert.DeepEqual(
+		t,
+		getHistoryServerProperties(cluster.Spec.HistoryServer),
+		map[string]string{
+			"jobmanager.archive.fs.dir":                 "gs://my-bucket/completed-jobs/",
+			"historyserver.archive.fs.dir":              "gs://my-bucket/completed-jobs/",
+			"historyserver.archive.fs.refresh-interval": "30000",
+			"historyserver.web.address":                 "0.0.0.0",
+			"historyserver.web.port":                    "8082",
+		})
+
+	var labels = map[string]string{
+		"app":       "flink",
+		"cluster":   "flinkjobcluster-sample",
+		"component": "historyserver",
+	}
+	var desiredState = getDesiredClusterState(cluster, time.Now())
+	var deployment = desiredState.HistoryServerDeployment
+	assert.Equal(t, deployment.ObjectMeta.Name, "flinkjobcluster-sample-historyserver")
+	assert.DeepEqual(t, deployment.Spec.Selector.MatchLabels, labels)
+	var container = deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, container.Name, "historyserver")
+	assert.Equal(t, container.Image, "flink:1.12.0")
+	assert.DeepEqual(t, container.Args, []string{"history-server"})
+	assert.DeepEqual(
+		t,
+		container.Ports,
+		[]corev1.ContainerPort{{Name: "ui", ContainerPort: 8082}})
+	assert.DeepEqual(
+		t,
+		container.VolumeMounts,
+		[]corev1.VolumeMount{{Name: "flink-config-volume", MountPath: "/opt/flink/conf"}})
+
+	var service = desiredState.HistoryServerService
+	assert.Equal(t, service.ObjectMeta.Name, "flinkjobcluster-sample-historyserver")
+	assert.Equal(t, service.Spec.Type, corev1.ServiceTypeLoadBalancer)
+	assert.DeepEqual(t, service.Spec.Selector, labels)
+	assert.Equal(t, service.Spec.Ports[0].Port, int32(8082))
+
+	var ingress = desiredState.HistoryServerIngress
+	assert.Equal(t, ingress.ObjectMeta.Name, "flinkjobcluster-sample-historyserver")
+	assert.Equal(
+		t, ingress.Spec.Rules[0].Host, "flinkjobcluster-sample-history.example.com")
+	assert.Equal(
+		t,
+		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName,
+		"flinkjobcluster-sample-historyserver")
+
+	// The History Server and the ConfigMap survive the cleanup of the cluster.
+	cluster.Status.Components.Job = &v1beta1.JobStatus{
+		State: v1beta1.JobStateSucceeded,
+	}
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Assert(t, desiredState.JmDeployment == nil)
+	assert.Assert(t, desiredState.TmDeployment == nil)
+	assert.Assert(t, desiredState.ConfigMap != nil)
+	assert.Assert(t, desiredState.HistoryServerDeployment != nil)
+	assert.Assert(t, desiredState.HistoryServerService != nil)
+	assert.Assert(t, desiredState.HistoryServerIngress != nil)
+
+	cluster.Spec.HistoryServer = nil
+	desiredState = getDesiredClusterState(cluster, time.Now())
+	assert.Assert(t, desiredState.ConfigMap == nil)
+	assert.Assert(t, desiredState.HistoryServerDeployment == nil)
+	assert.Assert(t, desiredState.HistoryServerService == nil)
+	assert.Assert(t, desiredState.HistoryServerIngress == nil)
+}
+
+func TestCalFlinkProcessSize(t *testing.T) {
+	var memoryOffHeapRatio int32 = 25
+	var memoryOffHeapMin = resource.MustParse("600M")
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			FlinkVersion: "1.10",
+			JobManager: v1beta1.JobManagerSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+				MemoryOffHeapRatio: &memoryOffHeapRatio,
+				MemoryOffHeapMin:   memoryOffHeapMin,
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceMemory: resource.MustParse("4G"),
+					},
+				},
+				MemoryOffHeapRatio: &memoryOffHeapRatio,
+				MemoryOffHeapMin:   memoryOffHeapMin,
+			},
+		},
+	}
+
+	// Flink 1.10: only the TaskManager uses the unified memory model.
+	assert.DeepEqual(
+		t,
+		calFlinkHeapSize(cluster),
+		map[string]string{"jobmanager.heap.size": "474m"})
+	assert.DeepEqual(
+		t,
+		calFlinkProcessSize(cluster),
+		map[string]string{"taskmanager.memory.process.size": "3814m"})
+
+	// Flink 1.11+: both components use the unified memory model.
+	cluster.Spec.FlinkVersion = "1.12.2"
+	assert.Assert(t, len(calFlinkHeapSize(cluster)) == 0)
+	assert.DeepEqual(
+		t,
+		calFlinkProcessSize(cluster),
+		map[string]string{
+			"jobmanager.memory.process.size":  "1024m",
+			"taskmanager.memory.process.size": "3814m",
+		})
+
+	// No version: the legacy heap sizes.
+	cluster.Spec.FlinkVersion = ""
+	assert.Assert(t, len(calFlinkHeapSize(cluster)) == 2)
+	assert.Assert(t, len(calFlinkProcessSize(cluster)) == 0)
+}
+
+func TestGetCleanupAction(t *testing.T) {
+	var cluster = v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{
+			Job: &v1beta1.JobSpec{
+				CleanupPolicy: &v1beta1.CleanupPolicy{
+					AfterJobSucceeds:  v1beta1.CleanupActionDeleteCluster,
+					AfterJobFails:     v1beta1.CleanupActionKeepCluster,
+					AfterJobCancelled: v1beta1.CleanupActionDeleteTaskManager,
+				},
+			},
+		},
+	}
+	assert.Equal(t, getCleanupAction(&cluster), v1beta1.CleanupAction(""))
+
+	cluster.Status.Components.Job = &v1beta1.JobStatus{
+		State: v1beta1.JobStateRunning,
+	}
+	assert.Equal(t, getCleanupAction(&cluster), v1beta1.CleanupAction(""))
+
+	cluster.Status.Components.Job.State = v1beta1.JobStateSucceeded
+	assert.Equal(t, getCleanupAction(&cluster), v1beta1.CleanupAction(v1beta1.CleanupActionDeleteCluster))
+	assert.Assert(t, shouldCleanup(&cluster, "JobManagerDeployment"))
+
+	cluster.Status.Components.Job.State = v1beta1.JobStateCancelled
+	assert.Equal(t, getCleanupAction(&cluster), v1beta1.CleanupAction(v1beta1.CleanupActionDeleteTaskManager))
+	assert.Assert(t, !shouldCleanup(&cluster, "JobManagerDeployment"))
+	assert.Assert(t, shouldCleanup(&cluster, "TaskManagerDeployment"))
+}
+
+func TestRestartNonce(t *testing.T) {
+	var rpcPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var cluster = v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.10.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &rpcPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Ports: v1beta1.TaskManagerPorts{
+					RPC:   &rpcPort,
+					Data:  &dataPort,
+					Query: &queryPort,
+				},
+			},
+			Job: &v1beta1.JobSpec{JarFile: "/opt/flink/job.jar"},
+		},
+	}
+	var jobSpecHash = getJobSpecHash(&cluster)
+	var _, ok = getFlinkConfAnnotations(&cluster)[restartNonceAnnotation]
+	assert.Assert(t, !ok)
+
+	// Changing the nonce restarts the pods and upgrades the job.
+	cluster.Spec.RestartNonce = "2020-06-01T12:00:00Z"
+	assert.Equal(
+		t,
+		getFlinkConfAnnotations(&cluster)[restartNonceAnnotation],
+		"2020-06-01T12:00:00Z")
+	assert.Assert(t, getJobSpecHash(&cluster) != jobSpecHash)
+}
+
+func TestGetProbe(t *testing.T) {
+	var defaultProbe = corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(6122)},
+		},
+		PeriodSeconds:    5,
+		FailureThreshold: 60,
+	}
+	assert.DeepEqual(t, *getProbe(nil, defaultProbe), defaultProbe)
+
+	// Only the thresholds are tuned.
+	var tuned = corev1.Probe{PeriodSeconds: 10, FailureThreshold: 3}
+	assert.DeepEqual(t, *getProbe(&tuned, defaultProbe), corev1.Probe{
+		Handler:          defaultProbe.Handler,
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	})
+
+	// The handler is replaced.
+	var exec = corev1.Probe{
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{Command: []string{"/bin/healthy"}},
+		},
+	}
+	assert.DeepEqual(t, *getProbe(&exec, defaultProbe), exec)
+}
+
+func TestGetShutdownProperties(t *testing.T) {
+	var clusterSpec = v1beta1.FlinkClusterSpec{}
+	assert.DeepEqual(t, getShutdownProperties(&clusterSpec), map[string]string{})
+
+	// The shortest grace period of the JobManager and TaskManagers.
+	var jmGracePeriod int64 = 60
+	var tmGracePeriod int64 = 45
+	clusterSpec.JobManager.TerminationGracePeriodSeconds = &jmGracePeriod
+	clusterSpec.TaskManager.TerminationGracePeriodSeconds = &tmGracePeriod
+	assert.DeepEqual(t, getShutdownProperties(&clusterSpec), map[string]string{
+		"cluster.services.shutdown-timeout": "40000",
+	})
+
+	// A grace period shorter than the margin.
+	tmGracePeriod = 3
+	assert.DeepEqual(t, getShutdownProperties(&clusterSpec), map[string]string{
+		"cluster.services.shutdown-timeout": "1000",
+	})
+}
+
+func TestGetIstioAnnotations(t *testing.T) {
+	var cluster = v1beta1.FlinkCluster{}
+	cluster.Default()
+	assert.Assert(t, getIstioAnnotations(&cluster, nil) == nil)
+
+	cluster.Spec.ServiceMesh = &v1beta1.ServiceMeshSpec{IstioEnabled: true}
+	assert.DeepEqual(t, getIstioAnnotations(&cluster, nil), map[string]string{
+		"proxy.istio.io/config": `{"holdApplicationUntilProxyStarts": true}`,
+	})
+
+	// The Flink ports of the TaskManager and the metrics port.
+	cluster.Spec.Metrics = &v1beta1.MetricsSpec{
+		Prometheus: &v1beta1.PrometheusMetricsSpec{},
+	}
+	cluster.Default()
+	var tmPorts = cluster.Spec.TaskManager.Ports
+	assert.DeepEqual(t,
+		getIstioAnnotations(
+			&cluster, []int32{*tmPorts.RPC, *tmPorts.Data, *tmPorts.Query}),
+		map[string]string{
+			"proxy.istio.io/config":                         `{"holdApplicationUntilProxyStarts": true}`,
+			"traffic.sidecar.istio.io/excludeInboundPorts":  "6122,6121,6125,9249",
+			"traffic.sidecar.istio.io/excludeOutboundPorts": "6123,6124,6125,6122,6121",
+		})
+}
+
+func TestGetSidecarShutdownURLs(t *testing.T) {
+	var cluster = v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{
+			Job: &v1beta1.JobSpec{
+				SidecarShutdownURLs: []string{"http://127.0.0.1:9091/quitquitquit"},
+			},
+		},
+	}
+	assert.DeepEqual(t,
+		getSidecarShutdownURLs(&cluster),
+		[]string{"http://127.0.0.1:9091/quitquitquit"})
+
+	cluster.Spec.ServiceMesh = &v1beta1.ServiceMeshSpec{IstioEnabled: true}
+	assert.DeepEqual(t,
+		getSidecarShutdownURLs(&cluster),
+		[]string{
+			"http://127.0.0.1:15020/quitquitquit",
+			"http://127.0.0.1:9091/quitquitquit",
+		})
+}
+
+func TestGetDesiredUIAuthProxy(t *testing.T) {
+	var jmRPCPort int32 = 6123
+	var jmBlobPort int32 = 6124
+	var jmQueryPort int32 = 6125
+	var jmUIPort int32 = 8081
+	var proxyPort int32 = 4180
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				AccessScope: v1beta1.AccessScopeExternal,
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &jmRPCPort,
+					Blob:  &jmBlobPort,
+					Query: &jmQueryPort,
+					UI:    &jmUIPort,
+				},
+			},
+		},
+	}
+
+	// Without the proxy, the UI port of the JobManager service is used.
+	assert.Assert(t, convertUIAuthProxy(cluster.Spec.JobManager.UIAuthProxy) == nil)
+	assert.Assert(t, getDesiredJobManagerRESTService(cluster) == nil)
+	assert.Equal(t,
+		getDesiredJobManagerService(cluster).Spec.Ports[3].TargetPort,
+		intstr.FromString("ui"))
+	assert.Equal(t,
+		getFlinkAPIBaseURL(cluster),
+		"http://mycluster-jobmanager.default.svc.cluster.local:8081")
+
+	cluster.Spec.JobManager.UIAuthProxy = &v1beta1.UIAuthProxySpec{
+		Container: corev1.Container{
+			Name:  "ui-auth-proxy",
+			Image: "quay.io/oauth2-proxy/oauth2-proxy:v7.2.1",
+			Args:  []string{"--upstream=http://127.0.0.1:8081"},
+		},
+		Port: &proxyPort,
+	}
+	assert.DeepEqual(t,
+		*convertUIAuthProxy(cluster.Spec.JobManager.UIAuthProxy),
+		corev1.Container{
+			Name:  "ui-auth-proxy",
+			Image: "quay.io/oauth2-proxy/oauth2-proxy:v7.2.1",
+			Args:  []string{"--upstream=http://127.0.0.1:8081"},
+			Ports: []corev1.ContainerPort{{Name: "ui-proxy", ContainerPort: 4180}},
+		})
+	assert.Equal(t, len(cluster.Spec.JobManager.UIAuthProxy.Container.Ports), 0)
+
+	// The UI port is routed to the proxy.
+	var jmService = getDesiredJobManagerService(cluster)
+	assert.Equal(t, jmService.Spec.Ports[3].Name, "ui")
+	assert.Equal(t, jmService.Spec.Ports[3].TargetPort, intstr.FromString("ui-proxy"))
+
+	// The operator uses the internal REST service which bypasses the proxy.
+	var labels = map[string]string{
+		"cluster":   "mycluster",
+		"app":       "flink",
+		"component": "jobmanager",
+	}
+	assert.DeepEqual(t,
+		*getDesiredJobManagerRESTService(cluster),
+		corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "mycluster-jobmanager-rest",
+				OwnerReferences: []metav1.OwnerReference{
+					toOwnerReference(cluster)},
+				Labels: labels,
+			},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceTypeClusterIP,
+				Selector: labels,
+				Ports: []corev1.ServicePort{{
+					Name:       "ui",
+					Port:       8081,
+					TargetPort: intstr.FromString("ui"),
+				}},
+			},
+		})
+	assert.Equal(t,
+		getFlinkAPIBaseURL(cluster),
+		"http://mycluster-jobmanager-rest.default.svc.cluster.local:8081")
+}
+
+func TestConvertFlinkPropertiesFrom(t *testing.T) {
+	var envVars, args = convertFlinkPropertiesFrom(nil)
+	assert.Assert(t, envVars == nil && args == nil)
+
+	var secretKeyRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "s3-credentials"},
+		Key:                  "secret-key",
+	}
+	envVars, args = convertFlinkPropertiesFrom([]v1beta1.FlinkPropertySource{
+		{
+			Name:      "s3.secret-key",
+			ValueFrom: v1beta1.FlinkPropertyValueSource{SecretKeyRef: secretKeyRef},
+		},
+	})
+	assert.DeepEqual(t, envVars, []corev1.EnvVar{{
+		Name:      "FLINK_PROPERTY_FROM_0",
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: secretKeyRef},
+	}})
+	assert.DeepEqual(t, args, []string{
+		"-D", "s3.secret-key=$(FLINK_PROPERTY_FROM_0)",
+	})
 }
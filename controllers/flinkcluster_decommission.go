@@ -0,0 +1,151 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The pods of a ReplicaSet with the lowest deletion cost are removed first on
+// scale-down, it is ignored by StatefulSets.
+const podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+// Checks whether the scale-down of the TaskManagers waits for them to become
+// idle.
+func isTaskManagerDecommissionEnabled(cluster *v1beta1.FlinkCluster) bool {
+	var timeoutSeconds = cluster.Spec.TaskManager.DecommissionTimeoutSeconds
+	return timeoutSeconds != nil && *timeoutSeconds > 0
+}
+
+// Gets the number of TaskManagers to be removed by the pending scale-down, 0
+// if the TaskManagers are not being scaled down.
+func getTaskManagerScaleDown(observed *ObservedClusterState) int32 {
+	var cluster = observed.cluster
+	if cluster == nil || cluster.Spec.TaskManager.Replicas == nil {
+		return 0
+	}
+	var observedReplicas *int32
+	if observed.tmDeployment != nil {
+		observedReplicas = observed.tmDeployment.Spec.Replicas
+	} else if observed.tmStatefulSet != nil {
+		observedReplicas = observed.tmStatefulSet.Spec.Replicas
+	}
+	var desiredReplicas = *cluster.Spec.TaskManager.Replicas
+	if observedReplicas == nil || *observedReplicas <= desiredReplicas {
+		return 0
+	}
+	return *observedReplicas - desiredReplicas
+}
+
+// Checks whether the pending scale-down waits for the TaskManagers to be
+// removed to become idle, until the decommission timeout elapses. The
+// TaskManagers of a cluster which is not running are removed without waiting.
+func isTaskManagerDecommissionPending(
+	observed *ObservedClusterState, now time.Time) bool {
+	var cluster = observed.cluster
+	if cluster == nil ||
+		!isTaskManagerDecommissionEnabled(cluster) ||
+		cluster.Status.State != v1beta1.ClusterStateRunning ||
+		getTaskManagerScaleDown(observed) == 0 {
+		return false
+	}
+	var startTime = cluster.Status.Components.TaskManagerDeployment.DecommissionStartTime
+	if startTime != "" {
+		var tc = &TimeConverter{}
+		var deadline = tc.FromString(startTime).Add(
+			time.Duration(*cluster.Spec.TaskManager.DecommissionTimeoutSeconds) *
+				time.Second)
+		if !now.Before(deadline) {
+			return false
+		}
+	}
+	return !isTaskManagerScaleDownIdle(observed)
+}
+
+// Checks whether the TaskManagers to be removed by the pending scale-down are
+// idle. The pods of a StatefulSet are removed from the highest ordinal, so they
+// must be idle, while any idle pods of a deployment are removed first by their
+// deletion cost. It is false if the TaskManagers were not observed.
+func isTaskManagerScaleDownIdle(observed *ObservedClusterState) bool {
+	if observed.flinkTaskManagers == nil {
+		return false
+	}
+	var scaleDown = getTaskManagerScaleDown(observed)
+	var usedSlots = getTaskManagerPodUsedSlots(
+		observed.tmPods, observed.flinkTaskManagers)
+	if observed.tmStatefulSet != nil {
+		var replicas = *observed.cluster.Spec.TaskManager.Replicas
+		for i := replicas; i < replicas+scaleDown; i++ {
+			var podName = fmt.Sprintf("%s-%d", observed.tmStatefulSet.Name, i)
+			if usedSlots[podName] > 0 {
+				return false
+			}
+		}
+		return true
+	}
+	var idlePods int32
+	for _, slots := range usedSlots {
+		if slots == 0 {
+			idlePods++
+		}
+	}
+	return idlePods >= scaleDown
+}
+
+// Gets the task slots used by the TaskManagers of each pod by the pod name.
+// The TaskManagers are matched with the pods by the host of their RPC
+// address, which is the pod IP, or the pod hostname for a StatefulSet. The
+// pods being deleted are skipped, and the pods without a registered
+// TaskManager use no slots.
+func getTaskManagerPodUsedSlots(
+	pods []corev1.Pod, taskManagers []flinkclient.TaskManager) map[string]int32 {
+	var usedSlots = map[string]int32{}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		usedSlots[pod.Name] = 0
+		for _, taskManager := range taskManagers {
+			var host = getTaskManagerHost(taskManager.Path)
+			if host != "" && (host == pod.Status.PodIP ||
+				host == pod.Name || strings.HasPrefix(host, pod.Name+".")) {
+				usedSlots[pod.Name] += taskManager.SlotsNumber - taskManager.FreeSlots
+			}
+		}
+	}
+	return usedSlots
+}
+
+// Gets the host of the RPC address of a TaskManager, e.g., `10.8.0.5` of
+// `akka.tcp://flink@10.8.0.5:6122/user/rpc/taskmanager_0`.
+func getTaskManagerHost(path string) string {
+	var start = strings.Index(path, "@")
+	if start < 0 {
+		return ""
+	}
+	var host = path[start+1:]
+	if end := strings.IndexAny(host, ":/"); end >= 0 {
+		host = host[:end]
+	}
+	return host
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetTaskManagerHost(t *testing.T) {
+	assert.Equal(t,
+		getTaskManagerHost("akka.tcp://flink@10.8.0.5:6122/user/rpc/taskmanager_0"),
+		"10.8.0.5")
+	assert.Equal(t,
+		getTaskManagerHost("akka.tcp://flink@mycluster-taskmanager-1.mycluster-taskmanager:6122/user/rpc/taskmanager_0"),
+		"mycluster-taskmanager-1.mycluster-taskmanager")
+	assert.Equal(t, getTaskManagerHost(""), "")
+}
+
+func TestGetTaskManagerPodUsedSlots(t *testing.T) {
+	var now = metav1.Now()
+	var pods = []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tm-a"},
+			Status:     corev1.PodStatus{PodIP: "10.8.0.5"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tm-b"},
+			Status:     corev1.PodStatus{PodIP: "10.8.0.6"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tm-c"},
+			Status:     corev1.PodStatus{PodIP: "10.8.0.7"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tm-d", DeletionTimestamp: &now},
+			Status:     corev1.PodStatus{PodIP: "10.8.0.8"},
+		},
+	}
+	var taskManagers = []flinkclient.TaskManager{
+		{Path: "akka.tcp://flink@10.8.0.5:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 0},
+		{Path: "akka.tcp://flink@10.8.0.6:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 2},
+		{Path: "akka.tcp://flink@10.8.0.8:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 1},
+	}
+	assert.DeepEqual(t,
+		getTaskManagerPodUsedSlots(pods, taskManagers),
+		map[string]int32{"tm-a": 2, "tm-b": 0, "tm-c": 0})
+}
+
+func TestIsTaskManagerDecommissionPending(t *testing.T) {
+	var now = time.Now()
+	var tc = &TimeConverter{}
+	var timeoutSeconds int32 = 300
+	var desiredReplicas int32 = 1
+	var observedReplicas int32 = 3
+	var cluster = v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas:                   &desiredReplicas,
+				DecommissionTimeoutSeconds: &timeoutSeconds,
+			},
+		},
+		Status: v1beta1.FlinkClusterStatus{State: v1beta1.ClusterStateRunning},
+	}
+	var pods = []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tm-a"},
+			Status:     corev1.PodStatus{PodIP: "10.8.0.5"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tm-b"},
+			Status:     corev1.PodStatus{PodIP: "10.8.0.6"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tm-c"},
+			Status:     corev1.PodStatus{PodIP: "10.8.0.7"},
+		},
+	}
+	var observed = ObservedClusterState{
+		cluster: &cluster,
+		tmDeployment: &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{Replicas: &observedReplicas},
+		},
+		tmPods: pods,
+		flinkTaskManagers: []flinkclient.TaskManager{
+			{Path: "akka.tcp://flink@10.8.0.5:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 0},
+			{Path: "akka.tcp://flink@10.8.0.6:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 2},
+			{Path: "akka.tcp://flink@10.8.0.7:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 1},
+		},
+	}
+	assert.Equal(t, getTaskManagerScaleDown(&observed), int32(2))
+
+	// Only one of the two TaskManagers to be removed is idle.
+	assert.Assert(t, isTaskManagerDecommissionPending(&observed, now))
+
+	// The TaskManagers were not observed.
+	var notObserved = observed
+	notObserved.flinkTaskManagers = nil
+	assert.Assert(t, isTaskManagerDecommissionPending(&notObserved, now))
+
+	// The decommission timed out.
+	cluster.Status.Components.TaskManagerDeployment.DecommissionStartTime =
+		tc.ToString(now.Add(-10 * time.Minute))
+	assert.Assert(t, !isTaskManagerDecommissionPending(&observed, now))
+	cluster.Status.Components.TaskManagerDeployment.DecommissionStartTime =
+		tc.ToString(now.Add(-time.Minute))
+	assert.Assert(t, isTaskManagerDecommissionPending(&observed, now))
+
+	// Enough TaskManagers are idle.
+	observed.flinkTaskManagers[2].FreeSlots = 2
+	assert.Assert(t, !isTaskManagerDecommissionPending(&observed, now))
+
+	// The pods of a StatefulSet are removed from the highest ordinal.
+	observed.tmDeployment = nil
+	observed.tmStatefulSet = &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "tm"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &observedReplicas},
+	}
+	observed.tmPods = []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "tm-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tm-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tm-2"}},
+	}
+	observed.flinkTaskManagers = []flinkclient.TaskManager{
+		{Path: "akka.tcp://flink@tm-0.tm:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 2},
+		{Path: "akka.tcp://flink@tm-1.tm:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 2},
+		{Path: "akka.tcp://flink@tm-2.tm:6122/user/rpc/taskmanager_0", SlotsNumber: 2, FreeSlots: 1},
+	}
+	assert.Assert(t, isTaskManagerDecommissionPending(&observed, now))
+	observed.flinkTaskManagers[2].FreeSlots = 2
+	assert.Assert(t, !isTaskManagerDecommissionPending(&observed, now))
+
+	// The decommission is disabled.
+	observed.flinkTaskManagers[2].FreeSlots = 1
+	cluster.Spec.TaskManager.DecommissionTimeoutSeconds = nil
+	assert.Assert(t, !isTaskManagerDecommissionPending(&observed, now))
+}
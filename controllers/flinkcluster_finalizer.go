@@ -0,0 +1,224 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// The running jobs are cancelled without a final savepoint after this
+	// timeout since the deletion of the cluster.
+	deletionSavepointTimeout = 5 * time.Minute
+
+	// The finalizer is removed after this timeout since the deletion of the
+	// cluster even if the cleanup hasn't finished, e.g., when the JobManager
+	// is unreachable.
+	deletionFinalizerTimeout = 10 * time.Minute
+)
+
+// A component which is deleted before the finalizer of the cluster is
+// removed.
+type finalizedComponent struct {
+	name   string
+	object runtime.Object
+}
+
+// Checks whether the cluster has the finalizer of the operator.
+func hasClusterFinalizer(cluster *v1beta1.FlinkCluster) bool {
+	for _, finalizer := range cluster.Finalizers {
+		if finalizer == v1beta1.ClusterFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// Adds the finalizer to the cluster, so that its deletion waits for the
+// cleanup by the operator.
+func (reconciler *ClusterReconciler) addClusterFinalizer() error {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster.DeepCopy()
+
+	log.Info("Adding finalizer", "finalizer", v1beta1.ClusterFinalizer)
+	cluster.Finalizers = append(cluster.Finalizers, v1beta1.ClusterFinalizer)
+	var err = reconciler.k8sClient.Update(reconciler.context, cluster)
+	if err != nil {
+		log.Error(err, "Failed to add finalizer")
+	}
+	return err
+}
+
+// Finalizes the deletion of the cluster before the Kubernetes garbage
+// collector deletes it, so that the job isn't left running headless and the
+// cloud load balancers are released. It cancels the running jobs first, after
+// taking a final savepoint if `takeSavepointOnDelete` is true, then deletes
+// the components and waits for them to be gone, and finally removes the
+// finalizer. The final savepoint is given up after
+// `deletionSavepointTimeout`, and the finalizer is removed after
+// `deletionFinalizerTimeout` with a warning event even if the cleanup hasn't
+// finished, so that a dead JobManager doesn't block the deletion.
+func (reconciler *ClusterReconciler) reconcileDeletion() (ctrl.Result, error) {
+	var log = reconciler.log
+	var observed = reconciler.observed
+	var cluster = observed.cluster
+
+	if !hasClusterFinalizer(cluster) {
+		log.Info("The cluster is being deleted, no action to take")
+		return ctrl.Result{}, nil
+	}
+
+	var now = time.Now()
+	if isDeletionTimedOut(cluster, deletionFinalizerTimeout, now) {
+		log.Info(
+			"Timed out finalizing the deletion",
+			"timeout", deletionFinalizerTimeout,
+			"runningJobs", len(observed.flinkRunningJobIDs))
+		reconciler.recorder.Event(
+			cluster,
+			corev1.EventTypeWarning,
+			"FinalizerTimedOut",
+			fmt.Sprintf(
+				"Removing the finalizer without finishing the cleanup after %v, the jobs may be left running",
+				deletionFinalizerTimeout))
+		return ctrl.Result{}, reconciler.removeClusterFinalizer()
+	}
+
+	if len(observed.flinkRunningJobIDs) > 0 {
+		var jobSpec = cluster.Spec.Job
+		var takeSavepoint = jobSpec != nil &&
+			jobSpec.TakeSavepointOnDelete != nil && *jobSpec.TakeSavepointOnDelete
+		if takeSavepoint &&
+			isDeletionTimedOut(cluster, deletionSavepointTimeout, now) {
+			log.Info(
+				"Timed out taking the final savepoint, cancelling running jobs without savepoint",
+				"timeout", deletionSavepointTimeout)
+			takeSavepoint = false
+		}
+		log.Info("Cancelling running jobs before deleting the cluster")
+		var err = reconciler.cancelRunningJobs(takeSavepoint)
+		return requeueResult, err
+	}
+
+	var remaining = 0
+	if observed.job != nil {
+		remaining++
+		if observed.job.DeletionTimestamp == nil {
+			var err = reconciler.deleteJob(observed.job)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+	for _, component := range getFinalizedComponents(&observed) {
+		remaining++
+		var accessor, err = meta.Accessor(component.object)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if accessor.GetDeletionTimestamp() != nil {
+			continue
+		}
+		err = reconciler.deleteObject(component.object, component.name)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if remaining > 0 {
+		log.Info("Waiting for the components to be deleted", "remaining", remaining)
+		return requeueResult, nil
+	}
+
+	return ctrl.Result{}, reconciler.removeClusterFinalizer()
+}
+
+// Checks whether the cluster has been being deleted for longer than the
+// timeout.
+func isDeletionTimedOut(
+	cluster *v1beta1.FlinkCluster, timeout time.Duration, now time.Time) bool {
+	return cluster.DeletionTimestamp != nil &&
+		now.After(cluster.DeletionTimestamp.Add(timeout))
+}
+
+// Removes the finalizer from the cluster, so that it is deleted by the
+// Kubernetes garbage collector.
+func (reconciler *ClusterReconciler) removeClusterFinalizer() error {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+
+	log.Info("Removing finalizer", "finalizer", v1beta1.ClusterFinalizer)
+	var updatedCluster = cluster.DeepCopy()
+	updatedCluster.Finalizers = nil
+	for _, finalizer := range cluster.Finalizers {
+		if finalizer != v1beta1.ClusterFinalizer {
+			updatedCluster.Finalizers = append(updatedCluster.Finalizers, finalizer)
+		}
+	}
+	var err = reconciler.k8sClient.Update(reconciler.context, updatedCluster)
+	if err != nil {
+		log.Error(err, "Failed to remove finalizer")
+	}
+	return err
+}
+
+// Gets the observed components other than the job submitter which are deleted
+// before the finalizer is removed. The other components, e.g., the ConfigMap and the RBAC objects,
+// are left to the garbage collector.
+func getFinalizedComponents(observed *ObservedClusterState) []finalizedComponent {
+	var components []finalizedComponent
+	if observed.jmDeployment != nil {
+		components = append(components, finalizedComponent{"JobManager deployment", observed.jmDeployment})
+	}
+	if observed.jmStatefulSet != nil {
+		components = append(components, finalizedComponent{"JobManager StatefulSet", observed.jmStatefulSet})
+	}
+	if observed.jmHeadlessService != nil {
+		components = append(components, finalizedComponent{"JobManager headless service", observed.jmHeadlessService})
+	}
+	if observed.jmService != nil {
+		components = append(components, finalizedComponent{"JobManager service", observed.jmService})
+	}
+	if observed.jmIngress != nil {
+		components = append(components, finalizedComponent{"JobManager ingress", observed.jmIngress})
+	}
+	if observed.tmDeployment != nil {
+		components = append(components, finalizedComponent{"TaskManager deployment", observed.tmDeployment})
+	}
+	if observed.tmStatefulSet != nil {
+		components = append(components, finalizedComponent{"TaskManager StatefulSet", observed.tmStatefulSet})
+	}
+	if observed.tmHeadlessService != nil {
+		components = append(components, finalizedComponent{"TaskManager headless service", observed.tmHeadlessService})
+	}
+	if observed.historyServerDeployment != nil {
+		components = append(components, finalizedComponent{"History Server deployment", observed.historyServerDeployment})
+	}
+	if observed.historyServerService != nil {
+		components = append(components, finalizedComponent{"History Server service", observed.historyServerService})
+	}
+	if observed.historyServerIngress != nil {
+		components = append(components, finalizedComponent{"History Server ingress", observed.historyServerIngress})
+	}
+	return components
+}
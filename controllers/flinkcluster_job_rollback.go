@@ -0,0 +1,200 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Checks whether a job other than `prevJobID` is running, i.e., the job
+// started after an upgrade or a rollback reached the RUNNING state.
+func isNewJobRunning(jobStatus *v1beta1.JobStatus, prevJobID string) bool {
+	return jobStatus.State == v1beta1.JobStateRunning &&
+		len(jobStatus.ID) > 0 &&
+		jobStatus.ID != prevJobID &&
+		jobStatus.FlinkJobState == "RUNNING"
+}
+
+// Gets the status of the upgrade after the previous job is stopped. When
+// `upgradeTimeoutSeconds` is specified, the upgrade is "Deploying" until the
+// upgraded job is running, so it can be rolled back if the job doesn't run in
+// time; otherwise, the upgrade is finished.
+func getDeployingJobUpgradeStatus(
+	cluster *v1beta1.FlinkCluster,
+	jobStatus *v1beta1.JobStatus) *v1beta1.JobUpgradeStatus {
+	var upgrade = jobStatus.Upgrade
+	if cluster.Spec.Job.UpgradeTimeoutSeconds == nil ||
+		upgrade == nil ||
+		upgrade.State == v1beta1.JobUpgradeStateFailed ||
+		upgrade.SpecHash != getJobSpecHash(cluster) ||
+		isJobCancelRequested(cluster) ||
+		jobStatus.State == v1beta1.JobStateSucceeded ||
+		jobStatus.State == v1beta1.JobStateCancelled ||
+		isNewJobRunning(jobStatus, upgrade.FromJobID) {
+		return nil
+	}
+	var deploying = upgrade.DeepCopy()
+	deploying.State = v1beta1.JobUpgradeStateDeploying
+	return deploying
+}
+
+// Updates the rollback of the job upgrade in the job status. The rollback
+// succeeds when the restored job is running, and it is cleared when the job
+// is upgraded to another job spec. The upgrade to the rolled back job spec
+// fails, so it is not deployed again until the job spec changes.
+func updateJobRollbackStatus(jobStatus *v1beta1.JobStatus) {
+	var rollback = jobStatus.Rollback
+	if rollback == nil {
+		return
+	}
+	if rollback.State == v1beta1.JobRollbackStateInProgress &&
+		isNewJobRunning(jobStatus, rollback.JobID) {
+		rollback.State = v1beta1.JobRollbackStateSucceeded
+	}
+	var upgrade = jobStatus.Upgrade
+	if upgrade == nil {
+		return
+	}
+	if upgrade.SpecHash == rollback.SpecHash {
+		if upgrade.State == v1beta1.JobUpgradeStateInProgress {
+			upgrade.State = v1beta1.JobUpgradeStateFailed
+			upgrade.Message = "The job spec was rolled back: " + rollback.Message
+		}
+	} else if rollback.State == v1beta1.JobRollbackStateSucceeded {
+		jobStatus.Rollback = nil
+	}
+}
+
+// Gets the JSON of the cluster spec which the job runs with, it is recorded
+// when `upgradeTimeoutSeconds` is specified and the job is running without an
+// upgrade or a rollback in progress.
+func getStableSpec(
+	cluster *v1beta1.FlinkCluster, jobStatus *v1beta1.JobStatus) string {
+	if cluster.Spec.Job.UpgradeTimeoutSeconds == nil {
+		return ""
+	}
+	if jobStatus.Upgrade != nil ||
+		(jobStatus.Rollback != nil &&
+			jobStatus.Rollback.State == v1beta1.JobRollbackStateInProgress) ||
+		jobStatus.State != v1beta1.JobStateRunning ||
+		jobStatus.FlinkJobState != "RUNNING" {
+		return jobStatus.StableSpec
+	}
+	var spec, err = json.Marshal(cluster.Spec)
+	if err != nil {
+		return jobStatus.StableSpec
+	}
+	return string(spec)
+}
+
+// Gets the time when the deploying upgrade is rolled back unless the upgraded
+// job is running, nil if the upgrade can't be rolled back.
+func getJobUpgradeRollbackTime(cluster *v1beta1.FlinkCluster) *time.Time {
+	var jobSpec = cluster.Spec.Job
+	var jobStatus = cluster.Status.Components.Job
+	if jobSpec == nil || jobSpec.UpgradeTimeoutSeconds == nil ||
+		jobStatus == nil || jobStatus.Upgrade == nil ||
+		jobStatus.Upgrade.State != v1beta1.JobUpgradeStateDeploying ||
+		len(jobStatus.StableSpec) == 0 {
+		return nil
+	}
+	var tc = &TimeConverter{}
+	var rollbackTime = tc.FromString(jobStatus.Upgrade.StartTime).Add(
+		time.Duration(*jobSpec.UpgradeTimeoutSeconds) * time.Second)
+	return &rollbackTime
+}
+
+// Checks whether the upgraded job didn't reach the RUNNING state within
+// `upgradeTimeoutSeconds` after the upgrade started.
+func shouldRollbackJobUpgrade(
+	cluster *v1beta1.FlinkCluster, now time.Time) bool {
+	var rollbackTime = getJobUpgradeRollbackTime(cluster)
+	return rollbackTime != nil && !now.Before(*rollbackTime)
+}
+
+// Rolls back the upgrade whose job didn't reach the RUNNING state in time. The
+// upgraded job is cancelled and its job submitter is deleted, then the cluster
+// spec is restored to the one the previous job ran with, and the job is
+// submitted from the savepoint taken before the upgrade in the following
+// reconciliations.
+func (reconciler *ClusterReconciler) rollbackJobUpgrade() error {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+	var jobStatus = cluster.Status.Components.Job
+	var upgrade = jobStatus.Upgrade
+
+	var stableSpec v1beta1.FlinkClusterSpec
+	var err = json.Unmarshal([]byte(jobStatus.StableSpec), &stableSpec)
+	if err != nil {
+		return fmt.Errorf("failed to parse the stable spec: %v", err)
+	}
+
+	log.Info(
+		"Rolling back job upgrade",
+		"specHash", upgrade.SpecHash,
+		"jobID", jobStatus.ID,
+		"savepoint", jobStatus.SavepointLocation)
+	err = reconciler.cancelRunningJobs(false /* takeSavepoint */)
+	if err != nil {
+		return err
+	}
+	if reconciler.observed.job != nil {
+		err = reconciler.deleteJob(reconciler.observed.job)
+		if err != nil {
+			return err
+		}
+	}
+
+	var clusterClone = cluster.DeepCopy()
+	clusterClone.Spec = stableSpec
+	err = reconciler.k8sClient.Update(reconciler.context, clusterClone)
+	if err != nil {
+		log.Error(err, "Failed to restore the cluster spec for rollback")
+		return err
+	}
+	var msg = fmt.Sprintf(
+		"The upgraded job didn't reach RUNNING within %v seconds",
+		*cluster.Spec.Job.UpgradeTimeoutSeconds)
+	reconciler.recorder.Event(
+		cluster,
+		corev1.EventTypeWarning,
+		"JobUpgradeRolledBack",
+		fmt.Sprintf(
+			"%v, rolling back to the previous spec from savepoint %v",
+			msg, jobStatus.SavepointLocation))
+
+	// The job is submitted again with the restored spec.
+	reconciler.observed.cluster = clusterClone
+	var newJobStatus = clusterClone.Status.Components.Job
+	newJobStatus.State = v1beta1.JobStatePending
+	newJobStatus.Upgrade = nil
+	newJobStatus.Rollback = &v1beta1.JobRollbackStatus{
+		SpecHash:  upgrade.SpecHash,
+		JobID:     jobStatus.ID,
+		Savepoint: jobStatus.SavepointLocation,
+		State:     v1beta1.JobRollbackStateInProgress,
+		Message:   msg,
+	}
+	setTimestamp(&newJobStatus.Rollback.Time)
+	return reconciler.k8sClient.Status().Update(
+		reconciler.context, clusterClone)
+}
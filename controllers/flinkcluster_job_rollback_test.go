@@ -0,0 +1,218 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+)
+
+func newTestRollbackCluster() *v1beta1.FlinkCluster {
+	var rpcPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var upgradeTimeoutSeconds int32 = 600
+	return &v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.10.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &rpcPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Ports: v1beta1.TaskManagerPorts{
+					RPC:   &rpcPort,
+					Data:  &dataPort,
+					Query: &queryPort,
+				},
+			},
+			Job: &v1beta1.JobSpec{
+				JarFile:               "/opt/flink/job.jar",
+				UpgradeTimeoutSeconds: &upgradeTimeoutSeconds,
+			},
+		},
+	}
+}
+
+func TestGetDeployingJobUpgradeStatus(t *testing.T) {
+	var cluster = newTestRollbackCluster()
+	var jobStatus = &v1beta1.JobStatus{
+		ID:            "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		State:         v1beta1.JobStateRunning,
+		FlinkJobState: "RUNNING",
+		Upgrade: &v1beta1.JobUpgradeStatus{
+			SpecHash:  getJobSpecHash(cluster),
+			State:     v1beta1.JobUpgradeStateInProgress,
+			FromJobID: "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		},
+	}
+	cluster.Status.Components.Job = jobStatus
+
+	// Deploying until the upgraded job is running.
+	var upgrade = getDeployingJobUpgradeStatus(cluster, jobStatus)
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateDeploying)
+	assert.Equal(t, upgrade.SpecHash, jobStatus.Upgrade.SpecHash)
+
+	jobStatus.ID = "2e9ddea53ee1bd2d9cae5ffb3da2dc6c"
+	jobStatus.FlinkJobState = "CREATED"
+	upgrade = getDeployingJobUpgradeStatus(cluster, jobStatus)
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateDeploying)
+
+	// The failed upgraded job is rolled back as well.
+	jobStatus.State = v1beta1.JobStateFailed
+	upgrade = getDeployingJobUpgradeStatus(cluster, jobStatus)
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateDeploying)
+
+	jobStatus.State = v1beta1.JobStateRunning
+	jobStatus.FlinkJobState = "RUNNING"
+	assert.Assert(t, getDeployingJobUpgradeStatus(cluster, jobStatus) == nil)
+
+	// Not tracked without the upgrade timeout.
+	jobStatus.FlinkJobState = "CREATED"
+	cluster.Spec.Job.UpgradeTimeoutSeconds = nil
+	assert.Assert(t, getDeployingJobUpgradeStatus(cluster, jobStatus) == nil)
+}
+
+func TestUpdateJobRollbackStatus(t *testing.T) {
+	var jobStatus = &v1beta1.JobStatus{
+		ID:            "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		State:         v1beta1.JobStatePending,
+		FlinkJobState: "RUNNING",
+		Rollback: &v1beta1.JobRollbackStatus{
+			SpecHash: "hash-2",
+			JobID:    "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+			State:    v1beta1.JobRollbackStateInProgress,
+			Message:  "The upgraded job didn't reach RUNNING within 600 seconds",
+		},
+	}
+
+	// Succeeded when the restored job is running.
+	updateJobRollbackStatus(jobStatus)
+	assert.Equal(t, jobStatus.Rollback.State, v1beta1.JobRollbackStateInProgress)
+	jobStatus.ID = "2e9ddea53ee1bd2d9cae5ffb3da2dc6c"
+	jobStatus.State = v1beta1.JobStateRunning
+	updateJobRollbackStatus(jobStatus)
+	assert.Equal(t, jobStatus.Rollback.State, v1beta1.JobRollbackStateSucceeded)
+
+	// The upgrade to the rolled back job spec fails.
+	jobStatus.Upgrade = &v1beta1.JobUpgradeStatus{
+		SpecHash: "hash-2",
+		State:    v1beta1.JobUpgradeStateInProgress,
+	}
+	updateJobRollbackStatus(jobStatus)
+	assert.Equal(t, jobStatus.Upgrade.State, v1beta1.JobUpgradeStateFailed)
+	assert.Equal(
+		t,
+		jobStatus.Upgrade.Message,
+		"The job spec was rolled back: The upgraded job didn't reach RUNNING within 600 seconds")
+
+	// Cleared when the job is upgraded to another job spec.
+	jobStatus.Upgrade = &v1beta1.JobUpgradeStatus{
+		SpecHash: "hash-3",
+		State:    v1beta1.JobUpgradeStateInProgress,
+	}
+	updateJobRollbackStatus(jobStatus)
+	assert.Equal(t, jobStatus.Upgrade.State, v1beta1.JobUpgradeStateInProgress)
+	assert.Assert(t, jobStatus.Rollback == nil)
+}
+
+func TestGetStableSpec(t *testing.T) {
+	var cluster = newTestRollbackCluster()
+	var jobStatus = &v1beta1.JobStatus{
+		State:         v1beta1.JobStateRunning,
+		FlinkJobState: "RUNNING",
+	}
+
+	var stableSpec = getStableSpec(cluster, jobStatus)
+	var spec v1beta1.FlinkClusterSpec
+	assert.NilError(t, json.Unmarshal([]byte(stableSpec), &spec))
+	assert.Equal(t, spec.Image.Name, "flink:1.10.0")
+	assert.Equal(t, spec.Job.JarFile, "/opt/flink/job.jar")
+	assert.Equal(t, *spec.Job.UpgradeTimeoutSeconds, int32(600))
+
+	// Kept while the job is being upgraded.
+	jobStatus.StableSpec = stableSpec
+	jobStatus.Upgrade = &v1beta1.JobUpgradeStatus{
+		State: v1beta1.JobUpgradeStateDeploying,
+	}
+	cluster.Spec.Image.Name = "flink:1.11.0"
+	assert.Equal(t, getStableSpec(cluster, jobStatus), stableSpec)
+
+	// Not recorded without the upgrade timeout.
+	cluster.Spec.Job.UpgradeTimeoutSeconds = nil
+	assert.Equal(t, getStableSpec(cluster, jobStatus), "")
+}
+
+func TestShouldRollbackJobUpgrade(t *testing.T) {
+	var tc = &TimeConverter{}
+	var now = time.Now()
+	var cluster = newTestRollbackCluster()
+	cluster.Status.Components.Job = &v1beta1.JobStatus{
+		StableSpec: "{}",
+		Upgrade: &v1beta1.JobUpgradeStatus{
+			State:     v1beta1.JobUpgradeStateDeploying,
+			StartTime: tc.ToString(now.Add(-5 * time.Minute)),
+		},
+	}
+	assert.Assert(t, !shouldRollbackJobUpgrade(cluster, now))
+	assert.Equal(
+		t,
+		*getJobUpgradeRollbackTime(cluster),
+		tc.FromString(cluster.Status.Components.Job.Upgrade.StartTime).
+			Add(10*time.Minute))
+	assert.Assert(t, shouldRollbackJobUpgrade(cluster, now.Add(5*time.Minute)))
+
+	// The upgrade is not rolled back before the job is stopped.
+	cluster.Status.Components.Job.Upgrade.State =
+		v1beta1.JobUpgradeStateInProgress
+	assert.Assert(t, !shouldRollbackJobUpgrade(cluster, now.Add(5*time.Minute)))
+
+	// There is no spec to roll back to.
+	cluster.Status.Components.Job.Upgrade.State =
+		v1beta1.JobUpgradeStateDeploying
+	cluster.Status.Components.Job.StableSpec = ""
+	assert.Assert(t, !shouldRollbackJobUpgrade(cluster, now.Add(5*time.Minute)))
+}
+
+func TestConvertFromSavepointForRollback(t *testing.T) {
+	var fromSavepoint = "gs://my-bucket/savepoints/savepoint-1"
+	var jobSpec = &v1beta1.JobSpec{FromSavepoint: &fromSavepoint}
+	var jobStatus = &v1beta1.JobStatus{
+		SavepointLocation: "gs://my-bucket/savepoints/savepoint-3",
+		Rollback: &v1beta1.JobRollbackStatus{
+			Savepoint: "gs://my-bucket/savepoints/savepoint-2",
+			State:     v1beta1.JobRollbackStateInProgress,
+		},
+	}
+	assert.Equal(
+		t,
+		*convertFromSavepoint(jobSpec, jobStatus, nil),
+		"gs://my-bucket/savepoints/savepoint-2")
+
+	jobStatus.Rollback.State = v1beta1.JobRollbackStateSucceeded
+	assert.Equal(t, *convertFromSavepoint(jobSpec, jobStatus, nil), fromSavepoint)
+}
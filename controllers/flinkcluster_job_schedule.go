@@ -0,0 +1,220 @@
+/*
+Copyright 2020 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Reconciles the schedule of a scheduled job. When a run is due, the previous
+// run is recorded in the run history and its job submitter is deleted, then
+// the job status is reset for the new run, which is submitted by the normal
+// job reconciliation. Returns true if the schedule took an action, then the
+// job is reconciled again after the status is updated; otherwise, the result
+// requeues the cluster at the next scheduled time.
+func (reconciler *ClusterReconciler) reconcileJobSchedule() (
+	ctrl.Result, bool, error) {
+	var log = reconciler.log
+	var observed = reconciler.observed
+	var cluster = observed.cluster
+	var jobSpec = cluster.Spec.Job
+	var jobStatus = cluster.Status.Components.Job
+	var now = time.Now()
+
+	// No more runs after the job is requested to be cancelled.
+	if jobSpec.CancelRequested != nil && *jobSpec.CancelRequested {
+		return ctrl.Result{}, false, nil
+	}
+
+	var dueTime, nextTime, err = getDueScheduleTime(cluster, now)
+	if err != nil {
+		log.Error(err, "Failed to get the next scheduled time")
+		return ctrl.Result{}, false, err
+	}
+	var result = ctrl.Result{RequeueAfter: nextTime.Sub(now)}
+	if dueTime == nil {
+		return result, false, nil
+	}
+
+	var tc = &TimeConverter{}
+	var runInProgress = jobStatus != nil &&
+		len(jobStatus.LastScheduleTime) > 0 &&
+		!isJobTerminated(jobSpec, jobStatus)
+	// Like the CronJob, the due run is started late when the previous run
+	// finishes.
+	if runInProgress &&
+		*jobSpec.ConcurrencyPolicy == v1beta1.JobConcurrencyPolicyForbid {
+		log.Info(
+			"Waiting for the previous run to finish before the scheduled run",
+			"scheduleTime", *dueTime)
+		return requeueResult, false, nil
+	}
+
+	if runInProgress {
+		log.Info(
+			"Replacing the previous run with the scheduled run",
+			"scheduleTime", *dueTime)
+		err = reconciler.cancelRunningJobs(false /* takeSavepoint */)
+		if err != nil {
+			return requeueResult, true, err
+		}
+	}
+	if observed.job != nil {
+		err = reconciler.deleteJob(observed.job)
+		if err != nil {
+			return requeueResult, true, err
+		}
+	}
+
+	log.Info("Starting the scheduled run", "scheduleTime", *dueTime)
+	var clusterClone = cluster.DeepCopy()
+	clusterClone.Status.Components.Job = getScheduledRunJobStatus(
+		jobSpec, jobStatus, *dueTime)
+	setTimestamp(&clusterClone.Status.LastUpdateTime)
+	err = reconciler.k8sClient.Status().Update(reconciler.context, clusterClone)
+	if err != nil {
+		return requeueResult, true, err
+	}
+	reconciler.recorder.Event(
+		cluster,
+		corev1.EventTypeNormal,
+		"RunStarted",
+		fmt.Sprintf("Started the run scheduled at %v", tc.ToString(*dueTime)))
+	return requeueResult, true, nil
+}
+
+// Gets the latest scheduled time which is not after now and has not been run
+// yet, nil if there is none, and the next scheduled time after now. The
+// schedule starts from the last scheduled run or the creation of the cluster.
+func getDueScheduleTime(cluster *v1beta1.FlinkCluster, now time.Time) (
+	*time.Time, time.Time, error) {
+	var schedule = *cluster.Spec.Job.Schedule
+	var jobStatus = cluster.Status.Components.Job
+	var from = cluster.CreationTimestamp.Time.UTC()
+	if jobStatus != nil && len(jobStatus.LastScheduleTime) > 0 {
+		var tc = &TimeConverter{}
+		from = tc.FromString(jobStatus.LastScheduleTime).UTC()
+	}
+	var dueTime *time.Time
+	var next, err = v1beta1.GetNextScheduleTime(schedule, from)
+	for err == nil && !next.After(now) {
+		var scheduleTime = next
+		dueTime = &scheduleTime
+		next, err = v1beta1.GetNextScheduleTime(schedule, next)
+	}
+	return dueTime, next, err
+}
+
+// Gets the job status of a new scheduled run. The previous run is recorded in
+// the run history, it is recorded as cancelled if it is replaced before it
+// finishes.
+func getScheduledRunJobStatus(
+	jobSpec *v1beta1.JobSpec,
+	jobStatus *v1beta1.JobStatus,
+	scheduleTime time.Time) *v1beta1.JobStatus {
+	var tc = &TimeConverter{}
+	var newJobStatus = &v1beta1.JobStatus{}
+	if jobStatus != nil {
+		jobStatus.DeepCopyInto(newJobStatus)
+		if len(jobStatus.LastScheduleTime) > 0 {
+			var run = v1beta1.JobRunStatus{
+				ScheduleTime:   jobStatus.LastScheduleTime,
+				ID:             jobStatus.ID,
+				State:          jobStatus.State,
+				FlinkJobState:  jobStatus.FlinkJobState,
+				StartTime:      jobStatus.StartTime,
+				FailureReasons: jobStatus.FailureReasons,
+			}
+			if !isJobStopped(jobStatus) {
+				run.State = v1beta1.JobStateCancelled
+			}
+			newJobStatus.RunHistory = appendJobRun(
+				jobStatus.RunHistory,
+				run,
+				*jobSpec.SuccessfulRunsHistoryLimit,
+				*jobSpec.FailedRunsHistoryLimit)
+		}
+	}
+	newJobStatus.ID = ""
+	newJobStatus.State = v1beta1.JobStatePending
+	newJobStatus.FlinkJobState = ""
+	newJobStatus.StartTime = ""
+	newJobStatus.FlinkJobRestarts = 0
+	newJobStatus.FailureReasons = nil
+	newJobStatus.FromSavepoint = ""
+	newJobStatus.LastCheckpointLocation = ""
+	newJobStatus.LastCheckpointTime = ""
+	newJobStatus.RestartCount = 0
+	newJobStatus.SubmissionAttempts = 0
+	newJobStatus.LastScheduleTime = tc.ToString(scheduleTime)
+	return newJobStatus
+}
+
+// Appends a finished run to the run history, and removes the oldest
+// successful and failed runs beyond the history limits.
+func appendJobRun(
+	history []v1beta1.JobRunStatus,
+	run v1beta1.JobRunStatus,
+	successfulRunsLimit int32,
+	failedRunsLimit int32) []v1beta1.JobRunStatus {
+	var runs = append(append([]v1beta1.JobRunStatus{}, history...), run)
+	var successfulRuns, failedRuns int32
+	var kept = make([]bool, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].State == v1beta1.JobStateSucceeded {
+			successfulRuns++
+			kept[i] = successfulRuns <= successfulRunsLimit
+		} else {
+			failedRuns++
+			kept[i] = failedRuns <= failedRunsLimit
+		}
+	}
+	var newHistory []v1beta1.JobRunStatus
+	for i := range runs {
+		if kept[i] {
+			newHistory = append(newHistory, runs[i])
+		}
+	}
+	return newHistory
+}
+
+// Gets the Flink jobs of the current run of a scheduled job, i.e., the jobs
+// started after its job submitter was created.
+func getCurrentRunJobs(
+	overview []flinkclient.JobDetails,
+	submitter *batchv1.Job) *flinkclient.JobStatusList {
+	var jobList = &flinkclient.JobStatusList{Jobs: []flinkclient.JobStatus{}}
+	if submitter == nil {
+		return jobList
+	}
+	var createdMillis = submitter.CreationTimestamp.Time.UnixNano() / int64(time.Millisecond)
+	for _, job := range overview {
+		if job.StartTime >= createdMillis {
+			jobList.Jobs = append(
+				jobList.Jobs,
+				flinkclient.JobStatus{ID: job.ID, Status: job.State})
+		}
+	}
+	return jobList
+}
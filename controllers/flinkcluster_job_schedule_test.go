@@ -0,0 +1,173 @@
+/*
+Copyright 2020 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"gotest.tools/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDueScheduleTime(t *testing.T) {
+	var schedule = "0 * * * *"
+	var cluster = v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(
+				time.Date(2020, 3, 1, 9, 30, 0, 0, time.UTC)),
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Job: &v1beta1.JobSpec{Schedule: &schedule},
+		},
+	}
+
+	// The first run is not due before the first scheduled time.
+	var dueTime, nextTime, err = getDueScheduleTime(
+		&cluster, time.Date(2020, 3, 1, 9, 45, 0, 0, time.UTC))
+	assert.NilError(t, err)
+	assert.Assert(t, dueTime == nil)
+	assert.Equal(t, nextTime, time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	// The latest missed run is due.
+	dueTime, nextTime, err = getDueScheduleTime(
+		&cluster, time.Date(2020, 3, 1, 12, 15, 0, 0, time.UTC))
+	assert.NilError(t, err)
+	assert.Equal(t, *dueTime, time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, nextTime, time.Date(2020, 3, 1, 13, 0, 0, 0, time.UTC))
+
+	// The schedule continues from the last scheduled run.
+	cluster.Status.Components.Job = &v1beta1.JobStatus{
+		LastScheduleTime: "2020-03-01T12:00:00Z",
+	}
+	dueTime, nextTime, err = getDueScheduleTime(
+		&cluster, time.Date(2020, 3, 1, 12, 30, 0, 0, time.UTC))
+	assert.NilError(t, err)
+	assert.Assert(t, dueTime == nil)
+	assert.Equal(t, nextTime, time.Date(2020, 3, 1, 13, 0, 0, 0, time.UTC))
+}
+
+func TestGetScheduledRunJobStatus(t *testing.T) {
+	var successfulRunsHistoryLimit = int32(3)
+	var failedRunsHistoryLimit = int32(1)
+	var jobSpec = v1beta1.JobSpec{
+		SuccessfulRunsHistoryLimit: &successfulRunsHistoryLimit,
+		FailedRunsHistoryLimit:     &failedRunsHistoryLimit,
+	}
+	var jobStatus = v1beta1.JobStatus{
+		Name:              "my-cluster-job",
+		ID:                "a7f9d7fbb2bd2aa6bb1d0e4c2e0d0a16",
+		State:             v1beta1.JobStateRunning,
+		FlinkJobState:     "RUNNING",
+		StartTime:         "2020-03-01T12:00:05Z",
+		SavepointLocation: "gs://my-bucket/savepoint-123",
+		RestartCount:      1,
+		LastScheduleTime:  "2020-03-01T12:00:00Z",
+	}
+
+	var newJobStatus = getScheduledRunJobStatus(
+		&jobSpec, &jobStatus, time.Date(2020, 3, 1, 13, 0, 0, 0, time.UTC))
+	assert.DeepEqual(
+		t,
+		*newJobStatus,
+		v1beta1.JobStatus{
+			Name:              "my-cluster-job",
+			State:             v1beta1.JobStatePending,
+			SavepointLocation: "gs://my-bucket/savepoint-123",
+			LastScheduleTime:  "2020-03-01T13:00:00Z",
+			RunHistory: []v1beta1.JobRunStatus{
+				{
+					ScheduleTime:  "2020-03-01T12:00:00Z",
+					ID:            "a7f9d7fbb2bd2aa6bb1d0e4c2e0d0a16",
+					State:         v1beta1.JobStateCancelled,
+					FlinkJobState: "RUNNING",
+					StartTime:     "2020-03-01T12:00:05Z",
+				},
+			},
+		})
+
+	// The first run.
+	newJobStatus = getScheduledRunJobStatus(
+		&jobSpec, nil, time.Date(2020, 3, 1, 13, 0, 0, 0, time.UTC))
+	assert.DeepEqual(
+		t,
+		*newJobStatus,
+		v1beta1.JobStatus{
+			State:            v1beta1.JobStatePending,
+			LastScheduleTime: "2020-03-01T13:00:00Z",
+		})
+}
+
+func TestAppendJobRun(t *testing.T) {
+	var history = []v1beta1.JobRunStatus{
+		{ScheduleTime: "2020-03-01T09:00:00Z", State: v1beta1.JobStateSucceeded},
+		{ScheduleTime: "2020-03-01T10:00:00Z", State: v1beta1.JobStateFailed},
+		{ScheduleTime: "2020-03-01T11:00:00Z", State: v1beta1.JobStateSucceeded},
+	}
+
+	assert.DeepEqual(
+		t,
+		appendJobRun(
+			history,
+			v1beta1.JobRunStatus{
+				ScheduleTime: "2020-03-01T12:00:00Z",
+				State:        v1beta1.JobStateCancelled,
+			},
+			1, /* successfulRunsLimit */
+			1 /* failedRunsLimit */),
+		[]v1beta1.JobRunStatus{
+			{ScheduleTime: "2020-03-01T11:00:00Z", State: v1beta1.JobStateSucceeded},
+			{ScheduleTime: "2020-03-01T12:00:00Z", State: v1beta1.JobStateCancelled},
+		})
+
+	assert.Assert(
+		t,
+		appendJobRun(
+			nil,
+			v1beta1.JobRunStatus{State: v1beta1.JobStateSucceeded},
+			0, /* successfulRunsLimit */
+			0 /* failedRunsLimit */) == nil)
+}
+
+func TestGetCurrentRunJobs(t *testing.T) {
+	var submitter = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(
+				time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)),
+		},
+	}
+	var created = submitter.CreationTimestamp.Time.UnixNano() /
+		int64(time.Millisecond)
+	var overview = []flinkclient.JobDetails{
+		{ID: "previous-run", State: "FINISHED", StartTime: created - 3600000},
+		{ID: "current-run", State: "RUNNING", StartTime: created + 5000},
+	}
+
+	assert.DeepEqual(
+		t,
+		*getCurrentRunJobs(overview, &submitter),
+		flinkclient.JobStatusList{
+			Jobs: []flinkclient.JobStatus{{ID: "current-run", Status: "RUNNING"}},
+		})
+	assert.DeepEqual(
+		t,
+		*getCurrentRunJobs(overview, nil),
+		flinkclient.JobStatusList{Jobs: []flinkclient.JobStatus{}})
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Reconciles the job in the "RestAPI" submission mode. Instead of creating a
+// job submitter, the operator uploads the JAR and runs the job through the
+// Flink REST API, then records the job in the status. The job is resubmitted
+// in the same way when it is restarted or upgraded.
+func (reconciler *ClusterReconciler) reconcileRestAPIJob() (ctrl.Result, error) {
+	var log = reconciler.log
+	var observed = reconciler.observed
+	var cluster = observed.cluster
+	var jobStatus = cluster.Status.Components.Job
+	var jobID = reconciler.getFlinkJobID()
+
+	// Update status changed via job reconciliation.
+	var newSavepointStatus *v1beta1.SavepointStatus
+	var newControlStatus *v1beta1.FlinkClusterControlStatus
+	defer reconciler.updateStatus(&newSavepointStatus, &newControlStatus)
+
+	// Cancel
+	if isJobCancelRequested(cluster) {
+		if len(jobID) > 0 && reconciler.isFlinkJobRunning(jobID) {
+			log.Info("Cancelling job", "jobID", jobID)
+			var takeSavepoint = cluster.Spec.Job.TakeSavepointOnCancel == nil ||
+				*cluster.Spec.Job.TakeSavepointOnCancel
+			var savepointStatus, err = reconciler.cancelFlinkJobAsync(jobID, takeSavepoint)
+			if !reflect.DeepEqual(savepointStatus, cluster.Status.Savepoint) {
+				newSavepointStatus = savepointStatus
+			}
+			if err != nil {
+				log.Error(err, "Failed to cancel job", "jobID", jobID)
+				newControlStatus = getFailedCancelStatus(err)
+			}
+			return requeueResult, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Upgrade
+	var upgradeRequested = isRestAPIJobUpgradeRequested(cluster) &&
+		!isJobStopped(jobStatus)
+	if upgradeRequested && reconciler.isFlinkJobRunning(jobID) {
+		log.Info("Job spec changed, upgrading job", "jobID", jobID)
+		var savepointStatus, err = reconciler.upgradeJob(jobID)
+		if !reflect.DeepEqual(savepointStatus, cluster.Status.Savepoint) {
+			newSavepointStatus = savepointStatus
+		}
+		if err != nil {
+			log.Error(err, "Failed to upgrade job", "jobID", jobID)
+		}
+		return requeueResult, err
+	}
+
+	// Retry the failed job submission after the backoff.
+	if isJobSubmissionRetryPending(jobStatus) && !upgradeRequested {
+		var retryTime = getJobSubmissionRetryTime(cluster.Spec.Job, jobStatus)
+		var now = time.Now()
+		if now.Before(retryTime) {
+			log.Info("Waiting to retry job submission", "retryTime", retryTime)
+			return ctrl.Result{RequeueAfter: retryTime.Sub(now)}, nil
+		}
+		log.Info(
+			"Retrying job submission", "attempts", jobStatus.SubmissionAttempts)
+		return reconciler.submitJob()
+	}
+
+	// Submit, restart or resubmit the upgraded job.
+	if jobStatus == nil || upgradeRequested ||
+		shouldRestartJob(cluster.Spec.Job, jobStatus) {
+		return reconciler.submitJob()
+	}
+
+	if len(observed.flinkDuplicateJobIDs) > 0 {
+		var err = reconciler.cancelDuplicateJobs()
+		return requeueResult, err
+	}
+
+	if len(jobID) > 0 {
+		if ok, savepointTriggerReason := reconciler.shouldTakeSavepoint(); ok {
+			newSavepointStatus, _ = reconciler.takeSavepointAsync(jobID, savepointTriggerReason)
+		}
+	}
+
+	if !isJobStopped(jobStatus) {
+		log.Info("Job is not finished yet, no action", "jobID", jobID)
+		return requeueResult, nil
+	}
+
+	log.Info("Job has finished, no action")
+	return ctrl.Result{}, nil
+}
+
+// Submits the job through the Flink REST API and records it in the status.
+func (reconciler *ClusterReconciler) submitJob() (ctrl.Result, error) {
+	var log = reconciler.log
+	var observed = reconciler.observed
+	var cluster = observed.cluster
+
+	// If the observed Flink job status list is not nil (e.g., emtpy list),
+	// it means Flink REST API server is up and running.
+	if observed.flinkJobList == nil {
+		log.Info("Waiting for Flink API server to be ready")
+		return requeueResult, nil
+	}
+
+	// The job ID is deterministic, if the job is found, it was submitted but
+	// failed to be recorded.
+	var request = getDesiredJarRunRequest(cluster)
+	for _, job := range observed.flinkJobList.Jobs {
+		if job.ID == request.JobID {
+			log.Info("Job was already submitted", "jobID", request.JobID)
+			return requeueResult, reconciler.recordSubmittedJob(request, nil)
+		}
+	}
+
+	if len(observed.flinkRunningJobIDs) > 0 {
+		log.Info("Cancelling unexpected running job(s)")
+		var err = reconciler.cancelRunningJobs(false /* takeSavepoint */)
+		return requeueResult, err
+	}
+
+	// Do not submit the job to a JobManager or TaskManagers which are
+	// going to be restarted by an update.
+	if !isJobManagerUpdated(&reconciler.desired, &observed) ||
+		!isTaskManagerUpdated(&reconciler.desired, &observed) {
+		log.Info("Waiting for JobManager and TaskManager to be updated")
+		return requeueResult, nil
+	}
+
+	if !hasReadySlots(observed.cluster.Spec.Job, observed.flinkClusterOverview) {
+		log.Info(
+			"Waiting for task slots",
+			"required", *observed.cluster.Spec.Job.ReadySlotsRequired)
+		return requeueResult, nil
+	}
+
+	var apiBaseURL = getFlinkAPIBaseURL(cluster)
+	var jarSha256 = ""
+	if cluster.Spec.Job.JarSha256 != nil {
+		jarSha256 = *cluster.Spec.Job.JarSha256
+	}
+	log.Info("Submitting job", "request", request)
+	var err = reconciler.flinkClient.SubmitJob(
+		apiBaseURL, cluster.Spec.Job.JarFile, jarSha256, request)
+	if err != nil {
+		log.Error(err, "Failed to submit job", "jobID", request.JobID)
+		reconciler.recorder.Event(
+			cluster,
+			corev1.EventTypeWarning,
+			"JobSubmissionFailed",
+			fmt.Sprintf("Failed to submit job %v: %v", request.JobID, err))
+		recordJobSubmissionMetrics(cluster.Namespace, true /* failed */)
+		// The job is rejected by Flink, e.g., the main method of the job
+		// threw an exception. Record the failed submission, which is retried
+		// up to `maxRetries`; otherwise, retry in the next reconciliation.
+		if _, ok := err.(*flinkclient.HTTPError); ok {
+			return requeueResult, reconciler.recordSubmittedJob(request, err)
+		}
+		return requeueResult, err
+	}
+	log.Info("Job submitted", "jobID", request.JobID)
+	reconciler.recorder.Event(
+		cluster,
+		corev1.EventTypeNormal,
+		"JobSubmitted",
+		fmt.Sprintf("Submitted job %v", request.JobID))
+	recordJobSubmissionMetrics(cluster.Namespace, false /* failed */)
+	return requeueResult, reconciler.recordSubmittedJob(request, nil)
+}
+
+// Records the submitted job in the status. The job is recorded as pending if
+// its rejected submission is retried, or failed otherwise.
+func (reconciler *ClusterReconciler) recordSubmittedJob(
+	request flinkclient.JarRunRequest, submitErr error) error {
+	var clusterClone = reconciler.observed.cluster.DeepCopy()
+	var jobStatus = &v1beta1.JobStatus{}
+	var recordedJobStatus = clusterClone.Status.Components.Job
+	if recordedJobStatus != nil {
+		recordedJobStatus.DeepCopyInto(jobStatus)
+		if recordedJobStatus.State == v1beta1.JobStateFailed ||
+			recordedJobStatus.State == v1beta1.JobStateCancelled {
+			jobStatus.RestartCount++
+			jobStatus.SubmissionAttempts = 0
+		}
+	}
+	jobStatus.ID = request.JobID
+	jobStatus.State = v1beta1.JobStateRunning
+	jobStatus.FlinkJobState = ""
+	jobStatus.StartTime = ""
+	jobStatus.FlinkJobRestarts = 0
+	jobStatus.FromSavepoint = request.SavepointPath
+	jobStatus.SpecHash = getJobSpecHash(clusterClone)
+	if submitErr != nil {
+		jobStatus.SubmissionAttempts++
+		jobStatus.LastSubmissionError = submitErr.Error()
+		setTimestamp(&jobStatus.LastSubmissionErrorTime)
+		if shouldRetryJobSubmission(
+			clusterClone.Spec.Job, jobStatus.SubmissionAttempts) {
+			jobStatus.State = v1beta1.JobStatePending
+		} else {
+			jobStatus.State = v1beta1.JobStateFailed
+			jobStatus.FailureReasons = appendFailureMessage(
+				jobStatus.FailureReasons,
+				fmt.Sprintf("Failed to submit job: %v", submitErr))
+		}
+	}
+	clusterClone.Status.Components.Job = jobStatus
+	setTimestamp(&clusterClone.Status.LastUpdateTime)
+	return reconciler.k8sClient.Status().Update(
+		reconciler.context, clusterClone)
+}
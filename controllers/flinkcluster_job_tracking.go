@@ -0,0 +1,111 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Checks whether the job tracked in the status is running in the JobManager.
+func isTrackedJobRunning(
+	jobStatus *v1beta1.JobStatus, runningJobIDs []string) bool {
+	if jobStatus == nil || len(jobStatus.ID) == 0 {
+		return false
+	}
+	for _, jobID := range runningJobIDs {
+		if jobID == jobStatus.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the running jobs other than the tracked running job, e.g., the job was
+// submitted twice after the operator restarted. They are only identified
+// while the tracked job is running, so the jobs which the operator lost track
+// of before it recorded the job ID are not taken as duplicates.
+func getDuplicateJobIDs(
+	jobStatus *v1beta1.JobStatus, runningJobIDs []string) []string {
+	if jobStatus == nil || jobStatus.State != v1beta1.JobStateRunning ||
+		!isTrackedJobRunning(jobStatus, runningJobIDs) {
+		return nil
+	}
+	var duplicateJobIDs []string
+	for _, jobID := range runningJobIDs {
+		if jobID != jobStatus.ID {
+			duplicateJobIDs = append(duplicateJobIDs, jobID)
+		}
+	}
+	return duplicateJobIDs
+}
+
+// Gets the time since when the running job has not been found in the job list
+// of the JobManager. The recorded time is kept while the job list can't be
+// observed, and it is unset when the job is found or stopped.
+func getJobLostSince(
+	jobStatus *v1beta1.JobStatus,
+	jobList *flinkclient.JobStatusList,
+	now time.Time) string {
+	if jobStatus.State != v1beta1.JobStateRunning || len(jobStatus.ID) == 0 {
+		return ""
+	}
+	if jobList == nil {
+		return jobStatus.LostSince
+	}
+	for _, job := range jobList.Jobs {
+		if job.ID == jobStatus.ID {
+			return ""
+		}
+	}
+	if len(jobStatus.LostSince) > 0 {
+		return jobStatus.LostSince
+	}
+	var tc = &TimeConverter{}
+	return tc.ToString(now)
+}
+
+// Cancels the running jobs other than the tracked job without savepoints, so
+// that only one job runs in the job cluster.
+func (reconciler *ClusterReconciler) cancelDuplicateJobs() error {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+	var apiBaseURL = getFlinkAPIBaseURL(cluster)
+	for _, jobID := range reconciler.observed.flinkDuplicateJobIDs {
+		log.Info(
+			"Cancelling duplicate job",
+			"jobID", jobID,
+			"trackedJobID", cluster.Status.Components.Job.ID)
+		var err = reconciler.flinkClient.StopJob(apiBaseURL, jobID)
+		if err != nil {
+			log.Error(err, "Failed to cancel duplicate job", "jobID", jobID)
+			return err
+		}
+		reconciler.recorder.Event(
+			cluster,
+			corev1.EventTypeWarning,
+			"DuplicateJobCancelled",
+			fmt.Sprintf(
+				"Cancelled job %v which ran besides the tracked job %v",
+				jobID, cluster.Status.Components.Job.ID))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"gotest.tools/assert"
+)
+
+func TestGetDuplicateJobIDs(t *testing.T) {
+	var jobStatus = &v1beta1.JobStatus{
+		ID:    "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		State: v1beta1.JobStateRunning,
+	}
+	var runningJobIDs = []string{
+		"2e9ddea53ee1bd2d9cae5ffb3da2dc6c",
+		"ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+	}
+	assert.Assert(t, isTrackedJobRunning(jobStatus, runningJobIDs))
+	assert.DeepEqual(
+		t,
+		getDuplicateJobIDs(jobStatus, runningJobIDs),
+		[]string{"2e9ddea53ee1bd2d9cae5ffb3da2dc6c"})
+
+	// No duplicates while the tracked job is not running.
+	assert.Assert(
+		t,
+		getDuplicateJobIDs(jobStatus, runningJobIDs[:1]) == nil)
+	jobStatus.State = v1beta1.JobStatePending
+	assert.Assert(t, getDuplicateJobIDs(jobStatus, runningJobIDs) == nil)
+	assert.Assert(t, getDuplicateJobIDs(nil, runningJobIDs) == nil)
+}
+
+func TestGetJobLostSince(t *testing.T) {
+	var tc = &TimeConverter{}
+	var now = tc.FromString("2020-01-01T00:00:00Z")
+	var jobStatus = &v1beta1.JobStatus{
+		ID:    "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		State: v1beta1.JobStateRunning,
+	}
+	var jobList = &flinkclient.JobStatusList{
+		Jobs: []flinkclient.JobStatus{
+			{ID: "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69", Status: "RUNNING"},
+		},
+	}
+	assert.Equal(t, getJobLostSince(jobStatus, jobList, now), "")
+
+	// Lost since the job is first not found.
+	jobList.Jobs = nil
+	jobStatus.LostSince = getJobLostSince(jobStatus, jobList, now)
+	assert.Equal(t, jobStatus.LostSince, "2020-01-01T00:00:00Z")
+	assert.Equal(
+		t,
+		getJobLostSince(jobStatus, jobList, now.Add(time.Minute)),
+		"2020-01-01T00:00:00Z")
+
+	// Kept while the job list can't be observed.
+	assert.Equal(
+		t,
+		getJobLostSince(jobStatus, nil, now.Add(time.Minute)),
+		"2020-01-01T00:00:00Z")
+
+	// Unset when the job is stopped.
+	jobStatus.State = v1beta1.JobStateFailed
+	assert.Equal(t, getJobLostSince(jobStatus, jobList, now), "")
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	kerberosKeytabVolume = "kerberos-keytab-volume"
+	kerberosKeytabPath   = "/opt/flink/kerberos"
+	krb5ConfigVolume     = "krb5-config-volume"
+
+	// The default location of krb5.conf of the JVM and Hadoop.
+	krb5ConfigPath = "/etc/krb5.conf"
+
+	// Environment variables of the job submitter, which doesn't mount the
+	// Flink config. The submit job script adds the Kerberos login to a copy
+	// of the config of the image.
+	kerberosKeytabEnv    = "FLINK_KERBEROS_KEYTAB"
+	kerberosPrincipalEnv = "FLINK_KERBEROS_PRINCIPAL"
+	kerberosContextsEnv  = "FLINK_KERBEROS_CONTEXTS"
+)
+
+// Checks whether the Flink components log in with a Kerberos keytab.
+func isKerberosEnabled(cluster *v1beta1.FlinkCluster) bool {
+	var security = cluster.Spec.Security
+	return security != nil && security.Kerberos != nil
+}
+
+// Gets the path of the mounted keytab.
+func getKerberosKeytabPath(cluster *v1beta1.FlinkCluster) string {
+	return kerberosKeytabPath + "/" + cluster.Spec.Security.Kerberos.KeytabKey
+}
+
+// Gets the Flink properties of the Kerberos login, empty if Kerberos is not
+// enabled.
+func getKerberosProperties(cluster *v1beta1.FlinkCluster) map[string]string {
+	if !isKerberosEnabled(cluster) {
+		return nil
+	}
+	var kerberos = cluster.Spec.Security.Kerberos
+	var props = map[string]string{
+		"security.kerberos.login.use-ticket-cache": "false",
+		"security.kerberos.login.keytab":           getKerberosKeytabPath(cluster),
+		"security.kerberos.login.principal":        kerberos.Principal,
+	}
+	if len(kerberos.LoginContexts) > 0 {
+		props["security.kerberos.login.contexts"] =
+			strings.Join(kerberos.LoginContexts, ",")
+	}
+	return props
+}
+
+// Gets the volumes and mounts of the keytab and krb5.conf, which is mounted
+// over the one of the image only if its ConfigMap is specified.
+func convertKerberos(cluster *v1beta1.FlinkCluster) (
+	[]corev1.Volume, []corev1.VolumeMount) {
+	if !isKerberosEnabled(cluster) {
+		return nil, nil
+	}
+	var kerberos = cluster.Spec.Security.Kerberos
+	var volumes = []corev1.Volume{{
+		Name: kerberosKeytabVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: kerberos.KeytabSecretName,
+			},
+		},
+	}}
+	var mounts = []corev1.VolumeMount{{
+		Name:      kerberosKeytabVolume,
+		MountPath: kerberosKeytabPath,
+		ReadOnly:  true,
+	}}
+	if len(kerberos.Krb5ConfigMapName) > 0 {
+		volumes = append(volumes, corev1.Volume{
+			Name: krb5ConfigVolume,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: kerberos.Krb5ConfigMapName,
+					},
+					Items: []corev1.KeyToPath{{
+						Key:  kerberos.Krb5ConfigKey,
+						Path: "krb5.conf",
+					}},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      krb5ConfigVolume,
+			MountPath: krb5ConfigPath,
+			SubPath:   "krb5.conf",
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
+
+// Gets the environment variables of the job submitter for the Kerberos login,
+// empty if Kerberos is not enabled.
+func getKerberosSubmitterEnv(cluster *v1beta1.FlinkCluster) []corev1.EnvVar {
+	if !isKerberosEnabled(cluster) {
+		return nil
+	}
+	var kerberos = cluster.Spec.Security.Kerberos
+	var envVars = []corev1.EnvVar{
+		{Name: kerberosKeytabEnv, Value: getKerberosKeytabPath(cluster)},
+		{Name: kerberosPrincipalEnv, Value: kerberos.Principal},
+	}
+	if len(kerberos.LoginContexts) > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  kerberosContextsEnv,
+			Value: strings.Join(kerberos.LoginContexts, ","),
+		})
+	}
+	return envVars
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestConvertKerberos(t *testing.T) {
+	var cluster = v1beta1.FlinkCluster{}
+	var volumes, mounts = convertKerberos(&cluster)
+	assert.Assert(t, volumes == nil && mounts == nil)
+	assert.Assert(t, getKerberosProperties(&cluster) == nil)
+	assert.Assert(t, getKerberosSubmitterEnv(&cluster) == nil)
+
+	cluster.Spec.Security = &v1beta1.SecuritySpec{
+		Kerberos: &v1beta1.KerberosSpec{
+			KeytabSecretName: "flink-keytab",
+			KeytabKey:        "krb5.keytab",
+			Principal:        "flink/admin@EXAMPLE.COM",
+			Krb5ConfigKey:    "krb5.conf",
+		},
+	}
+	assert.DeepEqual(t,
+		getKerberosProperties(&cluster),
+		map[string]string{
+			"security.kerberos.login.use-ticket-cache": "false",
+			"security.kerberos.login.keytab":           "/opt/flink/kerberos/krb5.keytab",
+			"security.kerberos.login.principal":        "flink/admin@EXAMPLE.COM",
+		})
+
+	// The krb5.conf of the image is used.
+	volumes, mounts = convertKerberos(&cluster)
+	assert.Equal(t, len(volumes), 1)
+	assert.Equal(t, volumes[0].Secret.SecretName, "flink-keytab")
+	assert.DeepEqual(t, mounts, []corev1.VolumeMount{{
+		Name:      "kerberos-keytab-volume",
+		MountPath: "/opt/flink/kerberos",
+		ReadOnly:  true,
+	}})
+
+	cluster.Spec.Security.Kerberos.Krb5ConfigMapName = "krb5"
+	cluster.Spec.Security.Kerberos.Krb5ConfigKey = "realm.conf"
+	cluster.Spec.Security.Kerberos.LoginContexts = []string{"Client", "KafkaClient"}
+	volumes, mounts = convertKerberos(&cluster)
+	assert.DeepEqual(t, volumes[1], corev1.Volume{
+		Name: "krb5-config-volume",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "krb5"},
+				Items: []corev1.KeyToPath{{
+					Key:  "realm.conf",
+					Path: "krb5.conf",
+				}},
+			},
+		},
+	})
+	assert.DeepEqual(t, mounts[1], corev1.VolumeMount{
+		Name:      "krb5-config-volume",
+		MountPath: "/etc/krb5.conf",
+		SubPath:   "krb5.conf",
+		ReadOnly:  true,
+	})
+	assert.Equal(t,
+		getKerberosProperties(&cluster)["security.kerberos.login.contexts"],
+		"Client,KafkaClient")
+	assert.DeepEqual(t,
+		getKerberosSubmitterEnv(&cluster),
+		[]corev1.EnvVar{
+			{Name: "FLINK_KERBEROS_KEYTAB", Value: "/opt/flink/kerberos/krb5.keytab"},
+			{Name: "FLINK_KERBEROS_PRINCIPAL", Value: "flink/admin@EXAMPLE.COM"},
+			{Name: "FLINK_KERBEROS_CONTEXTS", Value: "Client,KafkaClient"},
+		})
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Labels and annotations of the resources of a component of the cluster.
+type resourceMetadata struct {
+	labels      map[string]string
+	annotations map[string]string
+}
+
+// Gets the labels and annotations of the resources of a component, i.e., the
+// ones of the cluster overridden by the ones of the component.
+func getResourceMetadata(
+	clusterSpec *v1beta1.FlinkClusterSpec,
+	labels map[string]string,
+	annotations map[string]string) resourceMetadata {
+	return resourceMetadata{
+		labels:      mergeStringMaps(clusterSpec.Labels, labels),
+		annotations: mergeStringMaps(clusterSpec.Annotations, annotations),
+	}
+}
+
+// Adds the labels and annotations to the object, the ones which are already
+// set, e.g., by the operator, take precedence.
+func setResourceMetadata(object metav1.Object, metadata resourceMetadata) {
+	if len(metadata.labels) > 0 {
+		object.SetLabels(mergeStringMaps(metadata.labels, object.GetLabels()))
+	}
+	if len(metadata.annotations) > 0 {
+		object.SetAnnotations(
+			mergeStringMaps(metadata.annotations, object.GetAnnotations()))
+	}
+}
+
+// Sets the labels and annotations of the cluster and its components on the
+// desired resources and on the pod templates of the workloads.
+func setDesiredResourcesMetadata(
+	desired *DesiredClusterState, cluster *v1beta1.FlinkCluster) {
+	var clusterSpec = &cluster.Spec
+	var clusterMetadata = getResourceMetadata(clusterSpec, nil, nil)
+	var jmMetadata = getResourceMetadata(
+		clusterSpec,
+		clusterSpec.JobManager.Labels,
+		clusterSpec.JobManager.Annotations)
+	var tmMetadata = getResourceMetadata(
+		clusterSpec,
+		clusterSpec.TaskManager.Labels,
+		clusterSpec.TaskManager.Annotations)
+
+	if desired.ConfigMap != nil {
+		setResourceMetadata(desired.ConfigMap, clusterMetadata)
+	}
+	if desired.HAServiceAccount != nil {
+		setResourceMetadata(desired.HAServiceAccount, clusterMetadata)
+	}
+	if desired.HARole != nil {
+		setResourceMetadata(desired.HARole, clusterMetadata)
+	}
+	if desired.HARoleBinding != nil {
+		setResourceMetadata(desired.HARoleBinding, clusterMetadata)
+	}
+	if desired.RESTCertificate != nil {
+		setResourceMetadata(desired.RESTCertificate, clusterMetadata)
+	}
+	if desired.PodMonitor != nil {
+		setResourceMetadata(desired.PodMonitor, clusterMetadata)
+	}
+	if desired.PodGroup != nil {
+		setResourceMetadata(desired.PodGroup, clusterMetadata)
+	}
+
+	if desired.JmDeployment != nil {
+		setResourceMetadata(desired.JmDeployment, jmMetadata)
+		setResourceMetadata(&desired.JmDeployment.Spec.Template, jmMetadata)
+	}
+	if desired.JmStatefulSet != nil {
+		setResourceMetadata(desired.JmStatefulSet, jmMetadata)
+		setResourceMetadata(&desired.JmStatefulSet.Spec.Template, jmMetadata)
+	}
+	if desired.JmService != nil {
+		setResourceMetadata(desired.JmService, jmMetadata)
+	}
+	if desired.JmRESTService != nil {
+		setResourceMetadata(desired.JmRESTService, jmMetadata)
+	}
+	if desired.JmHeadlessService != nil {
+		setResourceMetadata(desired.JmHeadlessService, jmMetadata)
+	}
+	if desired.JmIngress != nil {
+		setResourceMetadata(desired.JmIngress, jmMetadata)
+	}
+	if desired.JmPodDisruptionBudget != nil {
+		setResourceMetadata(desired.JmPodDisruptionBudget, jmMetadata)
+	}
+	if desired.JmNetworkPolicy != nil {
+		setResourceMetadata(desired.JmNetworkPolicy, jmMetadata)
+	}
+
+	if desired.TmDeployment != nil {
+		setResourceMetadata(desired.TmDeployment, tmMetadata)
+		setResourceMetadata(&desired.TmDeployment.Spec.Template, tmMetadata)
+	}
+	if desired.TmStatefulSet != nil {
+		setResourceMetadata(desired.TmStatefulSet, tmMetadata)
+		setResourceMetadata(&desired.TmStatefulSet.Spec.Template, tmMetadata)
+	}
+	if desired.TmHeadlessService != nil {
+		setResourceMetadata(desired.TmHeadlessService, tmMetadata)
+	}
+	if desired.TmPodDisruptionBudget != nil {
+		setResourceMetadata(desired.TmPodDisruptionBudget, tmMetadata)
+	}
+	if desired.TmNetworkPolicy != nil {
+		setResourceMetadata(desired.TmNetworkPolicy, tmMetadata)
+	}
+
+	if desired.Job != nil && clusterSpec.Job != nil {
+		var jobMetadata = getResourceMetadata(
+			clusterSpec, clusterSpec.Job.Labels, clusterSpec.Job.Annotations)
+		setResourceMetadata(desired.Job, jobMetadata)
+		setResourceMetadata(&desired.Job.Spec.Template, jobMetadata)
+	}
+
+	if clusterSpec.HistoryServer != nil {
+		var historyServerMetadata = getResourceMetadata(
+			clusterSpec,
+			clusterSpec.HistoryServer.Labels,
+			clusterSpec.HistoryServer.Annotations)
+		if desired.HistoryServerDeployment != nil {
+			setResourceMetadata(
+				desired.HistoryServerDeployment, historyServerMetadata)
+			setResourceMetadata(
+				&desired.HistoryServerDeployment.Spec.Template,
+				historyServerMetadata)
+		}
+		if desired.HistoryServerService != nil {
+			setResourceMetadata(
+				desired.HistoryServerService, historyServerMetadata)
+		}
+		if desired.HistoryServerIngress != nil {
+			setResourceMetadata(
+				desired.HistoryServerIngress, historyServerMetadata)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetDesiredResourcesMetadata(t *testing.T) {
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "default"},
+		Spec: v1beta1.FlinkClusterSpec{
+			Labels: map[string]string{"team": "data", "env": "prod"},
+			Annotations: map[string]string{
+				"example.com/cost-center": "1234",
+				flinkConfHashAnnotation:   "overridden",
+			},
+			JobManager: v1beta1.JobManagerSpec{
+				Labels: map[string]string{"env": "staging"},
+			},
+			Job: &v1beta1.JobSpec{
+				Annotations: map[string]string{"example.com/owner": "alice"},
+			},
+		},
+	}
+	var jmLabels = getJobManagerLabels(cluster.Name)
+	var tmLabels = getTaskManagerLabels(cluster.Name)
+	var desired = DesiredClusterState{
+		ConfigMap: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"cluster": "mycluster", "app": "flink"},
+			},
+		},
+		JmDeployment: &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Labels: jmLabels},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: jmLabels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: jmLabels},
+				},
+			},
+		},
+		TmDeployment: &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Labels: tmLabels},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: tmLabels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: tmLabels,
+						Annotations: map[string]string{
+							flinkConfHashAnnotation: "1234abcd",
+						},
+					},
+				},
+			},
+		},
+		Job: &batchv1.Job{
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{"example.com/owner": "bob"},
+					},
+				},
+			},
+		},
+	}
+	setDesiredResourcesMetadata(&desired, cluster)
+
+	assert.DeepEqual(t,
+		desired.ConfigMap.Labels,
+		map[string]string{
+			"cluster": "mycluster",
+			"app":     "flink",
+			"team":    "data",
+			"env":     "prod",
+		})
+
+	// The labels of the component override the labels of the cluster, and
+	// the selector is not changed.
+	var jmDeployment = desired.JmDeployment
+	assert.DeepEqual(t,
+		jmDeployment.Labels,
+		map[string]string{
+			"cluster":   "mycluster",
+			"app":       "flink",
+			"component": "jobmanager",
+			"team":      "data",
+			"env":       "staging",
+		})
+	assert.DeepEqual(t, jmDeployment.Spec.Template.Labels, jmDeployment.Labels)
+	assert.DeepEqual(t,
+		jmDeployment.Spec.Selector.MatchLabels, getJobManagerLabels(cluster.Name))
+
+	// The annotations managed by the operator take precedence.
+	assert.DeepEqual(t,
+		desired.TmDeployment.Spec.Template.Annotations,
+		map[string]string{
+			"example.com/cost-center": "1234",
+			flinkConfHashAnnotation:   "1234abcd",
+		})
+	assert.Equal(t, desired.TmDeployment.Labels["env"], "prod")
+
+	// The pod annotations of the job take precedence.
+	assert.DeepEqual(t,
+		desired.Job.Annotations,
+		map[string]string{
+			"example.com/cost-center": "1234",
+			"example.com/owner":       "alice",
+			flinkConfHashAnnotation:   "overridden",
+		})
+	assert.Equal(t,
+		desired.Job.Spec.Template.Annotations["example.com/owner"], "bob")
+
+	// The spec of the cluster is not changed.
+	assert.DeepEqual(t,
+		cluster.Spec.JobManager.Labels, map[string]string{"env": "staging"})
+}
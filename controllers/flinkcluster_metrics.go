@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Operator metrics, exposed on the metrics endpoint of the controller
+// manager along with the controller-runtime metrics.
+var (
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "flink_operator",
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of the reconciliations of a FlinkCluster.",
+		},
+		[]string{"namespace", "cluster"})
+	reconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "flink_operator",
+			Name:      "reconcile_errors_total",
+			Help:      "Number of failed reconciliations of a FlinkCluster.",
+		},
+		[]string{"namespace", "cluster"})
+	clustersByState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "flink_operator",
+			Name:      "clusters",
+			Help:      "Number of FlinkClusters by state.",
+		},
+		[]string{"namespace", "state"})
+	jobSubmissions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "flink_operator",
+			Name:      "job_submissions_total",
+			Help:      "Number of job submissions by result, submitted or failed.",
+		},
+		[]string{"namespace", "result"})
+	savepoints = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "flink_operator",
+			Name:      "savepoints_total",
+			Help:      "Number of finished savepoints by result, succeeded or failed.",
+		},
+		[]string{"namespace", "result"})
+)
+
+// The last observed state of each cluster, from which `clustersByState` is
+// computed.
+var clusterStates = struct {
+	sync.Mutex
+	states map[types.NamespacedName]string
+}{states: make(map[types.NamespacedName]string)}
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileDuration,
+		reconcileErrors,
+		clustersByState,
+		jobSubmissions,
+		savepoints)
+}
+
+func recordReconcileMetrics(
+	name types.NamespacedName, startTime time.Time, err error) {
+	reconcileDuration.WithLabelValues(name.Namespace, name.Name).Observe(
+		time.Since(startTime).Seconds())
+	if err != nil {
+		reconcileErrors.WithLabelValues(name.Namespace, name.Name).Inc()
+	}
+}
+
+// Records the state of the observed cluster, nil if it has been deleted.
+func recordClusterStateMetrics(
+	name types.NamespacedName, cluster *v1beta1.FlinkCluster) {
+	clusterStates.Lock()
+	defer clusterStates.Unlock()
+
+	if cluster == nil {
+		delete(clusterStates.states, name)
+	} else {
+		clusterStates.states[name] = cluster.Status.State
+	}
+	clustersByState.Reset()
+	for clusterName, state := range clusterStates.states {
+		clustersByState.WithLabelValues(clusterName.Namespace, state).Inc()
+	}
+}
+
+func recordJobSubmissionMetrics(namespace string, failed bool) {
+	var result = "submitted"
+	if failed {
+		result = "failed"
+	}
+	jobSubmissions.WithLabelValues(namespace, result).Inc()
+}
+
+func recordSavepointMetrics(namespace string, state string) {
+	switch state {
+	case v1beta1.SavepointStateSucceeded:
+		savepoints.WithLabelValues(namespace, "succeeded").Inc()
+	case v1beta1.SavepointStateFailed, v1beta1.SavepointStateTriggerFailed:
+		savepoints.WithLabelValues(namespace, "failed").Inc()
+	}
+}
@@ -27,6 +27,11 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,18 +48,51 @@ type ClusterStateObserver struct {
 
 // ObservedClusterState holds observed state of a cluster.
 type ObservedClusterState struct {
-	cluster            *v1beta1.FlinkCluster
-	configMap          *corev1.ConfigMap
-	jmDeployment       *appsv1.Deployment
-	jmService          *corev1.Service
-	jmIngress          *extensionsv1beta1.Ingress
-	tmDeployment       *appsv1.Deployment
-	job                *batchv1.Job
-	flinkJobList       *flinkclient.JobStatusList
-	flinkRunningJobIDs []string
-	flinkJobID         *string
-	savepoint          *flinkclient.SavepointStatus
-	savepointErr       error
+	cluster                 *v1beta1.FlinkCluster
+	configMap               *corev1.ConfigMap
+	jmDeployment            *appsv1.Deployment
+	jmStatefulSet           *appsv1.StatefulSet
+	jmHeadlessService       *corev1.Service
+	jmService               *corev1.Service
+	jmRESTService           *corev1.Service
+	jmIngress               *extensionsv1beta1.Ingress
+	tmDeployment            *appsv1.Deployment
+	tmStatefulSet           *appsv1.StatefulSet
+	tmHeadlessService       *corev1.Service
+	tmPods                  []corev1.Pod
+	job                     *batchv1.Job
+	jobSubmissionFailure    *corev1.ContainerStateTerminated
+	jobSubmitterFailure     string
+	flinkJobList            *flinkclient.JobStatusList
+	flinkClusterOverview    *flinkclient.ClusterOverview
+	flinkTaskManagers       []flinkclient.TaskManager
+	flinkRunningJobIDs      []string
+	flinkDuplicateJobIDs    []string
+	flinkJobID              *string
+	flinkJob                *flinkclient.JobDetails
+	flinkJobExceptions      *flinkclient.JobExceptions
+	flinkJobCheckpoints     *flinkclient.CheckpointStats
+	flinkJobAccumulators    []flinkclient.Accumulator
+	savepoint               *flinkclient.SavepointStatus
+	savepointErr            error
+	jobMetrics              *flinkclient.JobMetrics
+	internalTLSSecret       *corev1.Secret
+	restTLSPasswordSecret   *corev1.Secret
+	restCertificate         *unstructured.Unstructured
+	haServiceAccount        *corev1.ServiceAccount
+	haRole                  *rbacv1.Role
+	haRoleBinding           *rbacv1.RoleBinding
+	podMonitor              *unstructured.Unstructured
+	jmPDB                   *policyv1beta1.PodDisruptionBudget
+	tmPDB                   *policyv1beta1.PodDisruptionBudget
+	jmNetworkPolicy         *networkingv1.NetworkPolicy
+	tmNetworkPolicy         *networkingv1.NetworkPolicy
+	podGroup                *unstructured.Unstructured
+	historyServerDeployment *appsv1.Deployment
+	historyServerService    *corev1.Service
+	historyServerIngress    *extensionsv1beta1.Ingress
+	resourceQuotas          []corev1.ResourceQuota
+	referencedDataHashes    map[string]string
 }
 
 // Observes the state of the cluster and its components.
@@ -94,6 +132,31 @@ func (observer *ClusterStateObserver) observe(
 		observed.configMap = observedConfigMap
 	}
 
+	// (Optional) HA ServiceAccount, Role and RoleBinding.
+	err = observer.observeHighAvailabilityRBAC(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) Secrets generated by the operator.
+	err = observer.observeGeneratedSecrets(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) ConfigMaps and Secrets which the pods are restarted on.
+	err = observer.observeReferencedData(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) cert-manager Certificate of the REST API, and the TLS config
+	// of the requests to the REST API.
+	err = observer.observeRESTTLS(observed)
+	if err != nil {
+		return err
+	}
+
 	// JobManager deployment.
 	var observedJmDeployment = new(appsv1.Deployment)
 	err = observer.observeJobManagerDeployment(observedJmDeployment)
@@ -109,6 +172,38 @@ func (observer *ClusterStateObserver) observe(
 		observed.jmDeployment = observedJmDeployment
 	}
 
+	// (Optional) JobManager StatefulSet.
+	var observedJmStatefulSet = new(appsv1.StatefulSet)
+	err = observer.observeJobManagerStatefulSet(observedJmStatefulSet)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get JobManager StatefulSet")
+			return err
+		}
+		log.Info("Observed JobManager StatefulSet", "state", "nil")
+		observedJmStatefulSet = nil
+	} else {
+		log.Info("Observed JobManager StatefulSet", "state", *observedJmStatefulSet)
+		observed.jmStatefulSet = observedJmStatefulSet
+	}
+
+	// (Optional) JobManager headless service.
+	var observedJmHeadlessService = new(corev1.Service)
+	err = observer.observeHeadlessService(
+		getJobManagerDeploymentName(observer.request.Name),
+		observedJmHeadlessService)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get JobManager headless service")
+			return err
+		}
+		log.Info("Observed JobManager headless service", "state", "nil")
+		observedJmHeadlessService = nil
+	} else {
+		log.Info("Observed JobManager headless service", "state", *observedJmHeadlessService)
+		observed.jmHeadlessService = observedJmHeadlessService
+	}
+
 	// JobManager service.
 	var observedJmService = new(corev1.Service)
 	err = observer.observeJobManagerService(observedJmService)
@@ -124,6 +219,20 @@ func (observer *ClusterStateObserver) observe(
 		observed.jmService = observedJmService
 	}
 
+	// (Optional) JobManager REST service.
+	var observedJmRESTService = new(corev1.Service)
+	err = observer.observeJobManagerRESTService(observedJmRESTService)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get JobManager REST service")
+			return err
+		}
+		log.Info("Observed JobManager REST service", "state", "nil")
+	} else {
+		log.Info("Observed JobManager REST service", "state", *observedJmRESTService)
+		observed.jmRESTService = observedJmRESTService
+	}
+
 	// (Optional) JobManager ingress.
 	var observedJmIngress = new(extensionsv1beta1.Ingress)
 	err = observer.observeJobManagerIngress(observedJmIngress)
@@ -154,14 +263,101 @@ func (observer *ClusterStateObserver) observe(
 		observed.tmDeployment = observedTmDeployment
 	}
 
+	// (Optional) TaskManager StatefulSet.
+	var observedTmStatefulSet = new(appsv1.StatefulSet)
+	err = observer.observeTaskManagerStatefulSet(observedTmStatefulSet)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get TaskManager StatefulSet")
+			return err
+		}
+		log.Info("Observed TaskManager StatefulSet", "state", "nil")
+		observedTmStatefulSet = nil
+	} else {
+		log.Info("Observed TaskManager StatefulSet", "state", *observedTmStatefulSet)
+		observed.tmStatefulSet = observedTmStatefulSet
+	}
+
+	// (Optional) TaskManager headless service.
+	var observedTmHeadlessService = new(corev1.Service)
+	err = observer.observeHeadlessService(
+		getTaskManagerDeploymentName(observer.request.Name),
+		observedTmHeadlessService)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get TaskManager headless service")
+			return err
+		}
+		log.Info("Observed TaskManager headless service", "state", "nil")
+		observedTmHeadlessService = nil
+	} else {
+		log.Info("Observed TaskManager headless service", "state", *observedTmHeadlessService)
+		observed.tmHeadlessService = observedTmHeadlessService
+	}
+
+	// (Optional) JobManager and TaskManager PodDisruptionBudgets.
+	err = observer.observePodDisruptionBudgets(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) JobManager and TaskManager NetworkPolicies.
+	err = observer.observeNetworkPolicies(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) PodMonitor.
+	err = observer.observePodMonitor(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) PodGroup of the batch scheduler.
+	err = observer.observePodGroup(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) History Server deployment, service and ingress.
+	err = observer.observeHistoryServer(observed)
+	if err != nil {
+		return err
+	}
+
+	// ResourceQuotas of the namespace, to check that the JobManager and
+	// TaskManagers fit in them before they are created.
+	err = observer.observeResourceQuotas(observed)
+	if err != nil {
+		return err
+	}
+
 	// (Optional) Savepoint.
 	// Savepoint observe error do not affect deploy reconciliation loop.
 	observer.observeSavepoint(observed)
 
 	// (Optional) job.
 	err = observer.observeJob(observed)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// The TaskManagers and task slots registered in the JobManager.
+	// Overview observe error do not affect deploy reconciliation loop.
+	observer.observeFlinkClusterOverview(observed)
+
+	// (Optional) TaskManager pods and the TaskManagers registered in the
+	// JobManager, to find the idle ones on scale-down.
+	err = observer.observeTaskManagerDecommission(observed)
+	if err != nil {
+		return err
+	}
+
+	// (Optional) job metrics for the autoscaler.
+	// Metrics observe error do not affect deploy reconciliation loop.
+	observer.observeJobMetrics(observed)
+
+	return nil
 }
 
 func (observer *ClusterStateObserver) observeJob(
@@ -174,10 +370,6 @@ func (observer *ClusterStateObserver) observeJob(
 		return nil
 	}
 
-	// Flink job status list can be available before there is any job
-	// submitted.
-	observer.observeFlinkJobs(observed)
-
 	// Job resource.
 	var observedJob = new(batchv1.Job)
 	err = observer.observeJobResource(observedJob)
@@ -193,6 +385,88 @@ func (observer *ClusterStateObserver) observeJob(
 		observed.job = observedJob
 	}
 
+	// The submission failure of the failed job submitter.
+	if observedJob != nil && observedJob.Status.Failed > 0 {
+		err = observer.observeJobSubmissionFailure(observed)
+		if err != nil {
+			log.Error(err, "Failed to get job submitter pods")
+			return err
+		}
+	}
+
+	// Flink job status list can be available before there is any job
+	// submitted. It is observed after the job resource, which identifies the
+	// current run of a scheduled job.
+	observer.observeFlinkJobs(observed)
+
+	return nil
+}
+
+// Observes the overview of the running cluster through Flink API, i.e., the
+// registered TaskManagers and their task slots, which are reported in the
+// status and gate the job submission with `readySlotsRequired`.
+func (observer *ClusterStateObserver) observeFlinkClusterOverview(
+	observed *ObservedClusterState) {
+	var log = observer.log
+
+	if observed.cluster == nil ||
+		isBlueGreenCluster(observed.cluster) ||
+		observed.cluster.Status.State != v1beta1.ClusterStateRunning {
+		return
+	}
+
+	var overview, err = observer.flinkClient.GetClusterOverview(
+		getFlinkAPIBaseURL(observed.cluster))
+	if err != nil {
+		log.Info("Failed to get Flink cluster overview.", "error", err)
+		return
+	}
+	log.Info("Observed Flink cluster overview", "overview", overview)
+	observed.flinkClusterOverview = &overview
+}
+
+// Observes the TaskManager pods and the task slots which their TaskManagers
+// use through Flink API while they are being scaled down with
+// `decommissionTimeoutSeconds`.
+func (observer *ClusterStateObserver) observeTaskManagerDecommission(
+	observed *ObservedClusterState) error {
+	var log = observer.log
+	var cluster = observed.cluster
+
+	if cluster == nil ||
+		!isTaskManagerDecommissionEnabled(cluster) ||
+		cluster.Status.State != v1beta1.ClusterStateRunning ||
+		getTaskManagerScaleDown(observed) == 0 {
+		return nil
+	}
+
+	var pods = new(corev1.PodList)
+	var err = observer.k8sClient.List(
+		observer.context,
+		pods,
+		client.InNamespace(observer.request.Namespace),
+		client.MatchingLabels{
+			"cluster":   observer.request.Name,
+			"component": "taskmanager",
+		})
+	if err != nil {
+		log.Error(err, "Failed to list TaskManager pods")
+		return err
+	}
+	log.Info("Observed TaskManager pods", "count", len(pods.Items))
+	observed.tmPods = pods.Items
+
+	taskManagers, err := observer.flinkClient.GetTaskManagers(
+		getFlinkAPIBaseURL(cluster))
+	if err != nil {
+		log.Info("Failed to get Flink TaskManagers.", "error", err)
+		return nil
+	}
+	if taskManagers == nil {
+		taskManagers = []flinkclient.TaskManager{}
+	}
+	log.Info("Observed Flink TaskManagers", "taskManagers", taskManagers)
+	observed.flinkTaskManagers = taskManagers
 	return nil
 }
 
@@ -206,6 +480,11 @@ func (observer *ClusterStateObserver) observeFlinkJobs(
 	observed *ObservedClusterState) {
 	var log = observer.log
 
+	// The jobs of a blue/green cluster run in its child clusters.
+	if isBlueGreenCluster(observed.cluster) {
+		return
+	}
+
 	// Wait until the cluster is running.
 	if observed.cluster.Status.State !=
 		v1beta1.ClusterStateRunning {
@@ -225,6 +504,19 @@ func (observer *ClusterStateObserver) observeFlinkJobs(
 		log.Info("Failed to get Flink job status list.", "error", err)
 		jobList = nil
 	}
+
+	// The jobs of the previous runs of a scheduled job stay in the list until
+	// they expire in the JobManager, only the jobs of the current run are
+	// observed.
+	if jobList != nil && isScheduledJob(observed.cluster.Spec.Job) {
+		var overview, err = observer.flinkClient.GetJobsOverview(flinkAPIBaseURL)
+		if err != nil {
+			log.Info("Failed to get Flink jobs overview.", "error", err)
+			jobList = nil
+		} else {
+			jobList = getCurrentRunJobs(overview, observed.job)
+		}
+	}
 	observed.flinkJobList = jobList
 
 	if jobList == nil {
@@ -247,11 +539,26 @@ func (observer *ClusterStateObserver) observeFlinkJobs(
 	// timed out and exited but the job submission was actually
 	// successfully. When retrying, it first cancels the existing running
 	// job which it has lost track of, then submit the job again.
+	//
+	// Jobs submitted through the Flink REST API are tracked by the job ID
+	// recorded in the status, the previous jobs stay in the list after the
+	// job is restarted or upgraded.
 	var flinkJobID *string
-	if len(observed.flinkRunningJobIDs) > 1 {
+	var recordedJobStatus = observed.cluster.Status.Components.Job
+	if isRestAPISubmission(observed.cluster.Spec.Job) {
+		for i := range jobList.Jobs {
+			if recordedJobStatus != nil &&
+				jobList.Jobs[i].ID == recordedJobStatus.ID {
+				flinkJobID = &jobList.Jobs[i].ID
+			}
+		}
+	} else if len(observed.flinkRunningJobIDs) > 1 {
 		log.Error(
 			errors.New("more than one running job were found"),
 			"", "jobs", observed.flinkRunningJobIDs)
+		if isTrackedJobRunning(recordedJobStatus, observed.flinkRunningJobIDs) {
+			flinkJobID = &recordedJobStatus.ID
+		}
 	} else if len(observed.flinkRunningJobIDs) == 1 {
 		flinkJobID = &observed.flinkRunningJobIDs[0]
 	} else if len(jobList.Jobs) > 1 {
@@ -264,9 +571,87 @@ func (observer *ClusterStateObserver) observeFlinkJobs(
 		flinkJobID = &jobList.Jobs[0].ID
 	}
 	observed.flinkJobID = flinkJobID
+	if observed.cluster.Spec.Job != nil {
+		observed.flinkDuplicateJobIDs = getDuplicateJobIDs(
+			recordedJobStatus, observed.flinkRunningJobIDs)
+	}
 	if flinkJobID != nil {
 		log.Info("Observed Flink job ID", "ID", *flinkJobID)
+	} else {
+		return
+	}
+
+	// Get the details of the Flink job.
+	var jobDetails, detailsErr = observer.flinkClient.GetJobDetails(
+		flinkAPIBaseURL, *flinkJobID)
+	if detailsErr != nil {
+		log.Info("Failed to get Flink job details.", "error", detailsErr)
+		return
+	}
+	log.Info("Observed Flink job details", "details", jobDetails)
+	observed.flinkJob = &jobDetails
+
+	// Get the latest completed checkpoint, which the job can be restarted
+	// from when it fails if it is externalized, and the progress of the
+	// savepoint in progress.
+	var checkpoints, checkpointsErr = observer.flinkClient.GetCheckpointStats(
+		flinkAPIBaseURL, *flinkJobID)
+	if checkpointsErr != nil {
+		log.Info("Failed to get Flink job checkpoints.", "error", checkpointsErr)
+	} else {
+		log.Info("Observed Flink job checkpoints", "checkpoints", checkpoints)
+		observed.flinkJobCheckpoints = checkpoints
+	}
+
+	// Get the final accumulators of the finished Flink job, they are recorded
+	// once before the JobManager is deleted by the cleanup.
+	if jobDetails.State == "FINISHED" &&
+		(recordedJobStatus == nil || recordedJobStatus.Accumulators == nil) {
+		var accumulators, accumulatorsErr = observer.flinkClient.GetJobAccumulators(
+			flinkAPIBaseURL, *flinkJobID)
+		if accumulatorsErr != nil {
+			log.Info("Failed to get Flink job accumulators.", "error", accumulatorsErr)
+		} else {
+			log.Info("Observed Flink job accumulators", "accumulators", accumulators)
+			observed.flinkJobAccumulators = accumulators
+		}
+	}
+
+	// Get the exceptions of the failed Flink job.
+	switch jobDetails.State {
+	case "FAILING", "FAILED", "RESTARTING":
+		var exceptions, exceptionsErr = observer.flinkClient.GetJobExceptions(
+			flinkAPIBaseURL, *flinkJobID)
+		if exceptionsErr != nil {
+			log.Info("Failed to get Flink job exceptions.", "error", exceptionsErr)
+			return
+		}
+		observed.flinkJobExceptions = &exceptions
+	}
+}
+
+// Observes the metrics of the running Flink job which are used by the
+// autoscaler.
+func (observer *ClusterStateObserver) observeJobMetrics(
+	observed *ObservedClusterState) {
+	var log = observer.log
+
+	if observed.cluster == nil ||
+		observed.cluster.Spec.Autoscaler == nil ||
+		len(observed.flinkRunningJobIDs) != 1 {
+		return
+	}
+
+	var flinkAPIBaseURL = getFlinkAPIBaseURL(observed.cluster)
+	var jobID = observed.flinkRunningJobIDs[0]
+	var metrics, err = observer.flinkClient.GetJobMetrics(flinkAPIBaseURL, jobID)
+	if err != nil {
+		// It is normal in many cases, not an error.
+		log.Info("Failed to get Flink job metrics.", "error", err)
+		return
 	}
+	log.Info("Observed Flink job metrics", "metrics", metrics)
+	observed.jobMetrics = &metrics
 }
 
 func (observer *ClusterStateObserver) observeSavepoint(observed *ObservedClusterState) error {
@@ -319,6 +704,419 @@ func (observer *ClusterStateObserver) observeConfigMap(
 		observedConfigMap)
 }
 
+// Observes the Secrets generated by the operator, i.e., the keystore of the
+// internal TLS and the keystore password of the REST API certificate, only if
+// they are enabled, so that the Secrets are not watched otherwise.
+func (observer *ClusterStateObserver) observeGeneratedSecrets(
+	observed *ObservedClusterState) error {
+	var cluster = observed.cluster
+	if cluster == nil {
+		return nil
+	}
+	var err error
+	if isInternalTLSSecretGenerated(cluster) {
+		observed.internalTLSSecret, err = observer.observeGeneratedSecret(
+			getGeneratedInternalTLSSecretName(cluster.Name), "internal TLS secret")
+		if err != nil {
+			return err
+		}
+	}
+	if isRESTTLSCertificateRequested(cluster) {
+		observed.restTLSPasswordSecret, err = observer.observeGeneratedSecret(
+			getGeneratedRESTTLSPasswordSecretName(cluster.Name),
+			"REST TLS password secret")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Observes the hashes of the data of the ConfigMaps and Secrets in
+// `restartOnChange`, keyed by `getReferenceKey`. The hash of the ones which are
+// not found is empty. Their content is not logged.
+func (observer *ClusterStateObserver) observeReferencedData(
+	observed *ObservedClusterState) error {
+	var cluster = observed.cluster
+	if cluster == nil || cluster.Spec.RestartOnChange == nil {
+		return nil
+	}
+	var log = observer.log
+	var hashes = map[string]string{}
+	for _, name := range cluster.Spec.RestartOnChange.ConfigMaps {
+		var key = getReferenceKey("ConfigMap", name)
+		var configMap = new(corev1.ConfigMap)
+		var err = observer.k8sClient.Get(
+			observer.context,
+			types.NamespacedName{Namespace: observer.request.Namespace, Name: name},
+			configMap)
+		if err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				log.Error(err, "Failed to get referenced configMap", "name", name)
+				return err
+			}
+			hashes[key] = ""
+			continue
+		}
+		hashes[key] = getConfigMapDataHash(configMap)
+	}
+	for _, name := range cluster.Spec.RestartOnChange.Secrets {
+		var key = getReferenceKey("Secret", name)
+		var secret = new(corev1.Secret)
+		var err = observer.k8sClient.Get(
+			observer.context,
+			types.NamespacedName{Namespace: observer.request.Namespace, Name: name},
+			secret)
+		if err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				log.Error(err, "Failed to get referenced secret", "name", name)
+				return err
+			}
+			hashes[key] = ""
+			continue
+		}
+		hashes[key] = getDataHash(secret.Data)
+	}
+	log.Info("Observed referenced data", "hashes", hashes)
+	observed.referencedDataHashes = hashes
+	return nil
+}
+
+// Observes a Secret generated by the operator, nil if it is not found. Its
+// content is not logged.
+func (observer *ClusterStateObserver) observeGeneratedSecret(
+	name string, description string) (*corev1.Secret, error) {
+	var log = observer.log
+	var observedSecret = new(corev1.Secret)
+	var err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      name,
+		},
+		observedSecret)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get "+description)
+			return nil, err
+		}
+		log.Info("Observed "+description, "state", "nil")
+		return nil, nil
+	}
+	log.Info("Observed "+description, "name", observedSecret.Name)
+	return observedSecret, nil
+}
+
+// Observes the cert-manager Certificate of the REST API, which is considered
+// absent if the Certificate CRD is not installed, and sets the TLS config of
+// the Flink client to trust the CA of the certificate.
+func (observer *ClusterStateObserver) observeRESTTLS(
+	observed *ObservedClusterState) error {
+	var cluster = observed.cluster
+	if cluster == nil {
+		return nil
+	}
+	var log = observer.log
+	var tlsConfig, err = getFlinkRESTTLSConfig(
+		observer.context, observer.k8sClient, cluster)
+	if err != nil {
+		log.Error(err, "Failed to get REST TLS secret")
+		return err
+	}
+	observer.flinkClient.HTTPClient.TLSConfig = tlsConfig
+
+	var observedCertificate = new(unstructured.Unstructured)
+	observedCertificate.SetGroupVersionKind(certificateGVK)
+	err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getRESTTLSCertificateName(observer.request.Name),
+		},
+		observedCertificate)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("Observed REST TLS Certificate", "state", "nil", "reason", "no Certificate CRD")
+			return nil
+		}
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get REST TLS Certificate")
+			return err
+		}
+		log.Info("Observed REST TLS Certificate", "state", "nil")
+		return nil
+	}
+	log.Info("Observed REST TLS Certificate", "state", *observedCertificate)
+	observed.restCertificate = observedCertificate
+	return nil
+}
+
+func (observer *ClusterStateObserver) observeHighAvailabilityRBAC(
+	observed *ObservedClusterState) error {
+	var log = observer.log
+	var key = types.NamespacedName{
+		Namespace: observer.request.Namespace,
+		Name:      getHAName(observer.request.Name),
+	}
+
+	var observedServiceAccount = new(corev1.ServiceAccount)
+	var err = observer.k8sClient.Get(
+		observer.context, key, observedServiceAccount)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get HA service account")
+			return err
+		}
+		log.Info("Observed HA service account", "state", "nil")
+	} else {
+		log.Info("Observed HA service account", "state", *observedServiceAccount)
+		observed.haServiceAccount = observedServiceAccount
+	}
+
+	var observedRole = new(rbacv1.Role)
+	err = observer.k8sClient.Get(observer.context, key, observedRole)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get HA role")
+			return err
+		}
+		log.Info("Observed HA role", "state", "nil")
+	} else {
+		log.Info("Observed HA role", "state", *observedRole)
+		observed.haRole = observedRole
+	}
+
+	var observedRoleBinding = new(rbacv1.RoleBinding)
+	err = observer.k8sClient.Get(observer.context, key, observedRoleBinding)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get HA role binding")
+			return err
+		}
+		log.Info("Observed HA role binding", "state", "nil")
+	} else {
+		log.Info("Observed HA role binding", "state", *observedRoleBinding)
+		observed.haRoleBinding = observedRoleBinding
+	}
+
+	return nil
+}
+
+// Observes the PodDisruptionBudgets of the JobManager and TaskManager pods,
+// which are named after their deployments.
+func (observer *ClusterStateObserver) observePodDisruptionBudgets(
+	observed *ObservedClusterState) error {
+	var log = observer.log
+	var clusterName = observer.request.Name
+
+	var observedJmPDB = new(policyv1beta1.PodDisruptionBudget)
+	var err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getJobManagerDeploymentName(clusterName),
+		},
+		observedJmPDB)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get JobManager PodDisruptionBudget")
+			return err
+		}
+		log.Info("Observed JobManager PodDisruptionBudget", "state", "nil")
+	} else {
+		log.Info("Observed JobManager PodDisruptionBudget", "state", *observedJmPDB)
+		observed.jmPDB = observedJmPDB
+	}
+
+	var observedTmPDB = new(policyv1beta1.PodDisruptionBudget)
+	err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getTaskManagerDeploymentName(clusterName),
+		},
+		observedTmPDB)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get TaskManager PodDisruptionBudget")
+			return err
+		}
+		log.Info("Observed TaskManager PodDisruptionBudget", "state", "nil")
+	} else {
+		log.Info("Observed TaskManager PodDisruptionBudget", "state", *observedTmPDB)
+		observed.tmPDB = observedTmPDB
+	}
+
+	return nil
+}
+
+// Observes the NetworkPolicies of the JobManager and TaskManager pods, which
+// are named after their deployments.
+func (observer *ClusterStateObserver) observeNetworkPolicies(
+	observed *ObservedClusterState) error {
+	var log = observer.log
+	var clusterName = observer.request.Name
+
+	var observedJmNetworkPolicy = new(networkingv1.NetworkPolicy)
+	var err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getJobManagerDeploymentName(clusterName),
+		},
+		observedJmNetworkPolicy)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get JobManager NetworkPolicy")
+			return err
+		}
+		log.Info("Observed JobManager NetworkPolicy", "state", "nil")
+	} else {
+		log.Info("Observed JobManager NetworkPolicy", "state", *observedJmNetworkPolicy)
+		observed.jmNetworkPolicy = observedJmNetworkPolicy
+	}
+
+	var observedTmNetworkPolicy = new(networkingv1.NetworkPolicy)
+	err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getTaskManagerDeploymentName(clusterName),
+		},
+		observedTmNetworkPolicy)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get TaskManager NetworkPolicy")
+			return err
+		}
+		log.Info("Observed TaskManager NetworkPolicy", "state", "nil")
+	} else {
+		log.Info("Observed TaskManager NetworkPolicy", "state", *observedTmNetworkPolicy)
+		observed.tmNetworkPolicy = observedTmNetworkPolicy
+	}
+
+	return nil
+}
+
+// Observes the PodMonitor of the Prometheus Operator, which is considered
+// absent if the PodMonitor CRD is not installed.
+func (observer *ClusterStateObserver) observePodMonitor(
+	observed *ObservedClusterState) error {
+	var log = observer.log
+	var observedPodMonitor = new(unstructured.Unstructured)
+	observedPodMonitor.SetGroupVersionKind(podMonitorGVK)
+	var err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getPodMonitorName(observer.request.Name),
+		},
+		observedPodMonitor)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("Observed PodMonitor", "state", "nil", "reason", "no PodMonitor CRD")
+			return nil
+		}
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get PodMonitor")
+			return err
+		}
+		log.Info("Observed PodMonitor", "state", "nil")
+		return nil
+	}
+	log.Info("Observed PodMonitor", "state", *observedPodMonitor)
+	observed.podMonitor = observedPodMonitor
+	return nil
+}
+
+// Observes the PodGroup of the batch scheduler in the cluster spec, which is
+// considered absent if the PodGroup CRD is not installed.
+func (observer *ClusterStateObserver) observePodGroup(
+	observed *ObservedClusterState) error {
+	var log = observer.log
+	if observed.cluster == nil || observed.cluster.Spec.BatchScheduler == nil {
+		log.Info("Observed PodGroup", "state", "nil")
+		return nil
+	}
+	var observedPodGroup = new(unstructured.Unstructured)
+	observedPodGroup.SetGroupVersionKind(
+		getPodGroupGVK(observed.cluster.Spec.BatchScheduler))
+	var err = observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getPodGroupName(observer.request.Name),
+		},
+		observedPodGroup)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("Observed PodGroup", "state", "nil", "reason", "no PodGroup CRD")
+			return nil
+		}
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get PodGroup")
+			return err
+		}
+		log.Info("Observed PodGroup", "state", "nil")
+		return nil
+	}
+	log.Info("Observed PodGroup", "state", *observedPodGroup)
+	observed.podGroup = observedPodGroup
+	return nil
+}
+
+// Observes the deployment, service and ingress of the History Server, which
+// share the same name.
+func (observer *ClusterStateObserver) observeHistoryServer(
+	observed *ObservedClusterState) error {
+	var log = observer.log
+	var name = types.NamespacedName{
+		Namespace: observer.request.Namespace,
+		Name:      getHistoryServerName(observer.request.Name),
+	}
+
+	var observedDeployment = new(appsv1.Deployment)
+	var err = observer.k8sClient.Get(observer.context, name, observedDeployment)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get History Server deployment")
+			return err
+		}
+		log.Info("Observed History Server deployment", "state", "nil")
+	} else {
+		log.Info("Observed History Server deployment", "state", *observedDeployment)
+		observed.historyServerDeployment = observedDeployment
+	}
+
+	var observedService = new(corev1.Service)
+	err = observer.k8sClient.Get(observer.context, name, observedService)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get History Server service")
+			return err
+		}
+		log.Info("Observed History Server service", "state", "nil")
+	} else {
+		log.Info("Observed History Server service", "state", *observedService)
+		observed.historyServerService = observedService
+	}
+
+	var observedIngress = new(extensionsv1beta1.Ingress)
+	err = observer.k8sClient.Get(observer.context, name, observedIngress)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get History Server ingress")
+			return err
+		}
+		log.Info("Observed History Server ingress", "state", "nil")
+	} else {
+		log.Info("Observed History Server ingress", "state", *observedIngress)
+		observed.historyServerIngress = observedIngress
+	}
+
+	return nil
+}
+
 func (observer *ClusterStateObserver) observeJobManagerDeployment(
 	observedDeployment *appsv1.Deployment) error {
 	var clusterNamespace = observer.request.Namespace
@@ -337,6 +1135,45 @@ func (observer *ClusterStateObserver) observeTaskManagerDeployment(
 		clusterNamespace, tmDeploymentName, "TaskManager", observedDeployment)
 }
 
+func (observer *ClusterStateObserver) observeJobManagerStatefulSet(
+	observedStatefulSet *appsv1.StatefulSet) error {
+	var clusterNamespace = observer.request.Namespace
+	var clusterName = observer.request.Name
+	return observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: clusterNamespace,
+			Name:      getJobManagerDeploymentName(clusterName),
+		},
+		observedStatefulSet)
+}
+
+func (observer *ClusterStateObserver) observeTaskManagerStatefulSet(
+	observedStatefulSet *appsv1.StatefulSet) error {
+	var clusterNamespace = observer.request.Namespace
+	var clusterName = observer.request.Name
+	return observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: clusterNamespace,
+			Name:      getTaskManagerDeploymentName(clusterName),
+		},
+		observedStatefulSet)
+}
+
+// Observes the headless service of the StatefulSet of a component.
+func (observer *ClusterStateObserver) observeHeadlessService(
+	statefulSetName string,
+	observedService *corev1.Service) error {
+	return observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getHeadlessServiceName(statefulSetName),
+		},
+		observedService)
+}
+
 func (observer *ClusterStateObserver) observeDeployment(
 	namespace string,
 	name string,
@@ -374,6 +1211,17 @@ func (observer *ClusterStateObserver) observeJobManagerService(
 		observedService)
 }
 
+func (observer *ClusterStateObserver) observeJobManagerRESTService(
+	observedService *corev1.Service) error {
+	return observer.k8sClient.Get(
+		observer.context,
+		types.NamespacedName{
+			Namespace: observer.request.Namespace,
+			Name:      getJobManagerRESTServiceName(observer.request.Name),
+		},
+		observedService)
+}
+
 func (observer *ClusterStateObserver) observeJobManagerIngress(
 	observedIngress *extensionsv1beta1.Ingress) error {
 	var clusterNamespace = observer.request.Namespace
@@ -388,6 +1236,67 @@ func (observer *ClusterStateObserver) observeJobManagerIngress(
 		observedIngress)
 }
 
+func (observer *ClusterStateObserver) observeResourceQuotas(
+	observed *ObservedClusterState) error {
+	var quotas = new(corev1.ResourceQuotaList)
+	var err = observer.k8sClient.List(
+		observer.context,
+		quotas,
+		client.InNamespace(observer.request.Namespace))
+	if err != nil {
+		observer.log.Error(err, "Failed to list ResourceQuotas")
+		return err
+	}
+	observer.log.Info("Observed ResourceQuotas", "count", len(quotas.Items))
+	observed.resourceQuotas = quotas.Items
+	return nil
+}
+
+// Observes the termination of the job submitter container which failed to
+// submit the job, its termination message is the submission error. The
+// termination message of the latest failed container of the job submitter
+// pods, including the init containers, is observed as the failure message.
+func (observer *ClusterStateObserver) observeJobSubmissionFailure(
+	observed *ObservedClusterState) error {
+	var pods = new(corev1.PodList)
+	var err = observer.k8sClient.List(
+		observer.context,
+		pods,
+		client.InNamespace(observer.request.Namespace),
+		client.MatchingLabels{"controller-uid": string(observed.job.UID)})
+	if err != nil {
+		return err
+	}
+	var lastFailure *corev1.ContainerStateTerminated
+	for _, pod := range pods.Items {
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			var terminated = containerStatus.State.Terminated
+			if terminated != nil &&
+				terminated.ExitCode == jobSubmissionFailureExitCode {
+				observer.log.Info(
+					"Observed job submission failure", "failure", *terminated)
+				observed.jobSubmissionFailure = terminated.DeepCopy()
+			}
+		}
+		var containerStatuses []corev1.ContainerStatus
+		containerStatuses = append(
+			containerStatuses, pod.Status.InitContainerStatuses...)
+		containerStatuses = append(
+			containerStatuses, pod.Status.ContainerStatuses...)
+		for _, containerStatus := range containerStatuses {
+			var terminated = containerStatus.State.Terminated
+			if terminated != nil && terminated.ExitCode != 0 &&
+				(lastFailure == nil ||
+					lastFailure.FinishedAt.Before(&terminated.FinishedAt)) {
+				lastFailure = terminated
+				observed.jobSubmitterFailure = getJobSubmitterFailureMessage(
+					containerStatus.Name, terminated)
+			}
+		}
+	}
+	return nil
+}
+
 func (observer *ClusterStateObserver) observeJobResource(
 	observedJob *batchv1.Job) error {
 	var clusterNamespace = observer.request.Namespace
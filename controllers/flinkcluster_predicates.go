@@ -0,0 +1,90 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Filters out the updates of the cluster components which don't need a
+// reconciliation, e.g., the status updates of the TaskManager deployment
+// while its pods flap.
+var clusterEventFilter = predicate.Funcs{UpdateFunc: isClusterUpdateRelevant}
+
+// Checks whether an update of a FlinkCluster or one of its components needs a
+// reconciliation of the cluster. The periodic resyncs of the FlinkClusters
+// are kept, they also resync the components, so the resyncs of the components
+// are dropped. The status-only updates of the deployments and StatefulSets are
+// dropped unless they change the replica counts which the cluster status is
+// derived from.
+func isClusterUpdateRelevant(e event.UpdateEvent) bool {
+	if e.MetaOld == nil || e.MetaNew == nil {
+		return true
+	}
+	if _, ok := e.ObjectNew.(*v1beta1.FlinkCluster); ok {
+		return true
+	}
+	if e.MetaOld.GetResourceVersion() == e.MetaNew.GetResourceVersion() {
+		return false
+	}
+	if e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+		!e.MetaOld.GetDeletionTimestamp().Equal(e.MetaNew.GetDeletionTimestamp()) ||
+		!equality.Semantic.DeepEqual(e.MetaOld.GetLabels(), e.MetaNew.GetLabels()) ||
+		!equality.Semantic.DeepEqual(
+			e.MetaOld.GetAnnotations(), e.MetaNew.GetAnnotations()) ||
+		!equality.Semantic.DeepEqual(
+			e.MetaOld.GetOwnerReferences(), e.MetaNew.GetOwnerReferences()) {
+		return true
+	}
+	switch oldObject := e.ObjectOld.(type) {
+	case *appsv1.Deployment:
+		var newObject, ok = e.ObjectNew.(*appsv1.Deployment)
+		return !ok || isDeploymentReplicaStatusChanged(oldObject, newObject)
+	case *appsv1.StatefulSet:
+		var newObject, ok = e.ObjectNew.(*appsv1.StatefulSet)
+		return !ok || isStatefulSetReplicaStatusChanged(oldObject, newObject)
+	}
+	// The other components have no generation, e.g., ConfigMaps, so their
+	// out-of-band changes are only found by comparing them.
+	return true
+}
+
+func isDeploymentReplicaStatusChanged(
+	oldDeployment *appsv1.Deployment, newDeployment *appsv1.Deployment) bool {
+	var oldStatus = &oldDeployment.Status
+	var newStatus = &newDeployment.Status
+	return oldStatus.ObservedGeneration != newStatus.ObservedGeneration ||
+		oldStatus.Replicas != newStatus.Replicas ||
+		oldStatus.UpdatedReplicas != newStatus.UpdatedReplicas ||
+		oldStatus.ReadyReplicas != newStatus.ReadyReplicas ||
+		oldStatus.AvailableReplicas != newStatus.AvailableReplicas
+}
+
+func isStatefulSetReplicaStatusChanged(
+	oldStatefulSet *appsv1.StatefulSet, newStatefulSet *appsv1.StatefulSet) bool {
+	var oldStatus = &oldStatefulSet.Status
+	var newStatus = &newStatefulSet.Status
+	return oldStatus.ObservedGeneration != newStatus.ObservedGeneration ||
+		oldStatus.Replicas != newStatus.Replicas ||
+		oldStatus.UpdatedReplicas != newStatus.UpdatedReplicas ||
+		oldStatus.ReadyReplicas != newStatus.ReadyReplicas ||
+		oldStatus.CurrentReplicas != newStatus.CurrentReplicas
+}
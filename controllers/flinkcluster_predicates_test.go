@@ -0,0 +1,101 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newTestUpdateEvent(
+	oldObject runtime.Object,
+	oldMeta metav1.Object,
+	newObject runtime.Object,
+	newMeta metav1.Object) event.UpdateEvent {
+	return event.UpdateEvent{
+		MetaOld:   oldMeta,
+		ObjectOld: oldObject,
+		MetaNew:   newMeta,
+		ObjectNew: newObject,
+	}
+}
+
+func TestIsClusterUpdateRelevant(t *testing.T) {
+	var oldDeployment = &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "mycluster-taskmanager",
+			ResourceVersion: "100",
+			Generation:      2,
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			ReadyReplicas:      3,
+			AvailableReplicas:  3,
+		},
+	}
+
+	// Resync of a component.
+	var newDeployment = oldDeployment.DeepCopy()
+	assert.Assert(t, !isClusterUpdateRelevant(newTestUpdateEvent(
+		oldDeployment, oldDeployment, newDeployment, newDeployment)))
+
+	// Status-only update which doesn't change the replica counts.
+	newDeployment.ResourceVersion = "101"
+	newDeployment.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionTrue,
+	}}
+	assert.Assert(t, !isClusterUpdateRelevant(newTestUpdateEvent(
+		oldDeployment, oldDeployment, newDeployment, newDeployment)))
+
+	// A TaskManager pod is not ready.
+	newDeployment.Status.ReadyReplicas = 2
+	assert.Assert(t, isClusterUpdateRelevant(newTestUpdateEvent(
+		oldDeployment, oldDeployment, newDeployment, newDeployment)))
+
+	// Out-of-band spec change.
+	newDeployment = oldDeployment.DeepCopy()
+	newDeployment.ResourceVersion = "101"
+	newDeployment.Generation = 3
+	assert.Assert(t, isClusterUpdateRelevant(newTestUpdateEvent(
+		oldDeployment, oldDeployment, newDeployment, newDeployment)))
+
+	// Changes of the components without generation are kept.
+	var oldConfigMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "100"},
+	}
+	var newConfigMap = oldConfigMap.DeepCopy()
+	newConfigMap.ResourceVersion = "101"
+	newConfigMap.Data = map[string]string{"flink-conf.yaml": ""}
+	assert.Assert(t, isClusterUpdateRelevant(newTestUpdateEvent(
+		oldConfigMap, oldConfigMap, newConfigMap, newConfigMap)))
+
+	// Resyncs of the clusters are kept.
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "100"},
+	}
+	assert.Assert(t, isClusterUpdateRelevant(newTestUpdateEvent(
+		cluster, cluster, cluster, cluster)))
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Checks whether the JobManager and TaskManagers which are not created yet fit
+// in the ResourceQuotas of the namespace, so the cluster reports the
+// insufficient quota instead of leaving the pods pending. Returns the quotas
+// which they don't fit in with the requested and available resources, empty
+// if they fit. The scoped quotas are not checked.
+func getInsufficientQuota(observed *ObservedClusterState) string {
+	if len(observed.resourceQuotas) == 0 {
+		return ""
+	}
+	var desired = getDesiredClusterState(observed.cluster, time.Now())
+	var requested = corev1.ResourceList{}
+	if desired.JmDeployment != nil && observed.jmDeployment == nil {
+		addWorkloadResources(
+			requested,
+			desired.JmDeployment.Spec.Replicas,
+			&desired.JmDeployment.Spec.Template.Spec)
+	}
+	if desired.JmStatefulSet != nil && observed.jmStatefulSet == nil {
+		addWorkloadResources(
+			requested,
+			desired.JmStatefulSet.Spec.Replicas,
+			&desired.JmStatefulSet.Spec.Template.Spec)
+	}
+	if desired.TmDeployment != nil && observed.tmDeployment == nil {
+		addWorkloadResources(
+			requested,
+			desired.TmDeployment.Spec.Replicas,
+			&desired.TmDeployment.Spec.Template.Spec)
+	}
+	if desired.TmStatefulSet != nil && observed.tmStatefulSet == nil {
+		addWorkloadResources(
+			requested,
+			desired.TmStatefulSet.Spec.Replicas,
+			&desired.TmStatefulSet.Spec.Template.Spec)
+	}
+	if len(requested) == 0 {
+		return ""
+	}
+
+	var messages []string
+	for _, quota := range observed.resourceQuotas {
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		var names []string
+		for name := range quota.Status.Hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			var quantity, ok = requested[corev1.ResourceName(name)]
+			if !ok {
+				continue
+			}
+			var available = quota.Status.Hard[corev1.ResourceName(name)]
+			available.Sub(quota.Status.Used[corev1.ResourceName(name)])
+			if quantity.Cmp(available) > 0 {
+				messages = append(messages, fmt.Sprintf(
+					"ResourceQuota %v: requested %v %v, available %v",
+					quota.Name, name, quantity.String(), available.String()))
+			}
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Adds the resources of the pods of a workload to the requested resources by
+// the names of the quotas, e.g., `requests.cpu` and `cpu`.
+func addWorkloadResources(
+	requested corev1.ResourceList, replicas *int32, podSpec *corev1.PodSpec) {
+	var count int64 = 1
+	if replicas != nil {
+		count = int64(*replicas)
+	}
+	var requests, limits = getPodResources(podSpec)
+	var quotaResources = map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourcePods:           *resource.NewQuantity(1, resource.DecimalSI),
+		corev1.ResourceCPU:            requests[corev1.ResourceCPU],
+		corev1.ResourceMemory:         requests[corev1.ResourceMemory],
+		corev1.ResourceRequestsCPU:    requests[corev1.ResourceCPU],
+		corev1.ResourceRequestsMemory: requests[corev1.ResourceMemory],
+		corev1.ResourceLimitsCPU:      limits[corev1.ResourceCPU],
+		corev1.ResourceLimitsMemory:   limits[corev1.ResourceMemory],
+	}
+	for name, quantity := range quotaResources {
+		var total = requested[name]
+		for i := int64(0); i < count; i++ {
+			total.Add(quantity)
+		}
+		requested[name] = total
+	}
+}
+
+// Gets the effective requests and limits of a pod like the scheduler, i.e.,
+// the sum of its containers or the maximum of its init containers. A
+// container without a request requests its limit.
+func getPodResources(
+	podSpec *corev1.PodSpec) (corev1.ResourceList, corev1.ResourceList) {
+	var requests = corev1.ResourceList{}
+	var limits = corev1.ResourceList{}
+	for _, container := range podSpec.Containers {
+		for _, name := range []corev1.ResourceName{
+			corev1.ResourceCPU, corev1.ResourceMemory} {
+			var request = getContainerRequest(&container.Resources, name)
+			var total = requests[name]
+			total.Add(request)
+			requests[name] = total
+			if limit, ok := container.Resources.Limits[name]; ok {
+				var total = limits[name]
+				total.Add(limit)
+				limits[name] = total
+			}
+		}
+	}
+	for _, container := range podSpec.InitContainers {
+		for _, name := range []corev1.ResourceName{
+			corev1.ResourceCPU, corev1.ResourceMemory} {
+			var request = getContainerRequest(&container.Resources, name)
+			if request.Cmp(requests[name]) > 0 {
+				requests[name] = request
+			}
+			if limit, ok := container.Resources.Limits[name]; ok &&
+				limit.Cmp(limits[name]) > 0 {
+				limits[name] = limit
+			}
+		}
+	}
+	return requests, limits
+}
+
+func getContainerRequest(
+	resources *corev1.ResourceRequirements,
+	name corev1.ResourceName) resource.Quantity {
+	if request, ok := resources.Requests[name]; ok {
+		return request.DeepCopy()
+	}
+	if limit, ok := resources.Limits[name]; ok {
+		return limit.DeepCopy()
+	}
+	return resource.Quantity{}
+}
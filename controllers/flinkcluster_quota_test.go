@@ -0,0 +1,132 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPodResources(t *testing.T) {
+	var podSpec = corev1.PodSpec{
+		InitContainers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("3"),
+				},
+			},
+		}},
+		Containers: []corev1.Container{
+			{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("2Gi"),
+					},
+				},
+			},
+			{
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("512Mi"),
+					},
+				},
+			},
+		},
+	}
+
+	var requests, limits = getPodResources(&podSpec)
+
+	assert.Equal(t, requests.Cpu().String(), "3")
+	assert.Equal(t, requests.Memory().String(), "1536Mi")
+	assert.Equal(t, limits.Cpu().String(), "3")
+	assert.Equal(t, limits.Memory().String(), "2560Mi")
+}
+
+func TestGetInsufficientQuota(t *testing.T) {
+	var jmReplicas int32 = 1
+	var tmReplicas int32 = 4
+	var resources = corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		},
+	}
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.12.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				Replicas:    &jmReplicas,
+				AccessScope: v1beta1.AccessScopeCluster,
+				Resources:   resources,
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Replicas:  &tmReplicas,
+				Resources: resources,
+			},
+		},
+	}
+	cluster.Default()
+	var quota = corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("8"),
+				corev1.ResourceRequestsMemory: resource.MustParse("16Gi"),
+				corev1.ResourcePods:           resource.MustParse("10"),
+			},
+			Used: corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("4"),
+				corev1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+				corev1.ResourcePods:           resource.MustParse("2"),
+			},
+		},
+	}
+	var observed = &ObservedClusterState{
+		cluster:        cluster,
+		resourceQuotas: []corev1.ResourceQuota{quota},
+	}
+
+	assert.Equal(
+		t,
+		getInsufficientQuota(observed),
+		"ResourceQuota compute: requested requests.cpu 5, available 4")
+
+	// Only the components which are not created yet are checked.
+	observed.jmDeployment = &appsv1.Deployment{}
+	assert.Equal(t, getInsufficientQuota(observed), "")
+
+	// The scoped quotas are not checked.
+	observed.jmDeployment = nil
+	observed.resourceQuotas[0].Spec.Scopes =
+		[]corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+	assert.Equal(t, getInsufficientQuota(observed), "")
+}
@@ -18,20 +18,28 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
 	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"github.com/googlecloudplatform/flink-operator/controllers/storageclient"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -39,17 +47,26 @@ import (
 // ClusterReconciler takes actions to drive the observed state towards the
 // desired state.
 type ClusterReconciler struct {
-	k8sClient   client.Client
-	flinkClient flinkclient.FlinkClient
-	context     context.Context
-	log         logr.Logger
-	observed    ObservedClusterState
-	desired     DesiredClusterState
-	recorder    record.EventRecorder
+	k8sClient      client.Client
+	flinkClient    flinkclient.FlinkClient
+	storageClients storageclient.StorageClients
+	context        context.Context
+	log            logr.Logger
+	observed       ObservedClusterState
+	desired        DesiredClusterState
+	recorder       record.EventRecorder
+
+	// Whether the components are written with server-side apply, with the
+	// scheme which resolves their kinds.
+	serverSideApply bool
+	scheme          *runtime.Scheme
 }
 
 var requeueResult = ctrl.Result{RequeueAfter: 10 * time.Second, Requeue: true}
 
+// The field manager of the operator with server-side apply.
+const fieldManager = "flink-operator"
+
 // Compares the desired state and the observed state, if there is a difference,
 // takes actions to drive the observed state towards the desired state.
 func (reconciler *ClusterReconciler) reconcile() (ctrl.Result, error) {
@@ -66,6 +83,27 @@ func (reconciler *ClusterReconciler) reconcile() (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	err = reconciler.reconcileHighAvailabilityRBAC()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = reconciler.reconcileGeneratedSecrets()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = reconciler.reconcileRESTCertificate()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The PodGroup is created before the pods which are assigned to it.
+	err = reconciler.reconcilePodGroup()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	err = reconciler.reconcileJobManagerDeployment()
 	if err != nil {
 		return ctrl.Result{}, err
@@ -86,23 +124,174 @@ func (reconciler *ClusterReconciler) reconcile() (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
-	result, err := reconciler.reconcileJob()
+	err = reconciler.reconcilePodDisruptionBudgets()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = reconciler.reconcileNetworkPolicies()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = reconciler.reconcilePodMonitor()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = reconciler.reconcileHistoryServer()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The error of the job is returned after the remaining steps, so that
+	// they are not blocked by a failing job.
+	result, jobErr := reconciler.reconcileJob()
+
+	// There are no Kubernetes events when the jobs of a session cluster
+	// finish, so it is requeued to observe them for the idle timeout.
+	var cluster = reconciler.observed.cluster
+	if result == (ctrl.Result{}) && cluster.Spec.Job == nil &&
+		cluster.Spec.IdleTimeoutSeconds != nil &&
+		cluster.Status.State == v1beta1.ClusterStateRunning {
+		result = requeueResult
+	}
+
+	// Requeued to check the quota again, the changes of the ResourceQuotas
+	// are not watched.
+	if result == (ctrl.Result{}) && reconciler.isQuotaInsufficient() {
+		result = requeueResult
+	}
+
+	// Requeued to check whether the TaskManagers to be removed are idle, the
+	// task slots are not watched.
+	if result == (ctrl.Result{}) && reconciler.isTaskManagerDecommissionPending() {
+		result = requeueResult
+	}
+
+	// Requeued to take the cleanup action when the TTL of the finished job
+	// expires.
+	var now = time.Now()
+	var expirationTime = getJobExpirationTime(
+		cluster.Spec.Job, cluster.Status.Components.Job)
+	if result == (ctrl.Result{}) && expirationTime != nil &&
+		now.Before(*expirationTime) {
+		result = ctrl.Result{RequeueAfter: expirationTime.Sub(now)}
+	}
+
+	// Requeued to roll back the upgrade when the upgraded job doesn't run in
+	// time.
+	var rollbackTime = getJobUpgradeRollbackTime(cluster)
+	if result == (ctrl.Result{}) && rollbackTime != nil &&
+		now.Before(*rollbackTime) {
+		result = ctrl.Result{RequeueAfter: rollbackTime.Sub(now)}
+	}
+
+	// Requeued to fail the startup of the cluster which is not running in
+	// time.
+	var startupDeadline = getClusterStartupDeadline(cluster)
+	if result == (ctrl.Result{}) && startupDeadline != nil &&
+		now.Before(*startupDeadline) {
+		result = ctrl.Result{RequeueAfter: startupDeadline.Sub(now)}
+	}
+
+	// The autoscaler is reconciled with the updated cluster in the next
+	// reconciliation when the savepoint history is updated.
+	historyUpdated, err := reconciler.reconcileSavepointHistory()
+	if err != nil || historyUpdated {
+		return result, utilerrors.NewAggregate([]error{jobErr, err})
+	}
+	// Requeued to retry deleting the expired savepoints after the backoff.
+	var deletionRetryTime = getSavepointDeletionRetryTime(
+		cluster.Status.Components.Job)
+	if result == (ctrl.Result{}) && deletionRetryTime != nil &&
+		now.Before(*deletionRetryTime) {
+		result = ctrl.Result{RequeueAfter: deletionRetryTime.Sub(now)}
+	}
+
+	// Updates the cluster spec, so it is done after all the other components
+	// have been reconciled with the observed spec.
+	err = reconciler.reconcileAutoscaler()
+	if err != nil {
+		return ctrl.Result{}, utilerrors.NewAggregate([]error{jobErr, err})
+	}
 
-	return result, nil
+	return result, jobErr
 }
 
 func (reconciler *ClusterReconciler) reconcileJobManagerDeployment() error {
-	return reconciler.reconcileDeployment(
+	var err = reconciler.reconcileDeployment(
 		"JobManager",
 		reconciler.desired.JmDeployment,
 		reconciler.observed.jmDeployment)
+	if err == nil {
+		err = reconciler.reconcileHeadlessService(
+			"JobManager",
+			reconciler.desired.JmHeadlessService,
+			reconciler.observed.jmHeadlessService)
+	}
+	if err == nil {
+		err = reconciler.reconcileStatefulSet(
+			"JobManager",
+			reconciler.desired.JmStatefulSet,
+			reconciler.observed.jmStatefulSet)
+	}
+	return err
 }
 
 func (reconciler *ClusterReconciler) reconcileTaskManagerDeployment() error {
-	return reconciler.reconcileDeployment(
-		"TaskManager",
-		reconciler.desired.TmDeployment,
-		reconciler.observed.tmDeployment)
+	// Removing TaskManagers whose slots are used would restart the running
+	// job, the scale-down waits for them to become idle until the
+	// decommission timeout elapses.
+	if reconciler.isTaskManagerDecommissionPending() {
+		reconciler.log.Info(
+			"Waiting for the TaskManagers to become idle before scaling down",
+			"scaleDown", getTaskManagerScaleDown(&reconciler.observed))
+		return reconciler.reconcileHeadlessService(
+			"TaskManager",
+			reconciler.desired.TmHeadlessService,
+			reconciler.observed.tmHeadlessService)
+	}
+
+	var err = reconciler.setTaskManagerDeletionCosts()
+	if err == nil {
+		err = reconciler.reconcileDeployment(
+			"TaskManager",
+			reconciler.desired.TmDeployment,
+			reconciler.observed.tmDeployment)
+	}
+	if err == nil {
+		err = reconciler.reconcileHeadlessService(
+			"TaskManager",
+			reconciler.desired.TmHeadlessService,
+			reconciler.observed.tmHeadlessService)
+	}
+	if err == nil {
+		err = reconciler.reconcileStatefulSet(
+			"TaskManager",
+			reconciler.desired.TmStatefulSet,
+			reconciler.observed.tmStatefulSet)
+	}
+
+	// The TaskManagers are deleted by all the cleanup actions.
+	var desired = reconciler.desired
+	var observed = reconciler.observed
+	if err == nil && desired.TmDeployment == nil && desired.TmStatefulSet == nil &&
+		(observed.tmDeployment != nil || observed.tmStatefulSet != nil) {
+		var cluster = reconciler.observed.cluster
+		var jobState = ""
+		if cluster.Status.Components.Job != nil {
+			jobState = cluster.Status.Components.Job.State
+		}
+		reconciler.recorder.Event(
+			cluster,
+			corev1.EventTypeNormal,
+			"Cleanup",
+			fmt.Sprintf(
+				"Executed cleanup action %v, job state: %v",
+				getCleanupAction(cluster), jobState))
+	}
+	return err
 }
 
 func (reconciler *ClusterReconciler) reconcileDeployment(
@@ -112,13 +301,64 @@ func (reconciler *ClusterReconciler) reconcileDeployment(
 	var log = reconciler.log.WithValues("component", component)
 
 	if desiredDeployment != nil && observedDeployment == nil {
+		if reconciler.isQuotaInsufficient() {
+			log.Info("Waiting for the quota to create the deployment")
+			return nil
+		}
 		return reconciler.createDeployment(desiredDeployment, component)
 	}
 
 	if desiredDeployment != nil && observedDeployment != nil {
+		// Flink configuration changes are propagated through the config hash
+		// annotation of the pod template, so update the deployment to restart
+		// the pods when it, the image or the hash of the referenced data
+		// differs.
+		if isDeploymentUpdateRequired(desiredDeployment, observedDeployment) {
+			// Restarting the pods would kill the running job, stop it with a
+			// savepoint first.
+			if reconciler.isJobUpgradePending() {
+				log.Info("Waiting for the job to be stopped before updating the deployment")
+				return nil
+			}
+			var updatedDeployment = observedDeployment.DeepCopy()
+			updatedDeployment.Spec = desiredDeployment.Spec
+			return reconciler.updateDeployment(
+				desiredDeployment, updatedDeployment, component)
+		}
+		// Scale the deployment in place, new TaskManagers register their
+		// slots with the running JobManager.
+		if !reflect.DeepEqual(desiredDeployment.Spec.Replicas, observedDeployment.Spec.Replicas) {
+			// Removing TaskManagers would fail the running job if it still
+			// uses their slots, e.g., when the autoscaler decreases the
+			// parallelism, scale down after the job is stopped.
+			if isScaleDown(desiredDeployment, observedDeployment) &&
+				reconciler.isJobUpgradePending() {
+				log.Info("Waiting for the job to be stopped before scaling down the deployment")
+				return nil
+			}
+			log.Info(
+				"Scaling deployment",
+				"from", observedDeployment.Spec.Replicas,
+				"to", desiredDeployment.Spec.Replicas)
+			var updatedDeployment = observedDeployment.DeepCopy()
+			updatedDeployment.Spec.Replicas = desiredDeployment.Spec.Replicas
+			return reconciler.updateDeployment(
+				desiredDeployment, updatedDeployment, component)
+		}
+		// Out-of-band edits of the pod template, e.g., with kubectl, are
+		// reverted.
+		if reconciler.isComponentPodTemplateDrifted(
+			&desiredDeployment.Spec.Template, &observedDeployment.Spec.Template) {
+			log.Info("Reverting out-of-band changes of the deployment")
+			reconciler.recordDriftEvent(
+				component+" deployment", observedDeployment.Name)
+			var updatedDeployment = observedDeployment.DeepCopy()
+			updatedDeployment.Spec.Template = desiredDeployment.Spec.Template
+			return reconciler.updateDeployment(
+				desiredDeployment, updatedDeployment, component)
+		}
 		log.Info("Deployment already exists, no action")
 		return nil
-		// TODO(dagang): compare and update if needed.
 	}
 
 	if desiredDeployment == nil && observedDeployment != nil {
@@ -128,38 +368,295 @@ func (reconciler *ClusterReconciler) reconcileDeployment(
 	return nil
 }
 
+func (reconciler *ClusterReconciler) isTaskManagerDecommissionPending() bool {
+	return isTaskManagerDecommissionPending(&reconciler.observed, time.Now())
+}
+
+// Sets the deletion cost of the TaskManager pods of the deployment to the
+// task slots which they use before scaling it down, so that the idle
+// TaskManagers are removed first.
+func (reconciler *ClusterReconciler) setTaskManagerDeletionCosts() error {
+	var observed = reconciler.observed
+	if observed.tmDeployment == nil || observed.flinkTaskManagers == nil ||
+		getTaskManagerScaleDown(&observed) == 0 {
+		return nil
+	}
+	var usedSlots = getTaskManagerPodUsedSlots(
+		observed.tmPods, observed.flinkTaskManagers)
+	for i := range observed.tmPods {
+		var pod = &observed.tmPods[i]
+		var slots, ok = usedSlots[pod.Name]
+		var cost = strconv.Itoa(int(slots))
+		if !ok || pod.Annotations[podDeletionCostAnnotation] == cost {
+			continue
+		}
+		var patch = objectForPatch{
+			Metadata: objectMetaForPatch{
+				Annotations: map[string]interface{}{
+					podDeletionCostAnnotation: cost,
+				},
+			},
+		}
+		var patchBytes, err = json.Marshal(&patch)
+		if err != nil {
+			return err
+		}
+		err = reconciler.k8sClient.Patch(
+			reconciler.context,
+			pod,
+			client.ConstantPatch(types.MergePatchType, patchBytes))
+		if err != nil {
+			reconciler.log.Error(
+				err, "Failed to set the deletion cost of TaskManager pod", "pod", pod.Name)
+			return err
+		}
+		reconciler.log.Info(
+			"Set the deletion cost of TaskManager pod", "pod", pod.Name, "cost", cost)
+	}
+	return nil
+}
+
+// Checks whether the JobManager and TaskManagers which are not created yet
+// don't fit in the ResourceQuotas of the namespace, they are not created until
+// they fit instead of leaving their pods pending.
+func (reconciler *ClusterReconciler) isQuotaInsufficient() bool {
+	return len(reconciler.observed.cluster.Status.InsufficientQuota) > 0
+}
+
 func (reconciler *ClusterReconciler) createDeployment(
 	deployment *appsv1.Deployment, component string) error {
-	var context = reconciler.context
 	var log = reconciler.log.WithValues("component", component)
-	var k8sClient = reconciler.k8sClient
 
 	log.Info("Creating deployment", "deployment", *deployment)
-	var err = k8sClient.Create(context, deployment)
+	var err = reconciler.createComponent(deployment)
 	if err != nil {
 		log.Error(err, "Failed to create deployment")
 	} else {
 		log.Info("Deployment created")
 	}
+	reconciler.recordComponentEvent("create", component+" deployment", deployment.Name, err)
 	return err
 }
 
 func (reconciler *ClusterReconciler) updateDeployment(
-	deployment *appsv1.Deployment, component string) error {
-	var context = reconciler.context
+	desired *appsv1.Deployment,
+	deployment *appsv1.Deployment,
+	component string) error {
 	var log = reconciler.log.WithValues("component", component)
-	var k8sClient = reconciler.k8sClient
 
 	log.Info("Updating deployment", "deployment", deployment)
-	var err = k8sClient.Update(context, deployment)
+	var err = reconciler.updateComponent(desired, deployment)
 	if err != nil {
 		log.Error(err, "Failed to update deployment")
 	} else {
 		log.Info("Deployment updated")
 	}
+	reconciler.recordComponentEvent("update", component+" deployment", deployment.Name, err)
+	return err
+}
+
+func getFlinkConfHash(podTemplate *corev1.PodTemplateSpec) string {
+	return podTemplate.Annotations[flinkConfHashAnnotation]
+}
+
+func getReferencesHash(podTemplate *corev1.PodTemplateSpec) string {
+	return podTemplate.Annotations[referencesHashAnnotation]
+}
+
+func getContainerImage(podTemplate *corev1.PodTemplateSpec) string {
+	var containers = podTemplate.Spec.Containers
+	if len(containers) == 0 {
+		return ""
+	}
+	return containers[0].Image
+}
+
+func isPodTemplateUpdateRequired(
+	desiredTemplate *corev1.PodTemplateSpec,
+	observedTemplate *corev1.PodTemplateSpec) bool {
+	return getFlinkConfHash(desiredTemplate) != getFlinkConfHash(observedTemplate) ||
+		getContainerImage(desiredTemplate) != getContainerImage(observedTemplate) ||
+		getReferencesHash(desiredTemplate) != getReferencesHash(observedTemplate)
+}
+
+func isDeploymentUpdateRequired(
+	desiredDeployment *appsv1.Deployment,
+	observedDeployment *appsv1.Deployment) bool {
+	return isPodTemplateUpdateRequired(
+		&desiredDeployment.Spec.Template, &observedDeployment.Spec.Template)
+}
+
+// Checks whether the deployment is up to date with the desired state and all
+// its pods have been updated.
+func isDeploymentUpdated(
+	desiredDeployment *appsv1.Deployment,
+	observedDeployment *appsv1.Deployment) bool {
+	if desiredDeployment == nil || observedDeployment == nil {
+		return desiredDeployment == nil && observedDeployment == nil
+	}
+	return !isDeploymentUpdateRequired(desiredDeployment, observedDeployment) &&
+		observedDeployment.Status.ObservedGeneration >= observedDeployment.Generation &&
+		observedDeployment.Status.UpdatedReplicas == observedDeployment.Status.Replicas
+}
+
+func isScaleDown(
+	desiredDeployment *appsv1.Deployment,
+	observedDeployment *appsv1.Deployment) bool {
+	return desiredDeployment.Spec.Replicas != nil &&
+		observedDeployment.Spec.Replicas != nil &&
+		*desiredDeployment.Spec.Replicas < *observedDeployment.Spec.Replicas
+}
+
+// Reconciles the StatefulSet of a component like its deployment. Only the pod
+// template and the replicas are updated, the other fields of a StatefulSet,
+// e.g., the volume claim templates, are immutable. The pods are replaced by
+// the rolling update of the StatefulSet one at a time, from the highest
+// ordinal to the lowest.
+func (reconciler *ClusterReconciler) reconcileStatefulSet(
+	component string,
+	desiredStatefulSet *appsv1.StatefulSet,
+	observedStatefulSet *appsv1.StatefulSet) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	if desiredStatefulSet != nil && observedStatefulSet == nil {
+		if reconciler.isQuotaInsufficient() {
+			log.Info("Waiting for the quota to create the StatefulSet")
+			return nil
+		}
+		return reconciler.createStatefulSet(desiredStatefulSet, component)
+	}
+
+	if desiredStatefulSet != nil && observedStatefulSet != nil {
+		if isPodTemplateUpdateRequired(
+			&desiredStatefulSet.Spec.Template, &observedStatefulSet.Spec.Template) {
+			if reconciler.isJobUpgradePending() {
+				log.Info("Waiting for the job to be stopped before updating the StatefulSet")
+				return nil
+			}
+			var updatedStatefulSet = observedStatefulSet.DeepCopy()
+			updatedStatefulSet.Spec.Template = desiredStatefulSet.Spec.Template
+			updatedStatefulSet.Spec.Replicas = desiredStatefulSet.Spec.Replicas
+			return reconciler.updateStatefulSet(
+				desiredStatefulSet, updatedStatefulSet, component)
+		}
+		var desiredReplicas = desiredStatefulSet.Spec.Replicas
+		var observedReplicas = observedStatefulSet.Spec.Replicas
+		if !reflect.DeepEqual(desiredReplicas, observedReplicas) {
+			if desiredReplicas != nil && observedReplicas != nil &&
+				*desiredReplicas < *observedReplicas &&
+				reconciler.isJobUpgradePending() {
+				log.Info("Waiting for the job to be stopped before scaling down the StatefulSet")
+				return nil
+			}
+			log.Info(
+				"Scaling StatefulSet",
+				"from", observedReplicas,
+				"to", desiredReplicas)
+			var updatedStatefulSet = observedStatefulSet.DeepCopy()
+			updatedStatefulSet.Spec.Replicas = desiredReplicas
+			return reconciler.updateStatefulSet(
+				desiredStatefulSet, updatedStatefulSet, component)
+		}
+		if reconciler.isComponentPodTemplateDrifted(
+			&desiredStatefulSet.Spec.Template, &observedStatefulSet.Spec.Template) {
+			log.Info("Reverting out-of-band changes of the StatefulSet")
+			reconciler.recordDriftEvent(
+				component+" StatefulSet", observedStatefulSet.Name)
+			var updatedStatefulSet = observedStatefulSet.DeepCopy()
+			updatedStatefulSet.Spec.Template = desiredStatefulSet.Spec.Template
+			return reconciler.updateStatefulSet(
+				desiredStatefulSet, updatedStatefulSet, component)
+		}
+		log.Info("StatefulSet already exists, no action")
+		return nil
+	}
+
+	if desiredStatefulSet == nil && observedStatefulSet != nil {
+		return reconciler.deleteStatefulSet(observedStatefulSet, component)
+	}
+
+	return nil
+}
+
+func (reconciler *ClusterReconciler) createStatefulSet(
+	statefulSet *appsv1.StatefulSet, component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Creating StatefulSet", "StatefulSet", *statefulSet)
+	var err = reconciler.createComponent(statefulSet)
+	if err != nil {
+		log.Error(err, "Failed to create StatefulSet")
+	} else {
+		log.Info("StatefulSet created")
+	}
+	reconciler.recordComponentEvent("create", component+" StatefulSet", statefulSet.Name, err)
+	return err
+}
+
+func (reconciler *ClusterReconciler) updateStatefulSet(
+	desired *appsv1.StatefulSet,
+	statefulSet *appsv1.StatefulSet,
+	component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Updating StatefulSet", "StatefulSet", statefulSet)
+	var err = reconciler.updateComponent(desired, statefulSet)
+	if err != nil {
+		log.Error(err, "Failed to update StatefulSet")
+	} else {
+		log.Info("StatefulSet updated")
+	}
+	reconciler.recordComponentEvent("update", component+" StatefulSet", statefulSet.Name, err)
+	return err
+}
+
+// Deletes the StatefulSet, the claims of its volume claim templates are kept.
+func (reconciler *ClusterReconciler) deleteStatefulSet(
+	statefulSet *appsv1.StatefulSet, component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Deleting StatefulSet", "StatefulSet", statefulSet)
+	var err = reconciler.k8sClient.Delete(reconciler.context, statefulSet)
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		log.Error(err, "Failed to delete StatefulSet")
+	} else {
+		log.Info("StatefulSet deleted")
+	}
+	reconciler.recordComponentEvent("delete", component+" StatefulSet", statefulSet.Name, err)
 	return err
 }
 
+// Checks whether the StatefulSet is up to date with the desired state and all
+// its pods have been updated.
+func isStatefulSetUpdated(
+	desiredStatefulSet *appsv1.StatefulSet,
+	observedStatefulSet *appsv1.StatefulSet) bool {
+	if desiredStatefulSet == nil || observedStatefulSet == nil {
+		return desiredStatefulSet == nil && observedStatefulSet == nil
+	}
+	return !isPodTemplateUpdateRequired(
+		&desiredStatefulSet.Spec.Template, &observedStatefulSet.Spec.Template) &&
+		observedStatefulSet.Status.ObservedGeneration >= observedStatefulSet.Generation &&
+		observedStatefulSet.Status.UpdatedReplicas == observedStatefulSet.Status.Replicas
+}
+
+// Checks whether the JobManager Deployment or StatefulSet is up to date with
+// the desired state and all its pods have been updated.
+func isJobManagerUpdated(
+	desired *DesiredClusterState, observed *ObservedClusterState) bool {
+	return isDeploymentUpdated(desired.JmDeployment, observed.jmDeployment) &&
+		isStatefulSetUpdated(desired.JmStatefulSet, observed.jmStatefulSet)
+}
+
+// Checks whether the TaskManager Deployment or StatefulSet is up to date with
+// the desired state and all its pods have been updated.
+func isTaskManagerUpdated(
+	desired *DesiredClusterState, observed *ObservedClusterState) bool {
+	return isDeploymentUpdated(desired.TmDeployment, observed.tmDeployment) &&
+		isStatefulSetUpdated(desired.TmStatefulSet, observed.tmStatefulSet)
+}
+
 func (reconciler *ClusterReconciler) deleteDeployment(
 	deployment *appsv1.Deployment, component string) error {
 	var context = reconciler.context
@@ -174,26 +671,73 @@ func (reconciler *ClusterReconciler) deleteDeployment(
 	} else {
 		log.Info("Deployment deleted")
 	}
+	reconciler.recordComponentEvent("delete", component+" deployment", deployment.Name, err)
 	return err
 }
 
 func (reconciler *ClusterReconciler) reconcileJobManagerService() error {
-	var desiredJmService = reconciler.desired.JmService
-	var observedJmService = reconciler.observed.jmService
+	var err = reconciler.reconcileService(
+		"JobManager",
+		reconciler.desired.JmService,
+		reconciler.observed.jmService)
+	if err == nil {
+		err = reconciler.reconcileService(
+			"JobManager REST",
+			reconciler.desired.JmRESTService,
+			reconciler.observed.jmRESTService)
+	}
+	return err
+}
+
+// Reconciles a service of a component, its out-of-band changes are reverted.
+func (reconciler *ClusterReconciler) reconcileService(
+	component string,
+	desiredService *corev1.Service,
+	observedService *corev1.Service) error {
+	if desiredService != nil && observedService == nil {
+		return reconciler.createService(desiredService, component)
+	}
+
+	if desiredService != nil && observedService != nil {
+		if isServiceDrifted(desiredService, observedService) {
+			reconciler.log.Info(fmt.Sprintf(
+				"Reverting out-of-band changes of the %v service", component))
+			reconciler.recordDriftEvent(component+" service", observedService.Name)
+			return reconciler.updateService(
+				desiredService,
+				getRevertedService(desiredService, observedService),
+				component)
+		}
+		reconciler.log.Info(component + " service already exists, no action")
+		return nil
+	}
+
+	if desiredService == nil && observedService != nil {
+		return reconciler.deleteService(observedService, component)
+	}
+
+	return nil
+}
 
-	if desiredJmService != nil && observedJmService == nil {
-		return reconciler.createService(desiredJmService, "JobManager")
+// Reconciles the headless service of the StatefulSet of a component, it is not
+// updated as its ports cannot be changed.
+func (reconciler *ClusterReconciler) reconcileHeadlessService(
+	component string,
+	desiredService *corev1.Service,
+	observedService *corev1.Service) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	if desiredService != nil && observedService == nil {
+		return reconciler.createService(desiredService, component)
 	}
 
-	if desiredJmService != nil && observedJmService != nil {
-		reconciler.log.Info("JobManager service already exists, no action")
+	if desiredService != nil && observedService != nil {
+		log.Info("Headless service already exists, no action")
 		return nil
-		// TODO(dagang): compare and update if needed.
 	}
 
-	if desiredJmService == nil && observedJmService != nil {
-		return reconciler.deleteService(observedJmService, "JobManager")
-		// TODO(dagang): compare and update if needed.
+	if desiredService == nil && observedService != nil {
+		return reconciler.deleteService(observedService, component)
 	}
 
 	return nil
@@ -201,17 +745,33 @@ func (reconciler *ClusterReconciler) reconcileJobManagerService() error {
 
 func (reconciler *ClusterReconciler) createService(
 	service *corev1.Service, component string) error {
-	var context = reconciler.context
 	var log = reconciler.log.WithValues("component", component)
-	var k8sClient = reconciler.k8sClient
 
 	log.Info("Creating service", "resource", *service)
-	var err = k8sClient.Create(context, service)
+	var err = reconciler.createComponent(service)
 	if err != nil {
 		log.Info("Failed to create service", "error", err)
 	} else {
 		log.Info("Service created")
 	}
+	reconciler.recordComponentEvent("create", component+" service", service.Name, err)
+	return err
+}
+
+func (reconciler *ClusterReconciler) updateService(
+	desired *corev1.Service,
+	service *corev1.Service,
+	component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Updating service", "service", service)
+	var err = reconciler.updateComponent(desired, service)
+	if err != nil {
+		log.Error(err, "Failed to update service")
+	} else {
+		log.Info("Service updated")
+	}
+	reconciler.recordComponentEvent("update", component+" service", service.Name, err)
 	return err
 }
 
@@ -229,6 +789,7 @@ func (reconciler *ClusterReconciler) deleteService(
 	} else {
 		log.Info("service deleted")
 	}
+	reconciler.recordComponentEvent("delete", component+" service", service.Name, err)
 	return err
 }
 
@@ -241,9 +802,18 @@ func (reconciler *ClusterReconciler) reconcileJobManagerIngress() error {
 	}
 
 	if desiredJmIngress != nil && observedJmIngress != nil {
+		if isIngressDrifted(desiredJmIngress, observedJmIngress) {
+			reconciler.log.Info("Reverting out-of-band changes of the JobManager ingress")
+			reconciler.recordDriftEvent("JobManager ingress", observedJmIngress.Name)
+			var updatedIngress = observedJmIngress.DeepCopy()
+			updatedIngress.Annotations = mergeStringMaps(
+				observedJmIngress.Annotations, desiredJmIngress.Annotations)
+			updatedIngress.Spec = desiredJmIngress.Spec
+			return reconciler.updateIngress(
+				desiredJmIngress, updatedIngress, "JobManager")
+		}
 		reconciler.log.Info("JobManager ingress already exists, no action")
 		return nil
-		// TODO: compare and update if needed.
 	}
 
 	if desiredJmIngress == nil && observedJmIngress != nil {
@@ -255,17 +825,33 @@ func (reconciler *ClusterReconciler) reconcileJobManagerIngress() error {
 
 func (reconciler *ClusterReconciler) createIngress(
 	ingress *extensionsv1beta1.Ingress, component string) error {
-	var context = reconciler.context
 	var log = reconciler.log.WithValues("component", component)
-	var k8sClient = reconciler.k8sClient
 
 	log.Info("Creating ingress", "resource", *ingress)
-	var err = k8sClient.Create(context, ingress)
+	var err = reconciler.createComponent(ingress)
 	if err != nil {
 		log.Info("Failed to create ingress", "error", err)
 	} else {
 		log.Info("Ingress created")
 	}
+	reconciler.recordComponentEvent("create", component+" ingress", ingress.Name, err)
+	return err
+}
+
+func (reconciler *ClusterReconciler) updateIngress(
+	desired *extensionsv1beta1.Ingress,
+	ingress *extensionsv1beta1.Ingress,
+	component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Updating ingress", "ingress", ingress)
+	var err = reconciler.updateComponent(desired, ingress)
+	if err != nil {
+		log.Error(err, "Failed to update ingress")
+	} else {
+		log.Info("Ingress updated")
+	}
+	reconciler.recordComponentEvent("update", component+" ingress", ingress.Name, err)
 	return err
 }
 
@@ -283,6 +869,79 @@ func (reconciler *ClusterReconciler) deleteIngress(
 	} else {
 		log.Info("Ingress deleted")
 	}
+	reconciler.recordComponentEvent("delete", component+" ingress", ingress.Name, err)
+	return err
+}
+
+// Reconciles the deployment, service and ingress of the History Server. The
+// History Server does not run jobs, so its pods are restarted right away when
+// the Flink configuration or the image changes.
+func (reconciler *ClusterReconciler) reconcileHistoryServer() error {
+	var desired = reconciler.desired
+	var observed = reconciler.observed
+	var component = "HistoryServer"
+	var err error
+
+	var desiredDeployment = desired.HistoryServerDeployment
+	var observedDeployment = observed.historyServerDeployment
+	if desiredDeployment != nil && observedDeployment == nil {
+		err = reconciler.createDeployment(desiredDeployment, component)
+	} else if desiredDeployment != nil && observedDeployment != nil {
+		if isDeploymentUpdateRequired(desiredDeployment, observedDeployment) {
+			var updatedDeployment = observedDeployment.DeepCopy()
+			updatedDeployment.Spec = desiredDeployment.Spec
+			err = reconciler.updateDeployment(
+				desiredDeployment, updatedDeployment, component)
+		} else if reconciler.isComponentPodTemplateDrifted(
+			&desiredDeployment.Spec.Template, &observedDeployment.Spec.Template) {
+			reconciler.recordDriftEvent(
+				"History Server deployment", observedDeployment.Name)
+			var updatedDeployment = observedDeployment.DeepCopy()
+			updatedDeployment.Spec.Template = desiredDeployment.Spec.Template
+			err = reconciler.updateDeployment(
+				desiredDeployment, updatedDeployment, component)
+		}
+	} else if desiredDeployment == nil && observedDeployment != nil {
+		err = reconciler.deleteDeployment(observedDeployment, component)
+	}
+	if err != nil {
+		return err
+	}
+
+	var desiredService = desired.HistoryServerService
+	var observedService = observed.historyServerService
+	if desiredService != nil && observedService == nil {
+		err = reconciler.createService(desiredService, component)
+	} else if desiredService != nil &&
+		isServiceDrifted(desiredService, observedService) {
+		reconciler.recordDriftEvent("History Server service", observedService.Name)
+		err = reconciler.updateService(
+			desiredService,
+			getRevertedService(desiredService, observedService),
+			component)
+	} else if desiredService == nil && observedService != nil {
+		err = reconciler.deleteService(observedService, component)
+	}
+	if err != nil {
+		return err
+	}
+
+	var desiredIngress = desired.HistoryServerIngress
+	var observedIngress = observed.historyServerIngress
+	if desiredIngress != nil && observedIngress == nil {
+		err = reconciler.createIngress(desiredIngress, component)
+	} else if desiredIngress != nil &&
+		isIngressDrifted(desiredIngress, observedIngress) {
+		reconciler.recordDriftEvent("History Server ingress", observedIngress.Name)
+		var updatedIngress = observedIngress.DeepCopy()
+		updatedIngress.Annotations = mergeStringMaps(
+			observedIngress.Annotations, desiredIngress.Annotations)
+		updatedIngress.Spec = desiredIngress.Spec
+		err = reconciler.updateIngress(
+			desiredIngress, updatedIngress, component)
+	} else if desiredIngress == nil && observedIngress != nil {
+		err = reconciler.deleteIngress(observedIngress, component)
+	}
 	return err
 }
 
@@ -295,9 +954,14 @@ func (reconciler *ClusterReconciler) reconcileConfigMap() error {
 	}
 
 	if desiredConfigMap != nil && observedConfigMap != nil {
+		if !reflect.DeepEqual(desiredConfigMap.Data, observedConfigMap.Data) {
+			var updatedConfigMap = observedConfigMap.DeepCopy()
+			updatedConfigMap.Data = desiredConfigMap.Data
+			return reconciler.updateConfigMap(
+				desiredConfigMap, updatedConfigMap, "ConfigMap")
+		}
 		reconciler.log.Info("ConfigMap already exists, no action")
 		return nil
-		// TODO: compare and update if needed.
 	}
 
 	if desiredConfigMap == nil && observedConfigMap != nil {
@@ -309,17 +973,33 @@ func (reconciler *ClusterReconciler) reconcileConfigMap() error {
 
 func (reconciler *ClusterReconciler) createConfigMap(
 	cm *corev1.ConfigMap, component string) error {
-	var context = reconciler.context
 	var log = reconciler.log.WithValues("component", component)
-	var k8sClient = reconciler.k8sClient
 
 	log.Info("Creating configMap", "configMap", *cm)
-	var err = k8sClient.Create(context, cm)
+	var err = reconciler.createComponent(cm)
 	if err != nil {
 		log.Info("Failed to create configMap", "error", err)
 	} else {
 		log.Info("ConfigMap created")
 	}
+	reconciler.recordComponentEvent("create", component, cm.Name, err)
+	return err
+}
+
+func (reconciler *ClusterReconciler) updateConfigMap(
+	desired *corev1.ConfigMap,
+	cm *corev1.ConfigMap,
+	component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Updating configMap", "configMap", cm)
+	var err = reconciler.updateComponent(desired, cm)
+	if err != nil {
+		log.Error(err, "Failed to update configMap")
+	} else {
+		log.Info("ConfigMap updated")
+	}
+	reconciler.recordComponentEvent("update", component, cm.Name, err)
 	return err
 }
 
@@ -337,9 +1017,358 @@ func (reconciler *ClusterReconciler) deleteConfigMap(
 	} else {
 		log.Info("ConfigMap deleted")
 	}
+	reconciler.recordComponentEvent("delete", component, cm.Name, err)
+	return err
+}
+
+// Reconciles the ServiceAccount, Role and RoleBinding which allow the
+// JobManager and TaskManagers to manage the HA ConfigMaps. They are created
+// before the deployments, so that the pods can use the service account.
+func (reconciler *ClusterReconciler) reconcileHighAvailabilityRBAC() error {
+	var desired = reconciler.desired
+	var observed = reconciler.observed
+	var err error
+
+	if desired.HAServiceAccount != nil && observed.haServiceAccount == nil {
+		err = reconciler.createObject(desired.HAServiceAccount, "HAServiceAccount")
+	} else if desired.HAServiceAccount == nil && observed.haServiceAccount != nil {
+		err = reconciler.deleteObject(observed.haServiceAccount, "HAServiceAccount")
+	}
+	if err != nil {
+		return err
+	}
+
+	if desired.HARole != nil && observed.haRole == nil {
+		err = reconciler.createObject(desired.HARole, "HARole")
+	} else if desired.HARole == nil && observed.haRole != nil {
+		err = reconciler.deleteObject(observed.haRole, "HARole")
+	}
+	if err != nil {
+		return err
+	}
+
+	if desired.HARoleBinding != nil && observed.haRoleBinding == nil {
+		err = reconciler.createObject(desired.HARoleBinding, "HARoleBinding")
+	} else if desired.HARoleBinding == nil && observed.haRoleBinding != nil {
+		err = reconciler.deleteObject(observed.haRoleBinding, "HARoleBinding")
+	}
 	return err
 }
 
+// Reconciles the Secrets generated by the operator, i.e., the keystore of the
+// internal TLS and the keystore password of the REST API certificate. They are
+// created before the components which use them, and never updated, as a new
+// keystore would not be trusted by the running pods. They are not deleted when
+// the TLS is disabled, but with the cluster.
+func (reconciler *ClusterReconciler) reconcileGeneratedSecrets() error {
+	var cluster = reconciler.observed.cluster
+	if isInternalTLSSecretGenerated(cluster) &&
+		reconciler.observed.internalTLSSecret == nil {
+		var secret, err = getDesiredInternalTLSSecret(cluster, time.Now())
+		if err == nil {
+			setResourceMetadata(secret, getResourceMetadata(&cluster.Spec, nil, nil))
+			err = reconciler.createSecret(secret, "InternalTLSSecret")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if isRESTTLSCertificateRequested(cluster) &&
+		reconciler.observed.restTLSPasswordSecret == nil {
+		var secret, err = getDesiredRESTTLSPasswordSecret(cluster)
+		if err == nil {
+			setResourceMetadata(secret, getResourceMetadata(&cluster.Spec, nil, nil))
+			err = reconciler.createSecret(secret, "RESTTLSPasswordSecret")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates a Secret generated by the operator. The object is not logged, as it
+// contains keys or passwords.
+func (reconciler *ClusterReconciler) createSecret(
+	secret *corev1.Secret, component string) error {
+	var log = reconciler.log.WithValues("component", component)
+	log.Info("Creating secret", "name", secret.Name)
+	var err = reconciler.createComponent(secret)
+	if err != nil {
+		log.Error(err, "Failed to create secret")
+	} else {
+		log.Info("Secret created")
+	}
+	reconciler.recordComponentEvent("create", component, secret.Name, err)
+	return err
+}
+
+// Reconciles the cert-manager Certificate of the REST API, it is updated when
+// its issuer or DNS names change, the other fields of the spec may be
+// defaulted by cert-manager.
+func (reconciler *ClusterReconciler) reconcileRESTCertificate() error {
+	var desiredCertificate = reconciler.desired.RESTCertificate
+	var observedCertificate = reconciler.observed.restCertificate
+
+	if desiredCertificate != nil && observedCertificate == nil {
+		return reconciler.createObject(desiredCertificate, "RESTCertificate")
+	}
+
+	if desiredCertificate != nil && observedCertificate != nil {
+		var changed bool
+		for _, field := range []string{"issuerRef", "dnsNames"} {
+			var desiredField, _, _ = unstructured.NestedFieldNoCopy(
+				desiredCertificate.Object, "spec", field)
+			var observedField, _, _ = unstructured.NestedFieldNoCopy(
+				observedCertificate.Object, "spec", field)
+			if !reflect.DeepEqual(desiredField, observedField) {
+				changed = true
+			}
+		}
+		if !changed {
+			reconciler.log.Info("RESTCertificate already exists, no action")
+			return nil
+		}
+		var updatedCertificate = observedCertificate.DeepCopy()
+		updatedCertificate.Object["spec"] = desiredCertificate.Object["spec"]
+		return reconciler.updateObject(
+			desiredCertificate, updatedCertificate, "RESTCertificate")
+	}
+
+	if desiredCertificate == nil && observedCertificate != nil {
+		return reconciler.deleteObject(observedCertificate, "RESTCertificate")
+	}
+
+	return nil
+}
+
+// Reconciles the PodDisruptionBudgets of the JobManager and TaskManager pods.
+// They are not updated, as their spec cannot be changed.
+func (reconciler *ClusterReconciler) reconcilePodDisruptionBudgets() error {
+	var desired = reconciler.desired
+	var observed = reconciler.observed
+	var err error
+
+	if desired.JmPodDisruptionBudget != nil && observed.jmPDB == nil {
+		err = reconciler.createObject(
+			desired.JmPodDisruptionBudget, "JobManager PodDisruptionBudget")
+	} else if desired.JmPodDisruptionBudget == nil && observed.jmPDB != nil {
+		err = reconciler.deleteObject(
+			observed.jmPDB, "JobManager PodDisruptionBudget")
+	}
+	if err != nil {
+		return err
+	}
+
+	if desired.TmPodDisruptionBudget != nil && observed.tmPDB == nil {
+		err = reconciler.createObject(
+			desired.TmPodDisruptionBudget, "TaskManager PodDisruptionBudget")
+	} else if desired.TmPodDisruptionBudget == nil && observed.tmPDB != nil {
+		err = reconciler.deleteObject(
+			observed.tmPDB, "TaskManager PodDisruptionBudget")
+	}
+	return err
+}
+
+// Reconciles the NetworkPolicies of the JobManager and TaskManager pods.
+func (reconciler *ClusterReconciler) reconcileNetworkPolicies() error {
+	var desired = reconciler.desired
+	var observed = reconciler.observed
+	var err error
+
+	if desired.JmNetworkPolicy != nil && observed.jmNetworkPolicy == nil {
+		err = reconciler.createObject(
+			desired.JmNetworkPolicy, "JobManager NetworkPolicy")
+	} else if desired.JmNetworkPolicy == nil && observed.jmNetworkPolicy != nil {
+		err = reconciler.deleteObject(
+			observed.jmNetworkPolicy, "JobManager NetworkPolicy")
+	}
+	if err != nil {
+		return err
+	}
+
+	if desired.TmNetworkPolicy != nil && observed.tmNetworkPolicy == nil {
+		err = reconciler.createObject(
+			desired.TmNetworkPolicy, "TaskManager NetworkPolicy")
+	} else if desired.TmNetworkPolicy == nil && observed.tmNetworkPolicy != nil {
+		err = reconciler.deleteObject(
+			observed.tmNetworkPolicy, "TaskManager NetworkPolicy")
+	}
+	return err
+}
+
+func (reconciler *ClusterReconciler) createObject(
+	object runtime.Object, component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Creating object", "object", object)
+	var err = reconciler.createComponent(object)
+	if err != nil {
+		log.Error(err, "Failed to create object")
+	} else {
+		log.Info("Object created")
+	}
+	reconciler.recordComponentEvent("create", component, getObjectName(object), err)
+	return err
+}
+
+func (reconciler *ClusterReconciler) updateObject(
+	desired runtime.Object,
+	object runtime.Object,
+	component string) error {
+	var log = reconciler.log.WithValues("component", component)
+
+	log.Info("Updating object", "object", object)
+	var err = reconciler.updateComponent(desired, object)
+	if err != nil {
+		log.Error(err, "Failed to update object")
+	} else {
+		log.Info("Object updated")
+	}
+	reconciler.recordComponentEvent("update", component, getObjectName(object), err)
+	return err
+}
+
+func (reconciler *ClusterReconciler) deleteObject(
+	object runtime.Object, component string) error {
+	var context = reconciler.context
+	var log = reconciler.log.WithValues("component", component)
+	var k8sClient = reconciler.k8sClient
+
+	log.Info("Deleting object", "object", object)
+	var err = k8sClient.Delete(context, object)
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		log.Error(err, "Failed to delete object")
+	} else {
+		log.Info("Object deleted")
+	}
+	reconciler.recordComponentEvent("delete", component, getObjectName(object), err)
+	return err
+}
+
+// Checks whether the pod template of a component has drifted from the desired
+// template. With server-side apply, the containers added by other controllers
+// are kept, so they are not taken as drift.
+func (reconciler *ClusterReconciler) isComponentPodTemplateDrifted(
+	desiredTemplate *corev1.PodTemplateSpec,
+	observedTemplate *corev1.PodTemplateSpec) bool {
+	if reconciler.serverSideApply {
+		observedTemplate = getOwnedPodTemplate(desiredTemplate, observedTemplate)
+	}
+	return isPodTemplateDrifted(desiredTemplate, observedTemplate)
+}
+
+// Creates the component, with server-side apply if it is enabled.
+func (reconciler *ClusterReconciler) createComponent(
+	desired runtime.Object) error {
+	if reconciler.serverSideApply {
+		return reconciler.applyComponent(desired)
+	}
+	var object, err = reconciler.getComponentObject(desired)
+	if err != nil {
+		return err
+	}
+	return reconciler.k8sClient.Create(reconciler.context, object)
+}
+
+// Updates the component to the updated object, i.e., the observed object with
+// the desired changes. With server-side apply, the desired object is applied
+// instead, so only the fields set by the operator are changed and the fields
+// set by other controllers, e.g., injectors, are kept.
+func (reconciler *ClusterReconciler) updateComponent(
+	desired runtime.Object, updated runtime.Object) error {
+	if reconciler.serverSideApply {
+		return reconciler.applyComponent(desired)
+	}
+	var object, err = reconciler.getComponentObject(updated)
+	if err != nil {
+		return err
+	}
+	return reconciler.k8sClient.Update(reconciler.context, object)
+}
+
+// Applies the desired component with server-side apply as `fieldManager`.
+// The operator takes over the fields it sets from other field managers.
+func (reconciler *ClusterReconciler) applyComponent(
+	desired runtime.Object) error {
+	var object = desired.DeepCopyObject()
+	var kinds, _, err = reconciler.scheme.ObjectKinds(object)
+	if err != nil {
+		return err
+	}
+	object.GetObjectKind().SetGroupVersionKind(kinds[0])
+	object, err = reconciler.getComponentObject(object)
+	if err != nil {
+		return err
+	}
+	return reconciler.k8sClient.Patch(
+		reconciler.context,
+		object,
+		client.Apply,
+		client.FieldOwner(fieldManager),
+		client.ForceOwnership)
+}
+
+// Reconciles the PodMonitor of the Prometheus Operator.
+func (reconciler *ClusterReconciler) reconcilePodMonitor() error {
+	var desiredPodMonitor = reconciler.desired.PodMonitor
+	var observedPodMonitor = reconciler.observed.podMonitor
+
+	if desiredPodMonitor != nil && observedPodMonitor == nil {
+		return reconciler.createObject(desiredPodMonitor, "PodMonitor")
+	}
+
+	if desiredPodMonitor != nil && observedPodMonitor != nil {
+		reconciler.log.Info("PodMonitor already exists, no action")
+		return nil
+	}
+
+	if desiredPodMonitor == nil && observedPodMonitor != nil {
+		return reconciler.deleteObject(observedPodMonitor, "PodMonitor")
+	}
+
+	return nil
+}
+
+// Reconciles the PodGroup of the batch scheduler, its minimum member is
+// updated when the JobManager or TaskManager replicas change.
+func (reconciler *ClusterReconciler) reconcilePodGroup() error {
+	var desiredPodGroup = reconciler.desired.PodGroup
+	var observedPodGroup = reconciler.observed.podGroup
+
+	if desiredPodGroup != nil && observedPodGroup == nil {
+		return reconciler.createObject(desiredPodGroup, "PodGroup")
+	}
+
+	if desiredPodGroup != nil && observedPodGroup != nil {
+		// Only the minimum member is compared, the other fields of the spec
+		// may be defaulted by the batch scheduler.
+		var desiredMinMember, _, _ = unstructured.NestedInt64(
+			desiredPodGroup.Object, "spec", "minMember")
+		var observedMinMember, _, _ = unstructured.NestedInt64(
+			observedPodGroup.Object, "spec", "minMember")
+		if desiredMinMember == observedMinMember {
+			reconciler.log.Info("PodGroup already exists, no action")
+			return nil
+		}
+		var updatedPodGroup = observedPodGroup.DeepCopy()
+		var err = unstructured.SetNestedField(
+			updatedPodGroup.Object, desiredMinMember, "spec", "minMember")
+		if err != nil {
+			return err
+		}
+		return reconciler.updateObject(
+			desiredPodGroup, updatedPodGroup, "PodGroup")
+	}
+
+	if desiredPodGroup == nil && observedPodGroup != nil {
+		return reconciler.deleteObject(observedPodGroup, "PodGroup")
+	}
+
+	return nil
+}
+
 func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 	var log = reconciler.log
 	var desiredJob = reconciler.desired.Job
@@ -347,6 +1376,35 @@ func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 	var observedJob = observed.job
 	var err error
 
+	if shouldRollbackJobUpgrade(observed.cluster, time.Now()) {
+		log.Info("Upgraded job is not running in time, rolling back")
+		err = reconciler.rollbackJobUpgrade()
+		if err != nil {
+			log.Error(err, "Failed to roll back job upgrade")
+		}
+		return requeueResult, err
+	}
+
+	if observedJob == nil && isJobFailedByStartupTimeout(observed.cluster) {
+		log.Info("Cluster didn't start in time, the job is not submitted")
+		return ctrl.Result{}, nil
+	}
+
+	if isRestAPISubmission(observed.cluster.Spec.Job) {
+		return reconciler.reconcileRestAPIJob()
+	}
+
+	// Start the scheduled run when it is due, otherwise requeue at the next
+	// scheduled time after the current run is reconciled.
+	var scheduleResult ctrl.Result
+	if isScheduledJob(observed.cluster.Spec.Job) {
+		var scheduled bool
+		scheduleResult, scheduled, err = reconciler.reconcileJobSchedule()
+		if scheduled || err != nil {
+			return scheduleResult, err
+		}
+	}
+
 	// Update status changed via job reconciliation.
 	var newSavepointStatus *v1beta1.SavepointStatus
 	var newControlStatus *v1beta1.FlinkClusterControlStatus
@@ -368,6 +1426,21 @@ func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 			return requeueResult, err
 		}
 
+		// Do not submit the job to a JobManager or TaskManagers which are
+		// going to be restarted by an update.
+		if !isJobManagerUpdated(&reconciler.desired, &observed) ||
+			!isTaskManagerUpdated(&reconciler.desired, &observed) {
+			log.Info("Waiting for JobManager and TaskManager to be updated")
+			return requeueResult, nil
+		}
+
+		if !hasReadySlots(observed.cluster.Spec.Job, observed.flinkClusterOverview) {
+			log.Info(
+				"Waiting for task slots",
+				"required", *observed.cluster.Spec.Job.ReadySlotsRequired)
+			return requeueResult, nil
+		}
+
 		err = reconciler.createJob(desiredJob)
 		return requeueResult, err
 	}
@@ -375,10 +1448,27 @@ func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 	// Update or restart
 	var jobID = reconciler.getFlinkJobID()
 	if desiredJob != nil && observedJob != nil {
-		var restartPolicy = observed.cluster.Spec.Job.RestartPolicy
 		var observedJobStatus = observed.cluster.Status.Components.Job
 
-		if shouldRestartJob(restartPolicy, observedJobStatus) {
+		// Retry the failed job submission by creating the job submitter again
+		// after the backoff.
+		if observedJob.Status.Failed > 0 &&
+			isJobSubmissionRetryPending(observedJobStatus) {
+			var retryTime = getJobSubmissionRetryTime(
+				observed.cluster.Spec.Job, observedJobStatus)
+			var now = time.Now()
+			if now.Before(retryTime) {
+				log.Info("Waiting to retry job submission", "retryTime", retryTime)
+				return ctrl.Result{RequeueAfter: retryTime.Sub(now)}, nil
+			}
+			log.Info(
+				"Retrying job submission",
+				"attempts", observedJobStatus.SubmissionAttempts)
+			err = reconciler.deleteJob(observedJob)
+			return requeueResult, err
+		}
+
+		if shouldRestartJob(observed.cluster.Spec.Job, observedJobStatus) {
 			var err = reconciler.restartJob()
 			if err != nil {
 				return requeueResult, err
@@ -386,6 +1476,25 @@ func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 			return ctrl.Result{}, nil
 		}
 
+		if reconciler.isJobUpgradeRequested() && !isJobStopped(observedJobStatus) {
+			log.Info("Job spec changed, upgrading job", "jobID", jobID)
+			var savepointStatus, err = reconciler.upgradeJob(jobID)
+			if !reflect.DeepEqual(savepointStatus, observed.cluster.Status.Savepoint) {
+				newSavepointStatus = savepointStatus
+			}
+			if err != nil {
+				log.Error(err, "Failed to upgrade job", "jobID", jobID)
+			}
+			return requeueResult, err
+		}
+
+		// Other running jobs than the tracked one, e.g., the job was
+		// submitted twice, are cancelled.
+		if len(observed.flinkDuplicateJobIDs) > 0 {
+			err = reconciler.cancelDuplicateJobs()
+			return requeueResult, err
+		}
+
 		if len(jobID) > 0 {
 			if ok, savepointTriggerReason := reconciler.shouldTakeSavepoint(); ok {
 				newSavepointStatus, _ = reconciler.takeSavepointAsync(jobID, savepointTriggerReason)
@@ -399,7 +1508,7 @@ func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 		}
 
 		log.Info("Job has finished, no action")
-		return ctrl.Result{}, nil
+		return scheduleResult, nil
 	}
 
 	// Delete
@@ -411,7 +1520,9 @@ func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 		// If savepoint or cancellation was failed, the control state is fallen to the failed in the updater.
 		log.Info("Cancelling job", "jobID", jobID)
 		if len(jobID) > 0 && len(observed.flinkRunningJobIDs) == 1 {
-			var savepointStatus, err = reconciler.cancelFlinkJobAsync(jobID, true /* takeSavepoint */)
+			var takeSavepoint = observed.cluster.Spec.Job.TakeSavepointOnCancel == nil ||
+				*observed.cluster.Spec.Job.TakeSavepointOnCancel
+			var savepointStatus, err = reconciler.cancelFlinkJobAsync(jobID, takeSavepoint)
 			if !reflect.DeepEqual(savepointStatus, observed.cluster.Status.Savepoint) {
 				newSavepointStatus = savepointStatus
 			}
@@ -433,9 +1544,9 @@ func (reconciler *ClusterReconciler) reconcileJob() (ctrl.Result, error) {
 		if err != nil {
 			newControlStatus = getFailedCancelStatus(err)
 		}
-		return ctrl.Result{}, err
+		return scheduleResult, err
 	}
-	return ctrl.Result{}, nil
+	return scheduleResult, nil
 }
 
 func (reconciler *ClusterReconciler) createJob(job *batchv1.Job) error {
@@ -449,7 +1560,9 @@ func (reconciler *ClusterReconciler) createJob(job *batchv1.Job) error {
 		log.Info("Failed to created job", "error", err)
 	} else {
 		log.Info("Job created")
+		recordJobSubmissionMetrics(job.Namespace, false /* failed */)
 	}
+	reconciler.recordComponentEvent("create", "job submitter", job.Name, err)
 	return err
 }
 
@@ -466,6 +1579,7 @@ func (reconciler *ClusterReconciler) deleteJob(job *batchv1.Job) error {
 	} else {
 		log.Info("Job deleted")
 	}
+	reconciler.recordComponentEvent("delete", "job submitter", job.Name, err)
 	return err
 }
 
@@ -505,6 +1619,117 @@ func (reconciler *ClusterReconciler) restartJob() error {
 	return nil
 }
 
+// Checks whether the upgradable properties of the observed job differ from the
+// desired ones.
+func (reconciler *ClusterReconciler) isJobUpgradeRequested() bool {
+	if isRestAPISubmission(reconciler.observed.cluster.Spec.Job) {
+		return isRestAPIJobUpgradeRequested(reconciler.observed.cluster)
+	}
+	var desiredJob = reconciler.desired.Job
+	var observedJob = reconciler.observed.job
+	return desiredJob != nil && observedJob != nil &&
+		getJobSpecHashAnnotation(desiredJob) != getJobSpecHashAnnotation(observedJob)
+}
+
+// Checks whether there is a running job waiting to be upgraded.
+func (reconciler *ClusterReconciler) isJobUpgradePending() bool {
+	return reconciler.isJobUpgradeRequested() &&
+		len(reconciler.observed.flinkRunningJobIDs) > 0
+}
+
+func getJobSpecHashAnnotation(job *batchv1.Job) string {
+	return job.Annotations[jobSpecHashAnnotation]
+}
+
+// Upgrades the running job. If `savepointsDir` is specified, stops the job
+// with a savepoint, otherwise cancels it only if `takeSavepointOnUpgrade` is
+// false, then deletes the job submitter if any. The JobManager and TaskManager are updated after the job is stopped,
+// then the new job is submitted from the savepoint in the following
+// reconciliations. The upgrade is given up after
+// `MaxJobUpgradeSavepointFailures` failed savepoints, the job keeps running
+// with the previous spec until the job spec changes again.
+func (reconciler *ClusterReconciler) upgradeJob(jobID string) (*v1beta1.SavepointStatus, error) {
+	var log = reconciler.log
+	var observed = reconciler.observed
+	var jobSpec = observed.cluster.Spec.Job
+	var jobStatus = observed.cluster.Status.Components.Job
+	var savepointStatus = observed.cluster.Status.Savepoint
+
+	// The job which didn't run in time is stopped without a savepoint when
+	// its upgrade is rolled back.
+	var rolledBack = jobStatus != nil && jobStatus.Rollback != nil &&
+		jobStatus.Rollback.State == v1beta1.JobRollbackStateInProgress &&
+		jobStatus.Rollback.JobID == jobID
+	if len(jobID) > 0 && reconciler.isFlinkJobRunning(jobID) {
+		if jobSpec.SavepointsDir != nil && len(*jobSpec.SavepointsDir) > 0 &&
+			!rolledBack {
+			if jobStatus != nil && jobStatus.Upgrade != nil &&
+				jobStatus.Upgrade.State == v1beta1.JobUpgradeStateFailed {
+				log.Info(
+					"Job upgrade failed, waiting for the job spec to change",
+					"jobID", jobID,
+					"failedSavepoints", jobStatus.Upgrade.FailedSavepoints)
+				return savepointStatus, nil
+			}
+			if savepointStatus != nil &&
+				savepointStatus.State == v1beta1.SavepointStateInProgress {
+				log.Info("Waiting for the savepoint to complete before upgrading job", "jobID", jobID)
+				return savepointStatus, nil
+			}
+			if isSavepointRetryPending(savepointStatus, time.Now()) {
+				log.Info("Waiting to retry savepoint before upgrading job", "retryTime", savepointStatus.RetryTime)
+				return savepointStatus, nil
+			}
+			if savepointStatus == nil ||
+				savepointStatus.JobID != jobID ||
+				savepointStatus.TriggerReason != v1beta1.SavepointTriggerReasonUpdate ||
+				savepointStatus.State != v1beta1.SavepointStateSucceeded {
+				return reconciler.stopJobWithSavepointAsync(
+					jobID, v1beta1.SavepointTriggerReasonUpdate, false /* drain */)
+			}
+			// The job is still running after the savepoint if it was taken by
+			// the fallback of stop-with-savepoint.
+		} else if rolledBack {
+			log.Info("Skip taking savepoint of the rolled back job", "jobID", jobID)
+		} else if jobSpec.TakeSavepointOnUpgrade == nil ||
+			*jobSpec.TakeSavepointOnUpgrade {
+			// The job state would be lost by cancelling the job, it keeps
+			// running with the previous spec.
+			log.Info(
+				"Cannot upgrade job without savepoint, savepointsDir is unspecified",
+				"jobID", jobID)
+			reconciler.recorder.Event(
+				observed.cluster,
+				corev1.EventTypeWarning,
+				"JobUpgradeBlocked",
+				fmt.Sprintf(
+					"Cannot upgrade job %v without savepoint, savepointsDir is unspecified",
+					jobID))
+			return savepointStatus, nil
+		} else {
+			log.Info("Skip taking savepoint before upgrading job, takeSavepointOnUpgrade is false", "jobID", jobID)
+		}
+
+		var apiBaseURL = getFlinkAPIBaseURL(observed.cluster)
+		log.Info("Stopping job for upgrade", "jobID", jobID)
+		var err = reconciler.flinkClient.StopJob(apiBaseURL, jobID)
+		if err != nil {
+			return savepointStatus, fmt.Errorf("failed to stop job: %v", err)
+		}
+		reconciler.recorder.Event(
+			observed.cluster,
+			corev1.EventTypeNormal,
+			"JobStopped",
+			fmt.Sprintf("Stopped job %v for upgrade", jobID))
+	}
+
+	// There is no job submitter in the "RestAPI" submission mode.
+	if observed.job == nil {
+		return savepointStatus, nil
+	}
+	return savepointStatus, reconciler.deleteJob(observed.job)
+}
+
 // Cancel running jobs.
 func (reconciler *ClusterReconciler) cancelRunningJobs(
 	takeSavepoint bool) error {
@@ -535,7 +1760,12 @@ func (reconciler *ClusterReconciler) cancelFlinkJob(jobID string, takeSavepoint
 
 	var apiBaseURL = getFlinkAPIBaseURL(reconciler.observed.cluster)
 	reconciler.log.Info("Stoping job", "jobID", jobID)
-	return reconciler.flinkClient.StopJob(apiBaseURL, jobID)
+	var err = reconciler.flinkClient.StopJob(apiBaseURL, jobID)
+	if err != nil {
+		return err
+	}
+	reconciler.recordJobCancelledEvent(jobID)
+	return nil
 }
 
 // Trigger savepoint if it is possible, then return the savepoint status to update.
@@ -550,8 +1780,15 @@ func (reconciler *ClusterReconciler) cancelFlinkJobAsync(jobID string, takeSavep
 
 	switch observedSavepoint.State {
 	case v1beta1.SavepointStateNotTriggered:
+		if takeSavepoint && isSavepointRetryPending(observedSavepoint, time.Now()) {
+			log.Info("Waiting to retry savepoint before stopping job", "retryTime", observedSavepoint.RetryTime)
+			return observedSavepoint, nil
+		}
 		if takeSavepoint && reconciler.canTakeSavepoint() {
-			savepointStatus, err = reconciler.takeSavepointAsync(jobID, v1beta1.SavepointTriggerReasonJobCancel)
+			var drain = cluster.Spec.Job.StopWithDrain != nil &&
+				*cluster.Spec.Job.StopWithDrain
+			savepointStatus, err = reconciler.stopJobWithSavepointAsync(
+				jobID, v1beta1.SavepointTriggerReasonJobCancel, drain)
 			if err != nil {
 				log.Info("Failed to trigger savepoint.")
 				return savepointStatus, fmt.Errorf("failed to trigger savepoint: %v", err)
@@ -572,6 +1809,10 @@ func (reconciler *ClusterReconciler) cancelFlinkJobAsync(jobID string, takeSavep
 	case v1beta1.SavepointStateSucceeded:
 		savepointStatus = observedSavepoint
 		log.Info("Successfully savepoint created. Proceed to stop job.")
+		// The job has been stopped by stop-with-savepoint.
+		if !reconciler.isFlinkJobRunning(jobID) {
+			return savepointStatus, nil
+		}
 	// Cannot be reached here with these states, because job-cancel control should be finished with failed savepoint states by updater.
 	case v1beta1.SavepointStateTriggerFailed:
 		fallthrough
@@ -587,6 +1828,7 @@ func (reconciler *ClusterReconciler) cancelFlinkJobAsync(jobID string, takeSavep
 	if err != nil {
 		return savepointStatus, fmt.Errorf("failed to stop job: %v", err)
 	}
+	reconciler.recordJobCancelledEvent(jobID)
 	return savepointStatus, nil
 }
 
@@ -601,6 +1843,11 @@ func (reconciler *ClusterReconciler) shouldTakeSavepoint() (bool, string) {
 		return false, ""
 	}
 
+	if isSavepointRetryPending(savepointStatus, time.Now()) {
+		log.Info("Waiting to retry savepoint", "retryTime", savepointStatus.RetryTime)
+		return false, ""
+	}
+
 	// User requested.
 
 	// In the case of which savepoint status is in finished state,
@@ -680,6 +1927,44 @@ func (reconciler *ClusterReconciler) takeSavepointAsync(jobID string, triggerRea
 	return &newSavepointStatus, err
 }
 
+// Trigger stop-with-savepoint for a job then return savepoint status to update.
+// Falls back to a plain savepoint followed by a cancel, if stop-with-savepoint
+// is not supported by the Flink cluster (Flink < 1.9).
+func (reconciler *ClusterReconciler) stopJobWithSavepointAsync(
+	jobID string,
+	triggerReason string,
+	drain bool) (*v1beta1.SavepointStatus, error) {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+	var apiBaseURL = getFlinkAPIBaseURL(reconciler.observed.cluster)
+
+	log.Info("Trigger stop-with-savepoint.", "jobID", jobID, "drain", drain)
+	var triggerID, err = reconciler.flinkClient.StopJobWithSavepoint(
+		apiBaseURL, jobID, *cluster.Spec.Job.SavepointsDir, drain)
+	// The job cannot be drained by the fallback.
+	if err != nil && drain {
+		log.Info("Failed to trigger stop-with-savepoint with drain.", "jobID", jobID, "error", err)
+		return nil, err
+	}
+	if err != nil {
+		log.Info("Failed to trigger stop-with-savepoint, fall back to savepoint then cancel.", "jobID", jobID, "error", err)
+		return reconciler.takeSavepointAsync(jobID, triggerReason)
+	}
+	log.Info("Stop-with-savepoint is triggered successfully.", "jobID", jobID, "triggerID", triggerID.RequestID)
+	var newSavepointStatus = getNewSavepointStatus(
+		jobID, triggerID.RequestID, triggerReason, "", true)
+	return &newSavepointStatus, nil
+}
+
+func (reconciler *ClusterReconciler) isFlinkJobRunning(jobID string) bool {
+	for _, runningJobID := range reconciler.observed.flinkRunningJobIDs {
+		if runningJobID == jobID {
+			return true
+		}
+	}
+	return false
+}
+
 // Takes savepoint for a job then update job status with the info.
 func (reconciler *ClusterReconciler) takeSavepoint(
 	jobID string) error {
@@ -720,6 +2005,7 @@ func (reconciler *ClusterReconciler) updateSavepointStatus(
 		jobStatus.LastSavepointTriggerID = savepointStatus.TriggerID
 		jobStatus.SavepointLocation = savepointStatus.Location
 		setTimestamp(&jobStatus.LastSavepointTime)
+		appendSavepointHistory(cluster.Spec.Job, jobStatus, savepointStatus.Location)
 		setTimestamp(&cluster.Status.LastUpdateTime)
 	}
 	// case in which savepointing is triggered by control annotation
@@ -744,6 +2030,86 @@ func (reconciler *ClusterReconciler) updateSavepointStatus(
 	return reconciler.k8sClient.Status().Update(reconciler.context, &cluster)
 }
 
+// Deletes the savepoints exceeding `savepointHistoryLimit` from the storage
+// and removes them from the savepoint history. The savepoints which fail to be
+// deleted stay in the history to be retried, and the savepoints in a storage
+// which is not supported are only removed from the history. It returns true
+// when the cluster status is updated.
+func (reconciler *ClusterReconciler) reconcileSavepointHistory() (bool, error) {
+	var log = reconciler.log
+	var cluster = reconciler.observed.cluster
+	var jobSpec = cluster.Spec.Job
+	var jobStatus = cluster.Status.Components.Job
+	var expired = getExpiredSavepoints(jobSpec, jobStatus)
+	if len(expired) == 0 {
+		return false, nil
+	}
+	var retryTime = getSavepointDeletionRetryTime(jobStatus)
+	if retryTime != nil && time.Now().Before(*retryTime) {
+		log.Info("Waiting to retry deleting expired savepoints", "retryTime", retryTime)
+		return false, nil
+	}
+
+	var retained []string
+	var failed = 0
+	for _, location := range expired {
+		// The savepoints which the job is restored from are kept.
+		if location == jobStatus.FromSavepoint ||
+			(jobSpec.FromSavepoint != nil && location == *jobSpec.FromSavepoint) ||
+			(jobStatus.Rollback != nil && location == jobStatus.Rollback.Savepoint) {
+			log.Info("Skip deleting savepoint restored from", "location", location)
+			continue
+		}
+		var storageClient = reconciler.storageClients.Get(location)
+		if storageClient == nil {
+			log.Info("Skip deleting savepoint in unsupported storage", "location", location)
+			continue
+		}
+		log.Info("Deleting expired savepoint", "location", location)
+		var err = storageClient.DeleteDirectory(location)
+		if err != nil {
+			log.Error(err, "Failed to delete expired savepoint", "location", location)
+			reconciler.recorder.Event(
+				cluster,
+				corev1.EventTypeWarning,
+				"FailedDeleteSavepoint",
+				fmt.Sprintf("Failed to delete savepoint %v: %v", location, err))
+			retained = append(retained, location)
+			failed++
+			continue
+		}
+		reconciler.recorder.Event(
+			cluster,
+			corev1.EventTypeNormal,
+			"SavepointDeleted",
+			fmt.Sprintf("Deleted expired savepoint: %v", location))
+	}
+	// Nothing changes when all the savepoints are skipped.
+	if len(retained) == len(expired) && failed == 0 &&
+		jobStatus.SavepointDeletionFailures == 0 {
+		return false, nil
+	}
+
+	var updatedCluster = cluster.DeepCopy()
+	var updatedJobStatus = updatedCluster.Status.Components.Job
+	updatedJobStatus.SavepointHistory = append(
+		retained, jobStatus.SavepointHistory[len(expired):]...)
+	// The failed deletions are retried with a backoff.
+	if failed > 0 {
+		updatedJobStatus.SavepointDeletionFailures++
+		setTimestamp(&updatedJobStatus.LastSavepointDeletionFailureTime)
+	} else {
+		updatedJobStatus.SavepointDeletionFailures = 0
+		updatedJobStatus.LastSavepointDeletionFailureTime = ""
+	}
+	setTimestamp(&updatedCluster.Status.LastUpdateTime)
+	var err = reconciler.k8sClient.Status().Update(reconciler.context, updatedCluster)
+	if err != nil {
+		log.Error(err, "Failed to update savepoint history")
+	}
+	return true, err
+}
+
 // If job cancellation is failed, fill the status message with error message.
 // Then, the state will be transited to the failed by the updater.
 func getFailedCancelStatus(cancelErr error) *v1beta1.FlinkClusterControlStatus {
@@ -773,10 +2139,22 @@ func (reconciler *ClusterReconciler) updateStatus(ss **v1beta1.SavepointStatus,
 		return
 	}
 
+	// The retries of the failed savepoint are carried over when it is
+	// triggered again.
+	var observedSavepoint = reconciler.observed.cluster.Status.Savepoint
+	if savepointStatus != nil && observedSavepoint != nil &&
+		observedSavepoint.State == v1beta1.SavepointStateNotTriggered &&
+		len(observedSavepoint.RetryTime) > 0 &&
+		savepointStatus.State != v1beta1.SavepointStateNotTriggered {
+		savepointStatus.Retries = observedSavepoint.Retries
+	}
+
 	// Record events
 	if savepointStatus != nil {
 		eventType, eventReason, eventMessage := getSavepointEvent(*savepointStatus)
 		reconciler.recorder.Event(reconciler.observed.cluster, eventType, eventReason, eventMessage)
+		recordSavepointMetrics(
+			reconciler.observed.cluster.Namespace, savepointStatus.State)
 	}
 	if controlStatus != nil {
 		eventType, eventReason, eventMessage := getControlEvent(*controlStatus)
@@ -812,3 +2190,49 @@ func (reconciler *ClusterReconciler) updateStatus(ss **v1beta1.SavepointStatus,
 			statusUpdateErr, "Failed to update status.", "error", statusUpdateErr)
 	}
 }
+
+func (reconciler *ClusterReconciler) recordJobCancelledEvent(jobID string) {
+	reconciler.recorder.Event(
+		reconciler.observed.cluster,
+		corev1.EventTypeNormal,
+		"JobCancelled",
+		fmt.Sprintf("Cancelled job %v", jobID))
+}
+
+// Records an event for creating, updating or deleting a component of the
+// cluster, e.g., "Created JobManager deployment: my-cluster-jobmanager".
+func (reconciler *ClusterReconciler) recordComponentEvent(
+	action string, component string, name string, err error) {
+	if err != nil {
+		reconciler.recorder.Event(
+			reconciler.observed.cluster,
+			corev1.EventTypeWarning,
+			"Failed"+strings.Title(action),
+			fmt.Sprintf("Failed to %v %v %v: %v", action, component, name, err))
+		return
+	}
+	reconciler.recorder.Event(
+		reconciler.observed.cluster,
+		corev1.EventTypeNormal,
+		"Successful"+strings.Title(action),
+		fmt.Sprintf("%vd %v: %v", strings.Title(action), component, name))
+}
+
+// Records an event for reverting the out-of-band changes of a component, which
+// may surprise the user who made them.
+func (reconciler *ClusterReconciler) recordDriftEvent(
+	component string, name string) {
+	reconciler.recorder.Event(
+		reconciler.observed.cluster,
+		corev1.EventTypeWarning,
+		"DriftReverted",
+		fmt.Sprintf("Reverted out-of-band changes of %v: %v", component, name))
+}
+
+func getObjectName(object runtime.Object) string {
+	var accessor, err = meta.Accessor(object)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetName()
+}
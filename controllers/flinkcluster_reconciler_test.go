@@ -0,0 +1,78 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestUpgradeJobWithoutSavepointsDir(t *testing.T) {
+	var jobID = "ec1c2cba2bbb5f7ae0e3f2ba7a4ce2b2"
+	var savepointStatus = &v1beta1.SavepointStatus{
+		JobID: jobID,
+		State: v1beta1.SavepointStateSucceeded,
+	}
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Job: &v1beta1.JobSpec{JarFile: "gs://my-bucket/myjob-v2.jar"},
+		},
+		Status: v1beta1.FlinkClusterStatus{
+			Components: v1beta1.FlinkClusterComponentsStatus{
+				Job: &v1beta1.JobStatus{
+					ID:    jobID,
+					State: v1beta1.JobStateRunning,
+				},
+			},
+			Savepoint: savepointStatus,
+		},
+	}
+	var recorder = record.NewFakeRecorder(1)
+
+	// Neither the Flink nor the Kubernetes client is expected to be called,
+	// the job must not be cancelled nor its submitter deleted.
+	var reconciler = &ClusterReconciler{
+		context:  context.Background(),
+		log:      log.Log,
+		recorder: recorder,
+		observed: ObservedClusterState{
+			cluster:            cluster,
+			job:                &batchv1.Job{},
+			flinkRunningJobIDs: []string{jobID},
+		},
+	}
+	var newSavepointStatus, err = reconciler.upgradeJob(jobID)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, newSavepointStatus, savepointStatus)
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Equal(
+		t,
+		<-recorder.Events,
+		"Warning JobUpgradeBlocked Cannot upgrade job "+jobID+
+			" without savepoint, savepointsDir is unspecified")
+}
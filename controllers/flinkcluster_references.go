@@ -0,0 +1,296 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Gets the key of a ConfigMap or Secret in the hashes of the referenced data,
+// e.g., `Secret/s3-credentials`.
+func getReferenceKey(kind string, name string) string {
+	return kind + "/" + name
+}
+
+// Gets the keys of the ConfigMaps and Secrets which the pods of the cluster
+// are restarted on.
+func getRestartOnChangeKeys(cluster *v1beta1.FlinkCluster) []string {
+	var restartOnChange = cluster.Spec.RestartOnChange
+	if restartOnChange == nil {
+		return nil
+	}
+	var keys []string
+	for _, name := range restartOnChange.ConfigMaps {
+		keys = append(keys, getReferenceKey("ConfigMap", name))
+	}
+	for _, name := range restartOnChange.Secrets {
+		keys = append(keys, getReferenceKey("Secret", name))
+	}
+	return keys
+}
+
+// Gets the hash of the data of a ConfigMap or Secret. The length of each value
+// is hashed with it, so that the boundaries of the keys and values are not
+// ambiguous.
+func getDataHash(data map[string][]byte) string {
+	var keys []string
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var hash = sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s\n%d\n", key, len(data[key]))
+		hash.Write(data[key])
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// Gets the hash of the data of a ConfigMap, including its binary data.
+func getConfigMapDataHash(configMap *corev1.ConfigMap) string {
+	var data = map[string][]byte{}
+	for key, value := range configMap.Data {
+		data[key] = []byte(value)
+	}
+	for key, value := range configMap.BinaryData {
+		data[key] = value
+	}
+	return getDataHash(data)
+}
+
+// Gets the keys of the ConfigMaps and Secrets referenced by the volumes and
+// the environment variables of the containers of a pod.
+func getPodSpecReferences(podSpec *corev1.PodSpec) map[string]struct{} {
+	var references = map[string]struct{}{}
+	var addConfigMap = func(name string) {
+		references[getReferenceKey("ConfigMap", name)] = struct{}{}
+	}
+	var addSecret = func(name string) {
+		references[getReferenceKey("Secret", name)] = struct{}{}
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.ConfigMap != nil {
+			addConfigMap(volume.ConfigMap.Name)
+		}
+		if volume.Secret != nil {
+			addSecret(volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					addConfigMap(source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					addSecret(source.Secret.Name)
+				}
+			}
+		}
+	}
+	var containers = append(
+		append([]corev1.Container{}, podSpec.InitContainers...),
+		podSpec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				addConfigMap(env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				addSecret(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				addConfigMap(envFrom.ConfigMapRef.Name)
+			}
+			if envFrom.SecretRef != nil {
+				addSecret(envFrom.SecretRef.Name)
+			}
+		}
+	}
+	return references
+}
+
+// Sets the annotation of the hash of the data of the observed ConfigMaps and
+// Secrets which the pod template references, keyed by `getReferenceKey`. The
+// ones which are not found have an empty hash, so that the pods are also
+// restarted when they are created. No annotation is set if the pod template
+// references none of them.
+func setReferencesHashAnnotation(
+	podTemplate *corev1.PodTemplateSpec, dataHashes map[string]string) {
+	var references = getPodSpecReferences(&podTemplate.Spec)
+	var keys []string
+	for key := range dataHashes {
+		if _, ok := references[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+	var hash = sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%s\n", key, dataHashes[key])
+	}
+	if podTemplate.Annotations == nil {
+		podTemplate.Annotations = map[string]string{}
+	}
+	podTemplate.Annotations[referencesHashAnnotation] =
+		fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// Sets the hash of the referenced data on the pod templates of the desired
+// JobManager, TaskManager and History Server workloads.
+func setDesiredReferencesHashes(
+	desired *DesiredClusterState, dataHashes map[string]string) {
+	if len(dataHashes) == 0 {
+		return
+	}
+	if desired.JmDeployment != nil {
+		setReferencesHashAnnotation(&desired.JmDeployment.Spec.Template, dataHashes)
+	}
+	if desired.JmStatefulSet != nil {
+		setReferencesHashAnnotation(&desired.JmStatefulSet.Spec.Template, dataHashes)
+	}
+	if desired.TmDeployment != nil {
+		setReferencesHashAnnotation(&desired.TmDeployment.Spec.Template, dataHashes)
+	}
+	if desired.TmStatefulSet != nil {
+		setReferencesHashAnnotation(&desired.TmStatefulSet.Spec.Template, dataHashes)
+	}
+	if desired.HistoryServerDeployment != nil {
+		setReferencesHashAnnotation(
+			&desired.HistoryServerDeployment.Spec.Template, dataHashes)
+	}
+}
+
+// Filters the events of the ConfigMaps and Secrets to the ones in the watched
+// namespaces which some FlinkClusters restart on, the updates which don't
+// change their data are dropped.
+func (reconciler *FlinkClusterReconciler) getReferencedDataFilter() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return reconciler.isReferencedData(e.Object, e.Meta)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isDataChanged(e.ObjectOld, e.ObjectNew) &&
+				reconciler.isReferencedData(e.ObjectNew, e.MetaNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return reconciler.isReferencedData(e.Object, e.Meta)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return reconciler.isReferencedData(e.Object, e.Meta)
+		},
+	}
+}
+
+// Checks whether the data of a ConfigMap or Secret changed.
+func isDataChanged(oldObject runtime.Object, newObject runtime.Object) bool {
+	switch oldObject := oldObject.(type) {
+	case *corev1.ConfigMap:
+		var newConfigMap, ok = newObject.(*corev1.ConfigMap)
+		return !ok || getConfigMapDataHash(oldObject) != getConfigMapDataHash(newConfigMap)
+	case *corev1.Secret:
+		var newSecret, ok = newObject.(*corev1.Secret)
+		return !ok || getDataHash(oldObject.Data) != getDataHash(newSecret.Data)
+	}
+	return true
+}
+
+// Checks whether a ConfigMap or Secret is in the watched namespaces and some
+// FlinkClusters restart on its changes.
+func (reconciler *FlinkClusterReconciler) isReferencedData(
+	object runtime.Object, meta metav1.Object) bool {
+	return len(reconciler.getRestartOnChangeRequests(
+		handler.MapObject{Object: object, Meta: meta})) > 0
+}
+
+// Checks whether the FlinkClusters of a namespace are watched.
+func (reconciler *FlinkClusterReconciler) isWatchedNamespace(
+	namespace string) bool {
+	if len(reconciler.WatchNamespaces) == 0 {
+		return true
+	}
+	for _, watchNamespace := range reconciler.WatchNamespaces {
+		if watchNamespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the reconcile requests of the FlinkClusters in the namespace of a
+// ConfigMap or Secret which restart their pods on its changes.
+func (reconciler *FlinkClusterReconciler) getRestartOnChangeRequests(
+	object handler.MapObject) []ctrl.Request {
+	var kind string
+	switch object.Object.(type) {
+	case *corev1.ConfigMap:
+		kind = "ConfigMap"
+	case *corev1.Secret:
+		kind = "Secret"
+	default:
+		return nil
+	}
+	var namespace = object.Meta.GetNamespace()
+	if !reconciler.isWatchedNamespace(namespace) {
+		return nil
+	}
+	var key = getReferenceKey(kind, object.Meta.GetName())
+	var clusters = new(v1beta1.FlinkClusterList)
+	var err = reconciler.Client.List(
+		context.Background(), clusters, client.InNamespace(namespace))
+	if err != nil {
+		reconciler.Log.Error(
+			err, "Failed to list FlinkClusters", "namespace", namespace)
+		return nil
+	}
+	var requests []ctrl.Request
+	for i := range clusters.Items {
+		var cluster = &clusters.Items[i]
+		for _, restartKey := range getRestartOnChangeKeys(cluster) {
+			if restartKey == key {
+				requests = append(requests, ctrl.Request{
+					NamespacedName: types.NamespacedName{
+						Namespace: namespace,
+						Name:      cluster.Name,
+					},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
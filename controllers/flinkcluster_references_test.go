@@ -0,0 +1,279 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestGetPodSpecReferences(t *testing.T) {
+	var podSpec = corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{
+				Name: "flink-config-volume",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: "mycluster-configmap",
+						},
+					},
+				},
+			},
+			{
+				Name: "tls",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: "tls-keystore"},
+				},
+			},
+			{
+				Name: "projected",
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources: []corev1.VolumeProjection{
+							{
+								ConfigMap: &corev1.ConfigMapProjection{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: "log-config",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		InitContainers: []corev1.Container{
+			{
+				Name: "init",
+				EnvFrom: []corev1.EnvFromSource{
+					{
+						SecretRef: &corev1.SecretEnvSource{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "init-env",
+							},
+						},
+					},
+				},
+			},
+		},
+		Containers: []corev1.Container{
+			{
+				Name: "jobmanager",
+				Env: []corev1.EnvVar{
+					{Name: "FOO", Value: "bar"},
+					{
+						Name: "AWS_SECRET_ACCESS_KEY",
+						ValueFrom: &corev1.EnvVarSource{
+							SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: "s3-credentials",
+								},
+								Key: "secret-key",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.DeepEqual(t,
+		getPodSpecReferences(&podSpec),
+		map[string]struct{}{
+			"ConfigMap/mycluster-configmap": {},
+			"ConfigMap/log-config":          {},
+			"Secret/tls-keystore":           {},
+			"Secret/init-env":               {},
+			"Secret/s3-credentials":         {},
+		})
+}
+
+func TestGetDataHash(t *testing.T) {
+	var hash = getDataHash(map[string][]byte{"a": []byte("b"), "c": []byte("d")})
+	assert.Equal(t,
+		getDataHash(map[string][]byte{"c": []byte("d"), "a": []byte("b")}), hash)
+	assert.Assert(t,
+		getDataHash(map[string][]byte{"a": []byte("b"), "c": []byte("e")}) != hash)
+	// The boundaries of the keys and values are not ambiguous.
+	assert.Assert(t,
+		getDataHash(map[string][]byte{"a": []byte("bc"), "": []byte("d")}) !=
+			getDataHash(map[string][]byte{"a": []byte("b"), "c": []byte("d")}))
+	assert.Equal(t,
+		getConfigMapDataHash(&corev1.ConfigMap{
+			Data:       map[string]string{"a": "b"},
+			BinaryData: map[string][]byte{"c": []byte("d")},
+		}),
+		hash)
+}
+
+func TestSetReferencesHashAnnotation(t *testing.T) {
+	var newTemplate = func() *corev1.PodTemplateSpec {
+		return &corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "taskmanager",
+						Image: "flink:1.8.1",
+						EnvFrom: []corev1.EnvFromSource{
+							{
+								SecretRef: &corev1.SecretEnvSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: "s3-credentials",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// The pod template doesn't reference the ConfigMap.
+	var template = newTemplate()
+	setReferencesHashAnnotation(
+		template, map[string]string{"ConfigMap/log-config": "1234"})
+	assert.Assert(t, template.Annotations == nil)
+
+	var observedTemplate = newTemplate()
+	setReferencesHashAnnotation(
+		observedTemplate, map[string]string{
+			"ConfigMap/log-config":  "1234",
+			"Secret/s3-credentials": "5678",
+		})
+	assert.Assert(t, getReferencesHash(observedTemplate) != "")
+
+	// The hash of the ConfigMap which is not referenced changed.
+	var desiredTemplate = newTemplate()
+	setReferencesHashAnnotation(
+		desiredTemplate, map[string]string{
+			"ConfigMap/log-config":  "abcd",
+			"Secret/s3-credentials": "5678",
+		})
+	assert.Assert(t, !isPodTemplateUpdateRequired(desiredTemplate, observedTemplate))
+
+	// The hash of the referenced Secret changed.
+	desiredTemplate = newTemplate()
+	setReferencesHashAnnotation(
+		desiredTemplate, map[string]string{
+			"ConfigMap/log-config":  "1234",
+			"Secret/s3-credentials": "",
+		})
+	assert.Assert(t, isPodTemplateUpdateRequired(desiredTemplate, observedTemplate))
+}
+
+// A Kubernetes client listing the given FlinkClusters of a namespace.
+type fakeClusterListK8sClient struct {
+	client.Client
+	clusters []v1beta1.FlinkCluster
+}
+
+func (c *fakeClusterListK8sClient) List(
+	ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	var listOptions client.ListOptions
+	for _, opt := range opts {
+		opt.ApplyToList(&listOptions)
+	}
+	var clusters = list.(*v1beta1.FlinkClusterList)
+	for _, cluster := range c.clusters {
+		if cluster.Namespace == listOptions.Namespace {
+			clusters.Items = append(clusters.Items, cluster)
+		}
+	}
+	return nil
+}
+
+func TestReferencedDataFilter(t *testing.T) {
+	var newCluster = func(namespace string) v1beta1.FlinkCluster {
+		return v1beta1.FlinkCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: namespace},
+			Spec: v1beta1.FlinkClusterSpec{
+				RestartOnChange: &v1beta1.RestartOnChangeSpec{
+					Secrets: []string{"s3-credentials"},
+				},
+			},
+		}
+	}
+	var reconciler = &FlinkClusterReconciler{
+		Client: &fakeClusterListK8sClient{
+			clusters: []v1beta1.FlinkCluster{
+				newCluster("default"), newCluster("unwatched")},
+		},
+		Log:             log.Log,
+		WatchNamespaces: []string{"default", "other"},
+	}
+	var filter = reconciler.getReferencedDataFilter()
+	var newSecret = func(namespace string, name string, data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"key": []byte(data)},
+		}
+	}
+
+	// The Secret is referenced by a cluster in a watched namespace.
+	var secret = newSecret("default", "s3-credentials", "v1")
+	assert.Assert(t, filter.Create(event.CreateEvent{Meta: secret, Object: secret}))
+	assert.Assert(t, filter.Delete(event.DeleteEvent{Meta: secret, Object: secret}))
+	var rotatedSecret = newSecret("default", "s3-credentials", "v2")
+	assert.Assert(t, filter.Update(event.UpdateEvent{
+		MetaOld:   secret,
+		ObjectOld: secret,
+		MetaNew:   rotatedSecret,
+		ObjectNew: rotatedSecret,
+	}))
+	var requests = reconciler.getRestartOnChangeRequests(
+		handler.MapObject{Meta: secret, Object: secret})
+	assert.Equal(t, len(requests), 1)
+	assert.Equal(t, requests[0].Namespace, "default")
+	assert.Equal(t, requests[0].Name, "mycluster")
+
+	// The updates which don't change the data are dropped.
+	var labeledSecret = secret.DeepCopy()
+	labeledSecret.Labels = map[string]string{"foo": "bar"}
+	assert.Assert(t, !filter.Update(event.UpdateEvent{
+		MetaOld:   secret,
+		ObjectOld: secret,
+		MetaNew:   labeledSecret,
+		ObjectNew: labeledSecret,
+	}))
+
+	// Not referenced by any cluster.
+	var otherSecret = newSecret("default", "other", "v1")
+	assert.Assert(t, !filter.Create(event.CreateEvent{Meta: otherSecret, Object: otherSecret}))
+	var otherNamespaceSecret = newSecret("other", "s3-credentials", "v1")
+	assert.Assert(t, !filter.Create(event.CreateEvent{
+		Meta: otherNamespaceSecret, Object: otherNamespaceSecret}))
+	var configMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: "default"},
+	}
+	assert.Assert(t, !filter.Create(event.CreateEvent{Meta: configMap, Object: configMap}))
+
+	// Referenced by a cluster outside the watched namespaces.
+	var unwatchedSecret = newSecret("unwatched", "s3-credentials", "v1")
+	assert.Assert(t, !filter.Create(event.CreateEvent{
+		Meta: unwatchedSecret, Object: unwatchedSecret}))
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"hash/fnv"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterShard is the shard of the FlinkClusters which an operator instance
+// reconciles when the clusters are split across multiple operator instances.
+// The FlinkSessionJobs and FlinkSavepoints are in the shard of their clusters.
+type ClusterShard struct {
+	// The number of shards, the clusters are not sharded when it is less
+	// than 2.
+	Count int
+
+	// The index of the shard of the operator instance, from 0 to Count - 1.
+	Index int
+
+	// (Optional) The label of the clusters whose value is hashed instead of
+	// the namespace/name, so the clusters with the same label value are in the
+	// same shard.
+	Label string
+}
+
+func (shard ClusterShard) isEnabled() bool {
+	return shard.Count > 1
+}
+
+// Checks whether the cluster is in the shard.
+func (shard ClusterShard) contains(cluster *v1beta1.FlinkCluster) bool {
+	if !shard.isEnabled() {
+		return true
+	}
+	var key = cluster.Namespace + "/" + cluster.Name
+	if len(shard.Label) > 0 {
+		if value, ok := cluster.Labels[shard.Label]; ok {
+			key = value
+		}
+	}
+	return getShardIndex(key, shard.Count) == shard.Index
+}
+
+// Checks whether the cluster with the name is in the shard. A cluster which
+// doesn't exist is in the shard of its namespace/name.
+func (shard ClusterShard) containsClusterNamed(
+	ctx context.Context,
+	k8sClient client.Client,
+	name types.NamespacedName) (bool, error) {
+	if !shard.isEnabled() {
+		return true, nil
+	}
+	var cluster = &v1beta1.FlinkCluster{}
+	var err = k8sClient.Get(ctx, name, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+		cluster.Namespace = name.Namespace
+		cluster.Name = name.Name
+	}
+	return shard.contains(cluster), nil
+}
+
+// Gets the shard of the key with the jump consistent hash, so only about
+// 1/count of the keys move to other shards when a shard is added.
+func getShardIndex(key string, count int) int {
+	var hash = fnv.New64a()
+	hash.Write([]byte(key))
+	var k = hash.Sum64()
+	var b, j int64 = -1, 0
+	for j < int64(count) {
+		b = j
+		k = k*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((k>>33)+1)))
+	}
+	return int(b)
+}
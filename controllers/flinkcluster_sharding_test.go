@@ -0,0 +1,81 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetShardIndex(t *testing.T) {
+	var counts = make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		var key = fmt.Sprintf("default/cluster-%v", i)
+		var index = getShardIndex(key, 4)
+		assert.Assert(t, index >= 0 && index < 4)
+		assert.Equal(t, getShardIndex(key, 4), index)
+		counts[index]++
+
+		// The keys only move to the added shard.
+		var newIndex = getShardIndex(key, 5)
+		assert.Assert(t, newIndex == index || newIndex == 4)
+	}
+	for _, count := range counts {
+		assert.Assert(t, count > 150)
+	}
+	assert.Equal(t, getShardIndex("default/cluster-0", 1), 0)
+}
+
+func TestClusterShardContains(t *testing.T) {
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "mycluster",
+			Labels:    map[string]string{"team": "team-a"},
+		},
+	}
+
+	// All the clusters are in the shard without sharding.
+	assert.Assert(t, ClusterShard{}.contains(cluster))
+	assert.Assert(t, ClusterShard{Count: 1}.contains(cluster))
+
+	// The cluster is in exactly one shard.
+	var shards = 0
+	for i := 0; i < 3; i++ {
+		if (ClusterShard{Count: 3, Index: i}).contains(cluster) {
+			shards++
+			assert.Equal(t, getShardIndex("default/mycluster", 3), i)
+		}
+	}
+	assert.Equal(t, shards, 1)
+
+	// The label value is hashed when the cluster has the label.
+	var index = getShardIndex("team-a", 3)
+	var shard = ClusterShard{Count: 3, Index: index, Label: "team"}
+	assert.Assert(t, shard.contains(cluster))
+	cluster.Name = "othercluster"
+	assert.Assert(t, shard.contains(cluster))
+	cluster.Labels = nil
+	assert.Equal(
+		t,
+		shard.contains(cluster),
+		getShardIndex("default/othercluster", 3) == index)
+}
@@ -16,6 +16,10 @@ limitations under the License.
 
 package controllers
 
+// The exit code of the job submitter when it fails to submit the job, the
+// submission error is written to its termination message.
+const jobSubmissionFailureExitCode = 4
+
 // This script is part of the cluster's ConfigMap and is mounted into the
 // job (submitter) container at `/opt/flink-operator/submit-job.sh` for job
 // submission.
@@ -33,6 +37,101 @@ set -euo pipefail
 
 JOB_MANAGER="$2"
 
+# Stops the sidecars of the pod when the script exits by sending a POST request
+# to each URL in FLINK_SIDECAR_SHUTDOWN_URLS, otherwise the pod never
+# completes. The requests are sent with bash, as the Flink image may not have
+# curl.
+function shutdown_sidecars() {
+	local url address host port path
+	for url in ${FLINK_SIDECAR_SHUTDOWN_URLS:-}; do
+		echo "Stopping sidecar ${url}..."
+		address="${url#http://}"
+		path="/${address#*/}"
+		if [[ "${address}" != */* ]]; then
+			path="/"
+		fi
+		address="${address%%/*}"
+		host="${address%:*}"
+		port="${address##*:}"
+		if [[ "${address}" != *:* ]]; then
+			port=80
+		fi
+		if ! (
+			exec 3<>"/dev/tcp/${host}/${port}" &&
+				printf 'POST %s HTTP/1.1\r\nHost: %s\r\nContent-Length: 0\r\nConnection: close\r\n\r\n' "${path}" "${address}" >&3 &&
+				cat <&3 >/dev/null
+		) 2>/dev/null; then
+			echo "Failed to stop sidecar ${url}." >&2
+		fi
+	done
+}
+
+trap shutdown_sidecars EXIT
+
+# Waits for the files in FLINK_JOB_WAIT_FOR_FILES, one per line, e.g., secrets
+# injected by a sidecar, for up to 5 minutes.
+function wait_for_files() {
+	local file
+	local deadline=$((SECONDS + 300))
+	while IFS= read -r file; do
+		if [[ -z "${file}" ]]; then
+			continue
+		fi
+		echo "Waiting for file ${file}..."
+		while [[ ! -e "${file}" ]]; do
+			if ((SECONDS >= deadline)); then
+				echo "Timed out waiting for file ${file}" | tee "${SUBMISSION_OUTPUT}" >&2
+				return 1
+			fi
+			sleep 2
+		done
+	done <<<"${FLINK_JOB_WAIT_FOR_FILES:-}"
+}
+
+# Copies the Flink config of the image to a writable directory, once, so that
+# properties can be added to it.
+function copy_flink_conf() {
+	local conf_dir=/tmp/flink-conf
+	if [[ "${FLINK_CONF_DIR:-}" == "${conf_dir}" ]]; then
+		return 0
+	fi
+	mkdir -p "${conf_dir}"
+	cp -L "${FLINK_CONF_DIR:-/opt/flink/conf}"/* "${conf_dir}/"
+	export FLINK_CONF_DIR="${conf_dir}"
+}
+
+# Enables the TLS of the REST API in a copy of the Flink config when
+# FLINK_SSL_REST_TRUSTSTORE is set, so that the truststore password in
+# FLINK_SSL_REST_PASSWORD is not written to a ConfigMap.
+function configure_rest_ssl() {
+	if [[ -z "${FLINK_SSL_REST_TRUSTSTORE:-}" ]]; then
+		return 0
+	fi
+	copy_flink_conf
+	{
+		echo "security.ssl.rest.enabled: true"
+		echo "security.ssl.rest.truststore: ${FLINK_SSL_REST_TRUSTSTORE}"
+		echo "security.ssl.rest.truststore-password: ${FLINK_SSL_REST_PASSWORD:-}"
+	} >>"${FLINK_CONF_DIR}/flink-conf.yaml"
+}
+
+# Enables the Kerberos login with the keytab in FLINK_KERBEROS_KEYTAB in a copy
+# of the Flink config, e.g., for the Hive catalogs of the job.
+function configure_kerberos() {
+	if [[ -z "${FLINK_KERBEROS_KEYTAB:-}" ]]; then
+		return 0
+	fi
+	copy_flink_conf
+	{
+		echo "security.kerberos.login.use-ticket-cache: false"
+		echo "security.kerberos.login.keytab: ${FLINK_KERBEROS_KEYTAB}"
+		echo "security.kerberos.login.principal: ${FLINK_KERBEROS_PRINCIPAL:-}"
+		if [[ -n "${FLINK_KERBEROS_CONTEXTS:-}" ]]; then
+			echo "security.kerberos.login.contexts: ${FLINK_KERBEROS_CONTEXTS}"
+		fi
+	} >>"${FLINK_CONF_DIR}/flink-conf.yaml"
+}
+
 function list_jobs() {
 	for i in {1..10}; do
 		if /opt/flink/bin/flink list -a --jobmanager "${JOB_MANAGER}" 2>&1; then
@@ -46,7 +145,34 @@ function list_jobs() {
 	return 1
 }
 
+# The ID of the submitted job, only tracked when the job ID is fixed in
+# FLINK_JOB_ID or for the runs of a scheduled job, because the jobs of the
+# previous runs are also listed.
+SUBMITTED_JOB_ID=""
+
+function list_current_jobs() {
+	if [[ -n "${SUBMITTED_JOB_ID}" ]]; then
+		list_jobs | grep "${SUBMITTED_JOB_ID}" || true
+	else
+		list_jobs
+	fi
+}
+
 function check_existing_jobs() {
+	# The job with the fixed ID may have been submitted by a previous run of
+	# the submitter, other jobs are not considered.
+	if [[ -n "${FLINK_JOB_ID:-}" ]]; then
+		SUBMITTED_JOB_ID="${FLINK_JOB_ID}"
+		echo "Checking existing job ${FLINK_JOB_ID}..."
+		if list_jobs | grep "${FLINK_JOB_ID}"; then
+			echo "Found the existing job, skip resubmitting..."
+			return 0
+		fi
+		return 1
+	fi
+	if [[ "${FLINK_JOB_SCHEDULED:-}" == "true" ]]; then
+		return 1
+	fi
 	echo "Checking existing jobs..."
 	list_jobs
 	if list_jobs | grep -e "(SCHEDULED)" -e "(RUNNING)" -e "(FINISHED)" -e "(FAILED)"; then
@@ -56,32 +182,75 @@ function check_existing_jobs() {
 	return 1
 }
 
+# Submits the SQL statements in FLINK_JOB_SQL with the SQL client, the
+# options of "flink run" are translated to SET statements of the session.
+function submit_sql_job() {
+	local init_file=/tmp/flink-job-init.sql
+	local sql_file=/tmp/flink-job.sql
+	echo "SET 'execution.target' = 'remote';" >"${init_file}"
+	echo "SET 'rest.address' = '${JOB_MANAGER%:*}';" >>"${init_file}"
+	echo "SET 'rest.port' = '${JOB_MANAGER##*:}';" >>"${init_file}"
+	while [[ $# -gt 0 ]]; do
+		case "$1" in
+		--fromSavepoint)
+			echo "SET 'execution.savepoint.path' = '$2';" >>"${init_file}"
+			shift
+			;;
+		--allowNonRestoredState)
+			echo "SET 'execution.savepoint.ignore-unclaimed-state' = 'true';" >>"${init_file}"
+			;;
+		--parallelism)
+			echo "SET 'parallelism.default' = '$2';" >>"${init_file}"
+			shift
+			;;
+		esac
+		shift
+	done
+	echo "${FLINK_JOB_SQL}" >"${sql_file}"
+
+	echo -e "\nSubmitting SQL job..."
+	cat "${init_file}" "${sql_file}"
+	/opt/flink/bin/sql-client.sh -i "${init_file}" -f "${sql_file}"
+}
+
+SUBMISSION_OUTPUT=/tmp/flink-job-submission.log
+
 function submit_job() {
-	echo -e "\nSubmitting job..."
-	echo "/opt/flink/bin/flink run $@"
-	/opt/flink/bin/flink run "$@"
+	local output="${SUBMISSION_OUTPUT}"
+	if [[ -n "${FLINK_JOB_SQL:-}" ]]; then
+		submit_sql_job "$@" 2>&1 | tee "${output}" || return 1
+	else
+		echo -e "\nSubmitting job..."
+		echo "/opt/flink/bin/flink run $@"
+		/opt/flink/bin/flink run "$@" 2>&1 | tee "${output}" || return 1
+	fi
+
+	if [[ "${FLINK_JOB_SCHEDULED:-}" == "true" ]]; then
+		SUBMITTED_JOB_ID="$(grep -oE '[0-9a-f]{32}' "${output}" | tail -n 1 || true)"
+		echo "Submitted job ID: ${SUBMITTED_JOB_ID}"
+	fi
 }
 
 function wait_for_job() {
 	while true; do
 		echo -e "\nWaiting for job to finish..."
-		list_jobs
+		list_current_jobs
 
 		# Find active job first.
 		# If the current job is restarted by the operator, there will be records of past stopped jobs.
 		# TODO: It needs to be improved to determine the job state with the submitted job id.
-		if list_jobs | grep -e "(SCHEDULED)" -e "(RUNNING)"; then
+		if list_current_jobs | grep -e "(SCHEDULED)" -e "(RUNNING)"; then
 			echo -e "\nFound an active job."
 		else
-			if list_jobs | grep "(FINISHED)"; then
+			if list_current_jobs | grep "(FINISHED)"; then
 				echo -e "\nJob has completed successfully, exiting 0"
 				return 0
 			fi
-			if list_jobs | grep "(FAILED)"; then
+			if list_current_jobs | grep "(FAILED)"; then
 				echo -e "\nJob failed, exiting 1"
 				return 1
 			fi
-			if list_jobs | grep "(CANCELED)"; then
+			if list_current_jobs | grep "(CANCELED)"; then
 				echo -e "\nJob has been cancelled, exiting 2"
 				return 2
 			fi
@@ -92,9 +261,25 @@ function wait_for_job() {
 	done
 }
 
+# Exits with 4 when the job cannot be submitted, the end of the submission
+# output is written to the termination message of the container, so the
+# operator can retry the submission.
 function main() {
+	configure_rest_ssl
+	configure_kerberos
+
+	if ! wait_for_files; then
+		echo -e "\nFailed to submit job, exiting 4"
+		tail -c 2048 "${SUBMISSION_OUTPUT}" >/dev/termination-log || true
+		exit 4
+	fi
+
 	if ! check_existing_jobs "$@"; then
-		submit_job "$@"
+		if ! submit_job "$@"; then
+			echo -e "\nFailed to submit job, exiting 4"
+			tail -c 2048 "${SUBMISSION_OUTPUT}" >/dev/termination-log || true
+			exit 4
+		fi
 	fi
 
 	wait_for_job "$@"
@@ -0,0 +1,200 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// Gets the timeout of a lifecycle phase in the spec, nil if it is not
+// specified.
+func getPhaseTimeoutSeconds(
+	timeouts *v1beta1.TimeoutsSpec, phase string) *int32 {
+	if timeouts == nil {
+		return nil
+	}
+	switch phase {
+	case v1beta1.TimeoutPhaseClusterStartup:
+		return timeouts.ClusterStartupSeconds
+	case v1beta1.TimeoutPhaseJobSubmission:
+		return timeouts.JobSubmissionSeconds
+	case v1beta1.TimeoutPhaseSavepoint:
+		return timeouts.SavepointSeconds
+	case v1beta1.TimeoutPhaseCancellation:
+		return timeouts.CancellationSeconds
+	case v1beta1.TimeoutPhaseUpgrade:
+		return timeouts.UpgradeSeconds
+	}
+	return nil
+}
+
+// Gets the seconds for a savepoint to complete, `SavepointTimeoutSec` unless
+// it is specified.
+func getSavepointTimeoutSeconds(timeouts *v1beta1.TimeoutsSpec) int32 {
+	var timeoutSeconds = getPhaseTimeoutSeconds(
+		timeouts, v1beta1.TimeoutPhaseSavepoint)
+	if timeoutSeconds == nil {
+		return SavepointTimeoutSec
+	}
+	return *timeoutSeconds
+}
+
+// Gets the deadline of a lifecycle phase which started at `startTime`, nil if
+// the phase has no timeout.
+func getPhaseDeadline(
+	timeouts *v1beta1.TimeoutsSpec,
+	phase string,
+	startTime time.Time) *time.Time {
+	var timeoutSeconds = getPhaseTimeoutSeconds(timeouts, phase)
+	if timeoutSeconds == nil || startTime.IsZero() {
+		return nil
+	}
+	var deadline = startTime.Add(time.Duration(*timeoutSeconds) * time.Second)
+	return &deadline
+}
+
+// Checks whether a lifecycle phase which started at `startTime` didn't
+// complete within its timeout.
+func isPhaseTimedOut(
+	timeouts *v1beta1.TimeoutsSpec,
+	phase string,
+	startTime time.Time,
+	now time.Time) bool {
+	var deadline = getPhaseDeadline(timeouts, phase, startTime)
+	return deadline != nil && !now.Before(*deadline)
+}
+
+// Creates the status of a lifecycle phase which timed out.
+func newTimeoutStatus(phase string, message string) *v1beta1.TimeoutStatus {
+	var timeout = &v1beta1.TimeoutStatus{Phase: phase, Message: message}
+	setTimestamp(&timeout.Time)
+	return timeout
+}
+
+// Checks whether the timeout of the phase has already been recorded, so the
+// failure it caused is recorded only once.
+func isTimeoutRecorded(recorded *v1beta1.TimeoutStatus, phase string) bool {
+	return recorded != nil && recorded.Phase == phase
+}
+
+// Gets the time when the startup of the cluster times out unless it is
+// running, nil if it is not being created or there is no timeout.
+func getClusterStartupDeadline(cluster *v1beta1.FlinkCluster) *time.Time {
+	var state = cluster.Status.State
+	if len(state) > 0 && state != v1beta1.ClusterStateCreating {
+		return nil
+	}
+	return getPhaseDeadline(
+		cluster.Spec.Timeouts,
+		v1beta1.TimeoutPhaseClusterStartup,
+		cluster.CreationTimestamp.Time)
+}
+
+// Checks whether the job submitter which is still active, e.g., its pod can't
+// be scheduled, didn't submit the job in time.
+func isJobSubmissionTimedOut(
+	cluster *v1beta1.FlinkCluster, submitter *batchv1.Job, now time.Time) bool {
+	return isPhaseTimedOut(
+		cluster.Spec.Timeouts,
+		v1beta1.TimeoutPhaseJobSubmission,
+		submitter.CreationTimestamp.Time,
+		now)
+}
+
+// Checks whether the job is not cancelled in time since the `job-cancel`
+// control was requested or last progressed.
+func isJobCancellationTimedOut(
+	timeouts *v1beta1.TimeoutsSpec,
+	controlStatus *v1beta1.FlinkClusterControlStatus,
+	now time.Time) bool {
+	if len(controlStatus.UpdateTime) == 0 {
+		return false
+	}
+	var tc = &TimeConverter{}
+	return isPhaseTimedOut(
+		timeouts,
+		v1beta1.TimeoutPhaseCancellation,
+		tc.FromString(controlStatus.UpdateTime),
+		now)
+}
+
+// Checks whether the running job is not stopped in time since the upgrade
+// started, e.g., its savepoints never complete.
+func isJobUpgradeTimedOut(
+	timeouts *v1beta1.TimeoutsSpec,
+	upgrade *v1beta1.JobUpgradeStatus,
+	now time.Time) bool {
+	if upgrade.State != v1beta1.JobUpgradeStateInProgress ||
+		len(upgrade.StartTime) == 0 {
+		return false
+	}
+	var tc = &TimeConverter{}
+	return isPhaseTimedOut(
+		timeouts,
+		v1beta1.TimeoutPhaseUpgrade,
+		tc.FromString(upgrade.StartTime),
+		now)
+}
+
+// Checks whether the job of the cluster failed because the cluster didn't
+// start in time, then the job is not submitted.
+func isJobFailedByStartupTimeout(cluster *v1beta1.FlinkCluster) bool {
+	var jobStatus = cluster.Status.Components.Job
+	return isTimeoutRecorded(
+		cluster.Status.Timeout, v1beta1.TimeoutPhaseClusterStartup) &&
+		jobStatus != nil && jobStatus.State == v1beta1.JobStateFailed
+}
+
+// Gets the timeout status from the recorded one and the new status of the
+// cluster, the recorded timeout is cleared when its phase is started again or
+// completed.
+func getTimeoutStatus(
+	recorded *v1beta1.TimeoutStatus,
+	status *v1beta1.FlinkClusterStatus) *v1beta1.TimeoutStatus {
+	if recorded == nil {
+		return nil
+	}
+	var jobStatus = status.Components.Job
+	var cleared = false
+	switch recorded.Phase {
+	case v1beta1.TimeoutPhaseClusterStartup:
+		if jobStatus != nil {
+			cleared = jobStatus.State != v1beta1.JobStateFailed
+		} else {
+			cleared = status.State != v1beta1.ClusterStateCreating
+		}
+	case v1beta1.TimeoutPhaseJobSubmission:
+		cleared = jobStatus == nil || jobStatus.State != v1beta1.JobStateFailed
+	case v1beta1.TimeoutPhaseSavepoint:
+		cleared = status.Savepoint == nil ||
+			status.Savepoint.State != v1beta1.SavepointStateFailed
+	case v1beta1.TimeoutPhaseCancellation:
+		cleared = status.Control == nil ||
+			status.Control.Name != v1beta1.ControlNameJobCancel ||
+			status.Control.State != v1beta1.ControlStateFailed
+	case v1beta1.TimeoutPhaseUpgrade:
+		cleared = jobStatus == nil || jobStatus.Upgrade == nil ||
+			jobStatus.Upgrade.State != v1beta1.JobUpgradeStateFailed
+	}
+	if cleared {
+		return nil
+	}
+	return recorded.DeepCopy()
+}
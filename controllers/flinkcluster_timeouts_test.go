@@ -0,0 +1,187 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsPhaseTimedOut(t *testing.T) {
+	var timeoutSeconds int32 = 60
+	var timeouts = &v1beta1.TimeoutsSpec{JobSubmissionSeconds: &timeoutSeconds}
+	var now = time.Now()
+
+	assert.Assert(t, !isPhaseTimedOut(
+		timeouts, v1beta1.TimeoutPhaseJobSubmission, now.Add(-30*time.Second), now))
+	assert.Assert(t, isPhaseTimedOut(
+		timeouts, v1beta1.TimeoutPhaseJobSubmission, now.Add(-60*time.Second), now))
+
+	// No timeout is specified for the phase.
+	assert.Assert(t, !isPhaseTimedOut(
+		timeouts, v1beta1.TimeoutPhaseUpgrade, now.Add(-time.Hour), now))
+	assert.Assert(t, !isPhaseTimedOut(
+		nil, v1beta1.TimeoutPhaseJobSubmission, now.Add(-time.Hour), now))
+
+	// The submitter created before the timeout.
+	var submitter = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Minute)),
+		},
+	}
+	var cluster = &v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{Timeouts: timeouts},
+	}
+	assert.Assert(t, isJobSubmissionTimedOut(cluster, submitter, now))
+}
+
+func TestGetSavepointTimeoutSeconds(t *testing.T) {
+	var timeoutSeconds int32 = 300
+	assert.Equal(t, getSavepointTimeoutSeconds(nil), int32(SavepointTimeoutSec))
+	assert.Equal(
+		t,
+		getSavepointTimeoutSeconds(
+			&v1beta1.TimeoutsSpec{SavepointSeconds: &timeoutSeconds}),
+		int32(300))
+}
+
+func TestIsJobCancellationTimedOut(t *testing.T) {
+	var tc = &TimeConverter{}
+	var now = time.Now()
+	var timeoutSeconds int32 = 300
+	var timeouts = &v1beta1.TimeoutsSpec{CancellationSeconds: &timeoutSeconds}
+	var controlStatus = &v1beta1.FlinkClusterControlStatus{
+		Name:       v1beta1.ControlNameJobCancel,
+		State:      v1beta1.ControlStateProgressing,
+		UpdateTime: tc.ToString(now.Add(-time.Minute)),
+	}
+
+	assert.Assert(t, !isJobCancellationTimedOut(timeouts, controlStatus, now))
+	assert.Assert(t, isJobCancellationTimedOut(
+		timeouts, controlStatus, now.Add(5*time.Minute)))
+	assert.Assert(t, !isJobCancellationTimedOut(
+		nil, controlStatus, now.Add(5*time.Minute)))
+}
+
+func TestIsJobUpgradeTimedOut(t *testing.T) {
+	var tc = &TimeConverter{}
+	var now = time.Now()
+	var timeoutSeconds int32 = 600
+	var timeouts = &v1beta1.TimeoutsSpec{UpgradeSeconds: &timeoutSeconds}
+	var upgrade = &v1beta1.JobUpgradeStatus{
+		State:     v1beta1.JobUpgradeStateInProgress,
+		StartTime: tc.ToString(now.Add(-11 * time.Minute)),
+	}
+
+	assert.Assert(t, isJobUpgradeTimedOut(timeouts, upgrade, now))
+	assert.Assert(t, !isJobUpgradeTimedOut(timeouts, upgrade, now.Add(-2*time.Minute)))
+
+	// The upgraded job is being deployed after the previous job is stopped.
+	upgrade.State = v1beta1.JobUpgradeStateDeploying
+	assert.Assert(t, !isJobUpgradeTimedOut(timeouts, upgrade, now))
+}
+
+func TestGetClusterStartupDeadline(t *testing.T) {
+	var now = time.Now()
+	var timeoutSeconds int32 = 600
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(now),
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Timeouts: &v1beta1.TimeoutsSpec{
+				ClusterStartupSeconds: &timeoutSeconds,
+			},
+		},
+		Status: v1beta1.FlinkClusterStatus{
+			State: v1beta1.ClusterStateCreating,
+		},
+	}
+
+	var deadline = getClusterStartupDeadline(cluster)
+	assert.Assert(t, deadline != nil)
+	assert.Equal(t, *deadline, cluster.CreationTimestamp.Add(10*time.Minute))
+
+	cluster.Status.State = v1beta1.ClusterStateRunning
+	assert.Assert(t, getClusterStartupDeadline(cluster) == nil)
+}
+
+func TestIsJobFailedByStartupTimeout(t *testing.T) {
+	var cluster = &v1beta1.FlinkCluster{
+		Status: v1beta1.FlinkClusterStatus{
+			State: v1beta1.ClusterStateCreating,
+			Components: v1beta1.FlinkClusterComponentsStatus{
+				Job: &v1beta1.JobStatus{State: v1beta1.JobStateFailed},
+			},
+			Timeout: &v1beta1.TimeoutStatus{
+				Phase: v1beta1.TimeoutPhaseClusterStartup,
+			},
+		},
+	}
+	assert.Assert(t, isJobFailedByStartupTimeout(cluster))
+
+	cluster.Status.Timeout.Phase = v1beta1.TimeoutPhaseJobSubmission
+	assert.Assert(t, !isJobFailedByStartupTimeout(cluster))
+}
+
+func TestGetTimeoutStatus(t *testing.T) {
+	var status = v1beta1.FlinkClusterStatus{
+		State: v1beta1.ClusterStateRunning,
+		Components: v1beta1.FlinkClusterComponentsStatus{
+			Job: &v1beta1.JobStatus{State: v1beta1.JobStateRunning},
+		},
+		Savepoint: &v1beta1.SavepointStatus{
+			State: v1beta1.SavepointStateFailed,
+		},
+	}
+	assert.Assert(t, getTimeoutStatus(nil, &status) == nil)
+
+	// Kept while the phase is failed.
+	var recorded = &v1beta1.TimeoutStatus{
+		Phase:   v1beta1.TimeoutPhaseSavepoint,
+		Time:    "2020-03-01T12:00:00Z",
+		Message: "The savepoint didn't complete within 60 seconds",
+	}
+	var timeout = getTimeoutStatus(recorded, &status)
+	assert.Assert(t, timeout != nil)
+	assert.Equal(t, *timeout, *recorded)
+
+	// Cleared when the phase is started again.
+	status.Savepoint.State = v1beta1.SavepointStateInProgress
+	assert.Assert(t, getTimeoutStatus(recorded, &status) == nil)
+
+	// The job which failed as the cluster didn't start in time.
+	recorded.Phase = v1beta1.TimeoutPhaseClusterStartup
+	status.Components.Job.State = v1beta1.JobStateFailed
+	assert.Assert(t, getTimeoutStatus(recorded, &status) != nil)
+	status.Components.Job.State = v1beta1.JobStateRunning
+	assert.Assert(t, getTimeoutStatus(recorded, &status) == nil)
+
+	// The failed upgrade is cleared when the job spec changes.
+	recorded.Phase = v1beta1.TimeoutPhaseUpgrade
+	status.Components.Job.Upgrade = &v1beta1.JobUpgradeStatus{
+		State: v1beta1.JobUpgradeStateFailed,
+	}
+	assert.Assert(t, getTimeoutStatus(recorded, &status) != nil)
+	status.Components.Job.Upgrade.State = v1beta1.JobUpgradeStateInProgress
+	assert.Assert(t, getTimeoutStatus(recorded, &status) == nil)
+}
@@ -0,0 +1,715 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"time"
+	"unicode/utf16"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	internalTLSVolume      = "internal-tls-volume"
+	internalTLSPath        = "/opt/flink/tls/internal"
+	internalTLSPasswordKey = "password"
+
+	// Environment variable holding the password of the internal keystore and
+	// truststore, which is passed to Flink as dynamic properties.
+	internalTLSPasswordEnv = "FLINK_SSL_INTERNAL_PASSWORD"
+
+	// Validity of the generated self-signed certificate.
+	internalTLSCertValidity = 10 * 365 * 24 * time.Hour
+
+	restTLSVolume      = "rest-tls-volume"
+	restTLSPath        = "/opt/flink/tls/rest"
+	restTLSPasswordKey = "password"
+	restTLSCAKey       = "ca.crt"
+
+	// Environment variable holding the password of the REST API keystore and
+	// truststore.
+	restTLSPasswordEnv = "FLINK_SSL_REST_PASSWORD"
+
+	// Environment variable holding the path of the REST API truststore, which
+	// the submit job script adds to the Flink config of the job submitter.
+	restTLSTruststoreEnv = "FLINK_SSL_REST_TRUSTSTORE"
+)
+
+// Certificate of cert-manager.
+var certificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// Checks whether the internal connections of the cluster are encrypted.
+func isInternalTLSEnabled(cluster *v1beta1.FlinkCluster) bool {
+	var security = cluster.Spec.Security
+	return security != nil && security.SSL != nil && security.SSL.Internal != nil
+}
+
+// Checks whether the keystore of the internal TLS is generated by the
+// operator.
+func isInternalTLSSecretGenerated(cluster *v1beta1.FlinkCluster) bool {
+	return isInternalTLSEnabled(cluster) &&
+		len(cluster.Spec.Security.SSL.Internal.SecretName) == 0
+}
+
+// Gets the name of the Secret of the internal keystore and truststore.
+func getInternalTLSSecretName(cluster *v1beta1.FlinkCluster) string {
+	if isInternalTLSSecretGenerated(cluster) {
+		return getGeneratedInternalTLSSecretName(cluster.Name)
+	}
+	return cluster.Spec.Security.SSL.Internal.SecretName
+}
+
+// Gets the Flink properties of the internal TLS, empty if it is disabled. The
+// passwords are passed as dynamic properties instead.
+func getInternalTLSProperties(cluster *v1beta1.FlinkCluster) map[string]string {
+	if !isInternalTLSEnabled(cluster) {
+		return nil
+	}
+	var internal = cluster.Spec.Security.SSL.Internal
+	return map[string]string{
+		"security.ssl.internal.enabled":    "true",
+		"security.ssl.internal.keystore":   internalTLSPath + "/" + internal.KeystoreKey,
+		"security.ssl.internal.truststore": internalTLSPath + "/" + internal.TruststoreKey,
+	}
+}
+
+// Gets the volume, volume mount and password env var of the internal TLS, and
+// the container args which pass the password to Flink as dynamic properties,
+// so that it is not written to flink-conf.yaml.
+func convertInternalTLS(cluster *v1beta1.FlinkCluster) (
+	*corev1.Volume, *corev1.VolumeMount, *corev1.EnvVar, []string) {
+	if !isInternalTLSEnabled(cluster) {
+		return nil, nil, nil, nil
+	}
+	var secretName = getInternalTLSSecretName(cluster)
+	var volume = &corev1.Volume{
+		Name: internalTLSVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+		},
+	}
+	var mount = &corev1.VolumeMount{
+		Name:      internalTLSVolume,
+		MountPath: internalTLSPath,
+		ReadOnly:  true,
+	}
+	var passwordRef = cluster.Spec.Security.SSL.Internal.PasswordSecretKeyRef
+	if passwordRef == nil {
+		passwordRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+			Key:                  internalTLSPasswordKey,
+		}
+	}
+	var env = &corev1.EnvVar{
+		Name:      internalTLSPasswordEnv,
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: passwordRef},
+	}
+	var args []string
+	for _, key := range []string{
+		"security.ssl.internal.keystore-password",
+		"security.ssl.internal.key-password",
+		"security.ssl.internal.truststore-password",
+	} {
+		args = append(args, "-D", key+"=$("+internalTLSPasswordEnv+")")
+	}
+	return volume, mount, env, args
+}
+
+// Gets the Secret of the internal TLS generated by the operator, whose
+// keystore holds a new self-signed certificate and is also the truststore, so
+// that the JobManager and TaskManagers trust each other. The keystore is in
+// PKCS12, which Java 8u60+ reads regardless of the keystore type.
+func getDesiredInternalTLSSecret(
+	cluster *v1beta1.FlinkCluster, now time.Time) (*corev1.Secret, error) {
+	var password, err = generatePassword()
+	if err != nil {
+		return nil, err
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	var template = &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: getGeneratedInternalTLSSecretName(cluster.Name),
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(internalTLSCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keystore, err := encodePKCS12(key, certDER, "flink", password)
+	if err != nil {
+		return nil, err
+	}
+	var internal = cluster.Spec.Security.SSL.Internal
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      getGeneratedInternalTLSSecretName(cluster.Name),
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(cluster)},
+			Labels: map[string]string{
+				"cluster": cluster.Name,
+				"app":     "flink",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			internal.KeystoreKey:   keystore,
+			internal.TruststoreKey: keystore,
+			internalTLSPasswordKey: []byte(password),
+		},
+	}, nil
+}
+
+// Checks whether the REST API and UI of the JobManager are served with TLS.
+func isRESTTLSEnabled(cluster *v1beta1.FlinkCluster) bool {
+	var security = cluster.Spec.Security
+	return security != nil && security.SSL != nil && security.SSL.REST != nil
+}
+
+// Checks whether the certificate of the REST API is requested from
+// cert-manager by the operator.
+func isRESTTLSCertificateRequested(cluster *v1beta1.FlinkCluster) bool {
+	return isRESTTLSEnabled(cluster) && cluster.Spec.Security.SSL.REST.IssuerRef != nil
+}
+
+// Gets the name of the Secret of the REST API keystore and truststore.
+func getRESTTLSSecretName(cluster *v1beta1.FlinkCluster) string {
+	if isRESTTLSCertificateRequested(cluster) {
+		return getRESTTLSCertificateName(cluster.Name)
+	}
+	return cluster.Spec.Security.SSL.REST.SecretName
+}
+
+// Gets the reference to the password of the REST API keystore and truststore.
+func getRESTTLSPasswordRef(cluster *v1beta1.FlinkCluster) *corev1.SecretKeySelector {
+	var rest = cluster.Spec.Security.SSL.REST
+	if rest.PasswordSecretKeyRef != nil {
+		return rest.PasswordSecretKeyRef
+	}
+	var secretName = rest.SecretName
+	if isRESTTLSCertificateRequested(cluster) {
+		secretName = getGeneratedRESTTLSPasswordSecretName(cluster.Name)
+	}
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+		Key:                  restTLSPasswordKey,
+	}
+}
+
+// Gets the path of the REST API truststore in the pods which mount it.
+func getRESTTLSTruststorePath(cluster *v1beta1.FlinkCluster) string {
+	return restTLSPath + "/" + cluster.Spec.Security.SSL.REST.TruststoreKey
+}
+
+// Gets the Flink properties of the REST API TLS, empty if it is disabled. The
+// passwords are passed as dynamic properties instead.
+func getRESTTLSProperties(cluster *v1beta1.FlinkCluster) map[string]string {
+	if !isRESTTLSEnabled(cluster) {
+		return nil
+	}
+	return map[string]string{
+		"security.ssl.rest.enabled":    "true",
+		"security.ssl.rest.keystore":   restTLSPath + "/" + cluster.Spec.Security.SSL.REST.KeystoreKey,
+		"security.ssl.rest.truststore": getRESTTLSTruststorePath(cluster),
+	}
+}
+
+// Gets the volume, volume mount and password env var of the REST API TLS, and
+// the JobManager args which pass the password to Flink as dynamic properties.
+func convertRESTTLS(cluster *v1beta1.FlinkCluster) (
+	*corev1.Volume, *corev1.VolumeMount, *corev1.EnvVar, []string) {
+	if !isRESTTLSEnabled(cluster) {
+		return nil, nil, nil, nil
+	}
+	var volume = &corev1.Volume{
+		Name: restTLSVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: getRESTTLSSecretName(cluster),
+			},
+		},
+	}
+	var mount = &corev1.VolumeMount{
+		Name:      restTLSVolume,
+		MountPath: restTLSPath,
+		ReadOnly:  true,
+	}
+	var env = &corev1.EnvVar{
+		Name: restTLSPasswordEnv,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: getRESTTLSPasswordRef(cluster),
+		},
+	}
+	var args []string
+	for _, key := range []string{
+		"security.ssl.rest.keystore-password",
+		"security.ssl.rest.key-password",
+		"security.ssl.rest.truststore-password",
+	} {
+		args = append(args, "-D", key+"=$("+restTLSPasswordEnv+")")
+	}
+	return volume, mount, env, args
+}
+
+// Gets the desired cert-manager Certificate of the REST API, nil if it is not
+// requested. The certificate is valid for the names of the JobManager service
+// and the internal REST service, and cert-manager writes it to the Secret of the same name with PKCS12
+// keystores encrypted with the generated password. It is an unstructured
+// object, so that the operator does not depend on cert-manager.
+func getDesiredRESTCertificate(
+	cluster *v1beta1.FlinkCluster) *unstructured.Unstructured {
+	if !isRESTTLSCertificateRequested(cluster) {
+		return nil
+	}
+	var issuerRef = cluster.Spec.Security.SSL.REST.IssuerRef
+	var serviceNames = []string{getJobManagerServiceName(cluster.Name)}
+	if isUIAuthProxyEnabled(cluster) {
+		serviceNames = append(
+			serviceNames, getJobManagerRESTServiceName(cluster.Name))
+	}
+	var dnsNames []interface{}
+	for _, serviceName := range serviceNames {
+		dnsNames = append(dnsNames,
+			serviceName,
+			serviceName+"."+cluster.Namespace,
+			serviceName+"."+cluster.Namespace+".svc",
+			serviceName+"."+cluster.Namespace+".svc.cluster.local")
+	}
+	var name = getRESTTLSCertificateName(cluster.Name)
+	var certificate = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"secretName": name,
+				"dnsNames":   dnsNames,
+				"issuerRef": map[string]interface{}{
+					"name":  issuerRef.Name,
+					"kind":  issuerRef.Kind,
+					"group": issuerRef.Group,
+				},
+				"keystores": map[string]interface{}{
+					"pkcs12": map[string]interface{}{
+						"create": true,
+						"passwordSecretRef": map[string]interface{}{
+							"name": getGeneratedRESTTLSPasswordSecretName(cluster.Name),
+							"key":  restTLSPasswordKey,
+						},
+					},
+				},
+			},
+		},
+	}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetNamespace(cluster.Namespace)
+	certificate.SetName(name)
+	certificate.SetOwnerReferences(
+		[]metav1.OwnerReference{toOwnerReference(cluster)})
+	certificate.SetLabels(map[string]string{
+		"cluster": cluster.Name,
+		"app":     "flink",
+	})
+	return certificate
+}
+
+// Gets the Secret of the keystore password of the REST API certificate
+// generated by the operator.
+func getDesiredRESTTLSPasswordSecret(
+	cluster *v1beta1.FlinkCluster) (*corev1.Secret, error) {
+	var password, err = generatePassword()
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      getGeneratedRESTTLSPasswordSecretName(cluster.Name),
+			OwnerReferences: []metav1.OwnerReference{
+				toOwnerReference(cluster)},
+			Labels: map[string]string{
+				"cluster": cluster.Name,
+				"app":     "flink",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{restTLSPasswordKey: []byte(password)},
+	}, nil
+}
+
+// Gets the TLS config of the requests of the operator to the REST API of the
+// cluster, nil if its TLS is disabled. The CA in the `ca.crt` key of the
+// Secret of the certificate is trusted, e.g., the CA of the cert-manager
+// issuer, otherwise the system CAs are.
+func getFlinkRESTTLSConfig(
+	ctx context.Context,
+	k8sClient client.Client,
+	cluster *v1beta1.FlinkCluster) (*tls.Config, error) {
+	if !isRESTTLSEnabled(cluster) {
+		return nil, nil
+	}
+	var secret = new(corev1.Secret)
+	var err = k8sClient.Get(
+		ctx,
+		types.NamespacedName{
+			Namespace: cluster.Namespace,
+			Name:      getRESTTLSSecretName(cluster),
+		},
+		secret)
+	if err != nil {
+		// The certificate may not be issued yet.
+		return &tls.Config{}, client.IgnoreNotFound(err)
+	}
+	return getRESTTLSConfig(secret), nil
+}
+
+func getRESTTLSConfig(secret *corev1.Secret) *tls.Config {
+	var config = &tls.Config{}
+	if ca, ok := secret.Data[restTLSCAKey]; ok {
+		config.RootCAs = x509.NewCertPool()
+		config.RootCAs.AppendCertsFromPEM(ca)
+	}
+	return config
+}
+
+func generatePassword() (string, error) {
+	var buf = make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ASN.1 structures of PKCS12, see RFC 7292.
+
+var (
+	oidData                          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSHA1                          = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPKCS8ShroudedKeyBag           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag                       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509Certificate       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidFriendlyName                  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+)
+
+const (
+	pkcs12Iterations = 2048
+	tagBMPString     = 30
+)
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue
+	Attributes []pkcs12Attribute `asn1:"set"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data asn1.RawValue
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// Encodes a private key and its certificate in a PKCS12 keystore under the
+// alias, like `openssl pkcs12 -export`. The key is encrypted with
+// pbeWithSHAAnd3-KeyTripleDES-CBC and the keystore is protected by an
+// HMAC-SHA1, which all Java versions support.
+func encodePKCS12(
+	key *rsa.PrivateKey, certDER []byte, alias string, password string) ([]byte, error) {
+	var encodedPassword = append(bmpString(password), 0, 0)
+	var localKeyID = sha1.Sum(certDER)
+	attributes, err := getPKCS12BagAttributes(alias, localKeyID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// Certificate bag, which is not encrypted.
+	certBagDER, err := asn1.Marshal(certBag{
+		ID:   oidCertTypeX509Certificate,
+		Data: explicitTag(octetString(certDER)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	certSafeDER, err := asn1.Marshal([]safeBag{{
+		ID:         oidCertBag,
+		Value:      explicitTag(certBagDER),
+		Attributes: attributes,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	// Shrouded key bag.
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var keySalt = make([]byte, 8)
+	if _, err = rand.Read(keySalt); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := encryptPBEWithSHAAnd3KeyTripleDESCBC(
+		keyDER, encodedPassword, keySalt)
+	if err != nil {
+		return nil, err
+	}
+	pbeParamsDER, err := asn1.Marshal(pbeParams{
+		Salt: keySalt, Iterations: pkcs12Iterations})
+	if err != nil {
+		return nil, err
+	}
+	encryptedKeyDER, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBEWithSHAAnd3KeyTripleDESCBC,
+			Parameters: asn1.RawValue{FullBytes: pbeParamsDER},
+		},
+		EncryptedData: encryptedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	keySafeDER, err := asn1.Marshal([]safeBag{{
+		ID:         oidPKCS8ShroudedKeyBag,
+		Value:      explicitTag(encryptedKeyDER),
+		Attributes: attributes,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	authSafeDER, err := asn1.Marshal([]contentInfo{
+		{ContentType: oidData, Content: explicitTag(octetString(certSafeDER))},
+		{ContentType: oidData, Content: explicitTag(octetString(keySafeDER))},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// MAC of the authenticated safe.
+	var macSalt = make([]byte, 8)
+	if _, err = rand.Read(macSalt); err != nil {
+		return nil, err
+	}
+	var macKey = pbkdfPKCS12(3, encodedPassword, macSalt, pkcs12Iterations, 20)
+	var mac = hmac.New(sha1.New, macKey)
+	mac.Write(authSafeDER)
+
+	return asn1.Marshal(pfxPdu{
+		Version: 3,
+		AuthSafe: contentInfo{
+			ContentType: oidData,
+			Content:     explicitTag(octetString(authSafeDER)),
+		},
+		MacData: macData{
+			Mac: digestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{
+					Algorithm:  oidSHA1,
+					Parameters: asn1.NullRawValue,
+				},
+				Digest: mac.Sum(nil),
+			},
+			MacSalt:    macSalt,
+			Iterations: pkcs12Iterations,
+		},
+	})
+}
+
+// Gets the friendly name and local key ID attributes, which pair the key and
+// certificate bags under the alias.
+func getPKCS12BagAttributes(
+	alias string, localKeyID []byte) ([]pkcs12Attribute, error) {
+	var friendlyName = asn1.RawValue{
+		Class: asn1.ClassUniversal,
+		Tag:   tagBMPString,
+		Bytes: bmpString(alias),
+	}
+	friendlyNameDER, err := asn1.Marshal(friendlyName)
+	if err != nil {
+		return nil, err
+	}
+	localKeyIDDER, err := asn1.Marshal(localKeyID)
+	if err != nil {
+		return nil, err
+	}
+	return []pkcs12Attribute{
+		{ID: oidFriendlyName, Value: setOf(friendlyNameDER)},
+		{ID: oidLocalKeyID, Value: setOf(localKeyIDDER)},
+	}, nil
+}
+
+// Encrypts data with pbeWithSHAAnd3-KeyTripleDES-CBC, i.e., 3DES in CBC mode
+// with the key and IV derived from the password by the PKCS12 KDF.
+func encryptPBEWithSHAAnd3KeyTripleDESCBC(
+	data []byte, encodedPassword []byte, salt []byte) ([]byte, error) {
+	var key = pbkdfPKCS12(1, encodedPassword, salt, pkcs12Iterations, 24)
+	var iv = pbkdfPKCS12(2, encodedPassword, salt, pkcs12Iterations, 8)
+	var block, err = des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var padding = block.BlockSize() - len(data)%block.BlockSize()
+	var encrypted = make([]byte, len(data)+padding)
+	copy(encrypted, data)
+	for i := len(data); i < len(encrypted); i++ {
+		encrypted[i] = byte(padding)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+	return encrypted, nil
+}
+
+// Derives key material from a password with the PKCS12 KDF and SHA-1, see
+// RFC 7292 appendix B.2. The ID is 1 for keys, 2 for IVs and 3 for MAC keys.
+func pbkdfPKCS12(
+	id byte, encodedPassword []byte, salt []byte, iterations int, size int) []byte {
+	const u, v = sha1.Size, 64
+	var fill = func(data []byte) []byte {
+		var filled = make([]byte, v*((len(data)+v-1)/v))
+		for i := range filled {
+			filled[i] = data[i%len(data)]
+		}
+		return filled
+	}
+	var d = make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+	var input = append(fill(salt), fill(encodedPassword)...)
+	var derived []byte
+	for len(derived) < size {
+		var a = sha1.Sum(append(append([]byte{}, d...), input...))
+		for i := 1; i < iterations; i++ {
+			a = sha1.Sum(a[:])
+		}
+		derived = append(derived, a[:]...)
+		if len(derived) >= size {
+			break
+		}
+		// Input blocks += a repeated to v bytes + 1.
+		var b = new(big.Int).SetBytes(fill(a[:u]))
+		b.Add(b, big.NewInt(1))
+		var modulus = new(big.Int).Lsh(big.NewInt(1), v*8)
+		for j := 0; j < len(input); j += v {
+			var block = new(big.Int).SetBytes(input[j : j+v])
+			block.Add(block, b)
+			block.Mod(block, modulus)
+			var blockBytes = block.Bytes()
+			var dst = input[j : j+v]
+			for k := range dst {
+				dst[k] = 0
+			}
+			copy(dst[v-len(blockBytes):], blockBytes)
+		}
+	}
+	return derived[:size]
+}
+
+// Encodes a string as a BMPString, i.e., UTF-16 big-endian. The passwords
+// are null-terminated in PKCS12.
+func bmpString(s string) []byte {
+	var encoded []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		encoded = append(encoded, byte(c>>8), byte(c))
+	}
+	return encoded
+}
+
+func explicitTag(der []byte) asn1.RawValue {
+	return asn1.RawValue{
+		Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func setOf(der []byte) asn1.RawValue {
+	return asn1.RawValue{
+		Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: der}
+}
+
+// Encodes bytes as an OCTET STRING, which cannot fail.
+func octetString(data []byte) []byte {
+	var der, _ = asn1.Marshal(data)
+	return der
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"golang.org/x/crypto/pkcs12"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDesiredInternalTLSSecret(t *testing.T) {
+	var now = time.Now()
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "default"},
+		Spec: v1beta1.FlinkClusterSpec{
+			Security: &v1beta1.SecuritySpec{
+				SSL: &v1beta1.SSLSpec{
+					Internal: &v1beta1.InternalSSLSpec{
+						KeystoreKey:   "keystore.p12",
+						TruststoreKey: "truststore.p12",
+					},
+				},
+			},
+		},
+	}
+	var secret, err = getDesiredInternalTLSSecret(cluster, now)
+	assert.NilError(t, err)
+	assert.Equal(t, secret.Name, "mycluster-internal-tls")
+	assert.Equal(t, len(secret.Data["password"]), 32)
+	assert.DeepEqual(t, secret.Data["keystore.p12"], secret.Data["truststore.p12"])
+
+	// The keystore can be decoded with the password.
+	key, cert, err := pkcs12.Decode(
+		secret.Data["keystore.p12"], string(secret.Data["password"]))
+	assert.NilError(t, err)
+	assert.Equal(t, cert.Subject.CommonName, "mycluster-internal-tls")
+	assert.Assert(t, cert.NotAfter.After(now.Add(365*24*time.Hour)))
+	assert.DeepEqual(t, cert.ExtKeyUsage, []x509.ExtKeyUsage{
+		x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth})
+	assert.Equal(t,
+		key.(*rsa.PrivateKey).PublicKey.N.Cmp(cert.PublicKey.(*rsa.PublicKey).N), 0)
+	_, _, err = pkcs12.Decode(secret.Data["keystore.p12"], "wrong")
+	assert.Assert(t, err != nil, "err is not expected to be nil")
+}
+
+func TestConvertInternalTLS(t *testing.T) {
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster"},
+		Spec: v1beta1.FlinkClusterSpec{
+			Security: &v1beta1.SecuritySpec{
+				SSL: &v1beta1.SSLSpec{
+					Internal: &v1beta1.InternalSSLSpec{
+						SecretName:    "mycluster-tls",
+						KeystoreKey:   "keystore.jks",
+						TruststoreKey: "truststore.jks",
+						PasswordSecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "mycluster-tls-password",
+							},
+							Key: "password",
+						},
+					},
+				},
+			},
+		},
+	}
+	var volume, mount, env, args = convertInternalTLS(cluster)
+	assert.Equal(t, volume.Secret.SecretName, "mycluster-tls")
+	assert.Equal(t, mount.MountPath, "/opt/flink/tls/internal")
+	assert.Equal(t, env.ValueFrom.SecretKeyRef.Name, "mycluster-tls-password")
+	assert.DeepEqual(t, args, []string{
+		"-D", "security.ssl.internal.keystore-password=$(FLINK_SSL_INTERNAL_PASSWORD)",
+		"-D", "security.ssl.internal.key-password=$(FLINK_SSL_INTERNAL_PASSWORD)",
+		"-D", "security.ssl.internal.truststore-password=$(FLINK_SSL_INTERNAL_PASSWORD)",
+	})
+	assert.DeepEqual(t, getInternalTLSProperties(cluster), map[string]string{
+		"security.ssl.internal.enabled":    "true",
+		"security.ssl.internal.keystore":   "/opt/flink/tls/internal/keystore.jks",
+		"security.ssl.internal.truststore": "/opt/flink/tls/internal/truststore.jks",
+	})
+
+	// The password of a generated Secret.
+	cluster.Spec.Security.SSL.Internal.SecretName = ""
+	cluster.Spec.Security.SSL.Internal.PasswordSecretKeyRef = nil
+	volume, _, env, _ = convertInternalTLS(cluster)
+	assert.Equal(t, volume.Secret.SecretName, "mycluster-internal-tls")
+	assert.DeepEqual(t, env.ValueFrom.SecretKeyRef, &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: "mycluster-internal-tls",
+		},
+		Key: "password",
+	})
+
+	cluster.Spec.Security = nil
+	volume, mount, env, args = convertInternalTLS(cluster)
+	assert.Assert(t, volume == nil && mount == nil && env == nil && args == nil)
+}
+
+func TestConvertRESTTLS(t *testing.T) {
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster"},
+		Spec: v1beta1.FlinkClusterSpec{
+			Security: &v1beta1.SecuritySpec{
+				SSL: &v1beta1.SSLSpec{
+					REST: &v1beta1.RESTSSLSpec{
+						SecretName:    "mycluster-rest",
+						KeystoreKey:   "keystore.p12",
+						TruststoreKey: "truststore.p12",
+					},
+				},
+			},
+		},
+	}
+	var volume, mount, env, args = convertRESTTLS(cluster)
+	assert.Equal(t, volume.Secret.SecretName, "mycluster-rest")
+	assert.Equal(t, mount.MountPath, "/opt/flink/tls/rest")
+	assert.DeepEqual(t, env.ValueFrom.SecretKeyRef, &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "mycluster-rest"},
+		Key:                  "password",
+	})
+	assert.DeepEqual(t, args, []string{
+		"-D", "security.ssl.rest.keystore-password=$(FLINK_SSL_REST_PASSWORD)",
+		"-D", "security.ssl.rest.key-password=$(FLINK_SSL_REST_PASSWORD)",
+		"-D", "security.ssl.rest.truststore-password=$(FLINK_SSL_REST_PASSWORD)",
+	})
+	assert.DeepEqual(t, getRESTTLSProperties(cluster), map[string]string{
+		"security.ssl.rest.enabled":    "true",
+		"security.ssl.rest.keystore":   "/opt/flink/tls/rest/keystore.p12",
+		"security.ssl.rest.truststore": "/opt/flink/tls/rest/truststore.p12",
+	})
+	assert.Assert(t, getDesiredRESTCertificate(cluster) == nil)
+
+	// The certificate issued by cert-manager.
+	cluster.Spec.Security.SSL.REST.SecretName = ""
+	cluster.Spec.Security.SSL.REST.IssuerRef = &v1beta1.CertManagerIssuerRef{
+		Name: "ca-issuer", Kind: "Issuer", Group: "cert-manager.io",
+	}
+	volume, _, env, _ = convertRESTTLS(cluster)
+	assert.Equal(t, volume.Secret.SecretName, "mycluster-rest-tls")
+	assert.DeepEqual(t, env.ValueFrom.SecretKeyRef, &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: "mycluster-rest-tls-password",
+		},
+		Key: "password",
+	})
+
+	cluster.Spec.Security = nil
+	volume, mount, env, args = convertRESTTLS(cluster)
+	assert.Assert(t, volume == nil && mount == nil && env == nil && args == nil)
+}
+
+func TestGetDesiredRESTCertificate(t *testing.T) {
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "default"},
+		Spec: v1beta1.FlinkClusterSpec{
+			Security: &v1beta1.SecuritySpec{
+				SSL: &v1beta1.SSLSpec{
+					REST: &v1beta1.RESTSSLSpec{
+						IssuerRef: &v1beta1.CertManagerIssuerRef{
+							Name:  "ca-issuer",
+							Kind:  "ClusterIssuer",
+							Group: "cert-manager.io",
+						},
+						KeystoreKey:   "keystore.p12",
+						TruststoreKey: "truststore.p12",
+					},
+				},
+			},
+		},
+	}
+	var certificate = getDesiredRESTCertificate(cluster)
+	assert.Equal(t, certificate.GetAPIVersion(), "cert-manager.io/v1")
+	assert.Equal(t, certificate.GetKind(), "Certificate")
+	assert.Equal(t, certificate.GetName(), "mycluster-rest-tls")
+	assert.DeepEqual(t, certificate.Object["spec"], map[string]interface{}{
+		"secretName": "mycluster-rest-tls",
+		"dnsNames": []interface{}{
+			"mycluster-jobmanager",
+			"mycluster-jobmanager.default",
+			"mycluster-jobmanager.default.svc",
+			"mycluster-jobmanager.default.svc.cluster.local",
+		},
+		"issuerRef": map[string]interface{}{
+			"name":  "ca-issuer",
+			"kind":  "ClusterIssuer",
+			"group": "cert-manager.io",
+		},
+		"keystores": map[string]interface{}{
+			"pkcs12": map[string]interface{}{
+				"create": true,
+				"passwordSecretRef": map[string]interface{}{
+					"name": "mycluster-rest-tls-password",
+					"key":  "password",
+				},
+			},
+		},
+	})
+
+	var secret, err = getDesiredRESTTLSPasswordSecret(cluster)
+	assert.NilError(t, err)
+	assert.Equal(t, secret.Name, "mycluster-rest-tls-password")
+	assert.Equal(t, len(secret.Data["password"]), 32)
+}
+
+func TestGetRESTTLSConfig(t *testing.T) {
+	var key, err = rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	var template = &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	var caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	var config = getRESTTLSConfig(&corev1.Secret{
+		Data: map[string][]byte{"ca.crt": caPEM},
+	})
+	assert.Equal(t, len(config.RootCAs.Subjects()), 1)
+
+	// The system CAs are trusted without the CA in the Secret.
+	config = getRESTTLSConfig(&corev1.Secret{})
+	assert.Assert(t, config.RootCAs == nil)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// The topology spread constraints of the pods are newer than the Kubernetes
+// API the operator is built with, so they are set in the unstructured
+// TaskManager Deployment or StatefulSet which is sent to the API server.
+
+// Gets the desired topology spread constraints of the TaskManager pods, in
+// the unstructured form of the pod spec, nil if none is specified. The
+// constraints count the TaskManager pods of the cluster by default.
+func getDesiredTaskManagerTopologySpreadConstraints(
+	flinkCluster *v1beta1.FlinkCluster) []interface{} {
+	var constraints = flinkCluster.Spec.TaskManager.TopologySpreadConstraints
+	if len(constraints) == 0 {
+		return nil
+	}
+	var clusterName = flinkCluster.ObjectMeta.Name
+	var desired []interface{}
+	for _, constraint := range constraints {
+		var labelSelector = constraint.LabelSelector
+		if labelSelector == nil {
+			labelSelector = &metav1.LabelSelector{
+				MatchLabels: getTaskManagerLabels(clusterName),
+			}
+		}
+		var selector, err = runtime.DefaultUnstructuredConverter.ToUnstructured(
+			labelSelector)
+		if err != nil {
+			// A label selector is always convertible.
+			panic(err)
+		}
+		desired = append(desired, map[string]interface{}{
+			"maxSkew":           int64(constraint.MaxSkew),
+			"topologyKey":       constraint.TopologyKey,
+			"whenUnsatisfiable": constraint.WhenUnsatisfiable,
+			"labelSelector":     selector,
+		})
+	}
+	return desired
+}
+
+// Gets the object of a component which is sent to the API server. It is the
+// object itself, except for the TaskManager Deployment or StatefulSet with
+// topology spread constraints, which is converted to an unstructured object
+// with the constraints in its pod spec.
+func (reconciler *ClusterReconciler) getComponentObject(
+	object runtime.Object) (runtime.Object, error) {
+	var constraints = reconciler.desired.TmTopologySpreadConstraints
+	if len(constraints) == 0 {
+		return object, nil
+	}
+	var podTemplate *corev1.PodTemplateSpec
+	switch typed := object.(type) {
+	case *appsv1.Deployment:
+		podTemplate = &typed.Spec.Template
+	case *appsv1.StatefulSet:
+		podTemplate = &typed.Spec.Template
+	}
+	if podTemplate == nil || podTemplate.Labels["component"] != "taskmanager" {
+		return object, nil
+	}
+
+	var kinds, _, err = reconciler.scheme.ObjectKinds(object)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, err
+	}
+	var unstructuredObject = &unstructured.Unstructured{Object: content}
+	unstructuredObject.SetGroupVersionKind(kinds[0])
+	err = unstructured.SetNestedSlice(
+		unstructuredObject.Object,
+		constraints,
+		"spec", "template", "spec", "topologySpreadConstraints")
+	if err != nil {
+		return nil, err
+	}
+	return unstructuredObject, nil
+}
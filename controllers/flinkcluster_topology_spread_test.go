@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestGetDesiredTaskManagerTopologySpreadConstraints(t *testing.T) {
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+	}
+	assert.Assert(t, getDesiredTaskManagerTopologySpreadConstraints(cluster) == nil)
+
+	cluster.Spec.TaskManager.TopologySpreadConstraints = []v1beta1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: v1beta1.UnsatisfiableConstraintActionDoNotSchedule,
+		},
+		{
+			MaxSkew:           2,
+			TopologyKey:       "kubernetes.io/hostname",
+			WhenUnsatisfiable: v1beta1.UnsatisfiableConstraintActionScheduleAnyway,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "flink"},
+			},
+		},
+	}
+	var expected = []interface{}{
+		map[string]interface{}{
+			"maxSkew":           int64(1),
+			"topologyKey":       "topology.kubernetes.io/zone",
+			"whenUnsatisfiable": "DoNotSchedule",
+			"labelSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					"cluster":   "mycluster",
+					"app":       "flink",
+					"component": "taskmanager",
+				},
+			},
+		},
+		map[string]interface{}{
+			"maxSkew":           int64(2),
+			"topologyKey":       "kubernetes.io/hostname",
+			"whenUnsatisfiable": "ScheduleAnyway",
+			"labelSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "flink"},
+			},
+		},
+	}
+	assert.DeepEqual(
+		t, getDesiredTaskManagerTopologySpreadConstraints(cluster), expected)
+}
+
+func TestGetComponentObjectWithTopologySpreadConstraints(t *testing.T) {
+	var constraints = []interface{}{
+		map[string]interface{}{
+			"maxSkew":           int64(1),
+			"topologyKey":       "topology.kubernetes.io/zone",
+			"whenUnsatisfiable": "DoNotSchedule",
+		},
+	}
+	var reconciler = &ClusterReconciler{
+		scheme: scheme.Scheme,
+		desired: DesiredClusterState{
+			TmTopologySpreadConstraints: constraints,
+		},
+	}
+	var newDeployment = func(component string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mycluster-" + component,
+				Namespace: "default",
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"component": component},
+					},
+				},
+			},
+		}
+	}
+
+	// The JobManager deployment is sent as is.
+	var jmDeployment = newDeployment("jobmanager")
+	var object, err = reconciler.getComponentObject(jmDeployment)
+	assert.NilError(t, err)
+	assert.Equal(t, object, jmDeployment)
+
+	// The TaskManager deployment gets the constraints in its pod spec.
+	object, err = reconciler.getComponentObject(newDeployment("taskmanager"))
+	assert.NilError(t, err)
+	var unstructuredObject, ok = object.(*unstructured.Unstructured)
+	assert.Assert(t, ok, "the TaskManager deployment is expected to be unstructured")
+	assert.Equal(t, unstructuredObject.GetAPIVersion(), "apps/v1")
+	assert.Equal(t, unstructuredObject.GetKind(), "Deployment")
+	assert.Equal(t, unstructuredObject.GetName(), "mycluster-taskmanager")
+	var topologySpreadConstraints, _, _ = unstructured.NestedSlice(
+		unstructuredObject.Object,
+		"spec", "template", "spec", "topologySpreadConstraints")
+	assert.DeepEqual(t, topologySpreadConstraints, constraints)
+
+	// Without constraints, the TaskManager deployment is sent as is.
+	reconciler.desired.TmTopologySpreadConstraints = nil
+	var tmDeployment = newDeployment("taskmanager")
+	object, err = reconciler.getComponentObject(tmDeployment)
+	assert.NilError(t, err)
+	assert.Equal(t, object, tmDeployment)
+}
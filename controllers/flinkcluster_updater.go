@@ -25,16 +25,29 @@ import (
 	"fmt"
 	"k8s.io/apimachinery/pkg/types"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/go-logr/logr"
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// The maximum number of failure reasons kept in the job status.
+const maxFailureReasons = 5
+
+// The maximum number of accumulators kept in the job status and the maximum
+// length of their values.
+const (
+	maxAccumulators           = 32
+	maxAccumulatorValueLength = 256
+)
+
 // ClusterStatusUpdater updates the status of the FlinkCluster CR.
 type ClusterStatusUpdater struct {
 	k8sClient client.Client
@@ -137,6 +150,30 @@ func (updater *ClusterStatusUpdater) createStatusChangeEvents(
 			oldStatus.Components.TaskManagerDeployment.State,
 			newStatus.Components.TaskManagerDeployment.State)
 	}
+	if oldStatus.Components.TaskManagerDeployment.DecommissionStartTime == "" &&
+		newStatus.Components.TaskManagerDeployment.DecommissionStartTime != "" {
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeNormal,
+			"DecommissioningTaskManagers",
+			fmt.Sprintf(
+				"Waiting up to %v seconds for the TaskManagers to become idle before scaling down",
+				*updater.observed.cluster.Spec.TaskManager.DecommissionTimeoutSeconds))
+	}
+
+	// History Server.
+	if newStatus.Components.HistoryServer != nil {
+		var oldState = ""
+		if oldStatus.Components.HistoryServer != nil {
+			oldState = oldStatus.Components.HistoryServer.State
+		}
+		if oldState != newStatus.Components.HistoryServer.State {
+			updater.createStatusChangeEvent(
+				"History Server deployment",
+				oldState,
+				newStatus.Components.HistoryServer.State)
+		}
+	}
 
 	// Job.
 	if oldStatus.Components.Job == nil && newStatus.Components.Job != nil {
@@ -151,15 +188,114 @@ func (updater *ClusterStatusUpdater) createStatusChangeEvents(
 			newStatus.Components.Job.State)
 	}
 
+	// Job submission failure of the job submitter.
+	if newStatus.Components.Job != nil &&
+		newStatus.Components.Job.SubmissionAttempts > 0 &&
+		(oldStatus.Components.Job == nil ||
+			oldStatus.Components.Job.SubmissionAttempts <
+				newStatus.Components.Job.SubmissionAttempts) {
+		var msg = newStatus.Components.Job.LastSubmissionError
+		if len(msg) > 100 {
+			msg = msg[:100] + "..."
+		}
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeWarning,
+			"JobSubmissionFailed",
+			fmt.Sprintf(
+				"Job submission attempt %v failed: %v",
+				newStatus.Components.Job.SubmissionAttempts, msg))
+		recordJobSubmissionMetrics(
+			updater.observed.cluster.Namespace, true /* failed */)
+	}
+
+	// Job upgrade failure.
+	if newStatus.Components.Job != nil &&
+		newStatus.Components.Job.Upgrade != nil &&
+		newStatus.Components.Job.Upgrade.State ==
+			v1beta1.JobUpgradeStateFailed &&
+		(oldStatus.Components.Job == nil ||
+			oldStatus.Components.Job.Upgrade == nil ||
+			oldStatus.Components.Job.Upgrade.State !=
+				v1beta1.JobUpgradeStateFailed) {
+		var upgrade = newStatus.Components.Job.Upgrade
+		var msg = upgrade.Message
+		if len(msg) > 100 {
+			msg = msg[:100] + "..."
+		}
+		if upgrade.FailedSavepoints >= MaxJobUpgradeSavepointFailures {
+			msg = fmt.Sprintf(
+				"Job upgrade failed after %v failed savepoints, it is retried when the job spec changes: %v",
+				upgrade.FailedSavepoints, msg)
+		} else {
+			msg = fmt.Sprintf(
+				"Job upgrade failed, it is retried when the job spec changes: %v",
+				msg)
+		}
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeWarning,
+			"JobUpgradeFailed",
+			msg)
+	}
+
+	// Job upgrade rollback.
+	if newStatus.Components.Job != nil &&
+		newStatus.Components.Job.Rollback != nil &&
+		newStatus.Components.Job.Rollback.State ==
+			v1beta1.JobRollbackStateSucceeded &&
+		oldStatus.Components.Job != nil &&
+		oldStatus.Components.Job.Rollback != nil &&
+		oldStatus.Components.Job.Rollback.State ==
+			v1beta1.JobRollbackStateInProgress {
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeNormal,
+			"JobRollbackSucceeded",
+			fmt.Sprintf(
+				"Job %v is running with the previous spec after the rollback",
+				newStatus.Components.Job.ID))
+	}
+
+	// Lost job.
+	if newStatus.Components.Job != nil &&
+		len(newStatus.Components.Job.LostSince) > 0 &&
+		(oldStatus.Components.Job == nil ||
+			len(oldStatus.Components.Job.LostSince) == 0) {
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeWarning,
+			"JobLost",
+			fmt.Sprintf(
+				"Job %v is not found in the JobManager",
+				newStatus.Components.Job.ID))
+	}
+
 	// Cluster.
 	if oldStatus.State != newStatus.State {
 		updater.createStatusChangeEvent("Cluster", oldStatus.State, newStatus.State)
 	}
 
+	// Idle timeout of a session cluster.
+	if !oldStatus.IdleTimedOut && newStatus.IdleTimedOut {
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeNormal,
+			"IdleTimedOut",
+			fmt.Sprintf(
+				"Cluster has been idle since %v, taking action %v",
+				newStatus.IdleSince,
+				updater.observed.cluster.Spec.IdleTimeoutAction))
+	}
+
 	// Savepoint.
 	if newStatus.Savepoint != nil && !reflect.DeepEqual(oldStatus.Savepoint, newStatus.Savepoint) {
 		eventType, eventReason, eventMessage := getSavepointEvent(*newStatus.Savepoint)
 		updater.recorder.Event(updater.observed.cluster, eventType, eventReason, eventMessage)
+		if oldStatus.Savepoint == nil || oldStatus.Savepoint.State != newStatus.Savepoint.State {
+			recordSavepointMetrics(
+				updater.observed.cluster.Namespace, newStatus.Savepoint.State)
+		}
 	}
 
 	// Control.
@@ -167,6 +303,31 @@ func (updater *ClusterStatusUpdater) createStatusChangeEvents(
 		eventType, eventReason, eventMessage := getControlEvent(*newStatus.Control)
 		updater.recorder.Event(updater.observed.cluster, eventType, eventReason, eventMessage)
 	}
+
+	// Insufficient quota.
+	if len(newStatus.InsufficientQuota) > 0 &&
+		newStatus.InsufficientQuota != oldStatus.InsufficientQuota {
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeWarning,
+			"InsufficientQuota",
+			fmt.Sprintf(
+				"Waiting for the quota to create the JobManager and TaskManagers: %v",
+				newStatus.InsufficientQuota))
+	}
+
+	// Lifecycle phase timeout.
+	if newStatus.Timeout != nil &&
+		!reflect.DeepEqual(oldStatus.Timeout, newStatus.Timeout) {
+		updater.recorder.Event(
+			updater.observed.cluster,
+			corev1.EventTypeWarning,
+			"TimedOut",
+			fmt.Sprintf(
+				"%v timed out: %v",
+				newStatus.Timeout.Phase,
+				newStatus.Timeout.Message))
+	}
 }
 
 func (updater *ClusterStatusUpdater) createStatusChangeEvent(
@@ -191,6 +352,10 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 	recorded *v1beta1.FlinkClusterStatus,
 	observed *ObservedClusterState) v1beta1.FlinkClusterStatus {
 	var status = v1beta1.FlinkClusterStatus{}
+	var now = time.Now()
+	var timeouts = observed.cluster.Spec.Timeouts
+	// The lifecycle phase which times out in this reconciliation.
+	var timeout *v1beta1.TimeoutStatus
 	var runningComponents = 0
 	// jmDeployment, jmService, tmDeployment.
 	var totalComponents = 3
@@ -217,13 +382,32 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 			observedJmDeployment.ObjectMeta.Name
 		status.Components.JobManagerDeployment.State =
 			getDeploymentState(observedJmDeployment)
+		status.Components.JobManagerDeployment.Replicas =
+			observedJmDeployment.Status.Replicas
+		status.Components.JobManagerDeployment.ReadyReplicas =
+			observedJmDeployment.Status.ReadyReplicas
+		if status.Components.JobManagerDeployment.State ==
+			v1beta1.ComponentStateReady {
+			runningComponents++
+		}
+	} else if observed.jmStatefulSet != nil {
+		// The status of the StatefulSet is recorded as the JobManager
+		// deployment status.
+		status.Components.JobManagerDeployment.Name =
+			observed.jmStatefulSet.ObjectMeta.Name
+		status.Components.JobManagerDeployment.State =
+			getStatefulSetState(observed.jmStatefulSet)
+		status.Components.JobManagerDeployment.Replicas =
+			observed.jmStatefulSet.Status.Replicas
+		status.Components.JobManagerDeployment.ReadyReplicas =
+			observed.jmStatefulSet.Status.ReadyReplicas
 		if status.Components.JobManagerDeployment.State ==
 			v1beta1.ComponentStateReady {
 			runningComponents++
 		}
 	} else if recorded.Components.JobManagerDeployment.Name != "" {
 		status.Components.JobManagerDeployment =
-			v1beta1.FlinkClusterComponentState{
+			v1beta1.JobManagerDeploymentStatus{
 				Name:  recorded.Components.JobManagerDeployment.Name,
 				State: v1beta1.ComponentStateDeleted,
 			}
@@ -268,6 +452,7 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 				Name:     observedJmService.ObjectMeta.Name,
 				State:    state,
 				NodePort: nodePort,
+				RestURL:  getFlinkAPIBaseURL(observed.cluster),
 			}
 	} else if recorded.Components.JobManagerService.Name != "" {
 		status.Components.JobManagerService =
@@ -356,6 +541,15 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 			}
 	}
 
+	// The web UI URL prefers the ingress and load balancer addresses, which
+	// are reachable from outside of the Kubernetes cluster.
+	if observedJmService != nil {
+		status.Components.JobManagerService.UIURL = getFlinkUIURL(
+			observed.cluster,
+			observedJmService,
+			status.Components.JobManagerIngress)
+	}
+
 	// TaskManager deployment.
 	var observedTmDeployment = observed.tmDeployment
 	if observedTmDeployment != nil {
@@ -363,27 +557,98 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 			observedTmDeployment.ObjectMeta.Name
 		status.Components.TaskManagerDeployment.State =
 			getDeploymentState(observedTmDeployment)
+		status.Components.TaskManagerDeployment.Replicas =
+			observedTmDeployment.Status.Replicas
+		status.Components.TaskManagerDeployment.ReadyReplicas =
+			observedTmDeployment.Status.ReadyReplicas
+		status.Components.TaskManagerDeployment.Selector =
+			metav1.FormatLabelSelector(observedTmDeployment.Spec.Selector)
+		if status.Components.TaskManagerDeployment.State ==
+			v1beta1.ComponentStateReady {
+			runningComponents++
+		}
+	} else if observed.tmStatefulSet != nil {
+		// The status of the StatefulSet is recorded as the TaskManager
+		// deployment status, which backs the scale subresource.
+		var observedTmStatefulSet = observed.tmStatefulSet
+		status.Components.TaskManagerDeployment.Name =
+			observedTmStatefulSet.ObjectMeta.Name
+		status.Components.TaskManagerDeployment.State =
+			getStatefulSetState(observedTmStatefulSet)
+		status.Components.TaskManagerDeployment.Replicas =
+			observedTmStatefulSet.Status.Replicas
+		status.Components.TaskManagerDeployment.ReadyReplicas =
+			observedTmStatefulSet.Status.ReadyReplicas
+		status.Components.TaskManagerDeployment.Selector =
+			metav1.FormatLabelSelector(observedTmStatefulSet.Spec.Selector)
 		if status.Components.TaskManagerDeployment.State ==
 			v1beta1.ComponentStateReady {
 			runningComponents++
 		}
 	} else if recorded.Components.TaskManagerDeployment.Name != "" {
 		status.Components.TaskManagerDeployment =
-			v1beta1.FlinkClusterComponentState{
+			v1beta1.TaskManagerDeploymentStatus{
 				Name:  recorded.Components.TaskManagerDeployment.Name,
 				State: v1beta1.ComponentStateDeleted,
 			}
 	}
 
+	// The TaskManagers and task slots registered in the JobManager, the
+	// recorded ones are kept while the TaskManagers are running but the
+	// overview cannot be observed, e.g., the JobManager is restarting.
+	var tmStatus = &status.Components.TaskManagerDeployment
+	if observed.flinkClusterOverview != nil {
+		tmStatus.RegisteredTaskManagers =
+			observed.flinkClusterOverview.TaskManagers
+		tmStatus.SlotsTotal = observed.flinkClusterOverview.SlotsTotal
+		tmStatus.SlotsAvailable = observed.flinkClusterOverview.SlotsAvailable
+	} else if observedTmDeployment != nil || observed.tmStatefulSet != nil {
+		var recordedTmStatus = &recorded.Components.TaskManagerDeployment
+		tmStatus.RegisteredTaskManagers = recordedTmStatus.RegisteredTaskManagers
+		tmStatus.SlotsTotal = recordedTmStatus.SlotsTotal
+		tmStatus.SlotsAvailable = recordedTmStatus.SlotsAvailable
+	}
+
+	// The time when the pending scale-down started waiting for the
+	// TaskManagers to become idle, which bounds the wait.
+	if isTaskManagerDecommissionEnabled(observed.cluster) &&
+		getTaskManagerScaleDown(observed) > 0 {
+		tmStatus.DecommissionStartTime =
+			recorded.Components.TaskManagerDeployment.DecommissionStartTime
+		if tmStatus.DecommissionStartTime == "" {
+			var tc = &TimeConverter{}
+			tmStatus.DecommissionStartTime = tc.ToString(now)
+		}
+	}
+
+	// (Optional) History Server deployment, which does not count as a
+	// running component of the cluster as it survives the cleanup.
+	var observedHistoryServer = observed.historyServerDeployment
+	if observedHistoryServer != nil {
+		status.Components.HistoryServer = &v1beta1.FlinkClusterComponentState{
+			Name:  observedHistoryServer.ObjectMeta.Name,
+			State: getDeploymentState(observedHistoryServer),
+		}
+	} else if recorded.Components.HistoryServer != nil {
+		status.Components.HistoryServer = &v1beta1.FlinkClusterComponentState{
+			Name:  recorded.Components.HistoryServer.Name,
+			State: v1beta1.ComponentStateDeleted,
+		}
+	}
+
 	// (Optional) Savepoint status
 	// update savepoint status if it is in progress
 	if recorded.Savepoint != nil {
 		var newSavepointStatus = recorded.Savepoint.DeepCopy()
+		var savepointTimeoutSec = getSavepointTimeoutSeconds(timeouts)
+		var savepointTimedOut = false
+		var savepointRetriable = false
 		if recorded.Savepoint.State == v1beta1.SavepointStateInProgress {
 			if observed.savepoint != nil {
 				switch {
 				case observed.savepoint.IsSuccessful():
 					newSavepointStatus.State = v1beta1.SavepointStateSucceeded
+					newSavepointStatus.ProgressPercent = 100
 				case observed.savepoint.IsFailed():
 					var msg string
 					newSavepointStatus.State = v1beta1.SavepointStateFailed
@@ -398,13 +663,22 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 						msg = msg[:1024] + "..."
 					}
 					newSavepointStatus.Message = msg
+					savepointRetriable = true
 				}
 			}
+			if newSavepointStatus.State == v1beta1.SavepointStateInProgress &&
+				observed.flinkJobCheckpoints != nil &&
+				observed.flinkJobCheckpoints.SavepointInProgress != nil {
+				newSavepointStatus.ProgressPercent = getCheckpointProgressPercent(
+					observed.flinkJobCheckpoints.SavepointInProgress)
+			}
 		}
 		if newSavepointStatus.State == v1beta1.SavepointStateNotTriggered || newSavepointStatus.State == v1beta1.SavepointStateInProgress {
-			if savepointTimeout(newSavepointStatus) {
+			if savepointTimeout(newSavepointStatus, savepointTimeoutSec) {
 				newSavepointStatus.State = v1beta1.SavepointStateFailed
 				newSavepointStatus.Message = "Timed out taking savepoint"
+				savepointTimedOut = true
+				savepointRetriable = true
 			} else if isJobStopped(recorded.Components.Job) {
 				newSavepointStatus.Message = "Flink job is stopped"
 				newSavepointStatus.State = v1beta1.SavepointStateFailed
@@ -414,9 +688,30 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 				newSavepointStatus.State = v1beta1.SavepointStateFailed
 			}
 		}
+		// The savepoint which failed or didn't complete in time is triggered
+		// again after the backoff while the job is running, so the
+		// cancellation or upgrade waiting for it is not blocked.
+		if savepointRetriable && !isJobStopped(recorded.Components.Job) &&
+			shouldRetrySavepoint(observed.cluster.Spec.Job, newSavepointStatus) {
+			newSavepointStatus = getSavepointRetryStatus(
+				observed.cluster.Spec.Job, newSavepointStatus, now)
+		} else if savepointTimedOut {
+			timeout = newTimeoutStatus(
+				v1beta1.TimeoutPhaseSavepoint,
+				fmt.Sprintf(
+					"The savepoint didn't complete within %v seconds",
+					savepointTimeoutSec))
+		}
 		status.Savepoint = newSavepointStatus
 	}
 
+	// (Optional) Autoscaler status.
+	// It is updated by the autoscaler when it makes a scaling decision, the
+	// observed metrics are not recorded here because they change constantly.
+	if observed.cluster.Spec.Autoscaler != nil {
+		status.Autoscaler = recorded.Autoscaler.DeepCopy()
+	}
+
 	// (Optional) Job.
 	var jobStopped = false
 	var jobSucceeded = false
@@ -436,14 +731,51 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 		if flinkJobID != nil {
 			jobStatus.ID = *flinkJobID
 		}
-		if observedJob.Status.Failed > 0 {
+		updateFlinkJobStatus(jobStatus, observed)
+		if observedJob.Status.Failed > 0 &&
+			len(observed.jobSubmitterFailure) > 0 {
+			jobStatus.SubmissionFailureMessage = observed.jobSubmitterFailure
+		}
+		var submissionFailure = observed.jobSubmissionFailure
+		if observedJob.Status.Failed > 0 && submissionFailure != nil {
+			updateJobSubmissionFailure(
+				jobStatus, observed.cluster.Spec.Job, submissionFailure)
+		}
+		if observedJob.Status.Failed > 0 && submissionFailure != nil &&
+			shouldRetryJobSubmission(
+				observed.cluster.Spec.Job, jobStatus.SubmissionAttempts) {
+			// The job submitter is created again by the reconciler after the
+			// retry backoff.
+			jobStatus.State = v1beta1.JobStatePending
+		} else if observedJob.Status.Failed > 0 {
 			jobStatus.State = v1beta1.JobStateFailed
 			jobStopped = true
 			jobFailed = true
+		} else if observedJob.Status.Succeeded > 0 &&
+			isJobStoppedForUpgrade(observed.cluster, observedJob, status.Savepoint) {
+			// The job stopped with a savepoint for an upgrade is still
+			// considered running until the upgraded job is submitted.
+			jobStatus.State = v1beta1.JobStateRunning
 		} else if observedJob.Status.Succeeded > 0 {
 			jobStatus.State = v1beta1.JobStateSucceeded
 			jobStopped = true
 			jobSucceeded = true
+		} else if flinkJobID == nil &&
+			isJobSubmissionTimedOut(observed.cluster, observedJob, now) {
+			// The job submitter is kept, so its logs can be inspected.
+			jobStatus.State = v1beta1.JobStateFailed
+			jobStopped = true
+			jobFailed = true
+			if !isTimeoutRecorded(
+				recorded.Timeout, v1beta1.TimeoutPhaseJobSubmission) {
+				timeout = newTimeoutStatus(
+					v1beta1.TimeoutPhaseJobSubmission,
+					fmt.Sprintf(
+						"The job wasn't submitted within %v seconds",
+						*timeouts.JobSubmissionSeconds))
+				jobStatus.FailureReasons = appendFailureMessage(
+					jobStatus.FailureReasons, timeout.Message)
+			}
 		} else {
 			// When job status is Active, it is possible that the pod is still
 			// Pending (for scheduling), so we use Flink job ID to determine
@@ -457,14 +789,45 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 				v1beta1.JobStateFailed ||
 				recordedJobStatus.State == v1beta1.JobStateCancelled) {
 				jobStatus.RestartCount++
+				jobStatus.SubmissionAttempts = 0
 			}
 		}
+	} else if isRestAPISubmission(observed.cluster.Spec.Job) &&
+		recordedJobStatus != nil {
+		// The job is submitted by the operator, its state is derived from the
+		// Flink job state. The job stopped by the operator for an upgrade is
+		// still considered running until the upgraded job is submitted.
+		jobStatus = recordedJobStatus.DeepCopy()
+		updateFlinkJobStatus(jobStatus, observed)
+		var flinkJob = observed.flinkJob
+		if flinkJob != nil && flinkJob.ID == jobStatus.ID &&
+			!isJobStopped(recordedJobStatus) &&
+			!isRestAPIJobUpgradeRequested(observed.cluster) {
+			jobStatus.State = getJobStateFromFlinkJobState(flinkJob.State)
+			// Jobs cancelled with a savepoint are stopped gracefully.
+			if jobStatus.State == v1beta1.JobStateSucceeded &&
+				isJobCancelRequested(observed.cluster) {
+				jobStatus.State = v1beta1.JobStateCancelled
+			}
+		}
+		jobStopped = isJobStopped(jobStatus)
+		jobSucceeded = jobStatus.State == v1beta1.JobStateSucceeded
+		jobFailed = jobStatus.State == v1beta1.JobStateFailed
+		jobCancelled = jobStatus.State == v1beta1.JobStateCancelled
 	} else if recordedJobStatus != nil {
 		jobStatus = recordedJobStatus.DeepCopy()
 		jobStopped = true
 		var cancelRequested = observed.cluster.Spec.Job.CancelRequested
-		if (cancelRequested != nil && *cancelRequested) ||
-			(observed.cluster.Status.Control != nil && observed.cluster.Status.Control.Name == v1beta1.ControlNameJobCancel) {
+		var controlStatus = observed.cluster.Status.Control
+		var cancelControlled = controlStatus != nil &&
+			controlStatus.Name == v1beta1.ControlNameJobCancel
+		// The finished cancel control of a previous run doesn't apply to the
+		// later runs of a scheduled job.
+		if cancelControlled && isScheduledJob(observed.cluster.Spec.Job) {
+			cancelControlled =
+				controlStatus.State == v1beta1.ControlStateProgressing
+		}
+		if (cancelRequested != nil && *cancelRequested) || cancelControlled {
 			jobStatus.State = v1beta1.JobStateCancelled
 			jobCancelled = true
 		}
@@ -474,20 +837,121 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 		jobStatus.LastSavepointTriggerID = observed.savepoint.TriggerID
 		jobStatus.SavepointLocation = observed.savepoint.Location
 		setTimestamp(&jobStatus.LastSavepointTime)
+		appendSavepointHistory(
+			observed.cluster.Spec.Job, jobStatus, observed.savepoint.Location)
+	}
+	// The upgrade of the running job to the updated job spec.
+	if jobStatus != nil {
+		if isJobSpecUpdated(observed.cluster, observedJob) &&
+			!isJobStopped(jobStatus) {
+			jobStatus.Upgrade = getJobUpgradeStatus(
+				jobStatus.Upgrade,
+				jobStatus.ID,
+				getJobSpecHash(observed.cluster),
+				status.Savepoint)
+			// The job keeps running with the previous spec when the upgrade
+			// times out.
+			var upgrade = jobStatus.Upgrade
+			if isJobUpgradeTimedOut(timeouts, upgrade, now) {
+				timeout = newTimeoutStatus(
+					v1beta1.TimeoutPhaseUpgrade,
+					fmt.Sprintf(
+						"The job wasn't stopped for the upgrade within %v seconds",
+						*timeouts.UpgradeSeconds))
+				upgrade.State = v1beta1.JobUpgradeStateFailed
+				upgrade.Message = timeout.Message
+			}
+		} else {
+			jobStatus.Upgrade = getDeployingJobUpgradeStatus(
+				observed.cluster, jobStatus)
+		}
+		updateJobRollbackStatus(jobStatus)
+		jobStatus.StableSpec = getStableSpec(observed.cluster, jobStatus)
+	}
+	// The job of a job cluster fails when the cluster doesn't start in time,
+	// then it is not submitted.
+	var startupTimedOut = (recorded.State == "" ||
+		recorded.State == v1beta1.ClusterStateCreating) &&
+		runningComponents < totalComponents &&
+		isPhaseTimedOut(
+			timeouts,
+			v1beta1.TimeoutPhaseClusterStartup,
+			observed.cluster.CreationTimestamp.Time,
+			now)
+	if startupTimedOut {
+		var startupRecorded = isTimeoutRecorded(
+			recorded.Timeout, v1beta1.TimeoutPhaseClusterStartup)
+		var msg = fmt.Sprintf(
+			"The cluster didn't start within %v seconds",
+			*timeouts.ClusterStartupSeconds)
+		if !startupRecorded {
+			timeout = newTimeoutStatus(v1beta1.TimeoutPhaseClusterStartup, msg)
+		}
+		if observed.cluster.Spec.Job != nil &&
+			(jobStatus == nil || jobStatus.State == v1beta1.JobStatePending) {
+			if jobStatus == nil {
+				jobStatus = &v1beta1.JobStatus{}
+			}
+			jobStatus.State = v1beta1.JobStateFailed
+			if !startupRecorded {
+				jobStatus.FailureReasons = appendFailureMessage(
+					jobStatus.FailureReasons, msg)
+			}
+		}
+	}
+	// The running job which is not found in the JobManager is lost.
+	if jobStatus != nil {
+		jobStatus.LostSince = getJobLostSince(
+			jobStatus, observed.flinkJobList, now)
+	}
+	// The completion time is reset when the job is restarted.
+	if jobStatus != nil {
+		if !isJobStopped(jobStatus) {
+			jobStatus.CompletionTime = ""
+		} else if len(jobStatus.CompletionTime) == 0 {
+			setTimestamp(&jobStatus.CompletionTime)
+		}
 	}
 	status.Components.Job = jobStatus
 
+	// (Optional) Idle status of a session cluster. The TaskManagers deleted
+	// after the idle timeout are not expected to be running.
+	var clusterSpec = observed.cluster.Spec
+	if clusterSpec.Job == nil && clusterSpec.IdleTimeoutSeconds != nil {
+		status.IdleSince, status.IdleTimedOut = getIdleStatus(
+			recorded,
+			*clusterSpec.IdleTimeoutSeconds,
+			observed.flinkJobList,
+			time.Now())
+		if status.IdleTimedOut && clusterSpec.IdleTimeoutAction ==
+			v1beta1.CleanupActionDeleteTaskManager {
+			totalComponents--
+		}
+	}
+
 	// Derive the new cluster state.
 	switch recorded.State {
 	case "", v1beta1.ClusterStateCreating:
-		if runningComponents < totalComponents {
+		if startupTimedOut && jobStatus != nil &&
+			jobStatus.State == v1beta1.JobStateFailed &&
+			observed.cluster.Spec.Job.CleanupPolicy.AfterJobFails !=
+				v1beta1.CleanupActionKeepCluster {
+			status.State = v1beta1.ClusterStateStopping
+		} else if runningComponents < totalComponents {
 			status.State = v1beta1.ClusterStateCreating
 		} else {
 			status.State = v1beta1.ClusterStateRunning
 		}
 	case v1beta1.ClusterStateRunning,
 		v1beta1.ClusterStateReconciling:
-		if jobStopped {
+		// The cluster of a scheduled job keeps running between the runs, and
+		// the cleanup is delayed for the TTL of the finished job.
+		var expirationTime = getJobExpirationTime(
+			observed.cluster.Spec.Job, jobStatus)
+		var cleanupDelayed = expirationTime != nil &&
+			time.Now().Before(*expirationTime)
+		if jobStopped && !isScheduledJob(observed.cluster.Spec.Job) &&
+			!cleanupDelayed {
 			var policy = observed.cluster.Spec.Job.CleanupPolicy
 			if jobSucceeded &&
 				policy.AfterJobSucceeds != v1beta1.CleanupActionKeepCluster {
@@ -501,6 +965,9 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 			} else {
 				status.State = v1beta1.ClusterStateRunning
 			}
+		} else if status.IdleTimedOut && clusterSpec.IdleTimeoutAction ==
+			v1beta1.CleanupActionDeleteCluster {
+			status.State = v1beta1.ClusterStateStopping
 		} else if runningComponents < totalComponents {
 			status.State = v1beta1.ClusterStateReconciling
 		} else {
@@ -535,7 +1002,7 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 			if observed.job == nil && status.Components.Job.State == v1beta1.JobStateCancelled {
 				controlStatus.State = v1beta1.ControlStateSucceeded
 				setTimestamp(&controlStatus.UpdateTime)
-			} else if isJobTerminated(observed.cluster.Spec.Job.RestartPolicy, recorded.Components.Job) {
+			} else if isJobTerminated(observed.cluster.Spec.Job, recorded.Components.Job) {
 				controlStatus.Message = "Aborted job cancellation: Job is terminated."
 				controlStatus.State = v1beta1.ControlStateFailed
 				setTimestamp(&controlStatus.UpdateTime)
@@ -546,6 +1013,17 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 			} else if recorded.Control.Message != "" {
 				controlStatus.State = v1beta1.ControlStateFailed
 				setTimestamp(&controlStatus.UpdateTime)
+			} else if isJobCancellationTimedOut(timeouts, recorded.Control, now) {
+				// The job keeps running when it is not cancelled in time.
+				timeout = newTimeoutStatus(
+					v1beta1.TimeoutPhaseCancellation,
+					fmt.Sprintf(
+						"The job wasn't cancelled within %v seconds",
+						*timeouts.CancellationSeconds))
+				controlStatus.Message =
+					"Aborted job cancellation: " + timeout.Message + "."
+				controlStatus.State = v1beta1.ControlStateFailed
+				setTimestamp(&controlStatus.UpdateTime)
 			}
 		case v1beta1.ControlNameSavepoint:
 			if savepointStatus != nil {
@@ -588,7 +1066,7 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 				status.Savepoint = &v1beta1.SavepointStatus{State: v1beta1.SavepointStateNotTriggered, TriggerReason: v1beta1.SavepointTriggerReasonUserRequested}
 				controlStatus = getNewUserControlStatus(userControl)
 			case v1beta1.ControlNameJobCancel:
-				if isJobTerminated(observed.cluster.Spec.Job.RestartPolicy, recorded.Components.Job) {
+				if isJobTerminated(observed.cluster.Spec.Job, recorded.Components.Job) {
 					updater.log.Info(fmt.Sprintf(v1beta1.InvalidJobStateForJobCancelMsg, v1beta1.ControlAnnotation))
 					break
 				}
@@ -610,13 +1088,203 @@ func (updater *ClusterStatusUpdater) deriveClusterStatus(
 	}
 	status.Control = controlStatus
 
+	// (Optional) The lifecycle phase which timed out.
+	if timeout != nil {
+		status.Timeout = timeout
+	} else {
+		status.Timeout = getTimeoutStatus(recorded.Timeout, &status)
+	}
+
+	// (Optional) The ResourceQuotas which the components to be created don't
+	// fit in.
+	status.InsufficientQuota = getInsufficientQuota(observed)
+
+	// Conditions, derived from the new status on every reconciliation.
+	status.Conditions = deriveClusterConditions(
+		recorded.Conditions, &status, observed.cluster.Spec.Job)
+	status.ObservedGeneration = observed.cluster.Generation
+
 	return status
 }
 
+// Derives the conditions of the cluster from the new status. The transition
+// time of a condition is kept unless its status changes.
+func deriveClusterConditions(
+	recorded []v1beta1.ClusterCondition,
+	status *v1beta1.FlinkClusterStatus,
+	jobSpec *v1beta1.JobSpec) []v1beta1.ClusterCondition {
+	var conditions = []v1beta1.ClusterCondition{
+		newClusterCondition(
+			v1beta1.ClusterConditionClusterReady,
+			status.State == v1beta1.ClusterStateRunning,
+			status.State,
+			""),
+	}
+	if jobSpec != nil {
+		var jobStatus = status.Components.Job
+		var jobState = v1beta1.JobStatePending
+		var jobMessage = ""
+		if jobStatus != nil {
+			jobState = jobStatus.State
+			if len(jobStatus.FailureReasons) > 0 {
+				jobMessage = jobStatus.FailureReasons[len(jobStatus.FailureReasons)-1]
+			}
+		}
+		conditions = append(
+			conditions,
+			newClusterCondition(
+				v1beta1.ClusterConditionJobRunning,
+				jobState == v1beta1.JobStateRunning,
+				jobState,
+				""),
+			newClusterCondition(
+				v1beta1.ClusterConditionJobFinished,
+				isJobTerminated(jobSpec, jobStatus),
+				jobState,
+				jobMessage))
+		if jobStatus != nil && len(jobStatus.LostSince) > 0 {
+			conditions = append(
+				conditions,
+				newClusterCondition(
+					v1beta1.ClusterConditionJobLost,
+					true,
+					jobState,
+					fmt.Sprintf(
+						"Job %v has not been found in the JobManager since %v",
+						jobStatus.ID, jobStatus.LostSince)))
+		}
+		if jobStatus != nil && jobStatus.Upgrade != nil {
+			conditions = append(
+				conditions,
+				newClusterCondition(
+					v1beta1.ClusterConditionJobUpgradeFailed,
+					jobStatus.Upgrade.State == v1beta1.JobUpgradeStateFailed,
+					jobStatus.Upgrade.State,
+					jobStatus.Upgrade.Message))
+		}
+	}
+	if status.Savepoint != nil {
+		conditions = append(
+			conditions,
+			newClusterCondition(
+				v1beta1.ClusterConditionSavepointComplete,
+				status.Savepoint.State == v1beta1.SavepointStateSucceeded,
+				status.Savepoint.State,
+				status.Savepoint.Message))
+	}
+	if status.Timeout != nil {
+		conditions = append(
+			conditions,
+			newClusterCondition(
+				v1beta1.ClusterConditionTimedOut,
+				true,
+				status.Timeout.Phase,
+				status.Timeout.Message))
+	}
+	if len(status.InsufficientQuota) > 0 {
+		conditions = append(
+			conditions,
+			newClusterCondition(
+				v1beta1.ClusterConditionInsufficientQuota,
+				true,
+				status.State,
+				status.InsufficientQuota))
+	}
+
+	var tc = &TimeConverter{}
+	var now = tc.ToString(time.Now())
+	for i := range conditions {
+		conditions[i].LastTransitionTime = now
+		for _, recordedCondition := range recorded {
+			if recordedCondition.Type == conditions[i].Type &&
+				recordedCondition.Status == conditions[i].Status {
+				conditions[i].LastTransitionTime =
+					recordedCondition.LastTransitionTime
+			}
+		}
+	}
+	return conditions
+}
+
+func newClusterCondition(
+	conditionType string,
+	isTrue bool,
+	reason string,
+	message string) v1beta1.ClusterCondition {
+	var condition = v1beta1.ClusterCondition{
+		Type:    conditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+	if isTrue {
+		condition.Status = corev1.ConditionTrue
+	}
+	return condition
+}
+
 // Gets Flink job ID based on the observed state and the recorded state.
 //
 // It is possible that the recorded is not nil, but the observed is, due
 // to transient error or being skiped as an optimization.
+// Updates the job status with the observed Flink job details and exceptions.
+func updateFlinkJobStatus(
+	jobStatus *v1beta1.JobStatus, observed *ObservedClusterState) {
+	var flinkJob = observed.flinkJob
+	if flinkJob != nil && flinkJob.ID == jobStatus.ID {
+		var tc = &TimeConverter{}
+		jobStatus.FlinkJobState = flinkJob.State
+		jobStatus.StartTime = tc.ToString(
+			time.Unix(0, flinkJob.StartTime*int64(time.Millisecond)))
+		jobStatus.FlinkJobRestarts = flinkJob.Restarts
+		if flinkJob.State != "FINISHED" {
+			jobStatus.Accumulators = nil
+		} else if observed.flinkJobAccumulators != nil {
+			jobStatus.Accumulators = getAccumulators(observed.flinkJobAccumulators)
+		}
+		var checkpoints = observed.flinkJobCheckpoints
+		if checkpoints != nil && checkpoints.LatestCompleted != nil {
+			var checkpoint = checkpoints.LatestCompleted
+			var completionTime = tc.ToString(
+				time.Unix(0, checkpoint.LatestAckTimestamp*int64(time.Millisecond)))
+			jobStatus.LastCompletedCheckpointID = checkpoint.ID
+			jobStatus.LastCompletedCheckpointTime = completionTime
+			jobStatus.LastCompletedCheckpointSize = checkpoint.StateSize
+			if checkpoint.IsExternalized() {
+				jobStatus.LastCheckpointLocation = checkpoint.ExternalPath
+				jobStatus.LastCheckpointTime = completionTime
+			}
+		}
+	}
+	var exceptions = observed.flinkJobExceptions
+	if exceptions != nil && len(exceptions.RootException) > 0 {
+		jobStatus.FailureReasons = appendFailureReason(
+			jobStatus.FailureReasons, exceptions)
+	}
+}
+
+// Records the submission failure of the failed job submitter in the job
+// status once, the failure is identified by its time. The error is also
+// recorded as a failure reason when there are no more retries.
+func updateJobSubmissionFailure(
+	jobStatus *v1beta1.JobStatus,
+	jobSpec *v1beta1.JobSpec,
+	submissionFailure *corev1.ContainerStateTerminated) {
+	var tc = &TimeConverter{}
+	var failureTime = tc.ToString(submissionFailure.FinishedAt.Time)
+	if jobStatus.LastSubmissionErrorTime == failureTime {
+		return
+	}
+	jobStatus.SubmissionAttempts++
+	jobStatus.LastSubmissionError = submissionFailure.Message
+	jobStatus.LastSubmissionErrorTime = failureTime
+	if !shouldRetryJobSubmission(jobSpec, jobStatus.SubmissionAttempts) {
+		jobStatus.FailureReasons = appendFailureMessage(
+			jobStatus.FailureReasons,
+			fmt.Sprintf("Failed to submit job: %v", submissionFailure.Message))
+	}
+}
+
 func (updater *ClusterStatusUpdater) getFlinkJobID() *string {
 	// Observed.
 	var observedID = updater.observed.flinkJobID
@@ -712,6 +1380,17 @@ func (updater *ClusterStatusUpdater) isStatusChanged(
 			newStatus.Components.TaskManagerDeployment)
 		changed = true
 	}
+	if !reflect.DeepEqual(
+		newStatus.Components.HistoryServer,
+		currentStatus.Components.HistoryServer) {
+		updater.log.Info(
+			"History Server status changed",
+			"current",
+			currentStatus.Components.HistoryServer,
+			"new",
+			newStatus.Components.HistoryServer)
+		changed = true
+	}
 	if currentStatus.Components.Job == nil {
 		if newStatus.Components.Job != nil {
 			updater.log.Info(
@@ -747,6 +1426,40 @@ func (updater *ClusterStatusUpdater) isStatusChanged(
 			newStatus.Savepoint)
 		changed = true
 	}
+	if !reflect.DeepEqual(newStatus.Autoscaler, currentStatus.Autoscaler) {
+		updater.log.Info(
+			"Autoscaler status changed", "current",
+			currentStatus.Autoscaler,
+			"new",
+			newStatus.Autoscaler)
+		changed = true
+	}
+	if newStatus.IdleSince != currentStatus.IdleSince ||
+		newStatus.IdleTimedOut != currentStatus.IdleTimedOut {
+		updater.log.Info(
+			"Idle status changed",
+			"currentIdleSince", currentStatus.IdleSince,
+			"currentIdleTimedOut", currentStatus.IdleTimedOut,
+			"newIdleSince", newStatus.IdleSince,
+			"newIdleTimedOut", newStatus.IdleTimedOut)
+		changed = true
+	}
+	if !reflect.DeepEqual(newStatus.Conditions, currentStatus.Conditions) {
+		updater.log.Info(
+			"Conditions changed", "current",
+			currentStatus.Conditions,
+			"new",
+			newStatus.Conditions)
+		changed = true
+	}
+	if newStatus.ObservedGeneration != currentStatus.ObservedGeneration {
+		updater.log.Info(
+			"Observed generation changed", "current",
+			currentStatus.ObservedGeneration,
+			"new",
+			newStatus.ObservedGeneration)
+		changed = true
+	}
 	return changed
 }
 
@@ -784,9 +1497,74 @@ func (updater *ClusterStatusUpdater) clearControlAnnotation(newControlStatus *v1
 	return nil
 }
 
+// Appends the root exception of the last failure to the failure reasons if it
+// is a new one, only the most recent reasons are kept.
+func appendFailureReason(
+	reasons []string, exceptions *flinkclient.JobExceptions) []string {
+	var tc = &TimeConverter{}
+	var reason = fmt.Sprintf(
+		"%s: %s",
+		tc.ToString(time.Unix(0, exceptions.Timestamp*int64(time.Millisecond))),
+		exceptions.RootException)
+	// limit message size to 1KiB
+	if len(reason) > 1024 {
+		reason = reason[:1024] + "..."
+	}
+	if len(reasons) > 0 && reasons[len(reasons)-1] == reason {
+		return reasons
+	}
+	reasons = append(reasons, reason)
+	if len(reasons) > maxFailureReasons {
+		reasons = reasons[len(reasons)-maxFailureReasons:]
+	}
+	return reasons
+}
+
+// Gets the accumulators to record in the job status by their names, the ones
+// with the first names are kept and the long values are truncated.
+func getAccumulators(
+	accumulators []flinkclient.Accumulator) map[string]string {
+	var names []string
+	var values = map[string]string{}
+	for _, accumulator := range accumulators {
+		var value = accumulator.Value
+		if len(value) > maxAccumulatorValueLength {
+			value = value[:maxAccumulatorValueLength] + "..."
+		}
+		if _, ok := values[accumulator.Name]; !ok {
+			names = append(names, accumulator.Name)
+		}
+		values[accumulator.Name] = value
+	}
+	sort.Strings(names)
+	if len(names) > maxAccumulators {
+		for _, name := range names[maxAccumulators:] {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// Appends an error message which is not from Flink to the failure reasons.
+func appendFailureMessage(reasons []string, message string) []string {
+	return appendFailureReason(
+		reasons,
+		&flinkclient.JobExceptions{
+			RootException: message,
+			Timestamp:     time.Now().UnixNano() / int64(time.Millisecond),
+		})
+}
+
 func getDeploymentState(deployment *appsv1.Deployment) string {
 	if deployment.Status.AvailableReplicas >= *deployment.Spec.Replicas {
 		return v1beta1.ComponentStateReady
 	}
 	return v1beta1.ComponentStateNotReady
 }
+
+func getStatefulSetState(statefulSet *appsv1.StatefulSet) string {
+	if statefulSet.Status.ReadyReplicas >= *statefulSet.Spec.Replicas {
+		return v1beta1.ComponentStateReady
+	}
+	return v1beta1.ComponentStateNotReady
+}
@@ -17,11 +17,18 @@ limitations under the License.
 package controllers
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
 	"gotest.tools/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -46,6 +53,20 @@ func TestGetDeploymentStateReady(t *testing.T) {
 	assert.Assert(t, state == v1beta1.ComponentStateReady)
 }
 
+func TestGetStatefulSetState(t *testing.T) {
+	var replicas int32 = 3
+	var statefulSet = appsv1.StatefulSet{
+		Spec:   appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: 2},
+	}
+	assert.Equal(
+		t, getStatefulSetState(&statefulSet), v1beta1.ComponentStateNotReady)
+
+	statefulSet.Status.ReadyReplicas = 3
+	assert.Equal(
+		t, getStatefulSetState(&statefulSet), v1beta1.ComponentStateReady)
+}
+
 func TestIsStatusChangedFalse(t *testing.T) {
 	var oldStatus = v1beta1.FlinkClusterStatus{}
 	var newStatus = v1beta1.FlinkClusterStatus{}
@@ -56,11 +77,11 @@ func TestIsStatusChangedFalse(t *testing.T) {
 func TestIsStatusChangedTrue(t *testing.T) {
 	var oldStatus = v1beta1.FlinkClusterStatus{
 		Components: v1beta1.FlinkClusterComponentsStatus{
-			JobManagerDeployment: v1beta1.FlinkClusterComponentState{
+			JobManagerDeployment: v1beta1.JobManagerDeploymentStatus{
 				Name:  "my-jobmanager",
 				State: "NotReady",
 			},
-			TaskManagerDeployment: v1beta1.FlinkClusterComponentState{
+			TaskManagerDeployment: v1beta1.TaskManagerDeploymentStatus{
 				Name:  "my-taskmanager",
 				State: "NotReady",
 			},
@@ -80,11 +101,11 @@ func TestIsStatusChangedTrue(t *testing.T) {
 		State: "Creating"}
 	var newStatus = v1beta1.FlinkClusterStatus{
 		Components: v1beta1.FlinkClusterComponentsStatus{
-			JobManagerDeployment: v1beta1.FlinkClusterComponentState{
+			JobManagerDeployment: v1beta1.JobManagerDeploymentStatus{
 				Name:  "my-jobmanager",
 				State: "Ready",
 			},
-			TaskManagerDeployment: v1beta1.FlinkClusterComponentState{
+			TaskManagerDeployment: v1beta1.TaskManagerDeploymentStatus{
 				Name:  "my-taskmanager",
 				State: "Ready",
 			},
@@ -106,3 +127,225 @@ func TestIsStatusChangedTrue(t *testing.T) {
 	var updater = &ClusterStatusUpdater{log: log.Log}
 	assert.Assert(t, updater.isStatusChanged(oldStatus, newStatus))
 }
+
+func TestAppendFailureReason(t *testing.T) {
+	var tc = &TimeConverter{}
+	var timestamp int64 = 1577836800000
+	var prefix = tc.ToString(time.Unix(0, timestamp*int64(time.Millisecond)))
+	var exceptions = flinkclient.JobExceptions{
+		RootException: "java.lang.RuntimeException: boom",
+		Timestamp:     timestamp,
+	}
+
+	var reasons = appendFailureReason(nil, &exceptions)
+	assert.DeepEqual(
+		t, reasons, []string{prefix + ": java.lang.RuntimeException: boom"})
+
+	// The same failure is not appended again.
+	reasons = appendFailureReason(reasons, &exceptions)
+	assert.Equal(t, len(reasons), 1)
+
+	// Only the most recent reasons are kept.
+	for i := 1; i <= maxFailureReasons; i++ {
+		exceptions.Timestamp = timestamp + int64(i)*1000
+		reasons = appendFailureReason(reasons, &exceptions)
+	}
+	assert.Equal(t, len(reasons), maxFailureReasons)
+	assert.Equal(
+		t,
+		reasons[maxFailureReasons-1],
+		tc.ToString(time.Unix(0, exceptions.Timestamp*int64(time.Millisecond)))+
+			": java.lang.RuntimeException: boom")
+}
+
+func TestUpdateJobSubmissionFailure(t *testing.T) {
+	var maxRetries int32 = 1
+	var jobSpec = v1beta1.JobSpec{MaxRetries: &maxRetries}
+	var jobStatus = v1beta1.JobStatus{State: v1beta1.JobStatePending}
+	var failure = corev1.ContainerStateTerminated{
+		ExitCode: jobSubmissionFailureExitCode,
+		Message:  "java.net.UnknownHostException: my-cluster-jobmanager",
+		FinishedAt: metav1.NewTime(
+			time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)),
+	}
+
+	updateJobSubmissionFailure(&jobStatus, &jobSpec, &failure)
+	assert.Equal(t, jobStatus.SubmissionAttempts, int32(1))
+	assert.Equal(
+		t,
+		jobStatus.LastSubmissionError,
+		"java.net.UnknownHostException: my-cluster-jobmanager")
+	assert.Equal(t, jobStatus.LastSubmissionErrorTime, "2020-03-01T12:00:00Z")
+	assert.Equal(t, len(jobStatus.FailureReasons), 0)
+
+	// The same failure is not recorded again.
+	updateJobSubmissionFailure(&jobStatus, &jobSpec, &failure)
+	assert.Equal(t, jobStatus.SubmissionAttempts, int32(1))
+
+	// The retries are exhausted.
+	failure.FinishedAt = metav1.NewTime(
+		time.Date(2020, 3, 1, 12, 1, 0, 0, time.UTC))
+	updateJobSubmissionFailure(&jobStatus, &jobSpec, &failure)
+	assert.Equal(t, jobStatus.SubmissionAttempts, int32(2))
+	assert.Equal(t, jobStatus.LastSubmissionErrorTime, "2020-03-01T12:01:00Z")
+	assert.Equal(t, len(jobStatus.FailureReasons), 1)
+}
+
+func TestUpdateFlinkJobStatusCheckpoints(t *testing.T) {
+	var jobStatus = v1beta1.JobStatus{ID: "abc"}
+	var observed = ObservedClusterState{
+		flinkJob: &flinkclient.JobDetails{ID: "abc", State: "RUNNING"},
+		flinkJobCheckpoints: &flinkclient.CheckpointStats{
+			LatestCompleted: &flinkclient.Checkpoint{
+				ID:                 7,
+				Status:             "COMPLETED",
+				ExternalPath:       "<checkpoint-not-externalized>",
+				LatestAckTimestamp: 1583064000000,
+				StateSize:          1024,
+			},
+		},
+	}
+
+	// The checkpoint which is not externalized is not recorded as the
+	// restore location.
+	updateFlinkJobStatus(&jobStatus, &observed)
+	assert.Equal(t, jobStatus.LastCompletedCheckpointID, int64(7))
+	assert.Equal(t, jobStatus.LastCompletedCheckpointTime, "2020-03-01T12:00:00Z")
+	assert.Equal(t, jobStatus.LastCompletedCheckpointSize, int64(1024))
+	assert.Equal(t, jobStatus.LastCheckpointLocation, "")
+
+	observed.flinkJobCheckpoints.LatestCompleted.ExternalPath = "gs://chk-7"
+	updateFlinkJobStatus(&jobStatus, &observed)
+	assert.Equal(t, jobStatus.LastCheckpointLocation, "gs://chk-7")
+	assert.Equal(t, jobStatus.LastCheckpointTime, "2020-03-01T12:00:00Z")
+}
+
+func TestGetAccumulators(t *testing.T) {
+	var accumulators []flinkclient.Accumulator
+	for i := 0; i < maxAccumulators+2; i++ {
+		accumulators = append(accumulators, flinkclient.Accumulator{
+			Name:  fmt.Sprintf("counter-%02d", i),
+			Type:  "LongCounter",
+			Value: strconv.Itoa(i),
+		})
+	}
+	accumulators[0].Value = strings.Repeat("x", maxAccumulatorValueLength+1)
+
+	var values = getAccumulators(accumulators)
+	assert.Equal(t, len(values), maxAccumulators)
+	assert.Equal(
+		t, values["counter-00"], strings.Repeat("x", maxAccumulatorValueLength)+"...")
+	assert.Equal(t, values["counter-31"], "31")
+	var _, ok = values["counter-32"]
+	assert.Assert(t, !ok)
+}
+
+func TestUpdateFlinkJobStatusAccumulators(t *testing.T) {
+	var jobStatus = v1beta1.JobStatus{ID: "abc"}
+	var observed = ObservedClusterState{
+		flinkJob: &flinkclient.JobDetails{ID: "abc", State: "FINISHED"},
+		flinkJobAccumulators: []flinkclient.Accumulator{
+			{Name: "records", Type: "LongCounter", Value: "1000"},
+		},
+	}
+	updateFlinkJobStatus(&jobStatus, &observed)
+	assert.DeepEqual(
+		t, jobStatus.Accumulators, map[string]string{"records": "1000"})
+
+	// The recorded accumulators are kept after they are fetched once.
+	observed.flinkJobAccumulators = nil
+	updateFlinkJobStatus(&jobStatus, &observed)
+	assert.DeepEqual(
+		t, jobStatus.Accumulators, map[string]string{"records": "1000"})
+
+	// The accumulators of the previous run are cleared when it is restarted.
+	observed.flinkJob.State = "RUNNING"
+	updateFlinkJobStatus(&jobStatus, &observed)
+	assert.Assert(t, jobStatus.Accumulators == nil)
+}
+
+func TestDeriveClusterConditions(t *testing.T) {
+	var jobSpec = v1beta1.JobSpec{}
+	var status = v1beta1.FlinkClusterStatus{
+		State: v1beta1.ClusterStateRunning,
+		Components: v1beta1.FlinkClusterComponentsStatus{
+			Job: &v1beta1.JobStatus{State: v1beta1.JobStateRunning},
+		},
+	}
+	var recorded = []v1beta1.ClusterCondition{
+		{
+			Type:               v1beta1.ClusterConditionClusterReady,
+			Status:             corev1.ConditionTrue,
+			Reason:             v1beta1.ClusterStateRunning,
+			LastTransitionTime: "2020-03-01T12:00:00Z",
+		},
+		{
+			Type:               v1beta1.ClusterConditionJobRunning,
+			Status:             corev1.ConditionFalse,
+			Reason:             v1beta1.JobStatePending,
+			LastTransitionTime: "2020-03-01T12:00:00Z",
+		},
+	}
+
+	var conditions = deriveClusterConditions(recorded, &status, &jobSpec)
+	assert.Equal(t, len(conditions), 3)
+
+	// Unchanged status keeps the transition time.
+	assert.Equal(t, conditions[0].Type, v1beta1.ClusterConditionClusterReady)
+	assert.Equal(t, conditions[0].Status, corev1.ConditionTrue)
+	assert.Equal(t, conditions[0].LastTransitionTime, "2020-03-01T12:00:00Z")
+
+	// Changed status gets a new transition time.
+	assert.Equal(t, conditions[1].Type, v1beta1.ClusterConditionJobRunning)
+	assert.Equal(t, conditions[1].Status, corev1.ConditionTrue)
+	assert.Equal(t, conditions[1].Reason, v1beta1.JobStateRunning)
+	assert.Assert(t, conditions[1].LastTransitionTime != "2020-03-01T12:00:00Z")
+
+	assert.Equal(t, conditions[2].Type, v1beta1.ClusterConditionJobFinished)
+	assert.Equal(t, conditions[2].Status, corev1.ConditionFalse)
+}
+
+func TestDeriveClusterConditionsTimedOut(t *testing.T) {
+	var status = v1beta1.FlinkClusterStatus{
+		State: v1beta1.ClusterStateCreating,
+		Timeout: &v1beta1.TimeoutStatus{
+			Phase:   v1beta1.TimeoutPhaseClusterStartup,
+			Message: "The cluster didn't start within 600 seconds",
+		},
+	}
+
+	var conditions = deriveClusterConditions(nil, &status, nil)
+	assert.Equal(t, len(conditions), 2)
+	assert.Equal(t, conditions[1].Type, v1beta1.ClusterConditionTimedOut)
+	assert.Equal(t, conditions[1].Status, corev1.ConditionTrue)
+	assert.Equal(t, conditions[1].Reason, v1beta1.TimeoutPhaseClusterStartup)
+	assert.Equal(
+		t, conditions[1].Message, "The cluster didn't start within 600 seconds")
+}
+
+func TestDeriveClusterConditionsJobLost(t *testing.T) {
+	var status = v1beta1.FlinkClusterStatus{
+		State: v1beta1.ClusterStateRunning,
+		Components: v1beta1.FlinkClusterComponentsStatus{
+			Job: &v1beta1.JobStatus{
+				ID:        "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+				State:     v1beta1.JobStateRunning,
+				LostSince: "2020-01-01T00:00:00Z",
+			},
+		},
+	}
+
+	var conditions = deriveClusterConditions(nil, &status, &v1beta1.JobSpec{})
+	assert.Equal(t, len(conditions), 4)
+	assert.Equal(t, conditions[3].Type, v1beta1.ClusterConditionJobLost)
+	assert.Equal(t, conditions[3].Status, corev1.ConditionTrue)
+	assert.Equal(
+		t,
+		conditions[3].Message,
+		"Job ec22ad0e0e7c0d0c5d2dbd5ab7a86f69 has not been found in the JobManager since 2020-01-01T00:00:00Z")
+
+	// Removed when the job is found again.
+	status.Components.Job.LostSince = ""
+	conditions = deriveClusterConditions(conditions, &status, &v1beta1.JobSpec{})
+	assert.Equal(t, len(conditions), 3)
+}
@@ -23,7 +23,11 @@ import (
 	"time"
 
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
 	batchv1 "k8s.io/api/batch/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -33,6 +37,16 @@ const (
 	ControlMaxRetries         = "3"
 
 	SavepointTimeoutSec = 60
+
+	// The job upgrade fails after this number of failed savepoints.
+	MaxJobUpgradeSavepointFailures = 3
+
+	// The maximum backoff of retrying the failed savepoints.
+	maxSavepointRetryBackoff = 300 * time.Second
+
+	// The backoff of retrying the failed deletion of the expired savepoints.
+	savepointDeletionBackoff    = 10 * time.Second
+	maxSavepointDeletionBackoff = 10 * time.Minute
 )
 
 type objectForPatch struct {
@@ -45,13 +59,70 @@ type objectMetaForPatch struct {
 }
 
 func getFlinkAPIBaseURL(cluster *v1beta1.FlinkCluster) string {
+	return getJobManagerServiceURL(cluster, getFlinkAPIServiceName(cluster))
+}
+
+// Gets the URL of the UI port of a JobManager service inside the Kubernetes
+// cluster.
+func getJobManagerServiceURL(
+	cluster *v1beta1.FlinkCluster, serviceName string) string {
 	return fmt.Sprintf(
-		"http://%s.%s.svc.cluster.local:%d",
-		getJobManagerServiceName(cluster.ObjectMeta.Name),
+		"%s://%s.%s.svc.cluster.local:%d",
+		getFlinkAPIScheme(cluster),
+		serviceName,
 		cluster.ObjectMeta.Namespace,
 		*cluster.Spec.JobManager.Ports.UI)
 }
 
+// Gets the name of the service which the operator and the job submitter
+// connect to the REST API through, the internal REST service if the UI port of
+// the JobManager service is routed to the auth proxy.
+func getFlinkAPIServiceName(cluster *v1beta1.FlinkCluster) string {
+	if isUIAuthProxyEnabled(cluster) {
+		return getJobManagerRESTServiceName(cluster.ObjectMeta.Name)
+	}
+	return getJobManagerServiceName(cluster.ObjectMeta.Name)
+}
+
+// Gets the scheme of the Flink REST API, HTTPS if its TLS is enabled.
+func getFlinkAPIScheme(cluster *v1beta1.FlinkCluster) string {
+	if isRESTTLSEnabled(cluster) {
+		return "https"
+	}
+	return "http"
+}
+
+// Gets the URL of the Flink web UI, the first URL of the JobManager ingress,
+// the load balancer address of the JobManager service, or the REST API URL
+// inside the Kubernetes cluster.
+func getFlinkUIURL(
+	cluster *v1beta1.FlinkCluster,
+	jmService *corev1.Service,
+	jmIngress *v1beta1.JobManagerIngressStatus) string {
+	if jmIngress != nil && len(jmIngress.URLs) > 0 {
+		return jmIngress.URLs[0]
+	}
+	if jmService.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ingress := range jmService.Status.LoadBalancer.Ingress {
+			var addr = ingress.Hostname
+			if addr == "" {
+				addr = ingress.IP
+			}
+			if addr == "" {
+				continue
+			}
+			for _, port := range jmService.Spec.Ports {
+				if port.Name == "ui" {
+					return fmt.Sprintf(
+						"%s://%s:%d", getFlinkAPIScheme(cluster), addr, port.Port)
+				}
+			}
+		}
+	}
+	return getJobManagerServiceURL(
+		cluster, getJobManagerServiceName(cluster.ObjectMeta.Name))
+}
+
 // Gets JobManager ingress name
 func getConfigMapName(clusterName string) string {
 	return clusterName + "-configmap"
@@ -67,6 +138,12 @@ func getJobManagerServiceName(clusterName string) string {
 	return clusterName + "-jobmanager"
 }
 
+// Gets the name of the internal service of the JobManager REST API, which
+// bypasses the UI auth proxy.
+func getJobManagerRESTServiceName(clusterName string) string {
+	return clusterName + "-jobmanager-rest"
+}
+
 // Gets JobManager ingress name
 func getJobManagerIngressName(clusterName string) string {
 	return clusterName + "-jobmanager"
@@ -77,11 +154,65 @@ func getTaskManagerDeploymentName(clusterName string) string {
 	return clusterName + "-taskmanager"
 }
 
+// Gets the name of the headless service of a StatefulSet.
+func getHeadlessServiceName(statefulSetName string) string {
+	return statefulSetName + "-headless"
+}
+
+// Checks whether the JobManagers are deployed as a StatefulSet.
+func isJobManagerStatefulSet(jmSpec *v1beta1.JobManagerSpec) bool {
+	return jmSpec.DeploymentType != nil &&
+		*jmSpec.DeploymentType == v1beta1.DeploymentTypeStatefulSet
+}
+
+// Checks whether the TaskManagers are deployed as a StatefulSet.
+func isTaskManagerStatefulSet(tmSpec *v1beta1.TaskManagerSpec) bool {
+	return tmSpec.DeploymentType != nil &&
+		*tmSpec.DeploymentType == v1beta1.DeploymentTypeStatefulSet
+}
+
 // Gets Job name
 func getJobName(clusterName string) string {
 	return clusterName + "-job"
 }
 
+// Gets the name of the ServiceAccount, Role and RoleBinding for HA
+func getHAName(clusterName string) string {
+	return clusterName + "-ha"
+}
+
+// Gets PodMonitor name
+func getPodMonitorName(clusterName string) string {
+	return clusterName + "-flink-metrics"
+}
+
+// Gets PodGroup name
+func getPodGroupName(clusterName string) string {
+	return clusterName + "-flink-podgroup"
+}
+
+// Gets the name of the History Server deployment, service and ingress
+func getHistoryServerName(clusterName string) string {
+	return clusterName + "-historyserver"
+}
+
+// Gets the name of the Secret of the internal TLS generated by the operator
+func getGeneratedInternalTLSSecretName(clusterName string) string {
+	return clusterName + "-internal-tls"
+}
+
+// Gets the name of the cert-manager Certificate of the REST API and its
+// Secret.
+func getRESTTLSCertificateName(clusterName string) string {
+	return clusterName + "-rest-tls"
+}
+
+// Gets the name of the Secret of the keystore password of the REST API
+// certificate generated by the operator.
+func getGeneratedRESTTLSPasswordSecretName(clusterName string) string {
+	return clusterName + "-rest-tls-password"
+}
+
 // TimeConverter converts between time.Time and string.
 type TimeConverter struct{}
 
@@ -110,13 +241,194 @@ func setTimestamp(target *string) {
 // shouldRestartJob returns true if the controller should restart the failed
 // job.
 func shouldRestartJob(
-	restartPolicy *v1beta1.JobRestartPolicy,
+	jobSpec *v1beta1.JobSpec,
 	jobStatus *v1beta1.JobStatus) bool {
-	return restartPolicy != nil &&
-		*restartPolicy == v1beta1.JobRestartPolicyFromSavepointOnFailure &&
+	return jobSpec != nil &&
+		jobSpec.RestartPolicy != nil &&
+		*jobSpec.RestartPolicy == v1beta1.JobRestartPolicyFromSavepointOnFailure &&
 		jobStatus != nil &&
 		jobStatus.State == v1beta1.JobStateFailed &&
-		len(jobStatus.SavepointLocation) > 0
+		len(getRestoreLocation(jobSpec, jobStatus, time.Now())) > 0
+}
+
+// getRestoreLocation returns the latest one of the externalized checkpoint and
+// the savepoint recorded in the job status, or empty if there is none or it is
+// older than `maxStateAgeToRestoreSeconds`.
+func getRestoreLocation(
+	jobSpec *v1beta1.JobSpec,
+	jobStatus *v1beta1.JobStatus,
+	now time.Time) string {
+	var location string
+	var stateTime time.Time
+	if len(jobStatus.SavepointLocation) > 0 {
+		location = jobStatus.SavepointLocation
+		stateTime, _ = time.Parse(time.RFC3339, jobStatus.LastSavepointTime)
+	}
+	if len(jobStatus.LastCheckpointLocation) > 0 {
+		var checkpointTime, _ = time.Parse(
+			time.RFC3339, jobStatus.LastCheckpointTime)
+		if len(location) == 0 || checkpointTime.After(stateTime) {
+			location = jobStatus.LastCheckpointLocation
+			stateTime = checkpointTime
+		}
+	}
+	if len(location) > 0 && jobSpec.MaxStateAgeToRestoreSeconds != nil {
+		var maxAge = time.Duration(*jobSpec.MaxStateAgeToRestoreSeconds) * time.Second
+		if now.Sub(stateTime) > maxAge {
+			return ""
+		}
+	}
+	return location
+}
+
+// Checks whether the failed job submission is retried, the job stays pending
+// until the retries are exhausted.
+func shouldRetryJobSubmission(
+	jobSpec *v1beta1.JobSpec, submissionAttempts int32) bool {
+	return jobSpec.MaxRetries != nil && submissionAttempts <= *jobSpec.MaxRetries
+}
+
+// Gets the failure message of a failed container of the job submitter from its
+// termination message, which is the last lines of its logs unless the
+// container writes the message itself.
+func getJobSubmitterFailureMessage(
+	containerName string, terminated *corev1.ContainerStateTerminated) string {
+	var msg = fmt.Sprintf(
+		"container %q exited with code %d", containerName, terminated.ExitCode)
+	if len(terminated.Reason) > 0 {
+		msg += fmt.Sprintf(" (%v)", terminated.Reason)
+	}
+	if len(terminated.Message) > 0 {
+		msg += ": " + terminated.Message
+	}
+	return msg
+}
+
+// Checks whether enough task slots are available for the job to be submitted,
+// always true unless `readySlotsRequired` is specified.
+func hasReadySlots(
+	jobSpec *v1beta1.JobSpec, overview *flinkclient.ClusterOverview) bool {
+	if jobSpec == nil || jobSpec.ReadySlotsRequired == nil {
+		return true
+	}
+	return overview != nil &&
+		overview.SlotsAvailable >= *jobSpec.ReadySlotsRequired
+}
+
+// Gets the idle status of a session cluster from the observed Flink jobs. The
+// cluster is idle since no job was found active, and times out after
+// `timeoutSeconds`. The recorded status is kept when the jobs are unknown.
+func getIdleStatus(
+	recorded *v1beta1.FlinkClusterStatus,
+	timeoutSeconds int32,
+	jobList *flinkclient.JobStatusList,
+	now time.Time) (idleSince string, timedOut bool) {
+	if jobList == nil {
+		return recorded.IdleSince, recorded.IdleTimedOut
+	}
+	for _, job := range jobList.Jobs {
+		if getJobStateFromFlinkJobState(job.Status) == v1beta1.JobStateRunning {
+			return "", false
+		}
+	}
+	var tc = &TimeConverter{}
+	if len(recorded.IdleSince) == 0 {
+		return tc.ToString(now), false
+	}
+	var timeoutTime = tc.FromString(recorded.IdleSince).Add(
+		time.Duration(timeoutSeconds) * time.Second)
+	return recorded.IdleSince, recorded.IdleTimedOut || !now.Before(timeoutTime)
+}
+
+// Checks whether the job is waiting for the retry of its failed submission.
+func isJobSubmissionRetryPending(jobStatus *v1beta1.JobStatus) bool {
+	return jobStatus != nil &&
+		jobStatus.State == v1beta1.JobStatePending &&
+		len(jobStatus.LastSubmissionErrorTime) > 0
+}
+
+// Gets the time to retry the failed job submission. The backoff doubles for
+// each failed attempt up to `maxRetryBackoffSeconds`.
+func getJobSubmissionRetryTime(
+	jobSpec *v1beta1.JobSpec, jobStatus *v1beta1.JobStatus) time.Time {
+	var tc = &TimeConverter{}
+	var backoff = time.Duration(*jobSpec.RetryBackoffSeconds) * time.Second
+	var maxBackoff = time.Duration(*jobSpec.MaxRetryBackoffSeconds) * time.Second
+	for i := int32(1); i < jobStatus.SubmissionAttempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return tc.FromString(jobStatus.LastSubmissionErrorTime).Add(backoff)
+}
+
+// Checks whether the savepoint which failed or didn't complete in time can be
+// retried, i.e., `savepointMaxRetries` is not reached.
+func shouldRetrySavepoint(
+	jobSpec *v1beta1.JobSpec, savepointStatus *v1beta1.SavepointStatus) bool {
+	return jobSpec != nil && jobSpec.SavepointMaxRetries != nil &&
+		savepointStatus.Retries < *jobSpec.SavepointMaxRetries
+}
+
+// Gets the status of the retry of the failed savepoint, which is triggered
+// again for the same reason after the backoff. The backoff doubles for each
+// retry up to `maxSavepointRetryBackoff`.
+func getSavepointRetryStatus(
+	jobSpec *v1beta1.JobSpec,
+	failed *v1beta1.SavepointStatus,
+	now time.Time) *v1beta1.SavepointStatus {
+	var backoff = time.Duration(*jobSpec.SavepointRetryBackoffSeconds) * time.Second
+	for i := int32(0); i < failed.Retries && backoff < maxSavepointRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxSavepointRetryBackoff {
+		backoff = maxSavepointRetryBackoff
+	}
+	var tc = &TimeConverter{}
+	return &v1beta1.SavepointStatus{
+		JobID:         failed.JobID,
+		TriggerReason: failed.TriggerReason,
+		State:         v1beta1.SavepointStateNotTriggered,
+		Message:       failed.Message,
+		Retries:       failed.Retries + 1,
+		RetryTime:     tc.ToString(now.Add(backoff)),
+	}
+}
+
+// Checks whether the failed savepoint is waiting for the backoff before it is
+// triggered again.
+func isSavepointRetryPending(
+	savepointStatus *v1beta1.SavepointStatus, now time.Time) bool {
+	if savepointStatus == nil ||
+		savepointStatus.State != v1beta1.SavepointStateNotTriggered ||
+		len(savepointStatus.RetryTime) == 0 {
+		return false
+	}
+	var tc = &TimeConverter{}
+	return now.Before(tc.FromString(savepointStatus.RetryTime))
+}
+
+// Gets the time to retry deleting the expired savepoints after a failure, or
+// nil if the last deletion didn't fail. The backoff doubles for each
+// consecutive failure up to `maxSavepointDeletionBackoff`.
+func getSavepointDeletionRetryTime(jobStatus *v1beta1.JobStatus) *time.Time {
+	if jobStatus == nil || jobStatus.SavepointDeletionFailures == 0 ||
+		len(jobStatus.LastSavepointDeletionFailureTime) == 0 {
+		return nil
+	}
+	var tc = &TimeConverter{}
+	var backoff = savepointDeletionBackoff
+	for i := int32(1); i < jobStatus.SavepointDeletionFailures &&
+		backoff < maxSavepointDeletionBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxSavepointDeletionBackoff {
+		backoff = maxSavepointDeletionBackoff
+	}
+	var retryTime = tc.FromString(
+		jobStatus.LastSavepointDeletionFailureTime).Add(backoff)
+	return &retryTime
 }
 
 func getFromSavepoint(jobSpec batchv1.JobSpec) string {
@@ -169,16 +481,25 @@ func getNewSavepointStatus(jobID string, triggerID string, triggerReason string,
 	return savepointStatus
 }
 
-func savepointTimeout(s *v1beta1.SavepointStatus) bool {
+func savepointTimeout(s *v1beta1.SavepointStatus, timeoutSec int32) bool {
 	if s.TriggerTime == "" {
 		return false
 	}
 	tc := &TimeConverter{}
 	triggerTime := tc.FromString(s.TriggerTime)
-	validTime := triggerTime.Add(time.Duration(int64(SavepointTimeoutSec) * int64(time.Second)))
+	validTime := triggerTime.Add(time.Duration(int64(timeoutSec) * int64(time.Second)))
 	return time.Now().After(validTime)
 }
 
+// Gets the percentage of the subtasks which have acknowledged the checkpoint
+// or savepoint.
+func getCheckpointProgressPercent(checkpoint *flinkclient.Checkpoint) int32 {
+	if checkpoint.NumSubtasks <= 0 {
+		return 0
+	}
+	return checkpoint.NumAcknowledgedSubtasks * 100 / checkpoint.NumSubtasks
+}
+
 func getControlEvent(status v1beta1.FlinkClusterControlStatus) (eventType string, eventReason string, eventMessage string) {
 	var msg = status.Message
 	if len(msg) > 100 {
@@ -227,6 +548,14 @@ func getSavepointEvent(status v1beta1.SavepointStatus) (eventType string, eventR
 		eventType = corev1.EventTypeWarning
 		eventReason = "SavepointFailed"
 		eventMessage = fmt.Sprintf("Savepoint creation failed: %v", msg)
+	case v1beta1.SavepointStateNotTriggered:
+		if status.Retries > 0 {
+			eventType = corev1.EventTypeWarning
+			eventReason = "SavepointRetrying"
+			eventMessage = fmt.Sprintf(
+				"Retrying savepoint %v at %v, retry %v: %v",
+				status.TriggerReason, status.RetryTime, status.Retries, msg)
+		}
 	}
 	return
 }
@@ -238,11 +567,302 @@ func isJobStopped(status *v1beta1.JobStatus) bool {
 			status.State == v1beta1.JobStateCancelled)
 }
 
-func isJobTerminated(restartPolicy *v1beta1.JobRestartPolicy, jobStatus *v1beta1.JobStatus) bool {
-	return isJobStopped(jobStatus) && !shouldRestartJob(restartPolicy, jobStatus)
+// Gets the time when the cleanup action is taken after the job finished, nil
+// if the job is not finished or its cleanup is not delayed.
+func getJobExpirationTime(
+	jobSpec *v1beta1.JobSpec, jobStatus *v1beta1.JobStatus) *time.Time {
+	if jobSpec == nil || jobSpec.TTLSecondsAfterFinished == nil ||
+		!isJobStopped(jobStatus) || len(jobStatus.CompletionTime) == 0 {
+		return nil
+	}
+	var tc = &TimeConverter{}
+	var expirationTime = tc.FromString(jobStatus.CompletionTime).Add(
+		time.Duration(*jobSpec.TTLSecondsAfterFinished) * time.Second)
+	return &expirationTime
+}
+
+// Records the location of a new savepoint in the savepoint history of the job
+// when `savepointHistoryLimit` is specified.
+func appendSavepointHistory(
+	jobSpec *v1beta1.JobSpec, jobStatus *v1beta1.JobStatus, location string) {
+	if jobSpec == nil || jobSpec.SavepointHistoryLimit == nil ||
+		len(location) == 0 {
+		return
+	}
+	var history = jobStatus.SavepointHistory
+	if len(history) > 0 && history[len(history)-1] == location {
+		return
+	}
+	jobStatus.SavepointHistory = append(history, location)
+}
+
+// Gets the locations of the savepoints exceeding `savepointHistoryLimit` from
+// the oldest, which are deleted by the operator.
+func getExpiredSavepoints(
+	jobSpec *v1beta1.JobSpec, jobStatus *v1beta1.JobStatus) []string {
+	if jobSpec == nil || jobSpec.SavepointHistoryLimit == nil ||
+		jobStatus == nil {
+		return nil
+	}
+	var numExpired = len(jobStatus.SavepointHistory) -
+		int(*jobSpec.SavepointHistoryLimit)
+	if numExpired <= 0 {
+		return nil
+	}
+	return jobStatus.SavepointHistory[:numExpired]
+}
+
+func isJobTerminated(jobSpec *v1beta1.JobSpec, jobStatus *v1beta1.JobStatus) bool {
+	return isJobStopped(jobStatus) && !shouldRestartJob(jobSpec, jobStatus)
+}
+
+// Checks whether the job is submitted through the Flink REST API by the
+// operator instead of a job submitter.
+func isRestAPISubmission(jobSpec *v1beta1.JobSpec) bool {
+	return jobSpec != nil && jobSpec.SubmissionMode != nil &&
+		*jobSpec.SubmissionMode == v1beta1.JobSubmissionModeRestAPI
+}
+
+// Checks whether the reconciliation of the cluster is paused by the user.
+func isReconciliationPaused(cluster *v1beta1.FlinkCluster) bool {
+	return cluster.Annotations[v1beta1.PausedAnnotation] == "true"
+}
+
+// Checks whether the job is a batch job which runs on a schedule.
+func isScheduledJob(jobSpec *v1beta1.JobSpec) bool {
+	return jobSpec != nil && jobSpec.Schedule != nil
+}
+
+// Gets the job state from the state of the Flink job reported by the
+// JobManager.
+func getJobStateFromFlinkJobState(flinkJobState string) string {
+	switch flinkJobState {
+	case "FINISHED":
+		return v1beta1.JobStateSucceeded
+	case "FAILED":
+		return v1beta1.JobStateFailed
+	case "CANCELED":
+		return v1beta1.JobStateCancelled
+	default:
+		return v1beta1.JobStateRunning
+	}
+}
+
+// Checks whether the job is requested to be cancelled or already cancelled.
+func isJobCancelRequested(cluster *v1beta1.FlinkCluster) bool {
+	var jobSpec = cluster.Spec.Job
+	var jobStatus = cluster.Status.Components.Job
+	var controlStatus = cluster.Status.Control
+	return (jobStatus != nil && jobStatus.State == v1beta1.JobStateCancelled) ||
+		(jobSpec.CancelRequested != nil && *jobSpec.CancelRequested) ||
+		(controlStatus != nil &&
+			controlStatus.Name == v1beta1.ControlNameJobCancel &&
+			controlStatus.State == v1beta1.ControlStateProgressing)
+}
+
+// Checks whether the job submitted through the Flink REST API needs to be
+// upgraded, i.e., the job spec changed after the job was submitted.
+func isRestAPIJobUpgradeRequested(cluster *v1beta1.FlinkCluster) bool {
+	var jobStatus = cluster.Status.Components.Job
+	return isRestAPISubmission(cluster.Spec.Job) &&
+		jobStatus != nil &&
+		len(jobStatus.SpecHash) > 0 &&
+		jobStatus.SpecHash != getJobSpecHash(cluster) &&
+		!isJobCancelRequested(cluster)
+}
+
+// Checks whether the job spec changed after the running job was submitted,
+// with the job submitter or through the Flink REST API.
+func isJobSpecUpdated(
+	cluster *v1beta1.FlinkCluster, observedJob *batchv1.Job) bool {
+	if isRestAPISubmission(cluster.Spec.Job) {
+		return isRestAPIJobUpgradeRequested(cluster)
+	}
+	return observedJob != nil &&
+		getJobSpecHashAnnotation(observedJob) != getJobSpecHash(cluster) &&
+		!isJobCancelRequested(cluster)
+}
+
+// Checks whether the job submitter finished because the job was stopped with
+// a savepoint for an upgrade.
+func isJobStoppedForUpgrade(
+	cluster *v1beta1.FlinkCluster,
+	observedJob *batchv1.Job,
+	savepoint *v1beta1.SavepointStatus) bool {
+	var jobStatus = cluster.Status.Components.Job
+	return isJobSpecUpdated(cluster, observedJob) &&
+		jobStatus != nil && !isJobStopped(jobStatus) &&
+		savepoint != nil &&
+		savepoint.JobID == jobStatus.ID &&
+		savepoint.TriggerReason == v1beta1.SavepointTriggerReasonUpdate &&
+		savepoint.State == v1beta1.SavepointStateSucceeded
+}
+
+// Derives the status of the upgrade of the job `jobID` to the job spec of
+// `specHash` from the savepoints taken for it. Each failed savepoint is counted
+// once, and the upgrade fails after `MaxJobUpgradeSavepointFailures` failed
+// savepoints.
+func getJobUpgradeStatus(
+	recorded *v1beta1.JobUpgradeStatus,
+	jobID string,
+	specHash string,
+	savepoint *v1beta1.SavepointStatus) *v1beta1.JobUpgradeStatus {
+	var upgrade = recorded.DeepCopy()
+	if upgrade == nil || upgrade.SpecHash != specHash {
+		upgrade = &v1beta1.JobUpgradeStatus{
+			SpecHash:  specHash,
+			State:     v1beta1.JobUpgradeStateInProgress,
+			FromJobID: jobID,
+		}
+		setTimestamp(&upgrade.StartTime)
+	}
+	if upgrade.State == v1beta1.JobUpgradeStateFailed ||
+		savepoint == nil ||
+		savepoint.TriggerReason != v1beta1.SavepointTriggerReasonUpdate ||
+		(savepoint.State != v1beta1.SavepointStateFailed &&
+			savepoint.State != v1beta1.SavepointStateTriggerFailed) ||
+		len(savepoint.TriggerTime) == 0 ||
+		savepoint.TriggerTime == upgrade.LastFailedSavepointTime {
+		return upgrade
+	}
+	// The failed savepoints of the previous upgrades don't count.
+	var tc = &TimeConverter{}
+	if tc.FromString(savepoint.TriggerTime).Before(
+		tc.FromString(upgrade.StartTime)) {
+		return upgrade
+	}
+	upgrade.FailedSavepoints++
+	upgrade.LastFailedSavepointTime = savepoint.TriggerTime
+	upgrade.Message = savepoint.Message
+	if upgrade.FailedSavepoints >= MaxJobUpgradeSavepointFailures {
+		upgrade.State = v1beta1.JobUpgradeStateFailed
+	}
+	return upgrade
 }
 
 func isUserControlFinished(controlStatus *v1beta1.FlinkClusterControlStatus) bool {
 	return controlStatus.State == v1beta1.ControlStateSucceeded ||
 		controlStatus.State == v1beta1.ControlStateFailed
 }
+
+// Checks whether the pod template has drifted from the desired template by an
+// out-of-band edit, e.g., with kubectl. Only the fields set by the operator
+// are compared, the ones defaulted by the API server and the labels and
+// annotations added by others, e.g., by `kubectl rollout restart`, are
+// ignored.
+func isPodTemplateDrifted(
+	desiredTemplate *corev1.PodTemplateSpec,
+	observedTemplate *corev1.PodTemplateSpec) bool {
+	if !equality.Semantic.DeepDerivative(
+		desiredTemplate.Labels, observedTemplate.Labels) ||
+		!equality.Semantic.DeepDerivative(
+			desiredTemplate.Annotations, observedTemplate.Annotations) {
+		return true
+	}
+	var desiredContainers = desiredTemplate.Spec.Containers
+	var observedContainers = observedTemplate.Spec.Containers
+	if len(desiredContainers) != len(observedContainers) {
+		return true
+	}
+	for i := range desiredContainers {
+		var desired = &desiredContainers[i]
+		var observed = &observedContainers[i]
+		if desired.Name != observed.Name ||
+			desired.Image != observed.Image ||
+			!equality.Semantic.DeepEqual(desired.Command, observed.Command) ||
+			!equality.Semantic.DeepEqual(desired.Args, observed.Args) ||
+			len(desired.Env) != len(observed.Env) ||
+			!equality.Semantic.DeepDerivative(desired.Env, observed.Env) ||
+			!equality.Semantic.DeepDerivative(desired.Resources, observed.Resources) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the observed pod template with only the containers which are in the
+// desired template, e.g., without the sidecars added by other controllers.
+func getOwnedPodTemplate(
+	desiredTemplate *corev1.PodTemplateSpec,
+	observedTemplate *corev1.PodTemplateSpec) *corev1.PodTemplateSpec {
+	var owned = observedTemplate.DeepCopy()
+	owned.Spec.Containers = nil
+	for _, container := range observedTemplate.Spec.Containers {
+		for _, desiredContainer := range desiredTemplate.Spec.Containers {
+			if container.Name == desiredContainer.Name {
+				owned.Spec.Containers = append(owned.Spec.Containers, container)
+				break
+			}
+		}
+	}
+	return owned
+}
+
+// Checks whether the service has drifted from the desired service by an
+// out-of-band edit. The cluster IP and node ports allocated by the API server
+// and the annotations added by others are ignored.
+func isServiceDrifted(
+	desiredService *corev1.Service, observedService *corev1.Service) bool {
+	var desired = &desiredService.Spec
+	var observed = &observedService.Spec
+	if (len(desired.Type) > 0 && desired.Type != observed.Type) ||
+		!equality.Semantic.DeepEqual(desired.Selector, observed.Selector) ||
+		!equality.Semantic.DeepEqual(
+			desired.LoadBalancerSourceRanges, observed.LoadBalancerSourceRanges) ||
+		!equality.Semantic.DeepDerivative(
+			desiredService.Annotations, observedService.Annotations) ||
+		len(desired.Ports) != len(observed.Ports) {
+		return true
+	}
+	for i := range desired.Ports {
+		var desiredPort = &desired.Ports[i]
+		var observedPort = &observed.Ports[i]
+		if desiredPort.Name != observedPort.Name ||
+			desiredPort.Port != observedPort.Port ||
+			(desiredPort.TargetPort != intstr.IntOrString{} &&
+				desiredPort.TargetPort != observedPort.TargetPort) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the observed service reverted to the desired spec. The cluster IP is
+// immutable, and the allocated node ports are kept if the type still needs
+// them.
+func getRevertedService(
+	desiredService *corev1.Service,
+	observedService *corev1.Service) *corev1.Service {
+	var service = observedService.DeepCopy()
+	service.Annotations = mergeStringMaps(
+		observedService.Annotations, desiredService.Annotations)
+	service.Spec.Type = desiredService.Spec.Type
+	service.Spec.Selector = desiredService.Spec.Selector
+	service.Spec.LoadBalancerSourceRanges =
+		desiredService.Spec.LoadBalancerSourceRanges
+	service.Spec.Ports = make([]corev1.ServicePort, len(desiredService.Spec.Ports))
+	for i, port := range desiredService.Spec.Ports {
+		if port.NodePort == 0 &&
+			(service.Spec.Type == corev1.ServiceTypeNodePort ||
+				service.Spec.Type == corev1.ServiceTypeLoadBalancer) {
+			for _, observedPort := range observedService.Spec.Ports {
+				if observedPort.Name == port.Name {
+					port.NodePort = observedPort.NodePort
+				}
+			}
+		}
+		service.Spec.Ports[i] = port
+	}
+	return service
+}
+
+// Checks whether the ingress has drifted from the desired ingress by an
+// out-of-band edit, the annotations added by others are ignored.
+func isIngressDrifted(
+	desiredIngress *extensionsv1beta1.Ingress,
+	observedIngress *extensionsv1beta1.Ingress) bool {
+	return !equality.Semantic.DeepDerivative(
+		desiredIngress.Spec, observedIngress.Spec) ||
+		!equality.Semantic.DeepDerivative(
+			desiredIngress.Annotations, observedIngress.Annotations)
+}
@@ -18,9 +18,16 @@ package controllers
 
 import (
 	"testing"
+	"time"
 
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
 	"gotest.tools/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestTimeConverter(t *testing.T) {
@@ -39,26 +46,204 @@ func TestTimeConverter(t *testing.T) {
 
 func TestShouldRestartJob(t *testing.T) {
 	var restartOnFailure = v1beta1.JobRestartPolicyFromSavepointOnFailure
+	var jobSpec1 = v1beta1.JobSpec{RestartPolicy: &restartOnFailure}
 	var jobStatus1 = v1beta1.JobStatus{
 		State:             v1beta1.JobStateFailed,
 		SavepointLocation: "gs://my-bucket/savepoint-123",
 	}
-	var restart1 = shouldRestartJob(&restartOnFailure, &jobStatus1)
+	var restart1 = shouldRestartJob(&jobSpec1, &jobStatus1)
 	assert.Equal(t, restart1, true)
 
 	var jobStatus2 = v1beta1.JobStatus{
 		State: v1beta1.JobStateFailed,
 	}
-	var restart2 = shouldRestartJob(&restartOnFailure, &jobStatus2)
+	var restart2 = shouldRestartJob(&jobSpec1, &jobStatus2)
 	assert.Equal(t, restart2, false)
 
 	var neverRestart = v1beta1.JobRestartPolicyNever
+	var jobSpec3 = v1beta1.JobSpec{RestartPolicy: &neverRestart}
 	var jobStatus3 = v1beta1.JobStatus{
 		State:             v1beta1.JobStateFailed,
 		SavepointLocation: "gs://my-bucket/savepoint-123",
 	}
-	var restart3 = shouldRestartJob(&neverRestart, &jobStatus3)
+	var restart3 = shouldRestartJob(&jobSpec3, &jobStatus3)
 	assert.Equal(t, restart3, false)
+
+	var jobStatus4 = v1beta1.JobStatus{
+		State:                  v1beta1.JobStateFailed,
+		LastCheckpointLocation: "gs://my-bucket/checkpoints/chk-45",
+		LastCheckpointTime:     "2020-03-01T12:00:00Z",
+	}
+	var restart4 = shouldRestartJob(&jobSpec1, &jobStatus4)
+	assert.Equal(t, restart4, true)
+}
+
+func TestGetRestoreLocation(t *testing.T) {
+	var maxStateAgeToRestoreSeconds int32 = 600
+	var jobSpec = v1beta1.JobSpec{}
+	var jobStatus = v1beta1.JobStatus{
+		SavepointLocation:      "gs://my-bucket/savepoint-123",
+		LastSavepointTime:      "2020-03-01T12:00:00Z",
+		LastCheckpointLocation: "gs://my-bucket/checkpoints/chk-45",
+		LastCheckpointTime:     "2020-03-01T12:05:00Z",
+	}
+	var now = time.Date(2020, 3, 1, 12, 20, 0, 0, time.UTC)
+
+	// The latest one of the checkpoint and the savepoint.
+	assert.Equal(
+		t,
+		getRestoreLocation(&jobSpec, &jobStatus, now),
+		"gs://my-bucket/checkpoints/chk-45")
+	jobStatus.LastSavepointTime = "2020-03-01T12:10:00Z"
+	assert.Equal(
+		t,
+		getRestoreLocation(&jobSpec, &jobStatus, now),
+		"gs://my-bucket/savepoint-123")
+
+	// Bounded by the max state age.
+	jobSpec.MaxStateAgeToRestoreSeconds = &maxStateAgeToRestoreSeconds
+	assert.Equal(
+		t,
+		getRestoreLocation(&jobSpec, &jobStatus, now),
+		"gs://my-bucket/savepoint-123")
+	assert.Equal(
+		t,
+		getRestoreLocation(&jobSpec, &jobStatus, now.Add(time.Minute)),
+		"")
+}
+
+func TestGetJobSubmissionRetryTime(t *testing.T) {
+	var maxRetries int32 = 10
+	var retryBackoffSeconds int32 = 10
+	var maxRetryBackoffSeconds int32 = 60
+	var jobSpec = v1beta1.JobSpec{
+		MaxRetries:             &maxRetries,
+		RetryBackoffSeconds:    &retryBackoffSeconds,
+		MaxRetryBackoffSeconds: &maxRetryBackoffSeconds,
+	}
+	var jobStatus = v1beta1.JobStatus{
+		State:                   v1beta1.JobStatePending,
+		SubmissionAttempts:      1,
+		LastSubmissionErrorTime: "2020-03-01T12:00:00Z",
+	}
+	assert.Assert(t, isJobSubmissionRetryPending(&jobStatus))
+
+	var errorTime = time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(
+		t,
+		getJobSubmissionRetryTime(&jobSpec, &jobStatus),
+		errorTime.Add(10*time.Second))
+	jobStatus.SubmissionAttempts = 3
+	assert.Equal(
+		t,
+		getJobSubmissionRetryTime(&jobSpec, &jobStatus),
+		errorTime.Add(40*time.Second))
+	jobStatus.SubmissionAttempts = 5
+	assert.Equal(
+		t,
+		getJobSubmissionRetryTime(&jobSpec, &jobStatus),
+		errorTime.Add(60*time.Second))
+
+	assert.Assert(t, shouldRetryJobSubmission(&jobSpec, 10))
+	assert.Assert(t, !shouldRetryJobSubmission(&jobSpec, 11))
+	assert.Assert(t, !shouldRetryJobSubmission(&v1beta1.JobSpec{}, 1))
+}
+
+func TestGetJobExpirationTime(t *testing.T) {
+	var ttlSecondsAfterFinished int32 = 600
+	var jobSpec = v1beta1.JobSpec{TTLSecondsAfterFinished: &ttlSecondsAfterFinished}
+	var jobStatus = v1beta1.JobStatus{
+		State:          v1beta1.JobStateSucceeded,
+		CompletionTime: "2020-03-01T12:00:00Z",
+	}
+
+	var expirationTime = getJobExpirationTime(&jobSpec, &jobStatus)
+	assert.Assert(t, expirationTime != nil)
+	assert.Equal(t, *expirationTime, time.Date(2020, 3, 1, 12, 10, 0, 0, time.UTC))
+
+	assert.Assert(t, getJobExpirationTime(&v1beta1.JobSpec{}, &jobStatus) == nil)
+	jobStatus.State = v1beta1.JobStateRunning
+	assert.Assert(t, getJobExpirationTime(&jobSpec, &jobStatus) == nil)
+}
+
+func TestGetIdleStatus(t *testing.T) {
+	var now = time.Date(2020, 3, 1, 12, 10, 0, 0, time.UTC)
+	var recorded = v1beta1.FlinkClusterStatus{IdleSince: "2020-03-01T12:00:00Z"}
+	var finishedJobs = &flinkclient.JobStatusList{
+		Jobs: []flinkclient.JobStatus{{ID: "1", Status: "FINISHED"}},
+	}
+
+	// Unknown jobs.
+	var idleSince, timedOut = getIdleStatus(&recorded, 300, nil, now)
+	assert.Equal(t, idleSince, "2020-03-01T12:00:00Z")
+	assert.Assert(t, !timedOut)
+
+	// Active jobs.
+	idleSince, timedOut = getIdleStatus(
+		&recorded,
+		300,
+		&flinkclient.JobStatusList{
+			Jobs: []flinkclient.JobStatus{
+				{ID: "1", Status: "FINISHED"}, {ID: "2", Status: "CREATED"}},
+		},
+		now)
+	assert.Equal(t, idleSince, "")
+	assert.Assert(t, !timedOut)
+
+	// Became idle.
+	idleSince, timedOut = getIdleStatus(
+		&v1beta1.FlinkClusterStatus{}, 300, finishedJobs, now)
+	assert.Equal(t, idleSince, "2020-03-01T12:10:00Z")
+	assert.Assert(t, !timedOut)
+
+	// Timed out.
+	idleSince, timedOut = getIdleStatus(&recorded, 900, finishedJobs, now)
+	assert.Equal(t, idleSince, "2020-03-01T12:00:00Z")
+	assert.Assert(t, !timedOut)
+	idleSince, timedOut = getIdleStatus(&recorded, 600, finishedJobs, now)
+	assert.Equal(t, idleSince, "2020-03-01T12:00:00Z")
+	assert.Assert(t, timedOut)
+}
+
+func TestGetJobSubmitterFailureMessage(t *testing.T) {
+	var terminated = corev1.ContainerStateTerminated{
+		ExitCode: 1,
+		Reason:   "Error",
+		Message:  "Could not find the job JAR file.",
+	}
+	assert.Equal(
+		t,
+		getJobSubmitterFailureMessage("main", &terminated),
+		`container "main" exited with code 1 (Error): Could not find the job JAR file.`)
+
+	terminated = corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}
+	assert.Equal(
+		t,
+		getJobSubmitterFailureMessage("jar-downloader", &terminated),
+		`container "jar-downloader" exited with code 137 (OOMKilled)`)
+}
+
+func TestHasReadySlots(t *testing.T) {
+	var required int32 = 4
+	var jobSpec = v1beta1.JobSpec{ReadySlotsRequired: &required}
+
+	assert.Assert(t, hasReadySlots(nil, nil))
+	assert.Assert(t, hasReadySlots(&v1beta1.JobSpec{}, nil))
+	assert.Assert(t, !hasReadySlots(&jobSpec, nil))
+	assert.Assert(t, !hasReadySlots(
+		&jobSpec, &flinkclient.ClusterOverview{SlotsTotal: 4, SlotsAvailable: 2}))
+	assert.Assert(t, hasReadySlots(
+		&jobSpec, &flinkclient.ClusterOverview{SlotsTotal: 6, SlotsAvailable: 4}))
+}
+
+func TestGetCheckpointProgressPercent(t *testing.T) {
+	assert.Equal(
+		t, getCheckpointProgressPercent(&flinkclient.Checkpoint{}), int32(0))
+	assert.Equal(
+		t,
+		getCheckpointProgressPercent(&flinkclient.Checkpoint{
+			NumSubtasks: 8, NumAcknowledgedSubtasks: 3}),
+		int32(37))
 }
 
 func TestGetRetryCount(t *testing.T) {
@@ -70,3 +255,441 @@ func TestGetRetryCount(t *testing.T) {
 	var result2, _ = getRetryCount(data2)
 	assert.Equal(t, result2, "2")
 }
+
+func TestIsPodTemplateDrifted(t *testing.T) {
+	var desired = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "flink"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "jobmanager",
+				Image: "flink:1.12.2",
+				Env:   []corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}},
+		},
+	}
+
+	// Defaulted by the API server or added by kubectl rollout restart.
+	var observed = desired.DeepCopy()
+	observed.Annotations = map[string]string{
+		"kubectl.kubernetes.io/restartedAt": "2020-03-01T12:00:00Z",
+	}
+	observed.Spec.Containers[0].ImagePullPolicy = corev1.PullIfNotPresent
+	observed.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] =
+		resource.MustParse("1024Mi")
+	observed.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	assert.Assert(t, !isPodTemplateDrifted(&desired, observed))
+
+	observed.Spec.Containers[0].Env = append(
+		observed.Spec.Containers[0].Env, corev1.EnvVar{Name: "DEBUG", Value: "1"})
+	assert.Assert(t, isPodTemplateDrifted(&desired, observed))
+
+	observed = desired.DeepCopy()
+	observed.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] =
+		resource.MustParse("2Gi")
+	assert.Assert(t, isPodTemplateDrifted(&desired, observed))
+}
+
+func TestGetOwnedPodTemplate(t *testing.T) {
+	var desired = corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "jobmanager", Image: "flink:1.12.2"},
+				{Name: "sidecar", Image: "sidecar:1.0"},
+			},
+		},
+	}
+
+	// Added by an injector.
+	var observed = desired.DeepCopy()
+	observed.Spec.Containers = append(
+		[]corev1.Container{{Name: "istio-proxy", Image: "istio/proxyv2"}},
+		observed.Spec.Containers...)
+	assert.Assert(t, isPodTemplateDrifted(&desired, observed))
+
+	var owned = getOwnedPodTemplate(&desired, observed)
+	assert.Equal(t, len(owned.Spec.Containers), 2)
+	assert.Equal(t, owned.Spec.Containers[0].Name, "jobmanager")
+	assert.Equal(t, owned.Spec.Containers[1].Name, "sidecar")
+	assert.Equal(t, len(observed.Spec.Containers), 3)
+	assert.Assert(t, !isPodTemplateDrifted(&desired, owned))
+
+	// The operator's containers are still compared.
+	observed.Spec.Containers[1].Image = "flink:1.11.3"
+	owned = getOwnedPodTemplate(&desired, observed)
+	assert.Assert(t, isPodTemplateDrifted(&desired, owned))
+}
+
+func TestIsServiceDrifted(t *testing.T) {
+	var desired = corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: map[string]string{"app": "flink"},
+			Ports: []corev1.ServicePort{
+				{Name: "ui", Port: 8081, TargetPort: intstr.FromString("ui")},
+			},
+		},
+	}
+
+	// Allocated by the API server.
+	var observed = desired.DeepCopy()
+	observed.Spec.ClusterIP = "10.0.0.1"
+	observed.Spec.Ports[0].NodePort = 30081
+	observed.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	assert.Assert(t, !isServiceDrifted(&desired, observed))
+
+	observed.Spec.Type = corev1.ServiceTypeLoadBalancer
+	observed.Spec.Ports[0].Port = 80
+	assert.Assert(t, isServiceDrifted(&desired, observed))
+
+	var reverted = getRevertedService(&desired, observed)
+	assert.Equal(t, reverted.Spec.Type, corev1.ServiceTypeNodePort)
+	assert.Equal(t, reverted.Spec.ClusterIP, "10.0.0.1")
+	assert.Equal(t, reverted.Spec.Ports[0].Port, int32(8081))
+	assert.Equal(t, reverted.Spec.Ports[0].NodePort, int32(30081))
+	assert.Assert(t, !isServiceDrifted(&desired, reverted))
+}
+
+func TestHasClusterFinalizer(t *testing.T) {
+	var cluster = v1beta1.FlinkCluster{}
+	assert.Assert(t, !hasClusterFinalizer(&cluster))
+
+	cluster.Finalizers = []string{"foregroundDeletion"}
+	assert.Assert(t, !hasClusterFinalizer(&cluster))
+
+	cluster.Finalizers = append(cluster.Finalizers, v1beta1.ClusterFinalizer)
+	assert.Assert(t, hasClusterFinalizer(&cluster))
+}
+
+func TestIsReconciliationPaused(t *testing.T) {
+	var cluster = v1beta1.FlinkCluster{}
+	assert.Assert(t, !isReconciliationPaused(&cluster))
+
+	cluster.Annotations = map[string]string{v1beta1.PausedAnnotation: "false"}
+	assert.Assert(t, !isReconciliationPaused(&cluster))
+
+	cluster.Annotations[v1beta1.PausedAnnotation] = "true"
+	assert.Assert(t, isReconciliationPaused(&cluster))
+}
+
+func TestIsDeletionTimedOut(t *testing.T) {
+	var now = time.Now()
+	var cluster = v1beta1.FlinkCluster{}
+	assert.Assert(t, !isDeletionTimedOut(&cluster, deletionSavepointTimeout, now))
+
+	var deletionTime = metav1.NewTime(now.Add(-7 * time.Minute))
+	cluster.DeletionTimestamp = &deletionTime
+	assert.Assert(t, isDeletionTimedOut(&cluster, deletionSavepointTimeout, now))
+	assert.Assert(t, !isDeletionTimedOut(&cluster, deletionFinalizerTimeout, now))
+	assert.Assert(
+		t,
+		isDeletionTimedOut(
+			&cluster, deletionFinalizerTimeout, now.Add(5*time.Minute)))
+}
+
+func TestBlueGreenCluster(t *testing.T) {
+	var blueGreen = v1beta1.UpdateStrategyBlueGreen
+	var savepointsDir = "gs://my-bucket/savepoints/"
+	var stableSeconds int32 = 60
+	var cluster = v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "myapp"},
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			Image:          v1beta1.ImageSpec{Name: "flink:1.8.1"},
+			Job:            &v1beta1.JobSpec{SavepointsDir: &savepointsDir},
+			UpdateStrategy: &blueGreen,
+			BlueGreen:      &v1beta1.BlueGreenSpec{StableSeconds: &stableSeconds},
+		},
+	}
+	assert.Assert(t, isBlueGreenCluster(&cluster))
+	assert.Equal(
+		t, getBlueGreenTargetName("mycluster", "mycluster-blue"), "mycluster-green")
+	assert.Equal(
+		t, getBlueGreenTargetName("mycluster", "mycluster-green"), "mycluster-blue")
+
+	// The hash only changes with the deployed properties.
+	var specHash = getBlueGreenSpecHash(&cluster)
+	var updatedCluster = cluster.DeepCopy()
+	stableSeconds = 120
+	updatedCluster.Spec.BlueGreen = &v1beta1.BlueGreenSpec{StableSeconds: &stableSeconds}
+	assert.Equal(t, getBlueGreenSpecHash(updatedCluster), specHash)
+	updatedCluster.Spec.Image.Name = "flink:1.9.0"
+	assert.Assert(t, getBlueGreenSpecHash(updatedCluster) != specHash)
+
+	var child = getDesiredBlueGreenCluster(
+		&cluster, "mycluster-green", specHash, "gs://my-bucket/savepoints/savepoint-1")
+	assert.Equal(t, child.Name, "mycluster-green")
+	assert.Equal(t, child.Namespace, "default")
+	assert.DeepEqual(t, child.Labels, map[string]string{"app": "myapp"})
+	assert.Equal(t, child.Annotations[blueGreenSpecHashAnnotation], specHash)
+	assert.Equal(t, len(child.OwnerReferences), 1)
+	assert.Assert(t, !isBlueGreenCluster(child))
+	assert.Assert(t, child.Spec.BlueGreen == nil)
+	assert.Equal(
+		t, *child.Spec.Job.FromSavepoint, "gs://my-bucket/savepoints/savepoint-1")
+	assert.Assert(t, cluster.Spec.Job.FromSavepoint == nil)
+}
+
+func TestSavepointHistory(t *testing.T) {
+	var limit int32 = 2
+	var jobSpec = v1beta1.JobSpec{}
+	var jobStatus = v1beta1.JobStatus{}
+
+	// Not recorded without the limit.
+	appendSavepointHistory(&jobSpec, &jobStatus, "gs://my-bucket/savepoint-1")
+	assert.Assert(t, jobStatus.SavepointHistory == nil)
+	assert.Assert(t, getExpiredSavepoints(&jobSpec, &jobStatus) == nil)
+
+	jobSpec.SavepointHistoryLimit = &limit
+	appendSavepointHistory(&jobSpec, &jobStatus, "gs://my-bucket/savepoint-1")
+	appendSavepointHistory(&jobSpec, &jobStatus, "gs://my-bucket/savepoint-1")
+	appendSavepointHistory(&jobSpec, &jobStatus, "gs://my-bucket/savepoint-2")
+	assert.DeepEqual(
+		t,
+		jobStatus.SavepointHistory,
+		[]string{"gs://my-bucket/savepoint-1", "gs://my-bucket/savepoint-2"})
+	assert.Assert(t, getExpiredSavepoints(&jobSpec, &jobStatus) == nil)
+
+	appendSavepointHistory(&jobSpec, &jobStatus, "gs://my-bucket/savepoint-3")
+	assert.DeepEqual(
+		t,
+		getExpiredSavepoints(&jobSpec, &jobStatus),
+		[]string{"gs://my-bucket/savepoint-1"})
+}
+
+func TestGetJobUpgradeStatus(t *testing.T) {
+	var tc = &TimeConverter{}
+	var now = time.Now()
+	var jobID = "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69"
+	var savepoint = v1beta1.SavepointStatus{
+		JobID:         jobID,
+		TriggerTime:   tc.ToString(now.Add(time.Minute)),
+		TriggerReason: v1beta1.SavepointTriggerReasonUpdate,
+		State:         v1beta1.SavepointStateInProgress,
+	}
+
+	// Started without a failed savepoint.
+	var upgrade = getJobUpgradeStatus(nil, jobID, "hash-1", &savepoint)
+	assert.Equal(t, upgrade.SpecHash, "hash-1")
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateInProgress)
+	assert.Equal(t, upgrade.FromJobID, jobID)
+	assert.Equal(t, upgrade.FailedSavepoints, int32(0))
+
+	// Each failed savepoint is counted once.
+	savepoint.State = v1beta1.SavepointStateFailed
+	savepoint.Message = "Timed out taking savepoint"
+	upgrade = getJobUpgradeStatus(upgrade, jobID, "hash-1", &savepoint)
+	upgrade = getJobUpgradeStatus(upgrade, jobID, "hash-1", &savepoint)
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateInProgress)
+	assert.Equal(t, upgrade.FailedSavepoints, int32(1))
+	assert.Equal(t, upgrade.Message, "Timed out taking savepoint")
+
+	savepoint.State = v1beta1.SavepointStateTriggerFailed
+	savepoint.TriggerTime = tc.ToString(now.Add(2 * time.Minute))
+	upgrade = getJobUpgradeStatus(upgrade, jobID, "hash-1", &savepoint)
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateInProgress)
+	savepoint.TriggerTime = tc.ToString(now.Add(3 * time.Minute))
+	upgrade = getJobUpgradeStatus(upgrade, jobID, "hash-1", &savepoint)
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateFailed)
+	assert.Equal(t, upgrade.FailedSavepoints, int32(3))
+
+	// Not counted after the upgrade failed.
+	savepoint.TriggerTime = tc.ToString(now.Add(4 * time.Minute))
+	upgrade = getJobUpgradeStatus(upgrade, jobID, "hash-1", &savepoint)
+	assert.Equal(t, upgrade.FailedSavepoints, int32(3))
+
+	// Restarted when the job spec changes, the failed savepoints of the
+	// previous upgrade don't count.
+	savepoint.TriggerTime = tc.ToString(now.Add(-time.Minute))
+	upgrade = getJobUpgradeStatus(upgrade, jobID, "hash-2", &savepoint)
+	assert.Equal(t, upgrade.SpecHash, "hash-2")
+	assert.Equal(t, upgrade.State, v1beta1.JobUpgradeStateInProgress)
+	assert.Equal(t, upgrade.FailedSavepoints, int32(0))
+}
+
+func TestIsJobStoppedForUpgrade(t *testing.T) {
+	var jobID = "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69"
+	var rpcPort int32 = 6123
+	var blobPort int32 = 6124
+	var queryPort int32 = 6125
+	var uiPort int32 = 8081
+	var dataPort int32 = 6121
+	var cluster = v1beta1.FlinkCluster{
+		Spec: v1beta1.FlinkClusterSpec{
+			Image: v1beta1.ImageSpec{Name: "flink:1.10.0"},
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{
+					RPC:   &rpcPort,
+					Blob:  &blobPort,
+					Query: &queryPort,
+					UI:    &uiPort,
+				},
+			},
+			TaskManager: v1beta1.TaskManagerSpec{
+				Ports: v1beta1.TaskManagerPorts{
+					RPC:   &rpcPort,
+					Data:  &dataPort,
+					Query: &queryPort,
+				},
+			},
+			Job: &v1beta1.JobSpec{JarFile: "/opt/flink/job.jar"},
+		},
+		Status: v1beta1.FlinkClusterStatus{
+			Components: v1beta1.FlinkClusterComponentsStatus{
+				Job: &v1beta1.JobStatus{
+					ID:    jobID,
+					State: v1beta1.JobStateRunning,
+				},
+			},
+		},
+	}
+	var observedJob = batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				jobSpecHashAnnotation: getJobSpecHash(&cluster),
+			},
+		},
+	}
+	var savepoint = v1beta1.SavepointStatus{
+		JobID:         jobID,
+		TriggerReason: v1beta1.SavepointTriggerReasonUpdate,
+		State:         v1beta1.SavepointStateSucceeded,
+	}
+	assert.Assert(t, !isJobStoppedForUpgrade(&cluster, &observedJob, &savepoint))
+
+	cluster.Spec.Image.Name = "flink:1.11.0"
+	assert.Assert(t, isJobStoppedForUpgrade(&cluster, &observedJob, &savepoint))
+
+	savepoint.TriggerReason = v1beta1.SavepointTriggerReasonScheduled
+	assert.Assert(t, !isJobStoppedForUpgrade(&cluster, &observedJob, &savepoint))
+}
+
+func TestGetSavepointDeletionRetryTime(t *testing.T) {
+	var tc = &TimeConverter{}
+	var jobStatus = v1beta1.JobStatus{}
+	assert.Assert(t, getSavepointDeletionRetryTime(&jobStatus) == nil)
+
+	jobStatus.SavepointDeletionFailures = 1
+	jobStatus.LastSavepointDeletionFailureTime = "2020-01-01T00:00:00Z"
+	assert.Equal(
+		t,
+		tc.ToString(*getSavepointDeletionRetryTime(&jobStatus)),
+		"2020-01-01T00:00:10Z")
+
+	jobStatus.SavepointDeletionFailures = 3
+	assert.Equal(
+		t,
+		tc.ToString(*getSavepointDeletionRetryTime(&jobStatus)),
+		"2020-01-01T00:00:40Z")
+
+	jobStatus.SavepointDeletionFailures = 10
+	assert.Equal(
+		t,
+		tc.ToString(*getSavepointDeletionRetryTime(&jobStatus)),
+		"2020-01-01T00:10:00Z")
+}
+
+func TestGetSavepointRetryStatus(t *testing.T) {
+	var tc = &TimeConverter{}
+	var now = tc.FromString("2020-01-01T00:00:00Z")
+	var maxRetries int32 = 3
+	var backoffSeconds int32 = 10
+	var jobSpec = v1beta1.JobSpec{
+		SavepointMaxRetries:          &maxRetries,
+		SavepointRetryBackoffSeconds: &backoffSeconds,
+	}
+	var failed = v1beta1.SavepointStatus{
+		JobID:         "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		TriggerID:     "6e7e0ab9a4bd3c2c2dc4b5ad2b6d8e0f",
+		TriggerReason: v1beta1.SavepointTriggerReasonUpdate,
+		State:         v1beta1.SavepointStateFailed,
+		Message:       "Savepoint timed out",
+	}
+	assert.Assert(t, shouldRetrySavepoint(&jobSpec, &failed))
+
+	var retry = getSavepointRetryStatus(&jobSpec, &failed, now)
+	assert.Equal(t, retry.State, v1beta1.SavepointStateNotTriggered)
+	assert.Equal(t, retry.JobID, failed.JobID)
+	assert.Equal(t, retry.TriggerID, "")
+	assert.Equal(t, retry.TriggerReason, v1beta1.SavepointTriggerReasonUpdate)
+	assert.Equal(t, retry.Retries, int32(1))
+	assert.Equal(t, retry.RetryTime, "2020-01-01T00:00:10Z")
+	assert.Assert(t, isSavepointRetryPending(retry, now))
+	assert.Assert(t, !isSavepointRetryPending(retry, now.Add(10*time.Second)))
+
+	// The backoff doubles for each retry up to 5 minutes.
+	failed.Retries = 2
+	retry = getSavepointRetryStatus(&jobSpec, &failed, now)
+	assert.Equal(t, retry.Retries, int32(3))
+	assert.Equal(t, retry.RetryTime, "2020-01-01T00:00:40Z")
+	failed.Retries = 10
+	retry = getSavepointRetryStatus(&jobSpec, &failed, now)
+	assert.Equal(t, retry.RetryTime, "2020-01-01T00:05:00Z")
+
+	// Not retried after the max retries or without them.
+	failed.Retries = 3
+	assert.Assert(t, !shouldRetrySavepoint(&jobSpec, &failed))
+	failed.Retries = 0
+	jobSpec.SavepointMaxRetries = nil
+	assert.Assert(t, !shouldRetrySavepoint(&jobSpec, &failed))
+}
+
+func TestGetFlinkUIURL(t *testing.T) {
+	var uiPort int32 = 8081
+	var cluster = v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "default"},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{UI: &uiPort},
+			},
+		},
+	}
+	var service = corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Name: "ui", Port: 8081}},
+		},
+	}
+
+	// The REST API URL until the load balancer is ready.
+	assert.Equal(
+		t,
+		getFlinkUIURL(&cluster, &service, nil),
+		"http://mycluster-jobmanager.default.svc.cluster.local:8081")
+
+	service.Status.LoadBalancer.Ingress =
+		[]corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	assert.Equal(
+		t, getFlinkUIURL(&cluster, &service, nil), "http://10.0.0.1:8081")
+
+	var ingress = v1beta1.JobManagerIngressStatus{
+		URLs: []string{"https://flink.example.com"},
+	}
+	assert.Equal(
+		t,
+		getFlinkUIURL(&cluster, &service, &ingress),
+		"https://flink.example.com")
+
+	// HTTPS with the TLS of the REST API.
+	cluster.Spec.Security = &v1beta1.SecuritySpec{
+		SSL: &v1beta1.SSLSpec{
+			REST: &v1beta1.RESTSSLSpec{SecretName: "mycluster-rest"},
+		},
+	}
+	assert.Equal(
+		t, getFlinkUIURL(&cluster, &service, nil), "https://10.0.0.1:8081")
+	service.Status.LoadBalancer.Ingress = nil
+	assert.Equal(
+		t,
+		getFlinkUIURL(&cluster, &service, nil),
+		"https://mycluster-jobmanager.default.svc.cluster.local:8081")
+}
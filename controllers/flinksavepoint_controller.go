@@ -0,0 +1,337 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"github.com/googlecloudplatform/flink-operator/controllers/tracing"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// The savepoint fails if its status cannot be polled from the JobManager for
+// this timeout, e.g., when the JobManager has been lost.
+const flinkSavepointPollTimeout = 5 * time.Minute
+
+// The Flink API used by the FlinkSavepoint controller.
+type savepointFlinkClient interface {
+	TriggerSavepoint(
+		apiBaseURL string,
+		jobID string,
+		dir string) (flinkclient.SavepointTriggerID, error)
+	GetSavepointStatus(
+		apiBaseURL string,
+		jobID string,
+		triggerID string) (flinkclient.SavepointStatus, error)
+}
+
+// FlinkSavepointReconciler reconciles a FlinkSavepoint object
+type FlinkSavepointReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	// The maximum number of FlinkSavepoints reconciled concurrently,
+	// default: 1.
+	MaxConcurrentReconciles int
+
+	// The shard of the FlinkClusters whose savepoints are reconciled by this
+	// operator instance, default: all the clusters.
+	Shard ClusterShard
+
+	// The log levels of the reconciliations, everything is logged if nil.
+	LogLevels *LogLevels
+}
+
+// +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinksavepoints,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinksavepoints/status,verbs=get;update;patch
+
+// Reconcile takes the savepoint of a FlinkSavepoint custom resource for the
+// job of its cluster and tracks the savepoint state.
+func (reconciler *FlinkSavepointReconciler) Reconcile(
+	request ctrl.Request) (ctrl.Result, error) {
+	var log = reconciler.LogLevels.getLogger(
+		reconciler.Log, "FlinkSavepoint", request.NamespacedName).WithValues(
+		"savepoint", request.NamespacedName)
+
+	// The savepoints of the clusters in other shards are reconciled by other
+	// operator instances.
+	if reconciler.Shard.isEnabled() {
+		var savepoint = &v1beta1.FlinkSavepoint{}
+		var err = reconciler.Client.Get(
+			context.Background(), request.NamespacedName, savepoint)
+		if err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		inShard, err := reconciler.Shard.containsClusterNamed(
+			context.Background(),
+			reconciler.Client,
+			types.NamespacedName{
+				Namespace: request.Namespace,
+				Name:      savepoint.Spec.ClusterName,
+			})
+		if err != nil || !inShard {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The reconciliation is traced with a span, whose children are the spans
+	// of its Flink REST API requests.
+	var ctx, span = tracing.StartSpan(
+		context.Background(),
+		"Reconcile FlinkSavepoint",
+		tracing.SpanKindInternal,
+		"k8s.namespace.name", request.Namespace,
+		"flinksavepoint.name", request.Name)
+	if span != nil {
+		log = log.WithValues("traceID", span.TraceID())
+	}
+
+	var handler = FlinkSavepointHandler{
+		k8sClient: reconciler.Client,
+		flinkClient: &flinkclient.FlinkClient{
+			Log:        log,
+			HTTPClient: flinkclient.HTTPClient{Log: log, Context: ctx},
+		},
+		request: request,
+		context: ctx,
+		log:     log,
+	}
+	result, err := handler.reconcile()
+	span.End(err)
+	return result, err
+}
+
+// SetupWithManager registers this reconciler with the controller manager and
+// starts watching FlinkSavepoint resources.
+func (reconciler *FlinkSavepointReconciler) SetupWithManager(
+	mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.FlinkSavepoint{}).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: reconciler.MaxConcurrentReconciles,
+		}).
+		Complete(reconciler)
+}
+
+// FlinkSavepointHandler holds the context and state for a reconcile request
+// of a FlinkSavepoint.
+type FlinkSavepointHandler struct {
+	k8sClient   client.Client
+	flinkClient savepointFlinkClient
+	request     ctrl.Request
+	context     context.Context
+	log         logr.Logger
+}
+
+func (handler *FlinkSavepointHandler) reconcile() (ctrl.Result, error) {
+	var k8sClient = handler.k8sClient
+	var log = handler.log
+	var context = handler.context
+
+	var savepoint = &v1beta1.FlinkSavepoint{}
+	var err = k8sClient.Get(context, handler.request.NamespacedName, savepoint)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			log.Info("Savepoint not found, it might have been deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var cluster = &v1beta1.FlinkCluster{}
+	err = k8sClient.Get(
+		context,
+		types.NamespacedName{
+			Namespace: savepoint.Namespace,
+			Name:      savepoint.Spec.ClusterName,
+		},
+		cluster)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		cluster = nil
+	}
+	if flinkClient, ok := handler.flinkClient.(*flinkclient.FlinkClient); ok &&
+		cluster != nil {
+		var tlsConfig, err = getFlinkRESTTLSConfig(context, k8sClient, cluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		flinkClient.HTTPClient.TLSConfig = tlsConfig
+	}
+
+	var newStatus = savepoint.Status.DeepCopy()
+	var result ctrl.Result
+	result, err = handler.reconcileSavepoint(savepoint, cluster, newStatus)
+	if !reflect.DeepEqual(*newStatus, savepoint.Status) {
+		log.Info("Updating status", "status", *newStatus)
+		var savepointClone = savepoint.DeepCopy()
+		savepointClone.Status = *newStatus
+		setTimestamp(&savepointClone.Status.LastUpdateTime)
+		var updateErr = k8sClient.Status().Update(context, savepointClone)
+		if updateErr != nil {
+			log.Error(updateErr, "Failed to update status")
+			if err == nil {
+				err = updateErr
+			}
+		}
+	}
+	return result, err
+}
+
+// Triggers the savepoint when the job of the cluster is running, then polls
+// the savepoint status and records it until the savepoint is completed. The
+// finished FlinkSavepoint is left as a record of the savepoint.
+func (handler *FlinkSavepointHandler) reconcileSavepoint(
+	savepoint *v1beta1.FlinkSavepoint,
+	cluster *v1beta1.FlinkCluster,
+	status *v1beta1.FlinkSavepointStatus) (ctrl.Result, error) {
+	var log = handler.log
+	var flinkClient = handler.flinkClient
+
+	if isFlinkSavepointFinished(status) {
+		log.Info("Savepoint has finished, no action")
+		return ctrl.Result{}, nil
+	}
+	if len(status.State) == 0 {
+		status.State = v1beta1.SavepointStateNotTriggered
+	}
+
+	if cluster == nil {
+		return handler.failSavepoint(
+			status, fmt.Sprintf("FlinkCluster %v not found", savepoint.Spec.ClusterName))
+	}
+	var apiBaseURL = getFlinkAPIBaseURL(cluster)
+
+	// Trigger
+	if status.State == v1beta1.SavepointStateNotTriggered {
+		if cluster.Spec.Job == nil {
+			return handler.failSavepoint(
+				status, fmt.Sprintf("%v is not a job cluster", cluster.Name))
+		}
+		var jobStatus = cluster.Status.Components.Job
+		if isJobStopped(jobStatus) {
+			return handler.failSavepoint(status, "the job is not running")
+		}
+		if jobStatus == nil || jobStatus.State != v1beta1.JobStateRunning ||
+			len(jobStatus.ID) == 0 {
+			log.Info("Waiting for the job to be running")
+			return requeueResult, nil
+		}
+		var savepointsDir = getFlinkSavepointDir(savepoint, cluster)
+		if len(savepointsDir) == 0 {
+			return handler.failSavepoint(status, "savepointsDir is not specified")
+		}
+
+		log.Info("Triggering savepoint", "jobID", jobStatus.ID, "dir", savepointsDir)
+		var triggerID, err = flinkClient.TriggerSavepoint(
+			apiBaseURL, jobStatus.ID, savepointsDir)
+		if err != nil {
+			log.Error(err, "Failed to trigger savepoint", "jobID", jobStatus.ID)
+			// The savepoint is rejected by Flink, otherwise retry in the next
+			// reconciliation.
+			if _, ok := err.(*flinkclient.HTTPError); ok {
+				status.JobID = jobStatus.ID
+				status.State = v1beta1.SavepointStateTriggerFailed
+				status.Message = fmt.Sprintf("Failed to trigger savepoint: %v", err)
+				setTimestamp(&status.CompletionTime)
+				return ctrl.Result{}, nil
+			}
+			return requeueResult, err
+		}
+		status.JobID = jobStatus.ID
+		status.TriggerID = triggerID.RequestID
+		status.State = v1beta1.SavepointStateInProgress
+		setTimestamp(&status.TriggerTime)
+		return requeueResult, nil
+	}
+
+	// Track
+	var flinkStatus, err = flinkClient.GetSavepointStatus(
+		apiBaseURL, status.JobID, status.TriggerID)
+	if err != nil {
+		log.Info("Failed to get savepoint status", "error", err)
+		var tc = &TimeConverter{}
+		var now = time.Now()
+		if len(status.PollErrorSince) == 0 {
+			status.PollErrorSince = tc.ToString(now)
+		} else if now.After(tc.FromString(status.PollErrorSince).Add(
+			flinkSavepointPollTimeout)) {
+			status.State = v1beta1.SavepointStateFailed
+			status.Message = fmt.Sprintf(
+				"Failed to get savepoint status for %v: %v",
+				flinkSavepointPollTimeout, err)
+			setTimestamp(&status.CompletionTime)
+			return ctrl.Result{}, nil
+		}
+		return requeueResult, nil
+	}
+	status.PollErrorSince = ""
+	if !flinkStatus.Completed {
+		log.Info("Savepoint is in progress", "triggerID", status.TriggerID)
+		return requeueResult, nil
+	}
+	if flinkStatus.IsFailed() {
+		status.State = v1beta1.SavepointStateFailed
+		status.Message = fmt.Sprintf(
+			"Savepoint failed: %v", flinkStatus.FailureCause.ExceptionClass)
+	} else {
+		status.State = v1beta1.SavepointStateSucceeded
+		status.Location = flinkStatus.Location
+	}
+	setTimestamp(&status.CompletionTime)
+	return ctrl.Result{}, nil
+}
+
+// Records a savepoint which cannot be triggered, it is not retried.
+func (handler *FlinkSavepointHandler) failSavepoint(
+	status *v1beta1.FlinkSavepointStatus, message string) (ctrl.Result, error) {
+	handler.log.Info("Savepoint cannot be triggered", "reason", message)
+	status.State = v1beta1.SavepointStateTriggerFailed
+	status.Message = message
+	setTimestamp(&status.CompletionTime)
+	return ctrl.Result{}, nil
+}
+
+// Gets the directory of the savepoint, the `savepointsDir` of the job is used
+// when it is not specified.
+func getFlinkSavepointDir(
+	savepoint *v1beta1.FlinkSavepoint, cluster *v1beta1.FlinkCluster) string {
+	if savepoint.Spec.SavepointsDir != nil {
+		return *savepoint.Spec.SavepointsDir
+	}
+	if cluster.Spec.Job != nil && cluster.Spec.Job.SavepointsDir != nil {
+		return *cluster.Spec.Job.SavepointsDir
+	}
+	return ""
+}
+
+func isFlinkSavepointFinished(status *v1beta1.FlinkSavepointStatus) bool {
+	return status.State == v1beta1.SavepointStateSucceeded ||
+		status.State == v1beta1.SavepointStateFailed ||
+		status.State == v1beta1.SavepointStateTriggerFailed
+}
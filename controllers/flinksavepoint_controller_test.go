@@ -0,0 +1,291 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// A Kubernetes client serving a FlinkSavepoint and its FlinkCluster, it
+// records the status updates of the savepoint.
+type fakeSavepointK8sClient struct {
+	client.Client
+	savepoint *v1beta1.FlinkSavepoint
+	cluster   *v1beta1.FlinkCluster
+}
+
+func (c *fakeSavepointK8sClient) Get(
+	ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	switch obj := obj.(type) {
+	case *v1beta1.FlinkSavepoint:
+		c.savepoint.DeepCopyInto(obj)
+		return nil
+	case *v1beta1.FlinkCluster:
+		if c.cluster == nil {
+			return apierrors.NewNotFound(
+				schema.GroupResource{
+					Group: "flinkoperator.k8s.io", Resource: "flinkclusters"},
+				key.Name)
+		}
+		c.cluster.DeepCopyInto(obj)
+		return nil
+	}
+	return fmt.Errorf("unexpected object: %v", obj)
+}
+
+func (c *fakeSavepointK8sClient) Status() client.StatusWriter {
+	return &fakeSavepointStatusWriter{client: c}
+}
+
+type fakeSavepointStatusWriter struct {
+	client.StatusWriter
+	client *fakeSavepointK8sClient
+}
+
+func (w *fakeSavepointStatusWriter) Update(
+	ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	obj.(*v1beta1.FlinkSavepoint).DeepCopyInto(w.client.savepoint)
+	return nil
+}
+
+// A Flink client returning the given savepoint trigger and status.
+type fakeSavepointFlinkClient struct {
+	triggerID  flinkclient.SavepointTriggerID
+	triggerErr error
+	status     flinkclient.SavepointStatus
+	statusErr  error
+	triggered  []string
+}
+
+func (c *fakeSavepointFlinkClient) TriggerSavepoint(
+	apiBaseURL string,
+	jobID string,
+	dir string) (flinkclient.SavepointTriggerID, error) {
+	c.triggered = append(c.triggered, jobID+" "+dir)
+	return c.triggerID, c.triggerErr
+}
+
+func (c *fakeSavepointFlinkClient) GetSavepointStatus(
+	apiBaseURL string,
+	jobID string,
+	triggerID string) (flinkclient.SavepointStatus, error) {
+	return c.status, c.statusErr
+}
+
+func newTestSavepointHandler(
+	k8sClient *fakeSavepointK8sClient,
+	flinkClient *fakeSavepointFlinkClient) *FlinkSavepointHandler {
+	return &FlinkSavepointHandler{
+		k8sClient:   k8sClient,
+		flinkClient: flinkClient,
+		request: ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: "default",
+				Name:      "mysavepoint",
+			},
+		},
+		context: context.Background(),
+		log:     log.Log,
+	}
+}
+
+func newTestSavepointObjects() (*v1beta1.FlinkSavepoint, *v1beta1.FlinkCluster) {
+	var uiPort int32 = 8081
+	var savepointsDir = "gs://my-bucket/savepoints/"
+	var savepoint = &v1beta1.FlinkSavepoint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysavepoint",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkSavepointSpec{ClusterName: "mycluster"},
+	}
+	var cluster = &v1beta1.FlinkCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mycluster",
+			Namespace: "default",
+		},
+		Spec: v1beta1.FlinkClusterSpec{
+			JobManager: v1beta1.JobManagerSpec{
+				Ports: v1beta1.JobManagerPorts{UI: &uiPort},
+			},
+			Job: &v1beta1.JobSpec{SavepointsDir: &savepointsDir},
+		},
+		Status: v1beta1.FlinkClusterStatus{
+			Components: v1beta1.FlinkClusterComponentsStatus{
+				Job: &v1beta1.JobStatus{
+					ID:    "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+					State: v1beta1.JobStateRunning,
+				},
+			},
+		},
+	}
+	return savepoint, cluster
+}
+
+func TestFlinkSavepointTrigger(t *testing.T) {
+	var savepoint, cluster = newTestSavepointObjects()
+	var k8sClient = &fakeSavepointK8sClient{savepoint: savepoint, cluster: cluster}
+	var flinkClient = &fakeSavepointFlinkClient{
+		triggerID: flinkclient.SavepointTriggerID{RequestID: "trigger-1"},
+	}
+
+	var result, err = newTestSavepointHandler(k8sClient, flinkClient).reconcile()
+	assert.NilError(t, err)
+	assert.Equal(t, result, requeueResult)
+	assert.DeepEqual(
+		t,
+		flinkClient.triggered,
+		[]string{"ec22ad0e0e7c0d0c5d2dbd5ab7a86f69 gs://my-bucket/savepoints/"})
+	var status = k8sClient.savepoint.Status
+	assert.Equal(t, status.State, v1beta1.SavepointStateInProgress)
+	assert.Equal(t, status.JobID, "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69")
+	assert.Equal(t, status.TriggerID, "trigger-1")
+	assert.Assert(t, len(status.TriggerTime) > 0)
+}
+
+func TestFlinkSavepointWaitingForJob(t *testing.T) {
+	var savepoint, cluster = newTestSavepointObjects()
+	cluster.Status.Components.Job.State = v1beta1.JobStatePending
+	var k8sClient = &fakeSavepointK8sClient{savepoint: savepoint, cluster: cluster}
+	var flinkClient = &fakeSavepointFlinkClient{}
+
+	var result, err = newTestSavepointHandler(k8sClient, flinkClient).reconcile()
+	assert.NilError(t, err)
+	assert.Equal(t, result, requeueResult)
+	assert.Assert(t, flinkClient.triggered == nil)
+	assert.Equal(
+		t, k8sClient.savepoint.Status.State, v1beta1.SavepointStateNotTriggered)
+}
+
+func TestFlinkSavepointSucceeded(t *testing.T) {
+	var savepoint, cluster = newTestSavepointObjects()
+	savepoint.Status = v1beta1.FlinkSavepointStatus{
+		State:          v1beta1.SavepointStateInProgress,
+		JobID:          "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		TriggerID:      "trigger-1",
+		PollErrorSince: "2020-01-01T00:00:00Z",
+	}
+	var k8sClient = &fakeSavepointK8sClient{savepoint: savepoint, cluster: cluster}
+	var flinkClient = &fakeSavepointFlinkClient{
+		status: flinkclient.SavepointStatus{
+			Completed: true,
+			Location:  "gs://my-bucket/savepoints/savepoint-123",
+		},
+	}
+
+	var result, err = newTestSavepointHandler(k8sClient, flinkClient).reconcile()
+	assert.NilError(t, err)
+	assert.Equal(t, result, ctrl.Result{})
+	var status = k8sClient.savepoint.Status
+	assert.Equal(t, status.State, v1beta1.SavepointStateSucceeded)
+	assert.Equal(t, status.Location, "gs://my-bucket/savepoints/savepoint-123")
+	assert.Equal(t, status.PollErrorSince, "")
+	assert.Assert(t, len(status.CompletionTime) > 0)
+}
+
+func TestFlinkSavepointFailed(t *testing.T) {
+	var savepoint, cluster = newTestSavepointObjects()
+	savepoint.Status = v1beta1.FlinkSavepointStatus{
+		State:     v1beta1.SavepointStateInProgress,
+		JobID:     "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		TriggerID: "trigger-1",
+	}
+	var k8sClient = &fakeSavepointK8sClient{savepoint: savepoint, cluster: cluster}
+	var flinkClient = &fakeSavepointFlinkClient{
+		status: flinkclient.SavepointStatus{
+			Completed: true,
+			FailureCause: flinkclient.SavepointFailureCause{
+				ExceptionClass: "java.util.concurrent.CompletionException",
+				StackTrace:     "...",
+			},
+		},
+	}
+
+	var result, err = newTestSavepointHandler(k8sClient, flinkClient).reconcile()
+	assert.NilError(t, err)
+	assert.Equal(t, result, ctrl.Result{})
+	var status = k8sClient.savepoint.Status
+	assert.Equal(t, status.State, v1beta1.SavepointStateFailed)
+	assert.Equal(
+		t,
+		status.Message,
+		"Savepoint failed: java.util.concurrent.CompletionException")
+}
+
+func TestFlinkSavepointPollTimeout(t *testing.T) {
+	var tc = &TimeConverter{}
+	var savepoint, cluster = newTestSavepointObjects()
+	savepoint.Status = v1beta1.FlinkSavepointStatus{
+		State:     v1beta1.SavepointStateInProgress,
+		JobID:     "ec22ad0e0e7c0d0c5d2dbd5ab7a86f69",
+		TriggerID: "trigger-1",
+	}
+	var k8sClient = &fakeSavepointK8sClient{savepoint: savepoint, cluster: cluster}
+	var flinkClient = &fakeSavepointFlinkClient{
+		statusErr: fmt.Errorf("connection refused"),
+	}
+
+	// The first error is recorded and the status is polled again.
+	var result, err = newTestSavepointHandler(k8sClient, flinkClient).reconcile()
+	assert.NilError(t, err)
+	assert.Equal(t, result, requeueResult)
+	assert.Equal(
+		t, k8sClient.savepoint.Status.State, v1beta1.SavepointStateInProgress)
+	assert.Assert(t, len(k8sClient.savepoint.Status.PollErrorSince) > 0)
+
+	// The savepoint fails after the timeout.
+	k8sClient.savepoint.Status.PollErrorSince =
+		tc.ToString(time.Now().Add(-flinkSavepointPollTimeout - time.Minute))
+	result, err = newTestSavepointHandler(k8sClient, flinkClient).reconcile()
+	assert.NilError(t, err)
+	assert.Equal(t, result, ctrl.Result{})
+	var status = k8sClient.savepoint.Status
+	assert.Equal(t, status.State, v1beta1.SavepointStateFailed)
+	assert.Equal(
+		t,
+		status.Message,
+		"Failed to get savepoint status for 5m0s: connection refused")
+}
+
+func TestFlinkSavepointClusterNotFound(t *testing.T) {
+	var savepoint, _ = newTestSavepointObjects()
+	var k8sClient = &fakeSavepointK8sClient{savepoint: savepoint}
+	var flinkClient = &fakeSavepointFlinkClient{}
+
+	var result, err = newTestSavepointHandler(k8sClient, flinkClient).reconcile()
+	assert.NilError(t, err)
+	assert.Equal(t, result, ctrl.Result{})
+	assert.Assert(t, flinkClient.triggered == nil)
+	var status = k8sClient.savepoint.Status
+	assert.Equal(t, status.State, v1beta1.SavepointStateTriggerFailed)
+	assert.Equal(t, status.Message, "FlinkCluster mycluster not found")
+}
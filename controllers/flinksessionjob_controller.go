@@ -0,0 +1,372 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/flinkclient"
+	"github.com/googlecloudplatform/flink-operator/controllers/tracing"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// The finalizer to cancel the Flink job before the FlinkSessionJob is deleted.
+const sessionJobFinalizer = "flinkoperator.k8s.io/session-job"
+
+// FlinkSessionJobReconciler reconciles a FlinkSessionJob object
+type FlinkSessionJobReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	// The maximum number of FlinkSessionJobs reconciled concurrently,
+	// default: 1.
+	MaxConcurrentReconciles int
+
+	// The shard of the FlinkClusters whose session jobs are reconciled by this
+	// operator instance, default: all the clusters.
+	Shard ClusterShard
+
+	// The log levels of the reconciliations, everything is logged if nil.
+	LogLevels *LogLevels
+}
+
+// +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinksessionjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=flinkoperator.k8s.io,resources=flinksessionjobs/status,verbs=get;update;patch
+
+// Reconcile submits the job of a FlinkSessionJob custom resource to its session
+// cluster and tracks the job state.
+func (reconciler *FlinkSessionJobReconciler) Reconcile(
+	request ctrl.Request) (ctrl.Result, error) {
+	var log = reconciler.LogLevels.getLogger(
+		reconciler.Log, "FlinkSessionJob", request.NamespacedName).WithValues(
+		"sessionjob", request.NamespacedName)
+
+	// The session jobs of the clusters in other shards are reconciled by other
+	// operator instances.
+	if reconciler.Shard.isEnabled() {
+		var sessionJob = &v1beta1.FlinkSessionJob{}
+		var err = reconciler.Client.Get(
+			context.Background(), request.NamespacedName, sessionJob)
+		if err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		inShard, err := reconciler.Shard.containsClusterNamed(
+			context.Background(),
+			reconciler.Client,
+			types.NamespacedName{
+				Namespace: request.Namespace,
+				Name:      sessionJob.Spec.ClusterName,
+			})
+		if err != nil || !inShard {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The reconciliation is traced with a span, whose children are the spans
+	// of its Flink REST API requests.
+	var ctx, span = tracing.StartSpan(
+		context.Background(),
+		"Reconcile FlinkSessionJob",
+		tracing.SpanKindInternal,
+		"k8s.namespace.name", request.Namespace,
+		"flinksessionjob.name", request.Name)
+	if span != nil {
+		log = log.WithValues("traceID", span.TraceID())
+	}
+
+	var handler = FlinkSessionJobHandler{
+		k8sClient: reconciler.Client,
+		flinkClient: flinkclient.FlinkClient{
+			Log:        log,
+			HTTPClient: flinkclient.HTTPClient{Log: log, Context: ctx},
+		},
+		request: request,
+		context: ctx,
+		log:     log,
+	}
+	result, err := handler.reconcile()
+	span.End(err)
+	return result, err
+}
+
+// SetupWithManager registers this reconciler with the controller manager and
+// starts watching FlinkSessionJob resources.
+func (reconciler *FlinkSessionJobReconciler) SetupWithManager(
+	mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.FlinkSessionJob{}).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: reconciler.MaxConcurrentReconciles,
+		}).
+		Complete(reconciler)
+}
+
+// FlinkSessionJobHandler holds the context and state for a reconcile request
+// of a FlinkSessionJob.
+type FlinkSessionJobHandler struct {
+	k8sClient   client.Client
+	flinkClient flinkclient.FlinkClient
+	request     ctrl.Request
+	context     context.Context
+	log         logr.Logger
+}
+
+func (handler *FlinkSessionJobHandler) reconcile() (ctrl.Result, error) {
+	var k8sClient = handler.k8sClient
+	var log = handler.log
+	var context = handler.context
+
+	var job = &v1beta1.FlinkSessionJob{}
+	var err = k8sClient.Get(context, handler.request.NamespacedName, job)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			log.Info("Session job not found, it might have been deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var cluster = &v1beta1.FlinkCluster{}
+	err = k8sClient.Get(
+		context,
+		types.NamespacedName{
+			Namespace: job.Namespace,
+			Name:      job.Spec.ClusterName,
+		},
+		cluster)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		cluster = nil
+	}
+	if cluster != nil {
+		var tlsConfig, err = getFlinkRESTTLSConfig(context, k8sClient, cluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		handler.flinkClient.HTTPClient.TLSConfig = tlsConfig
+	}
+
+	if !job.DeletionTimestamp.IsZero() {
+		return handler.finalize(job, cluster)
+	}
+	if !hasSessionJobFinalizer(job) {
+		log.Info("Adding finalizer")
+		job.Finalizers = append(job.Finalizers, sessionJobFinalizer)
+		return ctrl.Result{}, k8sClient.Update(context, job)
+	}
+
+	var newStatus = job.Status.DeepCopy()
+	var result ctrl.Result
+	result, err = handler.reconcileJob(job, cluster, newStatus)
+	if !reflect.DeepEqual(*newStatus, job.Status) {
+		log.Info("Updating status", "status", *newStatus)
+		var jobClone = job.DeepCopy()
+		jobClone.Status = *newStatus
+		setTimestamp(&jobClone.Status.LastUpdateTime)
+		var updateErr = k8sClient.Status().Update(context, jobClone)
+		if updateErr != nil {
+			log.Error(updateErr, "Failed to update status")
+			if err == nil {
+				err = updateErr
+			}
+		}
+	}
+	return result, err
+}
+
+// Submits the job when the session cluster is running, then polls the job
+// details and records them in the status until the job is stopped.
+func (handler *FlinkSessionJobHandler) reconcileJob(
+	job *v1beta1.FlinkSessionJob,
+	cluster *v1beta1.FlinkCluster,
+	status *v1beta1.FlinkSessionJobStatus) (ctrl.Result, error) {
+	var log = handler.log
+	var flinkClient = handler.flinkClient
+
+	if isSessionJobStopped(status) {
+		log.Info("Job has finished, no action")
+		return ctrl.Result{}, nil
+	}
+
+	if cluster == nil || cluster.Status.State != v1beta1.ClusterStateRunning {
+		if len(status.ID) == 0 {
+			status.State = v1beta1.JobStatePending
+		}
+		log.Info("Waiting for the session cluster to be running")
+		return requeueResult, nil
+	}
+
+	if cluster.Spec.Job != nil {
+		status.State = v1beta1.JobStateFailed
+		status.FailureReasons = appendFailureMessage(
+			status.FailureReasons,
+			fmt.Sprintf("%v is not a session cluster", cluster.Name))
+		return ctrl.Result{}, nil
+	}
+
+	var apiBaseURL = getFlinkAPIBaseURL(cluster)
+
+	// Submit
+	if len(status.ID) == 0 {
+		var request = getDesiredSessionJobRunRequest(job)
+		status.ID = request.JobID
+		status.State = v1beta1.JobStateRunning
+
+		// The job ID is deterministic, if the job is found, it was submitted
+		// but failed to be recorded.
+		var _, err = flinkClient.GetJobDetails(apiBaseURL, request.JobID)
+		if err == nil {
+			log.Info("Job was already submitted", "jobID", request.JobID)
+			return requeueResult, nil
+		}
+
+		log.Info("Submitting job", "request", request)
+		err = flinkClient.SubmitJob(
+			apiBaseURL, job.Spec.JarFile, "" /* jarSha256 */, request)
+		if err != nil {
+			log.Error(err, "Failed to submit job", "jobID", request.JobID)
+			// The job is rejected by Flink. Otherwise, e.g., on a 5xx
+			// response or a connection error, the submission is retried
+			// with the backoff of the returned error.
+			if flinkclient.IsClientError(err) {
+				status.State = v1beta1.JobStateFailed
+				status.FailureReasons = appendFailureMessage(
+					status.FailureReasons,
+					fmt.Sprintf("Failed to submit job: %v", err))
+				return ctrl.Result{}, nil
+			}
+			*status = job.Status
+			return requeueResult, err
+		}
+		log.Info("Job submitted", "jobID", request.JobID)
+		return requeueResult, nil
+	}
+
+	// Track
+	var details, err = flinkClient.GetJobDetails(apiBaseURL, status.ID)
+	if err != nil {
+		log.Info("Failed to get Flink job details", "error", err)
+		return requeueResult, nil
+	}
+	var tc = &TimeConverter{}
+	status.FlinkJobState = details.State
+	status.StartTime = tc.ToString(
+		time.Unix(0, details.StartTime*int64(time.Millisecond)))
+	status.State = getJobStateFromFlinkJobState(details.State)
+
+	switch details.State {
+	case "FAILING", "FAILED", "RESTARTING":
+		var exceptions, err = flinkClient.GetJobExceptions(apiBaseURL, status.ID)
+		if err != nil {
+			log.Info("Failed to get Flink job exceptions", "error", err)
+		} else if len(exceptions.RootException) > 0 {
+			status.FailureReasons = appendFailureReason(
+				status.FailureReasons, &exceptions)
+		}
+	}
+
+	if isSessionJobStopped(status) {
+		return ctrl.Result{}, nil
+	}
+	return requeueResult, nil
+}
+
+// Cancels the running job before the FlinkSessionJob is deleted.
+func (handler *FlinkSessionJobHandler) finalize(
+	job *v1beta1.FlinkSessionJob,
+	cluster *v1beta1.FlinkCluster) (ctrl.Result, error) {
+	var log = handler.log
+
+	if !hasSessionJobFinalizer(job) {
+		return ctrl.Result{}, nil
+	}
+
+	if cluster != nil &&
+		cluster.Status.State == v1beta1.ClusterStateRunning &&
+		len(job.Status.ID) > 0 &&
+		!isSessionJobStopped(&job.Status) {
+		log.Info("Cancelling job", "jobID", job.Status.ID)
+		var err = handler.flinkClient.StopJob(
+			getFlinkAPIBaseURL(cluster), job.Status.ID)
+		if err != nil {
+			log.Error(err, "Failed to cancel job", "jobID", job.Status.ID)
+			return requeueResult, err
+		}
+	}
+
+	log.Info("Removing finalizer")
+	var finalizers []string
+	for _, finalizer := range job.Finalizers {
+		if finalizer != sessionJobFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	job.Finalizers = finalizers
+	return ctrl.Result{}, handler.k8sClient.Update(handler.context, job)
+}
+
+// Gets the request to run the session job through the Flink REST API. The job
+// ID is derived from the UID of the FlinkSessionJob, so a retried submission
+// of the same job gets the same ID.
+func getDesiredSessionJobRunRequest(
+	job *v1beta1.FlinkSessionJob) flinkclient.JarRunRequest {
+	var jobSpec = job.Spec
+	var request = flinkclient.JarRunRequest{
+		ProgramArgsList: jobSpec.Args,
+	}
+	if jobSpec.ClassName != nil {
+		request.EntryClass = *jobSpec.ClassName
+	}
+	if jobSpec.Parallelism != nil {
+		request.Parallelism = *jobSpec.Parallelism
+	}
+	if jobSpec.FromSavepoint != nil {
+		request.SavepointPath = *jobSpec.FromSavepoint
+	}
+	if jobSpec.AllowNonRestoredState != nil {
+		request.AllowNonRestoredState = *jobSpec.AllowNonRestoredState
+	}
+	var hash = sha256.Sum256([]byte(job.UID))
+	request.JobID = fmt.Sprintf("%x", hash[:16])
+	return request
+}
+
+func hasSessionJobFinalizer(job *v1beta1.FlinkSessionJob) bool {
+	for _, finalizer := range job.Finalizers {
+		if finalizer == sessionJobFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func isSessionJobStopped(status *v1beta1.FlinkSessionJobStatus) bool {
+	return status.State == v1beta1.JobStateSucceeded ||
+		status.State == v1beta1.JobStateFailed ||
+		status.State == v1beta1.JobStateCancelled
+}
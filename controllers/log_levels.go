@@ -0,0 +1,193 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Log levels of the controllers. The info logs trace every step of the
+// reconciliations, the errors are always logged.
+const (
+	LogLevelInfo  = "info"
+	LogLevelError = "error"
+)
+
+// LogLevels holds the log levels of the controllers and of individual
+// resources, so the reconciliations of a specific cluster can be traced in a
+// busy operator. The levels are reloaded from a directory, e.g., a mounted
+// ConfigMap, where each file is named after a controller, e.g.,
+// `FlinkCluster`, or a resource, `<namespace>.<name>`, and contains its level.
+type LogLevels struct {
+	sync.RWMutex
+
+	// The level of the controllers without a level.
+	defaultLevel string
+
+	// The levels loaded from the directory.
+	levels map[string]string
+}
+
+// NewLogLevels creates the log levels with the default level.
+func NewLogLevels(defaultLevel string) (*LogLevels, error) {
+	if !isValidLogLevel(defaultLevel) {
+		return nil, fmt.Errorf("invalid log level %q", defaultLevel)
+	}
+	return &LogLevels{defaultLevel: defaultLevel}, nil
+}
+
+func isValidLogLevel(level string) bool {
+	return level == LogLevelInfo || level == LogLevelError
+}
+
+// Load loads the levels from the files in the directory, the hidden files,
+// e.g., the `..data` link of a mounted ConfigMap, and the files with an
+// invalid level are skipped.
+func (logLevels *LogLevels) Load(dir string) error {
+	var files, err = ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var levels = make(map[string]string)
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") || file.IsDir() {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		var level = strings.ToLower(strings.TrimSpace(string(content)))
+		if isValidLogLevel(level) {
+			levels[file.Name()] = level
+		}
+	}
+	logLevels.Lock()
+	defer logLevels.Unlock()
+	logLevels.levels = levels
+	return nil
+}
+
+// Reload reloads the levels from the directory periodically until `stop` is
+// closed, the errors are logged and the last loaded levels are kept.
+func (logLevels *LogLevels) Reload(
+	dir string, period time.Duration, log logr.Logger, stop <-chan struct{}) {
+	var ticker = time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			var err = logLevels.Load(dir)
+			if err != nil {
+				log.Error(err, "Failed to reload log levels", "dir", dir)
+			}
+		}
+	}
+}
+
+// Gets the level of the resource reconciled by the controller, the level of
+// the resource takes precedence over the level of the controller.
+func (logLevels *LogLevels) getLevel(
+	controller string, name types.NamespacedName) string {
+	logLevels.RLock()
+	defer logLevels.RUnlock()
+	if level, ok := logLevels.levels[name.Namespace+"."+name.Name]; ok {
+		return level
+	}
+	if level, ok := logLevels.levels[controller]; ok {
+		return level
+	}
+	return logLevels.defaultLevel
+}
+
+// Gets the logger of a reconciliation of the resource with its level, it logs
+// everything without the log levels.
+func (logLevels *LogLevels) getLogger(
+	log logr.Logger, controller string, name types.NamespacedName) logr.Logger {
+	if logLevels == nil {
+		return log
+	}
+	return levelLogger{
+		Logger:      log,
+		infoEnabled: logLevels.getLevel(controller, name) == LogLevelInfo,
+	}
+}
+
+// A logger which drops the info logs unless they are enabled.
+type levelLogger struct {
+	logr.Logger
+	infoEnabled bool
+}
+
+func (log levelLogger) Enabled() bool {
+	return log.infoEnabled && log.Logger.Enabled()
+}
+
+func (log levelLogger) Info(msg string, keysAndValues ...interface{}) {
+	if log.infoEnabled {
+		log.Logger.Info(msg, keysAndValues...)
+	}
+}
+
+// The verbose logs are dropped, as the levels don't go beyond info.
+func (log levelLogger) V(level int) logr.InfoLogger {
+	return levelInfoLogger{
+		InfoLogger:  log.Logger.V(level),
+		infoEnabled: log.infoEnabled && level <= 0,
+	}
+}
+
+func (log levelLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return levelLogger{
+		Logger:      log.Logger.WithValues(keysAndValues...),
+		infoEnabled: log.infoEnabled,
+	}
+}
+
+func (log levelLogger) WithName(name string) logr.Logger {
+	return levelLogger{
+		Logger:      log.Logger.WithName(name),
+		infoEnabled: log.infoEnabled,
+	}
+}
+
+// The info logger of a levelLogger at a verbosity, it drops the logs in the
+// same way.
+type levelInfoLogger struct {
+	logr.InfoLogger
+	infoEnabled bool
+}
+
+func (log levelInfoLogger) Enabled() bool {
+	return log.infoEnabled && log.InfoLogger.Enabled()
+}
+
+func (log levelInfoLogger) Info(msg string, keysAndValues ...interface{}) {
+	if log.infoEnabled {
+		log.InfoLogger.Info(msg, keysAndValues...)
+	}
+}
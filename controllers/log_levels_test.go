@@ -0,0 +1,101 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// A logger recording the messages.
+type testLogger struct {
+	logr.Logger
+	messages []string
+}
+
+func (log *testLogger) Enabled() bool { return true }
+
+func (log *testLogger) Info(msg string, keysAndValues ...interface{}) {
+	log.messages = append(log.messages, msg)
+}
+
+func (log *testLogger) Error(
+	err error, msg string, keysAndValues ...interface{}) {
+	log.messages = append(log.messages, msg)
+}
+
+func (log *testLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return log
+}
+
+func (log *testLogger) WithName(name string) logr.Logger { return log }
+
+func (log *testLogger) V(level int) logr.InfoLogger { return log }
+
+func TestLogLevels(t *testing.T) {
+	var _, err = NewLogLevels("debug")
+	assert.ErrorContains(t, err, "invalid log level")
+
+	logLevels, err := NewLogLevels(LogLevelError)
+	assert.NilError(t, err)
+	var name = types.NamespacedName{Namespace: "default", Name: "mycluster"}
+	assert.Equal(t, logLevels.getLevel("FlinkCluster", name), LogLevelError)
+
+	// Loaded from the files of a mounted ConfigMap.
+	dir, err := ioutil.TempDir("", "log-levels")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NilError(t, ioutil.WriteFile(
+		filepath.Join(dir, "FlinkSavepoint"), []byte("info\n"), 0644))
+	assert.NilError(t, ioutil.WriteFile(
+		filepath.Join(dir, "default.mycluster"), []byte("INFO"), 0644))
+	assert.NilError(t, ioutil.WriteFile(
+		filepath.Join(dir, "FlinkSessionJob"), []byte("verbose"), 0644))
+	assert.NilError(t, ioutil.WriteFile(
+		filepath.Join(dir, "..data"), []byte("info"), 0644))
+	assert.NilError(t, logLevels.Load(dir))
+
+	assert.Equal(t, logLevels.getLevel("FlinkCluster", name), LogLevelInfo)
+	var otherName = types.NamespacedName{Namespace: "default", Name: "other"}
+	assert.Equal(t, logLevels.getLevel("FlinkCluster", otherName), LogLevelError)
+	assert.Equal(t, logLevels.getLevel("FlinkSavepoint", otherName), LogLevelInfo)
+	assert.Equal(t, logLevels.getLevel("FlinkSessionJob", otherName), LogLevelError)
+	assert.Equal(t, len(logLevels.levels), 2)
+
+	// Info logs are dropped below the info level.
+	var log = logLevels.getLogger(
+		&testLogger{}, "FlinkCluster", otherName).WithValues("cluster", otherName)
+	log.Info("Dropped")
+	log.Error(nil, "Logged")
+	var logged = log.(levelLogger).Logger.(*testLogger)
+	assert.DeepEqual(t, logged.messages, []string{"Logged"})
+
+	// Verbose logs are dropped at the info level.
+	log = logLevels.getLogger(&testLogger{}, "FlinkCluster", name)
+	log.V(1).Info("Dropped")
+	assert.Assert(t, !log.V(1).Enabled())
+	log.V(0).Info("Logged")
+	log.Info("Logged")
+	logged = log.(levelLogger).Logger.(*testLogger)
+	assert.DeepEqual(t, logged.messages, []string{"Logged", "Logged"})
+}
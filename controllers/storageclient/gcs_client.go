@@ -0,0 +1,127 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	gcsAPIBaseURL = "https://storage.googleapis.com/storage/v1"
+	gcsTokenURL   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCSClient - Google Cloud Storage client. It authenticates with the service
+// account of the operator from the GCE metadata server, e.g., with Workload
+// Identity on GKE.
+type GCSClient struct {
+	Log logr.Logger
+}
+
+type gcsToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+type gcsObjectList struct {
+	Items []struct {
+		Name string `json:"name"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// DeleteDirectory deletes all the objects under the directory.
+func (c *GCSClient) DeleteDirectory(location string) error {
+	var bucket, prefix, err = parseDirectory(location)
+	if err != nil {
+		return err
+	}
+	token, err := c.getToken()
+	if err != nil {
+		return fmt.Errorf("failed to get GCS access token: %v", err)
+	}
+
+	var pageToken = ""
+	for {
+		var query = url.Values{}
+		query.Set("prefix", prefix)
+		query.Set("fields", "items(name),nextPageToken")
+		if len(pageToken) > 0 {
+			query.Set("pageToken", pageToken)
+		}
+		var listURL = fmt.Sprintf(
+			"%s/b/%s/o?%s", gcsAPIBaseURL, url.PathEscape(bucket), query.Encode())
+		var objectList = gcsObjectList{}
+		err = c.doGCSRequest("GET", listURL, token, &objectList)
+		if err != nil {
+			return fmt.Errorf("failed to list objects in %v: %v", location, err)
+		}
+		for _, item := range objectList.Items {
+			var objectURL = fmt.Sprintf(
+				"%s/b/%s/o/%s",
+				gcsAPIBaseURL, url.PathEscape(bucket), url.PathEscape(item.Name))
+			c.Log.Info("Deleting GCS object", "bucket", bucket, "object", item.Name)
+			err = c.doGCSRequest("DELETE", objectURL, token, nil)
+			if err != nil && !isNotFound(err) {
+				return fmt.Errorf("failed to delete object %v: %v", item.Name, err)
+			}
+		}
+		if len(objectList.NextPageToken) == 0 {
+			return nil
+		}
+		pageToken = objectList.NextPageToken
+	}
+}
+
+func (c *GCSClient) getToken() (string, error) {
+	var req, err = http.NewRequest("GET", gcsTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	body, err := doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	var token = gcsToken{}
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+func (c *GCSClient) doGCSRequest(
+	method string, requestURL string, token string, outStructPtr interface{}) error {
+	var req, err = http.NewRequest(method, requestURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	body, err := doRequest(req)
+	if err != nil {
+		return err
+	}
+	if outStructPtr != nil {
+		return json.Unmarshal(body, outStructPtr)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// S3Client - Amazon S3 client. It authenticates with the standard
+// `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY` and `AWS_SESSION_TOKEN`
+// environment variables of the operator. The region is taken from
+// `AWS_REGION`, and `AWS_S3_ENDPOINT` overrides the endpoint for
+// S3-compatible storages, e.g., MinIO.
+type S3Client struct {
+	Log logr.Logger
+}
+
+type s3ObjectList struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+type s3Credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+}
+
+// DeleteDirectory deletes all the objects under the directory.
+func (c *S3Client) DeleteDirectory(location string) error {
+	var bucket, prefix, err = parseDirectory(location)
+	if err != nil {
+		return err
+	}
+	var credentials = s3Credentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		region:          os.Getenv("AWS_REGION"),
+	}
+	if len(credentials.accessKeyID) == 0 || len(credentials.secretAccessKey) == 0 {
+		return fmt.Errorf("AWS credentials are not configured")
+	}
+	if len(credentials.region) == 0 {
+		credentials.region = "us-east-1"
+	}
+	var endpoint = os.Getenv("AWS_S3_ENDPOINT")
+	if len(endpoint) == 0 {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", credentials.region)
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	var continuationToken = ""
+	for {
+		var query = url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if len(continuationToken) > 0 {
+			query.Set("continuation-token", continuationToken)
+		}
+		var listURL = fmt.Sprintf("%s/%s", endpoint, uriEncode(bucket, true))
+		var body []byte
+		body, err = c.doS3Request("GET", listURL, query, credentials)
+		if err != nil {
+			return fmt.Errorf("failed to list objects in %v: %v", location, err)
+		}
+		var objectList = s3ObjectList{}
+		err = xml.Unmarshal(body, &objectList)
+		if err != nil {
+			return err
+		}
+		for _, object := range objectList.Contents {
+			var objectURL = fmt.Sprintf(
+				"%s/%s/%s", endpoint, uriEncode(bucket, true), uriEncode(object.Key, false))
+			c.Log.Info("Deleting S3 object", "bucket", bucket, "object", object.Key)
+			_, err = c.doS3Request("DELETE", objectURL, url.Values{}, credentials)
+			if err != nil && !isNotFound(err) {
+				return fmt.Errorf("failed to delete object %v: %v", object.Key, err)
+			}
+		}
+		if !objectList.IsTruncated {
+			return nil
+		}
+		continuationToken = objectList.NextContinuationToken
+	}
+}
+
+// Sends the request signed with AWS Signature Version 4.
+func (c *S3Client) doS3Request(
+	method string,
+	requestURL string,
+	query url.Values,
+	credentials s3Credentials) ([]byte, error) {
+	if len(query) > 0 {
+		requestURL += "?" + canonicalQueryString(query)
+	}
+	var req, err = http.NewRequest(method, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	signS3Request(req, credentials, time.Now())
+	return doRequest(req)
+}
+
+// Signs the request without a payload with AWS Signature Version 4, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signS3Request(req *http.Request, credentials s3Credentials, now time.Time) {
+	now = now.UTC()
+	var amzDate = now.Format("20060102T150405Z")
+	var date = now.Format("20060102")
+	var payloadHash = hashHex([]byte{})
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	var signedHeaderNames = []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if len(credentials.sessionToken) > 0 {
+		req.Header.Set("x-amz-security-token", credentials.sessionToken)
+		signedHeaderNames = append(signedHeaderNames, "x-amz-security-token")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaderNames {
+		var value = req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	var signedHeaders = strings.Join(signedHeaderNames, ";")
+	var canonicalRequest = strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	var scope = fmt.Sprintf("%s/%s/s3/aws4_request", date, credentials.region)
+	var stringToSign = strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+	var signingKey = hmacSHA256([]byte("AWS4"+credentials.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, credentials.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	var signature = hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.accessKeyID, scope, signedHeaders, signature))
+}
+
+// Encodes the query parameters sorted by name as required by AWS Signature
+// Version 4.
+func canonicalQueryString(query url.Values) string {
+	var names = make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var params []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// URI-encodes every byte except the unreserved characters of RFC 3986, "/" is
+// kept when `encodeSlash` is false.
+func uriEncode(s string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(s) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') ||
+			(b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' ||
+			(b == '/' && !encodeSlash) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+func hashHex(data []byte) string {
+	var sum = sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	var mac = hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// The timeout of a request to a storage, so that an unresponsive storage
+// doesn't block the reconciliation.
+const requestTimeout = 30 * time.Second
+
+// StorageClient - client of a storage which savepoints are stored in.
+type StorageClient interface {
+	// DeleteDirectory deletes all the objects under the directory, e.g.,
+	// `gs://my-bucket/savepoints/savepoint-123`.
+	DeleteDirectory(location string) error
+}
+
+// StorageClients - storage clients by the URL scheme of the locations.
+type StorageClients map[string]StorageClient
+
+// NewStorageClients creates the clients of the supported storages.
+func NewStorageClients(log logr.Logger) StorageClients {
+	var gcsClient = &GCSClient{Log: log}
+	var s3Client = &S3Client{Log: log}
+	return StorageClients{
+		"gs":  gcsClient,
+		"s3":  s3Client,
+		"s3a": s3Client,
+		"s3p": s3Client,
+	}
+}
+
+// Get returns the client of the storage of the location, or nil if the
+// storage is not supported.
+func (clients StorageClients) Get(location string) StorageClient {
+	var u, err = url.Parse(location)
+	if err != nil {
+		return nil
+	}
+	return clients[strings.ToLower(u.Scheme)]
+}
+
+// Splits the location of a directory into the bucket and the object name
+// prefix of the directory which ends with "/". Locations of buckets are
+// rejected, so a whole bucket is never deleted by mistake.
+func parseDirectory(location string) (bucket string, prefix string, err error) {
+	var u *url.URL
+	u, err = url.Parse(location)
+	if err != nil {
+		return "", "", err
+	}
+	bucket = u.Host
+	prefix = strings.Trim(u.Path, "/")
+	if len(bucket) == 0 || len(prefix) == 0 {
+		return "", "", fmt.Errorf("invalid directory location: %v", location)
+	}
+	return bucket, prefix + "/", nil
+}
+
+// Sends the request and checks the response status, the response body is
+// returned.
+func doRequest(req *http.Request) ([]byte, error) {
+	var client = &http.Client{Timeout: requestTimeout}
+	var resp, err = client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return body, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return body, nil
+}
+
+// HTTPError - error response of a storage.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Status
+}
+
+// Checks whether the error is a 404 response, e.g., the object has been
+// deleted already.
+func isNotFound(err error) bool {
+	var httpErr, ok = err.(*HTTPError)
+	return ok && httpErr.StatusCode == http.StatusNotFound
+}
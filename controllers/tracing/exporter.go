@@ -0,0 +1,339 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	defaultServiceName  = "flink-operator"
+	defaultBatchTimeout = 5 * time.Second
+	defaultMaxBatchSize = 512
+	// The ended spans which are not exported yet, the spans which are ended
+	// when the queue is full are dropped.
+	maxQueueSize = 2048
+)
+
+// Config is the config of the OTLP exporter of the spans.
+type Config struct {
+	// URL of the OTLP/HTTP traces endpoint, e.g.,
+	// `http://otel-collector:4318/v1/traces`.
+	Endpoint string
+	// Headers of the export requests, e.g., for authentication.
+	Headers map[string]string
+	// Name of the service of the spans, default: flink-operator.
+	ServiceName string
+	// The maximum duration that an ended span waits to be exported, default:
+	// 5s.
+	BatchTimeout time.Duration
+	// The maximum number of spans exported by a request, default: 512.
+	MaxBatchSize int
+}
+
+// ConfigFromEnv gets the config from the standard OpenTelemetry environment
+// variables, `OTEL_EXPORTER_OTLP_TRACES_ENDPOINT` or
+// `OTEL_EXPORTER_OTLP_ENDPOINT`, `OTEL_EXPORTER_OTLP_HEADERS` and
+// `OTEL_SERVICE_NAME`. The endpoint is empty if neither endpoint variable is
+// set.
+func ConfigFromEnv() Config {
+	var config = Config{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		Headers:     make(map[string]string),
+	}
+	if len(config.Endpoint) == 0 {
+		var endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if len(endpoint) > 0 {
+			config.Endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+		}
+	}
+	for _, header := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		var keyValue = strings.SplitN(header, "=", 2)
+		if len(keyValue) == 2 && len(strings.TrimSpace(keyValue[0])) > 0 {
+			config.Headers[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+		}
+	}
+	return config
+}
+
+// Exporter exports the ended spans in batches to the OTLP/HTTP endpoint, in
+// the JSON encoding of the protocol.
+type Exporter struct {
+	config     Config
+	log        logr.Logger
+	httpClient *http.Client
+	queue      chan *Span
+	done       chan struct{}
+
+	mutex   sync.RWMutex
+	stopped bool
+}
+
+// Start enables tracing and starts exporting the ended spans with the config.
+func Start(config Config, log logr.Logger) error {
+	if len(config.Endpoint) == 0 {
+		return fmt.Errorf("the OTLP endpoint of the traces is unspecified")
+	}
+	if len(config.ServiceName) == 0 {
+		config.ServiceName = defaultServiceName
+	}
+	if config.BatchTimeout <= 0 {
+		config.BatchTimeout = defaultBatchTimeout
+	}
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = defaultMaxBatchSize
+	}
+	var exporter = &Exporter{
+		config:     config,
+		log:        log,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan *Span, maxQueueSize),
+		done:       make(chan struct{}),
+	}
+	setExporter(exporter)
+	go exporter.run()
+	return nil
+}
+
+// Stop disables tracing, the ended spans which are not exported yet are
+// exported before it returns.
+func Stop() {
+	var exporter = getExporter()
+	if exporter == nil {
+		return
+	}
+	setExporter(nil)
+	exporter.mutex.Lock()
+	exporter.stopped = true
+	close(exporter.queue)
+	exporter.mutex.Unlock()
+	<-exporter.done
+}
+
+// Queues an ended span, it is dropped if the queue is full or the exporter
+// is stopped.
+func (e *Exporter) export(span *Span) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if e.stopped {
+		return
+	}
+	select {
+	case e.queue <- span:
+	default:
+		e.log.Info("Dropped span, the export queue is full", "span", span.name)
+	}
+}
+
+// Exports the queued spans when a batch is full or the batch timeout is
+// reached, until the queue is closed.
+func (e *Exporter) run() {
+	defer close(e.done)
+	var batch []*Span
+	var ticker = time.NewTicker(e.config.BatchTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case span, ok := <-e.queue:
+			if !ok {
+				e.exportBatch(batch)
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= e.config.MaxBatchSize {
+				e.exportBatch(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			e.exportBatch(batch)
+			batch = nil
+		}
+	}
+}
+
+func (e *Exporter) exportBatch(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	var body, err = json.Marshal(e.newExportRequest(batch))
+	if err != nil {
+		e.log.Error(err, "Failed to encode spans", "spans", len(batch))
+		return
+	}
+	req, err := http.NewRequest("POST", e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		e.log.Error(err, "Failed to export spans", "spans", len(batch))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "flink-operator")
+	for key, value := range e.config.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		e.log.Error(err, "Failed to export spans", "spans", len(batch))
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e.log.Error(
+			fmt.Errorf("%v", resp.Status),
+			"Failed to export spans",
+			"spans", len(batch))
+	}
+}
+
+// The ExportTraceServiceRequest of the OTLP protocol in JSON, the trace and
+// span IDs are hex strings.
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanData `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanData struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              SpanKind   `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type status struct {
+	// 0 unset, 1 ok and 2 error.
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const statusCodeError = 2
+
+func (e *Exporter) newExportRequest(batch []*Span) *exportRequest {
+	var spans []spanData
+	for _, span := range batch {
+		spans = append(spans, newSpanData(span))
+	}
+	return &exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{
+				Attributes: []keyValue{
+					newKeyValue("service.name", e.config.ServiceName),
+				},
+			},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: "github.com/googlecloudplatform/flink-operator"},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+func newSpanData(span *Span) spanData {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	var data = spanData{
+		TraceID:           hex.EncodeToString(span.traceID[:]),
+		SpanID:            hex.EncodeToString(span.spanID[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.startTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.endTime.UnixNano(), 10),
+	}
+	if span.parentSpanID != [8]byte{} {
+		data.ParentSpanID = hex.EncodeToString(span.parentSpanID[:])
+	}
+	for _, attribute := range span.attributes {
+		data.Attributes = append(
+			data.Attributes, newKeyValue(attribute.key, attribute.value))
+	}
+	if span.err != nil {
+		data.Status = status{Code: statusCodeError, Message: span.err.Error()}
+	}
+	return data
+}
+
+func newKeyValue(key string, value interface{}) keyValue {
+	var v anyValue
+	switch typed := value.(type) {
+	case string:
+		v.StringValue = &typed
+	case bool:
+		v.BoolValue = &typed
+	case int:
+		var intValue = strconv.FormatInt(int64(typed), 10)
+		v.IntValue = &intValue
+	case int32:
+		var intValue = strconv.FormatInt(int64(typed), 10)
+		v.IntValue = &intValue
+	case int64:
+		var intValue = strconv.FormatInt(typed, 10)
+		v.IntValue = &intValue
+	case float64:
+		v.DoubleValue = &typed
+	default:
+		var stringValue = fmt.Sprint(typed)
+		v.StringValue = &stringValue
+	}
+	return keyValue{Key: key, Value: v}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing traces the reconciliations of the operator with
+// OpenTelemetry spans, which are exported to an OTLP/HTTP endpoint, e.g., of
+// an OpenTelemetry Collector. Tracing is disabled until it is started, the
+// spans are nil then and all their methods are no-ops.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SpanKind is the kind of a span.
+type SpanKind int
+
+// SpanKind types, the values of the OTLP protocol.
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindClient   SpanKind = 3
+)
+
+// Span is an operation of a trace, e.g., a phase of a reconciliation or a
+// request to the Flink REST API.
+type Span struct {
+	traceID      [16]byte
+	spanID       [8]byte
+	parentSpanID [8]byte
+	name         string
+	kind         SpanKind
+	startTime    time.Time
+	endTime      time.Time
+
+	mutex      sync.Mutex
+	attributes []attribute
+	err        error
+	ended      bool
+
+	exporter *Exporter
+}
+
+type attribute struct {
+	key   string
+	value interface{}
+}
+
+type spanContextKey struct{}
+
+// The exporter of the ended spans, nil if tracing is disabled.
+var globalExporter struct {
+	sync.RWMutex
+	exporter *Exporter
+}
+
+func getExporter() *Exporter {
+	globalExporter.RLock()
+	defer globalExporter.RUnlock()
+	return globalExporter.exporter
+}
+
+func setExporter(exporter *Exporter) {
+	globalExporter.Lock()
+	defer globalExporter.Unlock()
+	globalExporter.exporter = exporter
+}
+
+// StartSpan starts a span of the given kind, which is a child of the span in
+// the context if any, and returns the context with the new span. The
+// attributes are key/value pairs like the values of a log line. It returns
+// the context as is and a nil span if tracing is disabled.
+func StartSpan(
+	ctx context.Context,
+	name string,
+	kind SpanKind,
+	keysAndValues ...interface{}) (context.Context, *Span) {
+	var exporter = getExporter()
+	if exporter == nil {
+		return ctx, nil
+	}
+	var span = &Span{
+		name:      name,
+		kind:      kind,
+		startTime: time.Now(),
+		exporter:  exporter,
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentSpanID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+	span.SetAttributes(keysAndValues...)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// FromContext gets the span in the context, nil if there is none.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	var span, _ = ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SetAttributes sets the attributes of the span from key/value pairs, the
+// values are strings, bools, integers or floats, other values are formatted
+// as strings.
+func (s *Span) SetAttributes(keysAndValues ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		s.attributes = append(s.attributes, attribute{
+			key:   fmt.Sprint(keysAndValues[i]),
+			value: keysAndValues[i+1],
+		})
+	}
+}
+
+// End ends the span, with an error status if the operation failed, and
+// queues it to be exported. Ending a span again has no effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.endTime = time.Now()
+	s.err = err
+	s.mutex.Unlock()
+	s.exporter.export(s)
+}
+
+// TraceID gets the hex trace ID of the span, empty if tracing is disabled.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// Inject sets the W3C `traceparent` header of an outbound request from the
+// span in the context, so that the servers which are traced too continue the
+// trace.
+func Inject(ctx context.Context, header http.Header) {
+	var span = FromContext(ctx)
+	if span == nil {
+		return
+	}
+	header.Set(
+		"traceparent",
+		fmt.Sprintf(
+			"00-%v-%v-01",
+			hex.EncodeToString(span.traceID[:]),
+			hex.EncodeToString(span.spanID[:])))
+}
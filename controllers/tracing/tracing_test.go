@@ -0,0 +1,145 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDisabledTracing(t *testing.T) {
+	var ctx = context.Background()
+	var spanContext, span = StartSpan(ctx, "Reconcile", SpanKindInternal)
+	assert.Assert(t, span == nil)
+	assert.Equal(t, spanContext, ctx)
+
+	// The methods of the nil span are no-ops.
+	span.SetAttributes("key", "value")
+	span.End(fmt.Errorf("failed"))
+	assert.Equal(t, span.TraceID(), "")
+
+	var header = http.Header{}
+	Inject(spanContext, header)
+	assert.Equal(t, header.Get("traceparent"), "")
+}
+
+func TestExportSpans(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []exportRequest
+	var headers []http.Header
+	var server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var request exportRequest
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+			mutex.Lock()
+			requests = append(requests, request)
+			headers = append(headers, r.Header)
+			mutex.Unlock()
+		}))
+	defer server.Close()
+
+	var err = Start(
+		Config{
+			Endpoint:     server.URL + "/v1/traces",
+			Headers:      map[string]string{"Authorization": "Bearer token"},
+			BatchTimeout: time.Hour,
+		},
+		log.Log)
+	assert.NilError(t, err)
+
+	var ctx, reconcileSpan = StartSpan(
+		context.Background(),
+		"Reconcile FlinkCluster",
+		SpanKindInternal,
+		"flinkcluster.name", "mycluster")
+	var requestContext, requestSpan = StartSpan(
+		ctx, "GET", SpanKindClient, "http.request.method", "GET")
+	requestSpan.SetAttributes("http.response.status_code", 503)
+	var header = http.Header{}
+	Inject(requestContext, header)
+	requestSpan.End(fmt.Errorf("503 Service Unavailable"))
+	reconcileSpan.End(nil)
+	// Ending a span again has no effect.
+	reconcileSpan.End(fmt.Errorf("failed"))
+
+	// The queued spans are exported when tracing is stopped.
+	Stop()
+	_, span := StartSpan(context.Background(), "Reconcile", SpanKindInternal)
+	assert.Assert(t, span == nil)
+
+	assert.Equal(t, len(requests), 1)
+	assert.Equal(t, headers[0].Get("Authorization"), "Bearer token")
+	assert.Equal(t, headers[0].Get("Content-Type"), "application/json")
+	var resourceSpans = requests[0].ResourceSpans
+	assert.Equal(t, len(resourceSpans), 1)
+	assert.Equal(t, resourceSpans[0].Resource.Attributes[0].Key, "service.name")
+	assert.Equal(
+		t, *resourceSpans[0].Resource.Attributes[0].Value.StringValue, "flink-operator")
+	var spans = resourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, len(spans), 2)
+
+	var traceID = reconcileSpan.TraceID()
+	assert.Equal(t, len(traceID), 32)
+	assert.Equal(t, spans[0].Name, "GET")
+	assert.Equal(t, spans[0].Kind, SpanKindClient)
+	assert.Equal(t, spans[0].TraceID, traceID)
+	assert.Equal(t, spans[0].ParentSpanID, spans[1].SpanID)
+	assert.Equal(t, spans[0].Attributes[1].Key, "http.response.status_code")
+	assert.Equal(t, *spans[0].Attributes[1].Value.IntValue, "503")
+	assert.DeepEqual(
+		t, spans[0].Status, status{Code: 2, Message: "503 Service Unavailable"})
+	assert.Equal(
+		t,
+		header.Get("traceparent"),
+		fmt.Sprintf("00-%v-%v-01", traceID, spans[0].SpanID))
+
+	assert.Equal(t, spans[1].Name, "Reconcile FlinkCluster")
+	assert.Equal(t, spans[1].Kind, SpanKindInternal)
+	assert.Equal(t, spans[1].TraceID, traceID)
+	assert.Equal(t, spans[1].ParentSpanID, "")
+	assert.Equal(t, *spans[1].Attributes[0].Value.StringValue, "mycluster")
+	assert.DeepEqual(t, spans[1].Status, status{})
+	assert.Assert(t, spans[1].StartTimeUnixNano <= spans[0].StartTimeUnixNano)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_HEADERS")
+
+	assert.Equal(t, ConfigFromEnv().Endpoint, "")
+
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318/")
+	os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret, tenant=data")
+	var config = ConfigFromEnv()
+	assert.Equal(t, config.Endpoint, "http://otel-collector:4318/v1/traces")
+	assert.DeepEqual(
+		t, config.Headers, map[string]string{"api-key": "secret", "tenant": "data"})
+
+	os.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://jaeger:4318/v1/traces")
+	assert.Equal(t, ConfigFromEnv().Endpoint, "http://jaeger:4318/v1/traces")
+}
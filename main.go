@@ -17,18 +17,23 @@ limitations under the License.
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/googlecloudplatform/flink-operator/api/v1alpha1"
 	v1beta1 "github.com/googlecloudplatform/flink-operator/api/v1beta1"
 	"github.com/googlecloudplatform/flink-operator/controllers"
-	appsv1 "k8s.io/api/apps/v1"
-	batchv1 "k8s.io/api/batch/v1"
-	corev1 "k8s.io/api/core/v1"
-	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"github.com/googlecloudplatform/flink-operator/controllers/tracing"
+	"github.com/googlecloudplatform/flink-operator/operatorconfig"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	// +kubebuilder:scaffold:imports
 )
@@ -38,66 +43,281 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// The period at which the log levels and the operator config are reloaded from
+// `--log-levels-dir` and `--config`.
+const reloadPeriod = 10 * time.Second
+
 func init() {
-	appsv1.AddToScheme(scheme)
-	batchv1.AddToScheme(scheme)
-	corev1.AddToScheme(scheme)
+	// All the built-in kinds of the components, e.g., NetworkPolicies and
+	// PodDisruptionBudgets.
+	clientgoscheme.AddToScheme(scheme)
+	v1alpha1.AddToScheme(scheme)
 	v1beta1.AddToScheme(scheme)
-	extensionsv1beta1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var leaderElectionID string
+	var leaderElectionNamespace string
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
 	var watchNamespace string
+	var maxConcurrentReconciles int
+	var syncPeriod time.Duration
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+	var shard controllers.ClusterShard
+	var serverSideApply bool
+	var watchReferencedData bool
+	var logFormat string
+	var logLevel string
+	var logLevelsDir string
+	var configPath string
+	var tracingEndpoint string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "controller-leader-election-helper",
+		"The name of the ConfigMap which holds the leader lock.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"The namespace of the ConfigMap which holds the leader lock. If empty, the namespace of the operator pod is used.")
+	flag.DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second,
+		"The duration that non-leader replicas wait before taking over the leadership after the leader stops renewing it.")
+	flag.DurationVar(&renewDeadline, "leader-election-renew-deadline", 10*time.Second,
+		"The duration that the leader retries renewing the leadership before giving it up, it must be less than the lease duration.")
+	flag.DurationVar(&retryPeriod, "leader-election-retry-period", 2*time.Second,
+		"The duration that the replicas wait between the attempts to acquire or renew the leadership.")
 	flag.StringVar(
 		&watchNamespace,
 		"watch-namespace",
 		"",
-		"Watch custom resources in the namespace, ignore other namespaces. If empty, all namespaces will be watched.")
+		"Watch custom resources in the namespace or the comma-separated namespaces, ignore other namespaces. If empty, all namespaces will be watched.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The maximum number of resources of each kind reconciled concurrently.")
+	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Hour,
+		"The period at which every watched resource is reconciled again even if it has not changed.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 5,
+		"The maximum queries per second from the operator to the Kubernetes API server.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 10,
+		"The maximum burst of queries from the operator to the Kubernetes API server.")
+	flag.IntVar(&shard.Count, "shard-count", 1,
+		"The number of operator instances which split the FlinkClusters, each instance reconciles only its shard of the clusters.")
+	flag.IntVar(&shard.Index, "shard-index", 0,
+		"The shard of the FlinkClusters reconciled by this operator instance, from 0 to the shard count - 1.")
+	flag.StringVar(&shard.Label, "shard-label", "",
+		"The label of the FlinkClusters whose value is hashed to assign the clusters to the shards instead of their namespace/name.")
+	flag.BoolVar(&serverSideApply, "server-side-apply", false,
+		"Create and update the components of the FlinkClusters with server-side apply, which requires Kubernetes 1.16+.")
+	flag.BoolVar(&watchReferencedData, "watch-referenced-data", true,
+		"Watch the ConfigMaps and Secrets in the restartOnChange of the FlinkClusters, otherwise their changes are only found by the periodic reconciliations.")
+	flag.StringVar(&logFormat, "log-format", "console",
+		"The format of the logs, console or json. The logs are structured key/value pairs in both formats.")
+	flag.StringVar(&logLevel, "log-level", controllers.LogLevelInfo,
+		"The log level of the controllers, info or error.")
+	flag.StringVar(&logLevelsDir, "log-levels-dir", "",
+		"The directory, e.g., a mounted ConfigMap, with the log levels of the controllers and resources, which are reloaded periodically.")
+	flag.StringVar(&configPath, "config", "",
+		"The YAML file, e.g., in a mounted ConfigMap, with the global defaults of the FlinkClusters, which is reloaded periodically.")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "",
+		"The OTLP/HTTP endpoint which the traces of the reconciliations are exported to, e.g., http://otel-collector:4318/v1/traces. "+
+			"Default: the OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT env var, tracing is disabled without an endpoint.")
 	flag.Parse()
 
-	ctrl.SetLogger(zap.Logger(true))
+	ctrl.SetLogger(zap.Logger(logFormat != "json"))
+	if logFormat != "console" && logFormat != "json" {
+		setupLog.Error(
+			fmt.Errorf("invalid log format %q", logFormat), "Invalid log format")
+		os.Exit(1)
+	}
+
+	logLevels, err := controllers.NewLogLevels(logLevel)
+	if err != nil {
+		setupLog.Error(err, "Invalid log level")
+		os.Exit(1)
+	}
+	var stop = ctrl.SetupSignalHandler()
+	if len(logLevelsDir) > 0 {
+		err = logLevels.Load(logLevelsDir)
+		if err != nil {
+			setupLog.Error(err, "Unable to load log levels", "dir", logLevelsDir)
+			os.Exit(1)
+		}
+		go logLevels.Reload(logLevelsDir, reloadPeriod, setupLog, stop)
+	}
+	if len(configPath) > 0 {
+		config, err := operatorconfig.Load(configPath)
+		if err != nil {
+			setupLog.Error(err, "Unable to load operator config", "path", configPath)
+			os.Exit(1)
+		}
+		operatorconfig.Set(config)
+		go operatorconfig.Reload(configPath, reloadPeriod, setupLog, stop)
+	}
+
+	var tracingConfig = tracing.ConfigFromEnv()
+	if len(tracingEndpoint) > 0 {
+		tracingConfig.Endpoint = tracingEndpoint
+	}
+	if len(tracingConfig.Endpoint) > 0 {
+		err = tracing.Start(tracingConfig, ctrl.Log.WithName("tracing"))
+		if err != nil {
+			setupLog.Error(err, "Unable to start tracing")
+			os.Exit(1)
+		}
+		setupLog.Info("Tracing reconciliations", "endpoint", tracingConfig.Endpoint)
+	}
+
+	if enableLeaderElection && renewDeadline >= leaseDuration {
+		setupLog.Error(
+			errors.New("the renew deadline must be less than the lease duration"),
+			"Invalid leader election durations",
+			"leaseDuration", leaseDuration,
+			"renewDeadline", renewDeadline)
+		os.Exit(1)
+	}
+
+	if shard.Index < 0 || shard.Index >= shard.Count {
+		setupLog.Error(
+			errors.New("the shard index must be from 0 to the shard count - 1"),
+			"Invalid shard",
+			"shardCount", shard.Count,
+			"shardIndex", shard.Index)
+		os.Exit(1)
+	}
+	// The replicas of each shard elect their own leader.
+	if shard.Count > 1 {
+		leaderElectionID = fmt.Sprintf("%v-shard-%v", leaderElectionID, shard.Index)
+		setupLog.Info(
+			"Sharding clusters",
+			"shardCount", shard.Count,
+			"shardIndex", shard.Index,
+			"shardLabel", shard.Label)
+	}
+
+	// The Kubernetes API requests of the concurrent reconciliations are
+	// throttled by the client.
+	var config = ctrl.GetConfigOrDie()
+	config.QPS = float32(kubeAPIQPS)
+	config.Burst = kubeAPIBurst
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	var options = ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
-		Namespace:          watchNamespace,
-	})
+		// The controllers only run in the leader replica, the other replicas
+		// serve the webhooks and take over when the leader is lost.
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		RetryPeriod:             &retryPeriod,
+		SyncPeriod:              &syncPeriod,
+	}
+	// A single namespace is watched with a namespaced cache, multiple
+	// namespaces with a cache per namespace.
+	var watchNamespaces = parseNamespaces(watchNamespace)
+	if len(watchNamespaces) == 1 {
+		options.Namespace = watchNamespaces[0]
+	} else if len(watchNamespaces) > 1 {
+		options.NewCache = cache.MultiNamespacedCacheBuilder(watchNamespaces)
+	}
+	setupLog.Info("Watching namespaces", "namespaces", watchNamespaces)
+
+	mgr, err := ctrl.NewManager(config, options)
 	if err != nil {
 		setupLog.Error(err, "Unable to start manager")
 		os.Exit(1)
 	}
 
 	err = (&controllers.FlinkClusterReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("FlinkCluster"),
+		Client:                  mgr.GetClient(),
+		Log:                     ctrl.Log.WithName("controllers").WithName("FlinkCluster"),
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Shard:                   shard,
+		ServerSideApply:         serverSideApply,
+		WatchNamespaces:         watchNamespaces,
+		WatchReferencedData:     watchReferencedData,
+		LogLevels:               logLevels,
 	}).SetupWithManager(mgr)
 	if err != nil {
 		setupLog.Error(err, "Unable to create controller", "controller", "FlinkCluster")
 		os.Exit(1)
 	}
 
+	err = (&controllers.FlinkSessionJobReconciler{
+		Client:                  mgr.GetClient(),
+		Log:                     ctrl.Log.WithName("controllers").WithName("FlinkSessionJob"),
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Shard:                   shard,
+		LogLevels:               logLevels,
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "Unable to create controller", "controller", "FlinkSessionJob")
+		os.Exit(1)
+	}
+
+	err = (&controllers.FlinkSavepointReconciler{
+		Client:                  mgr.GetClient(),
+		Log:                     ctrl.Log.WithName("controllers").WithName("FlinkSavepoint"),
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Shard:                   shard,
+		LogLevels:               logLevels,
+	}).SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "Unable to create controller", "controller", "FlinkSavepoint")
+		os.Exit(1)
+	}
+
 	// Set up webhooks for the custom resource.
 	// Disable it with `FLINK_OPERATOR_ENABLE_WEBHOOKS=false` when we run locally.
+	// The conversion webhook between the FlinkCluster versions is registered
+	// along with the FlinkCluster webhooks.
 	if os.Getenv("FLINK_OPERATOR_ENABLE_WEBHOOKS") != "false" {
 		err = (&v1beta1.FlinkCluster{}).SetupWebhookWithManager(mgr)
 		if err != nil {
 			setupLog.Error(err, "Unable to setup webhooks", "webhook", "FlinkCluster")
 			os.Exit(1)
 		}
+		err = (&v1alpha1.FlinkCluster{}).SetupWebhookWithManager(mgr)
+		if err != nil {
+			setupLog.Error(err, "Unable to setup webhooks", "webhook", "FlinkCluster/v1alpha1")
+			os.Exit(1)
+		}
+		err = (&v1beta1.FlinkSessionJob{}).SetupWebhookWithManager(mgr)
+		if err != nil {
+			setupLog.Error(err, "Unable to setup webhooks", "webhook", "FlinkSessionJob")
+			os.Exit(1)
+		}
+		err = (&v1beta1.FlinkSavepoint{}).SetupWebhookWithManager(mgr)
+		if err != nil {
+			setupLog.Error(err, "Unable to setup webhooks", "webhook", "FlinkSavepoint")
+			os.Exit(1)
+		}
 	}
 
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("Starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(stop)
+	// The spans of the last reconciliations are exported before exiting.
+	tracing.Stop()
+	if err != nil {
 		setupLog.Error(err, "Problem running manager")
 		os.Exit(1)
 	}
 }
+
+// Parses the comma-separated namespaces, empty if all namespaces are watched.
+func parseNamespaces(namespaces string) []string {
+	var parsed []string
+	for _, namespace := range strings.Split(namespaces, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if len(namespace) > 0 {
+			parsed = append(parsed, namespace)
+		}
+	}
+	return parsed
+}
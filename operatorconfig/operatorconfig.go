@@ -0,0 +1,140 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package operatorconfig holds the global configuration of the operator. It is
+// loaded from a YAML file, e.g., in a mounted ConfigMap, and reloaded
+// periodically, so it can be changed without restarting the operator.
+package operatorconfig
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the global configuration of the operator, all the fields are
+// optional.
+type Config struct {
+	// The default image of the FlinkClusters without an image by their
+	// `flinkVersion`, e.g., `"1.12": "flink:1.12.7"`.
+	Images map[string]string `json:"images,omitempty"`
+
+	// The default savepoints directory of the jobs.
+	SavepointsDir string `json:"savepointsDir,omitempty"`
+
+	// The default cleanup policy of the jobs, the unspecified actions default
+	// to the built-in defaults.
+	CleanupPolicy CleanupPolicy `json:"cleanupPolicy,omitempty"`
+
+	// The interval at which the clusters which are waiting for something,
+	// e.g., a running job, are reconciled again, default: 10.
+	RequeueIntervalSeconds int32 `json:"requeueIntervalSeconds,omitempty"`
+
+	// The behavior of the admission webhooks.
+	Webhook WebhookConfig `json:"webhook,omitempty"`
+}
+
+// CleanupPolicy is the default cleanup policy of the jobs.
+type CleanupPolicy struct {
+	AfterJobSucceeds  string `json:"afterJobSucceeds,omitempty"`
+	AfterJobFails     string `json:"afterJobFails,omitempty"`
+	AfterJobCancelled string `json:"afterJobCancelled,omitempty"`
+}
+
+// WebhookConfig is the behavior of the admission webhooks, including the
+// policy which the FlinkClusters must comply with.
+type WebhookConfig struct {
+	// The prefixes of the images which the FlinkClusters may use, including
+	// the sidecars and init containers, e.g., "gcr.io/my-project/", any image
+	// is allowed if empty.
+	AllowedImagePrefixes []string `json:"allowedImagePrefixes,omitempty"`
+
+	// The maximum TaskManager replicas of the FlinkClusters, unlimited if 0.
+	MaxTaskManagerReplicas int32 `json:"maxTaskManagerReplicas,omitempty"`
+
+	// The maximum CPU of the JobManager and TaskManager pods, i.e., the sum
+	// of the limits, or the requests without limits, of their containers.
+	MaxPodCPU *resource.Quantity `json:"maxPodCPU,omitempty"`
+
+	// The maximum memory of the JobManager and TaskManager pods, like the
+	// maximum CPU.
+	MaxPodMemory *resource.Quantity `json:"maxPodMemory,omitempty"`
+
+	// The labels which the FlinkClusters must have, e.g., "team".
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+}
+
+var current = struct {
+	sync.RWMutex
+	config *Config
+}{config: &Config{}}
+
+// Get gets the current configuration, it must not be modified.
+func Get() *Config {
+	current.RLock()
+	defer current.RUnlock()
+	return current.config
+}
+
+// Set sets the current configuration.
+func Set(config *Config) {
+	current.Lock()
+	defer current.Unlock()
+	current.config = config
+}
+
+// Load loads the configuration from the YAML file.
+func Load(path string) (*Config, error) {
+	var data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config = &Config{}
+	err = yaml.UnmarshalStrict(data, config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Reload reloads the configuration from the file periodically until `stop` is
+// closed. The errors are logged and the current configuration is kept.
+func Reload(
+	path string, period time.Duration, log logr.Logger, stop <-chan struct{}) {
+	var ticker = time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			var config, err = Load(path)
+			if err != nil {
+				log.Error(err, "Failed to reload operator config", "path", path)
+				continue
+			}
+			if !reflect.DeepEqual(config, Get()) {
+				log.Info("Reloaded operator config", "config", config)
+				Set(config)
+			}
+		}
+	}
+}
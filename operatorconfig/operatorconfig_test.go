@@ -0,0 +1,66 @@
+/*
+Copyright 2019 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operatorconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestLoad(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "operatorconfig")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(path, []byte(`
+images:
+  "1.12": flink:1.12.7
+savepointsDir: gs://my-bucket/savepoints/
+cleanupPolicy:
+  afterJobFails: DeleteTaskManager
+requeueIntervalSeconds: 30
+webhook:
+  allowedImagePrefixes:
+  - gcr.io/my-project/
+`), 0644)
+	assert.NilError(t, err)
+
+	config, err := Load(path)
+	assert.NilError(t, err)
+	assert.DeepEqual(
+		t,
+		config,
+		&Config{
+			Images:                 map[string]string{"1.12": "flink:1.12.7"},
+			SavepointsDir:          "gs://my-bucket/savepoints/",
+			CleanupPolicy:          CleanupPolicy{AfterJobFails: "DeleteTaskManager"},
+			RequeueIntervalSeconds: 30,
+			Webhook: WebhookConfig{
+				AllowedImagePrefixes: []string{"gcr.io/my-project/"},
+			},
+		})
+
+	// Unknown fields are rejected, e.g., typos.
+	err = ioutil.WriteFile(path, []byte("savepointDir: gs://my-bucket/\n"), 0644)
+	assert.NilError(t, err)
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "savepointDir")
+}